@@ -72,6 +72,7 @@ func init() {
 	autoServeCmd.Flags().Bool("metrics", true, "Enable metrics endpoints")
 	autoServeCmd.Flags().Bool("cors", true, "Enable CORS support")
 	autoServeCmd.Flags().Bool("schema-validation", true, "Enable schema validation")
+	autoServeCmd.Flags().Bool("preflight", true, "Check providers, models and tools before serving agents")
 
 	// LLM configuration
 	autoServeCmd.Flags().String("ollama-endpoint", "http://localhost:11434", "Ollama endpoint URL")
@@ -110,6 +111,7 @@ func runAutoServe(cmd *cobra.Command, args []string) error {
 	metrics, _ := cmd.Flags().GetBool("metrics")
 	cors, _ := cmd.Flags().GetBool("cors")
 	schemaValidation, _ := cmd.Flags().GetBool("schema-validation")
+	preflight, _ := cmd.Flags().GetBool("preflight")
 	ollamaEndpoint, _ := cmd.Flags().GetString("ollama-endpoint")
 	dev, _ := cmd.Flags().GetBool("dev")
 	watch, _ := cmd.Flags().GetBool("watch")
@@ -141,9 +143,11 @@ func runAutoServe(cmd *cobra.Command, args []string) error {
 		EnableMetricsAPI: metrics,
 		EnableCORS:       cors,
 		SchemaValidation: schemaValidation,
+		EnablePreflight:  preflight,
 		OllamaEndpoint:   ollamaEndpoint,
 		LLMProviders:     make(map[string]interface{}),
 		Middleware:       []string{"cors", "logging", "recovery"},
+		MaxRequestSize:   10 * 1024 * 1024,
 	}
 
 	// Add LLM providers based on flags
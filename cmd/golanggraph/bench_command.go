@@ -0,0 +1,263 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// benchCmd represents the bench command
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Compare providers and models on the same prompts",
+	Long: `Run every prompt against every provider and compare latency, token usage and cost.
+
+Prompts are read from a text file with one prompt per line; blank lines and lines
+starting with # are skipped. Providers are given as a comma-separated list of
+provider or provider:model entries. Requests are sent one at a time, paced by
+--rpm and retried with backoff when a provider reports a rate limit.
+
+Costs are computed from an optional pricing file mapping provider or provider/model
+to prices per 1000 tokens:
+  {"openai/gpt-4o-mini": {"prompt_per_1k": 0.00015, "completion_per_1k": 0.0006}}
+
+Examples:
+  # Compare a local and a hosted model
+  golanggraph bench --providers ollama:llama3,openai:gpt-4o-mini --prompts prompts.txt
+
+  # Three runs per prompt, written as CSV
+  golanggraph bench --providers ollama,openai --prompts prompts.txt --runs 3 --format csv -o bench.csv`,
+	RunE: runBench,
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+
+	benchCmd.Flags().String("providers", "", "Comma-separated providers to compare (provider or provider:model)")
+	benchCmd.Flags().String("prompts", "", "File with one prompt per line")
+	benchCmd.Flags().Int("runs", 1, "Runs per prompt")
+	benchCmd.Flags().Int("max-tokens", 512, "Maximum tokens per response")
+	benchCmd.Flags().Float64("temperature", 0, "Sampling temperature")
+	benchCmd.Flags().Duration("timeout", 2*time.Minute, "Timeout per request")
+	benchCmd.Flags().Int("rpm", 0, "Maximum requests per minute per provider (0 for no limit)")
+	benchCmd.Flags().String("pricing", "", "JSON file with prices per 1000 tokens")
+	benchCmd.Flags().StringP("format", "f", "table", "Output format (table, json, csv)")
+	benchCmd.Flags().StringP("output", "o", "", "Output file (default: stdout)")
+	benchCmd.Flags().String("openai-api-key", "", "OpenAI API key (defaults to OPENAI_API_KEY)")
+	benchCmd.Flags().String("gemini-api-key", "", "Gemini API key (defaults to GEMINI_API_KEY)")
+	benchCmd.Flags().String("ollama-endpoint", "http://localhost:11434", "Ollama endpoint URL")
+
+	benchCmd.MarkFlagRequired("providers")
+	benchCmd.MarkFlagRequired("prompts")
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	providersSpec, _ := cmd.Flags().GetString("providers")
+	promptsFile, _ := cmd.Flags().GetString("prompts")
+	pricingFile, _ := cmd.Flags().GetString("pricing")
+	format, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+
+	if format != "table" && format != "json" && format != "csv" {
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+
+	targets, err := llm.ParseBenchmarkTargets(providersSpec)
+	if err != nil {
+		return err
+	}
+	prompts, err := readBenchPrompts(promptsFile)
+	if err != nil {
+		return err
+	}
+
+	config := llm.DefaultBenchmarkConfig()
+	config.Targets = targets
+	config.Prompts = prompts
+	config.Runs, _ = cmd.Flags().GetInt("runs")
+	config.MaxTokens, _ = cmd.Flags().GetInt("max-tokens")
+	config.Temperature, _ = cmd.Flags().GetFloat64("temperature")
+	config.Timeout, _ = cmd.Flags().GetDuration("timeout")
+	config.RequestsPerMinute, _ = cmd.Flags().GetInt("rpm")
+	if pricingFile != "" {
+		data, err := os.ReadFile(pricingFile)
+		if err != nil {
+			return fmt.Errorf("failed to read pricing file: %w", err)
+		}
+		if err := json.Unmarshal(data, &config.Pricing); err != nil {
+			return fmt.Errorf("failed to parse pricing file: %w", err)
+		}
+	}
+
+	llmManager := llm.NewProviderManager()
+	for _, target := range targets {
+		if _, err := llmManager.GetProvider(target.Provider); err == nil {
+			continue
+		}
+		provider, err := newBenchProvider(cmd, target.Provider)
+		if err != nil {
+			return err
+		}
+		llmManager.RegisterProvider(target.Provider, provider)
+	}
+	defer llmManager.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	fmt.Fprintf(os.Stderr, "🏁 Running %d prompts x %d runs against %d providers\n", len(prompts), config.Runs, len(targets))
+
+	results, err := llm.RunBenchmark(ctx, llmManager, config)
+	if err != nil && len(results) == 0 {
+		return fmt.Errorf("benchmark failed: %w", err)
+	}
+
+	var out io.Writer = os.Stdout
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			return err
+		}
+	case "csv":
+		if err := writeBenchCSV(out, results); err != nil {
+			return err
+		}
+	default:
+		writeBenchTable(out, results)
+	}
+
+	if err != nil {
+		return fmt.Errorf("benchmark interrupted: %w", err)
+	}
+	return nil
+}
+
+// newBenchProvider creates a provider by name from the command flags
+func newBenchProvider(cmd *cobra.Command, name string) (llm.Provider, error) {
+	switch name {
+	case "openai":
+		apiKey, _ := cmd.Flags().GetString("openai-api-key")
+		if apiKey == "" {
+			apiKey = os.Getenv("OPENAI_API_KEY")
+		}
+		provider, err := llm.NewOpenAIProvider(&llm.ProviderConfig{APIKey: apiKey, Endpoint: "https://api.openai.com/v1"})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OpenAI provider: %w", err)
+		}
+		return provider, nil
+	case "ollama":
+		endpoint, _ := cmd.Flags().GetString("ollama-endpoint")
+		provider, err := llm.NewOllamaProvider(&llm.ProviderConfig{Endpoint: endpoint})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Ollama provider: %w", err)
+		}
+		return provider, nil
+	case "gemini":
+		apiKey, _ := cmd.Flags().GetString("gemini-api-key")
+		if apiKey == "" {
+			apiKey = os.Getenv("GEMINI_API_KEY")
+		}
+		provider, err := llm.NewGeminiProvider(&llm.ProviderConfig{APIKey: apiKey})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Gemini provider: %w", err)
+		}
+		return provider, nil
+	default:
+		return nil, fmt.Errorf("unsupported provider: %s", name)
+	}
+}
+
+// readBenchPrompts reads one prompt per line, skipping blank lines and comments
+func readBenchPrompts(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open prompts file: %w", err)
+	}
+	defer file.Close()
+
+	var prompts []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		prompts = append(prompts, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read prompts file: %w", err)
+	}
+	if len(prompts) == 0 {
+		return nil, fmt.Errorf("no prompts found in %s", path)
+	}
+	return prompts, nil
+}
+
+// writeBenchTable prints the comparison table
+func writeBenchTable(out io.Writer, results []llm.BenchmarkResult) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TARGET\tREQUESTS\tERRORS\tP50\tP90\tP99\tMEAN\tPROMPT TOKENS\tCOMPLETION TOKENS\tCOST (USD)")
+	for _, result := range results {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%v\t%v\t%v\t%v\t%d\t%d\t%.4f\n",
+			result.Target, result.Requests, result.Errors,
+			result.LatencyP50.Round(time.Millisecond), result.LatencyP90.Round(time.Millisecond),
+			result.LatencyP99.Round(time.Millisecond), result.LatencyMean.Round(time.Millisecond),
+			result.PromptTokens, result.CompletionTokens, result.Cost)
+	}
+	w.Flush()
+}
+
+// writeBenchCSV writes one row per target
+func writeBenchCSV(out io.Writer, results []llm.BenchmarkResult) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{"provider", "model", "requests", "errors", "p50_ms", "p90_ms", "p99_ms", "mean_ms", "prompt_tokens", "completion_tokens", "cost_usd"})
+	for _, result := range results {
+		w.Write([]string{
+			result.Target.Provider,
+			result.Target.Model,
+			strconv.Itoa(result.Requests),
+			strconv.Itoa(result.Errors),
+			strconv.FormatInt(result.LatencyP50.Milliseconds(), 10),
+			strconv.FormatInt(result.LatencyP90.Milliseconds(), 10),
+			strconv.FormatInt(result.LatencyP99.Milliseconds(), 10),
+			strconv.FormatInt(result.LatencyMean.Milliseconds(), 10),
+			strconv.Itoa(result.PromptTokens),
+			strconv.Itoa(result.CompletionTokens),
+			strconv.FormatFloat(result.Cost, 'f', 6, 64),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/server"
+)
+
+// deadLettersCmd represents the dead-letters command
+var deadLettersCmd = &cobra.Command{
+	Use:   "dead-letters",
+	Short: "List and retry failed unattended executions",
+	Long: `Inspect the executions a running server dead-lettered after they failed every retry.
+
+Scheduled and batch executions are retried with backoff; those still failing are kept
+in the server's dead-letter store with their input, error and attempt count.
+
+Examples:
+  # List the dead-lettered executions
+  golanggraph dead-letters list --server http://localhost:8080
+
+  # Run one again; it is removed from the store when it succeeds
+  golanggraph dead-letters retry 3f2c9a1e-...`,
+}
+
+// deadLettersListCmd represents the dead-letters list command
+var deadLettersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List dead-lettered executions",
+	Args:  cobra.NoArgs,
+	RunE:  runDeadLettersList,
+}
+
+// deadLettersRetryCmd represents the dead-letters retry command
+var deadLettersRetryCmd = &cobra.Command{
+	Use:   "retry [id]",
+	Short: "Run a dead-lettered execution again",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runDeadLettersRetry,
+}
+
+func init() {
+	rootCmd.AddCommand(deadLettersCmd)
+	deadLettersCmd.AddCommand(deadLettersListCmd)
+	deadLettersCmd.AddCommand(deadLettersRetryCmd)
+
+	deadLettersCmd.PersistentFlags().String("server", "http://localhost:8080", "Base URL of the server")
+}
+
+func runDeadLettersList(cmd *cobra.Command, args []string) error {
+	var response struct {
+		DeadLetters []*server.DeadLetter `json:"dead_letters"`
+	}
+	if err := callDeadLetterAPI(cmd, http.MethodGet, "", &response); err != nil {
+		return err
+	}
+	if len(response.DeadLetters) == 0 {
+		fmt.Println("No dead-lettered executions")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tSOURCE\tAGENT\tATTEMPTS\tFAILED AT\tERROR")
+	for _, letter := range response.DeadLetters {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%d\t%s\t%s\n", letter.ID, letter.Source, letter.AgentID,
+			letter.Attempts, letter.FailedAt.Format(time.RFC3339), letter.Error)
+	}
+	return writer.Flush()
+}
+
+func runDeadLettersRetry(cmd *cobra.Command, args []string) error {
+	if err := callDeadLetterAPI(cmd, http.MethodPost, url.PathEscape(args[0])+"/retry", nil); err != nil {
+		return err
+	}
+	fmt.Printf("Execution %s succeeded and was removed from the dead-letter store\n", args[0])
+	return nil
+}
+
+// callDeadLetterAPI sends a request to the dead-letter endpoints of the server, decoding the
+// response into result when it is not nil
+func callDeadLetterAPI(cmd *cobra.Command, method, path string, result interface{}) error {
+	baseURL, _ := cmd.Flags().GetString("server")
+	endpoint := strings.TrimRight(baseURL, "/") + "/api/v1/dead-letters"
+	if path != "" {
+		endpoint += "/" + path
+	}
+
+	request, err := http.NewRequestWithContext(cmd.Context(), method, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 6 * time.Minute}
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to reach server: %w", err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		var problem server.Problem
+		if json.Unmarshal(body, &problem) == nil && problem.Detail != "" {
+			return fmt.Errorf("server returned %d: %s", response.StatusCode, problem.Detail)
+		}
+		return fmt.Errorf("server returned %d", response.StatusCode)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(body, result)
+}
@@ -8,6 +8,8 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -19,6 +21,7 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/agent"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/config"
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/debug"
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
@@ -59,7 +62,7 @@ The server provides:
 - Visual debugging interface
 - Health monitoring endpoints`,
 	Run: func(cmd *cobra.Command, args []string) {
-		runServer()
+		runServer(cmd)
 	},
 }
 
@@ -84,12 +87,29 @@ var debugCmd = &cobra.Command{
 var visualizeCmd = &cobra.Command{
 	Use:   "visualize [graph-file]",
 	Short: "Visualize a graph structure",
-	Long:  `Generate visual representations of graph structures in various formats (Mermaid, DOT, JSON).`,
-	Args:  cobra.MaximumNArgs(1),
+	Long: `Generate visual representations of graph structures in various formats (Mermaid, DOT, JSON).
+With --trace, Mermaid diagrams are annotated with edge traversal counts and average node
+durations aggregated from recorded execution steps, highlighting hot paths.`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		format, _ := cmd.Flags().GetString("format")
 		output, _ := cmd.Flags().GetString("output")
-		runVisualize(args, format, output)
+		trace, _ := cmd.Flags().GetString("trace")
+		runVisualize(args, format, output, trace)
+	},
+}
+
+// debugRunCmd represents the debug run command
+var debugRunCmd = &cobra.Command{
+	Use:   "run [graph-file]",
+	Short: "Execute a graph step by step",
+	Long: `Execute a graph interactively, pausing before each node to show the state, the next node
+and the edges that may fire. The state can be printed and edited before stepping or
+continuing, and the changes of each node are printed as a diff.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		stateFile, _ := cmd.Flags().GetString("state")
+		runDebug(args, stateFile)
 	},
 }
 
@@ -155,7 +175,7 @@ Includes:
 - Real-time logging and metrics
 - Agent testing playground`,
 	Run: func(cmd *cobra.Command, args []string) {
-		runDevServer()
+		runDevServer(cmd)
 	},
 }
 
@@ -205,7 +225,7 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	// Global flags
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.golanggraph.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file or URL (default is $HOME/.golanggraph.yaml)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 
 	// Serve command flags
@@ -213,6 +233,7 @@ func init() {
 	serveCmd.Flags().IntP("port", "p", 8080, "Port to bind to")
 	serveCmd.Flags().String("static-dir", "./static", "Static files directory")
 	serveCmd.Flags().Bool("enable-cors", true, "Enable CORS")
+	serveCmd.Flags().Duration("config-poll-interval", 30*time.Second, "Interval at which a remote --config URL is polled for changes (0 disables polling)")
 
 	// Dev command flags
 	devCmd.Flags().StringP("host", "H", "localhost", "Host to bind to")
@@ -245,6 +266,10 @@ func init() {
 	// Visualize command flags
 	visualizeCmd.Flags().StringP("format", "f", "mermaid", "Output format (mermaid, dot, json)")
 	visualizeCmd.Flags().StringP("output", "o", "", "Output file (default: stdout)")
+	visualizeCmd.Flags().String("trace", "", "JSON file of recorded execution steps to annotate the diagram with")
+
+	// Debug run command flags
+	debugRunCmd.Flags().String("state", "", "JSON file of the initial state values")
 
 	// Add subcommands
 	rootCmd.AddCommand(initCmd)
@@ -263,6 +288,7 @@ func init() {
 	dockerCmd.AddCommand(dockerBuildCmd)
 	deployCmd.AddCommand(deployDockerCmd)
 	debugCmd.AddCommand(visualizeCmd)
+	debugCmd.AddCommand(debugRunCmd)
 
 	// Bind flags to viper
 	viper.BindPFlag("host", serveCmd.Flags().Lookup("host"))
@@ -296,25 +322,23 @@ func initConfig() {
 	}
 }
 
-func runServer() {
+func runServer(cmd *cobra.Command) {
 	fmt.Println("Starting GoLangGraph server...")
 
-	// Create server configuration
-	config := &server.ServerConfig{
-		Host:           viper.GetString("host"),
-		Port:           viper.GetInt("port"),
-		ReadTimeout:    30 * time.Second,
-		WriteTimeout:   30 * time.Second,
-		MaxHeaderBytes: 1 << 20,
-		EnableCORS:     viper.GetBool("enable-cors"),
-		StaticDir:      viper.GetString("static-dir"),
+	appConfig, err := loadAppConfig(cmd)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
 	}
 
+	// Create server configuration
+	serverConfig := appConfig.Server.ToServerConfig()
+
 	// Create server
-	srv := server.NewServer(config)
+	srv := server.NewServer(serverConfig)
 
 	// Initialize components
-	if err := initializeComponents(srv); err != nil {
+	llmManager, err := initializeComponents(srv, appConfig)
+	if err != nil {
 		log.Fatalf("Failed to initialize components: %v", err)
 	}
 
@@ -325,13 +349,39 @@ func runServer() {
 		}
 	}()
 
-	fmt.Printf("Server started on %s:%d\n", config.Host, config.Port)
-	fmt.Printf("Health check: http://%s:%d/api/v1/health\n", config.Host, config.Port)
+	scheme := "http"
+	if serverConfig.TLS.Enabled() {
+		scheme = "https"
+	}
+	fmt.Printf("Server started on %s:%d\n", serverConfig.Host, serverConfig.Port)
+	fmt.Printf("Health check: %s://%s:%d/api/v1/health\n", scheme, serverConfig.Host, serverConfig.Port)
 
-	// Wait for interrupt signal to gracefully shutdown
+	// Poll a remote config for changes, which reload like SIGHUP
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+	remoteChanges := watchRemoteConfig(watchCtx, cmd)
+
+	// Wait for interrupt signal to gracefully shutdown, reloading the model aliases and TLS
+	// certificates on SIGHUP
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+waitLoop:
+	for {
+		select {
+		case sig := <-quit:
+			if sig != syscall.SIGHUP {
+				break waitLoop
+			}
+			reloadModelAliases(cmd, llmManager)
+			if serverConfig.TLS.Enabled() {
+				if err := srv.ReloadCertificates(); err != nil {
+					log.Printf("Failed to reload TLS certificates: %v", err)
+				}
+			}
+		case <-remoteChanges:
+			reloadModelAliases(cmd, llmManager)
+		}
+	}
 
 	fmt.Println("Shutting down server...")
 
@@ -346,7 +396,82 @@ func runServer() {
 	fmt.Println("Server exited")
 }
 
-func initializeComponents(srv *server.Server) error {
+// loadAppConfig loads the config file in use, overridden by the serve flags given on the
+// command line, and validates it
+func loadAppConfig(cmd *cobra.Command) (*config.AppConfig, error) {
+	appConfig, err := config.Load(viper.ConfigFileUsed())
+	if err != nil {
+		return nil, err
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("host") {
+		appConfig.Server.Host, _ = flags.GetString("host")
+	}
+	if flags.Changed("port") {
+		appConfig.Server.Port, _ = flags.GetInt("port")
+	}
+	if flags.Changed("static-dir") {
+		appConfig.Server.StaticDir, _ = flags.GetString("static-dir")
+	}
+	if flags.Changed("enable-cors") {
+		appConfig.Server.EnableCORS, _ = flags.GetBool("enable-cors")
+	}
+
+	if err := appConfig.Validate(); err != nil {
+		return nil, err
+	}
+	return appConfig, nil
+}
+
+// watchRemoteConfig polls the config URL in use, when it is remote, and signals each change
+// on the returned channel; the channel never fires for local config files
+func watchRemoteConfig(ctx context.Context, cmd *cobra.Command) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+	location := viper.ConfigFileUsed()
+	interval, _ := cmd.Flags().GetDuration("config-poll-interval")
+	if !config.IsRemoteLocation(location) || interval <= 0 {
+		return changes
+	}
+
+	loader := config.NewLoader()
+	source, err := loader.OpenSource(location)
+	if err != nil {
+		log.Printf("Not polling the remote configuration: %v", err)
+		return changes
+	}
+	fmt.Printf("Polling %s for configuration changes every %s\n", source.Name(), interval)
+	go loader.Watch(ctx, source, interval, func(_ *config.AppConfig, err error) {
+		if err != nil {
+			log.Printf("Failed to poll the remote configuration: %v", err)
+			return
+		}
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	})
+	return changes
+}
+
+// reloadModelAliases applies the model aliases of the reloaded config file; agents pick up
+// the new targets on their next call
+func reloadModelAliases(cmd *cobra.Command, llmManager *llm.ProviderManager) {
+	appConfig, err := loadAppConfig(cmd)
+	if err != nil {
+		log.Printf("Keeping the current model aliases, invalid configuration: %v", err)
+		return
+	}
+	aliases, err := appConfig.ResolveModelAliases()
+	if err != nil {
+		log.Printf("Keeping the current model aliases: %v", err)
+		return
+	}
+	llmManager.SetModelAliases(aliases)
+	fmt.Printf("Reloaded %d model aliases\n", len(aliases))
+}
+
+func initializeComponents(srv *server.Server, appConfig *config.AppConfig) (*llm.ProviderManager, error) {
 	// Initialize LLM providers
 	llmManager := llm.NewProviderManager()
 
@@ -382,6 +507,13 @@ func initializeComponents(srv *server.Server) error {
 		}
 	}
 
+	// Map the logical model names agents reference to providers and models
+	aliases, err := appConfig.ResolveModelAliases()
+	if err != nil {
+		return nil, err
+	}
+	llmManager.SetModelAliases(aliases)
+
 	// Initialize tool registry
 	toolRegistry := tools.NewToolRegistry()
 
@@ -394,8 +526,16 @@ func initializeComponents(srv *server.Server) error {
 	toolRegistry.RegisterTool(tools.NewHTTPTool())
 	toolRegistry.RegisterTool(tools.NewTimeTool())
 
+	// Build the tools declared in the config file, including custom factories
+	if err := toolRegistry.RegisterFromSpecs(appConfig.Tools); err != nil {
+		return nil, err
+	}
+
 	// Initialize session manager (using memory for now)
 	sessionManager := persistence.NewSessionManager(nil)
+	if generator := appConfig.TitleGenerator(llmManager); generator != nil {
+		sessionManager.SetTitleGenerator(generator)
+	}
 
 	// Initialize agent manager
 	agentManager := server.NewAgentManager(llmManager, toolRegistry)
@@ -406,7 +546,7 @@ func initializeComponents(srv *server.Server) error {
 	srv.SetAgentManager(agentManager)
 	srv.SetSessionManager(sessionManager)
 
-	return nil
+	return llmManager, nil
 }
 
 func runMigrations() {
@@ -455,7 +595,7 @@ func runMigrations() {
 	}
 }
 
-func runVisualize(args []string, format, output string) {
+func runVisualize(args []string, format, output, trace string) {
 	fmt.Printf("Visualizing graph in %s format...\n", format)
 
 	// Create a sample graph for demonstration
@@ -468,10 +608,20 @@ func runVisualize(args []string, format, output string) {
 	// Get topology
 	topology := visualizer.GetGraphTopology(sampleGraph)
 
+	var stats *debug.GraphStats
+	if trace != "" {
+		steps, err := debug.LoadTraceFile(trace)
+		if err != nil {
+			log.Fatalf("Failed to load trace: %v", err)
+		}
+		stats = debug.AggregateStats(steps)
+		fmt.Printf("Aggregated %d runs from %s\n", stats.Runs, trace)
+	}
+
 	var result string
 	switch format {
 	case "mermaid":
-		result = visualizer.GenerateMermaidDiagram(topology)
+		result = visualizer.GenerateMermaidDiagramWithStats(topology, stats)
 	case "dot":
 		result = visualizer.GenerateDotDiagram(topology)
 	case "json":
@@ -492,6 +642,34 @@ func runVisualize(args []string, format, output string) {
 	}
 }
 
+func runDebug(args []string, stateFile string) {
+	// Debug the sample graph, as the visualize command does
+	// In a real implementation, this would load from a file or configuration
+	sampleGraph := createSampleGraph()
+
+	initialState := core.NewBaseState()
+	if stateFile != "" {
+		data, err := os.ReadFile(stateFile)
+		if err != nil {
+			log.Fatalf("Failed to read state file: %v", err)
+		}
+		var values map[string]interface{}
+		if err := json.Unmarshal(data, &values); err != nil {
+			log.Fatalf("Failed to parse state file: %v", err)
+		}
+		for key, value := range values {
+			initialState.Set(key, value)
+		}
+	}
+
+	fmt.Printf("Debugging graph %s, type help for the commands\n", sampleGraph.Name)
+
+	debugger := debug.NewStepDebugger(os.Stdin, os.Stdout)
+	if _, err := debugger.Run(context.Background(), sampleGraph, initialState); err != nil && !errors.Is(err, debug.ErrDebugQuit) {
+		os.Exit(1)
+	}
+}
+
 func createSampleGraph() *core.Graph {
 	// This is a placeholder - in a real implementation, you'd load from configuration
 	graph := core.NewGraph("sample-graph")
@@ -502,6 +680,7 @@ func createSampleGraph() *core.Graph {
 	})
 
 	graph.AddNode("process", "Process", func(ctx context.Context, state *core.BaseState) (*core.BaseState, error) {
+		state.Set("processed", true)
 		return state, nil
 	})
 
@@ -650,26 +829,23 @@ func runDockerBuild(args []string, distroless bool, tag, dockerfile, platform st
 	fmt.Printf("Docker build command prepared. Execute manually or integrate with docker library.\n")
 }
 
-func runDevServer() {
+func runDevServer(cmd *cobra.Command) {
 	fmt.Println("Starting development server...")
 
-	// Create development server configuration
-	config := &server.ServerConfig{
-		Host:           viper.GetString("host"),
-		Port:           viper.GetInt("port"),
-		ReadTimeout:    30 * time.Second,
-		WriteTimeout:   30 * time.Second,
-		MaxHeaderBytes: 1 << 20,
-		EnableCORS:     true,
-		StaticDir:      "./static",
-		DevMode:        true,
+	appConfig, err := loadAppConfig(cmd)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
 	}
 
+	// Create development server configuration
+	serverConfig := appConfig.Server.ToServerConfig()
+	serverConfig.DevMode = true
+
 	// Create server
-	srv := server.NewServer(config)
+	srv := server.NewServer(serverConfig)
 
 	// Initialize components
-	if err := initializeComponents(srv); err != nil {
+	if _, err := initializeComponents(srv, appConfig); err != nil {
 		log.Fatalf("Failed to initialize components: %v", err)
 	}
 
@@ -680,10 +856,10 @@ func runDevServer() {
 		}
 	}()
 
-	fmt.Printf("Development server started on %s:%d\n", config.Host, config.Port)
-	fmt.Printf("API endpoints: http://%s:%d/api/v1/\n", config.Host, config.Port)
-	fmt.Printf("Debug interface: http://%s:%d/debug\n", config.Host, config.Port)
-	fmt.Printf("Agent playground: http://%s:%d/playground\n", config.Host, config.Port)
+	fmt.Printf("Development server started on %s:%d\n", serverConfig.Host, serverConfig.Port)
+	fmt.Printf("API endpoints: http://%s:%d/api/v1/\n", serverConfig.Host, serverConfig.Port)
+	fmt.Printf("Debug interface: http://%s:%d/debug\n", serverConfig.Host, serverConfig.Port)
+	fmt.Printf("Agent playground: http://%s:%d/playground\n", serverConfig.Host, serverConfig.Port)
 
 	// Watch for file changes (hot-reload)
 	if viper.GetBool("hot-reload") {
@@ -720,18 +896,29 @@ func runValidate(args []string, strict bool) {
 	fmt.Printf("Config file: %s\n", configFile)
 	fmt.Printf("Strict mode: %t\n", strict)
 
-	// Check if config file exists
-	if _, err := os.Stat(configFile); os.IsNotExist(err) {
-		log.Fatalf("Configuration file not found: %s", configFile)
+	loader := config.NewLoader()
+	loader.KnownFields = strict
+
+	appConfig, err := loader.Load(configFile)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Note: In a real implementation, you would:
-	// 1. Parse the configuration file
-	// 2. Validate the schema
-	// 3. Check for required fields
-	// 4. Validate graph structure
-	// 5. Check tool availability
-	// 6. Validate LLM provider configuration
+	validate := appConfig.Validate
+	if strict {
+		validate = appConfig.ValidateStrict
+	}
+	if err := validate(); err != nil {
+		var validationErrs config.ValidationErrors
+		if !errors.As(err, &validationErrs) {
+			log.Fatalf("Configuration validation failed: %v", err)
+		}
+		fmt.Printf("Configuration is invalid (%d errors):\n", len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			fmt.Printf("  - %s\n", fieldErr.Error())
+		}
+		os.Exit(1)
+	}
 
 	fmt.Printf("Configuration validation completed successfully!\n")
 }
@@ -476,6 +476,14 @@ func runMultiAgentServe(args []string, host string, port int) {
 	toolRegistry.RegisterTool(tools.NewHTTPTool())
 	toolRegistry.RegisterTool(tools.NewTimeTool())
 
+	// Build the tools declared in the shared config, including custom factories
+	if config.Shared != nil {
+		if err := toolRegistry.RegisterFromSpecs(config.Shared.Tools); err != nil {
+			fmt.Printf("Error setting up tools: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// Create multi-agent manager
 	multiAgentManager, err := agent.NewMultiAgentManager(config, llmManager, toolRegistry)
 	if err != nil {
@@ -0,0 +1,129 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/persistence"
+)
+
+// reindexCmd represents the reindex command
+var reindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Re-embed all stored documents with a new embedding model",
+	Long: `Re-embed every RAG document stored in PostgreSQL/pgvector with a new embedding model.
+
+Documents are written to a staging table, the document counts are verified and the
+staging table is then atomically swapped in place of the documents table. The previous
+table is kept as documents_previous. Changes in embedding dimension are handled by
+creating the staging table with the new vector size.
+
+Interrupted runs can be resumed by running the same command again.
+
+Examples:
+  # Re-embed with an OpenAI model
+  golanggraph reindex --provider openai --model text-embedding-3-small
+
+  # Re-embed with a local Ollama model in batches of 20
+  golanggraph reindex --provider ollama --model nomic-embed-text --batch-size 20`,
+	RunE: runReindex,
+}
+
+func init() {
+	rootCmd.AddCommand(reindexCmd)
+
+	// Embedding flags
+	reindexCmd.Flags().String("provider", "openai", "Embedding provider (openai, ollama)")
+	reindexCmd.Flags().String("model", "", "New embedding model")
+	reindexCmd.Flags().Int("dimension", 0, "Embedding dimension (detected when zero)")
+	reindexCmd.Flags().Int("batch-size", 100, "Documents embedded per request")
+	reindexCmd.Flags().String("openai-api-key", "", "OpenAI API key (defaults to OPENAI_API_KEY)")
+	reindexCmd.Flags().String("ollama-endpoint", "http://localhost:11434", "Ollama endpoint URL")
+
+	// Database flags
+	reindexCmd.Flags().String("db-host", "localhost", "Database host")
+	reindexCmd.Flags().Int("db-port", 5432, "Database port")
+	reindexCmd.Flags().String("db-name", "golanggraph", "Database name")
+	reindexCmd.Flags().String("db-user", "postgres", "Database user")
+	reindexCmd.Flags().String("db-password", "", "Database password")
+
+	reindexCmd.MarkFlagRequired("model")
+}
+
+func runReindex(cmd *cobra.Command, args []string) error {
+	providerName, _ := cmd.Flags().GetString("provider")
+	model, _ := cmd.Flags().GetString("model")
+	dimension, _ := cmd.Flags().GetInt("dimension")
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+	dbHost, _ := cmd.Flags().GetString("db-host")
+	dbPort, _ := cmd.Flags().GetInt("db-port")
+	dbName, _ := cmd.Flags().GetString("db-name")
+	dbUser, _ := cmd.Flags().GetString("db-user")
+	dbPassword, _ := cmd.Flags().GetString("db-password")
+
+	llmManager := llm.NewProviderManager()
+	switch providerName {
+	case "openai":
+		apiKey, _ := cmd.Flags().GetString("openai-api-key")
+		if apiKey == "" {
+			apiKey = os.Getenv("OPENAI_API_KEY")
+		}
+		provider, err := llm.NewOpenAIProvider(&llm.ProviderConfig{APIKey: apiKey, Endpoint: "https://api.openai.com/v1"})
+		if err != nil {
+			return fmt.Errorf("failed to create OpenAI provider: %w", err)
+		}
+		llmManager.RegisterProvider(providerName, provider)
+	case "ollama":
+		endpoint, _ := cmd.Flags().GetString("ollama-endpoint")
+		provider, err := llm.NewOllamaProvider(&llm.ProviderConfig{Endpoint: endpoint})
+		if err != nil {
+			return fmt.Errorf("failed to create Ollama provider: %w", err)
+		}
+		llmManager.RegisterProvider(providerName, provider)
+	default:
+		return fmt.Errorf("unsupported embedding provider: %s", providerName)
+	}
+
+	config := persistence.NewPgVectorConfig(dbHost, dbPort, dbName, dbUser, dbPassword, dimension)
+	store, err := persistence.NewPostgresCheckpointer(config)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer store.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	embed := func(ctx context.Context, texts []string) ([][]float64, error) {
+		return llmManager.Embed(ctx, providerName, model, texts)
+	}
+
+	fmt.Printf("🔄 Re-embedding documents with %s/%s\n", providerName, model)
+
+	result, err := persistence.ReindexWithModel(ctx, store, embed, model, &persistence.ReindexConfig{
+		Dimension: dimension,
+		BatchSize: batchSize,
+		Progress: func(progress persistence.ReindexProgress) {
+			fmt.Printf("   %d/%d documents re-embedded (last: %s)\n", progress.Processed, progress.Total, progress.LastID)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("reindex failed (run again to resume): %w", err)
+	}
+
+	fmt.Printf("✅ Re-embedded %d documents (%d resumed) with %d dimensions in %v\n",
+		result.Total, result.Resumed, result.Dimension, result.Duration)
+	return nil
+}
@@ -69,6 +69,9 @@ type RAGSystem struct {
 	endpoint    string
 	model       string
 	documents   []Document
+
+	// similarityThreshold is the minimum relevance for a chunk to be used as context
+	similarityThreshold float64
 }
 
 // SearchResult represents a search result with similarity score
@@ -123,10 +126,11 @@ func main() {
 // NewRAGSystem creates a new RAG system
 func NewRAGSystem(endpoint, model string) *RAGSystem {
 	return &RAGSystem{
-		vectorStore: &VectorStore{chunks: make([]Chunk, 0)},
-		endpoint:    endpoint,
-		model:       model,
-		documents:   make([]Document, 0),
+		vectorStore:         &VectorStore{chunks: make([]Chunk, 0)},
+		endpoint:            endpoint,
+		model:               model,
+		documents:           make([]Document, 0),
+		similarityThreshold: 0.7,
 	}
 }
 
@@ -288,13 +292,17 @@ func hash(s string) int {
 	return h
 }
 
-// search performs similarity search in the vector store
+// search performs similarity search in the vector store, returning at most topK chunks
+// whose similarity reaches the threshold
 func (r *RAGSystem) search(query string, topK int) []SearchResult {
 	queryVector := r.generateEmbedding(query)
 
 	var results []SearchResult
 	for _, chunk := range r.vectorStore.chunks {
 		similarity := cosineSimilarity(queryVector, chunk.Vector)
+		if similarity < r.similarityThreshold {
+			continue
+		}
 		results = append(results, SearchResult{
 			Chunk:      chunk,
 			Similarity: similarity,
@@ -340,7 +348,11 @@ func cosineSimilarity(a, b []float64) float64 {
 func (r *RAGSystem) generateRAGResponse(query string, searchResults []SearchResult) string {
 	// Build context from search results
 	var contextBuilder strings.Builder
-	contextBuilder.WriteString("Based on the following information:\n\n")
+	if len(searchResults) == 0 {
+		contextBuilder.WriteString("No relevant context found in the knowledge base.\n\n")
+	} else {
+		contextBuilder.WriteString("Based on the following information:\n\n")
+	}
 
 	for i, result := range searchResults {
 		contextBuilder.WriteString(fmt.Sprintf("Source %d (Relevance: %.2f):\n%s\n\n",
@@ -474,6 +486,9 @@ func (r *RAGSystem) processRAGQuery(query string) {
 
 	// Display search results
 	fmt.Println("📊 Retrieved Sources:")
+	if len(searchResults) == 0 {
+		fmt.Printf("   No sources reached the %.2f relevance threshold\n", r.similarityThreshold)
+	}
 	for i, result := range searchResults {
 		fmt.Printf("%d. Relevance: %.2f | Document: %s\n",
 			i+1, result.Similarity, r.getDocumentTitle(result.Chunk.DocumentID))
@@ -522,6 +537,9 @@ func (r *RAGSystem) interactiveSearch() {
 			fmt.Printf("\n🔍 Search Results for: %s\n", query)
 			fmt.Println("─────────────────────────────────────────")
 
+			if len(results) == 0 {
+				fmt.Printf("No results reached the %.2f relevance threshold\n\n", r.similarityThreshold)
+			}
 			for i, result := range results {
 				fmt.Printf("%d. Similarity: %.3f\n", i+1, result.Similarity)
 				fmt.Printf("   Document: %s\n", r.getDocumentTitle(result.Chunk.DocumentID))
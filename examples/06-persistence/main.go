@@ -69,8 +69,15 @@ type PersistentAgent struct {
 	model               string
 	currentConversation *Conversation
 	conversationHistory []Message
+	contextWindow       int // Tokens the model runs with, read from Ollama
 }
 
+// defaultContextWindow is Ollama's num_ctx for models whose Modelfile sets none
+const defaultContextWindow = 2048
+
+// responseReserve is the part of the context window left for the answer
+const responseReserve = 512
+
 func main() {
 	fmt.Println("💾 GoLangGraph Persistent Agent")
 	fmt.Println("===============================")
@@ -113,6 +120,9 @@ func main() {
 	}
 	fmt.Println("✅ Ollama connection successful")
 
+	agent.detectContextWindow()
+	fmt.Printf("✅ Context window: %d tokens\n", agent.contextWindow)
+
 	fmt.Println("✅ Database initialized")
 	fmt.Println("✅ Persistent agent ready")
 	fmt.Println()
@@ -130,9 +140,10 @@ func NewPersistentAgent(endpoint, model string) (*PersistentAgent, error) {
 	}
 
 	agent := &PersistentAgent{
-		db:       db,
-		endpoint: endpoint,
-		model:    model,
+		db:            db,
+		endpoint:      endpoint,
+		model:         model,
+		contextWindow: defaultContextWindow,
 	}
 
 	// Create tables
@@ -512,10 +523,16 @@ func (p *PersistentAgent) buildConversationContext(currentInput string) string {
 	// Add system prompt
 	context.WriteString("You are a helpful and friendly AI assistant. Provide clear, concise, and helpful responses based on the conversation history.\n\n")
 
-	// Add conversation history (last 10 messages)
-	start := len(p.conversationHistory) - 10
-	if start < 0 {
-		start = 0
+	// Add as much recent history as fits in the model's context window
+	budget := p.contextWindow - responseReserve - estimateTokens(context.String()) - estimateTokens(currentInput)
+	start := len(p.conversationHistory)
+	for start > 0 {
+		tokens := estimateTokens(p.conversationHistory[start-1].Content) + 4 // Role prefix and newline
+		if tokens > budget {
+			break
+		}
+		budget -= tokens
+		start--
 	}
 
 	for i := start; i < len(p.conversationHistory); i++ {
@@ -534,6 +551,49 @@ func (p *PersistentAgent) buildConversationContext(currentInput string) string {
 	return context.String()
 }
 
+// estimateTokens approximates the number of tokens in a text, at about 4 characters each
+func estimateTokens(text string) int {
+	return len(text)/4 + 1
+}
+
+// detectContextWindow reads the num_ctx the model runs with from Ollama's /api/show,
+// keeping the default when the Modelfile sets none
+func (p *PersistentAgent) detectContextWindow() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	jsonData, err := json.Marshal(map[string]string{"model": p.model})
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.endpoint+"/api/show", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	var show struct {
+		Parameters string `json:"parameters"`
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&show) != nil {
+		return
+	}
+	for _, line := range strings.Split(show.Parameters, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "num_ctx" {
+			if numCtx, err := strconv.Atoi(fields[1]); err == nil && numCtx > 0 {
+				p.contextWindow = numCtx
+			}
+		}
+	}
+}
+
 // callOllama makes a request to the Ollama API
 func (p *PersistentAgent) callOllama(prompt string) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
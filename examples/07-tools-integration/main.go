@@ -66,7 +66,10 @@ type ToolsAgent struct {
 type FileSystemTool struct{}
 
 // WebRequestTool provides web request capabilities
-type WebRequestTool struct{}
+type WebRequestTool struct {
+	// MaxResultBytes limits the response body returned to the agent (0 disables the limit)
+	MaxResultBytes int
+}
 
 // SystemMonitorTool provides system monitoring
 type SystemMonitorTool struct{}
@@ -130,7 +133,7 @@ func NewToolsAgent(endpoint, model string) *ToolsAgent {
 
 	// Register tools
 	agent.registerTool(&FileSystemTool{})
-	agent.registerTool(&WebRequestTool{})
+	agent.registerTool(&WebRequestTool{MaxResultBytes: 4096})
 	agent.registerTool(&SystemMonitorTool{})
 	agent.registerTool(&DataProcessingTool{})
 	agent.registerTool(&CommandExecutorTool{})
@@ -584,7 +587,12 @@ func (w *WebRequestTool) Execute(args map[string]interface{}) (string, error) {
 		return "", fmt.Errorf("failed to read response: %w", err)
 	}
 
-	return fmt.Sprintf("HTTP %d response from %s:\n%s", resp.StatusCode, url, string(body)[:min(500, len(body))]), nil
+	content := string(body)
+	if w.MaxResultBytes > 0 && len(content) > w.MaxResultBytes {
+		content = fmt.Sprintf("%s\n... [truncated %d bytes]", content[:w.MaxResultBytes], len(content)-w.MaxResultBytes)
+	}
+
+	return fmt.Sprintf("HTTP %d response from %s:\n%s", resp.StatusCode, url, content), nil
 }
 
 func (w *WebRequestTool) GetUsageExamples() []string {
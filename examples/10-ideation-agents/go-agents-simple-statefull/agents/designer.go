@@ -541,6 +541,29 @@ func (d *DesignerDefinition) CreateAgent() (*agent.Agent, error) {
 	return baseAgent, nil
 }
 
+// Init checks the primary database is reachable before the designer serves requests
+func (dd *DesignerDefinition) Init(ctx context.Context) error {
+	if dd.databaseManager == nil || dd.databaseManager.Primary == nil {
+		return fmt.Errorf("designer requires a database manager")
+	}
+	if err := dd.databaseManager.Primary.Ping(); err != nil {
+		return fmt.Errorf("designer database unreachable: %w", err)
+	}
+	return nil
+}
+
+// Close drops the in-memory session state and closes the database manager the designer owns
+func (dd *DesignerDefinition) Close() error {
+	dd.conversationHistory = make(map[string]*ConversationContext)
+	dd.activeDesigns = make(map[string]*DesignSession)
+	dd.userProfiles = make(map[string]*UserProfile)
+
+	if dd.databaseManager == nil {
+		return nil
+	}
+	return dd.databaseManager.Close()
+}
+
 // Enhanced node implementations using GoLangGraph infrastructure
 
 // initializeSessionNode initializes or restores session context using GoLangGraph persistence
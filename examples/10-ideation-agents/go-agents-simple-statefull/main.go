@@ -381,8 +381,9 @@ func (s *StatefulAgentSystem) Start(ctx context.Context) error {
 func (s *StatefulAgentSystem) Shutdown() {
 	s.logger.Info("Shutting down stateful agent system...")
 
-	// Close database connections
-	if s.databaseManager != nil {
+	// The designer agent owns the database manager: the auto-server closes it through the
+	// agent's Close hook once it has stopped serving requests
+	if s.databaseManager != nil && s.autoServer == nil {
 		if err := s.databaseManager.Close(); err != nil {
 			s.logger.WithError(err).Error("Error closing database manager")
 		}
@@ -186,6 +186,10 @@ func (m *MockProvider) CompleteStreamWithMode(ctx context.Context, req llm.Compl
 
 func (m *MockProvider) SupportsStreaming() bool { return true }
 
+func (m *MockProvider) Capabilities() llm.ProviderCapabilities {
+	return llm.ProviderCapabilities{SupportsStreaming: true, SupportsTools: true, MaxContextTokens: 4096}
+}
+
 func (m *MockProvider) GetStreamingConfig() *llm.StreamingConfig {
 	return &llm.StreamingConfig{
 		Mode:      llm.StreamModeNone,
@@ -8,6 +8,7 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -18,6 +19,8 @@ import (
 
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/rag"
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
 )
 
@@ -28,24 +31,50 @@ const (
 	AgentTypeReAct AgentType = "react"
 	AgentTypeChat  AgentType = "chat"
 	AgentTypeTool  AgentType = "tool"
+	AgentTypeGraph AgentType = "graph"
 )
 
 // AgentConfig represents agent configuration
 type AgentConfig struct {
-	ID              string                 `json:"id"`
-	Name            string                 `json:"name"`
-	Type            AgentType              `json:"type"`
-	Model           string                 `json:"model"`
-	Provider        string                 `json:"provider"`
-	SystemPrompt    string                 `json:"system_prompt"`
-	Temperature     float64                `json:"temperature"`
-	MaxTokens       int                    `json:"max_tokens"`
-	MaxIterations   int                    `json:"max_iterations"`
-	Tools           []string               `json:"tools"`
-	EnableStreaming bool                   `json:"enable_streaming"`
-	StreamingMode   llm.StreamMode         `json:"streaming_mode,omitempty"`
-	Timeout         time.Duration          `json:"timeout"`
-	Metadata        map[string]interface{} `json:"metadata"`
+	ID                  string                 `json:"id"`
+	Name                string                 `json:"name"`
+	Type                AgentType              `json:"type"`
+	Model               string                 `json:"model"`
+	Provider            string                 `json:"provider"`
+	SystemPrompt        string                 `json:"system_prompt"`
+	PromptComposer      *PromptComposer        `json:"-"` // Composes the system prompt from fragments instead of SystemPrompt
+	Temperature         float64                `json:"temperature"`
+	MaxTokens           int                    `json:"max_tokens"`
+	MaxIterations       int                    `json:"max_iterations"`
+	MaxToolCalls        int                    `json:"max_tool_calls,omitempty"` // Tool calls allowed per execution, across all steps; unlimited when zero
+	RetryBudget         int                    `json:"retry_budget,omitempty"`   // Retries allowed per execution across provider, node and agent layers; unlimited when zero
+	Tools               []string               `json:"tools"`
+	EnableStreaming     bool                   `json:"enable_streaming"`
+	StreamingMode       llm.StreamMode         `json:"streaming_mode,omitempty"`
+	StreamGranularity   StreamGranularity      `json:"stream_granularity,omitempty"` // Unit in which streamed answers are sent; token by default
+	Timeout             time.Duration          `json:"timeout"`
+	RateLimit           *AgentRateLimit        `json:"rate_limit,omitempty"`
+	Seed                *int                   `json:"seed,omitempty"`                  // Sampling seed for reproducible runs where supported
+	Locale              string                 `json:"locale,omitempty"`                // Language the agent responds in, e.g. "fr"; ExecuteOptions.Locale overrides it
+	EmptyResponsePolicy EmptyResponsePolicy    `json:"empty_response_policy,omitempty"` // What to do when the model answers with nothing; fails by default
+	ContentBlockPolicy  ContentBlockPolicy     `json:"content_block_policy,omitempty"`  // What to do when the provider's content filter blocks; fails by default
+	ContentBlockMessage string                 `json:"content_block_message,omitempty"` // Answer given for blocked content under the fallback policy
+	LocaleRetries       int                    `json:"locale_retries,omitempty"`        // Rewrites of an answer in the wrong language, checked with the language detector
+	ContextRetriever    ContextRetriever       `json:"-"`                               // Refreshes the retrieved context from each input
+	Retriever           rag.Retriever          `json:"-"`                               // Retrieves the context of each input from a RAG backend, unless ContextRetriever is set
+	RetrieveOptions     *rag.RetrieveOptions   `json:"retrieve_options,omitempty"`      // Number, filter and minimum score of the chunks the Retriever returns
+	NoContextBehavior   NoContextBehavior      `json:"no_context_behavior,omitempty"`   // What to do when nothing relevant is retrieved; only noted to the model by default
+	NoContextMessage    string                 `json:"no_context_message,omitempty"`    // Answer given under the refuse behavior
+	NoContextTool       string                 `json:"no_context_tool,omitempty"`       // Tool called with the input under the fallback behavior; web_search by default
+	StopConditions      []StopCondition        `json:"-"`                               // End a ReAct loop before MaxIterations once one holds
+	ContextOrder        ContextOrder           `json:"context_order,omitempty"`         // Order of the prompt segments; DefaultContextOrder when empty
+	InjectionGuard      *InjectionGuard        `json:"-"`                               // Checks the input and tool results for prompt injections
+	InteractionRecorder *InteractionRecorder   `json:"-"`                               // Records sampled executions as fine-tuning data
+	ExecutionRetry      *ExecutionRetryPolicy  `json:"execution_retry,omitempty"`       // Runs the execution again while its output fails validation
+	OutputFilter        *RedactionFilter       `json:"-"`                               // Redacts the output and streamed tokens, e.g. PII echoed by the model
+	Examples            []Example              `json:"examples,omitempty"`              // Few-shot examples given to the model ahead of the conversation
+	ExampleSelection    *ExampleSelection      `json:"example_selection,omitempty"`     // Which examples are given and how; all, as turns, by default
+	Metadata            map[string]interface{} `json:"metadata"`
 }
 
 // DefaultAgentConfig returns default agent configuration
@@ -110,6 +139,42 @@ func (config *AgentConfig) Validate() error {
 		return fmt.Errorf("MaxIterations too large (%d), maximum allowed is 100", config.MaxIterations)
 	}
 
+	if config.MaxToolCalls < 0 {
+		return fmt.Errorf("MaxToolCalls must not be negative, got %d", config.MaxToolCalls)
+	}
+
+	if config.RetryBudget < 0 {
+		return fmt.Errorf("RetryBudget must not be negative, got %d", config.RetryBudget)
+	}
+
+	if config.ExecutionRetry != nil {
+		if err := config.ExecutionRetry.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := config.StreamGranularity.Validate(); err != nil {
+		return err
+	}
+
+	if err := config.ContextOrder.Validate(); err != nil {
+		return err
+	}
+
+	if err := config.ContentBlockPolicy.Validate(); err != nil {
+		return err
+	}
+
+	if config.ExampleSelection != nil {
+		if err := config.ExampleSelection.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := config.NoContextBehavior.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -184,8 +249,24 @@ type Agent struct {
 	graph        *core.Graph
 	conversation *llm.ConversationHistory
 	logger       *logrus.Logger
+	rateLimiter  *rateLimiter
 	mu           sync.RWMutex
 
+	// Checks the language of outputs for the locale post-check
+	languageDetector LanguageDetector
+
+	// Retrieved context message, kept apart from the conversation history
+	retrievedContext *llm.Message
+	// Messages of the latest model request, in the configured context order
+	assembledMessages []llm.Message
+	// Few-shot examples selected for the current input, and the embeddings of their inputs
+	exampleMessages   []llm.Message
+	exampleEmbeddings [][]float64
+
+	// State keys used to seed the graph input and read its output
+	inputKey  string
+	outputKey string
+
 	// Execution state
 	isRunning        bool
 	currentIteration int
@@ -194,18 +275,22 @@ type Agent struct {
 
 // AgentExecution represents an agent execution record
 type AgentExecution struct {
-	ID               string                 `json:"id"`
-	Timestamp        time.Time              `json:"timestamp"`
-	Input            string                 `json:"input"`
-	Output           string                 `json:"output"`            // Legacy string output for backward compatibility
-	StructuredOutput interface{}            `json:"structured_output"` // New structured JSON output
-	ToolCalls        []llm.ToolCall         `json:"tool_calls"`
-	Duration         time.Duration          `json:"duration"`
-	Success          bool                   `json:"success"`
-	Error            error                  `json:"error,omitempty"`
-	Metadata         map[string]interface{} `json:"metadata"`
-	ExecutionPath    []string               `json:"execution_path"`          // Track which nodes were executed
-	StateChanges     []StateChange          `json:"state_changes,omitempty"` // Track state progression
+	ID                string                 `json:"id"`
+	Timestamp         time.Time              `json:"timestamp"`
+	Input             string                 `json:"input"`
+	Output            string                 `json:"output"`            // Legacy string output for backward compatibility
+	StructuredOutput  interface{}            `json:"structured_output"` // New structured JSON output
+	ToolCalls         []llm.ToolCall         `json:"tool_calls"`
+	ToolCallCount     int                    `json:"tool_call_count"` // Tool calls made across all steps
+	RetryCount        int                    `json:"retry_count"`     // Retries spent across provider, node and agent layers
+	Duration          time.Duration          `json:"duration"`
+	Success           bool                   `json:"success"`
+	Error             error                  `json:"error,omitempty"`
+	Metadata          map[string]interface{} `json:"metadata"`
+	ExecutionPath     []string               `json:"execution_path"`               // Track which nodes were executed
+	StateChanges      []StateChange          `json:"state_changes,omitempty"`      // Track state progression
+	DeadlineTruncated bool                   `json:"deadline_truncated,omitempty"` // Set when the soft deadline cut the execution short
+	Attempts          []ExecutionAttempt     `json:"attempts,omitempty"`           // Runs of an execution retried by its ExecutionRetryPolicy
 }
 
 // StateChange represents a change in agent state during execution
@@ -262,6 +347,9 @@ func NewAgent(config *AgentConfig, llmManager *llm.ProviderManager, toolRegistry
 		toolRegistry:     toolRegistry,
 		conversation:     llm.NewConversationHistory(),
 		logger:           logger,
+		rateLimiter:      newRateLimiter(agentConfig.RateLimit),
+		inputKey:         "input",
+		outputKey:        "output",
 		executionHistory: make([]AgentExecution, 0),
 	}
 
@@ -350,21 +438,44 @@ func (a *Agent) buildToolGraph() {
 
 // Execute executes the agent with the given input
 func (a *Agent) Execute(ctx context.Context, input string) (*AgentExecution, error) {
+	return a.ExecuteWithOptions(ctx, input, nil)
+}
+
+// ExecuteWithOptions executes the agent with the given input and execution options
+func (a *Agent) ExecuteWithOptions(ctx context.Context, input string, options *ExecuteOptions) (*AgentExecution, error) {
+	if err := a.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer a.release()
+
+	return a.execute(ctx, input, options)
+}
+
+// acquire reserves the agent for an execution, applying its rate limit
+func (a *Agent) acquire(ctx context.Context) error {
+	if err := a.checkRateLimit(ctx); err != nil {
+		return err
+	}
+
 	a.mu.Lock()
+	defer a.mu.Unlock()
 	if a.isRunning {
-		a.mu.Unlock()
-		return nil, fmt.Errorf("agent is already running")
+		return fmt.Errorf("agent is already running")
 	}
 	a.isRunning = true
 	a.currentIteration = 0
-	a.mu.Unlock()
+	return nil
+}
 
-	defer func() {
-		a.mu.Lock()
-		a.isRunning = false
-		a.mu.Unlock()
-	}()
+// release ends the execution reserved with acquire
+func (a *Agent) release() {
+	a.mu.Lock()
+	a.isRunning = false
+	a.mu.Unlock()
+}
 
+// attempt runs the graph once for an execution reserved with acquire
+func (a *Agent) attempt(ctx context.Context, input string, options *ExecuteOptions) (*AgentExecution, error) {
 	start := time.Now()
 	execution := AgentExecution{
 		ID:        uuid.New().String(),
@@ -372,16 +483,65 @@ func (a *Agent) Execute(ctx context.Context, input string) (*AgentExecution, err
 		Input:     input,
 		Metadata:  make(map[string]interface{}),
 	}
+	if options != nil && options.ExecutionID != "" {
+		execution.ID = options.ExecutionID
+	}
+
+	// Bind the execution ID so node, tool and LLM logs can be correlated
+	ctx = logging.WithExecutionID(ctx, execution.ID)
+	ctx = logging.WithAgentID(ctx, a.config.ID)
+	ctx = withSoftDeadline(ctx, start, options)
+	ctx, toolCalls := withToolCallCounter(ctx, a.config.MaxToolCalls)
+
+	locale, localeRetries := a.config.Locale, a.config.LocaleRetries
+	if options != nil && options.Locale != "" {
+		locale, localeRetries = options.Locale, options.LocaleRetries
+	}
+	ctx = withLocale(ctx, locale)
+	if locale != "" {
+		execution.Metadata["locale"] = locale
+	}
+	if sessionID := logging.SessionID(ctx); sessionID != "" {
+		execution.Metadata[logging.FieldSessionID] = sessionID
+	}
+	logging.FromContext(ctx, a.logger).WithField("agent_name", a.config.Name).Info("Agent execution started")
+
+	// Screen the input for prompt injections before it reaches the conversation
+	input, findings, err := a.screenContent(ctx, InjectionSourceUserInput, "", input)
+	if len(findings) > 0 {
+		execution.Metadata["injection_suspected"] = true
+	}
+	if err != nil {
+		execution.Error = err
+		execution.Duration = time.Since(start)
+		return &execution, err
+	}
 
 	// Add user message to conversation
+	pinInput := options != nil && options.PinInput
+	if pinInput {
+		execution.Metadata["input_pinned"] = true
+	}
 	a.conversation.AddMessage(llm.Message{
 		Role:    "user",
 		Content: input,
+		Pinned:  pinInput,
 	})
+	if a.refreshRetrievedContext(ctx, input, &execution) {
+		if answer, refused := a.handleNoContext(ctx, input, &execution); refused {
+			a.conversation.AddMessage(llm.Message{Role: "assistant", Content: answer})
+			execution.Output = answer
+			execution.StructuredOutput = answer
+			execution.Success = true
+			execution.Duration = time.Since(start)
+			return &execution, nil
+		}
+	}
+	a.refreshExamples(ctx, input, &execution)
 
 	// Prepare initial state
 	state := core.NewBaseState()
-	state.Set("input", input)
+	state.Set(a.inputKey, input)
 	state.Set("conversation", a.conversation.GetMessages())
 	state.Set("iteration", 0)
 	state.Set("max_iterations", a.config.MaxIterations)
@@ -391,9 +551,12 @@ func (a *Agent) Execute(ctx context.Context, input string) (*AgentExecution, err
 	if err != nil {
 		execution.Error = err
 		execution.Success = false
+		if errors.Is(err, ErrMaxToolCallsExceeded) {
+			a.partialResult(&execution, toolCalls)
+		}
 	} else {
 		execution.Success = true
-		if output, exists := finalState.Get("output"); exists {
+		if output, exists := finalState.Get(a.outputKey); exists {
 			// Always store structured output and provide string fallback
 			execution.StructuredOutput = output
 
@@ -424,6 +587,23 @@ func (a *Agent) Execute(ctx context.Context, input string) (*AgentExecution, err
 				execution.ToolCalls = tc
 			}
 		}
+		if locale != "" {
+			output, matched := a.enforceLocale(ctx, locale, execution.Output, localeRetries)
+			if _, isString := execution.StructuredOutput.(string); isString {
+				execution.StructuredOutput = output
+			}
+			execution.Output = output
+			if !matched {
+				execution.Metadata["locale_mismatch"] = true
+			}
+		}
+		a.redactOutput(&execution)
+		if truncated, exists := finalState.Get("deadline_truncated"); exists {
+			execution.DeadlineTruncated, _ = truncated.(bool)
+		}
+		if condition := stoppedBy(finalState); condition != "" {
+			execution.Metadata[stopConditionKey] = condition
+		}
 
 		// Track execution path from graph
 		if a.graph != nil {
@@ -432,13 +612,8 @@ func (a *Agent) Execute(ctx context.Context, input string) (*AgentExecution, err
 		}
 	}
 
+	execution.ToolCallCount = len(toolCalls.executed())
 	execution.Duration = time.Since(start)
-
-	// Add execution to history
-	a.mu.Lock()
-	a.executionHistory = append(a.executionHistory, execution)
-	a.mu.Unlock()
-
 	return &execution, err
 }
 
@@ -449,11 +624,12 @@ func (a *Agent) reasonNode(ctx context.Context, state *core.BaseState) (*core.Ba
 	req := llm.CompletionRequest{
 		Messages:    messages,
 		Model:       a.config.Model,
-		Temperature: a.config.Temperature,
+		Temperature: a.temperature(ctx),
 		MaxTokens:   a.config.MaxTokens,
+		Seed:        a.seed(ctx),
 	}
 
-	resp, err := a.llmManager.Complete(ctx, a.config.Provider, req)
+	resp, err := a.completeAnswer(ctx, req, a.completeWithProvider)
 	if err != nil {
 		return nil, fmt.Errorf("reasoning failed: %w", err)
 	}
@@ -464,11 +640,13 @@ func (a *Agent) reasonNode(ctx context.Context, state *core.BaseState) (*core.Ba
 
 	reasoning := resp.Choices[0].Message.Content
 	state.Set("reasoning", reasoning)
+	emitStreamEvent(ctx, StreamEvent{Type: StreamEventThought, Content: reasoning})
 
 	// Add assistant message to conversation
 	a.conversation.AddMessage(resp.Choices[0].Message)
+	a.checkStopConditions(ctx, "reason", state)
 
-	a.logger.WithField("reasoning", reasoning).Info("Agent reasoning completed")
+	logging.FromContext(ctx, a.logger).WithField("reasoning", reasoning).Info("Agent reasoning completed")
 	return state, nil
 }
 
@@ -478,6 +656,7 @@ func (a *Agent) actNode(ctx context.Context, state *core.BaseState) (*core.BaseS
 
 	// Parse the reasoning to determine if tool calls are needed
 	toolCalls := a.parseToolCalls(fmt.Sprintf("%v", reasoning))
+	state.Delete("tool_results")
 
 	if len(toolCalls) == 0 {
 		// No tools needed, just return the reasoning as action
@@ -488,6 +667,7 @@ func (a *Agent) actNode(ctx context.Context, state *core.BaseState) (*core.BaseS
 	// Execute tool calls
 	var results []string
 	var executedCalls []llm.ToolCall
+	var toolResults []ToolStepResult
 
 	for _, toolCall := range toolCalls {
 		tool, exists := a.toolRegistry.GetTool(toolCall.Function.Name)
@@ -495,8 +675,11 @@ func (a *Agent) actNode(ctx context.Context, state *core.BaseState) (*core.BaseS
 			results = append(results, fmt.Sprintf("Tool %s not found", toolCall.Function.Name))
 			continue
 		}
+		if err := countToolCall(ctx, toolCall); err != nil {
+			return nil, err
+		}
 
-		result, err := tool.Execute(ctx, toolCall.Function.Arguments)
+		result, err := a.executeTool(ctx, tool, toolCall.Function.Arguments)
 		if err != nil {
 			results = append(results, fmt.Sprintf("Tool %s failed: %v", toolCall.Function.Name, err))
 		} else {
@@ -504,12 +687,19 @@ func (a *Agent) actNode(ctx context.Context, state *core.BaseState) (*core.BaseS
 		}
 
 		executedCalls = append(executedCalls, toolCall)
+		toolResults = append(toolResults, ToolStepResult{
+			Name:      toolCall.Function.Name,
+			Arguments: toolCall.Function.Arguments,
+			Result:    result,
+			Err:       err,
+		})
 	}
 
 	state.Set("action", strings.Join(results, "\n"))
 	state.Set("tool_calls", executedCalls)
+	state.Set("tool_results", toolResults)
 
-	a.logger.WithField("tool_calls", len(executedCalls)).Info("Agent action completed")
+	logging.FromContext(ctx, a.logger).WithField("tool_calls", len(executedCalls)).Info("Agent action completed")
 	return state, nil
 }
 
@@ -532,24 +722,30 @@ func (a *Agent) observeNode(ctx context.Context, state *core.BaseState) (*core.B
 	if iter, ok := iteration.(int); ok {
 		state.Set("iteration", iter+1)
 	}
+	a.checkStopConditions(ctx, "observe", state)
 
-	a.logger.WithField("observation", observation).Info("Agent observation completed")
+	logging.FromContext(ctx, a.logger).WithField("observation", observation).Info("Agent observation completed")
 	return state, nil
 }
 
 // finalizeNode implements the finalization step
 func (a *Agent) finalizeNode(ctx context.Context, state *core.BaseState) (*core.BaseState, error) {
+	if softDeadlineReached(ctx) {
+		return a.finalizeAtDeadline(ctx, state)
+	}
+
 	// Generate final response
-	messages := a.buildFinalizationMessages(state)
+	messages := withLocaleInstruction(ctx, a.buildFinalizationMessages(state))
 
 	req := llm.CompletionRequest{
 		Messages:    messages,
 		Model:       a.config.Model,
-		Temperature: a.config.Temperature,
+		Temperature: a.temperature(ctx),
 		MaxTokens:   a.config.MaxTokens,
+		Seed:        a.seed(ctx),
 	}
 
-	resp, err := a.llmManager.Complete(ctx, a.config.Provider, req)
+	resp, err := a.completeAnswer(ctx, req, a.completeWithProvider)
 	if err != nil {
 		return nil, fmt.Errorf("finalization failed: %w", err)
 	}
@@ -564,7 +760,44 @@ func (a *Agent) finalizeNode(ctx context.Context, state *core.BaseState) (*core.
 	// Add final message to conversation
 	a.conversation.AddMessage(resp.Choices[0].Message)
 
-	a.logger.WithField("output", output).Info("Agent finalization completed")
+	logging.FromContext(ctx, a.logger).WithField("output", output).Info("Agent finalization completed")
+	return state, nil
+}
+
+// finalizeAtDeadline asks the model for its best answer so far once the soft deadline has
+// passed, falling back to the latest reasoning when that turn fails or times out
+func (a *Agent) finalizeAtDeadline(ctx context.Context, state *core.BaseState) (*core.BaseState, error) {
+	messages := withLocaleInstruction(ctx, append(a.buildFinalizationMessages(state), llm.Message{
+		Role:    "user",
+		Content: bestAnswerPrompt,
+	}))
+
+	req := llm.CompletionRequest{
+		Messages:    messages,
+		Model:       a.config.Model,
+		Temperature: a.temperature(ctx),
+		MaxTokens:   a.config.MaxTokens,
+		Seed:        a.seed(ctx),
+	}
+
+	finalCtx, cancel := finalAnswerContext(ctx)
+	defer cancel()
+
+	var output string
+	resp, err := a.llmManager.Complete(finalCtx, a.config.Provider, a.fitContextWindow(ctx, req))
+	if err == nil && len(resp.Choices) > 0 {
+		output = resp.Choices[0].Message.Content
+		a.conversation.AddMessage(resp.Choices[0].Message)
+	} else {
+		reasoning, _ := state.Get("reasoning")
+		output = fmt.Sprintf("%v", reasoning)
+		logging.FromContext(ctx, a.logger).WithError(err).Warn("Best-answer turn failed, returning latest reasoning")
+	}
+
+	state.Set("output", output)
+	state.Set("deadline_truncated", true)
+
+	logging.FromContext(ctx, a.logger).WithField("output", output).Info("Agent finalized at soft deadline")
 	return state, nil
 }
 
@@ -573,41 +806,48 @@ func (a *Agent) chatNode(ctx context.Context, state *core.BaseState) (*core.Base
 	messages := a.conversation.GetMessages()
 
 	// Add system prompt if configured
-	if a.config.SystemPrompt != "" {
+	if a.hasSystemPrompt() {
 		systemMsg := llm.Message{
 			Role:    "system",
-			Content: a.config.SystemPrompt,
+			Content: a.systemPromptFor(state),
 		}
 		messages = append([]llm.Message{systemMsg}, messages...)
 	}
 
-	// Add tools if available
-	var toolDefs []llm.ToolDefinition
-	for _, toolName := range a.config.Tools {
-		if tool, exists := a.toolRegistry.GetTool(toolName); exists {
-			toolDefs = append(toolDefs, tool.GetDefinition())
-		}
+	// Add tools if available, with their usage guidance
+	toolDefs := a.toolRegistry.GetDefinitions(a.config.Tools)
+
+	// Fall back to describing tools in the prompt when the model lacks native tool calling
+	nativeTools := a.supportsNativeTools()
+	if len(toolDefs) > 0 && !nativeTools {
+		messages = append([]llm.Message{{Role: "system", Content: a.toolRegistry.ToolPrompt(a.config.Tools)}}, messages...)
 	}
+	messages = withLocaleInstruction(ctx, a.assembleContext(messages))
 
 	req := llm.CompletionRequest{
 		Messages:    messages,
 		Model:       a.config.Model,
-		Temperature: a.config.Temperature,
+		Temperature: a.temperature(ctx),
 		MaxTokens:   a.config.MaxTokens,
-		Tools:       toolDefs,
+		Seed:        a.seed(ctx),
 		Stream:      a.config.EnableStreaming,
 	}
+	if nativeTools {
+		req.Tools = toolDefs
+	}
 
-	var resp *llm.CompletionResponse
-	var err error
-
-	// Use streaming mode if enabled
-	if a.config.EnableStreaming {
-		resp, err = a.llmManager.CompleteWithMode(ctx, a.config.Provider, req, a.config.StreamingMode)
-	} else {
-		resp, err = a.llmManager.Complete(ctx, a.config.Provider, req)
+	// Stream tokens to the caller of Stream, or use streaming mode if enabled
+	complete := func(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+		if isStreamed(ctx) {
+			return a.completeStreamed(ctx, req)
+		}
+		if a.config.EnableStreaming {
+			return a.llmManager.CompleteWithMode(ctx, a.config.Provider, req, a.config.StreamingMode)
+		}
+		return a.llmManager.Complete(ctx, a.config.Provider, req)
 	}
 
+	resp, err := a.completeAnswer(ctx, req, complete)
 	if err != nil {
 		return nil, fmt.Errorf("chat failed: %w", err)
 	}
@@ -618,12 +858,20 @@ func (a *Agent) chatNode(ctx context.Context, state *core.BaseState) (*core.Base
 
 	message := resp.Choices[0].Message
 
+	toolCalls := message.ToolCalls
+	if len(toolCalls) == 0 && len(toolDefs) > 0 && !nativeTools {
+		toolCalls = a.parseToolCalls(message.Content)
+	}
+
 	// Handle tool calls if present
-	if len(message.ToolCalls) > 0 {
+	if len(toolCalls) > 0 {
 		var toolResults []string
-		for _, toolCall := range message.ToolCalls {
+		for _, toolCall := range toolCalls {
 			if tool, exists := a.toolRegistry.GetTool(toolCall.Function.Name); exists {
-				result, err := tool.Execute(ctx, toolCall.Function.Arguments)
+				if err := countToolCall(ctx, toolCall); err != nil {
+					return nil, err
+				}
+				result, err := a.executeTool(ctx, tool, toolCall.Function.Arguments)
 				if err != nil {
 					toolResults = append(toolResults, fmt.Sprintf("Error: %v", err))
 				} else {
@@ -637,11 +885,11 @@ func (a *Agent) chatNode(ctx context.Context, state *core.BaseState) (*core.Base
 			a.conversation.AddMessage(llm.Message{
 				Role:       "tool",
 				Content:    result,
-				ToolCallID: message.ToolCalls[i].ID,
+				ToolCallID: toolCalls[i].ID,
 			})
 		}
 
-		state.Set("tool_calls", message.ToolCalls)
+		state.Set("tool_calls", toolCalls)
 	}
 
 	output := message.Content
@@ -650,7 +898,7 @@ func (a *Agent) chatNode(ctx context.Context, state *core.BaseState) (*core.Base
 	// Add assistant message to conversation
 	a.conversation.AddMessage(message)
 
-	a.logger.WithField("output", output).Info("Agent chat completed")
+	logging.FromContext(ctx, a.logger).WithField("output", output).Info("Agent chat completed")
 	return state, nil
 }
 
@@ -673,8 +921,9 @@ Create a step-by-step plan.`, input, strings.Join(a.config.Tools, ", "))
 	req := llm.CompletionRequest{
 		Messages:    messages,
 		Model:       a.config.Model,
-		Temperature: a.config.Temperature,
+		Temperature: a.temperature(ctx),
 		MaxTokens:   a.config.MaxTokens,
+		Seed:        a.seed(ctx),
 	}
 
 	resp, err := a.llmManager.Complete(ctx, a.config.Provider, req)
@@ -688,8 +937,9 @@ Create a step-by-step plan.`, input, strings.Join(a.config.Tools, ", "))
 
 	plan := resp.Choices[0].Message.Content
 	state.Set("plan", plan)
+	emitStreamEvent(ctx, StreamEvent{Type: StreamEventThought, Content: plan})
 
-	a.logger.WithField("plan", plan).Info("Agent planning completed")
+	logging.FromContext(ctx, a.logger).WithField("plan", plan).Info("Agent planning completed")
 	return state, nil
 }
 
@@ -709,8 +959,11 @@ func (a *Agent) executeToolsNode(ctx context.Context, state *core.BaseState) (*c
 			results = append(results, fmt.Sprintf("Tool %s not found", toolCall.Function.Name))
 			continue
 		}
+		if err := countToolCall(ctx, toolCall); err != nil {
+			return nil, err
+		}
 
-		result, err := tool.Execute(ctx, toolCall.Function.Arguments)
+		result, err := a.executeTool(ctx, tool, toolCall.Function.Arguments)
 		if err != nil {
 			results = append(results, fmt.Sprintf("Tool %s failed: %v", toolCall.Function.Name, err))
 		} else {
@@ -723,7 +976,7 @@ func (a *Agent) executeToolsNode(ctx context.Context, state *core.BaseState) (*c
 	state.Set("execution_results", results)
 	state.Set("tool_calls", executedCalls)
 
-	a.logger.WithField("tool_calls", len(executedCalls)).Info("Agent tool execution completed")
+	logging.FromContext(ctx, a.logger).WithField("tool_calls", len(executedCalls)).Info("Agent tool execution completed")
 	return state, nil
 }
 
@@ -742,12 +995,14 @@ Determine if the task is complete or if more actions are needed.`, input, result
 		{Role: "system", Content: "You are a review agent. Assess if tasks have been completed successfully."},
 		{Role: "user", Content: reviewPrompt},
 	}
+	messages = withLocaleInstruction(ctx, messages)
 
 	req := llm.CompletionRequest{
 		Messages:    messages,
 		Model:       a.config.Model,
-		Temperature: a.config.Temperature,
+		Temperature: a.temperature(ctx),
 		MaxTokens:   a.config.MaxTokens,
+		Seed:        a.seed(ctx),
 	}
 
 	resp, err := a.llmManager.Complete(ctx, a.config.Provider, req)
@@ -763,13 +1018,17 @@ Determine if the task is complete or if more actions are needed.`, input, result
 	state.Set("review", review)
 	state.Set("output", review)
 
-	a.logger.WithField("review", review).Info("Agent review completed")
+	logging.FromContext(ctx, a.logger).WithField("review", review).Info("Agent review completed")
 	return state, nil
 }
 
 // Edge condition functions
 
 func (a *Agent) shouldAct(ctx context.Context, state *core.BaseState) (string, error) {
+	if softDeadlineReached(ctx) || stoppedBy(state) != "" {
+		return "", nil
+	}
+
 	reasoning, _ := state.Get("reasoning")
 	reasoningStr := fmt.Sprintf("%v", reasoning)
 
@@ -784,6 +1043,10 @@ func (a *Agent) shouldAct(ctx context.Context, state *core.BaseState) (string, e
 }
 
 func (a *Agent) shouldFinalize(ctx context.Context, state *core.BaseState) (string, error) {
+	if softDeadlineReached(ctx) || stoppedBy(state) != "" {
+		return "finalize", nil
+	}
+
 	iteration, _ := state.Get("iteration")
 	maxIterations, _ := state.Get("max_iterations")
 
@@ -809,6 +1072,10 @@ func (a *Agent) shouldFinalize(ctx context.Context, state *core.BaseState) (stri
 }
 
 func (a *Agent) shouldContinueReasoning(ctx context.Context, state *core.BaseState) (string, error) {
+	if softDeadlineReached(ctx) || stoppedBy(state) != "" {
+		return "", nil
+	}
+
 	iteration, _ := state.Get("iteration")
 	maxIterations, _ := state.Get("max_iterations")
 
@@ -839,13 +1106,67 @@ func (a *Agent) shouldReplan(ctx context.Context, state *core.BaseState) (string
 
 // Helper functions
 
+// executeTool runs a single tool with correlated logging and applies the registry sanitizer;
+// the registry caps the result size
+func (a *Agent) executeTool(ctx context.Context, tool tools.Tool, arguments string) (string, error) {
+	arguments, err := a.repairToolArguments(ctx, tool.GetName(), arguments)
+	if err != nil {
+		return "", err
+	}
+
+	// A failed node must not be retried once it ran a tool that is unsafe to repeat
+	if !a.toolRegistry.Retryable(tool.GetName()) {
+		core.MarkNonIdempotent(ctx, "tool "+tool.GetName())
+	}
+
+	emitStreamEvent(ctx, StreamEvent{Type: StreamEventToolCall, ToolName: tool.GetName(), Arguments: arguments})
+
+	start := time.Now()
+	result, err := a.toolRegistry.ExecuteTool(ctx, tool, arguments)
+
+	entry := logging.FromContext(ctx, a.logger).WithFields(logrus.Fields{
+		"tool":     tool.GetName(),
+		"duration": time.Since(start),
+	})
+	if err != nil {
+		entry.WithError(err).Warn("Tool execution failed")
+		emitStreamEvent(ctx, StreamEvent{Type: StreamEventToolResult, ToolName: tool.GetName(), Err: err})
+		return result, err
+	}
+	entry.WithField("result_bytes", len(result)).Debug("Tool execution completed")
+
+	// Guard the prompt against instructions injected in the result
+	result, err = a.toolRegistry.SanitizeResult(ctx, tool.GetName(), result)
+	if err == nil {
+		result, _, err = a.screenContent(ctx, InjectionSourceToolResult, tool.GetName(), result)
+	}
+	emitStreamEvent(ctx, StreamEvent{Type: StreamEventToolResult, ToolName: tool.GetName(), Content: result, Err: err})
+	return result, err
+}
+
+// repairToolArguments fixes almost-valid JSON arguments emitted by smaller models
+func (a *Agent) repairToolArguments(ctx context.Context, toolName, arguments string) (string, error) {
+	repaired, changed, err := tools.RepairJSON(arguments)
+	if err != nil {
+		return "", fmt.Errorf("invalid arguments for tool %s: %w", toolName, err)
+	}
+	if changed {
+		logging.FromContext(ctx, a.logger).WithFields(logrus.Fields{
+			"tool":      toolName,
+			"arguments": arguments,
+			"repaired":  repaired,
+		}).Info("Repaired malformed tool arguments")
+	}
+	return repaired, nil
+}
+
 func (a *Agent) buildReasoningMessages(state *core.BaseState) []llm.Message {
 	messages := []llm.Message{}
 
-	if a.config.SystemPrompt != "" {
+	if a.hasSystemPrompt() {
 		messages = append(messages, llm.Message{
 			Role:    "system",
-			Content: a.config.SystemPrompt,
+			Content: a.systemPromptFor(state),
 		})
 	} else {
 		messages = append(messages, llm.Message{
@@ -863,10 +1184,10 @@ Final Answer: [your final response]`,
 		})
 	}
 
-	// Add conversation history
+	// Add retrieved context and conversation history
 	messages = append(messages, a.conversation.GetMessages()...)
 
-	return messages
+	return a.assembleContext(messages)
 }
 
 func (a *Agent) buildFinalizationMessages(state *core.BaseState) []llm.Message {
@@ -877,10 +1198,19 @@ func (a *Agent) buildFinalizationMessages(state *core.BaseState) []llm.Message {
 		},
 	}
 
-	// Add conversation history
+	// Add retrieved context and conversation history
 	messages = append(messages, a.conversation.GetMessages()...)
 
-	return messages
+	return a.assembleContext(messages)
+}
+
+// supportsNativeTools reports whether the configured model accepts structured tool definitions
+func (a *Agent) supportsNativeTools() bool {
+	capabilities, err := a.llmManager.ModelCapabilities(a.config.Provider, a.config.Model)
+	if err != nil {
+		return true
+	}
+	return capabilities.SupportsTools
 }
 
 func (a *Agent) parseToolCalls(text string) []llm.ToolCall {
@@ -891,6 +1221,15 @@ func (a *Agent) parseToolCalls(text string) []llm.ToolCall {
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 
+		// Attach "Action Input: {...}" arguments to the preceding tool call
+		if strings.HasPrefix(strings.ToLower(line), "action input:") {
+			arguments := strings.TrimSpace(line[len("action input:"):])
+			if repaired, _, err := tools.RepairJSON(arguments); len(toolCalls) > 0 && arguments != "" && err == nil {
+				toolCalls[len(toolCalls)-1].Function.Arguments = repaired
+			}
+			continue
+		}
+
 		// Look for "Action: tool_name" or "Tool: tool_name"
 		if strings.HasPrefix(strings.ToLower(line), "action:") ||
 			strings.HasPrefix(strings.ToLower(line), "tool:") {
@@ -935,14 +1274,43 @@ func (a *Agent) UpdateConfig(config *AgentConfig) {
 	defer a.mu.Unlock()
 
 	a.config = config
+	a.rateLimiter = newRateLimiter(config.RateLimit)
 	a.buildGraph() // Rebuild graph with new config
 }
 
+// checkRateLimit rejects an execution when the agent's rate limit is exceeded
+func (a *Agent) checkRateLimit(ctx context.Context) error {
+	a.mu.RLock()
+	limiter, config := a.rateLimiter, a.config
+	a.mu.RUnlock()
+
+	if limiter == nil {
+		return nil
+	}
+	key := ""
+	if config.RateLimit.PerSession {
+		key = logging.SessionID(ctx)
+	}
+	if allowed, retryAfter := limiter.allow(key, time.Now()); !allowed {
+		logging.FromContext(ctx, a.logger).WithFields(logrus.Fields{
+			"agent_name":  config.Name,
+			"retry_after": retryAfter,
+		}).Warn("Agent rate limit exceeded")
+		return &RateLimitError{Agent: config.Name, RetryAfter: retryAfter}
+	}
+	return nil
+}
+
 // GetConversation returns the conversation history
 func (a *Agent) GetConversation() []llm.Message {
 	return a.conversation.GetMessages()
 }
 
+// PinMessage pins or unpins a conversation message by index so context trimming keeps it
+func (a *Agent) PinMessage(index int, pinned bool) error {
+	return a.conversation.SetPinned(index, pinned)
+}
+
 // ClearConversation clears the conversation history
 func (a *Agent) ClearConversation() {
 	a.conversation.Clear()
@@ -965,6 +1333,20 @@ func (a *Agent) IsRunning() bool {
 	return a.isRunning
 }
 
+// SetLogger sets the logger used for agent logs
+func (a *Agent) SetLogger(logger *logrus.Logger) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.logger = logger
+}
+
+// GetLogger returns the logger used for agent logs
+func (a *Agent) GetLogger() *logrus.Logger {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.logger
+}
+
 // GetGraph returns the agent's execution graph
 func (a *Agent) GetGraph() *core.Graph {
 	return a.graph
@@ -1081,25 +1463,40 @@ func (mac *MultiAgentCoordinator) ListAgents() []string {
 
 // ExecuteSequential executes agents sequentially, passing output to the next
 func (mac *MultiAgentCoordinator) ExecuteSequential(ctx context.Context, agentIDs []string, initialInput string) ([]AgentExecution, error) {
+	executions, _, err := mac.ExecuteSequentialUntil(ctx, agentIDs, initialInput, nil)
+	return executions, err
+}
+
+// ExecuteSequentialUntil executes agents sequentially and stops early when shouldContinue
+// returns false. It returns the index of the agent that halted the sequence, or -1 if all agents ran.
+func (mac *MultiAgentCoordinator) ExecuteSequentialUntil(ctx context.Context, agentIDs []string, initialInput string, shouldContinue func(index int, execution AgentExecution) bool) ([]AgentExecution, int, error) {
 	var executions []AgentExecution
 	currentInput := initialInput
 
-	for _, agentID := range agentIDs {
+	for i, agentID := range agentIDs {
 		agent, exists := mac.GetAgent(agentID)
 		if !exists {
-			return executions, fmt.Errorf("agent %s not found", agentID)
+			return executions, -1, fmt.Errorf("agent %s not found", agentID)
 		}
 
 		execution, err := agent.Execute(ctx, currentInput)
 		if err != nil {
-			return executions, fmt.Errorf("agent %s failed: %w", agentID, err)
+			return executions, -1, fmt.Errorf("agent %s failed: %w", agentID, err)
 		}
 
 		executions = append(executions, *execution)
 		currentInput = execution.Output // Use output as input for next agent
+
+		if shouldContinue != nil && i < len(agentIDs)-1 && !shouldContinue(i, *execution) {
+			mac.logger.WithFields(logrus.Fields{
+				"agent": agentID,
+				"stage": i,
+			}).Info("Sequential execution short-circuited")
+			return executions, i, nil
+		}
 	}
 
-	return executions, nil
+	return executions, -1, nil
 }
 
 // ExecuteParallel executes agents in parallel with the same input
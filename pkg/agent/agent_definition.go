@@ -339,6 +339,12 @@ func (adb *AgentDefinitionBuilder) WithSystemPrompt(prompt string) *AgentDefinit
 	return adb
 }
 
+// WithPromptComposer composes the system prompt from fragments
+func (adb *AgentDefinitionBuilder) WithPromptComposer(composer *PromptComposer) *AgentDefinitionBuilder {
+	adb.config.PromptComposer = composer
+	return adb
+}
+
 // WithTemperature sets the temperature
 func (adb *AgentDefinitionBuilder) WithTemperature(temperature float64) *AgentDefinitionBuilder {
 	adb.config.Temperature = temperature
@@ -7,10 +7,20 @@
 package agent
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/sirupsen/logrus"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
 )
 
@@ -74,6 +84,10 @@ func (m *mockProvider) CompleteStreamWithMode(ctx context.Context, req llm.Compl
 
 func (m *mockProvider) SupportsStreaming() bool { return true }
 
+func (m *mockProvider) Capabilities() llm.ProviderCapabilities {
+	return llm.ProviderCapabilities{SupportsStreaming: true, SupportsTools: true, MaxContextTokens: 4096}
+}
+
 func (m *mockProvider) GetStreamingConfig() *llm.StreamingConfig {
 	return &llm.StreamingConfig{
 		Mode:      llm.StreamModeNone,
@@ -200,6 +214,85 @@ func TestAgent_Execute(t *testing.T) {
 	}
 }
 
+func TestAgent_ExecuteCorrelatedLogging(t *testing.T) {
+	agent := createTestAgent(t, AgentTypeChat)
+
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	agent.SetLogger(logger)
+
+	ctx := logging.WithSessionID(context.Background(), "session-42")
+	execution, err := agent.Execute(ctx, "Hello")
+	if err != nil {
+		t.Fatalf("Execute() should not return an error, got: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) == 0 {
+		t.Fatal("Expected log output")
+	}
+
+	for _, line := range lines {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Failed to parse log line %q: %v", line, err)
+		}
+		if entry[logging.FieldSessionID] != "session-42" {
+			t.Errorf("Expected session_id on every entry, got %v", entry)
+		}
+		if entry[logging.FieldExecutionID] != execution.ID {
+			t.Errorf("Expected execution_id %s on every entry, got %v", execution.ID, entry)
+		}
+	}
+}
+
+func TestAgent_PromptInjectedToolsWithoutNativeSupport(t *testing.T) {
+	provider := &sequenceProvider{messages: []llm.Message{{
+		Role:    "assistant",
+		Content: "Action: calculator\nAction Input: {\"expression\": \"2+3\"}",
+	}}}
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", provider); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+	llmManager.SetModelCapabilities("mock", "test-model", llm.ProviderCapabilities{SupportsStreaming: true})
+
+	agent := NewAgent(&AgentConfig{
+		Name:     "test-agent",
+		Type:     AgentTypeChat,
+		Provider: "mock",
+		Model:    "test-model",
+		Tools:    []string{"calculator"},
+	}, llmManager, tools.NewToolRegistry())
+
+	if _, err := agent.Execute(context.Background(), "What is 2+3?"); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	if len(provider.requests) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(provider.requests))
+	}
+	req := provider.requests[0]
+	if len(req.Tools) != 0 {
+		t.Error("Tools should not be sent to a model without native tool support")
+	}
+	if req.Messages[0].Role != "system" || !strings.Contains(req.Messages[0].Content, "calculator") {
+		t.Errorf("Expected tool descriptions in system prompt, got %+v", req.Messages[0])
+	}
+
+	var toolResult string
+	for _, msg := range agent.GetConversation() {
+		if msg.Role == "tool" {
+			toolResult = msg.Content
+		}
+	}
+	if toolResult != "Result: 5" {
+		t.Errorf("Expected parsed tool call to run, got %q", toolResult)
+	}
+}
+
 func TestAgent_GetConversation(t *testing.T) {
 	agent := createTestAgent(t, AgentTypeChat)
 
@@ -346,6 +439,559 @@ func TestMultiAgentCoordinator(t *testing.T) {
 	}
 }
 
+func TestAgent_ToolResultLimit(t *testing.T) {
+	provider := &sequenceProvider{messages: []llm.Message{{
+		Role: "assistant",
+		ToolCalls: []llm.ToolCall{{
+			ID:       "call-1",
+			Type:     "function",
+			Function: llm.FunctionCall{Name: "calculator", Arguments: `{"expression": "2+3"}`},
+		}},
+	}}}
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", provider); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+	toolRegistry := tools.NewToolRegistry()
+	toolRegistry.SetToolMaxResultBytes("calculator", 6)
+
+	agent := NewAgent(&AgentConfig{
+		Name:     "test-agent",
+		Type:     AgentTypeChat,
+		Provider: "mock",
+		Model:    "test-model",
+		Tools:    []string{"calculator"},
+	}, llmManager, toolRegistry)
+
+	if _, err := agent.Execute(context.Background(), "What is 2+3?"); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	var toolResult string
+	for _, msg := range agent.GetConversation() {
+		if msg.Role == "tool" {
+			toolResult = msg.Content
+		}
+	}
+	if toolResult != "Result\n... [truncated 3 bytes]" {
+		t.Errorf("Expected truncated tool result, got %q", toolResult)
+	}
+}
+
+func TestAgent_RepairsMalformedToolArguments(t *testing.T) {
+	provider := &sequenceProvider{messages: []llm.Message{{
+		Role: "assistant",
+		ToolCalls: []llm.ToolCall{{
+			ID:       "call-1",
+			Type:     "function",
+			Function: llm.FunctionCall{Name: "calculator", Arguments: `{expression: '2+3',}`},
+		}},
+	}}}
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", provider); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+
+	agent := NewAgent(&AgentConfig{
+		Name:     "test-agent",
+		Type:     AgentTypeChat,
+		Provider: "mock",
+		Model:    "test-model",
+		Tools:    []string{"calculator"},
+	}, llmManager, tools.NewToolRegistry())
+
+	if _, err := agent.Execute(context.Background(), "What is 2+3?"); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	var toolResult string
+	for _, msg := range agent.GetConversation() {
+		if msg.Role == "tool" {
+			toolResult = msg.Content
+		}
+	}
+	if toolResult != "Result: 5" {
+		t.Errorf("Expected the repaired tool call to run, got %q", toolResult)
+	}
+}
+
+// slowReActProvider keeps requesting tools until it is asked for its best answer
+type slowReActProvider struct {
+	mockProvider
+	delay    time.Duration
+	requests int
+}
+
+func (p *slowReActProvider) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	p.requests++
+	time.Sleep(p.delay)
+	content := "Thought: I need to calculate.\nAction: calculator\nAction Input: {\"expression\": \"2+3\"}"
+	if last := req.Messages[len(req.Messages)-1]; last.Content == bestAnswerPrompt {
+		content = "Best guess: 5"
+	}
+	return &llm.CompletionResponse{
+		Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: content}, FinishReason: "stop"}},
+	}, nil
+}
+
+func TestAgent_SoftDeadline(t *testing.T) {
+	provider := &slowReActProvider{delay: 20 * time.Millisecond}
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", provider); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+
+	agent := NewAgent(&AgentConfig{
+		Name:          "researcher",
+		Type:          AgentTypeReAct,
+		Provider:      "mock",
+		Model:         "test-model",
+		MaxIterations: 50,
+		Tools:         []string{"calculator"},
+	}, llmManager, tools.NewToolRegistry())
+
+	execution, err := agent.ExecuteWithOptions(context.Background(), "What is 2+3?", &ExecuteOptions{SoftDeadline: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("ExecuteWithOptions() failed: %v", err)
+	}
+	if !execution.DeadlineTruncated {
+		t.Error("Expected the execution to be marked as deadline-truncated")
+	}
+	if execution.Output != "Best guess: 5" {
+		t.Errorf("Expected the best-answer turn as output, got %q", execution.Output)
+	}
+	if provider.requests > 10 {
+		t.Errorf("Expected the loop to stop near the deadline, got %d requests", provider.requests)
+	}
+}
+
+func TestAgent_RateLimit(t *testing.T) {
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", &mockProvider{response: "report"}); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+
+	agent := NewAgent(&AgentConfig{
+		Name:      "reporter",
+		Type:      AgentTypeChat,
+		Provider:  "mock",
+		Model:     "test-model",
+		RateLimit: &AgentRateLimit{RequestsPerMinute: 60, Burst: 2, PerSession: true},
+	}, llmManager, tools.NewToolRegistry())
+
+	ctx := logging.WithSessionID(context.Background(), "session-1")
+	for i := 0; i < 2; i++ {
+		if _, err := agent.Execute(ctx, "report"); err != nil {
+			t.Fatalf("Execution %d within the burst failed: %v", i+1, err)
+		}
+	}
+
+	_, err := agent.Execute(ctx, "report")
+	var rateLimitErr *RateLimitError
+	if !errors.Is(err, ErrRateLimited) || !errors.As(err, &rateLimitErr) {
+		t.Fatalf("Expected ErrRateLimited, got %v", err)
+	}
+	if rateLimitErr.RetryAfter <= 0 || rateLimitErr.RetryAfter > time.Second {
+		t.Errorf("Expected a retry-after of at most one second, got %v", rateLimitErr.RetryAfter)
+	}
+
+	// Other sessions have their own budget
+	if _, err := agent.Execute(logging.WithSessionID(context.Background(), "session-2"), "report"); err != nil {
+		t.Errorf("Expected another session to be allowed, got %v", err)
+	}
+}
+
+func TestRateLimiter_Refill(t *testing.T) {
+	limiter := newRateLimiter(&AgentRateLimit{RequestsPerMinute: 60})
+	now := time.Now()
+
+	if allowed, _ := limiter.allow("", now); !allowed {
+		t.Fatal("Expected the first request to be allowed")
+	}
+	if allowed, wait := limiter.allow("", now.Add(500*time.Millisecond)); allowed || wait != 500*time.Millisecond {
+		t.Errorf("Expected to wait 500ms for the next token, got allowed=%v wait=%v", allowed, wait)
+	}
+	if allowed, _ := limiter.allow("", now.Add(time.Second)); !allowed {
+		t.Error("Expected the bucket to refill after a second")
+	}
+	if newRateLimiter(nil) != nil || newRateLimiter(&AgentRateLimit{}) != nil {
+		t.Error("Expected no limiter without a rate")
+	}
+}
+
+func TestMultiAgentCoordinator_ExecuteSequentialUntil(t *testing.T) {
+	coordinator := NewMultiAgentCoordinator()
+	for _, id := range []string{"moderator", "writer", "editor"} {
+		coordinator.AddAgent(id, createTestAgent(t, AgentTypeChat))
+	}
+	agentIDs := []string{"moderator", "writer", "editor"}
+
+	var checked []int
+	executions, haltedAt, err := coordinator.ExecuteSequentialUntil(context.Background(), agentIDs, "input",
+		func(index int, execution AgentExecution) bool {
+			checked = append(checked, index)
+			return index < 1
+		})
+	if err != nil {
+		t.Fatalf("ExecuteSequentialUntil() failed: %v", err)
+	}
+	if haltedAt != 1 || len(executions) != 2 {
+		t.Errorf("Expected halt at stage 1 after 2 executions, got stage %d after %d", haltedAt, len(executions))
+	}
+	if len(checked) != 2 {
+		t.Errorf("Expected 2 checks, got %v", checked)
+	}
+
+	executions, haltedAt, err = coordinator.ExecuteSequentialUntil(context.Background(), agentIDs, "input", nil)
+	if err != nil || haltedAt != -1 || len(executions) != 3 {
+		t.Errorf("Expected all stages to run, got stage %d, %d executions, err %v", haltedAt, len(executions), err)
+	}
+}
+
+// prefixDetector detects French by a greeting and English otherwise
+type prefixDetector struct{}
+
+func (prefixDetector) DetectLanguage(ctx context.Context, text string) (string, error) {
+	if strings.HasPrefix(text, "Bonjour") {
+		return "fr", nil
+	}
+	return "en", nil
+}
+
+func TestAgent_Locale(t *testing.T) {
+	provider := &sequenceProvider{messages: []llm.Message{
+		{Role: "assistant", Content: "Hello, how can I help?"},
+		{Role: "assistant", Content: "Bonjour, comment puis-je aider ?"},
+	}}
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", provider); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+
+	agent := NewAgent(&AgentConfig{
+		Name:          "support",
+		Type:          AgentTypeChat,
+		Provider:      "mock",
+		Model:         "test-model",
+		Locale:        "de",
+		LocaleRetries: 1,
+	}, llmManager, tools.NewToolRegistry())
+	agent.SetLanguageDetector(prefixDetector{})
+
+	execution, err := agent.ExecuteWithOptions(context.Background(), "Hi!", &ExecuteOptions{Locale: "fr-FR", LocaleRetries: 1})
+	if err != nil {
+		t.Fatalf("ExecuteWithOptions() failed: %v", err)
+	}
+
+	messages := provider.requests[0].Messages
+	instruction := messages[len(messages)-1]
+	if instruction.Role != "system" || !strings.Contains(instruction.Content, "French (fr-FR)") {
+		t.Errorf("Expected a final instruction to respond in French, got %+v", instruction)
+	}
+	if len(provider.requests) != 2 || execution.Output != "Bonjour, comment puis-je aider ?" {
+		t.Errorf("Expected the English answer to be rewritten in French, got %q after %d requests", execution.Output, len(provider.requests))
+	}
+	if execution.Metadata["locale"] != "fr-FR" || execution.Metadata["locale_mismatch"] != nil {
+		t.Errorf("Unexpected locale metadata %v", execution.Metadata)
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := map[string]string{
+		"":                            "",
+		"*":                           "",
+		"fr-CH, fr;q=0.9, en;q=0.8":   "fr-CH",
+		"en;q=0.5, de;q=0.9, *;q=0.1": "de",
+		"es;q=0":                      "",
+	}
+	for header, expected := range tests {
+		if got := ParseAcceptLanguage(header); got != expected {
+			t.Errorf("ParseAcceptLanguage(%q) = %q, expected %q", header, got, expected)
+		}
+	}
+}
+
+// streamingProvider streams a tool call in fragments, then the answer token by token
+type streamingProvider struct {
+	mockProvider
+	requests int
+}
+
+func (p *streamingProvider) CompleteStream(ctx context.Context, req llm.CompletionRequest, callback llm.StreamCallback) error {
+	p.requests++
+	var deltas []llm.Message
+	if p.requests == 1 {
+		deltas = []llm.Message{
+			{ToolCalls: []llm.ToolCall{{ID: "call-1", Type: "function", Function: llm.FunctionCall{Name: "calculator", Arguments: `{"expr`}}}},
+			{ToolCalls: []llm.ToolCall{{Function: llm.FunctionCall{Arguments: `ession": "2+3"}`}}}},
+		}
+	} else {
+		deltas = []llm.Message{{Content: "The answer "}, {Content: "is 5"}}
+	}
+	for _, delta := range deltas {
+		if err := callback(llm.CompletionResponse{Choices: []llm.Choice{{Delta: delta}}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestAgent_Stream(t *testing.T) {
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", &streamingProvider{}); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+	agent := NewAgent(&AgentConfig{
+		Name:     "test-agent",
+		Type:     AgentTypeChat,
+		Provider: "mock",
+		Model:    "test-model",
+		Tools:    []string{"calculator"},
+	}, llmManager, tools.NewToolRegistry())
+
+	events, err := agent.Stream(context.Background(), "What is 2+3?")
+	if err != nil {
+		t.Fatalf("Stream() failed: %v", err)
+	}
+
+	var types []string
+	for event := range events {
+		types = append(types, string(event.Type))
+		switch event.Type {
+		case StreamEventToolCall:
+			if event.ToolName != "calculator" || event.Arguments != `{"expression": "2+3"}` {
+				t.Errorf("Expected the assembled tool call, got %+v", event)
+			}
+		case StreamEventToolResult:
+			if !strings.HasPrefix(event.Content, "Result") || event.Err != nil {
+				t.Errorf("Expected the tool result, got %+v", event)
+			}
+		case StreamEventDone:
+			if event.Execution == nil || !event.Execution.Success {
+				t.Errorf("Expected the execution on the done event, got %+v", event)
+			}
+		}
+	}
+	expected := []string{"tool_call", "tool_result", "done"}
+	if strings.Join(types, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected events %v, got %v", expected, types)
+	}
+
+	// The answer of the next execution arrives token by token
+	events, err = agent.Stream(context.Background(), "Thanks")
+	if err != nil {
+		t.Fatalf("Stream() failed: %v", err)
+	}
+	var tokens []string
+	var last StreamEvent
+	for event := range events {
+		if event.Type == StreamEventToken {
+			tokens = append(tokens, event.Content)
+		}
+		last = event
+	}
+	if strings.Join(tokens, "|") != "The answer |is 5" {
+		t.Errorf("Expected the answer token by token, got %v", tokens)
+	}
+	if last.Type != StreamEventDone || last.Content != "The answer is 5" {
+		t.Errorf("Expected the answer on the done event, got %+v", last)
+	}
+
+	// Executions that cannot start fail before streaming
+	config := *agent.GetConfig()
+	config.RateLimit = &AgentRateLimit{RequestsPerMinute: 1}
+	agent.UpdateConfig(&config)
+	if events, err := agent.Stream(context.Background(), "one"); err == nil {
+		for range events {
+		}
+	}
+	if _, err := agent.Stream(context.Background(), "two"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected the rate limit error, got %v", err)
+	}
+}
+
+func TestAgent_ExecuteWithCallback(t *testing.T) {
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", &streamingProvider{requests: 1}); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+	agent := NewAgent(&AgentConfig{
+		Name:     "test-agent",
+		Type:     AgentTypeChat,
+		Provider: "mock",
+		Model:    "test-model",
+	}, llmManager, tools.NewToolRegistry())
+
+	var tokens []string
+	execution, err := agent.ExecuteWithCallback(context.Background(), "What is 2+3?", func(token string) {
+		tokens = append(tokens, token)
+	})
+	if err != nil {
+		t.Fatalf("ExecuteWithCallback() failed: %v", err)
+	}
+	if strings.Join(tokens, "|") != "The answer |is 5" || execution.Output != "The answer is 5" {
+		t.Errorf("Expected the answer token by token, got %v and %q", tokens, execution.Output)
+	}
+
+	// Agents that do not stream tokens deliver the whole output at once
+	reactManager := llm.NewProviderManager()
+	if err := reactManager.RegisterProvider("mock", &mockProvider{response: "Thought: I know this.\nFinal Answer: 5"}); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+	react := NewAgent(&AgentConfig{
+		Name:     "test-agent",
+		Type:     AgentTypeReAct,
+		Provider: "mock",
+		Model:    "test-model",
+	}, reactManager, tools.NewToolRegistry())
+	tokens = nil
+	execution, err = react.ExecuteWithCallback(context.Background(), "Hello", func(token string) {
+		tokens = append(tokens, token)
+	})
+	if err != nil {
+		t.Fatalf("ExecuteWithCallback() failed: %v", err)
+	}
+	if len(tokens) != 1 || tokens[0] != execution.Output {
+		t.Errorf("Expected the output in one callback, got %v for %q", tokens, execution.Output)
+	}
+
+	// Failures are returned with their execution
+	failingManager := llm.NewProviderManager()
+	if err := failingManager.RegisterProvider("mock", &mockProvider{err: fmt.Errorf("provider down: %w", core.ErrPermanent)}); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+	failing := NewAgent(&AgentConfig{
+		Name:     "test-agent",
+		Type:     AgentTypeChat,
+		Provider: "mock",
+		Model:    "test-model",
+	}, failingManager, tools.NewToolRegistry())
+	if _, err := failing.ExecuteWithCallback(context.Background(), "Hello", func(string) {}); err == nil {
+		t.Error("Expected the execution error")
+	}
+}
+
+func TestAgent_EmptyResponsePolicy(t *testing.T) {
+	newAgent := func(policy EmptyResponsePolicy, messages ...llm.Message) (*Agent, *sequenceProvider) {
+		provider := &sequenceProvider{messages: messages}
+		llmManager := llm.NewProviderManager()
+		if err := llmManager.RegisterProvider("mock", provider); err != nil {
+			t.Fatalf("Failed to register provider: %v", err)
+		}
+		return NewAgent(&AgentConfig{
+			Name:                "test-agent",
+			Type:                AgentTypeChat,
+			Provider:            "mock",
+			Model:               "test-model",
+			EmptyResponsePolicy: policy,
+		}, llmManager, tools.NewToolRegistry()), provider
+	}
+	blank := llm.Message{Role: "assistant", Content: "  \n"}
+	answer := llm.Message{Role: "assistant", Content: "Hello"}
+
+	// Blank answers fail by default
+	agent, _ := newAgent("", blank)
+	_, err := agent.Execute(context.Background(), "Hi")
+	var emptyErr *EmptyResponseError
+	if !errors.Is(err, ErrEmptyResponse) || !errors.As(err, &emptyErr) {
+		t.Fatalf("Expected ErrEmptyResponse, got %v", err)
+	}
+	if emptyErr.FinishReason != "stop" || emptyErr.Blocked {
+		t.Errorf("Expected the finish reason of an unblocked response, got %+v", emptyErr)
+	}
+
+	// The retry policy asks once more
+	agent, provider := newAgent(EmptyResponseRetry, blank, answer)
+	execution, err := agent.Execute(context.Background(), "Hi")
+	if err != nil || execution.Output != "Hello" || len(provider.requests) != 2 {
+		t.Errorf("Expected the retried answer, got %v, %v after %d requests", execution, err, len(provider.requests))
+	}
+	agent, provider = newAgent(EmptyResponseRetry, blank)
+	if _, err := agent.Execute(context.Background(), "Hi"); !errors.Is(err, ErrEmptyResponse) || len(provider.requests) != 2 {
+		t.Errorf("Expected ErrEmptyResponse after one retry, got %v after %d requests", err, len(provider.requests))
+	}
+
+	// The allow policy keeps the blank answer
+	agent, _ = newAgent(EmptyResponseAllow, blank)
+	if _, err := agent.Execute(context.Background(), "Hi"); err != nil {
+		t.Errorf("Expected the blank answer to be allowed, got %v", err)
+	}
+}
+
+func TestAgent_ContentBlockPolicy(t *testing.T) {
+	newAgent := func(policy ContentBlockPolicy) (*Agent, *blockedProvider) {
+		provider := &blockedProvider{}
+		llmManager := llm.NewProviderManager()
+		if err := llmManager.RegisterProvider("mock", provider); err != nil {
+			t.Fatalf("Failed to register provider: %v", err)
+		}
+		return NewAgent(&AgentConfig{
+			Name:                "test-agent",
+			Type:                AgentTypeChat,
+			Provider:            "mock",
+			Model:               "test-model",
+			EmptyResponsePolicy: EmptyResponseRetry,
+			ContentBlockPolicy:  policy,
+		}, llmManager, tools.NewToolRegistry()), provider
+	}
+
+	// Blocked responses fail with the reason of the block, without an empty response retry
+	agent, provider := newAgent("")
+	_, err := agent.Execute(context.Background(), "Hi")
+	var blockedErr *llm.ContentBlockedError
+	if !errors.As(err, &blockedErr) || blockedErr.Provider != "mock" || blockedErr.Reason != "SAFETY" || provider.calls != 1 {
+		t.Fatalf("Expected a content blocked error without retry, got %v after %d calls", err, provider.calls)
+	}
+	if !errors.Is(err, llm.ErrContentBlocked) || !strings.Contains(err.Error(), "blocked") {
+		t.Errorf("Expected the error to explain the block, got %q", err.Error())
+	}
+
+	// The retry policy asks once more, within the content policy
+	agent, provider = newAgent(ContentBlockRetry)
+	provider.allowAfter = 1
+	execution, err := agent.Execute(context.Background(), "Hi")
+	if err != nil || execution.Output != "Rephrased" || provider.calls != 2 {
+		t.Fatalf("Expected the retry to answer, got %v after %d calls", err, provider.calls)
+	}
+	if last := provider.lastRequest.Messages[len(provider.lastRequest.Messages)-1]; last.Role != "system" || !strings.Contains(last.Content, "content policy") {
+		t.Errorf("Expected the retry to carry the content policy instruction, got %+v", last)
+	}
+
+	agent, provider = newAgent(ContentBlockRetry)
+	if _, err := agent.Execute(context.Background(), "Hi"); !errors.Is(err, llm.ErrContentBlocked) || provider.calls != 2 {
+		t.Errorf("Expected a single retry before failing, got %v after %d calls", err, provider.calls)
+	}
+
+	// The fallback policy answers with the canned message
+	agent, _ = newAgent(ContentBlockFallback)
+	execution, err = agent.Execute(context.Background(), "Hi")
+	if err != nil || execution.Output != DefaultContentBlockMessage {
+		t.Errorf("Expected the fallback message, got %v, %v", execution, err)
+	}
+
+	if err := ContentBlockPolicy("ignore").Validate(); err == nil {
+		t.Error("Expected an unknown policy to be rejected")
+	}
+}
+
+// blockedProvider answers requests with a response suppressed by a safety filter, then
+// with an answer once allowAfter requests were blocked
+type blockedProvider struct {
+	mockProvider
+	calls       int
+	allowAfter  int
+	lastRequest llm.CompletionRequest
+}
+
+func (p *blockedProvider) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	p.calls++
+	p.lastRequest = req
+	if p.allowAfter > 0 && p.calls > p.allowAfter {
+		return &llm.CompletionResponse{Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: "Rephrased"}, FinishReason: "stop"}}}, nil
+	}
+	return &llm.CompletionResponse{Choices: []llm.Choice{{Message: llm.Message{Role: "assistant"}, FinishReason: "SAFETY"}}}, nil
+}
+
 // Benchmark tests
 func BenchmarkAgent_Execute(b *testing.B) {
 	agent := createTestAgent(b, AgentTypeChat)
@@ -380,3 +1026,43 @@ func BenchmarkAgent_GetConversation(b *testing.B) {
 		agent.GetConversation()
 	}
 }
+
+func TestAgent_FitsContextWindow(t *testing.T) {
+	provider := &sequenceProvider{messages: []llm.Message{{Role: "assistant", Content: "Noted."}}}
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", provider); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+	llmManager.SetModelCapabilities("mock", "test-model", llm.ProviderCapabilities{MaxContextTokens: 600})
+
+	agent := NewAgent(&AgentConfig{
+		Name:      "test-agent",
+		Type:      AgentTypeChat,
+		Provider:  "mock",
+		Model:     "test-model",
+		MaxTokens: 200,
+	}, llmManager, tools.NewToolRegistry())
+	if agent.ContextWindow() != 600 {
+		t.Fatalf("Expected the model's context window, got %d", agent.ContextWindow())
+	}
+
+	var input string
+	for i := 0; i < 5; i++ {
+		input = fmt.Sprintf("Note %d: %s", i, strings.Repeat("word ", 80))
+		if _, err := agent.Execute(context.Background(), input); err != nil {
+			t.Fatalf("Execute() failed: %v", err)
+		}
+	}
+
+	last := provider.requests[len(provider.requests)-1]
+	if len(last.Messages) >= len(agent.GetConversation()) {
+		t.Errorf("Expected the history to be trimmed, sent %d of %d messages", len(last.Messages), len(agent.GetConversation()))
+	}
+	if last.Messages[len(last.Messages)-1].Content != input {
+		t.Error("The latest input should always be sent")
+	}
+	tokens, _ := llm.NewSimpleTokenCounter().CountMessagesTokens(last.Messages)
+	if tokens > 600-200 {
+		t.Errorf("Expected the prompt to leave room for the response, got %d tokens", tokens)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
+)
+
+// DefaultContentBlockMessage is the answer given for blocked content under the fallback
+// policy when the agent sets none
+const DefaultContentBlockMessage = "I'm sorry, but I can't help with that request."
+
+// ContentBlockPolicy decides what an agent does when the provider's content filter blocks
+// the request or the answer
+type ContentBlockPolicy string
+
+const (
+	// ContentBlockFailHard fails the execution with the llm.ContentBlockedError, carrying the
+	// reason and categories of the block; it is the default
+	ContentBlockFailHard ContentBlockPolicy = "fail"
+	// ContentBlockRetry asks the model once more to answer within the content policy, then
+	// fails if that is blocked too
+	ContentBlockRetry ContentBlockPolicy = "retry"
+	// ContentBlockFallback answers with the agent's ContentBlockMessage
+	ContentBlockFallback ContentBlockPolicy = "fallback"
+)
+
+// Validate checks that the policy is known; an empty policy fails hard
+func (p ContentBlockPolicy) Validate() error {
+	switch p {
+	case "", ContentBlockFailHard, ContentBlockRetry, ContentBlockFallback:
+		return nil
+	default:
+		return fmt.Errorf("unknown content block policy %q, expected fail, retry or fallback", p)
+	}
+}
+
+// handleContentBlocks wraps a completion so the agent's content block policy applies to the
+// blocks it reports
+func (a *Agent) handleContentBlocks(complete func(context.Context, llm.CompletionRequest) (*llm.CompletionResponse, error)) func(context.Context, llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	policy := a.config.ContentBlockPolicy
+	if policy == "" || policy == ContentBlockFailHard {
+		return complete
+	}
+
+	return func(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+		resp, err := complete(ctx, req)
+		var blocked *llm.ContentBlockedError
+		if err == nil || !errors.As(err, &blocked) {
+			return resp, err
+		}
+
+		logger := logging.FromContext(ctx, a.logger).WithFields(logrus.Fields{
+			"stage":      blocked.Stage,
+			"reason":     blocked.Reason,
+			"categories": blocked.Categories,
+		})
+		switch policy {
+		case ContentBlockRetry:
+			if !core.AllowRetry(ctx, core.RetryLayerAgent) {
+				return nil, err
+			}
+			logger.Warn("Content blocked by provider, retrying")
+			return complete(ctx, withContentPolicyInstruction(req, blocked))
+		case ContentBlockFallback:
+			logger.Warn("Content blocked by provider, answering with the fallback")
+			return a.contentBlockFallback(blocked), nil
+		}
+		return nil, err
+	}
+}
+
+// withContentPolicyInstruction appends the instruction to answer again within the content
+// policy, last so it takes precedence
+func withContentPolicyInstruction(req llm.CompletionRequest, blocked *llm.ContentBlockedError) llm.CompletionRequest {
+	instruction := "The provider's content filter blocked the previous attempt to answer"
+	if len(blocked.Categories) > 0 {
+		instruction += fmt.Sprintf(" (categories: %s)", strings.Join(blocked.Categories, ", "))
+	}
+	instruction += ". Answer again, rephrasing so the answer stays within the content policy, " +
+		"or briefly decline the parts you cannot help with."
+
+	messages := make([]llm.Message, len(req.Messages), len(req.Messages)+1)
+	copy(messages, req.Messages)
+	req.Messages = append(messages, llm.Message{Role: "system", Content: instruction})
+	return req
+}
+
+// contentBlockFallback returns the response answering blocked content with the fallback
+func (a *Agent) contentBlockFallback(blocked *llm.ContentBlockedError) *llm.CompletionResponse {
+	message := a.config.ContentBlockMessage
+	if message == "" {
+		message = DefaultContentBlockMessage
+	}
+	return &llm.CompletionResponse{
+		Model: blocked.Model,
+		Choices: []llm.Choice{{
+			Message:      llm.Message{Role: "assistant", Content: message},
+			FinishReason: "stop",
+		}},
+		Metadata: map[string]interface{}{
+			"content_blocked":    true,
+			"blocked_categories": blocked.Categories,
+		},
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"fmt"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// ContextSegment is a part of the messages sent to the model
+type ContextSegment string
+
+const (
+	// ContextSegmentSystem is the system prompt and the other leading system messages,
+	// followed by the agent's few-shot examples
+	ContextSegmentSystem ContextSegment = "system"
+	// ContextSegmentRetrieved is the retrieved context message
+	ContextSegmentRetrieved ContextSegment = "retrieved"
+	// ContextSegmentHistory is the conversation before the current input
+	ContextSegmentHistory ContextSegment = "history"
+	// ContextSegmentInput is the current input with the tool calls and results that followed it
+	ContextSegmentInput ContextSegment = "input"
+)
+
+// ContextOrder is the order in which the segments are sent to the model. Stable segments
+// first favour prompt caching; retrieved context close to the input suits models weighting
+// recent tokens more.
+type ContextOrder []ContextSegment
+
+var (
+	// DefaultContextOrder sends the retrieved context before the history
+	DefaultContextOrder = ContextOrder{ContextSegmentSystem, ContextSegmentRetrieved, ContextSegmentHistory, ContextSegmentInput}
+	// HistoryFirstContextOrder sends the retrieved context after the history, next to the
+	// input, keeping the system prompt and history a cacheable prefix
+	HistoryFirstContextOrder = ContextOrder{ContextSegmentSystem, ContextSegmentHistory, ContextSegmentRetrieved, ContextSegmentInput}
+)
+
+// Validate checks that an order names each segment exactly once; an empty order is the default
+func (o ContextOrder) Validate() error {
+	if len(o) == 0 {
+		return nil
+	}
+
+	seen := make(map[ContextSegment]bool, len(o))
+	for _, segment := range o {
+		switch segment {
+		case ContextSegmentSystem, ContextSegmentRetrieved, ContextSegmentHistory, ContextSegmentInput:
+		default:
+			return fmt.Errorf("unknown context segment: %s", segment)
+		}
+		if seen[segment] {
+			return fmt.Errorf("context segment %s is listed twice", segment)
+		}
+		seen[segment] = true
+	}
+	if len(seen) != len(DefaultContextOrder) {
+		return fmt.Errorf("context order must list the system, retrieved, history and input segments")
+	}
+	return nil
+}
+
+// assembleContext orders the messages of a request, given as the system messages followed
+// by the conversation, into the configured segments and records them for inspection
+func (a *Agent) assembleContext(messages []llm.Message) []llm.Message {
+	a.mu.RLock()
+	contextMessage := a.retrievedContext
+	examples := a.exampleMessages
+	a.mu.RUnlock()
+
+	systemEnd := 0
+	for systemEnd < len(messages) && messages[systemEnd].Role == "system" {
+		systemEnd++
+	}
+	// The input starts at the last user message, so the tool calls answering it stay after it
+	inputStart := len(messages)
+	for i := len(messages) - 1; i >= systemEnd; i-- {
+		if messages[i].Role == "user" {
+			inputStart = i
+			break
+		}
+	}
+
+	// The few-shot examples close the system segment, ahead of the conversation
+	system := messages[:systemEnd]
+	if len(examples) > 0 {
+		system = append(append(make([]llm.Message, 0, systemEnd+len(examples)), system...), examples...)
+	}
+
+	segments := map[ContextSegment][]llm.Message{
+		ContextSegmentSystem:  system,
+		ContextSegmentHistory: messages[systemEnd:inputStart],
+		ContextSegmentInput:   messages[inputStart:],
+	}
+	if contextMessage != nil {
+		segments[ContextSegmentRetrieved] = []llm.Message{*contextMessage}
+	}
+
+	order := a.config.ContextOrder
+	if len(order) == 0 {
+		order = DefaultContextOrder
+	}
+	assembled := make([]llm.Message, 0, len(messages)+len(examples)+1)
+	for _, segment := range order {
+		assembled = append(assembled, segments[segment]...)
+	}
+
+	a.mu.Lock()
+	a.assembledMessages = assembled
+	a.mu.Unlock()
+	return assembled
+}
+
+// AssembledMessages returns the messages of the agent's latest model request in the
+// configured context order, before the locale instruction is added
+func (a *Agent) AssembledMessages() []llm.Message {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	messages := make([]llm.Message, len(a.assembledMessages))
+	copy(messages, a.assembledMessages)
+	return messages
+}
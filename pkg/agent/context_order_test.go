@@ -0,0 +1,82 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+func TestAgent_ContextOrder(t *testing.T) {
+	describe := func(messages []llm.Message) []string {
+		var kinds []string
+		for _, message := range messages {
+			switch {
+			case llm.IsContextMessage(message):
+				kinds = append(kinds, "retrieved")
+			default:
+				kinds = append(kinds, message.Role+":"+message.Content)
+			}
+		}
+		return kinds
+	}
+
+	tests := []struct {
+		name     string
+		order    ContextOrder
+		expected []string
+	}{
+		{"default", nil, []string{"system:Be brief", "retrieved", "user:Hi", "assistant:Hello, World!", "user:Next"}},
+		{"history first", HistoryFirstContextOrder, []string{"system:Be brief", "user:Hi", "assistant:Hello, World!", "retrieved", "user:Next"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := createTestAgent(t, AgentTypeChat)
+			agent.config.SystemPrompt = "Be brief"
+			agent.config.ContextOrder = tt.order
+			agent.SetRetrievedContext([]llm.ContextChunk{{SourceID: "doc-1", Content: "Facts"}})
+
+			for _, input := range []string{"Hi", "Next"} {
+				if _, err := agent.Execute(context.Background(), input); err != nil {
+					t.Fatalf("Execute() failed: %v", err)
+				}
+			}
+
+			kinds := describe(agent.AssembledMessages())
+			if len(kinds) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, kinds)
+			}
+			for i := range kinds {
+				if kinds[i] != tt.expected[i] {
+					t.Fatalf("Expected %v, got %v", tt.expected, kinds)
+				}
+			}
+		})
+	}
+}
+
+func TestContextOrder_Validate(t *testing.T) {
+	valid := []ContextOrder{nil, DefaultContextOrder, HistoryFirstContextOrder}
+	for _, order := range valid {
+		if err := order.Validate(); err != nil {
+			t.Errorf("Expected %v to be valid, got %v", order, err)
+		}
+	}
+
+	invalid := []ContextOrder{
+		{ContextSegmentSystem, ContextSegmentHistory, ContextSegmentInput},
+		{ContextSegmentSystem, ContextSegmentRetrieved, ContextSegmentHistory, ContextSegmentHistory},
+		{ContextSegmentSystem, ContextSegmentRetrieved, ContextSegmentHistory, "tools"},
+	}
+	for _, order := range invalid {
+		if err := order.Validate(); err == nil {
+			t.Errorf("Expected %v to be rejected", order)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
+)
+
+// ContextWindow returns the context window, in tokens, of the agent's model as reported by
+// its provider, or 0 when it is unknown
+func (a *Agent) ContextWindow() int {
+	capabilities, err := a.llmManager.ModelCapabilities(a.config.Provider, a.config.Model)
+	if err != nil {
+		return 0
+	}
+	return capabilities.MaxContextTokens
+}
+
+// fitContextWindow drops the oldest messages of a request that does not fit in the model's
+// context window, leaving room for the response, so the prompt is sized for the configured
+// model instead of being truncated or rejected by the provider
+func (a *Agent) fitContextWindow(ctx context.Context, req llm.CompletionRequest) llm.CompletionRequest {
+	contextWindow := a.ContextWindow()
+	trimmed, ok := llm.FitToContextWindow(req, contextWindow)
+	if !ok {
+		return req
+	}
+
+	logging.FromContext(ctx, a.logger).WithFields(logrus.Fields{
+		"context_window": contextWindow,
+		"messages":       len(req.Messages),
+		"trimmed":        len(req.Messages) - len(trimmed.Messages),
+	}).Debug("Trimmed messages to fit the context window")
+	return trimmed
+}
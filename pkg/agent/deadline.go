@@ -0,0 +1,77 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultFinalAnswerTimeout bounds the final turn requested once a soft deadline is reached
+const DefaultFinalAnswerTimeout = 10 * time.Second
+
+// bestAnswerPrompt asks the model to answer with what it has gathered so far
+const bestAnswerPrompt = "Time is up. Do not call any more tools. Give the best answer you can based on what you know so far, and briefly mention anything you could not verify."
+
+// ExecuteOptions configures a single agent execution
+type ExecuteOptions struct {
+	// SoftDeadline is the time after which the ReAct loop stops at the next step boundary
+	// and the model is asked for its best answer so far; zero disables it
+	SoftDeadline time.Duration `json:"soft_deadline"`
+	// FinalAnswerTimeout bounds the best-answer turn; defaults to DefaultFinalAnswerTimeout
+	FinalAnswerTimeout time.Duration `json:"final_answer_timeout"`
+	// PinInput pins the input message so context trimming never drops it
+	PinInput bool `json:"pin_input"`
+	// Locale is the language the agent must respond in, e.g. "fr"; defaults to AgentConfig.Locale
+	Locale string `json:"locale,omitempty"`
+	// LocaleRetries is the number of times an answer detected in another language is rewritten
+	LocaleRetries int `json:"locale_retries,omitempty"`
+	// Stream controls how events are sent by StreamWithOptions
+	Stream StreamOptions `json:"stream"`
+	// ExecutionID is the ID of the execution, e.g. one chosen by a client to cancel it by;
+	// generated when empty. Retried attempts of the execution share it.
+	ExecutionID string `json:"execution_id,omitempty"`
+}
+
+// softDeadline is the deadline of an execution carried in its context
+type softDeadline struct {
+	at           time.Time
+	finalTimeout time.Duration
+}
+
+// softDeadlineKey is the context key of the soft deadline
+type softDeadlineKey struct{}
+
+// withSoftDeadline binds the soft deadline of the options to the context
+func withSoftDeadline(ctx context.Context, start time.Time, options *ExecuteOptions) context.Context {
+	if options == nil || options.SoftDeadline <= 0 {
+		return ctx
+	}
+	finalTimeout := options.FinalAnswerTimeout
+	if finalTimeout <= 0 {
+		finalTimeout = DefaultFinalAnswerTimeout
+	}
+	return context.WithValue(ctx, softDeadlineKey{}, &softDeadline{
+		at:           start.Add(options.SoftDeadline),
+		finalTimeout: finalTimeout,
+	})
+}
+
+// softDeadlineReached reports whether the soft deadline of the execution has passed
+func softDeadlineReached(ctx context.Context) bool {
+	deadline, ok := ctx.Value(softDeadlineKey{}).(*softDeadline)
+	return ok && !time.Now().Before(deadline.at)
+}
+
+// finalAnswerContext bounds the best-answer turn by the final answer timeout
+func finalAnswerContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Value(softDeadlineKey{}).(*softDeadline)
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, deadline.finalTimeout)
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
+)
+
+// ErrEmptyResponse is returned by Execute when the model answers with no content and no
+// tool calls
+var ErrEmptyResponse = errors.New("empty response from LLM")
+
+// EmptyResponsePolicy decides what an agent does when the model returns an empty answer
+type EmptyResponsePolicy string
+
+const (
+	// EmptyResponseFail fails the execution with an EmptyResponseError; it is the default
+	EmptyResponseFail EmptyResponsePolicy = "fail"
+	// EmptyResponseRetry asks the model once more before failing; blocked responses are
+	// not retried since the same request would be blocked again
+	EmptyResponseRetry EmptyResponsePolicy = "retry"
+	// EmptyResponseAllow returns the empty answer as the output
+	EmptyResponseAllow EmptyResponsePolicy = "allow"
+)
+
+// IsBlockedFinishReason reports whether a finish reason means the provider blocked the answer
+func IsBlockedFinishReason(reason string) bool {
+	return llm.IsContentBlockFinishReason(reason)
+}
+
+// EmptyResponseError reports an empty answer with the provider's finish reason
+type EmptyResponseError struct {
+	Agent        string
+	Provider     string
+	FinishReason string
+	// Blocked is set when the finish reason shows a content filter suppressed the answer
+	Blocked bool
+}
+
+// Error implements the error interface
+func (e *EmptyResponseError) Error() string {
+	switch {
+	case e.Blocked:
+		return fmt.Sprintf("%v for agent %s: provider %s blocked the response (finish reason %q)", ErrEmptyResponse, e.Agent, e.Provider, e.FinishReason)
+	case e.FinishReason != "":
+		return fmt.Sprintf("%v for agent %s (finish reason %q)", ErrEmptyResponse, e.Agent, e.FinishReason)
+	default:
+		return fmt.Sprintf("%v for agent %s", ErrEmptyResponse, e.Agent)
+	}
+}
+
+// Unwrap returns ErrEmptyResponse
+func (e *EmptyResponseError) Unwrap() error {
+	return ErrEmptyResponse
+}
+
+// Is matches core.ErrPermanent: the empty response policy already decides whether to ask
+// the model again, so the graph does not retry the node on top of it
+func (e *EmptyResponseError) Is(target error) bool {
+	return target == core.ErrPermanent
+}
+
+// completeAnswer runs a completion producing an answer, sized to the model's context window,
+// and applies the agent's content block and empty response policies to it
+func (a *Agent) completeAnswer(ctx context.Context, req llm.CompletionRequest,
+	complete func(context.Context, llm.CompletionRequest) (*llm.CompletionResponse, error)) (*llm.CompletionResponse, error) {
+	req = a.fitContextWindow(ctx, req)
+	complete = a.handleContentBlocks(complete)
+	resp, err := complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := a.config.EmptyResponsePolicy
+	emptyErr := a.emptyResponseError(resp)
+	if emptyErr == nil || policy == EmptyResponseAllow {
+		return resp, nil
+	}
+
+	if policy == EmptyResponseRetry && !emptyErr.Blocked && core.AllowRetry(ctx, core.RetryLayerAgent) {
+		logging.FromContext(ctx, a.logger).WithField("finish_reason", emptyErr.FinishReason).Warn("Empty response from LLM, retrying")
+		resp, err = complete(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if emptyErr = a.emptyResponseError(resp); emptyErr == nil {
+			return resp, nil
+		}
+	}
+	return nil, emptyErr
+}
+
+// completeWithProvider runs a completion with the agent's provider
+func (a *Agent) completeWithProvider(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	return a.llmManager.Complete(ctx, a.config.Provider, req)
+}
+
+// emptyResponseError returns the error of a response without content or tool calls, or nil
+func (a *Agent) emptyResponseError(resp *llm.CompletionResponse) *EmptyResponseError {
+	finishReason := ""
+	if len(resp.Choices) > 0 {
+		message := resp.Choices[0].Message
+		if strings.TrimSpace(message.Content) != "" || len(message.ToolCalls) > 0 {
+			return nil
+		}
+		finishReason = resp.Choices[0].FinishReason
+	}
+	return &EmptyResponseError{
+		Agent:        a.config.Name,
+		Provider:     a.config.Provider,
+		FinishReason: finishReason,
+		Blocked:      IsBlockedFinishReason(finishReason),
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AllTopics subscribes to events of every topic
+const AllTopics = "*"
+
+// DeliveryPolicy decides what happens when a subscriber's buffer is full
+type DeliveryPolicy string
+
+const (
+	// DeliveryDropNewest drops the event being published
+	DeliveryDropNewest DeliveryPolicy = "drop_newest"
+	// DeliveryDropOldest drops the oldest buffered event to make room
+	DeliveryDropOldest DeliveryPolicy = "drop_oldest"
+)
+
+// Event is a message published on the event bus
+type Event struct {
+	ID        string      `json:"id"`
+	Topic     string      `json:"topic"`
+	Source    string      `json:"source,omitempty"`
+	Payload   interface{} `json:"payload"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// EventBusConfig configures event delivery
+type EventBusConfig struct {
+	// BufferSize is the number of undelivered events kept per subscriber
+	BufferSize int            `json:"buffer_size" yaml:"buffer_size"`
+	Policy     DeliveryPolicy `json:"policy" yaml:"policy"`
+}
+
+// DefaultEventBusConfig returns default event bus configuration
+func DefaultEventBusConfig() *EventBusConfig {
+	return &EventBusConfig{
+		BufferSize: 64,
+		Policy:     DeliveryDropNewest,
+	}
+}
+
+// eventSubscription is a subscriber's buffered channel
+type eventSubscription struct {
+	topic string
+	ch    chan Event
+	mu    sync.Mutex
+}
+
+// EventBus delivers published events to topic subscribers. Publishing never blocks:
+// when a subscriber's buffer is full the event is dropped according to the policy.
+type EventBus struct {
+	config        *EventBusConfig
+	subscriptions map[string][]*eventSubscription
+	closed        bool
+	dropped       int64
+	mu            sync.RWMutex
+}
+
+// NewEventBus creates a new event bus
+func NewEventBus(config *EventBusConfig) *EventBus {
+	if config == nil {
+		config = DefaultEventBusConfig()
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = DefaultEventBusConfig().BufferSize
+	}
+	if config.Policy == "" {
+		config.Policy = DeliveryDropNewest
+	}
+
+	return &EventBus{
+		config:        config,
+		subscriptions: make(map[string][]*eventSubscription),
+	}
+}
+
+// Publish sends a payload to the subscribers of a topic and returns the number of
+// subscribers that received it
+func (eb *EventBus) Publish(topic string, payload interface{}) int {
+	return eb.PublishFrom("", topic, payload)
+}
+
+// PublishFrom publishes a payload on behalf of a source, usually an agent ID
+func (eb *EventBus) PublishFrom(source, topic string, payload interface{}) int {
+	event := Event{
+		ID:        uuid.New().String(),
+		Topic:     topic,
+		Source:    source,
+		Payload:   payload,
+		Timestamp: time.Now(),
+	}
+
+	eb.mu.RLock()
+	defer eb.mu.RUnlock()
+
+	if eb.closed {
+		return 0
+	}
+
+	delivered := 0
+	for _, subscriptions := range [][]*eventSubscription{eb.subscriptions[topic], eb.subscriptions[AllTopics]} {
+		for _, subscription := range subscriptions {
+			if eb.deliver(subscription, event) {
+				delivered++
+			} else {
+				atomic.AddInt64(&eb.dropped, 1)
+			}
+		}
+	}
+	return delivered
+}
+
+// deliver puts an event into a subscription buffer without blocking
+func (eb *EventBus) deliver(subscription *eventSubscription, event Event) bool {
+	subscription.mu.Lock()
+	defer subscription.mu.Unlock()
+
+	select {
+	case subscription.ch <- event:
+		return true
+	default:
+	}
+
+	if eb.config.Policy != DeliveryDropOldest {
+		return false
+	}
+
+	// Make room by discarding the oldest buffered event
+	select {
+	case <-subscription.ch:
+		atomic.AddInt64(&eb.dropped, 1)
+	default:
+	}
+	select {
+	case subscription.ch <- event:
+		return true
+	default:
+		return false
+	}
+}
+
+// Subscribe returns a channel receiving the events of a topic; use AllTopics for every
+// topic. The channel is closed by Unsubscribe or Close.
+func (eb *EventBus) Subscribe(topic string) <-chan Event {
+	subscription := &eventSubscription{
+		topic: topic,
+		ch:    make(chan Event, eb.config.BufferSize),
+	}
+
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	if eb.closed {
+		close(subscription.ch)
+		return subscription.ch
+	}
+	eb.subscriptions[topic] = append(eb.subscriptions[topic], subscription)
+	return subscription.ch
+}
+
+// Unsubscribe removes a subscription and closes its channel
+func (eb *EventBus) Unsubscribe(ch <-chan Event) {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	for topic, subscriptions := range eb.subscriptions {
+		for i, subscription := range subscriptions {
+			if subscription.ch == ch {
+				eb.subscriptions[topic] = append(subscriptions[:i], subscriptions[i+1:]...)
+				close(subscription.ch)
+				return
+			}
+		}
+	}
+}
+
+// Topics returns the topics with at least one subscriber
+func (eb *EventBus) Topics() []string {
+	eb.mu.RLock()
+	defer eb.mu.RUnlock()
+
+	topics := make([]string, 0, len(eb.subscriptions))
+	for topic, subscriptions := range eb.subscriptions {
+		if len(subscriptions) > 0 {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+// Dropped returns the number of events dropped because a subscriber was too slow
+func (eb *EventBus) Dropped() int64 {
+	return atomic.LoadInt64(&eb.dropped)
+}
+
+// Close closes every subscription; later publishes are ignored
+func (eb *EventBus) Close() {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	if eb.closed {
+		return
+	}
+	eb.closed = true
+	for _, subscriptions := range eb.subscriptions {
+		for _, subscription := range subscriptions {
+			close(subscription.ch)
+		}
+	}
+	eb.subscriptions = make(map[string][]*eventSubscription)
+}
+
+// EventTopic returns the topic of a typed event, the name of its type
+func EventTopic[T any]() string {
+	return reflect.TypeOf((*T)(nil)).Elem().Name()
+}
+
+// PublishEvent publishes a typed event on the topic named after its type,
+// e.g. a FindingsReady payload is published on "FindingsReady"
+func PublishEvent[T any](bus *EventBus, source string, payload T) int {
+	return bus.PublishFrom(source, EventTopic[T](), payload)
+}
+
+// SubscribeEvent subscribes to the topic of a typed event
+func SubscribeEvent[T any](bus *EventBus) <-chan Event {
+	return bus.Subscribe(EventTopic[T]())
+}
+
+// EventPayload returns the payload of an event as the given type
+func EventPayload[T any](event Event) (T, bool) {
+	payload, ok := event.Payload.(T)
+	return payload, ok
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"testing"
+	"time"
+)
+
+// FindingsReady is published when a researcher finishes
+type FindingsReady struct {
+	Summary string
+}
+
+func receiveEvent(t *testing.T, ch <-chan Event) Event {
+	t.Helper()
+	select {
+	case event, ok := <-ch:
+		if !ok {
+			t.Fatal("Subscription closed unexpectedly")
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for an event")
+	}
+	return Event{}
+}
+
+func TestEventBus_PublishSubscribe(t *testing.T) {
+	bus := NewEventBus(nil)
+	defer bus.Close()
+
+	tasks := bus.Subscribe("tasks")
+	all := bus.Subscribe(AllTopics)
+
+	if delivered := bus.PublishFrom("planner", "tasks", "write report"); delivered != 2 {
+		t.Errorf("Expected delivery to 2 subscribers, got %d", delivered)
+	}
+	bus.Publish("other", 1)
+
+	event := receiveEvent(t, tasks)
+	if event.Topic != "tasks" || event.Source != "planner" || event.Payload != "write report" || event.ID == "" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	if receiveEvent(t, all).Topic != "tasks" || receiveEvent(t, all).Topic != "other" {
+		t.Error("Expected the wildcard subscriber to receive every topic")
+	}
+}
+
+func TestEventBus_DeliveryPolicies(t *testing.T) {
+	newest := NewEventBus(&EventBusConfig{BufferSize: 1, Policy: DeliveryDropNewest})
+	ch := newest.Subscribe("updates")
+	newest.Publish("updates", 1)
+	if delivered := newest.Publish("updates", 2); delivered != 0 {
+		t.Error("Expected the newest event to be dropped")
+	}
+	if event := receiveEvent(t, ch); event.Payload != 1 || newest.Dropped() != 1 {
+		t.Errorf("Expected the first event to be kept, got %v with %d dropped", event.Payload, newest.Dropped())
+	}
+
+	oldest := NewEventBus(&EventBusConfig{BufferSize: 1, Policy: DeliveryDropOldest})
+	ch = oldest.Subscribe("updates")
+	oldest.Publish("updates", 1)
+	if delivered := oldest.Publish("updates", 2); delivered != 1 {
+		t.Error("Expected the newest event to replace the oldest")
+	}
+	if event := receiveEvent(t, ch); event.Payload != 2 || oldest.Dropped() != 1 {
+		t.Errorf("Expected the latest event to be kept, got %v with %d dropped", event.Payload, oldest.Dropped())
+	}
+}
+
+func TestEventBus_TypedEvents(t *testing.T) {
+	bus := NewEventBus(nil)
+	defer bus.Close()
+
+	ch := SubscribeEvent[FindingsReady](bus)
+	PublishEvent(bus, "researcher", FindingsReady{Summary: "done"})
+
+	event := receiveEvent(t, ch)
+	if event.Topic != "FindingsReady" {
+		t.Errorf("Expected the topic to be the type name, got %s", event.Topic)
+	}
+	findings, ok := EventPayload[FindingsReady](event)
+	if !ok || findings.Summary != "done" {
+		t.Errorf("Unexpected payload %+v", event.Payload)
+	}
+}
+
+func TestEventBus_UnsubscribeAndClose(t *testing.T) {
+	bus := NewEventBus(nil)
+	first := bus.Subscribe("tasks")
+	second := bus.Subscribe("tasks")
+
+	bus.Unsubscribe(first)
+	if _, ok := <-first; ok {
+		t.Error("Expected the unsubscribed channel to be closed")
+	}
+	if delivered := bus.Publish("tasks", nil); delivered != 1 {
+		t.Errorf("Expected delivery to the remaining subscriber, got %d", delivered)
+	}
+
+	bus.Close()
+	<-second
+	if _, ok := <-second; ok {
+		t.Error("Expected Close to close every subscription")
+	}
+	if delivered := bus.Publish("tasks", nil); delivered != 0 {
+		t.Error("Expected publishing on a closed bus to be ignored")
+	}
+	if _, ok := <-bus.Subscribe("tasks"); ok {
+		t.Error("Expected subscriptions on a closed bus to be closed")
+	}
+}
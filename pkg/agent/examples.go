@@ -0,0 +1,235 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
+)
+
+// Example is a few-shot example: an input and the answer expected for it
+type Example struct {
+	Input  string `json:"input" yaml:"input"`
+	Output string `json:"output" yaml:"output"`
+}
+
+// ExampleStrategy selects the examples given to the model for an input
+type ExampleStrategy string
+
+const (
+	// ExampleStrategyAll gives every example; the default
+	ExampleStrategyAll ExampleStrategy = "all"
+	// ExampleStrategyRandom gives K examples picked at random, reproducibly when the agent
+	// has a seed
+	ExampleStrategyRandom ExampleStrategy = "random"
+	// ExampleStrategyNearest gives the K examples whose input is the most similar to the
+	// input, by embedding similarity
+	ExampleStrategyNearest ExampleStrategy = "nearest"
+)
+
+// ExampleFormat is how the examples are given to the model
+type ExampleFormat string
+
+const (
+	// ExampleFormatMessages gives each example as a user turn and an assistant turn after
+	// the system prompt; the default
+	ExampleFormatMessages ExampleFormat = "messages"
+	// ExampleFormatSystem lists the examples in a system message
+	ExampleFormatSystem ExampleFormat = "system"
+)
+
+// ExampleSelection configures how the examples of an agent are selected and given
+type ExampleSelection struct {
+	Strategy ExampleStrategy `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+	// K is the number of examples given by the random and nearest strategies
+	K      int           `json:"k,omitempty" yaml:"k,omitempty"`
+	Format ExampleFormat `json:"format,omitempty" yaml:"format,omitempty"`
+	// EmbeddingProvider and EmbeddingModel embed the inputs for the nearest strategy; the
+	// provider defaults to the agent's
+	EmbeddingProvider string `json:"embedding_provider,omitempty" yaml:"embedding_provider,omitempty"`
+	EmbeddingModel    string `json:"embedding_model,omitempty" yaml:"embedding_model,omitempty"`
+}
+
+// Validate checks the example selection
+func (s *ExampleSelection) Validate() error {
+	switch s.Strategy {
+	case "", ExampleStrategyAll:
+	case ExampleStrategyRandom, ExampleStrategyNearest:
+		if s.K <= 0 {
+			return fmt.Errorf("example strategy %s requires k to be positive, got %d", s.Strategy, s.K)
+		}
+	default:
+		return fmt.Errorf("unknown example strategy %q, expected all, random or nearest", s.Strategy)
+	}
+	switch s.Format {
+	case "", ExampleFormatMessages, ExampleFormatSystem:
+	default:
+		return fmt.Errorf("unknown example format %q, expected messages or system", s.Format)
+	}
+	if s.Strategy == ExampleStrategyNearest && s.EmbeddingModel == "" {
+		return fmt.Errorf("example strategy nearest requires an embedding model")
+	}
+	return nil
+}
+
+// refreshExamples selects the examples given to the model for an input. The examples are
+// never added to the conversation history.
+func (a *Agent) refreshExamples(ctx context.Context, input string, execution *AgentExecution) {
+	if len(a.config.Examples) == 0 {
+		return
+	}
+	selection := a.config.ExampleSelection
+	if selection == nil {
+		selection = &ExampleSelection{}
+	}
+
+	var examples []Example
+	switch selection.Strategy {
+	case ExampleStrategyRandom:
+		examples = a.randomExamples(selection.K)
+	case ExampleStrategyNearest:
+		var err error
+		examples, err = a.nearestExamples(ctx, selection, input)
+		if err != nil {
+			logging.FromContext(ctx, a.logger).WithError(err).Warn("Example selection failed, giving the first examples")
+			examples = a.config.Examples[:min(selection.K, len(a.config.Examples))]
+		}
+	default:
+		examples = a.config.Examples
+	}
+
+	messages := exampleMessages(selection.Format, examples)
+	a.mu.Lock()
+	a.exampleMessages = messages
+	a.mu.Unlock()
+	execution.Metadata["examples"] = len(examples)
+}
+
+// randomExamples picks k examples at random, keeping their configured order
+func (a *Agent) randomExamples(k int) []Example {
+	seed := time.Now().UnixNano()
+	if a.config.Seed != nil {
+		seed = int64(*a.config.Seed)
+	}
+	indices := rand.New(rand.NewSource(seed)).Perm(len(a.config.Examples))
+	if k < len(indices) {
+		indices = indices[:k]
+	}
+	sort.Ints(indices)
+
+	examples := make([]Example, len(indices))
+	for i, index := range indices {
+		examples[i] = a.config.Examples[index]
+	}
+	return examples
+}
+
+// nearestExamples picks the k examples whose input is the most similar to the input, the
+// most similar last so it is closest to the input. The embeddings of the examples are
+// computed once.
+func (a *Agent) nearestExamples(ctx context.Context, selection *ExampleSelection, input string) ([]Example, error) {
+	provider := selection.EmbeddingProvider
+	if provider == "" {
+		provider = a.config.Provider
+	}
+
+	a.mu.RLock()
+	embeddings := a.exampleEmbeddings
+	a.mu.RUnlock()
+
+	if embeddings == nil {
+		inputs := make([]string, len(a.config.Examples))
+		for i, example := range a.config.Examples {
+			inputs[i] = example.Input
+		}
+		var err error
+		embeddings, err = a.llmManager.Embed(ctx, provider, selection.EmbeddingModel, inputs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed examples: %w", err)
+		}
+		if len(embeddings) != len(inputs) {
+			return nil, fmt.Errorf("expected %d example embeddings, got %d", len(inputs), len(embeddings))
+		}
+		a.mu.Lock()
+		a.exampleEmbeddings = embeddings
+		a.mu.Unlock()
+	}
+
+	query, err := a.llmManager.Embed(ctx, provider, selection.EmbeddingModel, []string{input})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed input: %w", err)
+	}
+	if len(query) != 1 {
+		return nil, fmt.Errorf("expected 1 input embedding, got %d", len(query))
+	}
+
+	indices := make([]int, len(embeddings))
+	similarities := make([]float64, len(embeddings))
+	for i, embedding := range embeddings {
+		indices[i] = i
+		similarities[i] = cosineSimilarity(query[0], embedding)
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return similarities[indices[i]] > similarities[indices[j]]
+	})
+	if selection.K < len(indices) {
+		indices = indices[:selection.K]
+	}
+
+	examples := make([]Example, len(indices))
+	for i, index := range indices {
+		examples[len(indices)-1-i] = a.config.Examples[index]
+	}
+	return examples, nil
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors, zero when either is null
+func cosineSimilarity(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := 0; i < len(a) && i < len(b); i++ {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// exampleMessages formats examples as conversation turns or as a system message
+func exampleMessages(format ExampleFormat, examples []Example) []llm.Message {
+	if len(examples) == 0 {
+		return nil
+	}
+
+	if format == ExampleFormatSystem {
+		var prompt strings.Builder
+		prompt.WriteString("Examples of inputs and the expected answers:")
+		for _, example := range examples {
+			fmt.Fprintf(&prompt, "\n\nInput: %s\nOutput: %s", example.Input, example.Output)
+		}
+		return []llm.Message{{Role: "system", Content: prompt.String()}}
+	}
+
+	messages := make([]llm.Message, 0, 2*len(examples))
+	for _, example := range examples {
+		messages = append(messages,
+			llm.Message{Role: "user", Content: example.Input},
+			llm.Message{Role: "assistant", Content: example.Output},
+		)
+	}
+	return messages
+}
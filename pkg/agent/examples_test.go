@@ -0,0 +1,156 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
+)
+
+// topicEmbeddingProvider embeds texts by the topics they mention
+type topicEmbeddingProvider struct {
+	mockProvider
+	embedCalls int
+}
+
+func (p *topicEmbeddingProvider) Embed(ctx context.Context, model string, texts []string) ([][]float64, error) {
+	p.embedCalls++
+	embeddings := make([][]float64, len(texts))
+	for i, text := range texts {
+		embedding := make([]float64, 3)
+		for topic, word := range []string{"weather", "math", "music"} {
+			if strings.Contains(text, word) {
+				embedding[topic] = 1
+			}
+		}
+		embeddings[i] = embedding
+	}
+	return embeddings, nil
+}
+
+var testExamples = []Example{
+	{Input: "What is the weather in Paris?", Output: "Sunny"},
+	{Input: "Some math: 2+2?", Output: "4"},
+	{Input: "Recommend music for work", Output: "Lo-fi"},
+}
+
+func newExamplesTestAgent(t *testing.T, provider llm.Provider, selection *ExampleSelection) *Agent {
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", provider); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+	config := &AgentConfig{
+		Name:             "test-agent",
+		Type:             AgentTypeChat,
+		Provider:         "mock",
+		Model:            "test-model",
+		SystemPrompt:     "You are terse.",
+		Examples:         testExamples,
+		ExampleSelection: selection,
+	}
+	if selection != nil {
+		if err := selection.Validate(); err != nil {
+			t.Fatalf("Validate() failed: %v", err)
+		}
+	}
+	return NewAgent(config, llmManager, tools.NewToolRegistry())
+}
+
+func TestAgent_Examples(t *testing.T) {
+	t.Run("all as turns", func(t *testing.T) {
+		agent := newExamplesTestAgent(t, &mockProvider{response: "ok"}, nil)
+		if _, err := agent.Execute(context.Background(), "Hello"); err != nil {
+			t.Fatalf("Execute() failed: %v", err)
+		}
+
+		messages := agent.AssembledMessages()
+		if len(messages) != 8 {
+			t.Fatalf("Expected the system prompt, 3 examples and the input, got %d messages", len(messages))
+		}
+		if messages[0].Role != "system" || messages[1].Content != testExamples[0].Input || messages[2].Role != "assistant" || messages[6].Content != testExamples[2].Output {
+			t.Errorf("Expected the examples as turns after the system prompt, got %+v", messages)
+		}
+		if messages[7].Content != "Hello" {
+			t.Errorf("Expected the input last, got %q", messages[7].Content)
+		}
+		for _, message := range agent.GetConversation() {
+			if message.Content == testExamples[0].Output {
+				t.Error("Expected the examples to stay out of the conversation history")
+			}
+		}
+	})
+
+	t.Run("system format", func(t *testing.T) {
+		agent := newExamplesTestAgent(t, &mockProvider{response: "ok"}, &ExampleSelection{Format: ExampleFormatSystem})
+		if _, err := agent.Execute(context.Background(), "Hello"); err != nil {
+			t.Fatalf("Execute() failed: %v", err)
+		}
+
+		messages := agent.AssembledMessages()
+		if len(messages) != 3 || messages[1].Role != "system" || !strings.Contains(messages[1].Content, "Input: Some math: 2+2?\nOutput: 4") {
+			t.Errorf("Expected the examples in a system message, got %+v", messages)
+		}
+	})
+
+	t.Run("random is reproducible with a seed", func(t *testing.T) {
+		seed := 7
+		var selected [][]llm.Message
+		for i := 0; i < 2; i++ {
+			agent := newExamplesTestAgent(t, &mockProvider{response: "ok"}, &ExampleSelection{Strategy: ExampleStrategyRandom, K: 2})
+			agent.config.Seed = &seed
+			execution, err := agent.Execute(context.Background(), "Hello")
+			if err != nil {
+				t.Fatalf("Execute() failed: %v", err)
+			}
+			if execution.Metadata["examples"] != 2 {
+				t.Errorf("Expected 2 examples in the metadata, got %v", execution.Metadata["examples"])
+			}
+			selected = append(selected, agent.AssembledMessages()[1:5])
+		}
+		for i := range selected[0] {
+			if selected[0][i].Content != selected[1][i].Content {
+				t.Errorf("Expected the same examples for the same seed, got %+v and %+v", selected[0], selected[1])
+			}
+		}
+	})
+
+	t.Run("nearest", func(t *testing.T) {
+		provider := &topicEmbeddingProvider{mockProvider: mockProvider{response: "ok"}}
+		agent := newExamplesTestAgent(t, provider, &ExampleSelection{Strategy: ExampleStrategyNearest, K: 1, EmbeddingModel: "embed"})
+		for _, input := range []string{"Any music tips?", "Is the weather nice?"} {
+			if _, err := agent.Execute(context.Background(), input); err != nil {
+				t.Fatalf("Execute() failed: %v", err)
+			}
+		}
+
+		messages := agent.AssembledMessages()
+		if messages[1].Content != testExamples[0].Input || messages[2].Content != testExamples[0].Output {
+			t.Errorf("Expected the weather example, got %+v", messages[1:3])
+		}
+		if provider.embedCalls != 3 {
+			t.Errorf("Expected the examples to be embedded once and each input once, got %d calls", provider.embedCalls)
+		}
+	})
+}
+
+func TestExampleSelection_Validate(t *testing.T) {
+	invalid := []ExampleSelection{
+		{Strategy: ExampleStrategyRandom},
+		{Strategy: ExampleStrategyNearest, K: 2},
+		{Strategy: "best"},
+		{Format: "xml"},
+	}
+	for _, selection := range invalid {
+		if err := selection.Validate(); err == nil {
+			t.Errorf("Expected %+v to be rejected", selection)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
+)
+
+// ErrOutputRejected is returned when no attempt of an execution passed the output validator
+// of its ExecutionRetryPolicy and no scorer picked one
+var ErrOutputRejected = errors.New("output rejected by validator")
+
+// maxTemperature is the highest temperature a retry is sampled at
+const maxTemperature = 2.0
+
+// OutputValidator checks the output of a successful execution; a nil error accepts it
+type OutputValidator func(ctx context.Context, execution *AgentExecution) error
+
+// OutputScorer rates the output of a successful execution; higher is better
+type OutputScorer func(ctx context.Context, execution *AgentExecution) float64
+
+// ExecutionRetryPolicy runs a whole execution again when its output fails validation. Unlike
+// node retries, which replay a step that returned an error, it retries successful runs whose
+// answer was unlucky, sampling each attempt differently.
+type ExecutionRetryPolicy struct {
+	// MaxAttempts is the number of runs, the first included
+	MaxAttempts int `json:"max_attempts"`
+	// TemperatureStep is added to the temperature of each retry, up to 2.0
+	TemperatureStep float64 `json:"temperature_step,omitempty"`
+	// Validator accepts or rejects the output of a run; the first accepted one is returned
+	Validator OutputValidator `json:"-"`
+	// Scorer picks the best run when none is accepted; without one the execution fails
+	// with ErrOutputRejected
+	Scorer OutputScorer `json:"-"`
+}
+
+// Validate checks the retry policy
+func (p *ExecutionRetryPolicy) Validate() error {
+	if p.MaxAttempts < 1 {
+		return fmt.Errorf("execution retry max attempts must be at least 1, got %d", p.MaxAttempts)
+	}
+	if p.TemperatureStep < 0 {
+		return fmt.Errorf("execution retry temperature step cannot be negative, got %f", p.TemperatureStep)
+	}
+	if p.Validator == nil {
+		return fmt.Errorf("execution retry requires a validator")
+	}
+	return nil
+}
+
+// ExecutionAttempt records a run of an execution retried by its ExecutionRetryPolicy
+type ExecutionAttempt struct {
+	Attempt     int           `json:"attempt"`
+	ExecutionID string        `json:"execution_id"`
+	Temperature float64       `json:"temperature"`
+	Seed        *int          `json:"seed,omitempty"`
+	Output      string        `json:"output"`
+	Error       string        `json:"error,omitempty"`
+	Rejection   string        `json:"rejection,omitempty"` // Why the validator rejected the output
+	Score       *float64      `json:"score,omitempty"`
+	Duration    time.Duration `json:"duration"`
+	Selected    bool          `json:"selected"`
+}
+
+// sampling is the temperature and seed of an attempt, overriding the agent's
+type sampling struct {
+	temperature float64
+	seed        *int
+}
+
+// samplingKey is the context key of the sampling of an attempt
+type samplingKey struct{}
+
+// temperature returns the temperature the execution samples at
+func (a *Agent) temperature(ctx context.Context) float64 {
+	if s, ok := ctx.Value(samplingKey{}).(sampling); ok {
+		return s.temperature
+	}
+	return a.config.Temperature
+}
+
+// seed returns the seed the execution samples with
+func (a *Agent) seed(ctx context.Context) *int {
+	if s, ok := ctx.Value(samplingKey{}).(sampling); ok {
+		return s.seed
+	}
+	return a.config.Seed
+}
+
+// samplingFor varies the sampling of a retry: the temperature rises by the policy's step and
+// a fixed seed is advanced, so the retry does not reproduce the rejected answer
+func (a *Agent) samplingFor(policy *ExecutionRetryPolicy, attempt int) sampling {
+	s := sampling{
+		temperature: math.Min(a.config.Temperature+float64(attempt-1)*policy.TemperatureStep, maxTemperature),
+	}
+	if a.config.Seed != nil {
+		seed := *a.config.Seed + attempt - 1
+		s.seed = &seed
+	}
+	return s
+}
+
+// execute runs an execution reserved with acquire, retrying it under the agent's
+// ExecutionRetryPolicy, and adds it to the history. Streamed executions run once, since
+// their answer was already sent. The retries of every layer are spent from the retry budget
+// of the context, such as that of a graph running the agent, or from the agent's own.
+func (a *Agent) execute(ctx context.Context, input string, options *ExecuteOptions) (*AgentExecution, error) {
+	budget := core.RetryBudgetFromContext(ctx)
+	if budget == nil {
+		budget = core.NewRetryBudget(a.config.RetryBudget)
+		ctx = core.WithRetryBudget(ctx, budget)
+	}
+	spent := budget.Used()
+
+	policy := a.config.ExecutionRetry
+	var execution *AgentExecution
+	var err error
+	if policy == nil || isStreamed(ctx) {
+		execution, err = a.attempt(ctx, input, options)
+	} else {
+		execution, err = a.executeWithRetry(ctx, policy, input, options)
+	}
+
+	if execution != nil {
+		execution.RetryCount = budget.Used() - spent
+		if err == nil {
+			a.recordInteraction(ctx, execution)
+		}
+		a.mu.Lock()
+		a.executionHistory = append(a.executionHistory, *execution)
+		a.mu.Unlock()
+	}
+	return execution, err
+}
+
+// executeWithRetry runs attempts until one passes the validator, returning it or the best
+// scored one. Each attempt starts from the conversation as it was before the execution, and
+// the conversation keeps the turn of the returned attempt.
+func (a *Agent) executeWithRetry(ctx context.Context, policy *ExecutionRetryPolicy, input string, options *ExecuteOptions) (*AgentExecution, error) {
+	logger := logging.FromContext(ctx, a.logger).WithField("agent_name", a.config.Name)
+	start := time.Now()
+	base := a.conversation.Size()
+
+	var executions []*AgentExecution
+	var turns [][]llm.Message
+	var attempts []ExecutionAttempt
+	selected := -1
+	exhausted := false
+	var err error
+	for n := 1; n <= policy.MaxAttempts; n++ {
+		if n > 1 {
+			if !core.AllowRetry(ctx, core.RetryLayerAgent) {
+				exhausted = true
+				break
+			}
+			a.conversation.Truncate(base)
+		}
+		s := a.samplingFor(policy, n)
+		var execution *AgentExecution
+		execution, err = a.attempt(context.WithValue(ctx, samplingKey{}, s), input, options)
+		record := ExecutionAttempt{
+			Attempt:     n,
+			ExecutionID: execution.ID,
+			Temperature: s.temperature,
+			Seed:        s.seed,
+			Output:      execution.Output,
+			Duration:    execution.Duration,
+		}
+		executions = append(executions, execution)
+		turns = append(turns, a.conversation.GetMessages()[base:])
+
+		// Failed runs are left to node retries and end the execution
+		if err != nil {
+			record.Error = err.Error()
+			attempts = append(attempts, record)
+			selected = n - 1
+			break
+		}
+		rejection := policy.Validator(ctx, execution)
+		if rejection == nil {
+			attempts = append(attempts, record)
+			selected = n - 1
+			break
+		}
+		record.Rejection = rejection.Error()
+		if policy.Scorer != nil {
+			score := policy.Scorer(ctx, execution)
+			record.Score = &score
+		}
+		attempts = append(attempts, record)
+		logger.WithField("attempt", n).WithError(rejection).Warn("Execution output rejected")
+	}
+
+	if selected < 0 {
+		if policy.Scorer != nil {
+			selected = 0
+			for i, attempt := range attempts {
+				if *attempt.Score > *attempts[selected].Score {
+					selected = i
+				}
+			}
+			executions[selected].Metadata["output_rejected"] = true
+		} else {
+			selected = len(executions) - 1
+			err = fmt.Errorf("%w after %d attempts: %s", ErrOutputRejected, len(attempts), attempts[selected].Rejection)
+			if exhausted {
+				err = fmt.Errorf("%w (%w)", err, core.ErrRetryBudgetExhausted)
+			}
+			executions[selected].Success = false
+			executions[selected].Error = err
+		}
+	}
+
+	// Keep the conversation turn of the returned attempt
+	if selected != len(executions)-1 {
+		a.conversation.Truncate(base)
+		for _, message := range turns[selected] {
+			a.conversation.AddMessage(message)
+		}
+	}
+
+	attempts[selected].Selected = true
+	execution := executions[selected]
+	execution.Attempts = attempts
+	execution.Duration = time.Since(start)
+	return execution, err
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
+)
+
+func TestAgent_ExecutionRetry(t *testing.T) {
+	answers := []llm.Message{
+		{Role: "assistant", Content: "maybe"},
+		{Role: "assistant", Content: "I think it is 4"},
+		{Role: "assistant", Content: "4"},
+	}
+	requireDigits := func(ctx context.Context, execution *AgentExecution) error {
+		if strings.Trim(execution.Output, "0123456789") != "" {
+			return fmt.Errorf("answer %q is not a number", execution.Output)
+		}
+		return nil
+	}
+	newAgent := func(policy *ExecutionRetryPolicy) (*Agent, *sequenceProvider) {
+		provider := &sequenceProvider{messages: answers}
+		llmManager := llm.NewProviderManager()
+		if err := llmManager.RegisterProvider("mock", provider); err != nil {
+			t.Fatalf("Failed to register provider: %v", err)
+		}
+		seed := 7
+		return NewAgent(&AgentConfig{
+			Name:           "test-agent",
+			Type:           AgentTypeChat,
+			Provider:       "mock",
+			Model:          "test-model",
+			Temperature:    0.5,
+			Seed:           &seed,
+			ExecutionRetry: policy,
+		}, llmManager, tools.NewToolRegistry()), provider
+	}
+
+	// Runs are retried with more variation until one passes the validator
+	agent, provider := newAgent(&ExecutionRetryPolicy{MaxAttempts: 3, TemperatureStep: 0.25, Validator: requireDigits})
+	execution, err := agent.Execute(context.Background(), "What is 2+2?")
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if execution.Output != "4" || len(execution.Attempts) != 3 || !execution.Attempts[2].Selected {
+		t.Fatalf("Expected the third attempt selected, got %q with %+v", execution.Output, execution.Attempts)
+	}
+	for i, request := range provider.requests {
+		if expected := 0.5 + 0.25*float64(i); request.Temperature != expected || *request.Seed != 7+i {
+			t.Errorf("Expected attempt %d at temperature %g with seed %d, got %g and %d", i+1, expected, 7+i, request.Temperature, *request.Seed)
+		}
+	}
+	if rejection := execution.Attempts[0].Rejection; !strings.Contains(rejection, "not a number") {
+		t.Errorf("Expected the rejection to be recorded, got %q", rejection)
+	}
+	if conversation := agent.GetConversation(); len(conversation) != 2 || conversation[1].Content != "4" {
+		t.Errorf("Expected only the selected turn in the conversation, got %+v", conversation)
+	}
+	if history := agent.GetExecutionHistory(); len(history) != 1 {
+		t.Errorf("Expected one execution in the history, got %d", len(history))
+	}
+
+	// Without an accepted run, the scorer picks the best one
+	agent, _ = newAgent(&ExecutionRetryPolicy{
+		MaxAttempts: 2,
+		Validator:   func(context.Context, *AgentExecution) error { return errors.New("never good enough") },
+		Scorer: func(ctx context.Context, execution *AgentExecution) float64 {
+			return float64(len(execution.Output))
+		},
+	})
+	execution, err = agent.Execute(context.Background(), "What is 2+2?")
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if execution.Output != "I think it is 4" || execution.Metadata["output_rejected"] != true {
+		t.Errorf("Expected the best scored attempt, got %q", execution.Output)
+	}
+	if conversation := agent.GetConversation(); conversation[len(conversation)-1].Content != "I think it is 4" {
+		t.Errorf("Expected the selected turn in the conversation, got %+v", conversation)
+	}
+
+	// Without a scorer the execution fails
+	agent, _ = newAgent(&ExecutionRetryPolicy{
+		MaxAttempts: 2,
+		Validator:   func(context.Context, *AgentExecution) error { return errors.New("never good enough") },
+	})
+	execution, err = agent.Execute(context.Background(), "What is 2+2?")
+	if !errors.Is(err, ErrOutputRejected) || execution.Success || len(execution.Attempts) != 2 {
+		t.Errorf("Expected ErrOutputRejected after two attempts, got %v", err)
+	}
+
+	// The retry budget stops the retries early and is reported in the execution
+	agent, _ = newAgent(&ExecutionRetryPolicy{MaxAttempts: 3, Validator: requireDigits})
+	agent.config.RetryBudget = 1
+	execution, err = agent.Execute(context.Background(), "What is 2+2?")
+	if !errors.Is(err, ErrOutputRejected) || !errors.Is(err, core.ErrRetryBudgetExhausted) {
+		t.Errorf("Expected the rejection to note the spent budget, got %v", err)
+	}
+	if len(execution.Attempts) != 2 || execution.RetryCount != 1 {
+		t.Errorf("Expected two attempts and one retry, got %d and %d", len(execution.Attempts), execution.RetryCount)
+	}
+
+	if err := (&ExecutionRetryPolicy{MaxAttempts: 2}).Validate(); err == nil {
+		t.Error("Expected a policy without a validator to be rejected")
+	}
+}
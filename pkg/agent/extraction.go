@@ -0,0 +1,425 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// FieldType represents the type of an extraction field
+type FieldType string
+
+const (
+	FieldTypeString  FieldType = "string"
+	FieldTypeNumber  FieldType = "number"
+	FieldTypeInteger FieldType = "integer"
+	FieldTypeBoolean FieldType = "boolean"
+	FieldTypeArray   FieldType = "array"
+	FieldTypeObject  FieldType = "object"
+)
+
+// extractionToolName is the function name used to request structured extraction
+const extractionToolName = "extract_fields"
+
+// ExtractionField describes a single field to extract from text
+type ExtractionField struct {
+	Name        string    `json:"name"`
+	Type        FieldType `json:"type"`
+	Description string    `json:"description"`
+	Required    bool      `json:"required"`
+}
+
+// ExtractionSchema describes the fields an extraction agent pulls out of text
+type ExtractionSchema struct {
+	Fields     []ExtractionField `json:"fields"`
+	MaxRetries int               `json:"max_retries"`
+}
+
+// NewExtractionSchema creates a new extraction schema with the given fields
+func NewExtractionSchema(fields ...ExtractionField) *ExtractionSchema {
+	return &ExtractionSchema{
+		Fields:     fields,
+		MaxRetries: 2,
+	}
+}
+
+// Validate validates the extraction schema
+func (s *ExtractionSchema) Validate() error {
+	if len(s.Fields) == 0 {
+		return fmt.Errorf("extraction schema must declare at least one field")
+	}
+
+	seen := make(map[string]bool)
+	for _, field := range s.Fields {
+		if field.Name == "" {
+			return fmt.Errorf("extraction field name is required")
+		}
+		if seen[field.Name] {
+			return fmt.Errorf("duplicate extraction field %s", field.Name)
+		}
+		seen[field.Name] = true
+
+		switch field.Type {
+		case FieldTypeString, FieldTypeNumber, FieldTypeInteger, FieldTypeBoolean, FieldTypeArray, FieldTypeObject:
+		default:
+			return fmt.Errorf("extraction field %s has unsupported type %q", field.Name, field.Type)
+		}
+	}
+
+	if s.MaxRetries < 0 {
+		return fmt.Errorf("max retries must not be negative, got %d", s.MaxRetries)
+	}
+
+	return nil
+}
+
+// ToolDefinition returns the function-calling definition for the schema
+func (s *ExtractionSchema) ToolDefinition() llm.ToolDefinition {
+	properties := make(map[string]interface{})
+	for _, field := range s.Fields {
+		properties[field.Name] = map[string]interface{}{
+			"type":        "object",
+			"description": field.Description,
+			"properties": map[string]interface{}{
+				"value": map[string]interface{}{
+					"type":        []string{string(field.Type), "null"},
+					"description": "Extracted value, or null when the field is not present in the text",
+				},
+				"confidence": map[string]interface{}{
+					"type":        "number",
+					"description": "Confidence between 0 and 1",
+				},
+			},
+			"required": []string{"value", "confidence"},
+		}
+	}
+
+	fieldNames := make([]string, 0, len(s.Fields))
+	for _, field := range s.Fields {
+		fieldNames = append(fieldNames, field.Name)
+	}
+
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.Function{
+			Name:        extractionToolName,
+			Description: "Record the fields extracted from the provided text",
+			Parameters: map[string]interface{}{
+				"type":       "object",
+				"properties": properties,
+				"required":   fieldNames,
+			},
+		},
+	}
+}
+
+// ExtractedField represents a single extracted value
+type ExtractedField struct {
+	Value      interface{} `json:"value"`
+	Confidence float64     `json:"confidence"`
+}
+
+// ExtractionResult represents the result of an extraction
+type ExtractionResult struct {
+	ID       string                     `json:"id"`
+	Fields   map[string]*ExtractedField `json:"fields"` // nil entry when the field was not found
+	Raw      string                     `json:"raw"`
+	Attempts int                        `json:"attempts"`
+	Duration time.Duration              `json:"duration"`
+}
+
+// Value returns the extracted value for a field, or nil when not found
+func (r *ExtractionResult) Value(name string) interface{} {
+	if field, ok := r.Fields[name]; ok && field != nil {
+		return field.Value
+	}
+	return nil
+}
+
+// Values returns a map of field names to extracted values
+func (r *ExtractionResult) Values() map[string]interface{} {
+	values := make(map[string]interface{}, len(r.Fields))
+	for name := range r.Fields {
+		values[name] = r.Value(name)
+	}
+	return values
+}
+
+// ExtractionAgent extracts structured fields from free text
+type ExtractionAgent struct {
+	schema     *ExtractionSchema
+	config     *AgentConfig
+	llmManager *llm.ProviderManager
+	logger     *logrus.Logger
+}
+
+// NewExtractionAgent creates a new extraction agent for the given schema
+func NewExtractionAgent(schema *ExtractionSchema, config *AgentConfig, llmManager *llm.ProviderManager) (*ExtractionAgent, error) {
+	if schema == nil {
+		return nil, fmt.Errorf("extraction schema is required")
+	}
+	if err := schema.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid extraction schema: %w", err)
+	}
+	if config == nil {
+		return nil, fmt.Errorf("agent config is required")
+	}
+
+	agentConfig := *config
+	if agentConfig.Name == "" {
+		agentConfig.Name = "extraction-agent"
+	}
+	if err := agentConfig.ValidateAndSanitize(); err != nil {
+		return nil, fmt.Errorf("invalid agent configuration: %w", err)
+	}
+
+	return &ExtractionAgent{
+		schema:     schema,
+		config:     &agentConfig,
+		llmManager: llmManager,
+		logger:     logrus.New(),
+	}, nil
+}
+
+// GetSchema returns the extraction schema
+func (ea *ExtractionAgent) GetSchema() *ExtractionSchema {
+	return ea.schema
+}
+
+// Execute extracts the schema fields from the given text
+func (ea *ExtractionAgent) Execute(ctx context.Context, text string) (*ExtractionResult, error) {
+	start := time.Now()
+	messages := []llm.Message{
+		{Role: "system", Content: ea.buildSystemPrompt()},
+		{Role: "user", Content: text},
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= ea.schema.MaxRetries+1; attempt++ {
+		req := llm.CompletionRequest{
+			Messages:    messages,
+			Model:       ea.config.Model,
+			Temperature: ea.config.Temperature,
+			MaxTokens:   ea.config.MaxTokens,
+			Seed:        ea.config.Seed,
+			Tools:       []llm.ToolDefinition{ea.schema.ToolDefinition()},
+			ToolChoice: map[string]interface{}{
+				"type":     "function",
+				"function": map[string]interface{}{"name": extractionToolName},
+			},
+		}
+
+		resp, err := ea.llmManager.Complete(ctx, ea.config.Provider, req)
+		if err != nil {
+			return nil, fmt.Errorf("extraction failed: %w", err)
+		}
+
+		if len(resp.Choices) == 0 {
+			return nil, fmt.Errorf("no response from LLM")
+		}
+
+		message := resp.Choices[0].Message
+		raw := extractionPayload(message)
+
+		fields, err := ea.parseAndValidate(raw)
+		if err == nil {
+			return &ExtractionResult{
+				ID:       uuid.New().String(),
+				Fields:   fields,
+				Raw:      raw,
+				Attempts: attempt,
+				Duration: time.Since(start),
+			}, nil
+		}
+
+		lastErr = err
+		if attempt <= ea.schema.MaxRetries && !core.AllowRetry(ctx, core.RetryLayerAgent) {
+			return nil, fmt.Errorf("extraction output did not match schema after %d attempts: %w (%w)", attempt, err, core.ErrRetryBudgetExhausted)
+		}
+		ea.logger.WithFields(logrus.Fields{
+			"attempt": attempt,
+			"error":   err,
+		}).Warn("Extraction output did not match schema, retrying")
+
+		// Feed the validation error back so the model can correct itself
+		messages = append(messages,
+			llm.Message{Role: "assistant", Content: raw},
+			llm.Message{Role: "user", Content: fmt.Sprintf("The previous output did not match the schema: %v. Respond again with valid JSON for all fields.", err)},
+		)
+	}
+
+	return nil, fmt.Errorf("extraction output did not match schema after %d attempts: %w", ea.schema.MaxRetries+1, lastErr)
+}
+
+// buildSystemPrompt builds the instruction prompt describing the schema
+func (ea *ExtractionAgent) buildSystemPrompt() string {
+	var builder strings.Builder
+	if ea.config.SystemPrompt != "" {
+		builder.WriteString(ea.config.SystemPrompt)
+		builder.WriteString("\n\n")
+	}
+
+	builder.WriteString("Extract the following fields from the user's text. ")
+	builder.WriteString("For each field return an object with \"value\" and \"confidence\" (0 to 1). ")
+	builder.WriteString("Use null as the value when the field is not present.\n\nFields:\n")
+	for _, field := range ea.schema.Fields {
+		required := ""
+		if field.Required {
+			required = ", required"
+		}
+		builder.WriteString(fmt.Sprintf("- %s (%s%s): %s\n", field.Name, field.Type, required, field.Description))
+	}
+	builder.WriteString(fmt.Sprintf("\nCall the %s function, or respond with a single JSON object if functions are unavailable.", extractionToolName))
+
+	return builder.String()
+}
+
+// parseAndValidate parses the raw payload and validates it against the schema
+func (ea *ExtractionAgent) parseAndValidate(raw string) (map[string]*ExtractedField, error) {
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	fields := make(map[string]*ExtractedField, len(ea.schema.Fields))
+	var problems []string
+
+	for _, field := range ea.schema.Fields {
+		rawField, exists := payload[field.Name]
+		if !exists || string(rawField) == "null" {
+			if field.Required {
+				problems = append(problems, fmt.Sprintf("%s is required", field.Name))
+			}
+			fields[field.Name] = nil
+			continue
+		}
+
+		extracted, err := decodeExtractedField(rawField)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", field.Name, err))
+			continue
+		}
+
+		if extracted.Value == nil {
+			if field.Required {
+				problems = append(problems, fmt.Sprintf("%s is required", field.Name))
+			}
+			fields[field.Name] = nil
+			continue
+		}
+
+		value, err := coerceFieldValue(field.Type, extracted.Value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", field.Name, err))
+			continue
+		}
+		extracted.Value = value
+		extracted.Confidence = clampConfidence(extracted.Confidence)
+
+		fields[field.Name] = extracted
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+
+	return fields, nil
+}
+
+// extractionPayload returns the extraction arguments from a tool call or the message content
+func extractionPayload(message llm.Message) string {
+	for _, toolCall := range message.ToolCalls {
+		if toolCall.Function.Name == extractionToolName {
+			return toolCall.Function.Arguments
+		}
+	}
+
+	content := strings.TrimSpace(message.Content)
+
+	// Strip markdown code fences that models commonly wrap JSON in
+	if strings.HasPrefix(content, "```") {
+		content = strings.TrimPrefix(content, "```json")
+		content = strings.TrimPrefix(content, "```")
+		content = strings.TrimSuffix(content, "```")
+		content = strings.TrimSpace(content)
+	}
+
+	return content
+}
+
+// decodeExtractedField decodes either {"value": x, "confidence": y} or a bare value
+func decodeExtractedField(raw json.RawMessage) (*ExtractedField, error) {
+	var wrapped map[string]interface{}
+	if err := json.Unmarshal(raw, &wrapped); err == nil {
+		if value, hasValue := wrapped["value"]; hasValue {
+			extracted := &ExtractedField{Value: value, Confidence: 1.0}
+			if confidence, ok := wrapped["confidence"].(float64); ok {
+				extracted.Confidence = confidence
+			}
+			return extracted, nil
+		}
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, fmt.Errorf("invalid value: %w", err)
+	}
+
+	return &ExtractedField{Value: value, Confidence: 1.0}, nil
+}
+
+// coerceFieldValue validates a decoded JSON value against the field type
+func coerceFieldValue(fieldType FieldType, value interface{}) (interface{}, error) {
+	switch fieldType {
+	case FieldTypeString:
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+	case FieldTypeNumber:
+		if v, ok := value.(float64); ok {
+			return v, nil
+		}
+	case FieldTypeInteger:
+		if v, ok := value.(float64); ok && v == float64(int64(v)) {
+			return int64(v), nil
+		}
+	case FieldTypeBoolean:
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+	case FieldTypeArray:
+		if v, ok := value.([]interface{}); ok {
+			return v, nil
+		}
+	case FieldTypeObject:
+		if v, ok := value.(map[string]interface{}); ok {
+			return v, nil
+		}
+	}
+
+	return nil, fmt.Errorf("expected %s, got %T", fieldType, value)
+}
+
+func clampConfidence(confidence float64) float64 {
+	if confidence < 0 {
+		return 0
+	}
+	if confidence > 1 {
+		return 1
+	}
+	return confidence
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// sequenceProvider returns a different canned message for each call
+type sequenceProvider struct {
+	mockProvider
+	messages []llm.Message
+	requests []llm.CompletionRequest
+}
+
+func (p *sequenceProvider) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	p.requests = append(p.requests, req)
+	index := len(p.requests) - 1
+	if index >= len(p.messages) {
+		index = len(p.messages) - 1
+	}
+	return &llm.CompletionResponse{
+		Choices: []llm.Choice{{Message: p.messages[index], FinishReason: "stop"}},
+	}, nil
+}
+
+func newExtractionTestAgent(t *testing.T, provider llm.Provider) *ExtractionAgent {
+	llmManager := llm.NewProviderManager()
+	require.NoError(t, llmManager.RegisterProvider("mock", provider))
+
+	schema := NewExtractionSchema(
+		ExtractionField{Name: "name", Type: FieldTypeString, Description: "Person name", Required: true},
+		ExtractionField{Name: "age", Type: FieldTypeInteger, Description: "Person age"},
+		ExtractionField{Name: "email", Type: FieldTypeString, Description: "Email address"},
+	)
+
+	extractor, err := NewExtractionAgent(schema, &AgentConfig{
+		Name:     "extractor",
+		Provider: "mock",
+		Model:    "test-model",
+	}, llmManager)
+	require.NoError(t, err)
+	return extractor
+}
+
+func TestExtractionAgent_ToolCall(t *testing.T) {
+	provider := &sequenceProvider{messages: []llm.Message{{
+		Role: "assistant",
+		ToolCalls: []llm.ToolCall{{
+			ID:   "call-1",
+			Type: "function",
+			Function: llm.FunctionCall{
+				Name:      extractionToolName,
+				Arguments: `{"name": {"value": "Ada", "confidence": 0.95}, "age": {"value": 36, "confidence": 0.8}, "email": {"value": null, "confidence": 0}}`,
+			},
+		}},
+	}}}
+
+	result, err := newExtractionTestAgent(t, provider).Execute(context.Background(), "Ada is 36.")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, result.Attempts)
+	assert.Equal(t, "Ada", result.Value("name"))
+	assert.Equal(t, int64(36), result.Value("age"))
+	assert.Nil(t, result.Value("email"))
+	assert.Nil(t, result.Fields["email"])
+	assert.InDelta(t, 0.95, result.Fields["name"].Confidence, 0.0001)
+
+	require.Len(t, provider.requests, 1)
+	require.Len(t, provider.requests[0].Tools, 1)
+	assert.Equal(t, extractionToolName, provider.requests[0].Tools[0].Function.Name)
+}
+
+func TestExtractionAgent_RetriesOnSchemaMismatch(t *testing.T) {
+	provider := &sequenceProvider{messages: []llm.Message{
+		{Role: "assistant", Content: `{"name": {"value": 42, "confidence": 0.5}}`},
+		{Role: "assistant", Content: "```json\n{\"name\": {\"value\": \"Grace\", \"confidence\": 0.9}, \"age\": \"unknown\"}\n```"},
+		{Role: "assistant", Content: `{"name": "Grace", "age": null}`},
+	}}
+
+	result, err := newExtractionTestAgent(t, provider).Execute(context.Background(), "Grace wrote a compiler.")
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, result.Attempts)
+	assert.Equal(t, "Grace", result.Value("name"))
+	assert.Nil(t, result.Value("age"))
+	assert.Len(t, provider.requests, 3)
+	assert.Greater(t, len(provider.requests[2].Messages), len(provider.requests[0].Messages))
+}
+
+func TestExtractionAgent_FailsAfterMaxRetries(t *testing.T) {
+	provider := &sequenceProvider{messages: []llm.Message{
+		{Role: "assistant", Content: "not json"},
+	}}
+
+	_, err := newExtractionTestAgent(t, provider).Execute(context.Background(), "nothing here")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "after 3 attempts")
+}
+
+func TestExtractionSchema_Validate(t *testing.T) {
+	assert.Error(t, NewExtractionSchema().Validate())
+	assert.Error(t, NewExtractionSchema(ExtractionField{Name: "a", Type: "date"}).Validate())
+	assert.Error(t, NewExtractionSchema(
+		ExtractionField{Name: "a", Type: FieldTypeString},
+		ExtractionField{Name: "a", Type: FieldTypeNumber},
+	).Validate())
+	assert.NoError(t, NewExtractionSchema(ExtractionField{Name: "a", Type: FieldTypeBoolean}).Validate())
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
+)
+
+// NewGraphAgent adapts a graph to the Agent API. The input is stored under inputKey in the
+// graph's initial state and the value found under outputKey in the final state becomes the
+// agent's response. Empty keys default to "input" and "output".
+func NewGraphAgent(graph *core.Graph, inputKey, outputKey string) *Agent {
+	if inputKey == "" {
+		inputKey = "input"
+	}
+	if outputKey == "" {
+		outputKey = "output"
+	}
+
+	config := DefaultAgentConfig()
+	config.Name = graph.Name
+	config.Type = AgentTypeGraph
+	config.Provider = ""
+	config.Model = ""
+	config.Metadata["input_key"] = inputKey
+	config.Metadata["output_key"] = outputKey
+	if graph.ID != "" {
+		config.ID = graph.ID
+	} else {
+		config.ID = uuid.New().String()
+	}
+
+	return &Agent{
+		config:           config,
+		llmManager:       llm.NewProviderManager(),
+		toolRegistry:     tools.NewToolRegistry(),
+		graph:            graph,
+		conversation:     llm.NewConversationHistory(),
+		logger:           logrus.New(),
+		inputKey:         inputKey,
+		outputKey:        outputKey,
+		executionHistory: make([]AgentExecution, 0),
+	}
+}
+
+// NewAgentNode wraps an agent as a graph node. The node reads its input from inputKey,
+// executes the agent and stores the agent's response under outputKey.
+func NewAgentNode(agent *Agent, inputKey, outputKey string) core.NodeFunc {
+	return func(ctx context.Context, state *core.BaseState) (*core.BaseState, error) {
+		input, exists := state.Get(inputKey)
+		if !exists {
+			return nil, fmt.Errorf("agent %s: input key %s not found in state", agent.config.Name, inputKey)
+		}
+
+		execution, err := agent.Execute(ctx, fmt.Sprintf("%v", input))
+		if err != nil {
+			return nil, fmt.Errorf("agent %s failed: %w", agent.config.Name, err)
+		}
+
+		state.Set(outputKey, execution.Output)
+		return state, nil
+	}
+}
+
+// GraphAgentDefinition exposes a graph as an agent definition so it can be served by the auto-server
+type GraphAgentDefinition struct {
+	*BaseAgentDefinition
+	graph     *core.Graph
+	inputKey  string
+	outputKey string
+}
+
+// NewGraphAgentDefinition creates an agent definition backed by a graph
+func NewGraphAgentDefinition(graph *core.Graph, inputKey, outputKey string) *GraphAgentDefinition {
+	return &GraphAgentDefinition{
+		BaseAgentDefinition: NewBaseAgentDefinition(&AgentConfig{
+			Name: graph.Name,
+			Type: AgentTypeGraph,
+		}),
+		graph:     graph,
+		inputKey:  inputKey,
+		outputKey: outputKey,
+	}
+}
+
+// CreateAgent creates the graph-backed agent
+func (gad *GraphAgentDefinition) CreateAgent() (*Agent, error) {
+	if err := gad.Validate(); err != nil {
+		return nil, err
+	}
+
+	agent := NewGraphAgent(gad.graph, gad.inputKey, gad.outputKey)
+	if gad.llmManager != nil {
+		agent.llmManager = gad.llmManager
+	}
+	if gad.toolRegistry != nil {
+		agent.toolRegistry = gad.toolRegistry
+	}
+	return agent, nil
+}
+
+// Validate validates the graph agent definition
+func (gad *GraphAgentDefinition) Validate() error {
+	if gad.graph == nil {
+		return fmt.Errorf("graph is required")
+	}
+	if gad.graph.Name == "" {
+		return fmt.Errorf("graph name is required")
+	}
+	return gad.graph.Validate()
+}
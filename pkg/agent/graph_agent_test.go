@@ -0,0 +1,82 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
+)
+
+func newUppercaseGraph() *core.Graph {
+	graph := core.NewGraph("uppercase")
+	graph.AddNode("upper", "Upper", func(ctx context.Context, state *core.BaseState) (*core.BaseState, error) {
+		question, _ := state.Get("question")
+		state.Set("answer", strings.ToUpper(question.(string)))
+		return state, nil
+	})
+	graph.SetStartNode("upper")
+	graph.AddEndNode("upper")
+	return graph
+}
+
+func TestNewGraphAgent(t *testing.T) {
+	graphAgent := NewGraphAgent(newUppercaseGraph(), "question", "answer")
+
+	execution, err := graphAgent.Execute(context.Background(), "hello graph")
+	require.NoError(t, err)
+
+	assert.True(t, execution.Success)
+	assert.Equal(t, "HELLO GRAPH", execution.Output)
+	assert.Equal(t, AgentTypeGraph, graphAgent.GetConfig().Type)
+	assert.Equal(t, "uppercase", graphAgent.GetConfig().Name)
+	assert.Len(t, graphAgent.GetExecutionHistory(), 1)
+}
+
+func TestNewAgentNode(t *testing.T) {
+	chatAgent := createTestAgent(t, AgentTypeChat)
+
+	graph := core.NewGraph("composed")
+	graph.AddNode("chat", "Chat", NewAgentNode(chatAgent, "prompt", "reply"))
+	graph.SetStartNode("chat")
+	graph.AddEndNode("chat")
+
+	state := core.NewBaseState()
+	state.Set("prompt", "Hi")
+
+	finalState, err := graph.Execute(context.Background(), state)
+	require.NoError(t, err)
+
+	reply, exists := finalState.Get("reply")
+	assert.True(t, exists)
+	assert.Equal(t, "Hello, World!", reply)
+
+	// Missing input keys fail the node
+	_, err = NewAgentNode(chatAgent, "missing", "reply")(context.Background(), core.NewBaseState())
+	assert.Error(t, err)
+}
+
+func TestGraphAgentDefinition(t *testing.T) {
+	registry := NewAgentRegistry()
+	require.NoError(t, registry.RegisterDefinition("uppercase", NewGraphAgentDefinition(newUppercaseGraph(), "question", "answer")))
+
+	graphAgent, err := registry.CreateAgentFromDefinition("uppercase", llm.NewProviderManager(), tools.NewToolRegistry())
+	require.NoError(t, err)
+
+	execution, err := graphAgent.Execute(context.Background(), "served")
+	require.NoError(t, err)
+	assert.Equal(t, "SERVED", execution.Output)
+
+	assert.Error(t, NewGraphAgentDefinition(core.NewGraph("empty"), "", "").Validate())
+}
@@ -81,6 +81,10 @@ func (m *MockLLMProvider) CompleteStreamWithMode(ctx context.Context, req llm.Co
 
 func (m *MockLLMProvider) SupportsStreaming() bool { return true }
 
+func (m *MockLLMProvider) Capabilities() llm.ProviderCapabilities {
+	return llm.ProviderCapabilities{SupportsStreaming: true, SupportsTools: true, MaxContextTokens: 4096}
+}
+
 func (m *MockLLMProvider) GetStreamingConfig() *llm.StreamingConfig {
 	return &llm.StreamingConfig{
 		Mode:      llm.StreamModeNone,
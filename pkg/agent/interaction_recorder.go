@@ -0,0 +1,304 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// ErrInteractionNotFound is returned for feedback on an interaction the recorder does not
+// hold: it was not sampled, or it was already written once its feedback window elapsed
+var ErrInteractionNotFound = errors.New("interaction not found")
+
+// FineTuneMessage is a message in the format of OpenAI's chat fine-tuning files
+type FineTuneMessage struct {
+	Role       string         `json:"role"`
+	Content    string         `json:"content"`
+	ToolCalls  []llm.ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string         `json:"tool_call_id,omitempty"`
+}
+
+// InteractionFeedback is a client's rating of a response
+type InteractionFeedback struct {
+	// Rating is on a scale chosen by the application, e.g. -1/1 or 1 to 5
+	Rating  int    `json:"rating"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// InteractionMetadata describes a recorded interaction. Strip it, or set
+// InteractionRecorderConfig.OmitMetadata, before uploading a file for fine-tuning.
+type InteractionMetadata struct {
+	ID        string               `json:"id"`
+	Agent     string               `json:"agent"`
+	Provider  string               `json:"provider"`
+	Model     string               `json:"model"`
+	Timestamp time.Time            `json:"timestamp"`
+	Feedback  *InteractionFeedback `json:"feedback,omitempty"`
+}
+
+// Interaction is an exchange with the model, as one line of a fine-tuning JSONL file
+type Interaction struct {
+	Messages []FineTuneMessage    `json:"messages"`
+	Tools    []llm.ToolDefinition `json:"tools,omitempty"`
+	Metadata *InteractionMetadata `json:"metadata,omitempty"`
+}
+
+// InteractionSink stores recorded interactions
+type InteractionSink interface {
+	WriteInteraction(ctx context.Context, interaction *Interaction) error
+}
+
+// JSONLInteractionSink writes each interaction as a line of JSON
+type JSONLInteractionSink struct {
+	writer io.Writer
+	mu     sync.Mutex
+}
+
+// NewJSONLInteractionSink creates a sink writing JSON lines to a writer
+func NewJSONLInteractionSink(writer io.Writer) *JSONLInteractionSink {
+	return &JSONLInteractionSink{writer: writer}
+}
+
+// NewJSONLFileInteractionSink creates a sink appending JSON lines to a file; close the
+// returned file once the recorder is flushed
+func NewJSONLFileInteractionSink(path string) (*JSONLInteractionSink, *os.File, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open interaction file: %w", err)
+	}
+	return NewJSONLInteractionSink(file), file, nil
+}
+
+// WriteInteraction writes an interaction as a line of JSON
+func (s *JSONLInteractionSink) WriteInteraction(ctx context.Context, interaction *Interaction) error {
+	line, err := json.Marshal(interaction)
+	if err != nil {
+		return fmt.Errorf("failed to encode interaction: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.writer.Write(append(line, '\n'))
+	return err
+}
+
+// piiPatterns match personal data removed by RedactPII, with their replacements
+var piiPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), "[EMAIL]"},
+	{regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), "[CARD_NUMBER]"},
+	{regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`), "[SSN]"},
+	{regexp.MustCompile(`\+?\d{1,3}?[ .-]?\(?\d{2,4}\)?[ .-]\d{3,4}[ .-]\d{3,4}\b`), "[PHONE]"},
+	{regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`), "[IP_ADDRESS]"},
+}
+
+// RedactPII replaces email addresses, card numbers, US social security numbers, phone
+// numbers and IPv4 addresses with placeholders. It is the recorder's default redactor.
+func RedactPII(text string) string {
+	for _, pii := range piiPatterns {
+		text = pii.pattern.ReplaceAllString(text, pii.replacement)
+	}
+	return text
+}
+
+// InteractionRecorderConfig configures the recording of interactions
+type InteractionRecorderConfig struct {
+	Sink InteractionSink
+	// SampleRate is the fraction of executions recorded, between 0 and 1; all when zero
+	SampleRate float64
+	// Redact removes personal data from every message and tool call; RedactPII when nil
+	Redact func(string) string
+	// FeedbackWindow holds an interaction before writing it, so feedback can be attached;
+	// zero writes it immediately
+	FeedbackWindow time.Duration
+	// OmitMetadata writes only the messages and tools, as fine-tuning files expect
+	OmitMetadata bool
+}
+
+// pendingInteraction is an interaction waiting for feedback
+type pendingInteraction struct {
+	interaction *Interaction
+	timer       *time.Timer
+}
+
+// InteractionRecorder records sampled agent executions in a fine-tuning format, with the
+// feedback clients give on them. Set it on AgentConfig.InteractionRecorder to opt in.
+type InteractionRecorder struct {
+	config  InteractionRecorderConfig
+	pending map[string]*pendingInteraction
+	mu      sync.Mutex
+}
+
+// NewInteractionRecorder creates an interaction recorder
+func NewInteractionRecorder(config InteractionRecorderConfig) (*InteractionRecorder, error) {
+	if config.Sink == nil {
+		return nil, fmt.Errorf("interaction recorder: sink is required")
+	}
+	if config.SampleRate < 0 || config.SampleRate > 1 {
+		return nil, fmt.Errorf("interaction recorder: sample rate must be between 0 and 1, got %g", config.SampleRate)
+	}
+	if config.SampleRate == 0 {
+		config.SampleRate = 1
+	}
+	if config.Redact == nil {
+		config.Redact = RedactPII
+	}
+	return &InteractionRecorder{
+		config:  config,
+		pending: make(map[string]*pendingInteraction),
+	}, nil
+}
+
+// sampled decides whether an execution is recorded
+func (r *InteractionRecorder) sampled() bool {
+	return r.config.SampleRate >= 1 || rand.Float64() < r.config.SampleRate
+}
+
+// Record redacts an interaction and writes it, or holds it for feedback during the
+// feedback window
+func (r *InteractionRecorder) Record(ctx context.Context, interaction *Interaction) error {
+	for i := range interaction.Messages {
+		message := &interaction.Messages[i]
+		message.Content = r.config.Redact(message.Content)
+		if len(message.ToolCalls) > 0 {
+			calls := make([]llm.ToolCall, len(message.ToolCalls))
+			copy(calls, message.ToolCalls)
+			for j := range calls {
+				calls[j].Function.Arguments = r.config.Redact(calls[j].Function.Arguments)
+				calls[j].Metadata = nil
+			}
+			message.ToolCalls = calls
+		}
+	}
+
+	if r.config.FeedbackWindow <= 0 || interaction.Metadata == nil {
+		return r.write(ctx, interaction)
+	}
+
+	id := interaction.Metadata.ID
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending[id] = &pendingInteraction{
+		interaction: interaction,
+		timer: time.AfterFunc(r.config.FeedbackWindow, func() {
+			r.release(context.Background(), id)
+		}),
+	}
+	return nil
+}
+
+// Feedback attaches a rating to a held interaction and writes it
+func (r *InteractionRecorder) Feedback(ctx context.Context, id string, feedback InteractionFeedback) error {
+	r.mu.Lock()
+	pending, exists := r.pending[id]
+	if exists {
+		pending.timer.Stop()
+		delete(r.pending, id)
+	}
+	r.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrInteractionNotFound, id)
+	}
+	pending.interaction.Metadata.Feedback = &feedback
+	return r.write(ctx, pending.interaction)
+}
+
+// Flush writes every held interaction without waiting for feedback, e.g. on shutdown
+func (r *InteractionRecorder) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	held := make([]*Interaction, 0, len(r.pending))
+	for id, pending := range r.pending {
+		pending.timer.Stop()
+		held = append(held, pending.interaction)
+		delete(r.pending, id)
+	}
+	r.mu.Unlock()
+
+	var errs []error
+	for _, interaction := range held {
+		if err := r.write(ctx, interaction); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// release writes a held interaction once its feedback window elapsed
+func (r *InteractionRecorder) release(ctx context.Context, id string) {
+	r.mu.Lock()
+	pending, exists := r.pending[id]
+	delete(r.pending, id)
+	r.mu.Unlock()
+
+	if exists {
+		r.write(ctx, pending.interaction)
+	}
+}
+
+// write sends an interaction to the sink
+func (r *InteractionRecorder) write(ctx context.Context, interaction *Interaction) error {
+	if r.config.OmitMetadata {
+		stripped := *interaction
+		stripped.Metadata = nil
+		interaction = &stripped
+	}
+	return r.config.Sink.WriteInteraction(ctx, interaction)
+}
+
+// recordInteraction records a successful execution as the messages of its final model
+// request followed by the answer
+func (a *Agent) recordInteraction(ctx context.Context, execution *AgentExecution) {
+	recorder := a.config.InteractionRecorder
+	if recorder == nil || !execution.Success || !recorder.sampled() {
+		return
+	}
+
+	assembled := a.AssembledMessages()
+	messages := make([]FineTuneMessage, 0, len(assembled)+1)
+	for _, message := range assembled {
+		messages = append(messages, FineTuneMessage{
+			Role:       message.Role,
+			Content:    message.Content,
+			ToolCalls:  message.ToolCalls,
+			ToolCallID: message.ToolCallID,
+		})
+	}
+	messages = append(messages, FineTuneMessage{Role: "assistant", Content: execution.Output})
+
+	interaction := &Interaction{
+		Messages: messages,
+		Metadata: &InteractionMetadata{
+			ID:        execution.ID,
+			Agent:     a.config.Name,
+			Provider:  a.config.Provider,
+			Model:     a.config.Model,
+			Timestamp: execution.Timestamp,
+		},
+	}
+	if a.toolRegistry != nil {
+		interaction.Tools = a.toolRegistry.GetDefinitions(a.config.Tools)
+	}
+	if err := recorder.Record(ctx, interaction); err != nil {
+		a.logger.WithError(err).WithField("execution_id", execution.ID).Warn("Failed to record interaction")
+		return
+	}
+	execution.Metadata["interaction_recorded"] = true
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactPII(t *testing.T) {
+	cases := map[string]string{
+		"Mail ada@example.com today":         "Mail [EMAIL] today",
+		"Card 4111 1111 1111 1111 expires":   "Card [CARD_NUMBER] expires",
+		"SSN 123-45-6789":                    "SSN [SSN]",
+		"Call +1 415-555-0132 after lunch":   "Call [PHONE] after lunch",
+		"Server at 192.168.10.4 is down":     "Server at [IP_ADDRESS] is down",
+		"Meeting on 2024-01-15 at 10:30 UTC": "Meeting on 2024-01-15 at 10:30 UTC",
+	}
+	for input, expected := range cases {
+		if got := RedactPII(input); got != expected {
+			t.Errorf("RedactPII(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestInteractionRecorder(t *testing.T) {
+	var output bytes.Buffer
+	recorder, err := NewInteractionRecorder(InteractionRecorderConfig{
+		Sink:           NewJSONLInteractionSink(&output),
+		FeedbackWindow: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewInteractionRecorder() failed: %v", err)
+	}
+	agent := createTestAgent(t, AgentTypeChat)
+	agent.config.InteractionRecorder = recorder
+
+	execution, err := agent.Execute(context.Background(), "Reply to ada@example.com")
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if execution.Metadata["interaction_recorded"] != true {
+		t.Errorf("Expected the execution to be recorded, got %v", execution.Metadata)
+	}
+
+	// The interaction waits for feedback before it is written
+	if output.Len() != 0 {
+		t.Fatalf("Expected the interaction to be held for feedback, got %q", output.String())
+	}
+	if err := recorder.Feedback(context.Background(), execution.ID, InteractionFeedback{Rating: 5, Comment: "helpful"}); err != nil {
+		t.Fatalf("Feedback() failed: %v", err)
+	}
+	if err := recorder.Feedback(context.Background(), execution.ID, InteractionFeedback{Rating: 1}); !errors.Is(err, ErrInteractionNotFound) {
+		t.Errorf("Expected ErrInteractionNotFound for a written interaction, got %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected one JSONL line, got %q", output.String())
+	}
+	var interaction Interaction
+	if err := json.Unmarshal([]byte(lines[0]), &interaction); err != nil {
+		t.Fatalf("Expected a JSON line, got %v", err)
+	}
+	messages := interaction.Messages
+	if len(messages) < 2 || messages[len(messages)-2].Content != "Reply to [EMAIL]" {
+		t.Errorf("Expected the redacted input before the answer, got %+v", messages)
+	}
+	if last := messages[len(messages)-1]; last.Role != "assistant" || last.Content != "Hello, World!" {
+		t.Errorf("Expected the answer last, got %+v", last)
+	}
+	if interaction.Metadata == nil || interaction.Metadata.ID != execution.ID || interaction.Metadata.Feedback.Rating != 5 {
+		t.Errorf("Expected the feedback in the metadata, got %+v", interaction.Metadata)
+	}
+
+	// Interactions without feedback are written on flush, without metadata when omitted
+	output.Reset()
+	recorder.config.OmitMetadata = true
+	if _, err := agent.Execute(context.Background(), "Hello again"); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if err := recorder.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() failed: %v", err)
+	}
+	if line := output.String(); !strings.HasPrefix(line, `{"messages":[`) || strings.Contains(line, "metadata") {
+		t.Errorf("Expected a bare fine-tuning line, got %q", line)
+	}
+
+	if _, err := NewInteractionRecorder(InteractionRecorderConfig{Sink: NewJSONLInteractionSink(&output), SampleRate: 2}); err == nil {
+		t.Error("Expected a sample rate above 1 to be rejected")
+	}
+}
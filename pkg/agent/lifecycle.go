@@ -0,0 +1,48 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+)
+
+// AgentInitializer is implemented by agent definitions that open resources, such as a
+// database connection or a cache, before they serve requests.
+//
+// Init is called once per definition when the agent is registered with an AgentManager or
+// when an AutoServer generates its endpoints, after Initialize and before CreateAgent. It
+// always completes before the server starts accepting requests for the agent. When Init
+// fails the agent is not served and Close is not called.
+type AgentInitializer interface {
+	Init(ctx context.Context) error
+}
+
+// AgentCloser is implemented by agent definitions that release resources at shutdown.
+//
+// Close is called once for every definition whose Init succeeded (or that has no Init),
+// after the server has stopped accepting requests and in-flight requests have drained.
+// Definitions are closed in the reverse order they were initialized, so an agent opened
+// after another can still use it while closing.
+type AgentCloser interface {
+	Close() error
+}
+
+// InitDefinition calls Init on definitions implementing AgentInitializer
+func InitDefinition(ctx context.Context, definition AgentDefinition) error {
+	if initializer, ok := definition.(AgentInitializer); ok {
+		return initializer.Init(ctx)
+	}
+	return nil
+}
+
+// CloseDefinition calls Close on definitions implementing AgentCloser
+func CloseDefinition(definition AgentDefinition) error {
+	if closer, ok := definition.(AgentCloser); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// languageNames names the common languages in locale instructions
+var languageNames = map[string]string{
+	"ar": "Arabic",
+	"de": "German",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"hi": "Hindi",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"nl": "Dutch",
+	"pl": "Polish",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"sv": "Swedish",
+	"tr": "Turkish",
+	"uk": "Ukrainian",
+	"zh": "Chinese",
+}
+
+// LanguageDetector detects the language of an agent's output for the locale post-check
+type LanguageDetector interface {
+	// DetectLanguage returns the language of the text as a language tag such as "fr" or "pt-BR"
+	DetectLanguage(ctx context.Context, text string) (string, error)
+}
+
+// LLMLanguageDetector detects languages by asking a model
+type LLMLanguageDetector struct {
+	manager  *llm.ProviderManager
+	provider string
+	model    string
+}
+
+// NewLLMLanguageDetector creates a language detector asking the given provider and model
+func NewLLMLanguageDetector(manager *llm.ProviderManager, provider, model string) *LLMLanguageDetector {
+	return &LLMLanguageDetector{manager: manager, provider: provider, model: model}
+}
+
+// DetectLanguage asks the model for the ISO 639-1 code of the text's language
+func (d *LLMLanguageDetector) DetectLanguage(ctx context.Context, text string) (string, error) {
+	resp, err := d.manager.Complete(ctx, d.provider, llm.CompletionRequest{
+		Model: d.model,
+		Messages: []llm.Message{
+			{Role: "system", Content: "Identify the language of the user's text. Reply with its two-letter ISO 639-1 code only, e.g. en."},
+			{Role: "user", Content: text},
+		},
+		MaxTokens: 5,
+	})
+	if err != nil {
+		return "", fmt.Errorf("language detection failed: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from LLM")
+	}
+	return strings.Trim(strings.TrimSpace(resp.Choices[0].Message.Content), ".\"'`"), nil
+}
+
+// SetLanguageDetector enables the post-check of executions with a locale and locale retries
+func (a *Agent) SetLanguageDetector(detector LanguageDetector) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.languageDetector = detector
+}
+
+// localeKey is the context key of the locale an execution responds in
+type localeKey struct{}
+
+// withLocale binds the locale an execution must respond in to the context
+func withLocale(ctx context.Context, locale string) context.Context {
+	if locale == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// localeFromContext returns the locale of the execution, if any
+func localeFromContext(ctx context.Context) string {
+	locale, _ := ctx.Value(localeKey{}).(string)
+	return locale
+}
+
+// languageName describes a locale for the model, e.g. "French (fr-CA)"
+func languageName(locale string) string {
+	if name, ok := languageNames[baseLanguage(locale)]; ok {
+		return fmt.Sprintf("%s (%s)", name, locale)
+	}
+	return locale
+}
+
+// baseLanguage returns the primary language subtag of a locale, e.g. "pt" for "pt_BR"
+func baseLanguage(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if index := strings.IndexAny(locale, "-_"); index >= 0 {
+		locale = locale[:index]
+	}
+	return locale
+}
+
+// withLocaleInstruction appends the instruction to respond in the execution's locale, last
+// so it takes precedence over the language of the conversation
+func withLocaleInstruction(ctx context.Context, messages []llm.Message) []llm.Message {
+	locale := localeFromContext(ctx)
+	if locale == "" {
+		return messages
+	}
+	return append(messages, llm.Message{
+		Role: "system",
+		Content: fmt.Sprintf("Always respond in %s, whatever the language of the user's messages, documents or tool results. "+
+			"Do not switch languages and do not translate your answer into other languages.", languageName(locale)),
+	})
+}
+
+// enforceLocale checks the language of an output and asks the model to rewrite it in the
+// locale until it matches or the retries are spent. It returns the final output and whether
+// it matches the locale.
+func (a *Agent) enforceLocale(ctx context.Context, locale, output string, retries int) (string, bool) {
+	a.mu.RLock()
+	detector := a.languageDetector
+	a.mu.RUnlock()
+	if detector == nil || retries <= 0 || output == "" {
+		return output, true
+	}
+
+	for attempt := 0; ; attempt++ {
+		detected, err := detector.DetectLanguage(ctx, output)
+		if err != nil {
+			a.logger.WithError(err).Warn("Failed to detect the output language")
+			return output, true
+		}
+		if baseLanguage(detected) == baseLanguage(locale) {
+			return output, true
+		}
+		if attempt == retries || !core.AllowRetry(ctx, core.RetryLayerAgent) {
+			a.logger.WithField("locale", locale).WithField("detected", detected).Warn("Output language does not match the locale")
+			return output, false
+		}
+
+		resp, err := a.llmManager.Complete(ctx, a.config.Provider, llm.CompletionRequest{
+			Model: a.config.Model,
+			Messages: withLocaleInstruction(ctx, []llm.Message{{
+				Role:    "user",
+				Content: fmt.Sprintf("Rewrite the following answer in %s, keeping its meaning and formatting:\n\n%s", languageName(locale), output),
+			}}),
+			Temperature: a.temperature(ctx),
+			MaxTokens:   a.config.MaxTokens,
+			Seed:        a.seed(ctx),
+		})
+		if err != nil || len(resp.Choices) == 0 {
+			a.logger.WithError(err).Warn("Failed to rewrite the output in the locale")
+			return output, false
+		}
+		output = resp.Choices[0].Message.Content
+	}
+}
+
+// ParseAcceptLanguage returns the preferred language of an Accept-Language header, or ""
+// when the header names no specific language
+func ParseAcceptLanguage(header string) string {
+	type preference struct {
+		locale string
+		weight float64
+	}
+
+	var preferences []preference
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		locale := strings.TrimSpace(fields[0])
+		if locale == "" || locale == "*" {
+			continue
+		}
+
+		weight := 1.0
+		for _, param := range fields[1:] {
+			if value, found := strings.CutPrefix(strings.TrimSpace(param), "q="); found {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					weight = q
+				}
+			}
+		}
+		if weight > 0 {
+			preferences = append(preferences, preference{locale: locale, weight: weight})
+		}
+	}
+	if len(preferences) == 0 {
+		return ""
+	}
+
+	sort.SliceStable(preferences, func(i, j int) bool { return preferences[i].weight > preferences[j].weight })
+	return preferences[0].locale
+}
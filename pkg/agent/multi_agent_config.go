@@ -10,6 +10,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
 )
 
 // MultiAgentConfig represents a configuration for multiple agents
@@ -232,6 +234,8 @@ type SharedConfig struct {
 	Environment  map[string]string             `json:"environment" yaml:"environment"`
 	Secrets      map[string]string             `json:"secrets" yaml:"secrets"`
 	LLMProviders map[string]*LLMProviderConfig `json:"llm_providers" yaml:"llm_providers"`
+	EventBus     *EventBusConfig               `json:"event_bus" yaml:"event_bus"`
+	Tools        []tools.ToolSpec              `json:"tools" yaml:"tools"`
 }
 
 // DatabaseConfig defines database configuration
@@ -44,6 +44,9 @@ type MultiAgentManager struct {
 
 	// Metrics and monitoring
 	metrics *MultiAgentMetrics
+
+	// Asynchronous agent-to-agent events
+	eventBus *EventBus
 }
 
 // MiddlewareFunc defines middleware function signature
@@ -145,6 +148,12 @@ func NewMultiAgentManager(config *MultiAgentConfig, llmManager *llm.ProviderMana
 		},
 	}
 
+	var eventBusConfig *EventBusConfig
+	if config.Shared != nil {
+		eventBusConfig = config.Shared.EventBus
+	}
+	manager.eventBus = NewEventBus(eventBusConfig)
+
 	// Initialize agents
 	if err := manager.initializeAgents(); err != nil {
 		return nil, fmt.Errorf("failed to initialize agents: %w", err)
@@ -898,10 +907,39 @@ func (mam *MultiAgentManager) Stop(ctx context.Context) error {
 	}
 	mam.mu.Unlock()
 
+	mam.eventBus.Close()
+
 	mam.logger.Info("Multi-agent manager stopped")
 	return nil
 }
 
+// EventBus returns the bus agents use to publish and subscribe to events
+func (mam *MultiAgentManager) EventBus() *EventBus {
+	return mam.eventBus
+}
+
+// PublishEvent publishes an event on behalf of an agent
+func (mam *MultiAgentManager) PublishEvent(agentID, topic string, payload interface{}) int {
+	return mam.eventBus.PublishFrom(agentID, topic, payload)
+}
+
+// OnEvent runs handler with the given agent for every event published on a topic until
+// the manager is stopped. Events are handled one at a time in the order they arrive.
+func (mam *MultiAgentManager) OnEvent(topic, agentID string, handler func(ctx context.Context, agent *Agent, event Event)) error {
+	agent, exists := mam.getAgent(agentID)
+	if !exists {
+		return fmt.Errorf("agent %s not found", agentID)
+	}
+
+	events := mam.eventBus.Subscribe(topic)
+	go func() {
+		for event := range events {
+			handler(context.Background(), agent, event)
+		}
+	}()
+	return nil
+}
+
 // GetRouter returns the HTTP router
 func (mam *MultiAgentManager) GetRouter() *mux.Router {
 	return mam.router
@@ -425,6 +425,24 @@ func TestMultiAgentManager(t *testing.T) {
 	assert.NotNil(t, deploymentState)
 	assert.Contains(t, deploymentState.AgentStates, "chat-agent")
 	assert.Contains(t, deploymentState.AgentStates, "react-agent")
+
+	// Test agent-to-agent events
+	received := make(chan Event, 1)
+	err = manager.OnEvent("findings", "react-agent", func(ctx context.Context, agent *Agent, event Event) {
+		assert.Equal(t, "react-agent", agent.GetConfig().ID)
+		received <- event
+	})
+	assert.NoError(t, err)
+	assert.Error(t, manager.OnEvent("findings", "missing-agent", func(context.Context, *Agent, Event) {}))
+
+	assert.Equal(t, 1, manager.PublishEvent("chat-agent", "findings", "summary"))
+	select {
+	case event := <-received:
+		assert.Equal(t, "chat-agent", event.Source)
+		assert.Equal(t, "summary", event.Payload)
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the event handler")
+	}
 }
 
 func TestMultiAgentManagerWithDefinitions(t *testing.T) {
@@ -0,0 +1,120 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
+)
+
+// DefaultNoContextMessage is the answer given under the refuse behavior when the agent sets
+// none
+const DefaultNoContextMessage = "I don't have information on that."
+
+// DefaultNoContextTool is the tool called under the fallback behavior when the agent sets none
+const DefaultNoContextTool = "web_search"
+
+// NoContextBehavior decides what an agent does when retrieval finds nothing relevant to the
+// input, that is when no chunk passes the retriever's similarity threshold
+type NoContextBehavior string
+
+const (
+	// NoContextAnswerFromModel answers without context, telling the model to state clearly
+	// that no sources support the answer
+	NoContextAnswerFromModel NoContextBehavior = "answer_from_model"
+	// NoContextRefuse answers with the agent's NoContextMessage without calling the model
+	NoContextRefuse NoContextBehavior = "refuse"
+	// NoContextFallback calls the agent's NoContextTool, a web search by default, with the
+	// input and gives its result to the model as the context; when the tool is missing or
+	// fails, the agent answers from the model
+	NoContextFallback NoContextBehavior = "fallback"
+)
+
+// Validate checks that the behavior is known; an empty behavior only tells the model that no
+// relevant sources were found
+func (b NoContextBehavior) Validate() error {
+	switch b {
+	case "", NoContextAnswerFromModel, NoContextRefuse, NoContextFallback:
+		return nil
+	default:
+		return fmt.Errorf("unknown no context behavior %q, expected answer_from_model, refuse or fallback", b)
+	}
+}
+
+// noSourcesInstruction follows the empty retrieved context under NoContextAnswerFromModel
+const noSourcesInstruction = "Answer from your general knowledge, and state clearly at the start of the answer " +
+	"that no sources were found to support it."
+
+// handleNoContext applies the agent's no context behavior after retrieval found nothing
+// relevant to the input. It returns the answer and true when the agent refuses without
+// calling the model.
+func (a *Agent) handleNoContext(ctx context.Context, input string, execution *AgentExecution) (string, bool) {
+	behavior := a.config.NoContextBehavior
+	if behavior == "" {
+		return "", false
+	}
+	execution.Metadata["no_context_behavior"] = string(behavior)
+
+	switch behavior {
+	case NoContextRefuse:
+		message := a.config.NoContextMessage
+		if message == "" {
+			message = DefaultNoContextMessage
+		}
+		return message, true
+	case NoContextFallback:
+		if a.fallbackContext(ctx, input, execution) {
+			return "", false
+		}
+	}
+
+	message := llm.NewContextMessage([]llm.ContextChunk{})
+	message.Content += " " + noSourcesInstruction
+	a.mu.Lock()
+	a.retrievedContext = &message
+	a.mu.Unlock()
+	return "", false
+}
+
+// fallbackContext replaces the empty retrieved context with the result of the fallback tool
+// called with the input, and reports whether it did
+func (a *Agent) fallbackContext(ctx context.Context, input string, execution *AgentExecution) bool {
+	name := a.config.NoContextTool
+	if name == "" {
+		name = DefaultNoContextTool
+	}
+	logger := logging.FromContext(ctx, a.logger).WithField("tool", name)
+
+	tool, exists := a.toolRegistry.GetTool(name)
+	if !exists {
+		logger.Warn("No context fallback tool not found, answering from the model")
+		return false
+	}
+	arguments, err := json.Marshal(map[string]string{"query": input})
+	if err != nil {
+		return false
+	}
+	call := llm.ToolCall{Type: "function", Function: llm.FunctionCall{Name: name, Arguments: string(arguments)}}
+	if err := countToolCall(ctx, call); err != nil {
+		logger.WithError(err).Warn("No context fallback refused, answering from the model")
+		return false
+	}
+
+	result, err := a.executeTool(ctx, tool, string(arguments))
+	if err != nil || result == "" {
+		logger.WithError(err).Warn("No context fallback failed, answering from the model")
+		return false
+	}
+
+	a.SetRetrievedContext([]llm.ContextChunk{{SourceID: name, Title: tool.GetDescription(), Content: result}})
+	execution.Metadata["no_context_fallback"] = name
+	return true
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// PreflightError lists every problem found while checking an agent's configuration
+type PreflightError struct {
+	AgentID   string
+	AgentName string
+	Problems  []string
+}
+
+// Error implements the error interface
+func (e *PreflightError) Error() string {
+	return fmt.Sprintf("agent %s failed preflight: %s", e.AgentName, strings.Join(e.Problems, "; "))
+}
+
+// Preflight checks that the agent can serve requests: the provider is set and reachable,
+// the model is available, every configured tool is registered with a valid schema and the
+// system prompt template renders. All problems are reported in a single PreflightError.
+func (a *Agent) Preflight(ctx context.Context) error {
+	result := &PreflightError{AgentID: a.config.ID, AgentName: a.config.Name}
+
+	if a.config.Type == AgentTypeGraph {
+		if a.graph == nil {
+			result.Problems = append(result.Problems, "graph is not set")
+		} else if err := a.graph.Validate(); err != nil {
+			result.Problems = append(result.Problems, fmt.Sprintf("invalid graph: %v", err))
+		}
+	} else {
+		result.Problems = append(result.Problems, a.preflightProvider(ctx)...)
+	}
+
+	for _, name := range a.config.Tools {
+		tool, exists := a.toolRegistry.GetTool(name)
+		if !exists {
+			result.Problems = append(result.Problems, fmt.Sprintf("tool %s is not registered", name))
+			continue
+		}
+		if err := validateToolDefinition(tool.GetDefinition()); err != nil {
+			result.Problems = append(result.Problems, fmt.Sprintf("tool %s has an invalid schema: %v", name, err))
+		}
+	}
+
+	if _, err := a.renderSystemPrompt(); err != nil {
+		result.Problems = append(result.Problems, fmt.Sprintf("system prompt does not render: %v", err))
+	}
+	if a.config.PromptComposer != nil {
+		if _, err := a.config.PromptComposer.Render(core.NewBaseState()); err != nil {
+			result.Problems = append(result.Problems, fmt.Sprintf("system prompt does not compose: %v", err))
+		}
+	}
+
+	if len(result.Problems) > 0 {
+		return result
+	}
+	return nil
+}
+
+// preflightProvider checks that the provider is reachable and serves the configured model
+func (a *Agent) preflightProvider(ctx context.Context) []string {
+	providerName, model, err := a.llmManager.ResolveModel(a.config.Provider, a.config.Model)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	var provider llm.Provider
+	if providerName == "" {
+		provider, err = a.llmManager.GetDefaultProvider()
+	} else {
+		provider, err = a.llmManager.GetProvider(providerName)
+	}
+	if err != nil {
+		return []string{fmt.Sprintf("provider is not available: %v", err)}
+	}
+
+	if err := provider.IsHealthy(ctx); err != nil {
+		return []string{fmt.Sprintf("provider %s is not reachable: %v", provider.GetName(), err)}
+	}
+
+	if model == "" {
+		return nil
+	}
+	models, err := provider.GetModels(ctx)
+	if err != nil {
+		return []string{fmt.Sprintf("failed to list models of provider %s: %v", provider.GetName(), err)}
+	}
+	// Some providers cannot list their models
+	if len(models) == 0 {
+		return nil
+	}
+	for _, available := range models {
+		if available == model {
+			return nil
+		}
+	}
+	return []string{fmt.Sprintf("model %s is not available from provider %s", model, provider.GetName())}
+}
+
+// validateToolDefinition checks that a tool definition is a usable function schema
+func validateToolDefinition(definition llm.ToolDefinition) error {
+	if definition.Function.Name == "" {
+		return fmt.Errorf("function name is required")
+	}
+	if definition.Function.Parameters == nil {
+		return nil
+	}
+
+	if schemaType, exists := definition.Function.Parameters["type"]; exists && schemaType != "object" {
+		return fmt.Errorf("parameters must be an object schema, got %v", schemaType)
+	}
+
+	properties, _ := definition.Function.Parameters["properties"].(map[string]interface{})
+	var required []string
+	switch values := definition.Function.Parameters["required"].(type) {
+	case []string:
+		required = values
+	case []interface{}:
+		for _, value := range values {
+			required = append(required, fmt.Sprintf("%v", value))
+		}
+	}
+	for _, name := range required {
+		if _, exists := properties[name]; !exists {
+			return fmt.Errorf("required parameter %s is not defined", name)
+		}
+	}
+	return nil
+}
+
+// renderSystemPrompt renders the system prompt as a text/template when it contains actions.
+// The template can reference .Name, .Type, .Model, .Tools and .Metadata.
+func (a *Agent) renderSystemPrompt() (string, error) {
+	prompt := a.config.SystemPrompt
+	if !strings.Contains(prompt, "{{") {
+		return prompt, nil
+	}
+
+	tmpl, err := template.New("system_prompt").Option("missingkey=error").Parse(prompt)
+	if err != nil {
+		return "", err
+	}
+
+	var rendered strings.Builder
+	err = tmpl.Execute(&rendered, map[string]interface{}{
+		"Name":     a.config.Name,
+		"Type":     a.config.Type,
+		"Model":    a.config.Model,
+		"Tools":    a.config.Tools,
+		"Metadata": a.config.Metadata,
+	})
+	if err != nil {
+		return "", err
+	}
+	return rendered.String(), nil
+}
+
+// systemPrompt returns the rendered system prompt, falling back to the raw prompt if it
+// does not render
+func (a *Agent) systemPrompt() string {
+	prompt, err := a.renderSystemPrompt()
+	if err != nil {
+		a.logger.WithError(err).Warn("Failed to render system prompt, using it verbatim")
+		return a.config.SystemPrompt
+	}
+	return prompt
+}
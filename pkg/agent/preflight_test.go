@@ -0,0 +1,68 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// brokenSchemaTool requires a parameter its schema does not define
+type brokenSchemaTool struct {
+	*TestTool
+}
+
+func (bt *brokenSchemaTool) GetDefinition() llm.ToolDefinition {
+	definition := bt.TestTool.GetDefinition()
+	definition.Function.Parameters["required"] = []string{"input", "missing"}
+	return definition
+}
+
+func TestAgent_Preflight(t *testing.T) {
+	agent := createTestAgent(t, AgentTypeChat)
+	agent.config.Tools = []string{"calculator"}
+	agent.config.SystemPrompt = "You are {{.Name}}."
+	require.NoError(t, agent.Preflight(context.Background()))
+	assert.Equal(t, "You are test-agent.", agent.systemPrompt())
+
+	// Every problem is reported at once
+	agent.config.Model = "unknown-model"
+	agent.config.Tools = []string{"calculator", "not-registered", "broken"}
+	agent.config.SystemPrompt = "You are {{.Missing}}."
+	require.NoError(t, agent.toolRegistry.RegisterTool(&brokenSchemaTool{TestTool: &TestTool{name: "broken"}}))
+
+	err := agent.Preflight(context.Background())
+	var preflightErr *PreflightError
+	require.True(t, errors.As(err, &preflightErr))
+	require.Len(t, preflightErr.Problems, 4)
+	assert.Contains(t, err.Error(), "model unknown-model is not available")
+	assert.Contains(t, err.Error(), "tool not-registered is not registered")
+	assert.Contains(t, err.Error(), "required parameter missing is not defined")
+	assert.Contains(t, err.Error(), "system prompt does not render")
+
+	// Unrenderable prompts are sent verbatim
+	assert.Equal(t, "You are {{.Missing}}.", agent.systemPrompt())
+}
+
+func TestAgent_PreflightProvider(t *testing.T) {
+	agent := NewAgent(&AgentConfig{Name: "orphan", Type: AgentTypeChat, Provider: "missing"}, llm.NewProviderManager(), nil)
+	err := agent.Preflight(context.Background())
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "provider is not available"))
+
+	// Graph agents are checked by validating their graph
+	assert.NoError(t, NewGraphAgent(newUppercaseGraph(), "question", "answer").Preflight(context.Background()))
+	assert.Error(t, NewGraphAgent(core.NewGraph("empty"), "", "").Preflight(context.Background()))
+}
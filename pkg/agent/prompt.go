@@ -0,0 +1,148 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+)
+
+// PromptFragmentFunc renders a dynamic prompt fragment from the execution state; preflight
+// renders it with an empty state. An empty result leaves the fragment out of the prompt.
+type PromptFragmentFunc func(state *core.BaseState) (string, error)
+
+// PromptFragment is a named part of a system prompt, either static text or rendered from
+// the state
+type PromptFragment struct {
+	Name   string
+	Text   string
+	Render PromptFragmentFunc
+}
+
+// PromptComposer assembles an agent's system prompt from ordered named fragments, such as
+// the role, format rules, tool usage and safety guidance, so fragments can be shared
+// between agents and tested on their own
+type PromptComposer struct {
+	fragments []PromptFragment
+	separator string
+}
+
+// NewPromptComposer creates a composer with the fragments in order
+func NewPromptComposer(fragments ...PromptFragment) *PromptComposer {
+	pc := &PromptComposer{separator: "\n\n"}
+	for _, fragment := range fragments {
+		pc.Add(fragment)
+	}
+	return pc
+}
+
+// Add appends a fragment; a fragment with the name of an existing one replaces it in place
+func (pc *PromptComposer) Add(fragment PromptFragment) *PromptComposer {
+	for i, existing := range pc.fragments {
+		if existing.Name == fragment.Name {
+			pc.fragments[i] = fragment
+			return pc
+		}
+	}
+	pc.fragments = append(pc.fragments, fragment)
+	return pc
+}
+
+// Static appends a fragment of fixed text
+func (pc *PromptComposer) Static(name, text string) *PromptComposer {
+	return pc.Add(PromptFragment{Name: name, Text: text})
+}
+
+// Dynamic appends a fragment rendered from the state
+func (pc *PromptComposer) Dynamic(name string, render PromptFragmentFunc) *PromptComposer {
+	return pc.Add(PromptFragment{Name: name, Render: render})
+}
+
+// Remove removes the named fragment
+func (pc *PromptComposer) Remove(name string) *PromptComposer {
+	for i, fragment := range pc.fragments {
+		if fragment.Name == name {
+			pc.fragments = append(pc.fragments[:i], pc.fragments[i+1:]...)
+			break
+		}
+	}
+	return pc
+}
+
+// SetSeparator sets the text placed between fragments; fragments are separated by a blank
+// line by default
+func (pc *PromptComposer) SetSeparator(separator string) *PromptComposer {
+	pc.separator = separator
+	return pc
+}
+
+// Names returns the names of the fragments in order
+func (pc *PromptComposer) Names() []string {
+	names := make([]string, len(pc.fragments))
+	for i, fragment := range pc.fragments {
+		names[i] = fragment.Name
+	}
+	return names
+}
+
+// RenderFragment renders a single fragment for the state
+func (pc *PromptComposer) RenderFragment(name string, state *core.BaseState) (string, error) {
+	for _, fragment := range pc.fragments {
+		if fragment.Name == name {
+			return fragment.render(state)
+		}
+	}
+	return "", fmt.Errorf("prompt fragment %s not found", name)
+}
+
+// Render assembles the system prompt for the state, leaving out empty fragments
+func (pc *PromptComposer) Render(state *core.BaseState) (string, error) {
+	parts := make([]string, 0, len(pc.fragments))
+	for _, fragment := range pc.fragments {
+		text, err := fragment.render(state)
+		if err != nil {
+			return "", err
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, pc.separator), nil
+}
+
+// render renders the fragment, naming it in the error
+func (pf PromptFragment) render(state *core.BaseState) (string, error) {
+	if pf.Render == nil {
+		return pf.Text, nil
+	}
+	text, err := pf.Render(state)
+	if err != nil {
+		return "", fmt.Errorf("prompt fragment %s: %w", pf.Name, err)
+	}
+	return text, nil
+}
+
+// hasSystemPrompt reports whether the agent has a system prompt or prompt composer
+func (a *Agent) hasSystemPrompt() bool {
+	return a.config.SystemPrompt != "" || a.config.PromptComposer != nil
+}
+
+// systemPromptFor returns the system prompt for the state: the composer's prompt when the
+// agent has one, falling back to the configured prompt if it fails to render
+func (a *Agent) systemPromptFor(state *core.BaseState) string {
+	if a.config.PromptComposer == nil {
+		return a.systemPrompt()
+	}
+	prompt, err := a.config.PromptComposer.Render(state)
+	if err != nil {
+		a.logger.WithError(err).Warn("Failed to compose system prompt, using the configured prompt")
+		return a.systemPrompt()
+	}
+	return prompt
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
+)
+
+func TestPromptComposer(t *testing.T) {
+	composer := NewPromptComposer().
+		Static("role", "You are a support agent.").
+		Dynamic("task", func(state *core.BaseState) (string, error) {
+			input, ok := state.Get("input")
+			if !ok {
+				return "", nil
+			}
+			return fmt.Sprintf("The user asked: %v", input), nil
+		}).
+		Static("format", "Answer in one sentence.")
+
+	prompt, err := composer.Render(core.NewBaseState())
+	require.NoError(t, err)
+	assert.Equal(t, "You are a support agent.\n\nAnswer in one sentence.", prompt)
+
+	state := core.NewBaseState()
+	state.Set("input", "where is my order?")
+	prompt, err = composer.Render(state)
+	require.NoError(t, err)
+	assert.Equal(t, "You are a support agent.\n\nThe user asked: where is my order?\n\nAnswer in one sentence.", prompt)
+
+	// Fragments render on their own and can be replaced in place or removed
+	fragment, err := composer.RenderFragment("format", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Answer in one sentence.", fragment)
+	_, err = composer.RenderFragment("missing", nil)
+	assert.Error(t, err)
+
+	composer.Static("role", "You are a billing agent.").Remove("task").SetSeparator("\n")
+	assert.Equal(t, []string{"role", "format"}, composer.Names())
+	prompt, err = composer.Render(state)
+	require.NoError(t, err)
+	assert.Equal(t, "You are a billing agent.\nAnswer in one sentence.", prompt)
+
+	// Errors name the failing fragment
+	composer.Dynamic("safety", func(state *core.BaseState) (string, error) {
+		return "", errors.New("policy unavailable")
+	})
+	_, err = composer.Render(nil)
+	assert.EqualError(t, err, "prompt fragment safety: policy unavailable")
+}
+
+func TestAgent_PromptComposer(t *testing.T) {
+	provider := &sequenceProvider{messages: []llm.Message{{Role: "assistant", Content: "On its way."}}}
+	llmManager := llm.NewProviderManager()
+	require.NoError(t, llmManager.RegisterProvider("mock", provider))
+
+	config := &AgentConfig{
+		Name:         "support",
+		Type:         AgentTypeChat,
+		Provider:     "mock",
+		Model:        "test-model",
+		SystemPrompt: "Fallback prompt.",
+		PromptComposer: NewPromptComposer().
+			Static("role", "You are a support agent.").
+			Dynamic("task", func(state *core.BaseState) (string, error) {
+				input, _ := state.Get("input")
+				return fmt.Sprintf("The user asked: %v", input), nil
+			}),
+	}
+	agent := NewAgent(config, llmManager, tools.NewToolRegistry())
+
+	_, err := agent.Execute(context.Background(), "where is my order?")
+	require.NoError(t, err)
+	require.Len(t, provider.requests, 1)
+	assert.Equal(t, llm.Message{Role: "system", Content: "You are a support agent.\n\nThe user asked: where is my order?"}, provider.requests[0].Messages[0])
+
+	// A composer that fails falls back to the configured prompt and fails preflight
+	config.PromptComposer.Dynamic("safety", func(state *core.BaseState) (string, error) {
+		return "", errors.New("policy unavailable")
+	})
+	_, err = agent.Execute(context.Background(), "and now?")
+	require.NoError(t, err)
+	assert.Equal(t, "Fallback prompt.", provider.requests[1].Messages[0].Content)
+	assert.ErrorContains(t, agent.Preflight(context.Background()), "system prompt does not compose")
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned by Execute when an agent's rate limit is exceeded
+var ErrRateLimited = errors.New("agent rate limit exceeded")
+
+// maxRateLimitBuckets bounds the number of per-session buckets kept in memory
+const maxRateLimitBuckets = 10000
+
+// AgentRateLimit caps how often an agent may execute, independently of provider limits
+type AgentRateLimit struct {
+	RequestsPerMinute int `json:"requests_per_minute" yaml:"requests_per_minute"`
+	// Burst is the number of executions allowed at once; defaults to 1
+	Burst int `json:"burst" yaml:"burst"`
+	// PerSession applies the limit to each session separately instead of the whole agent
+	PerSession bool `json:"per_session" yaml:"per_session"`
+}
+
+// RateLimitError reports a rejected execution and when the agent may run again
+type RateLimitError struct {
+	Agent      string
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%v for agent %s, retry after %s", ErrRateLimited, e.Agent, e.RetryAfter.Round(time.Millisecond))
+}
+
+// Unwrap returns ErrRateLimited
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// tokenBucket holds the executions currently available to a key
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is a token bucket limiter keyed by agent or session
+type rateLimiter struct {
+	rate    float64 // tokens per second
+	burst   float64
+	buckets map[string]*tokenBucket
+	mu      sync.Mutex
+}
+
+// newRateLimiter creates a limiter for the configured limit, or nil when there is none
+func newRateLimiter(limit *AgentRateLimit) *rateLimiter {
+	if limit == nil || limit.RequestsPerMinute <= 0 {
+		return nil
+	}
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:    float64(limit.RequestsPerMinute) / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes a token for the key, or returns how long to wait for the next one
+func (rl *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	bucket, exists := rl.buckets[key]
+	if !exists {
+		if len(rl.buckets) >= maxRateLimitBuckets {
+			rl.prune(now)
+		}
+		bucket = &tokenBucket{tokens: rl.burst, last: now}
+		rl.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(rl.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*rl.rate)
+	bucket.last = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - bucket.tokens) / rl.rate * float64(time.Second))
+	return false, wait
+}
+
+// prune drops buckets that have refilled completely, as they behave like new ones
+func (rl *rateLimiter) prune(now time.Time) {
+	for key, bucket := range rl.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*rl.rate >= rl.burst {
+			delete(rl.buckets, key)
+		}
+	}
+}
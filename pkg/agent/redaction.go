@@ -0,0 +1,199 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// DefaultRedactionHoldback is the number of bytes at the end of a streamed answer held back
+// until the next delta, so a match split across deltas is redacted whole
+const DefaultRedactionHoldback = 128
+
+// RedactionRule replaces the text matching a regular expression in the output of an agent
+type RedactionRule struct {
+	Name    string `json:"name" yaml:"name"`
+	Pattern string `json:"pattern" yaml:"pattern"`
+	// Replacement replaces each match and may refer to groups as $1; defaults to
+	// "[REDACTED]"
+	Replacement string `json:"replacement,omitempty" yaml:"replacement,omitempty"`
+}
+
+// Redaction presets for personal data commonly echoed by models. Credit cards come before
+// phone numbers, which would otherwise match part of a card number.
+var (
+	RedactCreditCards = RedactionRule{
+		Name:        "credit_card",
+		Pattern:     `\b(?:\d[ -]?){12,18}\d\b`,
+		Replacement: "[CREDIT_CARD]",
+	}
+	RedactSSNs = RedactionRule{
+		Name:        "ssn",
+		Pattern:     `\b\d{3}-\d{2}-\d{4}\b`,
+		Replacement: "[SSN]",
+	}
+	RedactEmails = RedactionRule{
+		Name:        "email",
+		Pattern:     `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`,
+		Replacement: "[EMAIL]",
+	}
+	RedactPhoneNumbers = RedactionRule{
+		Name:        "phone",
+		Pattern:     `(?:\+\d{1,3}[ .-]?)?(?:\(\d{2,4}\)|\b\d{2,4})[ .-]?\d{3}[ .-]?\d{3,4}\b`,
+		Replacement: "[PHONE]",
+	}
+	RedactIPAddresses = RedactionRule{
+		Name:        "ip_address",
+		Pattern:     `\b(?:\d{1,3}\.){3}\d{1,3}\b`,
+		Replacement: "[IP_ADDRESS]",
+	}
+)
+
+// PIIRedactionRules returns the presets, in the order they should be applied
+func PIIRedactionRules() []RedactionRule {
+	return []RedactionRule{RedactCreditCards, RedactSSNs, RedactEmails, RedactPhoneNumbers, RedactIPAddresses}
+}
+
+// RedactionFilter sanitizes what an agent returns: its final output, and its streamed tokens
+// and thoughts. It is distinct from the injection guard, which checks what comes in.
+type RedactionFilter struct {
+	// Holdback is the number of bytes held back at the end of a stream until more arrives;
+	// it should exceed the longest text a rule can match. Defaults to
+	// DefaultRedactionHoldback.
+	Holdback int
+
+	rules    []RedactionRule
+	patterns []*regexp.Regexp
+}
+
+// NewRedactionFilter creates a filter applying the rules in order
+func NewRedactionFilter(rules ...RedactionRule) (*RedactionFilter, error) {
+	filter := &RedactionFilter{rules: rules, patterns: make([]*regexp.Regexp, len(rules))}
+	for i, rule := range rules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction rule %s: %w", rule.Name, err)
+		}
+		if rule.Replacement == "" {
+			filter.rules[i].Replacement = "[REDACTED]"
+		}
+		filter.patterns[i] = pattern
+	}
+	return filter, nil
+}
+
+// Redact returns text with the matches of every rule replaced, and the names of the rules
+// that matched
+func (f *RedactionFilter) Redact(text string) (string, []string) {
+	var matched []string
+	for i, pattern := range f.patterns {
+		if !pattern.MatchString(text) {
+			continue
+		}
+		text = pattern.ReplaceAllString(text, f.rules[i].Replacement)
+		matched = append(matched, f.rules[i].Name)
+	}
+	return text, matched
+}
+
+// holdback returns the configured holdback or its default
+func (f *RedactionFilter) holdback() int {
+	if f.Holdback > 0 {
+		return f.Holdback
+	}
+	return DefaultRedactionHoldback
+}
+
+// safeCut returns how much of pending text can be redacted and sent: everything but the
+// holdback, moved back so no current match is split, nor a rune
+func (f *RedactionFilter) safeCut(text string) int {
+	cut := len(text) - f.holdback()
+	if cut <= 0 {
+		return 0
+	}
+
+	var matches [][]int
+	for _, pattern := range f.patterns {
+		matches = append(matches, pattern.FindAllStringIndex(text, -1)...)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i][0] > matches[j][0]
+	})
+	for _, match := range matches {
+		if match[0] < cut && match[1] > cut {
+			cut = match[0]
+		}
+	}
+
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return cut
+}
+
+// redactTokens wraps an event emitter so the tokens and thoughts it sends are redacted.
+// Token text within the filter's holdback of the end is held until the next delta, so a
+// match split across deltas is redacted whole; it is sent ahead of any other event.
+func redactTokens(filter *RedactionFilter, emit func(StreamEvent)) func(StreamEvent) {
+	if filter == nil || len(filter.patterns) == 0 {
+		return emit
+	}
+
+	var mu sync.Mutex
+	var pending strings.Builder
+	return func(event StreamEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if event.Type == StreamEventToken {
+			pending.WriteString(event.Content)
+			text := pending.String()
+			cut := filter.safeCut(text)
+			if cut == 0 {
+				return
+			}
+			pending.Reset()
+			pending.WriteString(text[cut:])
+			event.Content, _ = filter.Redact(text[:cut])
+			emit(event)
+			return
+		}
+
+		if pending.Len() > 0 {
+			content, _ := filter.Redact(pending.String())
+			emit(StreamEvent{Type: StreamEventToken, Content: content})
+			pending.Reset()
+		}
+		if event.Type == StreamEventThought {
+			event.Content, _ = filter.Redact(event.Content)
+		}
+		emit(event)
+	}
+}
+
+// redactOutput applies the agent's output filter to the final output of an execution
+func (a *Agent) redactOutput(execution *AgentExecution) {
+	filter := a.config.OutputFilter
+	if filter == nil {
+		return
+	}
+
+	output, matched := filter.Redact(execution.Output)
+	if len(matched) == 0 {
+		return
+	}
+	if _, isString := execution.StructuredOutput.(string); isString {
+		execution.StructuredOutput = output
+	}
+	execution.Output = output
+	execution.Metadata["redacted"] = matched
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
+)
+
+// piiProvider streams an answer whose personal data is split across fragments
+type piiProvider struct {
+	mockProvider
+}
+
+func (p *piiProvider) CompleteStream(ctx context.Context, req llm.CompletionRequest, callback llm.StreamCallback) error {
+	for _, fragment := range []string{"Write to jane.do", "e@example.com or pay with 4111 1111 ", "1111 1111", " today."} {
+		if err := callback(llm.CompletionResponse{Choices: []llm.Choice{{Delta: llm.Message{Content: fragment}}}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestRedactionFilter_Redact(t *testing.T) {
+	filter, err := NewRedactionFilter(PIIRedactionRules()...)
+	if err != nil {
+		t.Fatalf("NewRedactionFilter() failed: %v", err)
+	}
+
+	tests := map[string]string{
+		"Mail bob@corp.io now":            "Mail [EMAIL] now",
+		"Card 4111-1111-1111-1111 works":  "Card [CREDIT_CARD] works",
+		"Call +1 (555) 123-4567 tomorrow": "Call [PHONE] tomorrow",
+		"SSN 123-45-6789, IP 10.0.0.1":    "SSN [SSN], IP [IP_ADDRESS]",
+		"Pi is 3.14 and 42 is the answer": "Pi is 3.14 and 42 is the answer",
+	}
+	for input, expected := range tests {
+		if got, _ := filter.Redact(input); got != expected {
+			t.Errorf("Redact(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+
+	custom, err := NewRedactionFilter(RedactionRule{Name: "ticket", Pattern: `TICKET-(\d+)`, Replacement: "TICKET-***"})
+	if err != nil {
+		t.Fatalf("NewRedactionFilter() failed: %v", err)
+	}
+	if got, matched := custom.Redact("See TICKET-991"); got != "See TICKET-***" || len(matched) != 1 || matched[0] != "ticket" {
+		t.Errorf("Expected the custom rule to apply, got %q (%v)", got, matched)
+	}
+
+	if _, err := NewRedactionFilter(RedactionRule{Name: "broken", Pattern: "("}); err == nil {
+		t.Error("Expected an invalid pattern to be rejected")
+	}
+}
+
+func TestAgent_OutputFilter_Stream(t *testing.T) {
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", &piiProvider{}); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+	filter, err := NewRedactionFilter(PIIRedactionRules()...)
+	if err != nil {
+		t.Fatalf("NewRedactionFilter() failed: %v", err)
+	}
+	filter.Holdback = 24
+	agent := NewAgent(&AgentConfig{
+		Name:         "test-agent",
+		Type:         AgentTypeChat,
+		Provider:     "mock",
+		Model:        "test-model",
+		OutputFilter: filter,
+	}, llmManager, tools.NewToolRegistry())
+
+	events, err := agent.Stream(context.Background(), "How do I reach you?")
+	if err != nil {
+		t.Fatalf("Stream() failed: %v", err)
+	}
+	expected := "Write to [EMAIL] or pay with [CREDIT_CARD] today."
+	var streamed strings.Builder
+	for event := range events {
+		switch event.Type {
+		case StreamEventToken:
+			if strings.Contains(event.Content, "@") || strings.Contains(event.Content, "1111") {
+				t.Errorf("Expected no personal data in a token, got %q", event.Content)
+			}
+			streamed.WriteString(event.Content)
+		case StreamEventDone:
+			if event.Content != expected {
+				t.Errorf("Expected the redacted answer on the done event, got %q", event.Content)
+			}
+			if matched, _ := event.Execution.Metadata["redacted"].([]string); len(matched) != 2 {
+				t.Errorf("Expected the matched rules in the metadata, got %v", event.Execution.Metadata["redacted"])
+			}
+		case StreamEventError:
+			t.Fatalf("Unexpected error: %v", event.Err)
+		}
+	}
+	if streamed.String() != expected {
+		t.Errorf("Expected the redacted tokens %q, got %q", expected, streamed.String())
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/rag"
+)
+
+// ContextRetriever retrieves the context chunks relevant to an execution's input, such as
+// the documents of a vector search converted with persistence.ContextChunks
+type ContextRetriever func(ctx context.Context, input string) ([]llm.ContextChunk, error)
+
+// RetrieverContext adapts a RAG retriever to a ContextRetriever, retrieving with the given
+// options
+func RetrieverContext(retriever rag.Retriever, options rag.RetrieveOptions) ContextRetriever {
+	return func(ctx context.Context, input string) ([]llm.ContextChunk, error) {
+		chunks, err := retriever.Retrieve(ctx, input, options)
+		if err != nil {
+			return nil, err
+		}
+		return rag.ContextChunks(chunks), nil
+	}
+}
+
+// contextRetriever returns the configured ContextRetriever, else one over the configured
+// Retriever, or nil when neither is set
+func (a *Agent) contextRetriever() ContextRetriever {
+	if a.config.ContextRetriever != nil {
+		return a.config.ContextRetriever
+	}
+	if a.config.Retriever == nil {
+		return nil
+	}
+
+	var options rag.RetrieveOptions
+	if a.config.RetrieveOptions != nil {
+		options = *a.config.RetrieveOptions
+	}
+	return RetrieverContext(a.config.Retriever, options)
+}
+
+// SetRetrievedContext replaces the retrieved context given to the model with chunks. The
+// context is sent as one structured message placed by the context order and is never added
+// to the conversation history; nil chunks remove it.
+func (a *Agent) SetRetrievedContext(chunks []llm.ContextChunk) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if chunks == nil {
+		a.retrievedContext = nil
+		return
+	}
+	message := llm.NewContextMessage(chunks)
+	a.retrievedContext = &message
+}
+
+// RetrievedContext returns the chunks of the current retrieved context
+func (a *Agent) RetrievedContext() []llm.ContextChunk {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.retrievedContext == nil {
+		return nil
+	}
+	return llm.ContextChunks(*a.retrievedContext)
+}
+
+// ClearRetrievedContext removes the retrieved context
+func (a *Agent) ClearRetrievedContext() {
+	a.SetRetrievedContext(nil)
+}
+
+// refreshRetrievedContext replaces the retrieved context with the chunks retrieved for an
+// input when a retriever is configured, and reports whether nothing relevant was retrieved.
+// A failed retrieval clears the context rather than leaving the chunks of an earlier input.
+func (a *Agent) refreshRetrievedContext(ctx context.Context, input string, execution *AgentExecution) bool {
+	retriever := a.contextRetriever()
+	if retriever == nil {
+		return false
+	}
+
+	chunks, err := retriever(ctx, input)
+	if err != nil {
+		logging.FromContext(ctx, a.logger).WithError(err).Warn("Context retrieval failed, continuing without retrieved context")
+		a.ClearRetrievedContext()
+		return false
+	}
+	if chunks == nil {
+		chunks = []llm.ContextChunk{}
+	}
+	a.SetRetrievedContext(chunks)
+	execution.Metadata["retrieved_chunks"] = len(chunks)
+	if len(chunks) == 0 {
+		execution.Metadata["no_relevant_context"] = true
+		return true
+	}
+	return false
+}
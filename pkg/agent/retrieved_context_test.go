@@ -0,0 +1,203 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/rag"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
+)
+
+func TestAgent_RetrievedContext(t *testing.T) {
+	provider := &sequenceProvider{messages: []llm.Message{{Role: "assistant", Content: "Go was released in 2009 [1]."}}}
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", provider); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+
+	retrievals := 0
+	agent := NewAgent(&AgentConfig{
+		Name:         "rag",
+		Type:         AgentTypeChat,
+		Provider:     "mock",
+		Model:        "test-model",
+		SystemPrompt: "Answer from the sources.",
+		ContextRetriever: func(ctx context.Context, input string) ([]llm.ContextChunk, error) {
+			retrievals++
+			if retrievals > 2 {
+				return nil, errors.New("index unavailable")
+			}
+			return []llm.ContextChunk{{SourceID: "doc-" + input, Content: "Go was released in 2009."}}, nil
+		},
+	}, llmManager, tools.NewToolRegistry())
+
+	for _, input := range []string{"1", "2"} {
+		execution, err := agent.Execute(context.Background(), input)
+		if err != nil {
+			t.Fatalf("Execute() failed: %v", err)
+		}
+		if execution.Metadata["retrieved_chunks"] != 1 {
+			t.Errorf("Expected the retrieved chunks in the metadata, got %v", execution.Metadata)
+		}
+	}
+
+	// The block follows the system prompt and is replaced on each input, not accumulated
+	messages := provider.requests[1].Messages
+	if messages[0].Content != "Answer from the sources." || !llm.IsContextMessage(messages[1]) {
+		t.Fatalf("Expected the context message after the system prompt, got %+v", messages)
+	}
+	contextMessages := 0
+	for _, msg := range messages {
+		if llm.IsContextMessage(msg) {
+			contextMessages++
+		}
+	}
+	if chunks := llm.ContextChunks(messages[1]); contextMessages != 1 || len(chunks) != 1 || chunks[0].SourceID != "doc-2" {
+		t.Errorf("Expected only the context of the latest input, got %d messages with %+v", contextMessages, chunks)
+	}
+	for _, msg := range agent.GetConversation() {
+		if llm.IsContextMessage(msg) {
+			t.Errorf("Retrieved context should not be part of the conversation history")
+		}
+	}
+
+	// A failed retrieval drops the stale context
+	if _, err := agent.Execute(context.Background(), "3"); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if agent.RetrievedContext() != nil {
+		t.Errorf("Expected the context to be cleared, got %+v", agent.RetrievedContext())
+	}
+	for _, msg := range provider.requests[2].Messages {
+		if llm.IsContextMessage(msg) {
+			t.Errorf("Expected no context message after a failed retrieval")
+		}
+	}
+}
+
+func TestAgent_Retriever(t *testing.T) {
+	provider := &sequenceProvider{messages: []llm.Message{{Role: "assistant", Content: "Use the cluster API [1]."}}}
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", provider); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+
+	// A custom backend, such as a search cluster, only has to implement rag.Retriever
+	var received rag.RetrieveOptions
+	retriever := rag.RetrieverFunc(func(ctx context.Context, query string, options rag.RetrieveOptions) ([]rag.RetrievedChunk, error) {
+		received = options
+		return []rag.RetrievedChunk{{ID: "es-42", Title: "Cluster guide", Content: "Use the cluster API.", Score: 7.5}}, nil
+	})
+	agent := NewAgent(&AgentConfig{
+		Name:            "rag",
+		Type:            AgentTypeChat,
+		Provider:        "mock",
+		Model:           "test-model",
+		Retriever:       retriever,
+		RetrieveOptions: &rag.RetrieveOptions{TopK: 3},
+	}, llmManager, tools.NewToolRegistry())
+
+	execution, err := agent.Execute(context.Background(), "How do I scale?")
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if execution.Metadata["retrieved_chunks"] != 1 || received.TopK != 3 {
+		t.Errorf("Expected one chunk retrieved with the options, got %v and %+v", execution.Metadata, received)
+	}
+	chunks := agent.RetrievedContext()
+	if len(chunks) != 1 || chunks[0].SourceID != "es-42" || chunks[0].Title != "Cluster guide" {
+		t.Errorf("Expected the retrieved chunk as context, got %+v", chunks)
+	}
+}
+
+func TestAgent_NoContextBehavior(t *testing.T) {
+	newAgent := func(behavior NoContextBehavior, tool string, registry *tools.ToolRegistry) (*Agent, *sequenceProvider) {
+		provider := &sequenceProvider{messages: []llm.Message{{Role: "assistant", Content: "From what I know..."}}}
+		llmManager := llm.NewProviderManager()
+		if err := llmManager.RegisterProvider("mock", provider); err != nil {
+			t.Fatalf("Failed to register provider: %v", err)
+		}
+		// Nothing passes the similarity threshold
+		retriever := rag.RetrieverFunc(func(ctx context.Context, query string, options rag.RetrieveOptions) ([]rag.RetrievedChunk, error) {
+			return nil, nil
+		})
+		return NewAgent(&AgentConfig{
+			Name:              "rag",
+			Type:              AgentTypeChat,
+			Provider:          "mock",
+			Model:             "test-model",
+			Retriever:         retriever,
+			NoContextBehavior: behavior,
+			NoContextTool:     tool,
+		}, llmManager, registry), provider
+	}
+	contextMessage := func(req llm.CompletionRequest) string {
+		for _, message := range req.Messages {
+			if llm.IsContextMessage(message) {
+				return message.Content
+			}
+		}
+		return ""
+	}
+
+	t.Run("refuse", func(t *testing.T) {
+		agent, provider := newAgent(NoContextRefuse, "", tools.NewToolRegistry())
+		execution, err := agent.Execute(context.Background(), "Who won the 1930 cup?")
+		if err != nil {
+			t.Fatalf("Execute() failed: %v", err)
+		}
+		if execution.Output != DefaultNoContextMessage || len(provider.requests) != 0 {
+			t.Errorf("Expected a refusal without calling the model, got %q after %d requests", execution.Output, len(provider.requests))
+		}
+		if execution.Metadata["no_relevant_context"] != true {
+			t.Errorf("Expected no relevant context in metadata, got %v", execution.Metadata)
+		}
+	})
+
+	t.Run("answer_from_model", func(t *testing.T) {
+		agent, provider := newAgent(NoContextAnswerFromModel, "", tools.NewToolRegistry())
+		if _, err := agent.Execute(context.Background(), "Who won the 1930 cup?"); err != nil {
+			t.Fatalf("Execute() failed: %v", err)
+		}
+		if len(provider.requests) != 1 || !strings.Contains(contextMessage(provider.requests[0]), "no sources were found") {
+			t.Errorf("Expected the model told no sources were found, got %+v", provider.requests)
+		}
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		registry := tools.NewToolRegistry()
+		if err := registry.RegisterTool(&staticTool{name: "archive_search", result: "Uruguay won the 1930 World Cup."}); err != nil {
+			t.Fatalf("Failed to register tool: %v", err)
+		}
+		agent, provider := newAgent(NoContextFallback, "archive_search", registry)
+		execution, err := agent.Execute(context.Background(), "Who won the 1930 cup?")
+		if err != nil {
+			t.Fatalf("Execute() failed: %v", err)
+		}
+		if execution.Metadata["no_context_fallback"] != "archive_search" {
+			t.Errorf("Expected the fallback tool called, got %v", execution.Metadata)
+		}
+		if len(provider.requests) != 1 || !strings.Contains(contextMessage(provider.requests[0]), "Uruguay") {
+			t.Errorf("Expected the tool result as context, got %+v", provider.requests)
+		}
+	})
+
+	t.Run("fallback without the tool", func(t *testing.T) {
+		agent, provider := newAgent(NoContextFallback, "archive_search", tools.NewToolRegistry())
+		if _, err := agent.Execute(context.Background(), "Who won the 1930 cup?"); err != nil {
+			t.Fatalf("Execute() failed: %v", err)
+		}
+		if len(provider.requests) != 1 || !strings.Contains(contextMessage(provider.requests[0]), "no sources were found") {
+			t.Errorf("Expected an answer from the model, got %+v", provider.requests)
+		}
+	})
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
+)
+
+// stopConditionKey is the state key of the name of the condition that stopped a ReAct loop
+const stopConditionKey = "stop_condition"
+
+// ReActStep describes a step of a ReAct loop to stop conditions: the reasoning of the model
+// after the reason node, and the tool results of the iteration after the observe node
+type ReActStep struct {
+	// Node is "reason" or "observe"
+	Node      string
+	Iteration int
+	Reasoning string
+	// ToolResults are the tool calls of the iteration's act node, set on observe steps
+	ToolResults []ToolStepResult
+	State       *core.BaseState
+}
+
+// ToolStepResult is the result of a tool call of a ReAct step
+type ToolStepResult struct {
+	Name      string
+	Arguments string
+	Result    string
+	Err       error
+}
+
+// StopCondition ends a ReAct loop once it holds. Conditions are evaluated after each
+// reason and observe step; the first condition holding sends the loop to its final answer.
+type StopCondition interface {
+	// Name identifies the condition in the execution metadata
+	Name() string
+	// ShouldStop reports whether the loop is done after a step
+	ShouldStop(ctx context.Context, step ReActStep) bool
+}
+
+// stopConditionFunc is a StopCondition implemented by a function
+type stopConditionFunc struct {
+	name       string
+	shouldStop func(ctx context.Context, step ReActStep) bool
+}
+
+func (c stopConditionFunc) Name() string { return c.name }
+
+func (c stopConditionFunc) ShouldStop(ctx context.Context, step ReActStep) bool {
+	return c.shouldStop(ctx, step)
+}
+
+// NewStopCondition returns a named stop condition implemented by a function
+func NewStopCondition(name string, shouldStop func(ctx context.Context, step ReActStep) bool) StopCondition {
+	return stopConditionFunc{name: name, shouldStop: shouldStop}
+}
+
+// StopOnMarker stops once the model's reasoning contains a marker, such as "FINAL ANSWER:",
+// compared case-insensitively
+func StopOnMarker(marker string) StopCondition {
+	lowered := strings.ToLower(marker)
+	return NewStopCondition("marker:"+marker, func(ctx context.Context, step ReActStep) bool {
+		return step.Node == "reason" && strings.Contains(strings.ToLower(step.Reasoning), lowered)
+	})
+}
+
+// StopOnToolResult stops once a tool returns a terminal result: any successful result when
+// isTerminal is nil, otherwise a successful result for which it returns true
+func StopOnToolResult(toolName string, isTerminal func(result string) bool) StopCondition {
+	return NewStopCondition("tool:"+toolName, func(ctx context.Context, step ReActStep) bool {
+		for _, result := range step.ToolResults {
+			if result.Name == toolName && result.Err == nil && (isTerminal == nil || isTerminal(result.Result)) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// StopOnState stops once a predicate on the execution state holds
+func StopOnState(name string, predicate func(state *core.BaseState) bool) StopCondition {
+	return NewStopCondition(name, func(ctx context.Context, step ReActStep) bool {
+		return predicate(step.State)
+	})
+}
+
+// checkStopConditions evaluates the stop conditions after a step, recording the name of the
+// first one holding in the state
+func (a *Agent) checkStopConditions(ctx context.Context, node string, state *core.BaseState) {
+	if len(a.config.StopConditions) == 0 || stoppedBy(state) != "" {
+		return
+	}
+
+	step := ReActStep{Node: node, State: state}
+	if iteration, ok := state.Get("iteration"); ok {
+		step.Iteration, _ = iteration.(int)
+	}
+	if reasoning, ok := state.Get("reasoning"); ok {
+		step.Reasoning, _ = reasoning.(string)
+	}
+	if node == "observe" {
+		if results, ok := state.Get("tool_results"); ok {
+			step.ToolResults, _ = results.([]ToolStepResult)
+		}
+	}
+
+	for _, condition := range a.config.StopConditions {
+		if condition.ShouldStop(ctx, step) {
+			state.Set(stopConditionKey, condition.Name())
+			logging.FromContext(ctx, a.logger).WithFields(logrus.Fields{
+				"stop_condition": condition.Name(),
+				"node":           node,
+				"iteration":      step.Iteration,
+			}).Info("Stop condition reached")
+			return
+		}
+	}
+}
+
+// stoppedBy returns the name of the condition that stopped the loop, if any
+func stoppedBy(state *core.BaseState) string {
+	name, _ := state.Get(stopConditionKey)
+	stopped, _ := name.(string)
+	return stopped
+}
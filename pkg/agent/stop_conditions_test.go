@@ -0,0 +1,79 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
+)
+
+func TestAgent_StopConditions(t *testing.T) {
+	act := llm.Message{Role: "assistant", Content: "Thought: compute it\nAction: calculator\nAction Input: {\"expression\": \"2+3\"}"}
+	final := llm.Message{Role: "assistant", Content: "The answer is 5."}
+
+	newAgent := func(conditions ...StopCondition) (*Agent, *sequenceProvider) {
+		provider := &sequenceProvider{messages: []llm.Message{act, final}}
+		llmManager := llm.NewProviderManager()
+		if err := llmManager.RegisterProvider("mock", provider); err != nil {
+			t.Fatalf("Failed to register provider: %v", err)
+		}
+		return NewAgent(&AgentConfig{
+			Name:           "test-agent",
+			Type:           AgentTypeReAct,
+			Provider:       "mock",
+			Model:          "test-model",
+			MaxIterations:  5,
+			Tools:          []string{"calculator"},
+			StopConditions: conditions,
+		}, llmManager, tools.NewToolRegistry()), provider
+	}
+
+	// A terminal tool result ends the loop after the observation
+	agent, provider := newAgent(
+		StopOnMarker("FINAL ANSWER:"),
+		StopOnToolResult("calculator", func(result string) bool { return strings.Contains(result, "5") }),
+	)
+	execution, err := agent.Execute(context.Background(), "What is 2+3?")
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if execution.Metadata["stop_condition"] != "tool:calculator" || execution.Output != "The answer is 5." {
+		t.Errorf("Expected the calculator result to stop the loop, got %v and %q", execution.Metadata, execution.Output)
+	}
+	if len(provider.requests) != 2 || len(execution.ToolCalls) != 1 {
+		t.Errorf("Expected one reasoning step and the final answer, got %d requests and %d tool calls", len(provider.requests), len(execution.ToolCalls))
+	}
+
+	// A condition holding after reasoning skips the action
+	agent, _ = newAgent(StopOnState("asked", func(state *core.BaseState) bool {
+		iteration, _ := state.Get("iteration")
+		return iteration == 0
+	}))
+	execution, err = agent.Execute(context.Background(), "What is 2+3?")
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if execution.Metadata["stop_condition"] != "asked" || len(execution.ToolCalls) != 0 {
+		t.Errorf("Expected the state predicate to stop before acting, got %v with %d tool calls", execution.Metadata, len(execution.ToolCalls))
+	}
+
+	// Without conditions holding, nothing is recorded
+	agent, provider = newAgent(StopOnMarker("<done>"))
+	provider.messages = []llm.Message{act, {Role: "assistant", Content: "Final Answer: 5"}, final}
+	execution, err = agent.Execute(context.Background(), "What is 2+3?")
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if _, stopped := execution.Metadata["stop_condition"]; stopped {
+		t.Errorf("Expected no stop condition, got %v", execution.Metadata)
+	}
+}
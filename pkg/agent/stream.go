@@ -0,0 +1,215 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// StreamEventType is the kind of a StreamEvent
+type StreamEventType string
+
+const (
+	// StreamEventToken carries a fragment of the model's answer
+	StreamEventToken StreamEventType = "token"
+	// StreamEventThought carries the reasoning or plan of a ReAct or tool agent
+	StreamEventThought StreamEventType = "thought"
+	// StreamEventToolCall announces a tool call with its arguments
+	StreamEventToolCall StreamEventType = "tool_call"
+	// StreamEventToolResult carries the result of a tool call, or its error
+	StreamEventToolResult StreamEventType = "tool_result"
+	// StreamEventDone is the last event of a successful execution and carries its result
+	StreamEventDone StreamEventType = "done"
+	// StreamEventError is the last event of a failed execution
+	StreamEventError StreamEventType = "error"
+)
+
+// streamBufferSize is the number of events buffered ahead of a slow reader
+const streamBufferSize = 64
+
+// StreamEvent is an event of an agent execution streamed by Stream
+type StreamEvent struct {
+	Type StreamEventType `json:"type"`
+	// Content is the token, thought or tool result
+	Content   string `json:"content,omitempty"`
+	ToolName  string `json:"tool_name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+	// Execution is the result of the execution on the done and error events
+	Execution *AgentExecution `json:"execution,omitempty"`
+	// Err is the error of a failed tool call or execution
+	Err       error     `json:"-"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// streamEmitterKey is the context key of the function receiving an execution's events
+type streamEmitterKey struct{}
+
+// Stream executes the agent and returns a channel of its events: the tokens of the answer
+// as the model produces them, thoughts, tool calls and their results, and finally a done or
+// error event. The channel is closed when the execution ends. The caller must read the
+// channel until it is closed, or cancel ctx to stop the execution. Errors preventing the
+// execution from starting, such as the rate limit, are returned directly.
+func (a *Agent) Stream(ctx context.Context, input string) (<-chan StreamEvent, error) {
+	return a.StreamWithOptions(ctx, input, nil)
+}
+
+// StreamWithOptions streams an execution with execution options. With a word or sentence
+// granularity, answer tokens are coalesced into whole units before they are sent. With an
+// output filter, the end of the answer is held back until it can be redacted.
+func (a *Agent) StreamWithOptions(ctx context.Context, input string, options *ExecuteOptions) (<-chan StreamEvent, error) {
+	granularity := a.streamGranularity(options)
+	if err := granularity.Validate(); err != nil {
+		return nil, err
+	}
+	if err := a.acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	events := make(chan StreamEvent, streamBufferSize)
+	send := func(event StreamEvent) {
+		event.Timestamp = time.Now()
+		if event.Err != nil {
+			event.Error = event.Err.Error()
+		}
+		select {
+		case events <- event:
+		case <-ctx.Done():
+		}
+	}
+	emit := redactTokens(a.config.OutputFilter, coalesceTokens(granularity, send))
+
+	go func() {
+		defer close(events)
+		defer a.release()
+
+		execution, err := a.execute(context.WithValue(ctx, streamEmitterKey{}, emit), input, options)
+		if err != nil {
+			emit(StreamEvent{Type: StreamEventError, Execution: execution, Err: err})
+			return
+		}
+		emit(StreamEvent{Type: StreamEventDone, Content: execution.Output, Execution: execution})
+	}()
+	return events, nil
+}
+
+// ExecuteWithCallback executes the agent, calling onToken with each fragment of the answer
+// as the model produces it, and returns the execution with the full output. When no token
+// was streamed, because the provider or the agent type does not stream, the whole output is
+// delivered in a single call. onToken is called from the caller's goroutine.
+func (a *Agent) ExecuteWithCallback(ctx context.Context, input string, onToken func(token string)) (*AgentExecution, error) {
+	events, err := a.Stream(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	streamed := false
+	var execution *AgentExecution
+	var executionErr error
+	for event := range events {
+		switch event.Type {
+		case StreamEventToken:
+			streamed = true
+			onToken(event.Content)
+		case StreamEventDone:
+			execution = event.Execution
+		case StreamEventError:
+			execution, executionErr = event.Execution, event.Err
+		}
+	}
+
+	// The final event is dropped when ctx ends first
+	if execution == nil && executionErr == nil {
+		return nil, ctx.Err()
+	}
+	if executionErr != nil {
+		return execution, executionErr
+	}
+	if !streamed && execution.Output != "" {
+		onToken(execution.Output)
+	}
+	return execution, nil
+}
+
+// emitStreamEvent sends an event to the caller of Stream, if the execution is streamed
+func emitStreamEvent(ctx context.Context, event StreamEvent) {
+	if emit, ok := ctx.Value(streamEmitterKey{}).(func(StreamEvent)); ok {
+		emit(event)
+	}
+}
+
+// isStreamed reports whether the execution's events are streamed to a caller
+func isStreamed(ctx context.Context) bool {
+	_, ok := ctx.Value(streamEmitterKey{}).(func(StreamEvent))
+	return ok
+}
+
+// completeStreamed runs a completion as a stream, emitting each token, and returns the
+// response assembled from the chunks
+func (a *Agent) completeStreamed(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	var content strings.Builder
+	var toolCalls []llm.ToolCall
+	var last llm.CompletionResponse
+
+	req.Stream = true
+	err := a.llmManager.CompleteStream(ctx, a.config.Provider, req, func(chunk llm.CompletionResponse) error {
+		last = chunk
+		if len(chunk.Choices) == 0 {
+			return nil
+		}
+
+		// Providers without native streaming send the whole message in a single chunk
+		choice := chunk.Choices[0]
+		delta := choice.Delta
+		if delta.Content == "" && len(delta.ToolCalls) == 0 {
+			delta = choice.Message
+		}
+		if delta.Content != "" {
+			content.WriteString(delta.Content)
+			emitStreamEvent(ctx, StreamEvent{Type: StreamEventToken, Content: delta.Content})
+		}
+		toolCalls = appendToolCallDeltas(toolCalls, delta.ToolCalls)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := last
+	finishReason := ""
+	if len(last.Choices) > 0 {
+		finishReason = last.Choices[0].FinishReason
+	}
+	resp.Choices = []llm.Choice{{
+		Message: llm.Message{
+			Role:      "assistant",
+			Content:   content.String(),
+			ToolCalls: toolCalls,
+		},
+		FinishReason: finishReason,
+	}}
+	return &resp, nil
+}
+
+// appendToolCallDeltas assembles streamed tool calls: a fragment with an ID starts a call,
+// and fragments without one continue the arguments of the previous call
+func appendToolCallDeltas(calls []llm.ToolCall, deltas []llm.ToolCall) []llm.ToolCall {
+	for _, delta := range deltas {
+		if delta.ID != "" || len(calls) == 0 {
+			calls = append(calls, delta)
+			continue
+		}
+		current := &calls[len(calls)-1]
+		current.Function.Name += delta.Function.Name
+		current.Function.Arguments += delta.Function.Arguments
+	}
+	return calls
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// StreamGranularity is the unit in which the tokens of a streamed answer are sent
+type StreamGranularity string
+
+const (
+	// StreamGranularityToken sends every provider delta as it arrives; the default
+	StreamGranularityToken StreamGranularity = "token"
+	// StreamGranularityWord coalesces deltas into whole words, with their trailing space;
+	// a delta holding several words is sent as one event
+	StreamGranularityWord StreamGranularity = "word"
+	// StreamGranularitySentence coalesces deltas into whole sentences or lines, likewise
+	StreamGranularitySentence StreamGranularity = "sentence"
+)
+
+// StreamOptions controls how an execution's events are streamed
+type StreamOptions struct {
+	// Granularity coalesces answer tokens into larger units before they are sent, trading
+	// latency for fewer events; defaults to AgentConfig.StreamGranularity, then token
+	Granularity StreamGranularity `json:"granularity,omitempty"`
+}
+
+// Validate reports an unknown granularity
+func (g StreamGranularity) Validate() error {
+	switch g {
+	case "", StreamGranularityToken, StreamGranularityWord, StreamGranularitySentence:
+		return nil
+	default:
+		return fmt.Errorf("unknown stream granularity %q, expected token, word or sentence", g)
+	}
+}
+
+// streamGranularity returns the granularity of a streamed execution
+func (a *Agent) streamGranularity(options *ExecuteOptions) StreamGranularity {
+	if options != nil && options.Stream.Granularity != "" {
+		return options.Stream.Granularity
+	}
+	if a.config.StreamGranularity != "" {
+		return a.config.StreamGranularity
+	}
+	return StreamGranularityToken
+}
+
+// coalesceTokens wraps an event emitter so token events are sent in units of the
+// granularity. Pending text is sent ahead of any other event, so the text of an answer is
+// complete before its tool calls or its done event.
+func coalesceTokens(granularity StreamGranularity, emit func(StreamEvent)) func(StreamEvent) {
+	if granularity == "" || granularity == StreamGranularityToken {
+		return emit
+	}
+
+	var mu sync.Mutex
+	var pending strings.Builder
+	return func(event StreamEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if event.Type == StreamEventToken {
+			pending.WriteString(event.Content)
+			text := pending.String()
+			cut := unitBoundary(granularity, text)
+			if cut == 0 {
+				return
+			}
+			pending.Reset()
+			pending.WriteString(text[cut:])
+			event.Content = text[:cut]
+			emit(event)
+			return
+		}
+
+		if pending.Len() > 0 {
+			emit(StreamEvent{Type: StreamEventToken, Content: pending.String()})
+			pending.Reset()
+		}
+		emit(event)
+	}
+}
+
+// unitBoundary returns the length of the complete units at the start of text, or zero when
+// the first unit may still be continued by the next delta
+func unitBoundary(granularity StreamGranularity, text string) int {
+	cut := 0
+	for i, r := range text {
+		next := i + utf8.RuneLen(r)
+		switch granularity {
+		case StreamGranularityWord:
+			if unicode.IsSpace(r) {
+				cut = next
+			}
+		case StreamGranularitySentence:
+			if r == '\n' {
+				cut = next
+				continue
+			}
+			// A terminator ends a sentence once whitespace follows it, so "3.14" is kept whole
+			if isSentenceTerminator(r) && next < len(text) {
+				following, size := utf8.DecodeRuneInString(text[next:])
+				if unicode.IsSpace(following) {
+					cut = next + size
+				}
+			}
+		}
+	}
+	return cut
+}
+
+// isSentenceTerminator reports whether r ends a sentence
+func isSentenceTerminator(r rune) bool {
+	switch r {
+	case '.', '!', '?', '…', '。', '！', '？':
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
+)
+
+// fragmentProvider streams its answer in fragments cutting across words and sentences
+type fragmentProvider struct {
+	mockProvider
+}
+
+func (p *fragmentProvider) CompleteStream(ctx context.Context, req llm.CompletionRequest, callback llm.StreamCallback) error {
+	for _, fragment := range []string{"Pi is 3", ".14 ro", "ughly. Th", "anks!\nBye", " now"} {
+		if err := callback(llm.CompletionResponse{Choices: []llm.Choice{{Delta: llm.Message{Content: fragment}}}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestAgent_StreamGranularity(t *testing.T) {
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", &fragmentProvider{}); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+	agent := NewAgent(&AgentConfig{
+		Name:              "test-agent",
+		Type:              AgentTypeChat,
+		Provider:          "mock",
+		Model:             "test-model",
+		StreamGranularity: StreamGranularityWord,
+	}, llmManager, tools.NewToolRegistry())
+
+	tokens := func(options *ExecuteOptions) []string {
+		events, err := agent.StreamWithOptions(context.Background(), "What is pi?", options)
+		if err != nil {
+			t.Fatalf("StreamWithOptions() failed: %v", err)
+		}
+		var tokens []string
+		for event := range events {
+			switch event.Type {
+			case StreamEventToken:
+				tokens = append(tokens, event.Content)
+			case StreamEventDone:
+				if event.Content != "Pi is 3.14 roughly. Thanks!\nBye now" {
+					t.Errorf("Expected the whole answer on the done event, got %q", event.Content)
+				}
+			case StreamEventError:
+				t.Fatalf("Unexpected error: %v", event.Err)
+			}
+		}
+		return tokens
+	}
+
+	tests := []struct {
+		name     string
+		options  *ExecuteOptions
+		expected []string
+	}{
+		{
+			name:     "agent default",
+			expected: []string{"Pi is ", "3.14 ", "roughly. ", "Thanks!\n", "Bye ", "now"},
+		},
+		{
+			name:     "token",
+			options:  &ExecuteOptions{Stream: StreamOptions{Granularity: StreamGranularityToken}},
+			expected: []string{"Pi is 3", ".14 ro", "ughly. Th", "anks!\nBye", " now"},
+		},
+		{
+			name:     "sentence",
+			options:  &ExecuteOptions{Stream: StreamOptions{Granularity: StreamGranularitySentence}},
+			expected: []string{"Pi is 3.14 roughly. ", "Thanks!\n", "Bye now"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tokens(tt.options)
+			if strings.Join(got, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("Expected tokens %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	if _, err := agent.StreamWithOptions(context.Background(), "What is pi?", &ExecuteOptions{Stream: StreamOptions{Granularity: "paragraph"}}); err == nil {
+		t.Error("Expected an unknown granularity to be rejected")
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// ErrMaxToolCallsExceeded is returned when the model requests more tool calls in one execution
+// than AgentConfig.MaxToolCalls allows. The execution returned with it holds the partial result.
+var ErrMaxToolCallsExceeded = errors.New("maximum tool calls exceeded")
+
+// toolCallCounterKey is the context key of the tool calls of an execution
+type toolCallCounterKey struct{}
+
+// toolCallCounter counts the tool calls of an execution against its limit
+type toolCallCounter struct {
+	limit int
+	calls []llm.ToolCall
+	mu    sync.Mutex
+}
+
+// withToolCallCounter binds a tool call counter to the context of an execution; a zero
+// limit only counts
+func withToolCallCounter(ctx context.Context, limit int) (context.Context, *toolCallCounter) {
+	counter := &toolCallCounter{limit: limit}
+	return context.WithValue(ctx, toolCallCounterKey{}, counter), counter
+}
+
+// countToolCall records a tool call of the execution, failing with ErrMaxToolCallsExceeded
+// instead once the limit is reached. The error is permanent, so the node is not retried.
+func countToolCall(ctx context.Context, call llm.ToolCall) error {
+	counter, ok := ctx.Value(toolCallCounterKey{}).(*toolCallCounter)
+	if !ok {
+		return nil
+	}
+
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+	if counter.limit > 0 && len(counter.calls) >= counter.limit {
+		return fmt.Errorf("%w (%d), refused %s: %w", ErrMaxToolCallsExceeded, counter.limit, call.Function.Name, core.ErrPermanent)
+	}
+	counter.calls = append(counter.calls, call)
+	return nil
+}
+
+// executed returns the tool calls made so far
+func (c *toolCallCounter) executed() []llm.ToolCall {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]llm.ToolCall(nil), c.calls...)
+}
+
+// partialResult fills an execution stopped by the tool call limit with the calls made and
+// the model's latest answer to its input
+func (a *Agent) partialResult(execution *AgentExecution, counter *toolCallCounter) {
+	execution.ToolCalls = counter.executed()
+	messages := a.conversation.GetMessages()
+	for i := len(messages) - 1; i >= 0 && messages[i].Role != "user"; i-- {
+		if messages[i].Role == "assistant" && messages[i].Content != "" {
+			execution.Output = messages[i].Content
+			execution.StructuredOutput = messages[i].Content
+			break
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
+)
+
+func TestAgent_MaxToolCalls(t *testing.T) {
+	act := llm.Message{Role: "assistant", Content: "Thought: compute it again\nAction: calculator\nAction Input: {\"expression\": \"2+3\"}"}
+	final := llm.Message{Role: "assistant", Content: "Final Answer: 5"}
+
+	newAgent := func(maxToolCalls int, messages ...llm.Message) *Agent {
+		llmManager := llm.NewProviderManager()
+		if err := llmManager.RegisterProvider("mock", &sequenceProvider{messages: messages}); err != nil {
+			t.Fatalf("Failed to register provider: %v", err)
+		}
+		return NewAgent(&AgentConfig{
+			Name:          "test-agent",
+			Type:          AgentTypeReAct,
+			Provider:      "mock",
+			Model:         "test-model",
+			MaxIterations: 10,
+			MaxToolCalls:  maxToolCalls,
+			Tools:         []string{"calculator"},
+		}, llmManager, tools.NewToolRegistry())
+	}
+
+	// A model calling tools without end is stopped at the limit, keeping the partial result
+	execution, err := newAgent(2, act).Execute(context.Background(), "What is 2+3?")
+	if !errors.Is(err, ErrMaxToolCallsExceeded) {
+		t.Fatalf("Expected ErrMaxToolCallsExceeded, got %v", err)
+	}
+	if execution == nil || execution.Success {
+		t.Fatalf("Expected a failed execution with the partial result, got %+v", execution)
+	}
+	if execution.ToolCallCount != 2 || len(execution.ToolCalls) != 2 {
+		t.Errorf("Expected the two calls made, got count %d and %d calls", execution.ToolCallCount, len(execution.ToolCalls))
+	}
+	if !strings.Contains(execution.Output, "compute it again") {
+		t.Errorf("Expected the latest reasoning as partial output, got %q", execution.Output)
+	}
+
+	// Executions within the limit report their tool-call count
+	execution, err = newAgent(2, act, final).Execute(context.Background(), "What is 2+3?")
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if execution.ToolCallCount != 1 {
+		t.Errorf("Expected one tool call, got %d", execution.ToolCallCount)
+	}
+
+	if err := (&AgentConfig{Name: "a", Model: "m", Provider: "p", MaxTokens: 1000, MaxToolCalls: -1}).Validate(); err == nil {
+		t.Error("Expected a negative MaxToolCalls to be rejected")
+	}
+}
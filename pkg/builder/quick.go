@@ -15,6 +15,7 @@ import (
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/agent"
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/persistence"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/rag"
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/server"
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
 )
@@ -24,6 +25,7 @@ type QuickBuilder struct {
 	llmManager   *llm.ProviderManager
 	toolRegistry *tools.ToolRegistry
 	checkpointer persistence.Checkpointer
+	retriever    rag.Retriever
 	config       *QuickConfig
 }
 
@@ -158,6 +160,13 @@ func (qb *QuickBuilder) WithLLM(provider string, config interface{}) *QuickBuild
 				qb.llmManager.RegisterProvider("gemini", geminiProvider)
 			}
 		}
+	case "llamacpp":
+		if cfg, ok := config.(*llm.ProviderConfig); ok {
+			llamaCppProvider, err := llm.NewLlamaCppProvider(cfg)
+			if err == nil {
+				qb.llmManager.RegisterProvider("llamacpp", llamaCppProvider)
+			}
+		}
 	}
 	return qb
 }
@@ -176,6 +185,13 @@ func (qb *QuickBuilder) WithPersistence(checkpointer persistence.Checkpointer) *
 	return qb
 }
 
+// WithRetriever sets the retrieval backend of RAG agents, such as a rag.VectorRetriever over
+// a vector store or a custom Elasticsearch or hosted retriever
+func (qb *QuickBuilder) WithRetriever(retriever rag.Retriever) *QuickBuilder {
+	qb.retriever = retriever
+	return qb
+}
+
 // ========== ULTRA-MINIMAL AGENT CREATION ==========
 
 // Chat creates a simple chat agent in 1 line
@@ -241,7 +257,9 @@ func (qb *QuickBuilder) Tool(name ...string) *agent.Agent {
 	return agent.NewAgent(config, qb.llmManager, qb.toolRegistry)
 }
 
-// RAG creates a RAG (Retrieval-Augmented Generation) agent
+// RAG creates a RAG (Retrieval-Augmented Generation) agent. With a retriever set through
+// WithRetriever, each input is answered from the retrieved context and the agent can search
+// for more with the vector_search tool.
 func (qb *QuickBuilder) RAG(name ...string) *agent.Agent {
 	agentName := "RAGAgent"
 	if len(name) > 0 {
@@ -259,6 +277,14 @@ func (qb *QuickBuilder) RAG(name ...string) *agent.Agent {
 		Tools:        []string{"web_search", "file_read"},
 	}
 
+	if qb.retriever != nil {
+		config.Retriever = qb.retriever
+		if searchTool, err := tools.NewRetrievalTool(qb.retriever); err == nil {
+			qb.toolRegistry.RegisterTool(searchTool)
+			config.Tools = append(config.Tools, searchTool.GetName())
+		}
+	}
+
 	return agent.NewAgent(config, qb.llmManager, qb.toolRegistry)
 }
 
@@ -429,14 +455,51 @@ func (qb *QuickBuilder) getBestProvider() string {
 
 // ========== WORKFLOW TYPES ==========
 
+// ShouldContinueFunc decides whether a pipeline proceeds after a stage completes
+type ShouldContinueFunc func(execution agent.AgentExecution) bool
+
+// PipelineResult represents the outcome of a pipeline run
+type PipelineResult struct {
+	Executions     []agent.AgentExecution `json:"executions"`
+	Output         string                 `json:"output"`
+	ShortCircuited bool                   `json:"short_circuited"`
+	HaltedAt       int                    `json:"halted_at"` // Index of the halting stage, -1 if all stages ran
+}
+
 // AgentPipeline represents a sequential workflow
 type AgentPipeline struct {
-	agents      []*agent.Agent
-	coordinator *agent.MultiAgentCoordinator
+	agents         []*agent.Agent
+	coordinator    *agent.MultiAgentCoordinator
+	shouldContinue ShouldContinueFunc
+	stageChecks    map[int]ShouldContinueFunc
+}
+
+// ShouldContinue sets a check run after every stage; returning false halts the pipeline
+func (ap *AgentPipeline) ShouldContinue(fn ShouldContinueFunc) *AgentPipeline {
+	ap.shouldContinue = fn
+	return ap
+}
+
+// StageShouldContinue sets a check run after the given stage; returning false halts the pipeline
+func (ap *AgentPipeline) StageShouldContinue(stage int, fn ShouldContinueFunc) *AgentPipeline {
+	if ap.stageChecks == nil {
+		ap.stageChecks = make(map[int]ShouldContinueFunc)
+	}
+	ap.stageChecks[stage] = fn
+	return ap
 }
 
 // Execute runs the pipeline sequentially
 func (ap *AgentPipeline) Execute(ctx context.Context, input string) ([]agent.AgentExecution, error) {
+	result, err := ap.Run(ctx, input)
+	if result == nil {
+		return nil, err
+	}
+	return result.Executions, err
+}
+
+// Run runs the pipeline sequentially, stopping early when a stage check returns false
+func (ap *AgentPipeline) Run(ctx context.Context, input string) (*PipelineResult, error) {
 	agentIDs := make([]string, len(ap.agents))
 
 	for i, agent := range ap.agents {
@@ -445,7 +508,29 @@ func (ap *AgentPipeline) Execute(ctx context.Context, input string) ([]agent.Age
 		ap.coordinator.AddAgent(id, agent)
 	}
 
-	return ap.coordinator.ExecuteSequential(ctx, agentIDs, input)
+	executions, haltedAt, err := ap.coordinator.ExecuteSequentialUntil(ctx, agentIDs, input, ap.continueAfter)
+
+	result := &PipelineResult{
+		Executions:     executions,
+		ShortCircuited: haltedAt >= 0,
+		HaltedAt:       haltedAt,
+	}
+	if len(executions) > 0 {
+		result.Output = executions[len(executions)-1].Output
+	}
+
+	return result, err
+}
+
+// continueAfter applies the stage-specific and pipeline-wide checks
+func (ap *AgentPipeline) continueAfter(stage int, execution agent.AgentExecution) bool {
+	if check, exists := ap.stageChecks[stage]; exists && !check(execution) {
+		return false
+	}
+	if ap.shouldContinue != nil && !ap.shouldContinue(execution) {
+		return false
+	}
+	return true
 }
 
 // AgentSwarm represents a parallel workflow
@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/agent"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/rag"
 )
 
 func TestNewQuickBuilder(t *testing.T) {
@@ -201,6 +202,15 @@ func TestQuickBuilder_RAG(t *testing.T) {
 	if len(config.Tools) == 0 {
 		t.Error("RAG agent should have tools")
 	}
+
+	// The agent and its search tool retrieve through the configured backend
+	retriever := rag.RetrieverFunc(func(ctx context.Context, query string, options rag.RetrieveOptions) ([]rag.RetrievedChunk, error) {
+		return []rag.RetrievedChunk{{ID: "1", Content: "answer"}}, nil
+	})
+	config = NewQuickBuilder().WithRetriever(retriever).RAG().GetConfig()
+	if config.Retriever == nil || config.Tools[len(config.Tools)-1] != "vector_search" {
+		t.Errorf("Expected the retriever and the vector_search tool, got %v", config.Tools)
+	}
 }
 
 func TestQuickBuilder_Specialized(t *testing.T) {
@@ -316,6 +326,37 @@ func TestAgentPipeline_Execute(t *testing.T) {
 	t.Skip("Skipping pipeline execution test - requires actual LLM providers")
 }
 
+func TestAgentPipeline_ShouldContinue(t *testing.T) {
+	pipeline := OneLinePipeline(OneLineChat("Moderator"), OneLineChat("Writer"), OneLineChat("Editor"))
+
+	rejected := agent.AgentExecution{Output: "REJECTED"}
+	accepted := agent.AgentExecution{Output: "ok"}
+
+	if !pipeline.continueAfter(0, rejected) {
+		t.Error("Pipeline without checks should always continue")
+	}
+
+	pipeline.StageShouldContinue(0, func(execution agent.AgentExecution) bool {
+		return execution.Output != "REJECTED"
+	})
+	if pipeline.continueAfter(0, rejected) {
+		t.Error("Stage check should halt the pipeline")
+	}
+	if !pipeline.continueAfter(1, rejected) {
+		t.Error("Stage check should only apply to its own stage")
+	}
+
+	pipeline.ShouldContinue(func(execution agent.AgentExecution) bool {
+		return execution.Output != "DONE"
+	})
+	if pipeline.continueAfter(1, agent.AgentExecution{Output: "DONE"}) {
+		t.Error("Pipeline-wide check should halt the pipeline")
+	}
+	if !pipeline.continueAfter(0, accepted) {
+		t.Error("Pipeline should continue when all checks pass")
+	}
+}
+
 func TestAgentSwarm_Execute(t *testing.T) {
 	// Skip this test as it requires actual LLM providers
 	t.Skip("Skipping swarm execution test - requires actual LLM providers")
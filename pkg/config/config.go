@@ -0,0 +1,285 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+// Package config defines the schema of GoLangGraph configuration files, loads them from
+// YAML or JSON with environment overrides, and validates them.
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/agent"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/persistence"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/rag"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/server"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
+)
+
+// AppConfig is the schema of a configuration file: an agent declared at the top level,
+// followed by the server, storage and retrieval sections
+type AppConfig struct {
+	AgentConfig `yaml:",inline"`
+
+	Server          ServerConfig           `json:"server" yaml:"server"`
+	Database        *DatabaseConfig        `json:"database,omitempty" yaml:"database,omitempty"`
+	VectorStore     *VectorStoreConfig     `json:"vector_store,omitempty" yaml:"vector_store,omitempty"`
+	RAG             *RAGConfig             `json:"rag,omitempty" yaml:"rag,omitempty"`
+	DocumentLoaders []DocumentLoaderConfig `json:"document_loaders,omitempty" yaml:"document_loaders,omitempty"`
+	ThreadTitles    *ThreadTitleConfig     `json:"thread_titles,omitempty" yaml:"thread_titles,omitempty"`
+
+	// ModelAliases maps logical model names to "provider:model" targets. Agents reference an
+	// alias with provider "alias" and the alias as their model.
+	ModelAliases map[string]string `json:"model_aliases,omitempty" yaml:"model_aliases,omitempty"`
+}
+
+// AgentConfig configures the agent of a configuration file
+type AgentConfig struct {
+	Name            string           `json:"name" yaml:"name"`
+	Type            string           `json:"type" yaml:"type"`
+	Model           string           `json:"model" yaml:"model"`
+	Provider        string           `json:"provider" yaml:"provider"`
+	SystemPrompt    string           `json:"system_prompt,omitempty" yaml:"system_prompt,omitempty"`
+	Temperature     float64          `json:"temperature" yaml:"temperature"`
+	MaxTokens       int              `json:"max_tokens" yaml:"max_tokens"`
+	MaxIterations   int              `json:"max_iterations" yaml:"max_iterations"`
+	MaxToolCalls    int              `json:"max_tool_calls,omitempty" yaml:"max_tool_calls,omitempty"`
+	RetryBudget     int              `json:"retry_budget,omitempty" yaml:"retry_budget,omitempty"`
+	Timeout         time.Duration    `json:"timeout" yaml:"timeout"`
+	EnableStreaming bool             `json:"enable_streaming,omitempty" yaml:"enable_streaming,omitempty"`
+	Locale          string           `json:"locale,omitempty" yaml:"locale,omitempty"`
+	Tools           []tools.ToolSpec `json:"tools,omitempty" yaml:"tools,omitempty"`
+}
+
+// ServerConfig configures the HTTP server
+type ServerConfig struct {
+	Host            string        `json:"host" yaml:"host"`
+	Port            int           `json:"port" yaml:"port"`
+	ReadTimeout     time.Duration `json:"read_timeout" yaml:"read_timeout"`
+	WriteTimeout    time.Duration `json:"write_timeout" yaml:"write_timeout"`
+	EnableCORS      bool          `json:"enable_cors" yaml:"enable_cors"`
+	StaticDir       string        `json:"static_dir" yaml:"static_dir"`
+	LogLevel        string        `json:"log_level" yaml:"log_level"`
+	MaxRequestBytes int64         `json:"max_request_bytes" yaml:"max_request_bytes"`
+	// PreflightTimeout bounds the preflight of created and registered agents
+	PreflightTimeout time.Duration `json:"preflight_timeout,omitempty" yaml:"preflight_timeout,omitempty"`
+
+	AllowConcurrentPerSession bool `json:"allow_concurrent_per_session" yaml:"allow_concurrent_per_session"`
+	RejectBusySession         bool `json:"reject_busy_session" yaml:"reject_busy_session"`
+
+	Admission server.AdmissionConfig `json:"admission" yaml:"admission"`
+	WorkQueue server.WorkQueueConfig `json:"work_queue" yaml:"work_queue"`
+	TLS       *server.TLSConfig      `json:"tls,omitempty" yaml:"tls,omitempty"`
+	// DeadLetter overrides the retries of unattended executions before they are dead-lettered
+	DeadLetter *server.DeadLetterConfig `json:"dead_letter,omitempty" yaml:"dead_letter,omitempty"`
+	// SessionStore set to redis shares the session locks between replicas
+	SessionStore server.SessionStoreConfig `json:"session_store" yaml:"session_store"`
+}
+
+// DatabaseConfig configures the database persisting threads and checkpoints
+type DatabaseConfig struct {
+	Type     string `json:"type" yaml:"type"`
+	Host     string `json:"host" yaml:"host"`
+	Port     int    `json:"port" yaml:"port"`
+	Database string `json:"database" yaml:"database"`
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password" yaml:"password"`
+	SSLMode  string `json:"ssl_mode,omitempty" yaml:"ssl_mode,omitempty"`
+}
+
+// VectorStoreConfig configures the database storing document embeddings
+type VectorStoreConfig struct {
+	DatabaseConfig `yaml:",inline"`
+
+	Dimensions     int    `json:"dimensions" yaml:"dimensions"`
+	CollectionName string `json:"collection_name,omitempty" yaml:"collection_name,omitempty"`
+}
+
+// RAGConfig configures document chunking and retrieval
+type RAGConfig struct {
+	Enabled             bool    `json:"enabled" yaml:"enabled"`
+	ChunkSize           int     `json:"chunk_size" yaml:"chunk_size"`
+	ChunkOverlap        int     `json:"chunk_overlap" yaml:"chunk_overlap"`
+	SimilarityThreshold float64 `json:"similarity_threshold" yaml:"similarity_threshold"`
+	MaxChunks           int     `json:"max_chunks" yaml:"max_chunks"`
+	EmbeddingModel      string  `json:"embedding_model" yaml:"embedding_model"`
+
+	// NoContextBehavior is what the agent does when no chunk passes the similarity
+	// threshold: answer_from_model, refuse or fallback, see agent.NoContextBehavior
+	NoContextBehavior string `json:"no_context_behavior,omitempty" yaml:"no_context_behavior,omitempty"`
+	// NoContextMessage is the answer given under the refuse behavior
+	NoContextMessage string `json:"no_context_message,omitempty" yaml:"no_context_message,omitempty"`
+	// FallbackTool is the tool called under the fallback behavior; web_search by default
+	FallbackTool string `json:"fallback_tool,omitempty" yaml:"fallback_tool,omitempty"`
+}
+
+// DocumentLoaderConfig enables a document loader
+type DocumentLoaderConfig struct {
+	Type    string `json:"type" yaml:"type"`
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+}
+
+// ThreadTitleConfig titles conversation threads with a model instead of the first words of
+// the opening message. The provider and model default to those of the agent.
+type ThreadTitleConfig struct {
+	Enabled  bool   `json:"enabled" yaml:"enabled"`
+	Provider string `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Model    string `json:"model,omitempty" yaml:"model,omitempty"`
+}
+
+// DefaultAppConfig returns the configuration files are applied on top of
+func DefaultAppConfig() *AppConfig {
+	defaults := server.DefaultServerConfig()
+	return &AppConfig{
+		AgentConfig: AgentConfig{
+			Type:          string(agent.AgentTypeChat),
+			Temperature:   0.7,
+			MaxTokens:     1000,
+			MaxIterations: 10,
+			Timeout:       30 * time.Second,
+		},
+		Server: ServerConfig{
+			Host:            defaults.Host,
+			Port:            defaults.Port,
+			ReadTimeout:     defaults.ReadTimeout,
+			WriteTimeout:    defaults.WriteTimeout,
+			EnableCORS:      defaults.EnableCORS,
+			StaticDir:       defaults.StaticDir,
+			LogLevel:        defaults.LogLevel,
+			MaxRequestBytes: defaults.MaxRequestBytes,
+			Admission:       defaults.Admission,
+			WorkQueue:       defaults.WorkQueue,
+		},
+	}
+}
+
+// HasAgent reports whether the configuration declares an agent; server-only configuration
+// files leave the agent fields empty
+func (c *AppConfig) HasAgent() bool {
+	return c.Name != "" || c.Model != "" || c.Provider != "" || c.SystemPrompt != ""
+}
+
+// ResolveModelAliases parses the alias targets for ProviderManager.SetModelAliases
+func (c *AppConfig) ResolveModelAliases() (map[string]llm.ModelAlias, error) {
+	aliases := make(map[string]llm.ModelAlias, len(c.ModelAliases))
+	for alias, target := range c.ModelAliases {
+		parsed, err := llm.ParseModelAlias(target)
+		if err != nil {
+			return nil, fmt.Errorf("model alias %s: %w", alias, err)
+		}
+		aliases[alias] = parsed
+	}
+	return aliases, nil
+}
+
+// TitleGenerator returns the generator titling threads with the configured model, or nil
+// when thread titles are not enabled
+func (c *AppConfig) TitleGenerator(manager *llm.ProviderManager) persistence.TitleGenerator {
+	if c.ThreadTitles == nil || !c.ThreadTitles.Enabled {
+		return nil
+	}
+	provider, model := c.threadTitleModel()
+	return persistence.NewLLMTitleGenerator(manager, provider, model)
+}
+
+// threadTitleModel returns the provider and model titling threads, those of the agent by
+// default
+func (c *AppConfig) threadTitleModel() (string, string) {
+	provider, model := c.ThreadTitles.Provider, c.ThreadTitles.Model
+	if provider == "" {
+		provider = c.Provider
+	}
+	if model == "" {
+		model = c.Model
+	}
+	return provider, model
+}
+
+// ToAgentConfig converts the configuration into an agent configuration with the names of
+// the enabled tools
+func (c *AgentConfig) ToAgentConfig() *agent.AgentConfig {
+	config := agent.DefaultAgentConfig()
+	config.Name = c.Name
+	config.Type = agent.AgentType(c.Type)
+	config.Model = c.Model
+	config.Provider = c.Provider
+	config.SystemPrompt = c.SystemPrompt
+	config.Temperature = c.Temperature
+	config.MaxTokens = c.MaxTokens
+	config.MaxIterations = c.MaxIterations
+	config.MaxToolCalls = c.MaxToolCalls
+	config.RetryBudget = c.RetryBudget
+	config.Timeout = c.Timeout
+	config.EnableStreaming = c.EnableStreaming
+	config.Locale = c.Locale
+	for _, spec := range c.Tools {
+		if spec.IsEnabled() {
+			config.Tools = append(config.Tools, spec.Name)
+		}
+	}
+	return config
+}
+
+// ToAgentConfig converts the configuration into an agent configuration, with the retrieval
+// settings of the rag section when it is enabled
+func (c *AppConfig) ToAgentConfig() *agent.AgentConfig {
+	config := c.AgentConfig.ToAgentConfig()
+	if c.RAG != nil && c.RAG.Enabled {
+		c.RAG.ApplyTo(config)
+	}
+	return config
+}
+
+// ApplyTo sets the retrieval options and no context behavior of an agent configuration
+func (c *RAGConfig) ApplyTo(config *agent.AgentConfig) {
+	config.RetrieveOptions = &rag.RetrieveOptions{
+		TopK:     c.MaxChunks,
+		MinScore: c.SimilarityThreshold,
+	}
+	config.NoContextBehavior = agent.NoContextBehavior(c.NoContextBehavior)
+	config.NoContextMessage = c.NoContextMessage
+	config.NoContextTool = c.FallbackTool
+}
+
+// ToServerConfig converts the configuration into a server configuration
+func (c *ServerConfig) ToServerConfig() *server.ServerConfig {
+	config := server.DefaultServerConfig()
+	config.Host = c.Host
+	config.Port = c.Port
+	config.ReadTimeout = c.ReadTimeout
+	config.WriteTimeout = c.WriteTimeout
+	config.EnableCORS = c.EnableCORS
+	config.StaticDir = c.StaticDir
+	config.LogLevel = c.LogLevel
+	config.MaxRequestBytes = c.MaxRequestBytes
+	if c.PreflightTimeout > 0 {
+		config.PreflightTimeout = c.PreflightTimeout
+	}
+	config.AllowConcurrentPerSession = c.AllowConcurrentPerSession
+	config.RejectBusySession = c.RejectBusySession
+	config.Admission = c.Admission
+	config.WorkQueue = c.WorkQueue
+	config.SessionStore = c.SessionStore
+	config.TLS = c.TLS
+	if c.DeadLetter != nil {
+		config.DeadLetter = *c.DeadLetter
+	}
+	return config
+}
+
+// ToDatabaseConfig converts the configuration into a persistence database configuration
+func (c *DatabaseConfig) ToDatabaseConfig() *persistence.DatabaseConfig {
+	return &persistence.DatabaseConfig{
+		Type:     persistence.DatabaseType(c.Type),
+		Host:     c.Host,
+		Port:     c.Port,
+		Database: c.Database,
+		Username: c.Username,
+		Password: c.Password,
+		SSLMode:  c.SSLMode,
+	}
+}
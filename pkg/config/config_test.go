@@ -0,0 +1,382 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package config
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/agent"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/server"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeConfig(t, "agent-config.yaml", `name: "basic-agent"
+type: "chat"
+model: "gpt-3.5-turbo"
+provider: "openai"
+max_tokens: 2000
+timeout: 1m
+
+tools:
+  - name: "calculator"
+    enabled: true
+  - name: "web_search"
+    enabled: false
+
+server:
+  port: 9090
+  admission:
+    enabled: true
+    max_memory_percent: 80
+  work_queue:
+    enabled: true
+    workers: 4
+  session_store:
+    type: redis
+    addr: "redis:6379"
+
+database:
+  type: "postgres"
+  host: "localhost"
+  port: 5432
+  database: "golanggraph"
+`)
+
+	t.Setenv("GOLANGGRAPH_MODEL", "gpt-4")
+	t.Setenv("GOLANGGRAPH_SERVER_HOST", "127.0.0.1")
+	t.Setenv("GOLANGGRAPH_DATABASE_PASSWORD", "secret")
+	t.Setenv("GOLANGGRAPH_RAG_CHUNK_SIZE", "500")
+
+	config, err := Load(path)
+	require.NoError(t, err)
+
+	// Environment overrides the file, which overrides the defaults
+	assert.Equal(t, "gpt-4", config.Model)
+	assert.Equal(t, 2000, config.MaxTokens)
+	assert.Equal(t, time.Minute, config.Timeout)
+	assert.Equal(t, 0.7, config.Temperature)
+	assert.Equal(t, "127.0.0.1", config.Server.Host)
+	assert.Equal(t, 9090, config.Server.Port)
+	assert.True(t, config.Server.EnableCORS)
+	require.NotNil(t, config.Database)
+	assert.Equal(t, "secret", config.Database.Password)
+	assert.Equal(t, "golanggraph", config.Database.Database)
+	require.NotNil(t, config.RAG)
+	assert.Equal(t, 500, config.RAG.ChunkSize)
+	assert.Nil(t, config.VectorStore)
+
+	agentConfig := config.ToAgentConfig()
+	assert.Equal(t, agent.AgentTypeChat, agentConfig.Type)
+	assert.Equal(t, []string{"calculator"}, agentConfig.Tools)
+	assert.Equal(t, 9090, config.Server.ToServerConfig().Port)
+
+	// Admission thresholds not set in the file keep their defaults
+	admission := config.Server.ToServerConfig().Admission
+	assert.True(t, admission.Enabled)
+	assert.Equal(t, 80.0, admission.MaxMemoryPercent)
+	assert.Equal(t, 90.0, admission.MaxCPUPercent)
+
+	// So do the work queue limits
+	workQueue := config.Server.ToServerConfig().WorkQueue
+	assert.True(t, workQueue.Enabled)
+	assert.Equal(t, 4, workQueue.Workers)
+	assert.Equal(t, 100, workQueue.MaxDepth)
+
+	sessionStore := config.Server.ToServerConfig().SessionStore
+	assert.Equal(t, server.SessionStoreRedis, sessionStore.Type)
+	assert.Equal(t, "redis:6379", sessionStore.Addr)
+}
+
+func TestLoad_JSON(t *testing.T) {
+	path := writeConfig(t, "agent-config.json", `{
+  "name": "json-agent",
+  "model": "llama3",
+  "provider": "ollama",
+  "vector_store": {"type": "pgvector", "host": "db", "port": 5432, "database": "vectors", "dimensions": 768}
+}`)
+
+	config, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "json-agent", config.Name)
+	require.NotNil(t, config.VectorStore)
+	assert.Equal(t, "db", config.VectorStore.Host)
+	assert.Equal(t, 768, config.VectorStore.Dimensions)
+	assert.NoError(t, config.Validate())
+}
+
+func TestLoad_Errors(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+
+	t.Setenv("GOLANGGRAPH_SERVER_PORT", "eighty")
+	_, err = Load("")
+	assert.ErrorContains(t, err, "GOLANGGRAPH_SERVER_PORT")
+
+	path := writeConfig(t, "agent-config.yaml", "name: agent\nunknown_field: true\n")
+	loader := &Loader{KnownFields: true}
+	_, err = loader.Load(path)
+	assert.ErrorContains(t, err, "unknown_field")
+}
+
+func TestLoad_RemoteSource(t *testing.T) {
+	var mu sync.Mutex
+	content, etag := "name: remote-agent\nmodel: gpt-4\n", `"v1"`
+	var requests, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if r.Header.Get("Authorization") != "Bearer config-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	_, err := (&Loader{Source: &SourceOptions{}}).Load(server.URL + "/agent.yaml")
+	assert.ErrorContains(t, err, "status 401")
+
+	t.Setenv("GOLANGGRAPH_CONFIG_TOKEN", "config-token")
+	loader := NewLoader()
+	config, err := loader.Load(server.URL + "/agent.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "remote-agent", config.Name)
+	assert.Equal(t, "gpt-4", config.Model)
+
+	// Changes are reported once each; unchanged polls are conditional requests
+	source, err := loader.OpenSource(server.URL + "/agent.yaml")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan *AppConfig, 4)
+	go loader.Watch(ctx, source, 10*time.Millisecond, func(config *AppConfig, err error) {
+		if err == nil {
+			changes <- config
+		}
+	})
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return notModified >= 2
+	}, time.Second, 5*time.Millisecond)
+	mu.Lock()
+	content, etag = "name: remote-agent\nmodel: gpt-4o\n", `"v2"`
+	mu.Unlock()
+
+	select {
+	case changed := <-changes:
+		assert.Equal(t, "gpt-4o", changed.Model)
+	case <-time.After(time.Second):
+		t.Fatal("Expected the change to be reported")
+	}
+	assert.Empty(t, changes)
+}
+
+func TestOpenSource(t *testing.T) {
+	path := writeConfig(t, "agent-config.yaml", "name: file-agent\n")
+	for _, location := range []string{path, "file://" + path} {
+		source, err := OpenSource(location, SourceOptions{})
+		require.NoError(t, err)
+		data, _, err := source.Fetch(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "name: file-agent\n", string(data))
+	}
+
+	_, err := OpenSource("consul://localhost/agents/support", SourceOptions{})
+	assert.ErrorContains(t, err, "unsupported config source scheme")
+
+	RegisterSourceScheme("memory", func(location *url.URL, options SourceOptions) (ConfigSource, error) {
+		return staticSource{name: location.String(), data: "name: " + location.Host + "\n"}, nil
+	})
+	config, err := Load("memory://pluggable-agent")
+	require.NoError(t, err)
+	assert.Equal(t, "pluggable-agent", config.Name)
+
+	assert.True(t, IsRemoteLocation("https://config.example.com/agent.yaml"))
+	assert.False(t, IsRemoteLocation(path))
+	assert.False(t, IsRemoteLocation("file://"+path))
+}
+
+// staticSource serves fixed contents
+type staticSource struct {
+	name string
+	data string
+}
+
+func (s staticSource) Name() string { return s.name }
+
+func (s staticSource) Fetch(ctx context.Context) ([]byte, string, error) {
+	return []byte(s.data), s.data, nil
+}
+
+func TestAppConfig_Validate(t *testing.T) {
+	config := DefaultAppConfig()
+	config.Name = "agent"
+	config.Temperature = 3
+	config.MaxTokens = 50
+	config.Tools = append(config.Tools, toolSpec("calculator"), toolSpec(""), toolSpec("calculator"))
+	config.Server.Port = 0
+	config.Server.Admission.MaxCPUPercent = 150
+	config.Server.WorkQueue.Workers = -1
+	config.Server.SessionStore.Type = server.SessionStoreRedis
+	config.Database = &DatabaseConfig{Type: "postgres", Port: 5432}
+	config.RAG = &RAGConfig{Enabled: true, ChunkSize: 100, ChunkOverlap: 100, SimilarityThreshold: 0.7, MaxChunks: 5, EmbeddingModel: "embed", NoContextBehavior: "guess"}
+
+	err := config.Validate()
+	var validationErrs ValidationErrors
+	require.True(t, errors.As(err, &validationErrs))
+
+	paths := make([]string, len(validationErrs))
+	for i, fieldErr := range validationErrs {
+		paths[i] = fieldErr.Path
+	}
+	assert.Equal(t, []string{
+		"model",
+		"provider",
+		"temperature",
+		"max_tokens",
+		"tools[1].name",
+		"tools[2].name",
+		"server.port",
+		"server.admission.max_cpu_percent",
+		"server.work_queue.workers",
+		"server.session_store.addr",
+		"database.host",
+		"database.database",
+		"rag.chunk_overlap",
+		"rag.no_context_behavior",
+		"vector_store",
+	}, paths)
+	assert.Contains(t, err.Error(), "tools[2].name: duplicates tools[0]")
+
+	// Configuration files without an agent only configure the server
+	assert.NoError(t, DefaultAppConfig().Validate())
+}
+
+func TestAppConfig_ValidateStrict(t *testing.T) {
+	config := DefaultAppConfig()
+	config.Name = "rag-agent"
+	config.Type = "rag"
+	config.Model = "gpt-4"
+	config.Provider = "openai"
+	config.Tools = append(config.Tools, toolSpec("calculator"), toolSpec("summarizer"))
+
+	assert.NoError(t, config.Validate())
+
+	err := config.ValidateStrict()
+	var validationErrs ValidationErrors
+	require.True(t, errors.As(err, &validationErrs))
+	require.Len(t, validationErrs, 2)
+	assert.Equal(t, "type", validationErrs[0].Path)
+	assert.Equal(t, "tools[1].name", validationErrs[1].Path)
+}
+
+func TestAppConfig_ModelAliases(t *testing.T) {
+	path := writeConfig(t, "agent-config.yaml", `name: "aliased-agent"
+provider: "alias"
+model: "smart"
+
+model_aliases:
+  fast: "openai:gpt-4o-mini"
+  smart: "anthropic:claude-3-5-sonnet"
+`)
+
+	config, err := Load(path)
+	require.NoError(t, err)
+	require.NoError(t, config.Validate())
+
+	aliases, err := config.ResolveModelAliases()
+	require.NoError(t, err)
+	assert.Equal(t, llm.ModelAlias{Provider: "anthropic", Model: "claude-3-5-sonnet"}, aliases["smart"])
+
+	config.Model = "smartest"
+	config.ModelAliases["broken"] = "gpt-4"
+	err = config.Validate()
+	var validationErrs ValidationErrors
+	require.True(t, errors.As(err, &validationErrs))
+	require.Len(t, validationErrs, 2)
+	assert.Equal(t, "model_aliases.broken", validationErrs[0].Path)
+	assert.Equal(t, "model", validationErrs[1].Path)
+}
+
+func toolSpec(name string) tools.ToolSpec {
+	return tools.ToolSpec{Name: name}
+}
+
+func TestAppConfig_ToAgentConfig_RAG(t *testing.T) {
+	config := DefaultAppConfig()
+	config.Name = "rag-agent"
+	config.RAG = &RAGConfig{
+		SimilarityThreshold: 0.75,
+		MaxChunks:           4,
+		NoContextBehavior:   "fallback",
+		FallbackTool:        "http_search",
+	}
+
+	// The rag section only applies once enabled
+	assert.Nil(t, config.ToAgentConfig().RetrieveOptions)
+
+	config.RAG.Enabled = true
+	agentConfig := config.ToAgentConfig()
+	require.NotNil(t, agentConfig.RetrieveOptions)
+	assert.Equal(t, 4, agentConfig.RetrieveOptions.TopK)
+	assert.Equal(t, 0.75, agentConfig.RetrieveOptions.MinScore)
+	assert.Equal(t, agent.NoContextFallback, agentConfig.NoContextBehavior)
+	assert.Equal(t, "http_search", agentConfig.NoContextTool)
+}
+
+func TestAppConfig_TitleGenerator(t *testing.T) {
+	config := DefaultAppConfig()
+	llmManager := llm.NewProviderManager()
+	assert.Nil(t, config.TitleGenerator(llmManager))
+
+	// The thread_titles section titles threads with the agent's model unless it names one
+	config.ThreadTitles = &ThreadTitleConfig{Enabled: true, Model: "gpt-4o-mini"}
+	err := config.Validate()
+	var validationErrs ValidationErrors
+	require.True(t, errors.As(err, &validationErrs))
+	require.Len(t, validationErrs, 1)
+	assert.Equal(t, "thread_titles.provider", validationErrs[0].Path)
+
+	config.Name = "titled-agent"
+	config.Provider = "openai"
+	config.Model = "gpt-4"
+	require.NoError(t, config.Validate())
+	provider, model := config.threadTitleModel()
+	assert.Equal(t, "openai", provider)
+	assert.Equal(t, "gpt-4o-mini", model)
+	assert.NotNil(t, config.TitleGenerator(llmManager))
+}
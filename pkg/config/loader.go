@@ -0,0 +1,259 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultEnvPrefix prefixes the environment variables overriding configuration fields
+const DefaultEnvPrefix = "GOLANGGRAPH"
+
+// Loader loads configuration files. Values are applied with increasing precedence: the
+// defaults, the file, then environment variables named after the field path, such as
+// GOLANGGRAPH_MODEL or GOLANGGRAPH_SERVER_PORT for server.port.
+type Loader struct {
+	// EnvPrefix prefixes the environment variables; empty disables environment overrides
+	EnvPrefix string
+	// KnownFields rejects files with fields that are not part of the schema
+	KnownFields bool
+	// Source configures the authentication and TLS of remote locations; nil reads them from
+	// the environment, as described by SourceOptionsFromEnv
+	Source *SourceOptions
+}
+
+// NewLoader creates a loader reading GOLANGGRAPH_ environment overrides
+func NewLoader() *Loader {
+	return &Loader{EnvPrefix: DefaultEnvPrefix}
+}
+
+// Load loads a configuration file with the default loader
+func Load(path string) (*AppConfig, error) {
+	return NewLoader().Load(path)
+}
+
+// Load reads a YAML or JSON configuration file and applies the environment overrides. The
+// path may also be a URL, such as https://config.example.com/agent.yaml, read with
+// OpenSource. An empty path loads the defaults and the environment only. The configuration
+// is not validated.
+func (l *Loader) Load(path string) (*AppConfig, error) {
+	if strings.Contains(path, "://") {
+		source, err := l.OpenSource(path)
+		if err != nil {
+			return nil, err
+		}
+		return l.LoadSource(context.Background(), source)
+	}
+
+	var data []byte
+	if path != "" {
+		var err error
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
+	return l.load(data, path)
+}
+
+// OpenSource returns the source of a location with the loader's source options
+func (l *Loader) OpenSource(location string) (ConfigSource, error) {
+	if l.Source != nil {
+		return OpenSource(location, *l.Source)
+	}
+	return OpenSource(location, SourceOptionsFromEnv(l.envPrefix()))
+}
+
+// LoadSource reads a configuration from a source and applies the environment overrides.
+// The configuration is not validated.
+func (l *Loader) LoadSource(ctx context.Context, source ConfigSource) (*AppConfig, error) {
+	data, _, err := source.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return l.load(data, source.Name())
+}
+
+// Watch polls a source every interval until the context ends, calling onChange with the
+// configuration loaded whenever the source's version changes, or with the error when it
+// cannot be fetched or parsed. The version at the time Watch is called is the baseline, so
+// the configuration already loaded is not reported again.
+func (l *Loader) Watch(ctx context.Context, source ConfigSource, interval time.Duration, onChange func(*AppConfig, error)) {
+	_, current, err := source.Fetch(ctx)
+	if err != nil {
+		onChange(nil, err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		data, version, err := source.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				onChange(nil, err)
+			}
+			continue
+		}
+		if version == current {
+			continue
+		}
+		current = version
+
+		onChange(l.load(data, source.Name()))
+	}
+}
+
+// envPrefix returns the prefix of the loader's environment variables
+func (l *Loader) envPrefix() string {
+	if l.EnvPrefix != "" {
+		return l.EnvPrefix
+	}
+	return DefaultEnvPrefix
+}
+
+// load decodes the contents of a configuration file over the defaults and applies the
+// environment overrides
+func (l *Loader) load(data []byte, name string) (*AppConfig, error) {
+	config := DefaultAppConfig()
+
+	if err := l.decode(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", name, err)
+	}
+
+	if l.EnvPrefix != "" {
+		if err := applyEnv(reflect.ValueOf(config).Elem(), l.EnvPrefix); err != nil {
+			return nil, err
+		}
+	}
+
+	return config, nil
+}
+
+// decode decodes a file over the configuration. JSON documents are valid YAML, so both
+// formats share the decoder and accept durations such as "30s".
+func (l *Loader) decode(data []byte, config *AppConfig) error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(l.KnownFields)
+	return decoder.Decode(config)
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv sets the fields of a struct from the environment variables named after their
+// YAML names. Nested sections extend the prefix and optional sections are only allocated
+// when a variable sets one of their fields.
+func applyEnv(value reflect.Value, prefix string) error {
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		name, inline := yamlFieldName(field)
+		if name == "-" {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+		envName := prefix
+		if !inline {
+			envName = prefix + "_" + strings.ToUpper(name)
+		}
+
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			if err := applyEnv(fieldValue, envName); err != nil {
+				return err
+			}
+		case field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct:
+			section := reflect.New(field.Type.Elem())
+			if !fieldValue.IsNil() {
+				section.Elem().Set(fieldValue.Elem())
+			}
+			if err := applyEnv(section.Elem(), envName); err != nil {
+				return err
+			}
+			if !fieldValue.IsNil() || !section.Elem().IsZero() {
+				fieldValue.Set(section)
+			}
+		default:
+			raw, ok := os.LookupEnv(envName)
+			if !ok {
+				continue
+			}
+			if err := setScalar(fieldValue, raw); err != nil {
+				return fmt.Errorf("invalid %s: %w", envName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// yamlFieldName returns the YAML name of a field and whether it is inlined
+func yamlFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("yaml")
+	name, options, _ := strings.Cut(tag, ",")
+	if strings.Contains(options, "inline") {
+		return "", true
+	}
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name, false
+}
+
+// setScalar parses an environment value into a field; fields of other kinds, such as the
+// tool list, can only be set in files
+func setScalar(value reflect.Value, raw string) error {
+	if value.Type() == durationType {
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(duration))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		value.SetBool(parsed)
+	case reflect.Int, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		value.SetInt(parsed)
+	case reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		value.SetFloat(parsed)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConfigSource supplies the contents of a configuration file, such as a local file, an HTTP
+// endpoint or a key in a remote store
+type ConfigSource interface {
+	// Name identifies the source in errors
+	Name() string
+	// Fetch returns the current contents with a version that changes whenever they change
+	Fetch(ctx context.Context) (data []byte, version string, err error)
+}
+
+// SourceOptions configures the authentication and TLS of remote sources
+type SourceOptions struct {
+	// BearerToken is sent in the Authorization header
+	BearerToken string
+	// Username and Password are sent with basic authentication when no token is set
+	Username string
+	Password string
+	// Headers are sent with every request
+	Headers map[string]string
+	// CAFile is a PEM bundle of the authorities trusted in addition to the system ones
+	CAFile string
+	// CertFile and KeyFile are the client certificate presented for mutual TLS
+	CertFile string
+	KeyFile  string
+	// InsecureSkipVerify disables the verification of the server certificate
+	InsecureSkipVerify bool
+	// Timeout bounds each request; defaults to 30 seconds
+	Timeout time.Duration
+}
+
+// SourceOptionsFromEnv reads the options of remote sources from environment variables such
+// as GOLANGGRAPH_CONFIG_TOKEN, GOLANGGRAPH_CONFIG_USERNAME, GOLANGGRAPH_CONFIG_PASSWORD,
+// GOLANGGRAPH_CONFIG_CA_FILE, GOLANGGRAPH_CONFIG_CERT_FILE, GOLANGGRAPH_CONFIG_KEY_FILE and
+// GOLANGGRAPH_CONFIG_INSECURE_SKIP_VERIFY
+func SourceOptionsFromEnv(prefix string) SourceOptions {
+	env := func(name string) string {
+		return os.Getenv(prefix + "_CONFIG_" + name)
+	}
+	insecure, _ := strconv.ParseBool(env("INSECURE_SKIP_VERIFY"))
+	return SourceOptions{
+		BearerToken:        env("TOKEN"),
+		Username:           env("USERNAME"),
+		Password:           env("PASSWORD"),
+		CAFile:             env("CA_FILE"),
+		CertFile:           env("CERT_FILE"),
+		KeyFile:            env("KEY_FILE"),
+		InsecureSkipVerify: insecure,
+	}
+}
+
+// TLSConfig returns the TLS configuration of the options, or nil when they use the defaults
+func (o SourceOptions) TLSConfig() (*tls.Config, error) {
+	if o.CAFile == "" && o.CertFile == "" && !o.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", o.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if o.CertFile != "" || o.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// SourceFactory opens the source of a location with a registered scheme
+type SourceFactory func(location *url.URL, options SourceOptions) (ConfigSource, error)
+
+var (
+	sourceFactoriesMu sync.RWMutex
+	sourceFactories   = map[string]SourceFactory{
+		"file": func(location *url.URL, options SourceOptions) (ConfigSource, error) {
+			return &FileSource{Path: location.Host + location.Path}, nil
+		},
+		"http":  newHTTPSourceFactory,
+		"https": newHTTPSourceFactory,
+	}
+)
+
+// RegisterSourceScheme makes the locations of a URL scheme, such as "s3" or "consul", open
+// with a factory. Registering http, https or file replaces the built-in sources.
+func RegisterSourceScheme(scheme string, factory SourceFactory) {
+	sourceFactoriesMu.Lock()
+	defer sourceFactoriesMu.Unlock()
+	sourceFactories[strings.ToLower(scheme)] = factory
+}
+
+// IsRemoteLocation reports whether a configuration location is a URL rather than a path
+func IsRemoteLocation(location string) bool {
+	scheme, _, found := strings.Cut(location, "://")
+	return found && scheme != "" && !strings.ContainsAny(scheme, `/\`) && strings.ToLower(scheme) != "file"
+}
+
+// OpenSource returns the source of a location: a file path, or a URL whose scheme is
+// registered, such as https://config.example.com/agent.yaml
+func OpenSource(location string, options SourceOptions) (ConfigSource, error) {
+	if !strings.Contains(location, "://") {
+		return &FileSource{Path: location}, nil
+	}
+
+	parsed, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config location %s: %w", location, err)
+	}
+	sourceFactoriesMu.RLock()
+	factory, exists := sourceFactories[strings.ToLower(parsed.Scheme)]
+	sourceFactoriesMu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("unsupported config source scheme: %s", parsed.Scheme)
+	}
+	return factory(parsed, options)
+}
+
+// FileSource reads configuration from a local file
+type FileSource struct {
+	Path string
+}
+
+// Name returns the file path
+func (s *FileSource) Name() string {
+	return s.Path
+}
+
+// Fetch reads the file; its version is the hash of its contents
+func (s *FileSource) Fetch(ctx context.Context) ([]byte, string, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read config file: %w", err)
+	}
+	return data, contentVersion(data), nil
+}
+
+// HTTPSource reads configuration from an HTTP(S) URL. Requests after the first are
+// conditional on the ETag of the last response, so an unchanged file is not downloaded again.
+type HTTPSource struct {
+	URL     string
+	Options SourceOptions
+	client  *http.Client
+
+	mu       sync.Mutex
+	etag     string
+	lastData []byte
+}
+
+// NewHTTPSource creates a source reading a URL with the authentication and TLS of options
+func NewHTTPSource(location string, options SourceOptions) (*HTTPSource, error) {
+	tlsConfig, err := options.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &HTTPSource{
+		URL:     location,
+		Options: options,
+		client:  &http.Client{Timeout: timeout, Transport: transport},
+	}, nil
+}
+
+func newHTTPSourceFactory(location *url.URL, options SourceOptions) (ConfigSource, error) {
+	return NewHTTPSource(location.String(), options)
+}
+
+// Name returns the URL without its credentials
+func (s *HTTPSource) Name() string {
+	if parsed, err := url.Parse(s.URL); err == nil {
+		return parsed.Redacted()
+	}
+	return s.URL
+}
+
+// Fetch downloads the file; its version is the ETag of the response, or the hash of the
+// contents when the server sends none
+func (s *HTTPSource) Fetch(ctx context.Context) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create config request: %w", err)
+	}
+	for key, value := range s.Options.Headers {
+		req.Header.Set(key, value)
+	}
+	if s.Options.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Options.BearerToken)
+	} else if s.Options.Username != "" {
+		req.SetBasicAuth(s.Options.Username, s.Options.Password)
+	}
+
+	s.mu.Lock()
+	etag, lastData := s.etag, s.lastData
+	s.mu.Unlock()
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch config from %s: %w", s.Name(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && lastData != nil {
+		return lastData, etag, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to fetch config from %s: status %d", s.Name(), resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read config from %s: %w", s.Name(), err)
+	}
+
+	version := resp.Header.Get("ETag")
+	s.mu.Lock()
+	s.etag, s.lastData = version, data
+	s.mu.Unlock()
+	if version == "" {
+		version = contentVersion(data)
+	}
+	return data, version, nil
+}
+
+// contentVersion returns the hash of contents, as the version of sources without one
+func contentVersion(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
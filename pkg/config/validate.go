@@ -0,0 +1,295 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/agent"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/server"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
+)
+
+// FieldError is a validation error of a configuration field
+type FieldError struct {
+	// Path locates the field, e.g. "tools[1].name" or "server.port"
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidationErrors lists every invalid field of a configuration
+type ValidationErrors []FieldError
+
+// Error implements the error interface
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Error()
+	}
+	return fmt.Sprintf("%d configuration errors: %s", len(e), strings.Join(messages, "; "))
+}
+
+// validator collects field errors
+type validator struct {
+	errors ValidationErrors
+}
+
+func (v *validator) add(path, format string, args ...interface{}) {
+	v.errors = append(v.errors, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) err() error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return v.errors
+}
+
+// Validate checks every section of the configuration and returns all the invalid fields at
+// once as ValidationErrors. The agent fields are only checked when HasAgent reports an agent.
+func (c *AppConfig) Validate() error {
+	v := &validator{}
+	c.validate(v)
+	return v.err()
+}
+
+// ValidateStrict validates the configuration and also rejects agent types and tools that
+// are not registered
+func (c *AppConfig) ValidateStrict() error {
+	v := &validator{}
+	c.validate(v)
+
+	if c.HasAgent() {
+		switch agent.AgentType(c.Type) {
+		case agent.AgentTypeChat, agent.AgentTypeReAct, agent.AgentTypeTool, agent.AgentTypeGraph:
+		default:
+			if c.Type != "" {
+				v.add("type", "unknown agent type %q", c.Type)
+			}
+		}
+	}
+
+	registered := make(map[string]bool)
+	for _, name := range tools.ListFactories() {
+		registered[name] = true
+	}
+	for i, spec := range c.Tools {
+		if spec.Name != "" && !registered[spec.Name] {
+			v.add(fmt.Sprintf("tools[%d].name", i), "unknown tool %q", spec.Name)
+		}
+	}
+
+	return v.err()
+}
+
+func (c *AppConfig) validate(v *validator) {
+	if c.HasAgent() {
+		c.AgentConfig.validate(v)
+	}
+	c.Server.validate(v, "server")
+	if c.Database != nil {
+		c.Database.validate(v, "database")
+	}
+	if c.VectorStore != nil {
+		c.VectorStore.DatabaseConfig.validate(v, "vector_store")
+		if c.VectorStore.Dimensions <= 0 {
+			v.add("vector_store.dimensions", "must be greater than 0")
+		}
+	}
+	if c.RAG != nil && c.RAG.Enabled {
+		c.RAG.validate(v, "rag")
+		if c.VectorStore == nil {
+			v.add("vector_store", "is required when rag is enabled")
+		}
+	}
+	aliases := make([]string, 0, len(c.ModelAliases))
+	for alias := range c.ModelAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		if _, err := llm.ParseModelAlias(c.ModelAliases[alias]); err != nil {
+			v.add("model_aliases."+alias, "%v", err)
+		}
+	}
+	if c.HasAgent() && c.Provider == llm.AliasProvider && c.Model != "" {
+		if _, exists := c.ModelAliases[c.Model]; !exists {
+			v.add("model", "model alias %q is not defined in model_aliases", c.Model)
+		}
+	}
+	if c.ThreadTitles != nil && c.ThreadTitles.Enabled {
+		provider, model := c.threadTitleModel()
+		if provider == "" {
+			v.add("thread_titles.provider", "is required when the agent has no provider")
+		}
+		if model == "" {
+			v.add("thread_titles.model", "is required when the agent has no model")
+		}
+	}
+	for i, loader := range c.DocumentLoaders {
+		if loader.Type == "" {
+			v.add(fmt.Sprintf("document_loaders[%d].type", i), "is required")
+		}
+	}
+}
+
+func (c *AgentConfig) validate(v *validator) {
+	if c.Name == "" {
+		v.add("name", "is required")
+	}
+	if c.Type == "" {
+		v.add("type", "is required")
+	}
+	if c.Model == "" {
+		v.add("model", "is required")
+	}
+	if c.Provider == "" {
+		v.add("provider", "is required")
+	}
+	if c.Temperature < 0 || c.Temperature > 2.0 {
+		v.add("temperature", "must be between 0 and 2.0, got %g", c.Temperature)
+	}
+	// The agent refuses limits low enough to truncate responses
+	if c.MaxTokens <= 100 || c.MaxTokens > 100000 {
+		v.add("max_tokens", "must be greater than 100 and at most 100000, got %d", c.MaxTokens)
+	}
+	if c.MaxIterations <= 0 || c.MaxIterations > 100 {
+		v.add("max_iterations", "must be between 1 and 100, got %d", c.MaxIterations)
+	}
+	if c.MaxToolCalls < 0 {
+		v.add("max_tool_calls", "must not be negative, got %d", c.MaxToolCalls)
+	}
+	if c.RetryBudget < 0 {
+		v.add("retry_budget", "must not be negative, got %d", c.RetryBudget)
+	}
+	if c.Timeout < 0 {
+		v.add("timeout", "must not be negative")
+	}
+
+	seen := make(map[string]int)
+	for i, spec := range c.Tools {
+		path := fmt.Sprintf("tools[%d].name", i)
+		if spec.Name == "" {
+			v.add(path, "is required")
+			continue
+		}
+		if first, exists := seen[spec.Name]; exists {
+			v.add(path, "duplicates tools[%d]", first)
+			continue
+		}
+		seen[spec.Name] = i
+		if limit := spec.Limit; limit != nil {
+			limitPath := fmt.Sprintf("tools[%d].limit", i)
+			if limit.RequestsPerMinute < 0 {
+				v.add(limitPath+".requests_per_minute", "must not be negative, got %d", limit.RequestsPerMinute)
+			}
+			if limit.Daily < 0 {
+				v.add(limitPath+".daily", "must not be negative, got %d", limit.Daily)
+			}
+			if limit.Monthly < 0 {
+				v.add(limitPath+".monthly", "must not be negative, got %d", limit.Monthly)
+			}
+		}
+	}
+}
+
+func (c *ServerConfig) validate(v *validator, path string) {
+	if c.Port < 1 || c.Port > 65535 {
+		v.add(path+".port", "must be between 1 and 65535, got %d", c.Port)
+	}
+	if c.ReadTimeout < 0 {
+		v.add(path+".read_timeout", "must not be negative")
+	}
+	if c.WriteTimeout < 0 {
+		v.add(path+".write_timeout", "must not be negative")
+	}
+	switch c.LogLevel {
+	case "", "debug", "info", "warn", "error":
+	default:
+		v.add(path+".log_level", "must be one of debug, info, warn, error, got %q", c.LogLevel)
+	}
+	if c.MaxRequestBytes < 0 {
+		v.add(path+".max_request_bytes", "must not be negative")
+	}
+	if c.PreflightTimeout < 0 {
+		v.add(path+".preflight_timeout", "must not be negative")
+	}
+	if c.Admission.MaxCPUPercent < 0 || c.Admission.MaxCPUPercent > 100 {
+		v.add(path+".admission.max_cpu_percent", "must be between 0 and 100, got %g", c.Admission.MaxCPUPercent)
+	}
+	if c.Admission.MaxMemoryPercent < 0 || c.Admission.MaxMemoryPercent > 100 {
+		v.add(path+".admission.max_memory_percent", "must be between 0 and 100, got %g", c.Admission.MaxMemoryPercent)
+	}
+	if c.Admission.Hysteresis < 0 {
+		v.add(path+".admission.hysteresis", "must not be negative")
+	}
+	if c.Admission.SampleInterval < 0 {
+		v.add(path+".admission.sample_interval", "must not be negative")
+	}
+	if c.WorkQueue.Workers < 0 {
+		v.add(path+".work_queue.workers", "must not be negative")
+	}
+	if c.WorkQueue.MaxDepth < 0 {
+		v.add(path+".work_queue.max_depth", "must not be negative")
+	}
+	if c.WorkQueue.AgingInterval < 0 {
+		v.add(path+".work_queue.aging_interval", "must not be negative")
+	}
+	switch c.SessionStore.Type {
+	case "", server.SessionStoreMemory:
+	case server.SessionStoreRedis:
+		if c.SessionStore.Addr == "" {
+			v.add(path+".session_store.addr", "is required for a redis session store")
+		}
+	default:
+		v.add(path+".session_store.type", "must be memory or redis, got %q", c.SessionStore.Type)
+	}
+}
+
+func (c *DatabaseConfig) validate(v *validator, path string) {
+	if c.Type == "" {
+		v.add(path+".type", "is required")
+	}
+	if c.Host == "" {
+		v.add(path+".host", "is required")
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		v.add(path+".port", "must be between 1 and 65535, got %d", c.Port)
+	}
+	if c.Database == "" && c.Type != "redis" {
+		v.add(path+".database", "is required")
+	}
+}
+
+func (c *RAGConfig) validate(v *validator, path string) {
+	if c.ChunkSize <= 0 {
+		v.add(path+".chunk_size", "must be greater than 0")
+	}
+	if c.ChunkOverlap < 0 || (c.ChunkSize > 0 && c.ChunkOverlap >= c.ChunkSize) {
+		v.add(path+".chunk_overlap", "must be at least 0 and smaller than chunk_size")
+	}
+	if c.SimilarityThreshold < 0 || c.SimilarityThreshold > 1 {
+		v.add(path+".similarity_threshold", "must be between 0 and 1, got %g", c.SimilarityThreshold)
+	}
+	if c.MaxChunks <= 0 {
+		v.add(path+".max_chunks", "must be greater than 0")
+	}
+	if c.EmbeddingModel == "" {
+		v.add(path+".embedding_model", "is required")
+	}
+	if err := agent.NoContextBehavior(c.NoContextBehavior).Validate(); err != nil {
+		v.add(path+".no_context_behavior", "%v", err)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package core
+
+import (
+	"context"
+	"fmt"
+)
+
+// Reducer merges a contribution into the accumulated value of a state key. existing is nil
+// for the first contribution.
+type Reducer func(existing, contribution interface{}) interface{}
+
+// ContributionKey returns the state key an accumulator node reads the contribution to key
+// from. Nodes in a loop body set it; the accumulator merges and removes it.
+func ContributionKey(key string) string {
+	return key + "_contribution"
+}
+
+// AppendReducer collects every contribution in a []interface{}
+func AppendReducer(existing, contribution interface{}) interface{} {
+	items, _ := existing.([]interface{})
+	return append(items, contribution)
+}
+
+// SumReducer adds numeric contributions, as a float64
+func SumReducer(existing, contribution interface{}) interface{} {
+	total, _ := toFloat(existing)
+	value, _ := toFloat(contribution)
+	return total + value
+}
+
+// NewAccumulatorNode creates a node merging the value under ContributionKey(key) into key
+// with a reducer, so a loop can collect its results without custom node code. An iteration
+// without a contribution leaves key unchanged.
+func NewAccumulatorNode(id, key string, reducer Reducer) *Node {
+	contributionKey := ContributionKey(key)
+	accumulate := func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		contribution, exists := state.Get(contributionKey)
+		if !exists {
+			return state, nil
+		}
+		existing, _ := state.Get(key)
+		state.Set(key, reducer(existing, contribution))
+		state.Delete(contributionKey)
+		return state, nil
+	}
+
+	return &Node{
+		ID:       id,
+		Name:     id,
+		Function: accumulate,
+		Metadata: map[string]interface{}{
+			"type": "accumulator",
+			"key":  key,
+		},
+	}
+}
+
+// NewCounterNode creates a node incrementing the integer under key, starting from zero, such
+// as the number of iterations or retries of a loop
+func NewCounterNode(id, key string) *Node {
+	increment := func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		count := 0
+		if value, exists := state.Get(key); exists {
+			// Counters restored from a checkpoint come back as float64
+			number, ok := toFloat(value)
+			if !ok {
+				return nil, fmt.Errorf("counter node %s: state key %s holds %T, not a number: %w", id, key, value, ErrPermanent)
+			}
+			count = int(number)
+		}
+		state.Set(key, count+1)
+		return state, nil
+	}
+
+	return &Node{
+		ID:       id,
+		Name:     id,
+		Function: increment,
+		Metadata: map[string]interface{}{
+			"type": "counter",
+			"key":  key,
+		},
+	}
+}
+
+// toFloat converts a numeric value to float64
+func toFloat(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case int:
+		return float64(number), true
+	case int32:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	case float32:
+		return float64(number), true
+	case float64:
+		return number, true
+	}
+	return 0, false
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAccumulatorAndCounterNodes(t *testing.T) {
+	graph := NewGraph("loop")
+	graph.AddNode("work", "Work", func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		count, _ := state.Get("iterations")
+		n, _ := count.(int)
+		state.Set(ContributionKey("results"), n*10)
+		state.Set(ContributionKey("total"), n)
+		return state, nil
+	})
+	graph.AddPrebuiltNode(NewCounterNode("count", "iterations"))
+	graph.AddPrebuiltNode(NewAccumulatorNode("collect", "results", AppendReducer))
+	graph.AddPrebuiltNode(NewAccumulatorNode("sum", "total", SumReducer))
+	graph.AddNode("done", "Done", func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		return state, nil
+	})
+
+	graph.AddEdge("work", "count", nil)
+	graph.AddEdge("count", "collect", nil)
+	graph.AddEdge("collect", "sum", nil)
+	graph.AddEdge("sum", "work", func(ctx context.Context, state *BaseState) (string, error) {
+		if count, _ := state.Get("iterations"); count.(int) < 3 {
+			return "work", nil
+		}
+		return "", nil
+	})
+	graph.AddEdge("sum", "done", func(ctx context.Context, state *BaseState) (string, error) {
+		if count, _ := state.Get("iterations"); count.(int) >= 3 {
+			return "done", nil
+		}
+		return "", nil
+	})
+	graph.SetStartNode("work")
+	graph.AddEndNode("done")
+
+	result, err := graph.Execute(context.Background(), NewBaseState())
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	if count, _ := result.Get("iterations"); count != 3 {
+		t.Errorf("Expected 3 iterations, got %v", count)
+	}
+	results, _ := result.Get("results")
+	items, ok := results.([]interface{})
+	if !ok || len(items) != 3 || items[0] != 0 || items[2] != 20 {
+		t.Errorf("Expected the results of every iteration, got %v", results)
+	}
+	if total, _ := result.Get("total"); total != 3.0 {
+		t.Errorf("Expected a total of 3, got %v", total)
+	}
+	if _, exists := result.Get(ContributionKey("results")); exists {
+		t.Error("Expected the contribution to be consumed")
+	}
+}
+
+func TestCounterNode_RestoredAndInvalidValues(t *testing.T) {
+	node := NewCounterNode("count", "retries")
+
+	state := NewBaseState()
+	state.Set("retries", 2.0)
+	if _, err := node.Function(context.Background(), state); err != nil {
+		t.Fatalf("Counter failed: %v", err)
+	}
+	if count, _ := state.Get("retries"); count != 3 {
+		t.Errorf("Expected a restored counter to continue from 2, got %v", count)
+	}
+
+	state.Set("retries", "two")
+	if _, err := node.Function(context.Background(), state); err == nil {
+		t.Error("Expected a non-numeric counter to fail")
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Benchmarks of the execution engine. Run them with
+//
+//	go test ./pkg/core -run '^$' -bench . -benchmem
+//
+// and compare runs with benchstat to catch regressions in per-node overhead and allocations.
+
+// benchmarkGraphSizes are the node counts of the benchmarked graphs
+var benchmarkGraphSizes = []int{1, 10, 50}
+
+// benchmarkStateSizes are the key counts of the benchmarked states
+var benchmarkStateSizes = []int{10, 100, 1000}
+
+// newChainGraph builds a graph of n nodes executed one after the other. Logging is limited to
+// warnings so the benchmarks measure the engine rather than log output.
+func newChainGraph(n int, nodeFunc NodeFunc) *Graph {
+	graph := NewGraph("benchmark")
+	graph.logger.SetLevel(logrus.WarnLevel)
+	graph.Config.MaxIterations = n + 1
+
+	for i := 0; i < n; i++ {
+		graph.AddNode(fmt.Sprintf("node_%d", i), fmt.Sprintf("Node %d", i), nodeFunc)
+		if i > 0 {
+			graph.AddEdge(fmt.Sprintf("node_%d", i-1), fmt.Sprintf("node_%d", i), nil)
+		}
+	}
+	graph.SetStartNode("node_0")
+	graph.AddEndNode(fmt.Sprintf("node_%d", n-1))
+	return graph
+}
+
+// newBenchmarkState creates a state of n keys holding scalars, slices and nested maps
+func newBenchmarkState(n int) *BaseState {
+	state := NewBaseState()
+	for i := 0; i < n; i++ {
+		switch i % 3 {
+		case 0:
+			state.Set(fmt.Sprintf("key_%d", i), i)
+		case 1:
+			state.Set(fmt.Sprintf("key_%d", i), []interface{}{"a", "b", i})
+		default:
+			state.Set(fmt.Sprintf("key_%d", i), map[string]interface{}{"name": "value", "count": i})
+		}
+	}
+	return state
+}
+
+// naiveDeepCopy copies a state through a JSON round trip, the baseline Clone is compared to
+func naiveDeepCopy(state *BaseState) *BaseState {
+	data, err := state.ToJSON()
+	if err != nil {
+		panic(err)
+	}
+	clone := NewBaseState()
+	if err := clone.FromJSON(data); err != nil {
+		panic(err)
+	}
+	return clone
+}
+
+// BenchmarkGraph_EngineOverhead measures the cost of the engine alone: the nodes do nothing,
+// so the time and allocations per operation are those of scheduling n nodes
+func BenchmarkGraph_EngineOverhead(b *testing.B) {
+	noop := func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		return state, nil
+	}
+
+	for _, size := range benchmarkGraphSizes {
+		b.Run(fmt.Sprintf("nodes=%d", size), func(b *testing.B) {
+			graph := newChainGraph(size, noop)
+			ctx := context.Background()
+			state := NewBaseState()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := graph.Execute(ctx, state); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*size), "ns/node")
+		})
+	}
+}
+
+// BenchmarkCompiledGraph_EngineOverhead measures the same graphs as
+// BenchmarkGraph_EngineOverhead executed through Compile, whose routing tables replace the
+// per-step validation and edge scans
+func BenchmarkCompiledGraph_EngineOverhead(b *testing.B) {
+	noop := func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		return state, nil
+	}
+
+	for _, size := range benchmarkGraphSizes {
+		b.Run(fmt.Sprintf("nodes=%d", size), func(b *testing.B) {
+			compiled, err := newChainGraph(size, noop).Compile()
+			if err != nil {
+				b.Fatal(err)
+			}
+			ctx := context.Background()
+			state := NewBaseState()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := compiled.Execute(ctx, state); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*size), "ns/node")
+		})
+	}
+}
+
+// BenchmarkGraph_PerNodeExecution measures graphs whose nodes write to the state, with an
+// initial state of realistic size
+func BenchmarkGraph_PerNodeExecution(b *testing.B) {
+	write := func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		state.Set("processed", true)
+		return state, nil
+	}
+
+	for _, size := range benchmarkGraphSizes {
+		b.Run(fmt.Sprintf("nodes=%d", size), func(b *testing.B) {
+			graph := newChainGraph(size, write)
+			ctx := context.Background()
+			initial := newBenchmarkState(100)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := graph.Execute(ctx, initial); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*size), "ns/node")
+		})
+	}
+}
+
+// BenchmarkBaseState_CloneBySize measures Clone against a naive deep copy through JSON
+func BenchmarkBaseState_CloneBySize(b *testing.B) {
+	for _, size := range benchmarkStateSizes {
+		state := newBenchmarkState(size)
+
+		b.Run(fmt.Sprintf("keys=%d/Clone", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				state.Clone()
+			}
+		})
+		b.Run(fmt.Sprintf("keys=%d/NaiveDeepCopy", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				naiveDeepCopy(state)
+			}
+		})
+	}
+}
+
+// BenchmarkBaseState_Merge measures merging states of varying sizes
+func BenchmarkBaseState_Merge(b *testing.B) {
+	for _, size := range benchmarkStateSizes {
+		b.Run(fmt.Sprintf("keys=%d", size), func(b *testing.B) {
+			target := newBenchmarkState(size)
+			other := newBenchmarkState(size)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				target.Merge(other)
+			}
+		})
+	}
+}
+
+// TestNaiveDeepCopy_MatchesClone checks the benchmark baseline copies the same data as Clone
+func TestNaiveDeepCopy_MatchesClone(t *testing.T) {
+	state := newBenchmarkState(9)
+
+	cloned, err := json.Marshal(state.Clone().GetAll())
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied, err := json.Marshal(naiveDeepCopy(state).GetAll())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(cloned) != string(copied) {
+		t.Errorf("Expected the naive copy to match Clone, got %s and %s", copied, cloned)
+	}
+}
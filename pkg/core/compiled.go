@@ -0,0 +1,215 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// CompiledGraph is an immutable, validated form of a graph built by Compile for graphs
+// executed many times. Its routing tables index the outgoing edges of every node, so a step
+// costs no edge scan, and it keeps no execution state, so it may run any number of
+// executions concurrently.
+//
+// A compiled graph executes the nodes, edges and configuration the graph had when it was
+// compiled. It does not feed the graph's Stream channel, history or state violations, nor
+// answer Interrupt; cancel the context of an execution to stop it.
+type CompiledGraph struct {
+	// graph is the snapshot of the compiled graph the nodes run on
+	graph *Graph
+	nodes []compiledNode
+	start int
+}
+
+// compiledNode is a node with its routing table
+type compiledNode struct {
+	node  *Node
+	isEnd bool
+	// conditional are the node's conditional edges, sorted by target like OutgoingEdges
+	conditional []compiledEdge
+	// fallback is the index of the first unconditional successor, -1 when there is none
+	fallback int
+}
+
+// compiledEdge is a conditional edge resolved to the index of its target
+type compiledEdge struct {
+	condition EdgeCondition
+	to        string
+	target    int
+}
+
+// Compile validates the graph and builds its compiled form. Later changes to the graph do
+// not affect the compiled graph; signals delivered with the graph's Signal still reach the
+// wait nodes of its executions.
+func (g *Graph) Compile() (*CompiledGraph, error) {
+	if err := g.Validate(); err != nil {
+		return nil, fmt.Errorf("graph validation failed: %w", err)
+	}
+
+	signals := g.signalBoard()
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	config := *g.Config
+	snapshot := &Graph{
+		ID:           g.ID,
+		Name:         g.Name,
+		Nodes:        make(map[string]*Node, len(g.Nodes)),
+		Edges:        make(map[string]*Edge, len(g.Edges)),
+		StartNode:    g.StartNode,
+		EndNodes:     append([]string(nil), g.EndNodes...),
+		Config:       &config,
+		Metadata:     g.Metadata,
+		checkpointer: g.checkpointer,
+		graphContext: make(map[string]interface{}, len(g.graphContext)),
+		signals:      signals,
+		logger:       g.logger,
+		compiled:     true,
+	}
+	for key, value := range g.graphContext {
+		snapshot.graphContext[key] = value
+	}
+
+	// Nodes are indexed in ID order so compiling the same graph gives the same tables
+	ids := make([]string, 0, len(g.Nodes))
+	for id := range g.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	index := make(map[string]int, len(ids))
+	compiled := &CompiledGraph{graph: snapshot, nodes: make([]compiledNode, len(ids))}
+	for i, id := range ids {
+		node := *g.Nodes[id]
+		if node.Options != nil {
+			options := *node.Options
+			node.Options = &options
+		}
+		snapshot.Nodes[id] = &node
+		index[id] = i
+		compiled.nodes[i] = compiledNode{node: &node, fallback: -1}
+	}
+	compiled.start = index[g.StartNode]
+	for _, id := range g.EndNodes {
+		compiled.nodes[index[id]].isEnd = true
+	}
+
+	edges := make([]*Edge, 0, len(g.Edges))
+	for id, edge := range g.Edges {
+		copied := *edge
+		snapshot.Edges[id] = &copied
+		edges = append(edges, &copied)
+	}
+	sort.SliceStable(edges, func(i, j int) bool {
+		return edges[i].To < edges[j].To
+	})
+	for _, edge := range edges {
+		from := &compiled.nodes[index[edge.From]]
+		switch {
+		case edge.Condition != nil:
+			from.conditional = append(from.conditional, compiledEdge{condition: edge.Condition, to: edge.To, target: index[edge.To]})
+		case from.fallback < 0:
+			from.fallback = index[edge.To]
+		}
+	}
+
+	return compiled, nil
+}
+
+// Name returns the name of the compiled graph
+func (c *CompiledGraph) Name() string {
+	return c.graph.Name
+}
+
+// Execute executes the compiled graph with the given initial state. Executions are
+// independent and may run concurrently.
+func (c *CompiledGraph) Execute(ctx context.Context, initialState *BaseState) (*BaseState, error) {
+	ctx, err := c.graph.enterExecution(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.run(ctx, initialState, c.start)
+}
+
+// run executes the graph from a node until an end node or a node without successor
+func (c *CompiledGraph) run(ctx context.Context, initialState *BaseState, start int) (*BaseState, error) {
+	g := c.graph
+	execCtx, cancel := context.WithTimeout(ctx, g.Config.Timeout)
+	defer cancel()
+
+	observe, _ := execCtx.Value(resultObserverKey{}).(func(*ExecutionResult) error)
+	state := initialState.Clone()
+	current := start
+
+	for step := 0; ; step++ {
+		if execCtx.Err() != nil {
+			return nil, fmt.Errorf("execution timeout or cancelled")
+		}
+		if step >= g.Config.MaxIterations {
+			return nil, fmt.Errorf("maximum iterations (%d) exceeded", g.Config.MaxIterations)
+		}
+
+		compiled := &c.nodes[current]
+		nodeID := compiled.node.ID
+
+		// Let a debugger inspect or edit the state before the node runs
+		if err := g.callBeforeNodeHook(execCtx, nodeID, step, state); err != nil {
+			return state, err
+		}
+
+		// Snapshot the state the node starts from, so the execution can resume from it
+		g.saveNodeCheckpoint(execCtx, nodeID, step, state)
+
+		result, err := g.runNode(execCtx, compiled.node, state)
+		if err != nil {
+			return nil, fmt.Errorf("node execution failed: %w", err)
+		}
+		state = result.State
+
+		if observe != nil {
+			if err := observe(result); err != nil {
+				return nil, fmt.Errorf("execution stream closed: %w", err)
+			}
+		}
+
+		if compiled.isEnd {
+			break
+		}
+
+		next, err := c.next(execCtx, compiled, state)
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine next node: %w", err)
+		}
+		if next < 0 {
+			break
+		}
+		current = next
+	}
+
+	return state, nil
+}
+
+// next routes from a node like Graph.getNextNode: the first conditional edge whose condition
+// names its target, otherwise the first unconditional edge. It returns -1 when the node has
+// no outgoing edge.
+func (c *CompiledGraph) next(ctx context.Context, node *compiledNode, state *BaseState) (int, error) {
+	for _, edge := range node.conditional {
+		to, err := edge.condition(ctx, state)
+		if err != nil {
+			return -1, fmt.Errorf("edge condition evaluation failed: %w", err)
+		}
+		if to == edge.to {
+			return edge.target, nil
+		}
+	}
+	if node.fallback < 0 && len(node.conditional) > 0 {
+		return -1, fmt.Errorf("no valid next node found from %s", node.node.ID)
+	}
+	return node.fallback, nil
+}
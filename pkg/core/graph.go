@@ -8,7 +8,10 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -27,7 +30,21 @@ type Node struct {
 	ID       string                 `json:"id"`
 	Name     string                 `json:"name"`
 	Function NodeFunc               `json:"-"`
+	Group    string                 `json:"group,omitempty"` // Optional label used to cluster nodes in visualizations
+	Options  *NodeOptions           `json:"options,omitempty"`
 	Metadata map[string]interface{} `json:"metadata"`
+	// Subgraph is the graph executed by a node added with AddSubgraph
+	Subgraph *Graph `json:"-"`
+}
+
+// NodeOptions overrides the graph's retry settings for a single node and bounds each attempt.
+// Zero retry settings inherit those of the graph; a negative RetryAttempts disables retries.
+type NodeOptions struct {
+	Timeout       time.Duration `json:"timeout,omitempty"`
+	RetryAttempts int           `json:"retry_attempts,omitempty"`
+	RetryDelay    time.Duration `json:"retry_delay,omitempty"`
+	// Writes declares the state keys the node may change, checked when GraphConfig.StateCheck is set
+	Writes []string `json:"writes,omitempty"`
 }
 
 // Edge represents an edge in the graph
@@ -58,6 +75,10 @@ type GraphConfig struct {
 	ParallelExecution bool          `json:"parallel_execution"`
 	RetryAttempts     int           `json:"retry_attempts"`
 	RetryDelay        time.Duration `json:"retry_delay"`
+	// StateCheck reports nodes changing state keys they did not declare; for debugging
+	StateCheck StateCheckMode `json:"state_check,omitempty"`
+	// MaxSubgraphDepth bounds how deeply graphs may execute inside this graph
+	MaxSubgraphDepth int `json:"max_subgraph_depth,omitempty"`
 }
 
 // DefaultGraphConfig returns default configuration
@@ -70,6 +91,7 @@ func DefaultGraphConfig() *GraphConfig {
 		ParallelExecution: true,
 		RetryAttempts:     3,
 		RetryDelay:        1 * time.Second,
+		MaxSubgraphDepth:  DefaultMaxSubgraphDepth,
 	}
 }
 
@@ -87,6 +109,7 @@ type Graph struct {
 	// Execution state
 	currentState     *BaseState
 	executionHistory []*ExecutionResult
+	stateViolations  []StateViolation
 	isRunning        bool
 	mu               sync.RWMutex
 
@@ -94,6 +117,18 @@ type Graph struct {
 	streamChan    chan *ExecutionResult
 	interruptChan chan struct{}
 
+	// Checkpoints taken before each node
+	checkpointer NodeCheckpointer
+
+	// Constants available to every node, see SetContext
+	graphContext map[string]interface{}
+
+	// External events awaited by wait nodes, see Signal
+	signals *signalBoard
+
+	// Set on the snapshot a CompiledGraph executes
+	compiled bool
+
 	// Logger
 	logger *logrus.Logger
 }
@@ -131,6 +166,77 @@ func (g *Graph) AddNode(id, name string, fn NodeFunc) *Node {
 	return node
 }
 
+// AddPrebuiltNode adds a node created by a node factory such as NewHTTPNode
+func (g *Graph) AddPrebuiltNode(node *Node) *Node {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if node.Metadata == nil {
+		node.Metadata = make(map[string]interface{})
+	}
+	g.Nodes[node.ID] = node
+	return node
+}
+
+// SetNodeOptions sets the retry and timeout options of an existing node
+func (g *Graph) SetNodeOptions(nodeID string, options *NodeOptions) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	node, exists := g.Nodes[nodeID]
+	if !exists {
+		return fmt.Errorf("node %s does not exist", nodeID)
+	}
+
+	node.Options = options
+	return nil
+}
+
+// AddNodeToGroup adds a node to the graph under the given group label
+func (g *Graph) AddNodeToGroup(group, id, name string, fn NodeFunc) *Node {
+	node := g.AddNode(id, name, fn)
+
+	g.mu.Lock()
+	node.Group = group
+	g.mu.Unlock()
+
+	return node
+}
+
+// SetNodeGroup assigns an existing node to a group
+func (g *Graph) SetNodeGroup(nodeID, group string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	node, exists := g.Nodes[nodeID]
+	if !exists {
+		return fmt.Errorf("node %s does not exist", nodeID)
+	}
+
+	node.Group = group
+	return nil
+}
+
+// GetGroups returns node IDs keyed by group label, ungrouped nodes are omitted
+func (g *Graph) GetGroups() map[string][]string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	groups := make(map[string][]string)
+	for nodeID, node := range g.Nodes {
+		if node.Group == "" {
+			continue
+		}
+		groups[node.Group] = append(groups[node.Group], nodeID)
+	}
+
+	for group := range groups {
+		sort.Strings(groups[group])
+	}
+
+	return groups
+}
+
 // AddEdge adds an edge to the graph
 func (g *Graph) AddEdge(from, to string, condition EdgeCondition) *Edge {
 	g.mu.Lock()
@@ -174,8 +280,16 @@ func (g *Graph) AddEndNode(nodeID string) error {
 	return nil
 }
 
-// Validate validates the graph structure
+// Validate validates the graph structure, including that no subgraph includes itself
 func (g *Graph) Validate() error {
+	if err := g.validateStructure(); err != nil {
+		return err
+	}
+	return g.checkSubgraphRecursion()
+}
+
+// validateStructure checks the start node, end nodes and edges reference existing nodes
+func (g *Graph) validateStructure() error {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 
@@ -215,10 +329,21 @@ func (g *Graph) Execute(ctx context.Context, initialState *BaseState) (*BaseStat
 		return nil, fmt.Errorf("graph validation failed: %w", err)
 	}
 
+	ctx, err := g.enterExecution(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.run(ctx, initialState, g.StartNode)
+}
+
+// run executes the graph from a node until an end node or a node without successor
+func (g *Graph) run(ctx context.Context, initialState *BaseState, startNode string) (*BaseState, error) {
 	g.mu.Lock()
 	g.isRunning = true
 	g.currentState = initialState.Clone()
 	g.executionHistory = make([]*ExecutionResult, 0)
+	g.stateViolations = nil
 	g.mu.Unlock()
 
 	defer func() {
@@ -231,8 +356,7 @@ func (g *Graph) Execute(ctx context.Context, initialState *BaseState) (*BaseStat
 	execCtx, cancel := context.WithTimeout(ctx, g.Config.Timeout)
 	defer cancel()
 
-	// Start execution from the start node
-	currentNode := g.StartNode
+	currentNode := startNode
 	iterations := 0
 
 	for {
@@ -251,6 +375,18 @@ func (g *Graph) Execute(ctx context.Context, initialState *BaseState) (*BaseStat
 			return nil, fmt.Errorf("maximum iterations (%d) exceeded", g.Config.MaxIterations)
 		}
 
+		g.mu.RLock()
+		state := g.currentState
+		g.mu.RUnlock()
+
+		// Let a debugger inspect or edit the state before the node runs
+		if err := g.callBeforeNodeHook(execCtx, currentNode, iterations, state); err != nil {
+			return state, err
+		}
+
+		// Snapshot the state the node starts from, so the execution can resume from it
+		g.saveNodeCheckpoint(execCtx, currentNode, iterations, state)
+
 		// Execute the current node
 		result, err := g.executeNode(execCtx, currentNode)
 		if err != nil {
@@ -263,6 +399,13 @@ func (g *Graph) Execute(ctx context.Context, initialState *BaseState) (*BaseStat
 		g.executionHistory = append(g.executionHistory, result)
 		g.mu.Unlock()
 
+		// Deliver the result to a per-execution stream, waiting while its buffer is full
+		if observe, ok := execCtx.Value(resultObserverKey{}).(func(*ExecutionResult) error); ok {
+			if err := observe(result); err != nil {
+				return nil, fmt.Errorf("execution stream closed: %w", err)
+			}
+		}
+
 		// Stream result if enabled
 		if g.Config.EnableStreaming {
 			select {
@@ -299,13 +442,21 @@ func (g *Graph) Execute(ctx context.Context, initialState *BaseState) (*BaseStat
 func (g *Graph) executeNode(ctx context.Context, nodeID string) (*ExecutionResult, error) {
 	g.mu.RLock()
 	node, exists := g.Nodes[nodeID]
-	state := g.currentState.Clone()
+	before := g.currentState
 	g.mu.RUnlock()
 
 	if !exists {
 		return nil, fmt.Errorf("node %s does not exist", nodeID)
 	}
 
+	return g.runNode(ctx, node, before)
+}
+
+// runNode executes a node on a copy of the state it starts from, retrying failed attempts
+func (g *Graph) runNode(ctx context.Context, node *Node, before *BaseState) (*ExecutionResult, error) {
+	nodeID := node.ID
+	state := before.Clone()
+
 	g.logger.WithFields(logrus.Fields{
 		"node_id":   nodeID,
 		"node_name": node.Name,
@@ -318,13 +469,40 @@ func (g *Graph) executeNode(ctx context.Context, nodeID string) (*ExecutionResul
 	var resultState *BaseState
 	var err error
 
-	for attempt := 0; attempt <= g.Config.RetryAttempts; attempt++ {
-		resultState, err = node.Function(ctx, state)
-		if err == nil {
+	retryAttempts, retryDelay := g.Config.RetryAttempts, g.Config.RetryDelay
+	if node.Options != nil {
+		if node.Options.RetryAttempts != 0 {
+			retryAttempts = max(node.Options.RetryAttempts, 0)
+		}
+		if node.Options.RetryDelay > 0 {
+			retryDelay = node.Options.RetryDelay
+		}
+	}
+
+	for attempt := 0; attempt <= retryAttempts; attempt++ {
+		attemptCtx, effects := withSideEffects(ctx)
+		resultState, err = runNodeFunction(attemptCtx, node, state)
+		if err == nil || errors.Is(err, ErrMaxDepthExceeded) || errors.Is(err, ErrPermanent) {
+			break
+		}
+
+		// Retrying would repeat operations that must not run twice
+		if operations := effects.list(); len(operations) > 0 && attempt < retryAttempts {
+			g.logger.WithFields(logrus.Fields{
+				"node_id":    nodeID,
+				"attempt":    attempt + 1,
+				"operations": operations,
+				"error":      err,
+			}).Warn("Node execution failed after a non-idempotent operation, not retrying")
+			err = fmt.Errorf("%w (%w: %s)", err, ErrNotRetried, strings.Join(operations, ", "))
 			break
 		}
 
-		if attempt < g.Config.RetryAttempts {
+		if attempt < retryAttempts {
+			if !AllowRetry(ctx, RetryLayerNode) {
+				err = fmt.Errorf("%w (%w)", err, ErrRetryBudgetExhausted)
+				break
+			}
 			g.logger.WithFields(logrus.Fields{
 				"node_id": nodeID,
 				"attempt": attempt + 1,
@@ -334,12 +512,17 @@ func (g *Graph) executeNode(ctx context.Context, nodeID string) (*ExecutionResul
 			select {
 			case <-ctx.Done():
 				return nil, ctx.Err()
-			case <-time.After(g.Config.RetryDelay):
+			case <-time.After(retryDelay):
 				// Continue with retry
 			}
 		}
 	}
 
+	// Undeclared state writes are a bug in the node, so they are not retried
+	if err == nil {
+		err = g.checkStateWrites(node, before, resultState)
+	}
+
 	duration := time.Since(start)
 
 	result := &ExecutionResult{
@@ -367,6 +550,17 @@ func (g *Graph) executeNode(ctx context.Context, nodeID string) (*ExecutionResul
 	return result, err
 }
 
+// runNodeFunction runs a node once, bounded by the node's timeout
+func runNodeFunction(ctx context.Context, node *Node, state *BaseState) (*BaseState, error) {
+	if node.Options == nil || node.Options.Timeout <= 0 {
+		return node.Function(ctx, state)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, node.Options.Timeout)
+	defer cancel()
+	return node.Function(attemptCtx, state)
+}
+
 // getNextNode determines the next node to execute
 func (g *Graph) getNextNode(ctx context.Context, currentNodeID string) (string, error) {
 	g.mu.RLock()
@@ -430,6 +624,40 @@ func (g *Graph) Stream() <-chan *ExecutionResult {
 	return g.streamChan
 }
 
+// resultObserverKey is the context key of the observer used by ExecuteStream
+type resultObserverKey struct{}
+
+// ExecuteStream executes the graph and streams the result of every node, including its
+// intermediate state, on a channel with the given buffer size. Unlike Stream, a full buffer
+// pauses the execution instead of dropping results; cancelling ctx stops the execution.
+// The results channel is closed when the execution ends, after its error, if any, was sent
+// on the error channel.
+func (g *Graph) ExecuteStream(ctx context.Context, initialState *BaseState, bufferSize int) (<-chan *ExecutionResult, <-chan error) {
+	if bufferSize < 0 {
+		bufferSize = 0
+	}
+	results := make(chan *ExecutionResult, bufferSize)
+	errs := make(chan error, 1)
+
+	observe := func(result *ExecutionResult) error {
+		select {
+		case results <- result:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	go func() {
+		defer close(errs)
+		defer close(results)
+		if _, err := g.Execute(context.WithValue(ctx, resultObserverKey{}, observe), initialState); err != nil {
+			errs <- err
+		}
+	}()
+	return results, errs
+}
+
 // Interrupt interrupts the current execution
 func (g *Graph) Interrupt() {
 	select {
@@ -474,6 +702,7 @@ func (g *Graph) Reset() {
 
 	g.currentState = nil
 	g.executionHistory = make([]*ExecutionResult, 0)
+	g.stateViolations = nil
 	g.isRunning = false
 }
 
@@ -483,6 +712,7 @@ func (g *Graph) ExecuteParallel(ctx context.Context, nodeIDs []string, state *Ba
 		return make(map[string]*ExecutionResult), nil
 	}
 
+	ctx = g.bindGraphContext(ctx)
 	results := make(map[string]*ExecutionResult)
 	resultsMu := sync.Mutex{}
 	errChan := make(chan error, len(nodeIDs))
@@ -0,0 +1,98 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package core
+
+import (
+	"context"
+	"fmt"
+)
+
+// graphContextKey is the context key of an execution's graph context
+type graphContextKey struct{}
+
+// SetContext sets a constant of the graph context, available to the nodes of every later
+// execution through ContextValue. Unlike the state, the graph context does not flow between
+// nodes and nodes cannot change it: use it for configuration, clients or the user ID, and the
+// state for the data the nodes produce.
+func (g *Graph) SetContext(key string, value interface{}) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.graphContext == nil {
+		g.graphContext = make(map[string]interface{})
+	}
+	g.graphContext[key] = value
+}
+
+// GetContext returns a constant set with SetContext
+func (g *Graph) GetContext(key string) (interface{}, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	value, exists := g.graphContext[key]
+	return value, exists
+}
+
+// WithGraphContext returns a context whose graph executions see the values as constants of
+// their graph context, e.g. the ID of the user a request is served for. The values take
+// precedence over the constants set with SetContext.
+func WithGraphContext(ctx context.Context, values map[string]interface{}) context.Context {
+	merged := make(map[string]interface{}, len(values))
+	if parent, ok := ctx.Value(graphContextKey{}).(map[string]interface{}); ok {
+		for key, value := range parent {
+			merged[key] = value
+		}
+	}
+	for key, value := range values {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, graphContextKey{}, merged)
+}
+
+// ContextValue returns a constant of the graph context of the executing graph
+func ContextValue(ctx context.Context, key string) (interface{}, bool) {
+	values, _ := ctx.Value(graphContextKey{}).(map[string]interface{})
+	value, exists := values[key]
+	return value, exists
+}
+
+// ContextString returns a string constant of the graph context, or "" when it is missing or
+// not a string
+func ContextString(ctx context.Context, key string) string {
+	value, _ := ContextValue(ctx, key)
+	str, _ := value.(string)
+	return str
+}
+
+// RequireContextValue returns a constant of the graph context, or an error naming the missing key
+func RequireContextValue(ctx context.Context, key string) (interface{}, error) {
+	value, exists := ContextValue(ctx, key)
+	if !exists {
+		return nil, fmt.Errorf("graph context has no %s", key)
+	}
+	return value, nil
+}
+
+// bindGraphContext snapshots the graph's constants into the context of an execution, so
+// SetContext calls during the execution do not affect it. Values already in the context, set
+// with WithGraphContext or by a parent graph, take precedence.
+func (g *Graph) bindGraphContext(ctx context.Context) context.Context {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	parent, _ := ctx.Value(graphContextKey{}).(map[string]interface{})
+	if len(g.graphContext) == 0 {
+		return ctx
+	}
+
+	merged := make(map[string]interface{}, len(g.graphContext)+len(parent))
+	for key, value := range g.graphContext {
+		merged[key] = value
+	}
+	for key, value := range parent {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, graphContextKey{}, merged)
+}
@@ -8,8 +8,12 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestNewGraph(t *testing.T) {
@@ -343,6 +347,36 @@ func TestGraph_GetTopology(t *testing.T) {
 	}
 }
 
+func TestGraph_Groups(t *testing.T) {
+	graph := NewGraph("test_graph")
+
+	nodeFunc := func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		return state, nil
+	}
+
+	graph.AddNodeToGroup("retrieval", "search", "Search", nodeFunc)
+	graph.AddNodeToGroup("retrieval", "rerank", "Rerank", nodeFunc)
+	graph.AddNode("answer", "Answer", nodeFunc)
+
+	if err := graph.SetNodeGroup("answer", "generation"); err != nil {
+		t.Fatalf("SetNodeGroup() failed: %v", err)
+	}
+	if err := graph.SetNodeGroup("missing", "generation"); err == nil {
+		t.Error("SetNodeGroup() should fail for unknown node")
+	}
+
+	groups := graph.GetGroups()
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(groups))
+	}
+	if len(groups["retrieval"]) != 2 || groups["retrieval"][0] != "rerank" || groups["retrieval"][1] != "search" {
+		t.Errorf("Unexpected retrieval group: %v", groups["retrieval"])
+	}
+	if len(groups["generation"]) != 1 || groups["generation"][0] != "answer" {
+		t.Errorf("Unexpected generation group: %v", groups["generation"])
+	}
+}
+
 func TestGraph_StreamAndInterrupt(t *testing.T) {
 	graph := NewGraph("test_graph")
 
@@ -370,6 +404,63 @@ func TestGraph_StreamAndInterrupt(t *testing.T) {
 	}
 }
 
+// newCounterGraph creates a graph of the given number of nodes that each increment "count"
+func newCounterGraph(nodes int) *Graph {
+	graph := NewGraph("counter")
+	for i := 1; i <= nodes; i++ {
+		graph.AddNode(fmt.Sprintf("node%d", i), fmt.Sprintf("Node %d", i), func(ctx context.Context, state *BaseState) (*BaseState, error) {
+			count, _ := state.Get("count")
+			n, _ := count.(int)
+			state.Set("count", n+1)
+			return state, nil
+		})
+		if i > 1 {
+			graph.AddEdge(fmt.Sprintf("node%d", i-1), fmt.Sprintf("node%d", i), nil)
+		}
+	}
+	graph.SetStartNode("node1")
+	graph.AddEndNode(fmt.Sprintf("node%d", nodes))
+	return graph
+}
+
+func TestGraph_ExecuteStream(t *testing.T) {
+	graph := newCounterGraph(3)
+
+	// An unbuffered stream must still deliver every intermediate state
+	results, errs := graph.ExecuteStream(context.Background(), NewBaseState(), 0)
+	var counts []interface{}
+	var nodes []string
+	for result := range results {
+		count, _ := result.State.Get("count")
+		counts = append(counts, count)
+		nodes = append(nodes, result.NodeID)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("ExecuteStream() failed: %v", err)
+	}
+	if fmt.Sprint(counts) != "[1 2 3]" || fmt.Sprint(nodes) != "[node1 node2 node3]" {
+		t.Errorf("Expected every node's state in order, got %v from %v", counts, nodes)
+	}
+}
+
+func TestGraph_ExecuteStreamCancel(t *testing.T) {
+	graph := newCounterGraph(5)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	results, errs := graph.ExecuteStream(ctx, NewBaseState(), 0)
+	<-results
+	cancel()
+	for range results {
+	}
+
+	if err := <-errs; err == nil {
+		t.Error("Expected cancelling the stream to stop the execution")
+	}
+	if history := graph.GetExecutionHistory(); len(history) == 5 {
+		t.Error("Expected the execution to stop before the last node")
+	}
+}
+
 func TestGraph_Reset(t *testing.T) {
 	graph := NewGraph("test_graph")
 
@@ -395,6 +486,373 @@ func TestGraph_Reset(t *testing.T) {
 	}
 }
 
+func TestGraph_DeltaNode(t *testing.T) {
+	graph := NewGraph("delta")
+	graph.AddDeltaNode("count", "Count", func(ctx context.Context, state *BaseState) (StateDelta, error) {
+		count, _ := state.Get("count")
+		return StateDelta{"count": count.(int) + 1, "scratch": nil}, nil
+	})
+	graph.AddDeltaNode("stomp", "Stomp", func(ctx context.Context, state *BaseState) (StateDelta, error) {
+		state.Set("count", 0)
+		return nil, nil
+	})
+	graph.AddEdge("count", "stomp", nil)
+	graph.SetStartNode("count")
+	graph.AddEndNode("count")
+	graph.Config.RetryAttempts = 0
+
+	initial := NewBaseState()
+	initial.Set("count", 1)
+	initial.Set("scratch", "temporary")
+	result, err := graph.Execute(context.Background(), initial)
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if count, _ := result.Get("count"); count != 2 {
+		t.Errorf("Expected the delta to be applied, got %v", count)
+	}
+	if _, exists := result.Get("scratch"); exists {
+		t.Error("Expected a nil delta value to delete the key")
+	}
+
+	// Writing to the read-only view fails the node instead of corrupting the state
+	graph.EndNodes = []string{"stomp"}
+	if _, err := graph.Execute(context.Background(), initial); err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Errorf("Expected a read-only error, got %v", err)
+	}
+}
+
+func TestGraph_StateCheck(t *testing.T) {
+	graph := NewGraph("checked")
+	graph.AddNode("summarize", "Summarize", func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		state.Set("summary", "short")
+		state.Set("input", "overwritten")
+		return state, nil
+	})
+	graph.SetNodeOptions("summarize", &NodeOptions{Writes: []string{"summary"}})
+	graph.SetStartNode("summarize")
+	graph.AddEndNode("summarize")
+
+	initial := NewBaseState()
+	initial.Set("input", "original")
+
+	// Without checks nothing is recorded
+	if _, err := graph.Execute(context.Background(), initial); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if len(graph.GetStateViolations()) != 0 {
+		t.Error("Expected no violations with checks disabled")
+	}
+
+	graph.Config.StateCheck = StateCheckWarn
+	if _, err := graph.Execute(context.Background(), initial); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	violations := graph.GetStateViolations()
+	if len(violations) != 1 || violations[0].NodeID != "summarize" || fmt.Sprint(violations[0].Keys) != "[input]" {
+		t.Errorf("Expected the undeclared input write to be recorded, got %+v", violations)
+	}
+
+	graph.Config.StateCheck = StateCheckStrict
+	if _, err := graph.Execute(context.Background(), initial); err == nil || !strings.Contains(err.Error(), "undeclared state keys [input]") {
+		t.Errorf("Expected strict checks to fail the node, got %v", err)
+	}
+}
+
+func TestGraph_NoRetryAfterNonIdempotentOperation(t *testing.T) {
+	graph := NewGraph("retries")
+	graph.Config.RetryDelay = time.Millisecond
+
+	var reads, charges int
+	graph.AddNode("read", "Read", func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		reads++
+		if reads < 3 {
+			return nil, errors.New("temporary failure")
+		}
+		return state, nil
+	})
+	graph.AddNode("charge", "Charge", func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		charges++
+		MarkNonIdempotent(ctx, "payment")
+		return nil, errors.New("timeout after charging")
+	})
+	graph.AddEdge("read", "charge", nil)
+	graph.SetStartNode("read")
+	graph.AddEndNode("charge")
+
+	_, err := graph.Execute(context.Background(), NewBaseState())
+	if reads != 3 {
+		t.Errorf("Expected the idempotent node to be retried until it succeeds, ran %d times", reads)
+	}
+	if charges != 1 {
+		t.Errorf("Expected the non-idempotent node to run once, ran %d times", charges)
+	}
+	if !errors.Is(err, ErrNotRetried) || !strings.Contains(err.Error(), "timeout after charging") {
+		t.Errorf("Expected the original error marked as not retried, got %v", err)
+	}
+}
+
+func TestGraph_NoRetryOnPermanentError(t *testing.T) {
+	graph := NewGraph("permanent")
+	graph.Config.RetryDelay = time.Millisecond
+
+	var attempts int
+	graph.AddNode("filtered", "Filtered", func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		attempts++
+		return nil, fmt.Errorf("answer blocked: %w", ErrPermanent)
+	})
+	graph.SetStartNode("filtered")
+	graph.AddEndNode("filtered")
+
+	if _, err := graph.Execute(context.Background(), NewBaseState()); !errors.Is(err, ErrPermanent) {
+		t.Errorf("Expected the permanent error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected a permanent error not to be retried, ran %d times", attempts)
+	}
+}
+
+func TestGraph_NodeOptionsInheritRetries(t *testing.T) {
+	graph := NewGraph("inherit")
+	graph.Config.RetryAttempts = 2
+	graph.Config.RetryDelay = time.Millisecond
+
+	var attempts int
+	graph.AddNode("flaky", "Flaky", func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		attempts++
+		return nil, errors.New("temporarily unavailable")
+	})
+	graph.SetStartNode("flaky")
+	graph.AddEndNode("flaky")
+
+	// Options that only set a timeout keep the graph's retries
+	graph.SetNodeOptions("flaky", &NodeOptions{Timeout: time.Second})
+	graph.Execute(context.Background(), NewBaseState())
+	if attempts != 3 {
+		t.Errorf("Expected the graph's retries, ran %d times", attempts)
+	}
+
+	attempts = 0
+	graph.SetNodeOptions("flaky", &NodeOptions{RetryAttempts: -1})
+	graph.Execute(context.Background(), NewBaseState())
+	if attempts != 1 {
+		t.Errorf("Expected retries to be disabled, ran %d times", attempts)
+	}
+}
+
+func TestGraph_RetryBudget(t *testing.T) {
+	graph := NewGraph("budget")
+	graph.Config.RetryDelay = time.Millisecond
+
+	var attempts int
+	graph.AddNode("flaky", "Flaky", func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		attempts++
+		return nil, errors.New("upstream unavailable")
+	})
+	graph.SetStartNode("flaky")
+	graph.AddEndNode("flaky")
+
+	// The node stops retrying once the budget shared by the execution is spent
+	budget := NewRetryBudget(2)
+	AllowRetry(WithRetryBudget(context.Background(), budget), RetryLayerProvider)
+	_, err := graph.Execute(WithRetryBudget(context.Background(), budget), NewBaseState())
+	if !errors.Is(err, ErrRetryBudgetExhausted) {
+		t.Errorf("Expected ErrRetryBudgetExhausted, got %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected a single retry left in the budget, ran %d times", attempts)
+	}
+	if used := budget.UsedByLayer(); budget.Used() != 2 || used[RetryLayerNode] != 1 || used[RetryLayerProvider] != 1 {
+		t.Errorf("Expected one provider and one node retry, got %v", used)
+	}
+
+	// Without a budget the graph's retry settings apply
+	attempts = 0
+	graph.Execute(context.Background(), NewBaseState())
+	if attempts != graph.Config.RetryAttempts+1 {
+		t.Errorf("Expected %d attempts without a budget, ran %d", graph.Config.RetryAttempts+1, attempts)
+	}
+}
+
+func TestGraph_BeforeNodeHook(t *testing.T) {
+	graph := NewGraph("hooked")
+	graph.AddNode("first", "First", func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		return state, nil
+	})
+	graph.AddNode("second", "Second", func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		value, _ := state.Get("injected")
+		state.Set("seen", value)
+		return state, nil
+	})
+	graph.AddEdge("first", "second", nil)
+	graph.SetStartNode("first")
+	graph.AddEndNode("second")
+
+	var steps []NodeStep
+	ctx := WithBeforeNodeHook(context.Background(), func(ctx context.Context, step NodeStep) error {
+		steps = append(steps, step)
+		if step.NodeID == "second" {
+			step.State.Set("injected", "by hook")
+		}
+		return nil
+	})
+	result, err := graph.Execute(ctx, NewBaseState())
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if len(steps) != 2 || steps[0].NodeID != "first" || steps[1].Step != 1 {
+		t.Fatalf("Expected the hook before both nodes, got %v", steps)
+	}
+	if len(steps[0].Edges) != 1 || steps[0].Edges[0].To != "second" || steps[0].IsEnd {
+		t.Errorf("Expected the first step to list its edge to second, got %v", steps[0].Edges)
+	}
+	if !steps[1].IsEnd {
+		t.Error("Expected the second step to be marked as the end")
+	}
+	if seen, _ := result.Get("seen"); seen != "by hook" {
+		t.Errorf("Expected the node to see the hook's edit, got %v", seen)
+	}
+
+	// A hook error stops the execution before the node
+	stop := errors.New("stop")
+	ctx = WithBeforeNodeHook(context.Background(), func(ctx context.Context, step NodeStep) error {
+		if step.NodeID == "second" {
+			return stop
+		}
+		return nil
+	})
+	result, err = graph.Execute(ctx, NewBaseState())
+	if !errors.Is(err, stop) {
+		t.Fatalf("Expected the hook error, got %v", err)
+	}
+	if _, exists := result.Get("seen"); exists {
+		t.Error("Expected the second node not to run")
+	}
+}
+
+func TestGraph_Subgraph(t *testing.T) {
+	inner := NewGraph("inner")
+	inner.AddNode("double", "Double", func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		value, _ := state.Get("value")
+		state.Set("value", value.(int)*2)
+		return state, nil
+	})
+	inner.SetStartNode("double")
+	inner.AddEndNode("double")
+
+	outer := NewGraph("outer")
+	node := outer.AddSubgraph("inner", "Inner", inner)
+	outer.SetStartNode("inner")
+	outer.AddEndNode("inner")
+	if node.Subgraph != inner || node.Metadata["subgraph"] != "inner" {
+		t.Errorf("Expected the node to reference its subgraph, got %+v", node.Metadata)
+	}
+
+	initial := NewBaseState()
+	initial.Set("value", 21)
+	result, err := outer.Execute(context.Background(), initial)
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if value, _ := result.Get("value"); value != 42 {
+		t.Errorf("Expected the subgraph result 42, got %v", value)
+	}
+
+	// A subgraph including its parent is rejected before execution
+	inner.AddSubgraph("outer", "Outer", outer)
+	err = outer.Validate()
+	if err == nil || !strings.Contains(err.Error(), "recursive subgraph reference: outer -> inner -> outer") {
+		t.Errorf("Expected the recursive reference to be reported, got %v", err)
+	}
+}
+
+func TestGraph_MaxSubgraphDepth(t *testing.T) {
+	// Each level builds a fresh graph, so the recursion is only visible at run time
+	executions := 0
+	var newLevel func() *Graph
+	newLevel = func() *Graph {
+		graph := NewGraph(fmt.Sprintf("level%d", executions))
+		graph.Config.MaxSubgraphDepth = 3
+		graph.AddNode("nest", "Nest", func(ctx context.Context, state *BaseState) (*BaseState, error) {
+			executions++
+			return newLevel().Execute(ctx, state)
+		})
+		graph.SetStartNode("nest")
+		graph.AddEndNode("nest")
+		return graph
+	}
+
+	_, err := newLevel().Execute(context.Background(), NewBaseState())
+	if !errors.Is(err, ErrMaxDepthExceeded) {
+		t.Fatalf("Expected ErrMaxDepthExceeded, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "level0 -> level1 -> level2 -> level3 -> level4") {
+		t.Errorf("Expected the nesting path in the error, got %v", err)
+	}
+	if executions != 4 {
+		t.Errorf("Expected depth errors not to be retried, got %d node executions", executions)
+	}
+}
+
+func TestGraph_Context(t *testing.T) {
+	sub := NewGraph("sub")
+	sub.SetContext("region", "us-east-1")
+	sub.SetContext("tier", "sub")
+	sub.AddNode("read", "Read", func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		state.Set("sub_region", ContextString(ctx, "region"))
+		state.Set("sub_tier", ContextString(ctx, "tier"))
+		return state, nil
+	})
+	sub.SetStartNode("read")
+	sub.AddEndNode("read")
+
+	graph := NewGraph("main")
+	graph.SetContext("region", "eu-west-1")
+	graph.AddNode("first", "First", func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		// Changes during the execution do not affect it
+		graph.SetContext("region", "changed")
+		if _, err := RequireContextValue(ctx, "missing"); err == nil {
+			return nil, fmt.Errorf("expected missing constant to fail")
+		}
+		state.Set("user_id", ContextString(ctx, "user_id"))
+		return state, nil
+	})
+	graph.AddSubgraph("sub", "Sub", sub)
+	graph.AddNode("last", "Last", func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		state.Set("region", ContextString(ctx, "region"))
+		return state, nil
+	})
+	graph.AddEdge("first", "sub", nil)
+	graph.AddEdge("sub", "last", nil)
+	graph.SetStartNode("first")
+	graph.AddEndNode("last")
+
+	ctx := WithGraphContext(context.Background(), map[string]interface{}{"user_id": "user-42"})
+	result, err := graph.Execute(ctx, NewBaseState())
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"user_id":    "user-42",
+		"region":     "eu-west-1",
+		"sub_region": "eu-west-1",
+		"sub_tier":   "sub",
+	}
+	for key, want := range expected {
+		if got, _ := result.Get(key); got != want {
+			t.Errorf("expected %s %q, got %v", key, want, got)
+		}
+	}
+
+	if value, _ := graph.GetContext("region"); value != "changed" {
+		t.Errorf("expected SetContext to apply to later executions, got %v", value)
+	}
+	if _, exists := ContextValue(context.Background(), "region"); exists {
+		t.Error("expected no graph context outside executions")
+	}
+}
+
 // Benchmark tests
 func BenchmarkGraph_AddNode(b *testing.B) {
 	graph := NewGraph("test_graph")
@@ -429,3 +887,110 @@ func BenchmarkGraph_Execute(b *testing.B) {
 		graph.Execute(ctx, state)
 	}
 }
+
+func TestGraph_Compile(t *testing.T) {
+	graph := NewGraph("compiled")
+	increment := func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		count, _ := state.Get("count")
+		n, _ := count.(int)
+		state.Set("count", n+1)
+		return state, nil
+	}
+	graph.AddNode("loop", "Loop", increment)
+	graph.AddNode("done", "Done", func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		state.Set("done", true)
+		return state, nil
+	})
+	route := func(ctx context.Context, state *BaseState) (string, error) {
+		if count, _ := state.Get("count"); count.(int) < 3 {
+			return "loop", nil
+		}
+		return "done", nil
+	}
+	graph.AddEdge("loop", "loop", route)
+	graph.AddEdge("loop", "done", route)
+	graph.SetStartNode("loop")
+	graph.AddEndNode("done")
+
+	compiled, err := graph.Compile()
+	if err != nil {
+		t.Fatalf("Compile() failed: %v", err)
+	}
+
+	// Later changes to the graph do not affect the compiled form
+	graph.AddNode("extra", "Extra", increment)
+	graph.AddEdge("done", "extra", nil)
+
+	// Concurrent executions are independent
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := compiled.Execute(context.Background(), NewBaseState())
+			if err != nil {
+				errs <- err
+				return
+			}
+			count, _ := result.Get("count")
+			done, _ := result.Get("done")
+			if count != 3 || done != true {
+				errs <- fmt.Errorf("expected 3 loops then done, got count %v done %v", count, done)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// Compiling validates the graph
+	invalid := NewGraph("invalid")
+	invalid.AddNode("a", "A", increment)
+	invalid.AddEdge("a", "missing", nil)
+	invalid.SetStartNode("a")
+	if _, err := invalid.Compile(); err == nil {
+		t.Error("Expected Compile() to reject an edge to a missing node")
+	}
+}
+
+func TestCompiledGraph_MatchesGraph(t *testing.T) {
+	graph := NewGraph("routing")
+	mark := func(id string) NodeFunc {
+		return func(ctx context.Context, state *BaseState) (*BaseState, error) {
+			path, _ := state.Get("path")
+			p, _ := path.(string)
+			state.Set("path", p+id)
+			return state, nil
+		}
+	}
+	for _, id := range []string{"a", "b", "c", "d"} {
+		graph.AddNode(id, strings.ToUpper(id), mark(id))
+	}
+	graph.AddEdge("a", "b", func(ctx context.Context, state *BaseState) (string, error) { return "c", nil })
+	graph.AddEdge("a", "c", nil)
+	graph.AddEdge("c", "d", nil)
+	graph.SetStartNode("a")
+
+	expected, err := graph.Execute(context.Background(), NewBaseState())
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	compiled, err := graph.Compile()
+	if err != nil {
+		t.Fatalf("Compile() failed: %v", err)
+	}
+	result, err := compiled.Execute(context.Background(), NewBaseState())
+	if err != nil {
+		t.Fatalf("Execute() of the compiled graph failed: %v", err)
+	}
+
+	// Unmatched conditions fall back to the unconditional edge; d has no successor
+	want, _ := expected.Get("path")
+	got, _ := result.Get("path")
+	if got != "acd" || got != want {
+		t.Errorf("Expected path acd like the graph's %v, got %v", want, got)
+	}
+}
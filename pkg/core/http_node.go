@@ -0,0 +1,201 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+// HTTPNodeConfig configures a node that calls an HTTP endpoint.
+//
+// URL, BodyTemplate and the values of HeaderTemplate are text/template templates
+// rendered with the current state values; the json function encodes a value as JSON,
+// e.g. {"query": {{json .question}}}.
+type HTTPNodeConfig struct {
+	URL            string            `json:"url"`
+	Method         string            `json:"method"`
+	BodyTemplate   string            `json:"body_template,omitempty"`
+	HeaderTemplate map[string]string `json:"header_template,omitempty"`
+
+	// ResponseKey is the state key the response is stored under. JSON responses are
+	// decoded, other responses are stored as a string.
+	ResponseKey string `json:"response_key"`
+
+	// Options sets the timeout and retries of each call; nil uses the graph's retry settings
+	Options *NodeOptions `json:"options,omitempty"`
+
+	// MaxResponseBytes caps the size of the response; defaults to DefaultHTTPNodeMaxResponseBytes
+	MaxResponseBytes int64 `json:"max_response_bytes,omitempty"`
+
+	// Client is the HTTP client used for calls; defaults to http.DefaultClient
+	Client *http.Client `json:"-"`
+}
+
+// HTTPStatusError is returned when the endpoint responds with a non-2xx status
+type HTTPStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+// Error implements the error interface
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("HTTP request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// maxHTTPErrorBody limits how much of an error response is kept in HTTPStatusError
+const maxHTTPErrorBody = 512
+
+// DefaultHTTPNodeMaxResponseBytes is the default cap on the response size of an HTTP node
+const DefaultHTTPNodeMaxResponseBytes = 10 << 20
+
+// NewHTTPNode creates a node that renders a request from the state, calls the endpoint
+// and stores the response under config.ResponseKey. Add it with Graph.AddPrebuiltNode.
+func NewHTTPNode(id string, config HTTPNodeConfig) (*Node, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("HTTP node %s: URL is required", id)
+	}
+	if config.ResponseKey == "" {
+		return nil, fmt.Errorf("HTTP node %s: response key is required", id)
+	}
+	if config.Method == "" {
+		config.Method = http.MethodGet
+		if config.BodyTemplate != "" {
+			config.Method = http.MethodPost
+		}
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	if config.MaxResponseBytes <= 0 {
+		config.MaxResponseBytes = DefaultHTTPNodeMaxResponseBytes
+	}
+
+	urlTemplate, err := parseHTTPTemplate(id, "url", config.URL)
+	if err != nil {
+		return nil, err
+	}
+	var bodyTemplate *template.Template
+	if config.BodyTemplate != "" {
+		if bodyTemplate, err = parseHTTPTemplate(id, "body", config.BodyTemplate); err != nil {
+			return nil, err
+		}
+	}
+	headerTemplates := make(map[string]*template.Template, len(config.HeaderTemplate))
+	for name, value := range config.HeaderTemplate {
+		if headerTemplates[name], err = parseHTTPTemplate(id, "header "+name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	call := func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		data := state.GetAll()
+
+		url, err := renderHTTPTemplate(urlTemplate, data)
+		if err != nil {
+			return nil, err
+		}
+		var body io.Reader
+		if bodyTemplate != nil {
+			rendered, err := renderHTTPTemplate(bodyTemplate, data)
+			if err != nil {
+				return nil, err
+			}
+			body = strings.NewReader(rendered)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, config.Method, url, body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+		}
+		if bodyTemplate != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for name, headerTemplate := range headerTemplates {
+			value, err := renderHTTPTemplate(headerTemplate, data)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set(name, value)
+		}
+
+		resp, err := config.Client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("HTTP request failed: %w", err)
+		}
+		defer resp.Body.Close()
+
+		// Read one byte past the cap to tell a response of exactly the cap from a larger one
+		respBody, err := io.ReadAll(io.LimitReader(resp.Body, config.MaxResponseBytes+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read HTTP response: %w", err)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			if len(respBody) > maxHTTPErrorBody {
+				respBody = respBody[:maxHTTPErrorBody]
+			}
+			return nil, &HTTPStatusError{StatusCode: resp.StatusCode, Body: string(respBody)}
+		}
+		if int64(len(respBody)) > config.MaxResponseBytes {
+			return nil, fmt.Errorf("HTTP response exceeds %d bytes: %w", config.MaxResponseBytes, ErrPermanent)
+		}
+
+		var value interface{} = string(respBody)
+		if strings.Contains(resp.Header.Get("Content-Type"), "json") {
+			var decoded interface{}
+			if err := json.Unmarshal(respBody, &decoded); err != nil {
+				return nil, fmt.Errorf("failed to decode JSON response: %w", err)
+			}
+			value = decoded
+		}
+
+		state.Set(config.ResponseKey, value)
+		state.SetMetadata(config.ResponseKey+"_status", resp.StatusCode)
+		return state, nil
+	}
+
+	return &Node{
+		ID:       id,
+		Name:     id,
+		Function: call,
+		Options:  config.Options,
+		Metadata: map[string]interface{}{
+			"type":   "http",
+			"method": config.Method,
+			"url":    config.URL,
+		},
+	}, nil
+}
+
+// parseHTTPTemplate parses a request template of an HTTP node
+func parseHTTPTemplate(nodeID, name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(template.FuncMap{
+		"json": func(value interface{}) (string, error) {
+			data, err := json.Marshal(value)
+			return string(data), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP node %s: invalid %s template: %w", nodeID, name, err)
+	}
+	return tmpl, nil
+}
+
+// renderHTTPTemplate renders a request template with the state values
+func renderHTTPTemplate(tmpl *template.Template, data map[string]StateValue) (string, error) {
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", tmpl.Name(), err)
+	}
+	return rendered.String(), nil
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPNode(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			http.Error(w, "temporarily unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path != "/users/42" || r.Header.Get("X-Tenant") != "acme" {
+			t.Errorf("Unexpected request %s with tenant %q", r.URL.Path, r.Header.Get("X-Tenant"))
+		}
+
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["query"] != `say "hi"` {
+			t.Errorf("Unexpected request body %v: %v", body, err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name": "Ada"}`))
+	}))
+	defer server.Close()
+
+	node, err := NewHTTPNode("lookup", HTTPNodeConfig{
+		URL:            server.URL + "/users/{{.user_id}}",
+		BodyTemplate:   `{"query": {{json .query}}}`,
+		HeaderTemplate: map[string]string{"X-Tenant": "{{.tenant}}"},
+		ResponseKey:    "user",
+		Options:        &NodeOptions{Timeout: time.Second, RetryAttempts: 1, RetryDelay: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	graph := NewGraph("http")
+	graph.AddPrebuiltNode(node)
+	graph.SetStartNode("lookup")
+	graph.AddEndNode("lookup")
+
+	state := NewBaseState()
+	state.Set("user_id", 42)
+	state.Set("query", `say "hi"`)
+	state.Set("tenant", "acme")
+
+	result, err := graph.Execute(context.Background(), state)
+	if err != nil {
+		t.Fatalf("Graph execution failed: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected the failed call to be retried once, got %d calls", calls)
+	}
+	user, _ := result.Get("user")
+	if user.(map[string]interface{})["name"] != "Ada" {
+		t.Errorf("Expected the decoded response in state, got %v", user)
+	}
+	if node.Metadata["method"] != http.MethodPost {
+		t.Errorf("Expected POST for a request with a body, got %v", node.Metadata["method"])
+	}
+}
+
+func TestHTTPNode_TimeoutAndErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		if r.URL.Path == "/large" {
+			w.Write([]byte(strings.Repeat("x", 100)))
+			return
+		}
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	node, err := NewHTTPNode("slow", HTTPNodeConfig{
+		URL:         server.URL + "/slow",
+		ResponseKey: "result",
+		Options:     &NodeOptions{Timeout: 20 * time.Millisecond, RetryAttempts: -1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph := NewGraph("timeout")
+	graph.AddPrebuiltNode(node)
+	graph.SetStartNode("slow")
+	graph.AddEndNode("slow")
+
+	if _, err := graph.Execute(context.Background(), NewBaseState()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+
+	node, _ = NewHTTPNode("missing", HTTPNodeConfig{URL: server.URL + "/missing", ResponseKey: "result"})
+	_, err = node.Function(context.Background(), NewBaseState())
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected an HTTP status error, got %v", err)
+	}
+
+	node, _ = NewHTTPNode("large", HTTPNodeConfig{URL: server.URL + "/large", ResponseKey: "result", MaxResponseBytes: 10})
+	if _, err = node.Function(context.Background(), NewBaseState()); !errors.Is(err, ErrPermanent) {
+		t.Errorf("Expected responses over the cap to fail, got %v", err)
+	}
+
+	if _, err := NewHTTPNode("broken", HTTPNodeConfig{URL: "{{.unclosed", ResponseKey: "result"}); err == nil {
+		t.Error("Expected an error for an invalid URL template")
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NodeCheckpointer stores the state a graph execution holds before each node, so that the
+// execution can later be resumed from that node
+type NodeCheckpointer interface {
+	// SaveNodeCheckpoint saves the state a thread's execution starts the node with
+	SaveNodeCheckpoint(ctx context.Context, threadID, nodeID string, step int, state *BaseState) error
+
+	// LoadNodeCheckpoint loads the state of the latest execution of the thread that reached the node
+	LoadNodeCheckpoint(ctx context.Context, threadID, nodeID string) (*BaseState, error)
+}
+
+// threadIDKey is the context key of the thread an execution checkpoints under
+type threadIDKey struct{}
+
+// WithThreadID returns a context whose graph executions checkpoint under the thread ID
+func WithThreadID(ctx context.Context, threadID string) context.Context {
+	return context.WithValue(ctx, threadIDKey{}, threadID)
+}
+
+// ThreadIDFromContext returns the thread ID set with WithThreadID
+func ThreadIDFromContext(ctx context.Context) (string, bool) {
+	threadID, ok := ctx.Value(threadIDKey{}).(string)
+	return threadID, ok && threadID != ""
+}
+
+// SetCheckpointer makes the graph snapshot the state before each node of the executions
+// whose context carries a thread ID. Snapshots are taken while Config.EnableCheckpoints is set.
+func (g *Graph) SetCheckpointer(checkpointer NodeCheckpointer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.checkpointer = checkpointer
+}
+
+// saveNodeCheckpoint snapshots the state a node starts from. A failed snapshot is logged
+// rather than failing the execution.
+func (g *Graph) saveNodeCheckpoint(ctx context.Context, nodeID string, step int, state *BaseState) {
+	threadID, ok := ThreadIDFromContext(ctx)
+
+	g.mu.RLock()
+	checkpointer := g.checkpointer
+	g.mu.RUnlock()
+
+	if checkpointer == nil || !ok || !g.Config.EnableCheckpoints {
+		return
+	}
+
+	if err := checkpointer.SaveNodeCheckpoint(ctx, threadID, nodeID, step, state.Clone()); err != nil {
+		g.logger.WithFields(logrus.Fields{
+			"node_id":   nodeID,
+			"thread_id": threadID,
+			"error":     err,
+		}).Warn("Failed to save node checkpoint")
+	}
+}
+
+// ResumeFromNode replays a thread's execution from the checkpoint taken just before a node,
+// typically the node it failed at. The overrides are set on the checkpointed state first,
+// so a bad input can be fixed without rerunning the nodes before it. The resumed execution
+// checkpoints under the same thread.
+func (g *Graph) ResumeFromNode(ctx context.Context, threadID, nodeID string, stateOverrides map[string]interface{}) (*BaseState, error) {
+	if err := g.Validate(); err != nil {
+		return nil, fmt.Errorf("graph validation failed: %w", err)
+	}
+
+	g.mu.RLock()
+	checkpointer := g.checkpointer
+	_, exists := g.Nodes[nodeID]
+	g.mu.RUnlock()
+
+	if checkpointer == nil {
+		return nil, fmt.Errorf("graph %s has no checkpointer", g.Name)
+	}
+	if !exists {
+		return nil, fmt.Errorf("node %s does not exist", nodeID)
+	}
+
+	state, err := checkpointer.LoadNodeCheckpoint(ctx, threadID, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint before node %s: %w", nodeID, err)
+	}
+	for key, value := range stateOverrides {
+		state.Set(key, value)
+	}
+
+	ctx, err = g.enterExecution(WithThreadID(ctx, threadID))
+	if err != nil {
+		return nil, err
+	}
+
+	return g.run(ctx, state, nodeID)
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrRetryBudgetExhausted is wrapped by errors that were not retried because the retry
+// budget of the execution was spent
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// Retry layers, as counted by a RetryBudget
+const (
+	RetryLayerProvider = "provider"
+	RetryLayerNode     = "node"
+	RetryLayerAgent    = "agent"
+)
+
+type retryBudgetKey struct{}
+
+// RetryBudget caps the retries of one execution across every layer that retries: the
+// provider manager, graph nodes and agents. Each layer asks it before retrying, so retries
+// of retries cannot multiply the model calls of a single request.
+type RetryBudget struct {
+	max  int
+	used map[string]int
+	mu   sync.Mutex
+}
+
+// NewRetryBudget creates a budget of max retries; a zero max only counts them
+func NewRetryBudget(max int) *RetryBudget {
+	return &RetryBudget{max: max, used: make(map[string]int)}
+}
+
+// WithRetryBudget binds a retry budget to the context of an execution
+func WithRetryBudget(ctx context.Context, budget *RetryBudget) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, budget)
+}
+
+// RetryBudgetFromContext returns the retry budget of the execution, or nil
+func RetryBudgetFromContext(ctx context.Context) *RetryBudget {
+	budget, _ := ctx.Value(retryBudgetKey{}).(*RetryBudget)
+	return budget
+}
+
+// AllowRetry spends a retry of the execution's budget for a layer, reporting false once the
+// budget is spent. Retries are always allowed outside an execution with a budget.
+func AllowRetry(ctx context.Context, layer string) bool {
+	budget := RetryBudgetFromContext(ctx)
+	if budget == nil {
+		return true
+	}
+
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+	if budget.max > 0 && budget.total() >= budget.max {
+		return false
+	}
+	budget.used[layer]++
+	return true
+}
+
+// Used returns the number of retries spent
+func (b *RetryBudget) Used() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.total()
+}
+
+// UsedByLayer returns the number of retries spent by each layer
+func (b *RetryBudget) UsedByLayer() map[string]int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	used := make(map[string]int, len(b.used))
+	for layer, count := range b.used {
+		used[layer] = count
+	}
+	return used
+}
+
+// total returns the retries spent; the caller holds the lock
+func (b *RetryBudget) total() int {
+	total := 0
+	for _, count := range b.used {
+		total += count
+	}
+	return total
+}
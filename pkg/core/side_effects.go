@@ -0,0 +1,57 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrNotRetried is wrapped by node errors that were not retried because the failed attempt
+// ran an operation that must not run twice
+var ErrNotRetried = errors.New("not retried after a non-idempotent operation")
+
+// ErrPermanent is matched by node errors that retrying the node cannot fix; the graph
+// surfaces them without retrying. Errors match it by wrapping it or with an Is method.
+var ErrPermanent = errors.New("permanent node error")
+
+type sideEffectsKey struct{}
+
+// sideEffects collects the non-idempotent operations run during one node attempt
+type sideEffects struct {
+	operations []string
+	mu         sync.Mutex
+}
+
+// MarkNonIdempotent records that the current node attempt ran an operation that must not
+// run twice, such as a payment, an HTTP POST or a file append. When the attempt fails the
+// graph does not retry the node and surfaces the error instead. Calls outside a node
+// attempt are ignored.
+func MarkNonIdempotent(ctx context.Context, operation string) {
+	if effects, ok := ctx.Value(sideEffectsKey{}).(*sideEffects); ok {
+		effects.mu.Lock()
+		effects.operations = append(effects.operations, operation)
+		effects.mu.Unlock()
+	}
+}
+
+// withSideEffects returns a context collecting the non-idempotent operations of a node attempt
+func withSideEffects(ctx context.Context) (context.Context, *sideEffects) {
+	effects := &sideEffects{}
+	return context.WithValue(ctx, sideEffectsKey{}, effects), effects
+}
+
+// list returns the operations recorded so far
+func (e *sideEffects) list() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	operations := make([]string, len(e.operations))
+	copy(operations, e.operations)
+	return operations
+}
@@ -8,6 +8,7 @@ package core
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"sync"
@@ -76,11 +77,15 @@ func (sh *StateHistory) GetSnapshot(id string) (*StateSnapshot, error) {
 	return nil, fmt.Errorf("snapshot with ID %s not found", id)
 }
 
+// ErrReadOnlyState is the panic value of writes to a read-only state view
+var ErrReadOnlyState = errors.New("state is read-only")
+
 // BaseState represents the base state structure
 type BaseState struct {
 	data     map[string]StateValue
 	metadata map[string]interface{}
 	history  *StateHistory
+	readOnly bool
 	mu       sync.RWMutex
 }
 
@@ -104,6 +109,7 @@ func (bs *BaseState) Get(key string) (StateValue, bool) {
 
 // Set sets a value in the state
 func (bs *BaseState) Set(key string, value StateValue) {
+	bs.checkWritable()
 	bs.mu.Lock()
 	defer bs.mu.Unlock()
 
@@ -112,6 +118,7 @@ func (bs *BaseState) Set(key string, value StateValue) {
 
 // Delete removes a key from the state
 func (bs *BaseState) Delete(key string) {
+	bs.checkWritable()
 	bs.mu.Lock()
 	defer bs.mu.Unlock()
 
@@ -144,6 +151,7 @@ func (bs *BaseState) GetAll() map[string]StateValue {
 
 // SetMetadata sets metadata for the state
 func (bs *BaseState) SetMetadata(key string, value interface{}) {
+	bs.checkWritable()
 	bs.mu.Lock()
 	defer bs.mu.Unlock()
 
@@ -189,6 +197,7 @@ func (bs *BaseState) CreateSnapshot() StateSnapshot {
 
 // RestoreFromSnapshot restores the state from a snapshot
 func (bs *BaseState) RestoreFromSnapshot(snapshot StateSnapshot) {
+	bs.checkWritable()
 	bs.mu.Lock()
 	defer bs.mu.Unlock()
 
@@ -214,6 +223,7 @@ func (bs *BaseState) GetHistory() *StateHistory {
 
 // Merge merges another state into this state
 func (bs *BaseState) Merge(other *BaseState) {
+	bs.checkWritable()
 	bs.mu.Lock()
 	defer bs.mu.Unlock()
 
@@ -223,6 +233,26 @@ func (bs *BaseState) Merge(other *BaseState) {
 	}
 }
 
+// ReadOnly returns a read-only copy of the state; writes to it panic with ErrReadOnlyState.
+// Clone the view to get a writable copy.
+func (bs *BaseState) ReadOnly() *BaseState {
+	view := bs.Clone()
+	view.readOnly = true
+	return view
+}
+
+// IsReadOnly reports whether the state is a read-only view
+func (bs *BaseState) IsReadOnly() bool {
+	return bs.readOnly
+}
+
+// checkWritable panics when the state is a read-only view
+func (bs *BaseState) checkWritable() {
+	if bs.readOnly {
+		panic(ErrReadOnlyState)
+	}
+}
+
 // Clone creates a deep copy of the state
 func (bs *BaseState) Clone() *BaseState {
 	bs.mu.RLock()
@@ -261,6 +291,7 @@ func (bs *BaseState) ToJSON() ([]byte, error) {
 
 // FromJSON loads the state from JSON
 func (bs *BaseState) FromJSON(data []byte) error {
+	bs.checkWritable()
 	bs.mu.Lock()
 	defer bs.mu.Unlock()
 
@@ -0,0 +1,144 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// StateCheckMode decides how writes to undeclared state keys are handled
+type StateCheckMode string
+
+const (
+	// StateCheckOff does not check state writes
+	StateCheckOff StateCheckMode = ""
+	// StateCheckWarn logs and records writes to keys a node did not declare
+	StateCheckWarn StateCheckMode = "warn"
+	// StateCheckStrict fails the node on writes to keys it did not declare
+	StateCheckStrict StateCheckMode = "strict"
+)
+
+// StateDelta holds the explicit state changes returned by a delta node; a nil value
+// deletes the key
+type StateDelta map[string]StateValue
+
+// DeltaNodeFunc is a node function that reads a read-only state and returns its changes
+type DeltaNodeFunc func(ctx context.Context, state *BaseState) (StateDelta, error)
+
+// StateViolation records a node writing state keys it did not declare in NodeOptions.Writes
+type StateViolation struct {
+	NodeID    string    `json:"node_id"`
+	Keys      []string  `json:"keys"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// AddDeltaNode adds a node that receives a read-only view of the state and returns explicit
+// changes, which are applied to the state after the node returns
+func (g *Graph) AddDeltaNode(id, name string, fn DeltaNodeFunc) *Node {
+	return g.AddNode(id, name, func(ctx context.Context, state *BaseState) (result *BaseState, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				if recovered, ok := r.(error); ok && errors.Is(recovered, ErrReadOnlyState) {
+					err = fmt.Errorf("node %s wrote to its read-only state: return a StateDelta instead", id)
+					return
+				}
+				panic(r)
+			}
+		}()
+
+		delta, err := fn(ctx, state.ReadOnly())
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range delta {
+			if value == nil {
+				state.Delete(key)
+			} else {
+				state.Set(key, value)
+			}
+		}
+		return state, nil
+	})
+}
+
+// GetStateViolations returns the undeclared state writes recorded during the last execution
+func (g *Graph) GetStateViolations() []StateViolation {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	violations := make([]StateViolation, len(g.stateViolations))
+	copy(violations, g.stateViolations)
+	return violations
+}
+
+// checkStateWrites compares the state before and after a node and reports the changed keys
+// the node did not declare. Nodes without declared writes are not checked.
+func (g *Graph) checkStateWrites(node *Node, before, after *BaseState) error {
+	if g.Config.StateCheck == StateCheckOff || node.Options == nil || len(node.Options.Writes) == 0 || after == nil {
+		return nil
+	}
+
+	declared := make(map[string]bool, len(node.Options.Writes))
+	for _, key := range node.Options.Writes {
+		declared[key] = true
+	}
+
+	var undeclared []string
+	for _, key := range changedKeys(before, after) {
+		if !declared[key] {
+			undeclared = append(undeclared, key)
+		}
+	}
+	if len(undeclared) == 0 {
+		return nil
+	}
+
+	// Compiled graphs run concurrent executions, so they only log violations
+	if !g.compiled {
+		g.mu.Lock()
+		g.stateViolations = append(g.stateViolations, StateViolation{NodeID: node.ID, Keys: undeclared, Timestamp: time.Now()})
+		g.mu.Unlock()
+	}
+
+	g.logger.WithFields(logrus.Fields{
+		"node_id":  node.ID,
+		"keys":     undeclared,
+		"declared": node.Options.Writes,
+	}).Warn("Node wrote undeclared state keys")
+
+	if g.Config.StateCheck == StateCheckStrict {
+		return fmt.Errorf("node %s wrote undeclared state keys %v", node.ID, undeclared)
+	}
+	return nil
+}
+
+// changedKeys returns the keys added, removed or modified between two states
+func changedKeys(before, after *BaseState) []string {
+	beforeData := before.GetAll()
+	afterData := after.GetAll()
+
+	var keys []string
+	for key, value := range afterData {
+		if previous, exists := beforeData[key]; !exists || !reflect.DeepEqual(previous, value) {
+			keys = append(keys, key)
+		}
+	}
+	for key := range beforeData {
+		if _, exists := afterData[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
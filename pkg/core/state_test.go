@@ -311,3 +311,39 @@ func BenchmarkBaseState_Clone(b *testing.B) {
 		state.Clone()
 	}
 }
+
+func TestBaseState_ReadOnly(t *testing.T) {
+	state := NewBaseState()
+	state.Set("key", "value")
+
+	view := state.ReadOnly()
+	if !view.IsReadOnly() || state.IsReadOnly() {
+		t.Fatal("Only the view should be read-only")
+	}
+	if value, _ := view.Get("key"); value != "value" {
+		t.Errorf("Expected the view to read the state, got %v", value)
+	}
+
+	for name, write := range map[string]func(){
+		"Set":         func() { view.Set("key", "changed") },
+		"Delete":      func() { view.Delete("key") },
+		"SetMetadata": func() { view.SetMetadata("key", "changed") },
+		"Merge":       func() { view.Merge(state) },
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r != ErrReadOnlyState {
+					t.Errorf("Expected %s to panic with ErrReadOnlyState, got %v", name, r)
+				}
+			}()
+			write()
+		}()
+	}
+
+	// A clone of the view is writable
+	clone := view.Clone()
+	clone.Set("key", "changed")
+	if value, _ := state.Get("key"); value != "value" {
+		t.Errorf("Writes to a clone must not reach the state, got %v", value)
+	}
+}
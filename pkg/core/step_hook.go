@@ -0,0 +1,76 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// NodeStep is the node an execution is about to run
+type NodeStep struct {
+	// Step counts the nodes run before this one
+	Step   int
+	NodeID string
+	// State is the state the node starts from; changes made by a hook are kept
+	State *BaseState
+	// Edges are the node's outgoing edges, sorted by target
+	Edges []*Edge
+	// IsEnd is set when the execution ends after the node
+	IsEnd bool
+}
+
+// BeforeNodeHook is called before each node of an execution; returning an error stops the
+// execution with it. Hooks may block, for example to wait for a debugger command, but the
+// graph's timeout keeps running meanwhile.
+type BeforeNodeHook func(ctx context.Context, step NodeStep) error
+
+// beforeNodeHookKey is the context key of the hook set with WithBeforeNodeHook
+type beforeNodeHookKey struct{}
+
+// WithBeforeNodeHook returns a context whose graph executions call the hook before each node
+func WithBeforeNodeHook(ctx context.Context, hook BeforeNodeHook) context.Context {
+	return context.WithValue(ctx, beforeNodeHookKey{}, hook)
+}
+
+// OutgoingEdges returns the edges leaving a node, sorted by target
+func (g *Graph) OutgoingEdges(nodeID string) []*Edge {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var edges []*Edge
+	for _, edge := range g.Edges {
+		if edge.From == nodeID {
+			edges = append(edges, edge)
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		return edges[i].To < edges[j].To
+	})
+	return edges
+}
+
+// callBeforeNodeHook calls the execution's hook, if any, before a node runs from the state
+func (g *Graph) callBeforeNodeHook(ctx context.Context, nodeID string, step int, state *BaseState) error {
+	hook, ok := ctx.Value(beforeNodeHookKey{}).(BeforeNodeHook)
+	if !ok {
+		return nil
+	}
+
+	err := hook(ctx, NodeStep{
+		Step:   step,
+		NodeID: nodeID,
+		State:  state,
+		Edges:  g.OutgoingEdges(nodeID),
+		IsEnd:  g.isEndNode(nodeID),
+	})
+	if err != nil {
+		return fmt.Errorf("stopped before node %s: %w", nodeID, err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxSubgraphDepth is the nesting limit used when GraphConfig.MaxSubgraphDepth is not set
+const DefaultMaxSubgraphDepth = 10
+
+// ErrMaxDepthExceeded is returned when graphs executing inside other graphs nest deeper than
+// the root graph's MaxSubgraphDepth. The error message includes the nesting path.
+var ErrMaxDepthExceeded = errors.New("maximum subgraph depth exceeded")
+
+// executionPathKey is the context key of the graphs an execution is nested in
+type executionPathKey struct{}
+
+// executionPath lists the graphs an execution is nested in, outermost first, with the depth
+// limit of the outermost graph
+type executionPath struct {
+	graphs   []string
+	maxDepth int
+}
+
+// enterExecution records a graph execution and binds its graph context, failing with
+// ErrMaxDepthExceeded when it nests deeper than the outermost graph allows
+func (g *Graph) enterExecution(ctx context.Context) (context.Context, error) {
+	parent, _ := ctx.Value(executionPathKey{}).(*executionPath)
+
+	path := &executionPath{maxDepth: g.Config.MaxSubgraphDepth}
+	if parent != nil {
+		path.graphs = append(path.graphs, parent.graphs...)
+		path.maxDepth = parent.maxDepth
+	}
+	if path.maxDepth <= 0 {
+		path.maxDepth = DefaultMaxSubgraphDepth
+	}
+	path.graphs = append(path.graphs, g.Name)
+
+	if depth := len(path.graphs) - 1; depth > path.maxDepth {
+		return ctx, fmt.Errorf("%w (%d): %s", ErrMaxDepthExceeded, path.maxDepth, strings.Join(path.graphs, " -> "))
+	}
+	ctx = context.WithValue(ctx, signalBoardKey{}, g.signalBoard())
+	return g.bindGraphContext(context.WithValue(ctx, executionPathKey{}, path)), nil
+}
+
+// AddSubgraph adds a node that executes another graph on the current state and continues with
+// the subgraph's final state. Validate rejects subgraphs that include themselves.
+func (g *Graph) AddSubgraph(id, name string, subgraph *Graph) *Node {
+	node := g.AddNode(id, name, func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		// The subgraph's node results and checkpoints are not part of the parent's execution
+		ctx = context.WithValue(ctx, resultObserverKey{}, nil)
+		ctx = WithThreadID(ctx, "")
+
+		result, err := subgraph.Execute(ctx, state)
+		if err != nil {
+			return nil, fmt.Errorf("subgraph %s: %w", subgraph.Name, err)
+		}
+		return result, nil
+	})
+
+	g.mu.Lock()
+	node.Subgraph = subgraph
+	node.Metadata["subgraph"] = subgraph.Name
+	node.Metadata["subgraph_id"] = subgraph.ID
+	g.mu.Unlock()
+
+	return node
+}
+
+// subgraphs returns the subgraphs referenced by the graph's nodes
+func (g *Graph) subgraphs() []*Graph {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var subgraphs []*Graph
+	for _, node := range g.Nodes {
+		if node.Subgraph != nil {
+			subgraphs = append(subgraphs, node.Subgraph)
+		}
+	}
+	return subgraphs
+}
+
+// checkSubgraphRecursion reports a graph that includes itself through its subgraph nodes,
+// directly or through other subgraphs
+func (g *Graph) checkSubgraphRecursion() error {
+	var visit func(graph *Graph, path []*Graph) error
+	visit = func(graph *Graph, path []*Graph) error {
+		for i, ancestor := range path {
+			if ancestor == graph {
+				names := make([]string, 0, len(path)-i+1)
+				for _, cycle := range path[i:] {
+					names = append(names, cycle.Name)
+				}
+				names = append(names, graph.Name)
+				return fmt.Errorf("recursive subgraph reference: %s", strings.Join(names, " -> "))
+			}
+		}
+
+		path = append(path, graph)
+		for _, subgraph := range graph.subgraphs() {
+			if err := visit(subgraph, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return visit(g, nil)
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// SchemaViolation is a value that does not satisfy a JSON schema
+type SchemaViolation struct {
+	// Path is the JSON pointer of the offending value, "" for the root
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// String returns the violation as "path: message"
+func (v SchemaViolation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + v.Message
+}
+
+// JSONSchema is a compiled JSON schema
+type JSONSchema struct {
+	schema *jsonschema.Schema
+}
+
+// CompileJSONSchema compiles a JSON schema given as raw JSON, a map or any value encoding
+// to a schema. Drafts 4 to 2020-12 are supported; the draft defaults to 2020-12.
+func CompileJSONSchema(schema interface{}) (*JSONSchema, error) {
+	data, ok := schema.([]byte)
+	if !ok {
+		if text, isString := schema.(string); isString {
+			data = []byte(text)
+		} else {
+			var err error
+			if data, err = json.Marshal(schema); err != nil {
+				return nil, fmt.Errorf("failed to encode JSON schema: %w", err)
+			}
+		}
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("schema.json", bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	compiled, err := compiler.Compile("schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	return &JSONSchema{schema: compiled}, nil
+}
+
+// Validate checks a value against the schema and returns every violation, sorted by path.
+// Go values are validated as their JSON encoding.
+func (s *JSONSchema) Validate(value interface{}) ([]SchemaViolation, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode value: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var instance interface{}
+	if err := decoder.Decode(&instance); err != nil {
+		return nil, fmt.Errorf("failed to decode value: %w", err)
+	}
+	return s.validateInstance(instance)
+}
+
+// ValidateJSON checks a JSON document against the schema and returns every violation
+func (s *JSONSchema) ValidateJSON(data []byte) ([]SchemaViolation, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var instance interface{}
+	if err := decoder.Decode(&instance); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return s.validateInstance(instance)
+}
+
+// validateInstance validates a decoded JSON value
+func (s *JSONSchema) validateInstance(instance interface{}) ([]SchemaViolation, error) {
+	err := s.schema.Validate(instance)
+	if err == nil {
+		return nil, nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, err
+	}
+
+	violations := collectViolations(validationErr, nil)
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Path < violations[j].Path
+	})
+	return violations, nil
+}
+
+// collectViolations flattens a validation error tree into its leaves, which are the
+// individual violations; the inner errors only group them
+func collectViolations(err *jsonschema.ValidationError, violations []SchemaViolation) []SchemaViolation {
+	if len(err.Causes) == 0 {
+		return append(violations, SchemaViolation{Path: err.InstanceLocation, Message: err.Message})
+	}
+	for _, cause := range err.Causes {
+		violations = collectViolations(cause, violations)
+	}
+	return violations
+}
+
+// ValidationErrorsKey returns the state key a validation node stores the violations of the
+// value under inputKey in
+func ValidationErrorsKey(inputKey string) string {
+	return inputKey + "_validation_errors"
+}
+
+// NewValidationNode creates a node that validates the state value under inputKey against a
+// JSON schema. A valid value continues along the node's other edges; an invalid or missing
+// value stores the violations under ValidationErrorsKey(inputKey) and routes to onFailNode.
+// Add it with Graph.AddValidationNode, which also adds the edge to onFailNode.
+func NewValidationNode(id string, schema interface{}, inputKey, onFailNode string) (*Node, error) {
+	if inputKey == "" {
+		return nil, fmt.Errorf("validation node %s: input key is required", id)
+	}
+	if onFailNode == "" {
+		return nil, fmt.Errorf("validation node %s: failure node is required", id)
+	}
+	compiled, err := CompileJSONSchema(schema)
+	if err != nil {
+		return nil, fmt.Errorf("validation node %s: %w", id, err)
+	}
+
+	errorsKey := ValidationErrorsKey(inputKey)
+	validate := func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		var violations []SchemaViolation
+		value, exists := state.Get(inputKey)
+		if !exists {
+			violations = []SchemaViolation{{Message: fmt.Sprintf("state key %s is missing", inputKey)}}
+		} else if violations, err = compiled.Validate(value); err != nil {
+			return nil, fmt.Errorf("validation node %s: %w", id, err)
+		}
+
+		if len(violations) == 0 {
+			state.Delete(errorsKey)
+			return state, nil
+		}
+		state.Set(errorsKey, violations)
+		return state, nil
+	}
+
+	return &Node{
+		ID:       id,
+		Name:     id,
+		Function: validate,
+		Metadata: map[string]interface{}{
+			"type":      "validation",
+			"input_key": inputKey,
+			"on_fail":   onFailNode,
+		},
+	}, nil
+}
+
+// AddValidationNode adds a node created with NewValidationNode and the edge routing an
+// invalid value to onFailNode. Add the edge followed by valid values with AddEdge.
+func (g *Graph) AddValidationNode(id string, schema interface{}, inputKey, onFailNode string) (*Node, error) {
+	node, err := NewValidationNode(id, schema, inputKey, onFailNode)
+	if err != nil {
+		return nil, err
+	}
+	g.AddPrebuiltNode(node)
+
+	errorsKey := ValidationErrorsKey(inputKey)
+	g.AddEdge(id, onFailNode, func(ctx context.Context, state *BaseState) (string, error) {
+		if _, failed := state.Get(errorsKey); failed {
+			return onFailNode, nil
+		}
+		return "", nil
+	})
+	return node, nil
+}
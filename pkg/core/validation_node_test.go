@@ -0,0 +1,91 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+func TestValidationNode(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":     "object",
+		"required": []string{"name", "age"},
+		"properties": map[string]interface{}{
+			"name":  map[string]interface{}{"type": "string"},
+			"age":   map[string]interface{}{"type": "integer", "minimum": 0},
+			"email": map[string]interface{}{"type": "string", "pattern": "@"},
+		},
+	}
+
+	graph := NewGraph("contract")
+	if _, err := graph.AddValidationNode("validate", schema, "record", "reject"); err != nil {
+		t.Fatalf("AddValidationNode() failed: %v", err)
+	}
+	graph.AddNode("store", "Store", func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		state.Set("stored", true)
+		return state, nil
+	})
+	graph.AddNode("reject", "Reject", func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		state.Set("rejected", true)
+		return state, nil
+	})
+	graph.AddEdge("validate", "store", nil)
+	graph.SetStartNode("validate")
+	graph.AddEndNode("store")
+	graph.AddEndNode("reject")
+
+	// Valid values continue along the graph
+	state := NewBaseState()
+	state.Set("record", map[string]interface{}{"name": "Ada", "age": 36})
+	result, err := graph.Execute(context.Background(), state)
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if stored, _ := result.Get("stored"); stored != true {
+		t.Errorf("Expected the valid record to be stored")
+	}
+	if _, exists := result.Get(ValidationErrorsKey("record")); exists {
+		t.Errorf("Expected no validation errors for a valid record")
+	}
+
+	// Invalid values route to the failure node with every violation
+	state = NewBaseState()
+	state.Set("record", map[string]interface{}{"age": -1, "email": "nobody"})
+	result, err = graph.Execute(context.Background(), state)
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if rejected, _ := result.Get("rejected"); rejected != true {
+		t.Fatalf("Expected the invalid record to be rejected")
+	}
+	value, _ := result.Get(ValidationErrorsKey("record"))
+	violations, ok := value.([]SchemaViolation)
+	if !ok || len(violations) != 3 {
+		t.Fatalf("Expected three violations, got %v", value)
+	}
+	paths := []string{violations[0].Path, violations[1].Path, violations[2].Path}
+	if paths[0] != "" || paths[1] != "/age" || paths[2] != "/email" {
+		t.Errorf("Expected violations of the root, age and email, got %v", violations)
+	}
+
+	// A missing value is a violation too
+	result, err = graph.Execute(context.Background(), NewBaseState())
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if rejected, _ := result.Get("rejected"); rejected != true {
+		t.Errorf("Expected a missing record to be rejected")
+	}
+
+	if _, err := NewValidationNode("bad", `{"type": 12}`, "record", "reject"); err == nil {
+		t.Error("Expected an invalid schema to be rejected")
+	}
+	if _, err := NewValidationNode("bad", schema, "record", ""); err == nil {
+		t.Error("Expected a missing failure node to be rejected")
+	}
+}
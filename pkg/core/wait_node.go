@@ -0,0 +1,177 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WaitTimeoutKey returns the state key a wait node sets to true when its timeout elapses
+// before the signal is delivered
+func WaitTimeoutKey(signalKey string) string {
+	return signalKey + "_timed_out"
+}
+
+// signalBoardKey is the context key of the signals of the executing graph
+type signalBoardKey struct{}
+
+// signalAddress identifies the signals a wait node of a thread's execution waits for
+type signalAddress struct {
+	threadID  string
+	signalKey string
+}
+
+// signalBoard routes the signals delivered to a graph to its waiting nodes. A signal
+// delivered while no node waits for it is held until one does.
+type signalBoard struct {
+	mu      sync.Mutex
+	waiting map[signalAddress]chan interface{}
+	pending map[signalAddress]interface{}
+}
+
+// newSignalBoard creates an empty signal board
+func newSignalBoard() *signalBoard {
+	return &signalBoard{
+		waiting: make(map[signalAddress]chan interface{}),
+		pending: make(map[signalAddress]interface{}),
+	}
+}
+
+// deliver hands a payload to the node waiting for it, or holds it, replacing a payload
+// already held. It reports whether a node was waiting.
+func (b *signalBoard) deliver(address signalAddress, payload interface{}) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if waiter, exists := b.waiting[address]; exists {
+		delete(b.waiting, address)
+		waiter <- payload
+		return true
+	}
+	b.pending[address] = payload
+	return false
+}
+
+// wait registers a waiting node, returning the channel its signal arrives on and a function
+// unregistering it. A held signal is already on the channel.
+func (b *signalBoard) wait(address signalAddress) (<-chan interface{}, func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, exists := b.waiting[address]; exists {
+		return nil, nil, fmt.Errorf("signal %s of thread %s is already awaited", address.signalKey, address.threadID)
+	}
+
+	waiter := make(chan interface{}, 1)
+	if payload, exists := b.pending[address]; exists {
+		delete(b.pending, address)
+		waiter <- payload
+		return waiter, func() {}, nil
+	}
+	b.waiting[address] = waiter
+
+	return waiter, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if b.waiting[address] == waiter {
+			delete(b.waiting, address)
+		}
+	}, nil
+}
+
+// signalBoard returns the graph's signal board, creating it on first use
+func (g *Graph) signalBoard() *signalBoard {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.signals == nil {
+		g.signals = newSignalBoard()
+	}
+	return g.signals
+}
+
+// Signal delivers an external event to the wait node of a thread's execution waiting for
+// signalKey, which resumes with the payload in its state. A signal delivered before the node
+// waits, or while the execution is stopped to be resumed with ResumeFromNode, is held until it
+// does; a later signal for the same key replaces it. Signal reports whether a node was waiting.
+func (g *Graph) Signal(threadID, signalKey string, payload interface{}) bool {
+	return g.signalBoard().deliver(signalAddress{threadID: threadID, signalKey: signalKey}, payload)
+}
+
+// NewWaitNode creates a node pausing the execution until Graph.Signal delivers signalKey for
+// the execution's thread, set with WithThreadID. The payload is stored under signalKey. When
+// timeout elapses first, WaitTimeoutKey(signalKey) is set to true instead; a zero timeout
+// waits as long as the graph's own timeout allows. The checkpoint taken before the node lets
+// a stopped execution wait again with ResumeFromNode.
+// Add it with Graph.AddWaitNode, which also adds the edge followed on timeout.
+func NewWaitNode(id, signalKey string, timeout time.Duration) *Node {
+	timeoutKey := WaitTimeoutKey(signalKey)
+	wait := func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		board, ok := ctx.Value(signalBoardKey{}).(*signalBoard)
+		if !ok {
+			return nil, fmt.Errorf("wait node %s must run inside a graph execution: %w", id, ErrPermanent)
+		}
+		threadID, ok := ThreadIDFromContext(ctx)
+		if !ok {
+			return nil, fmt.Errorf("wait node %s: execution has no thread ID to receive signals on: %w", id, ErrPermanent)
+		}
+
+		signals, stop, err := board.wait(signalAddress{threadID: threadID, signalKey: signalKey})
+		if err != nil {
+			return nil, fmt.Errorf("wait node %s: %w", id, err)
+		}
+		defer stop()
+
+		var expired <-chan time.Time
+		if timeout > 0 {
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			expired = timer.C
+		}
+
+		select {
+		case payload := <-signals:
+			state.Set(signalKey, payload)
+			state.Delete(timeoutKey)
+		case <-expired:
+			state.Set(timeoutKey, true)
+		case <-ctx.Done():
+			return nil, fmt.Errorf("wait node %s stopped waiting for %s: %w", id, signalKey, ctx.Err())
+		}
+		return state, nil
+	}
+
+	return &Node{
+		ID:       id,
+		Name:     id,
+		Function: wait,
+		Metadata: map[string]interface{}{
+			"type":       "wait",
+			"signal_key": signalKey,
+			"timeout":    timeout.String(),
+		},
+	}
+}
+
+// AddWaitNode adds a node created with NewWaitNode and the edge routing a timed out wait to
+// onTimeoutNode. Add the edge followed once the signal arrives with AddEdge.
+func (g *Graph) AddWaitNode(id, signalKey string, timeout time.Duration, onTimeoutNode string) *Node {
+	node := NewWaitNode(id, signalKey, timeout)
+	node.Metadata["on_timeout"] = onTimeoutNode
+	g.AddPrebuiltNode(node)
+
+	timeoutKey := WaitTimeoutKey(signalKey)
+	g.AddEdge(id, onTimeoutNode, func(ctx context.Context, state *BaseState) (string, error) {
+		if timedOut, _ := state.Get(timeoutKey); timedOut == true {
+			return onTimeoutNode, nil
+		}
+		return "", nil
+	})
+	return node
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newApprovalGraph(timeout time.Duration) *Graph {
+	graph := NewGraph("approval")
+	graph.AddWaitNode("await_approval", "approval", timeout, "escalate")
+	graph.AddNode("apply", "Apply", func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		state.Set("applied", true)
+		return state, nil
+	})
+	graph.AddNode("escalate", "Escalate", func(ctx context.Context, state *BaseState) (*BaseState, error) {
+		state.Set("escalated", true)
+		return state, nil
+	})
+	graph.AddEdge("await_approval", "apply", nil)
+	graph.SetStartNode("await_approval")
+	graph.AddEndNode("apply")
+	graph.AddEndNode("escalate")
+	return graph
+}
+
+func TestWaitNodeResumesOnSignal(t *testing.T) {
+	graph := newApprovalGraph(time.Minute)
+	ctx := WithThreadID(context.Background(), "thread-1")
+
+	done := make(chan *BaseState, 1)
+	go func() {
+		result, err := graph.Execute(ctx, NewBaseState())
+		if err != nil {
+			t.Errorf("Execute() failed: %v", err)
+		}
+		done <- result
+	}()
+
+	// Signals for other threads do not wake the node
+	graph.Signal("thread-2", "approval", "ignored")
+
+	// A signal arriving before the node waits is held, so the payload is delivered either way
+	time.Sleep(10 * time.Millisecond)
+	graph.Signal("thread-1", "approval", map[string]interface{}{"approved_by": "ops"})
+
+	result := <-done
+	if result == nil {
+		t.Fatal("Expected a final state")
+	}
+	payload, _ := result.Get("approval")
+	if approval, _ := payload.(map[string]interface{}); approval["approved_by"] != "ops" {
+		t.Errorf("Expected the signal payload in state, got %v", payload)
+	}
+	if applied, _ := result.Get("applied"); applied != true {
+		t.Errorf("Expected the execution to continue along the signal edge")
+	}
+	if _, timedOut := result.Get(WaitTimeoutKey("approval")); timedOut {
+		t.Errorf("Expected no timeout flag")
+	}
+}
+
+func TestWaitNodeUsesHeldSignal(t *testing.T) {
+	graph := newApprovalGraph(time.Minute)
+
+	if graph.Signal("thread-1", "approval", "early") {
+		t.Errorf("Expected no node to be waiting yet")
+	}
+
+	result, err := graph.Execute(WithThreadID(context.Background(), "thread-1"), NewBaseState())
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if payload, _ := result.Get("approval"); payload != "early" {
+		t.Errorf("Expected the held signal payload, got %v", payload)
+	}
+}
+
+func TestWaitNodeTimeout(t *testing.T) {
+	graph := newApprovalGraph(20 * time.Millisecond)
+
+	result, err := graph.Execute(WithThreadID(context.Background(), "thread-1"), NewBaseState())
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if escalated, _ := result.Get("escalated"); escalated != true {
+		t.Errorf("Expected the execution to follow the timeout edge")
+	}
+	if applied, _ := result.Get("applied"); applied == true {
+		t.Errorf("Expected the signal edge not to be followed")
+	}
+}
+
+func TestWaitNodeRequiresThreadID(t *testing.T) {
+	graph := newApprovalGraph(time.Minute)
+
+	_, err := graph.Execute(context.Background(), NewBaseState())
+	if !errors.Is(err, ErrPermanent) {
+		t.Errorf("Expected a permanent error without a thread ID, got %v", err)
+	}
+}
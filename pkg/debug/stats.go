@@ -0,0 +1,179 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// hotPathRatio is the share of the busiest edge's traffic from which an edge counts as hot
+const hotPathRatio = 0.5
+
+// GraphStats aggregates recorded executions of a graph
+type GraphStats struct {
+	Runs int `json:"runs"`
+	// EdgeCounts counts traversals keyed by EdgeKey
+	EdgeCounts map[string]int `json:"edge_counts"`
+	NodeCounts map[string]int `json:"node_counts"`
+	// NodeDurations holds the average duration of each node
+	NodeDurations map[string]time.Duration `json:"node_durations"`
+}
+
+// EdgeKey returns the key of an edge in GraphStats.EdgeCounts
+func EdgeKey(from, to string) string {
+	return from + "->" + to
+}
+
+// AggregateStats computes edge traversal counts and average node durations from execution
+// steps. Steps are grouped into runs by thread ID; within a run, consecutive node visits
+// are counted as an edge traversal.
+func AggregateStats(steps []ExecutionStep) *GraphStats {
+	stats := &GraphStats{
+		EdgeCounts:    make(map[string]int),
+		NodeCounts:    make(map[string]int),
+		NodeDurations: make(map[string]time.Duration),
+	}
+
+	var threads []string
+	visits := make(map[string][]ExecutionStep)
+	for _, step := range steps {
+		if _, exists := visits[step.ThreadID]; !exists {
+			threads = append(threads, step.ThreadID)
+		}
+		visits[step.ThreadID] = append(visits[step.ThreadID], step)
+	}
+
+	totals := make(map[string]time.Duration)
+	timed := make(map[string]int)
+	for _, threadID := range threads {
+		stats.Runs++
+
+		// Prefer "enter" steps for the path; traces without them fall back to all steps
+		var path []string
+		for _, step := range visits[threadID] {
+			if step.StepType == "enter" {
+				path = append(path, step.NodeID)
+			}
+			if step.Duration > 0 {
+				totals[step.NodeID] += step.Duration
+				timed[step.NodeID]++
+			}
+		}
+		if len(path) == 0 {
+			for _, step := range visits[threadID] {
+				path = append(path, step.NodeID)
+			}
+		}
+
+		for i, nodeID := range path {
+			stats.NodeCounts[nodeID]++
+			if i > 0 {
+				stats.EdgeCounts[EdgeKey(path[i-1], nodeID)]++
+			}
+		}
+	}
+
+	for nodeID, total := range totals {
+		stats.NodeDurations[nodeID] = total / time.Duration(timed[nodeID])
+	}
+	return stats
+}
+
+// LoadTraceFile reads execution steps recorded as a JSON array
+func LoadTraceFile(path string) ([]ExecutionStep, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trace file: %w", err)
+	}
+
+	var steps []ExecutionStep
+	if err := json.Unmarshal(data, &steps); err != nil {
+		return nil, fmt.Errorf("failed to parse trace file: %w", err)
+	}
+	return steps, nil
+}
+
+// GetGraphStats aggregates the recorded execution history
+func (gv *GraphVisualizer) GetGraphStats() *GraphStats {
+	return AggregateStats(gv.GetExecutionHistory(""))
+}
+
+// GenerateMermaidDiagramWithStats generates a Mermaid diagram annotated with traversal
+// counts on edges and average durations on nodes. Hot paths are highlighted and edges
+// that were never taken are dimmed.
+func (gv *GraphVisualizer) GenerateMermaidDiagramWithStats(topology *GraphTopology, stats *GraphStats) string {
+	if stats == nil {
+		return gv.GenerateMermaidDiagram(topology)
+	}
+
+	annotated := &GraphTopology{
+		Nodes:  make([]NodeInfo, len(topology.Nodes)),
+		Edges:  make([]EdgeInfo, len(topology.Edges)),
+		Groups: topology.Groups,
+	}
+	for i, node := range topology.Nodes {
+		if count := stats.NodeCounts[node.ID]; count > 0 {
+			node.Name = fmt.Sprintf("%s<br/>%d runs", node.Name, count)
+			if duration, exists := stats.NodeDurations[node.ID]; exists {
+				node.Name += fmt.Sprintf(", avg %s", formatStatsDuration(duration))
+			}
+		}
+		annotated.Nodes[i] = node
+	}
+
+	maxCount := 0
+	for _, edge := range topology.Edges {
+		if count := stats.EdgeCounts[EdgeKey(edge.From, edge.To)]; count > maxCount {
+			maxCount = count
+		}
+	}
+
+	var styles strings.Builder
+	hotNodes := make(map[string]bool)
+	for i, edge := range topology.Edges {
+		count := stats.EdgeCounts[EdgeKey(edge.From, edge.To)]
+		if edge.Condition != "" {
+			edge.Condition = fmt.Sprintf("%s: %d", edge.Condition, count)
+		} else {
+			edge.Condition = fmt.Sprintf("%d", count)
+		}
+		annotated.Edges[i] = edge
+
+		switch {
+		case count == 0:
+			styles.WriteString(fmt.Sprintf("    linkStyle %d stroke:#cccccc,stroke-dasharray:3\n", i))
+		case float64(count) >= hotPathRatio*float64(maxCount):
+			styles.WriteString(fmt.Sprintf("    linkStyle %d stroke:#d62728,stroke-width:3px\n", i))
+			hotNodes[edge.From] = true
+			hotNodes[edge.To] = true
+		}
+	}
+
+	for _, node := range topology.Nodes {
+		if hotNodes[node.ID] {
+			styles.WriteString(fmt.Sprintf("    style %s stroke:#d62728,stroke-width:3px\n", node.ID))
+		}
+	}
+
+	return gv.GenerateMermaidDiagram(annotated) + styles.String()
+}
+
+// formatStatsDuration rounds a duration for display in a diagram
+func formatStatsDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(time.Millisecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordRun records the enter and exit steps of one run through the given nodes
+func recordRun(threadID string, nodes ...string) []ExecutionStep {
+	var steps []ExecutionStep
+	for _, nodeID := range nodes {
+		steps = append(steps,
+			ExecutionStep{ThreadID: threadID, NodeID: nodeID, StepType: "enter"},
+			ExecutionStep{ThreadID: threadID, NodeID: nodeID, StepType: "exit", Duration: 10 * time.Millisecond},
+		)
+	}
+	return steps
+}
+
+func TestAggregateStats(t *testing.T) {
+	var steps []ExecutionStep
+	for i := 0; i < 3; i++ {
+		steps = append(steps, recordRun(fmt.Sprintf("run-%d", i), "classify", "answer")...)
+	}
+	steps = append(steps, recordRun("run-3", "classify", "escalate")...)
+	steps[len(steps)-1].Duration = 50 * time.Millisecond
+
+	stats := AggregateStats(steps)
+	if stats.Runs != 4 {
+		t.Errorf("Expected 4 runs, got %d", stats.Runs)
+	}
+	if stats.EdgeCounts[EdgeKey("classify", "answer")] != 3 || stats.EdgeCounts[EdgeKey("classify", "escalate")] != 1 {
+		t.Errorf("Unexpected edge counts %v", stats.EdgeCounts)
+	}
+	if stats.NodeCounts["classify"] != 4 {
+		t.Errorf("Expected classify to run 4 times, got %d", stats.NodeCounts["classify"])
+	}
+	if stats.NodeDurations["escalate"] != 50*time.Millisecond || stats.NodeDurations["answer"] != 10*time.Millisecond {
+		t.Errorf("Unexpected average durations %v", stats.NodeDurations)
+	}
+}
+
+func TestGraphVisualizer_GenerateMermaidDiagramWithStats(t *testing.T) {
+	visualizer := NewGraphVisualizer(nil, nil)
+	topology := &GraphTopology{
+		Nodes: []NodeInfo{
+			{ID: "classify", Name: "Classify", IsStartNode: true},
+			{ID: "answer", Name: "Answer", IsEndNode: true},
+			{ID: "escalate", Name: "Escalate", IsEndNode: true},
+			{ID: "refund", Name: "Refund", IsEndNode: true},
+		},
+		Edges: []EdgeInfo{
+			{From: "classify", To: "answer"},
+			{From: "classify", To: "escalate", Condition: "condition"},
+			{From: "classify", To: "refund"},
+		},
+	}
+
+	var steps []ExecutionStep
+	for i := 0; i < 3; i++ {
+		steps = append(steps, recordRun(fmt.Sprintf("run-%d", i), "classify", "answer")...)
+	}
+	steps = append(steps, recordRun("run-3", "classify", "escalate")...)
+
+	diagram := visualizer.GenerateMermaidDiagramWithStats(topology, AggregateStats(steps))
+
+	expected := []string{
+		"classify[Classify<br/>4 runs, avg 10ms]",
+		"refund[Refund]",
+		"classify --> answer|3|",
+		"classify --> escalate|condition: 1|",
+		"classify --> refund|0|",
+		"linkStyle 0 stroke:#d62728,stroke-width:3px",
+		"linkStyle 2 stroke:#cccccc,stroke-dasharray:3",
+		"style answer stroke:#d62728",
+	}
+	for _, part := range expected {
+		if !strings.Contains(diagram, part) {
+			t.Errorf("Expected diagram to contain %q, got:\n%s", part, diagram)
+		}
+	}
+	if strings.Contains(diagram, "linkStyle 1") {
+		t.Error("Expected the cold edge to keep the default style")
+	}
+
+	if visualizer.GenerateMermaidDiagramWithStats(topology, nil) != visualizer.GenerateMermaidDiagram(topology) {
+		t.Error("Expected a plain diagram without stats")
+	}
+}
+
+func TestLoadTraceFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.json")
+	data, _ := json.Marshal(recordRun("run-1", "classify", "answer"))
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	steps, err := LoadTraceFile(path)
+	if err != nil {
+		t.Fatalf("LoadTraceFile failed: %v", err)
+	}
+	if len(steps) != 4 || steps[2].NodeID != "answer" {
+		t.Errorf("Unexpected steps %+v", steps)
+	}
+
+	if _, err := LoadTraceFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected an error for a missing trace file")
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package debug
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+)
+
+// ErrDebugQuit is returned when the user quits a debugging session
+var ErrDebugQuit = errors.New("debugging session quit")
+
+// debugTimeout replaces the graph's timeout while a session may be paused at a prompt
+const debugTimeout = 24 * time.Hour
+
+// stepDebuggerHelp lists the commands of the step debugger
+const stepDebuggerHelp = `Commands:
+  s, step           run the next node and pause again (default)
+  c, continue       run to the end without pausing
+  p, print [key]    print the state, or a single value
+  set <key> <json>  set a state value; values that are not JSON are set as strings
+  del <key>         delete a state value
+  e, edges          list the edges leaving the next node
+  q, quit           stop the execution
+  h, help           show this help`
+
+// StateDiffKind is the kind of a state change
+type StateDiffKind string
+
+// Kinds of state changes
+const (
+	StateAdded   StateDiffKind = "added"
+	StateRemoved StateDiffKind = "removed"
+	StateChanged StateDiffKind = "changed"
+)
+
+// StateDiff is a change of a state value between two snapshots
+type StateDiff struct {
+	Key    string        `json:"key"`
+	Kind   StateDiffKind `json:"kind"`
+	Before interface{}   `json:"before,omitempty"`
+	After  interface{}   `json:"after,omitempty"`
+}
+
+// DiffStates returns the values added, removed or changed between two state snapshots,
+// sorted by key
+func DiffStates(before, after map[string]core.StateValue) []StateDiff {
+	var diffs []StateDiff
+	for key, value := range after {
+		previous, existed := before[key]
+		switch {
+		case !existed:
+			diffs = append(diffs, StateDiff{Key: key, Kind: StateAdded, After: value})
+		case !reflect.DeepEqual(previous, value):
+			diffs = append(diffs, StateDiff{Key: key, Kind: StateChanged, Before: previous, After: value})
+		}
+	}
+	for key, value := range before {
+		if _, exists := after[key]; !exists {
+			diffs = append(diffs, StateDiff{Key: key, Kind: StateRemoved, Before: value})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Key < diffs[j].Key
+	})
+	return diffs
+}
+
+// StepDebugger executes a graph node by node, pausing before each node to let the user
+// inspect and edit the state, and printing the state changes of every node
+type StepDebugger struct {
+	in  *bufio.Scanner
+	out io.Writer
+
+	continuing bool
+	lastNode   string
+	snapshot   map[string]core.StateValue
+}
+
+// NewStepDebugger creates a step debugger reading commands from in and writing to out.
+// When in is exhausted the execution continues to the end.
+func NewStepDebugger(in io.Reader, out io.Writer) *StepDebugger {
+	return &StepDebugger{in: bufio.NewScanner(in), out: out}
+}
+
+// Run executes the graph under the debugger and prints the changes of the last node. The
+// graph's timeout is lifted for the session, since the execution waits on the user.
+func (d *StepDebugger) Run(ctx context.Context, graph *core.Graph, initialState *core.BaseState) (*core.BaseState, error) {
+	timeout := graph.Config.Timeout
+	graph.Config.Timeout = debugTimeout
+	defer func() { graph.Config.Timeout = timeout }()
+
+	d.continuing = false
+	d.lastNode = ""
+	d.snapshot = nil
+
+	result, err := graph.Execute(core.WithBeforeNodeHook(ctx, d.Hook()), initialState)
+	if result != nil {
+		d.printDiff(result)
+	}
+	if err != nil {
+		fmt.Fprintf(d.out, "✗ Execution stopped: %v\n", err)
+		return result, err
+	}
+	fmt.Fprintln(d.out, "✓ Execution completed")
+	return result, nil
+}
+
+// Hook returns the hook pausing executions before each node, for use with
+// core.WithBeforeNodeHook
+func (d *StepDebugger) Hook() core.BeforeNodeHook {
+	return func(ctx context.Context, step core.NodeStep) error {
+		if d.snapshot != nil {
+			d.printDiff(step.State)
+		}
+		defer func() {
+			d.lastNode = step.NodeID
+			d.snapshot = step.State.GetAll()
+		}()
+
+		fmt.Fprintf(d.out, "\n▶ Step %d: next node %s", step.Step+1, step.NodeID)
+		if step.IsEnd {
+			fmt.Fprint(d.out, " (end node)")
+		}
+		fmt.Fprintln(d.out)
+		d.printEdges(step)
+
+		for !d.continuing {
+			fmt.Fprint(d.out, "(debug) ")
+			if !d.in.Scan() {
+				fmt.Fprintln(d.out)
+				d.continuing = true
+				break
+			}
+			done, err := d.command(step, strings.TrimSpace(d.in.Text()))
+			if err != nil {
+				return err
+			}
+			if done {
+				break
+			}
+		}
+		return nil
+	}
+}
+
+// command runs a debugger command and reports whether the execution should proceed
+func (d *StepDebugger) command(step core.NodeStep, line string) (bool, error) {
+	name, args, _ := strings.Cut(line, " ")
+	args = strings.TrimSpace(args)
+
+	switch name {
+	case "", "s", "step", "n", "next":
+		return true, nil
+	case "c", "continue":
+		d.continuing = true
+		return true, nil
+	case "q", "quit", "exit":
+		return false, ErrDebugQuit
+	case "p", "print":
+		d.printState(step.State, args)
+	case "e", "edges":
+		d.printEdges(step)
+	case "set":
+		key, raw, found := strings.Cut(args, " ")
+		if !found || key == "" {
+			fmt.Fprintln(d.out, "usage: set <key> <json>")
+			break
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			value = raw
+		}
+		step.State.Set(key, value)
+		fmt.Fprintf(d.out, "  %s = %s\n", key, formatValue(value))
+	case "del", "delete":
+		if args == "" {
+			fmt.Fprintln(d.out, "usage: del <key>")
+			break
+		}
+		step.State.Delete(args)
+		fmt.Fprintf(d.out, "  deleted %s\n", args)
+	case "h", "help", "?":
+		fmt.Fprintln(d.out, stepDebuggerHelp)
+	default:
+		fmt.Fprintf(d.out, "unknown command %q, type help for the commands\n", name)
+	}
+	return false, nil
+}
+
+// printDiff prints the changes the last node made to the state
+func (d *StepDebugger) printDiff(state *core.BaseState) {
+	if d.lastNode == "" {
+		return
+	}
+	diffs := DiffStates(d.snapshot, state.GetAll())
+	fmt.Fprintf(d.out, "  %s changed %d value(s)\n", d.lastNode, len(diffs))
+	for _, diff := range diffs {
+		switch diff.Kind {
+		case StateAdded:
+			fmt.Fprintf(d.out, "  + %s: %s\n", diff.Key, formatValue(diff.After))
+		case StateRemoved:
+			fmt.Fprintf(d.out, "  - %s: %s\n", diff.Key, formatValue(diff.Before))
+		default:
+			fmt.Fprintf(d.out, "  ~ %s: %s → %s\n", diff.Key, formatValue(diff.Before), formatValue(diff.After))
+		}
+	}
+	d.lastNode = ""
+}
+
+// printEdges lists the edges that may fire after the node
+func (d *StepDebugger) printEdges(step core.NodeStep) {
+	if step.IsEnd || len(step.Edges) == 0 {
+		fmt.Fprintln(d.out, "  no outgoing edges, the execution ends after this node")
+		return
+	}
+	for _, edge := range step.Edges {
+		if edge.Condition != nil {
+			fmt.Fprintf(d.out, "  → %s (conditional)\n", edge.To)
+		} else {
+			fmt.Fprintf(d.out, "  → %s\n", edge.To)
+		}
+	}
+}
+
+// printState prints the whole state or a single value
+func (d *StepDebugger) printState(state *core.BaseState, key string) {
+	if key != "" {
+		value, exists := state.Get(key)
+		if !exists {
+			fmt.Fprintf(d.out, "  %s is not set\n", key)
+			return
+		}
+		fmt.Fprintf(d.out, "  %s = %s\n", key, formatValue(value))
+		return
+	}
+
+	values := state.GetAll()
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		fmt.Fprintln(d.out, "  (empty state)")
+	}
+	for _, k := range keys {
+		fmt.Fprintf(d.out, "  %s = %s\n", k, formatValue(values[k]))
+	}
+}
+
+// formatValue renders a state value as compact JSON, falling back to Go formatting
+func formatValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
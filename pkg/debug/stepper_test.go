@@ -0,0 +1,135 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package debug
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+)
+
+// newCounterGraph creates a graph incrementing a counter and routing on its value
+func newCounterGraph() *core.Graph {
+	graph := core.NewGraph("counter")
+	graph.AddNode("increment", "Increment", func(ctx context.Context, state *core.BaseState) (*core.BaseState, error) {
+		count, _ := state.Get("count")
+		value, _ := count.(float64)
+		state.Set("count", value+1)
+		return state, nil
+	})
+	graph.AddNode("big", "Big", func(ctx context.Context, state *core.BaseState) (*core.BaseState, error) {
+		state.Set("result", "big")
+		return state, nil
+	})
+	graph.AddNode("small", "Small", func(ctx context.Context, state *core.BaseState) (*core.BaseState, error) {
+		state.Set("result", "small")
+		state.Delete("scratch")
+		return state, nil
+	})
+	graph.AddEdge("increment", "big", func(ctx context.Context, state *core.BaseState) (string, error) {
+		count, _ := state.Get("count")
+		if value, _ := count.(float64); value > 10 {
+			return "big", nil
+		}
+		return "small", nil
+	})
+	graph.AddEdge("increment", "small", nil)
+	graph.SetStartNode("increment")
+	graph.AddEndNode("big")
+	graph.AddEndNode("small")
+	return graph
+}
+
+func TestDiffStates(t *testing.T) {
+	before := map[string]core.StateValue{"kept": 1, "changed": []string{"a"}, "removed": true}
+	after := map[string]core.StateValue{"kept": 1, "changed": []string{"a", "b"}, "added": "x"}
+
+	diffs := DiffStates(before, after)
+	if len(diffs) != 3 {
+		t.Fatalf("Expected 3 diffs, got %v", diffs)
+	}
+	expected := []StateDiff{
+		{Key: "added", Kind: StateAdded},
+		{Key: "changed", Kind: StateChanged},
+		{Key: "removed", Kind: StateRemoved},
+	}
+	for i, diff := range diffs {
+		if diff.Key != expected[i].Key || diff.Kind != expected[i].Kind {
+			t.Errorf("Expected diff %d to be %s %s, got %s %s", i, expected[i].Kind, expected[i].Key, diff.Kind, diff.Key)
+		}
+	}
+}
+
+func TestStepDebugger(t *testing.T) {
+	graph := newCounterGraph()
+	graph.Config.Timeout = time.Second
+
+	// Edit the counter before the first node, then step and continue
+	input := strings.NewReader("p\nset count 41\nset scratch temporary note\ns\nc\n")
+	var out bytes.Buffer
+	state := core.NewBaseState()
+	state.Set("count", float64(0))
+
+	result, err := NewStepDebugger(input, &out).Run(context.Background(), graph, state)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if value, _ := result.Get("result"); value != "big" {
+		t.Errorf("Expected the edited counter to route to big, got %v", value)
+	}
+	if value, _ := result.Get("scratch"); value != "temporary note" {
+		t.Errorf("Expected non-JSON values to be set as strings, got %v", value)
+	}
+	if graph.Config.Timeout != time.Second {
+		t.Errorf("Expected the graph timeout to be restored, got %v", graph.Config.Timeout)
+	}
+
+	output := out.String()
+	for _, expected := range []string{
+		"▶ Step 1: next node increment",
+		"→ big (conditional)",
+		"→ small",
+		"count = 0",
+		"~ count: 41 → 42",
+		"▶ Step 2: next node big (end node)",
+		"+ result: \"big\"",
+		"✓ Execution completed",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+	if strings.Count(output, "(debug) ") != 5 {
+		t.Errorf("Expected the debugger to stop prompting after continue, got:\n%s", output)
+	}
+}
+
+func TestStepDebugger_Quit(t *testing.T) {
+	var out bytes.Buffer
+	_, err := NewStepDebugger(strings.NewReader("s\nq\n"), &out).Run(context.Background(), newCounterGraph(), core.NewBaseState())
+	if !errors.Is(err, ErrDebugQuit) {
+		t.Fatalf("Expected ErrDebugQuit, got %v", err)
+	}
+	if !strings.Contains(out.String(), "+ count: 1") {
+		t.Errorf("Expected the diff of the first node, got:\n%s", out.String())
+	}
+
+	// Without input the execution runs to the end
+	out.Reset()
+	result, err := NewStepDebugger(strings.NewReader(""), &out).Run(context.Background(), newCounterGraph(), core.NewBaseState())
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if value, _ := result.Get("result"); value != "small" {
+		t.Errorf("Expected the execution to reach small, got %v", value)
+	}
+}
@@ -9,6 +9,7 @@ package debug
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -73,8 +74,9 @@ type VisualizationSubscriber interface {
 
 // GraphTopology represents the structure of a graph
 type GraphTopology struct {
-	Nodes []NodeInfo `json:"nodes"`
-	Edges []EdgeInfo `json:"edges"`
+	Nodes  []NodeInfo          `json:"nodes"`
+	Edges  []EdgeInfo          `json:"edges"`
+	Groups map[string][]string `json:"groups,omitempty"` // group label -> node IDs
 }
 
 // NodeInfo represents information about a graph node
@@ -82,6 +84,7 @@ type NodeInfo struct {
 	ID          string                 `json:"id"`
 	Name        string                 `json:"name"`
 	Type        string                 `json:"type"`
+	Group       string                 `json:"group,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata"`
 	IsStartNode bool                   `json:"is_start_node"`
 	IsEndNode   bool                   `json:"is_end_node"`
@@ -187,8 +190,9 @@ func (gv *GraphVisualizer) GetExecutionHistory(threadID string) []ExecutionStep
 // GetGraphTopology extracts topology information from a graph
 func (gv *GraphVisualizer) GetGraphTopology(graph *core.Graph) *GraphTopology {
 	topology := &GraphTopology{
-		Nodes: make([]NodeInfo, 0),
-		Edges: make([]EdgeInfo, 0),
+		Nodes:  make([]NodeInfo, 0),
+		Edges:  make([]EdgeInfo, 0),
+		Groups: make(map[string][]string),
 	}
 
 	// Extract nodes
@@ -197,11 +201,20 @@ func (gv *GraphVisualizer) GetGraphTopology(graph *core.Graph) *GraphTopology {
 			ID:          node.ID,
 			Name:        node.Name,
 			Type:        gv.getNodeType(node),
+			Group:       node.Group,
 			Metadata:    node.Metadata,
 			IsStartNode: node.ID == graph.StartNode,
 			IsEndNode:   gv.isEndNode(graph, node.ID),
 		}
 		topology.Nodes = append(topology.Nodes, nodeInfo)
+
+		if node.Group != "" {
+			topology.Groups[node.Group] = append(topology.Groups[node.Group], node.ID)
+		}
+	}
+
+	for group := range topology.Groups {
+		sort.Strings(topology.Groups[group])
 	}
 
 	// Extract edges
@@ -223,20 +236,22 @@ func (gv *GraphVisualizer) GenerateMermaidDiagram(topology *GraphTopology) strin
 	var builder strings.Builder
 	builder.WriteString("graph TD\n")
 
-	// Add nodes
+	// Add ungrouped nodes
 	for _, node := range topology.Nodes {
-		nodeShape := gv.getMermaidNodeShape(node)
-		builder.WriteString(fmt.Sprintf("    %s%s\n", node.ID, nodeShape))
-
-		// Add styling for special nodes
-		if node.IsStartNode {
-			builder.WriteString("    classDef startNode fill:#90EE90\n")
-			builder.WriteString(fmt.Sprintf("    class %s startNode\n", node.ID))
+		if node.Group == "" {
+			gv.writeMermaidNode(&builder, node, "    ")
 		}
-		if node.IsEndNode {
-			builder.WriteString("    classDef endNode fill:#FFB6C1\n")
-			builder.WriteString(fmt.Sprintf("    class %s endNode\n", node.ID))
+	}
+
+	// Add grouped nodes inside subgraphs
+	for i, group := range sortedGroupNames(topology) {
+		builder.WriteString(fmt.Sprintf("    subgraph group%d[\"%s\"]\n", i, group))
+		for _, node := range topology.Nodes {
+			if node.Group == group {
+				gv.writeMermaidNode(&builder, node, "        ")
+			}
 		}
+		builder.WriteString("    end\n")
 	}
 
 	// Add edges
@@ -258,18 +273,23 @@ func (gv *GraphVisualizer) GenerateDotDiagram(topology *GraphTopology) string {
 	builder.WriteString("    rankdir=TD;\n")
 	builder.WriteString("    node [shape=box];\n")
 
-	// Add nodes
+	// Add ungrouped nodes
 	for _, node := range topology.Nodes {
-		attrs := []string{fmt.Sprintf("label=\"%s\"", node.Name)}
-
-		if node.IsStartNode {
-			attrs = append(attrs, "style=filled", "fillcolor=lightgreen")
-		}
-		if node.IsEndNode {
-			attrs = append(attrs, "style=filled", "fillcolor=lightcoral")
+		if node.Group == "" {
+			gv.writeDotNode(&builder, node, "    ")
 		}
+	}
 
-		builder.WriteString(fmt.Sprintf("    %s [%s];\n", node.ID, strings.Join(attrs, ", ")))
+	// Add grouped nodes inside clusters
+	for i, group := range sortedGroupNames(topology) {
+		builder.WriteString(fmt.Sprintf("    subgraph cluster_%d {\n", i))
+		builder.WriteString(fmt.Sprintf("        label=\"%s\";\n", group))
+		for _, node := range topology.Nodes {
+			if node.Group == group {
+				gv.writeDotNode(&builder, node, "        ")
+			}
+		}
+		builder.WriteString("    }\n")
 	}
 
 	// Add edges
@@ -382,6 +402,53 @@ func (gv *GraphVisualizer) getConditionName(edge *core.Edge) string {
 	return "condition"
 }
 
+// writeMermaidNode writes a single Mermaid node declaration with its styling
+func (gv *GraphVisualizer) writeMermaidNode(builder *strings.Builder, node NodeInfo, indent string) {
+	nodeShape := gv.getMermaidNodeShape(node)
+	builder.WriteString(fmt.Sprintf("%s%s%s\n", indent, node.ID, nodeShape))
+
+	// Add styling for special nodes
+	if node.IsStartNode {
+		builder.WriteString(fmt.Sprintf("%sclassDef startNode fill:#90EE90\n", indent))
+		builder.WriteString(fmt.Sprintf("%sclass %s startNode\n", indent, node.ID))
+	}
+	if node.IsEndNode {
+		builder.WriteString(fmt.Sprintf("%sclassDef endNode fill:#FFB6C1\n", indent))
+		builder.WriteString(fmt.Sprintf("%sclass %s endNode\n", indent, node.ID))
+	}
+}
+
+// writeDotNode writes a single DOT node declaration
+func (gv *GraphVisualizer) writeDotNode(builder *strings.Builder, node NodeInfo, indent string) {
+	attrs := []string{fmt.Sprintf("label=\"%s\"", node.Name)}
+
+	if node.IsStartNode {
+		attrs = append(attrs, "style=filled", "fillcolor=lightgreen")
+	}
+	if node.IsEndNode {
+		attrs = append(attrs, "style=filled", "fillcolor=lightcoral")
+	}
+
+	builder.WriteString(fmt.Sprintf("%s%s [%s];\n", indent, node.ID, strings.Join(attrs, ", ")))
+}
+
+// sortedGroupNames returns the distinct group labels of the topology in stable order
+func sortedGroupNames(topology *GraphTopology) []string {
+	seen := make(map[string]bool)
+	for _, node := range topology.Nodes {
+		if node.Group != "" {
+			seen[node.Group] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (gv *GraphVisualizer) getMermaidNodeShape(node NodeInfo) string {
 	switch node.Type {
 	case "start":
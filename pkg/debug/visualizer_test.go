@@ -138,6 +138,34 @@ func TestGraphVisualizer_GenerateDotDiagram(t *testing.T) {
 	}
 }
 
+func TestGraphVisualizer_GroupedDiagrams(t *testing.T) {
+	visualizer := NewGraphVisualizer(nil, nil)
+	graph := createTestGraph()
+	graph.AddNodeToGroup("Post-processing", "node3", "Node 3", testNodeFunction)
+	graph.SetNodeGroup("node2", "Post-processing")
+	graph.AddEdge("node2", "node3", nil)
+
+	topology := visualizer.GetGraphTopology(graph)
+	if len(topology.Groups["Post-processing"]) != 2 {
+		t.Fatalf("Expected 2 nodes in group, got %v", topology.Groups)
+	}
+
+	mermaidOutput := visualizer.GenerateMermaidDiagram(topology)
+	if !strings.Contains(mermaidOutput, "subgraph group0[\"Post-processing\"]") {
+		t.Errorf("Mermaid output should contain group subgraph, got:\n%s", mermaidOutput)
+	}
+	subgraph := mermaidOutput[strings.Index(mermaidOutput, "subgraph"):]
+	subgraph = subgraph[:strings.Index(subgraph, "    end")]
+	if !strings.Contains(subgraph, "node2") || !strings.Contains(subgraph, "node3") || strings.Contains(subgraph, "node1[") {
+		t.Errorf("Mermaid subgraph should contain only grouped nodes, got:\n%s", subgraph)
+	}
+
+	dotOutput := visualizer.GenerateDotDiagram(topology)
+	if !strings.Contains(dotOutput, "subgraph cluster_0 {") || !strings.Contains(dotOutput, "label=\"Post-processing\";") {
+		t.Errorf("DOT output should contain group cluster, got:\n%s", dotOutput)
+	}
+}
+
 func TestGraphVisualizer_RecordStep(t *testing.T) {
 	visualizer := NewGraphVisualizer(nil, nil)
 
@@ -0,0 +1,207 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+// Package eval runs suites of test cases against an agent and scores its answers, so prompt
+// and model changes can be checked for regressions like code.
+//
+// A case gives an input and what a good answer looks like: a reference answer, substrings it
+// must contain or JSON fields it must hold. Scorers grade each answer between 0 and 1 and
+// decide whether it passes; besides the scorers derived from the case, a suite can add its
+// own, such as an LLM-as-judge:
+//
+//	suite, err := eval.LoadSuite("evals/support.yaml")
+//	suite.Scorers = []eval.Scorer{eval.NewLLMJudge(llmManager, "openai", "gpt-4o")}
+//
+//	report, err := eval.Run(ctx, agent, suite)
+//	report.WriteText(os.Stdout)
+//	if !report.OK() {
+//		os.Exit(1)
+//	}
+//
+// For deterministic runs, record the agent's completions once with llm.NewRecordingProvider
+// and replay them with llm.NewReplayProvider.
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/agent"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Case is an input and the properties of a good answer to it
+type Case struct {
+	Name  string `json:"name,omitempty" yaml:"name,omitempty"`
+	Input string `json:"input" yaml:"input"`
+	// Expected is the reference answer. It is matched exactly when nothing else scores the
+	// case, and given to judges otherwise.
+	Expected string `json:"expected,omitempty" yaml:"expected,omitempty"`
+	// Contains lists substrings the answer must contain
+	Contains []string `json:"contains,omitempty" yaml:"contains,omitempty"`
+	// Fields are values the answer, parsed as JSON, must hold, keyed by dotted path
+	Fields map[string]interface{} `json:"fields,omitempty" yaml:"fields,omitempty"`
+	// Criteria tell a judge what to look for in the answer
+	Criteria string   `json:"criteria,omitempty" yaml:"criteria,omitempty"`
+	Tags     []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+
+	// Scorers grade the case in addition to the suite's scorers
+	Scorers []Scorer `json:"-" yaml:"-"`
+}
+
+// scorers returns the scorers grading the case: the suite's, the case's and those checking
+// the case's properties
+func (c Case) scorers(suite []Scorer) []Scorer {
+	scorers := append(append([]Scorer{}, suite...), c.Scorers...)
+	if len(c.Contains) > 0 {
+		scorers = append(scorers, &Contains{})
+	}
+	if len(c.Fields) > 0 {
+		scorers = append(scorers, &JSONFieldMatch{})
+	}
+	if len(scorers) == 0 && c.Expected != "" {
+		scorers = append(scorers, &ExactMatch{})
+	}
+	return scorers
+}
+
+// Suite is a named set of cases
+type Suite struct {
+	Name  string `json:"name" yaml:"name"`
+	Cases []Case `json:"cases" yaml:"cases"`
+
+	// Scorers grade every case
+	Scorers []Scorer `json:"-" yaml:"-"`
+	// Timeout bounds each case; zero means no limit
+	Timeout time.Duration `json:"-" yaml:"-"`
+}
+
+// LoadSuite reads a suite from a YAML or JSON file
+func LoadSuite(filename string) (*Suite, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read eval suite: %w", err)
+	}
+
+	var suite Suite
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(content, &suite); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML eval suite: %w", err)
+		}
+	case ".json":
+		if err := json.Unmarshal(content, &suite); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON eval suite: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported eval suite format: %s", ext)
+	}
+
+	if suite.Name == "" {
+		suite.Name = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	}
+	if err := suite.Validate(); err != nil {
+		return nil, err
+	}
+	return &suite, nil
+}
+
+// Validate checks that the suite has cases and that each has an input
+func (s *Suite) Validate() error {
+	if len(s.Cases) == 0 {
+		return fmt.Errorf("eval suite %q has no cases", s.Name)
+	}
+	for i, c := range s.Cases {
+		if strings.TrimSpace(c.Input) == "" {
+			return fmt.Errorf("eval case %d of suite %q has no input", i+1, s.Name)
+		}
+	}
+	return nil
+}
+
+// Target is what a suite runs against, such as an *agent.Agent
+type Target interface {
+	Execute(ctx context.Context, input string) (*agent.AgentExecution, error)
+}
+
+// conversationClearer is implemented by targets keeping a conversation, which is cleared
+// before each case so cases do not see each other
+type conversationClearer interface {
+	ClearConversation()
+}
+
+// Run runs every case of the suite against the target in order and scores the answers. A case
+// whose execution fails is reported as failed; Run itself only fails for an invalid suite or a
+// cancelled context, returning the cases run so far.
+func Run(ctx context.Context, target Target, suite *Suite) (*Report, error) {
+	if err := suite.Validate(); err != nil {
+		return nil, err
+	}
+
+	report := &Report{Suite: suite.Name, StartedAt: time.Now()}
+	for i, c := range suite.Cases {
+		if err := ctx.Err(); err != nil {
+			report.summarize()
+			return report, err
+		}
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("case-%d", i+1)
+		}
+		report.Cases = append(report.Cases, runCase(ctx, target, suite, c))
+	}
+	report.summarize()
+	return report, nil
+}
+
+// runCase executes a case and scores its answer
+func runCase(ctx context.Context, target Target, suite *Suite, c Case) CaseResult {
+	result := CaseResult{Name: c.Name, Input: c.Input, Tags: c.Tags}
+	if clearer, ok := target.(conversationClearer); ok {
+		clearer.ClearConversation()
+	}
+
+	caseCtx := ctx
+	if suite.Timeout > 0 {
+		var cancel context.CancelFunc
+		caseCtx, cancel = context.WithTimeout(ctx, suite.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	execution, err := target.Execute(caseCtx, c.Input)
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Output = execution.Output
+
+	scorers := c.scorers(suite.Scorers)
+	if len(scorers) == 0 {
+		result.Error = "no scorer applies to the case"
+		return result
+	}
+
+	result.Passed = true
+	var total float64
+	for _, scorer := range scorers {
+		score, err := scorer.Score(ctx, c, result.Output)
+		if err != nil {
+			score = Score{Reason: err.Error()}
+		}
+		score.Scorer = scorer.Name()
+		result.Scores = append(result.Scores, score)
+		result.Passed = result.Passed && score.Passed
+		total += score.Value
+	}
+	result.Score = total / float64(len(scorers))
+	return result
+}
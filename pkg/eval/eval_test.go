@@ -0,0 +1,180 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package eval
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/agent"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
+)
+
+// targetFunc answers cases with a function
+type targetFunc func(ctx context.Context, input string) (*agent.AgentExecution, error)
+
+func (f targetFunc) Execute(ctx context.Context, input string) (*agent.AgentExecution, error) {
+	return f(ctx, input)
+}
+
+// answers returns a target answering inputs from a map
+func answers(outputs map[string]string) Target {
+	return targetFunc(func(ctx context.Context, input string) (*agent.AgentExecution, error) {
+		output, ok := outputs[input]
+		if !ok {
+			return nil, errors.New("model unavailable")
+		}
+		return &agent.AgentExecution{Input: input, Output: output, Success: true}, nil
+	})
+}
+
+// scriptedProvider answers every request with the same content
+type scriptedProvider struct {
+	*llm.GeminiProvider
+	content string
+	calls   int
+}
+
+func (p *scriptedProvider) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	p.calls++
+	return &llm.CompletionResponse{Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: p.content}, FinishReason: "stop"}}}, nil
+}
+
+func (p *scriptedProvider) CompleteWithMode(ctx context.Context, req llm.CompletionRequest, mode llm.StreamMode) (*llm.CompletionResponse, error) {
+	return p.Complete(ctx, req)
+}
+
+func newScriptedProvider(t *testing.T, content string) *scriptedProvider {
+	gemini, err := llm.NewGeminiProvider(&llm.ProviderConfig{APIKey: "test-key", Model: "test-model"}) // pragma: allowlist secret
+	require.NoError(t, err)
+	return &scriptedProvider{GeminiProvider: gemini, content: content}
+}
+
+func TestRun_Scorers(t *testing.T) {
+	suite := &Suite{
+		Name: "support",
+		Cases: []Case{
+			{Name: "capital", Input: "Capital of France?", Expected: "paris"},
+			{Name: "refund", Input: "How do refunds work?", Contains: []string{"30 days", "receipt"}},
+			{Name: "order", Input: "Extract the order", Fields: map[string]interface{}{"id": 42, "items.0.sku": "A-1"}},
+			{Input: "Broken"},
+		},
+	}
+	target := answers(map[string]string{
+		"Capital of France?":   " Paris ",
+		"How do refunds work?": "Return it within 30 days.",
+		"Extract the order":    "```json\n{\"id\": 42, \"items\": [{\"sku\": \"A-1\"}]}\n```",
+	})
+
+	report, err := Run(context.Background(), target, suite)
+	require.NoError(t, err)
+	require.Len(t, report.Cases, 4)
+
+	assert.True(t, report.Cases[0].Passed)
+	assert.Equal(t, 1.0, report.Cases[0].Score)
+
+	refund := report.Cases[1]
+	assert.False(t, refund.Passed)
+	assert.Equal(t, 0.5, refund.Score)
+	assert.Contains(t, refund.Scores[0].Reason, "receipt")
+
+	assert.True(t, report.Cases[2].Passed, "fields: %+v", report.Cases[2].Scores)
+
+	assert.Equal(t, "case-4", report.Cases[3].Name)
+	assert.Equal(t, "model unavailable", report.Cases[3].Error)
+
+	assert.Equal(t, 2, report.Passed)
+	assert.Equal(t, 2, report.Failed)
+	assert.Equal(t, 0.5, report.PassRate)
+	assert.False(t, report.OK())
+	assert.Len(t, report.Failures(), 2)
+
+	var text bytes.Buffer
+	require.NoError(t, report.WriteText(&text))
+	assert.Contains(t, text.String(), "PASS  capital")
+	assert.Contains(t, text.String(), "FAIL  refund")
+	assert.Contains(t, text.String(), "support: 2 passed, 2 failed (50%)")
+}
+
+func TestLLMJudge(t *testing.T) {
+	provider := newScriptedProvider(t, `Sure! {"score": 0.8, "reason": "Correct but terse"}`)
+	llmManager := llm.NewProviderManager()
+	require.NoError(t, llmManager.RegisterProvider("judge", provider))
+
+	judge := NewLLMJudge(llmManager, "judge", "test-model")
+	score, err := judge.Score(context.Background(), Case{Input: "Capital of France?", Expected: "Paris"}, "Paris.")
+	require.NoError(t, err)
+	assert.Equal(t, 0.8, score.Value)
+	assert.True(t, score.Passed)
+	assert.Equal(t, "Correct but terse", score.Reason)
+
+	judge.PassScore = 0.9
+	score, err = judge.Score(context.Background(), Case{Input: "Capital of France?"}, "Paris.")
+	require.NoError(t, err)
+	assert.False(t, score.Passed)
+}
+
+func TestLoadSuite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "greetings.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`cases:
+  - name: hello
+    input: Say hello
+    contains: [hello]
+  - input: Say bye
+    expected: Bye!
+    tags: [smoke]
+`), 0o644))
+
+	suite, err := LoadSuite(path)
+	require.NoError(t, err)
+	assert.Equal(t, "greetings", suite.Name)
+	require.Len(t, suite.Cases, 2)
+	assert.Equal(t, []string{"hello"}, suite.Cases[0].Contains)
+	assert.Equal(t, "Bye!", suite.Cases[1].Expected)
+
+	require.NoError(t, os.WriteFile(path, []byte("name: empty\ncases: []\n"), 0o644))
+	_, err = LoadSuite(path)
+	assert.Error(t, err)
+}
+
+func TestRun_ReplayedAgent(t *testing.T) {
+	suite := &Suite{Name: "replay", Cases: []Case{{Input: "Say hello", Contains: []string{"hello"}}}}
+	newAgent := func(provider llm.Provider) *agent.Agent {
+		llmManager := llm.NewProviderManager()
+		require.NoError(t, llmManager.RegisterProvider("model", provider))
+		return agent.NewAgent(&agent.AgentConfig{
+			Name:     "greeter",
+			Type:     agent.AgentTypeChat,
+			Provider: "model",
+			Model:    "test-model",
+		}, llmManager, tools.NewToolRegistry())
+	}
+
+	// Record the answers of the model once...
+	cassette := llm.NewCassette()
+	live := newScriptedProvider(t, "Well hello!")
+	recorded, err := Run(context.Background(), newAgent(llm.NewRecordingProvider(live, cassette)), suite)
+	require.NoError(t, err)
+	require.True(t, recorded.OK(), "%+v", recorded.Cases)
+
+	// ...and replay them without calling it
+	replayed, err := Run(context.Background(), newAgent(llm.NewReplayProvider("model", cassette)), suite)
+	require.NoError(t, err)
+	assert.True(t, replayed.OK(), "%+v", replayed.Cases)
+	assert.Equal(t, recorded.Cases[0].Output, replayed.Cases[0].Output)
+	assert.Equal(t, 1, live.calls)
+	assert.True(t, strings.Contains(replayed.Cases[0].Output, "hello"))
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package eval
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// CaseResult is the outcome of a case
+type CaseResult struct {
+	Name     string        `json:"name"`
+	Input    string        `json:"input"`
+	Output   string        `json:"output,omitempty"`
+	Tags     []string      `json:"tags,omitempty"`
+	Scores   []Score       `json:"scores,omitempty"`
+	Score    float64       `json:"score"` // Mean of the scores
+	Passed   bool          `json:"passed"`
+	Error    string        `json:"error,omitempty"` // Set when the case could not be run or scored
+	Duration time.Duration `json:"duration"`
+}
+
+// Report is the outcome of a suite run
+type Report struct {
+	Suite     string        `json:"suite"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Cases     []CaseResult  `json:"cases"`
+	Passed    int           `json:"passed"`
+	Failed    int           `json:"failed"`
+	PassRate  float64       `json:"pass_rate"`
+	MeanScore float64       `json:"mean_score"`
+}
+
+// summarize computes the totals of the report from its cases
+func (r *Report) summarize() {
+	r.Duration = time.Since(r.StartedAt)
+	r.Passed, r.Failed, r.PassRate, r.MeanScore = 0, 0, 0, 0
+	for _, result := range r.Cases {
+		if result.Passed {
+			r.Passed++
+		} else {
+			r.Failed++
+		}
+		r.MeanScore += result.Score
+	}
+	if len(r.Cases) > 0 {
+		r.PassRate = float64(r.Passed) / float64(len(r.Cases))
+		r.MeanScore /= float64(len(r.Cases))
+	}
+}
+
+// OK returns true when every case passed
+func (r *Report) OK() bool {
+	return r.Failed == 0
+}
+
+// Failures returns the cases that failed
+func (r *Report) Failures() []CaseResult {
+	var failures []CaseResult
+	for _, result := range r.Cases {
+		if !result.Passed {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// WriteText writes the report as one PASS or FAIL line per case, with the reasons of failed
+// scores, followed by the totals
+func (r *Report) WriteText(w io.Writer) error {
+	for _, result := range r.Cases {
+		status := "PASS"
+		if !result.Passed {
+			status = "FAIL"
+		}
+		if _, err := fmt.Fprintf(w, "%s  %-30s score=%.2f  %s\n", status, result.Name, result.Score, result.Duration.Round(time.Millisecond)); err != nil {
+			return err
+		}
+		if result.Error != "" {
+			if _, err := fmt.Fprintf(w, "      error: %s\n", result.Error); err != nil {
+				return err
+			}
+		}
+		for _, score := range result.Scores {
+			if score.Passed {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "      %s: %.2f %s\n", score.Scorer, score.Value, score.Reason); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "%s: %d passed, %d failed (%.0f%%), mean score %.2f in %s\n",
+		r.Suite, r.Passed, r.Failed, r.PassRate*100, r.MeanScore, r.Duration.Round(time.Millisecond))
+	return err
+}
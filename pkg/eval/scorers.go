@@ -0,0 +1,284 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
+)
+
+// Score is a scorer's grade of an answer
+type Score struct {
+	Scorer string  `json:"scorer"`
+	Value  float64 `json:"value"` // Between 0 and 1
+	Passed bool    `json:"passed"`
+	Reason string  `json:"reason,omitempty"`
+}
+
+// Scorer grades the answer to a case. An error fails the score with the error as reason.
+type Scorer interface {
+	Name() string
+	Score(ctx context.Context, c Case, output string) (Score, error)
+}
+
+// ExactMatch passes answers equal to the case's expected answer, ignoring surrounding space
+// and, unless CaseSensitive, case
+type ExactMatch struct {
+	CaseSensitive bool
+}
+
+// Name returns the scorer name
+func (s *ExactMatch) Name() string {
+	return "exact_match"
+}
+
+// Score compares the answer to the expected answer
+func (s *ExactMatch) Score(ctx context.Context, c Case, output string) (Score, error) {
+	if c.Expected == "" {
+		return Score{}, fmt.Errorf("case has no expected answer")
+	}
+
+	expected, actual := strings.TrimSpace(c.Expected), strings.TrimSpace(output)
+	if expected == actual || (!s.CaseSensitive && strings.EqualFold(expected, actual)) {
+		return Score{Value: 1, Passed: true}, nil
+	}
+	return Score{Reason: fmt.Sprintf("expected %q", expected)}, nil
+}
+
+// Contains passes answers containing every substring, those of the case when Substrings is
+// empty. The value is the share of substrings found.
+type Contains struct {
+	Substrings    []string
+	CaseSensitive bool
+}
+
+// Name returns the scorer name
+func (s *Contains) Name() string {
+	return "contains"
+}
+
+// Score looks for the substrings in the answer
+func (s *Contains) Score(ctx context.Context, c Case, output string) (Score, error) {
+	substrings := s.Substrings
+	if len(substrings) == 0 {
+		substrings = c.Contains
+	}
+	if len(substrings) == 0 {
+		return Score{}, fmt.Errorf("no substrings to look for")
+	}
+
+	if !s.CaseSensitive {
+		output = strings.ToLower(output)
+	}
+	var missing []string
+	for _, substring := range substrings {
+		needle := substring
+		if !s.CaseSensitive {
+			needle = strings.ToLower(needle)
+		}
+		if !strings.Contains(output, needle) {
+			missing = append(missing, substring)
+		}
+	}
+
+	score := Score{
+		Value:  float64(len(substrings)-len(missing)) / float64(len(substrings)),
+		Passed: len(missing) == 0,
+	}
+	if len(missing) > 0 {
+		score.Reason = fmt.Sprintf("missing %q", missing)
+	}
+	return score, nil
+}
+
+// JSONFieldMatch parses the answer as JSON and passes it when it holds every field, those of
+// the case when Fields is empty. Fields are keyed by dotted path, e.g. "address.city" or
+// "items.0.sku". Answers wrapped in code fences or prose are repaired first. The value is the
+// share of fields matched.
+type JSONFieldMatch struct {
+	Fields map[string]interface{}
+}
+
+// Name returns the scorer name
+func (s *JSONFieldMatch) Name() string {
+	return "json_fields"
+}
+
+// Score compares the fields of the answer to the expected values
+func (s *JSONFieldMatch) Score(ctx context.Context, c Case, output string) (Score, error) {
+	fields := s.Fields
+	if len(fields) == 0 {
+		fields = c.Fields
+	}
+	if len(fields) == 0 {
+		return Score{}, fmt.Errorf("no fields to match")
+	}
+
+	repaired, _, err := tools.RepairJSON(output)
+	if err != nil {
+		return Score{Reason: "answer is not JSON"}, nil
+	}
+	var document interface{}
+	if err := json.Unmarshal([]byte(repaired), &document); err != nil {
+		return Score{Reason: "answer is not JSON"}, nil
+	}
+
+	paths := make([]string, 0, len(fields))
+	for path := range fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var mismatches []string
+	for _, path := range paths {
+		expected, err := normalizeJSON(fields[path])
+		if err != nil {
+			return Score{}, fmt.Errorf("invalid expected value for %s: %w", path, err)
+		}
+		actual, found := lookupPath(document, path)
+		switch {
+		case !found:
+			mismatches = append(mismatches, fmt.Sprintf("%s missing", path))
+		case !reflect.DeepEqual(expected, actual):
+			mismatches = append(mismatches, fmt.Sprintf("%s = %v, expected %v", path, actual, expected))
+		}
+	}
+
+	score := Score{
+		Value:  float64(len(paths)-len(mismatches)) / float64(len(paths)),
+		Passed: len(mismatches) == 0,
+	}
+	if len(mismatches) > 0 {
+		score.Reason = strings.Join(mismatches, "; ")
+	}
+	return score, nil
+}
+
+// normalizeJSON converts a value to its form decoded from JSON, so that an expected 3 matches
+// a decoded 3.0
+func normalizeJSON(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	err = json.Unmarshal(data, &normalized)
+	return normalized, err
+}
+
+// lookupPath returns the value at a dotted path of object keys and array indexes
+func lookupPath(document interface{}, path string) (interface{}, bool) {
+	current := document
+	for _, part := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[part]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// DefaultJudgePassScore is the score a judge must give for an answer to pass
+const DefaultJudgePassScore = 0.7
+
+// LLMJudge asks a model to grade the answer between 0 and 1 against the case's criteria and
+// reference answer
+type LLMJudge struct {
+	llmManager *llm.ProviderManager
+	provider   string
+	model      string
+
+	// Criteria apply to every case, before the case's own
+	Criteria string
+	// PassScore is the score an answer needs to pass; defaults to DefaultJudgePassScore
+	PassScore float64
+}
+
+// NewLLMJudge creates a judge grading answers with a provider's model
+func NewLLMJudge(llmManager *llm.ProviderManager, provider, model string) *LLMJudge {
+	return &LLMJudge{llmManager: llmManager, provider: provider, model: model, PassScore: DefaultJudgePassScore}
+}
+
+// Name returns the scorer name
+func (s *LLMJudge) Name() string {
+	return "llm_judge"
+}
+
+// judgeVerdict is the JSON answer the judge is asked for
+type judgeVerdict struct {
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+// Score asks the model to grade the answer
+func (s *LLMJudge) Score(ctx context.Context, c Case, output string) (Score, error) {
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Question:\n%s\n\n", c.Input)
+	if c.Expected != "" {
+		fmt.Fprintf(&prompt, "Reference answer:\n%s\n\n", c.Expected)
+	}
+	if criteria := strings.TrimSpace(s.Criteria + "\n" + c.Criteria); criteria != "" {
+		fmt.Fprintf(&prompt, "Criteria:\n%s\n\n", criteria)
+	}
+	fmt.Fprintf(&prompt, "Answer to grade:\n%s", output)
+
+	resp, err := s.llmManager.Complete(ctx, s.provider, llm.CompletionRequest{
+		Model: s.model,
+		Messages: []llm.Message{
+			{
+				Role: "system",
+				Content: "You grade the answers of an AI assistant. Compare the answer to the reference answer, " +
+					"if given, and check it against the criteria. Reply only with a JSON object " +
+					"{\"score\": <number between 0 and 1>, \"reason\": \"<one sentence>\"}, where 1 is a fully " +
+					"correct answer and 0 a wrong or missing one.",
+			},
+			{Role: "user", Content: prompt.String()},
+		},
+	})
+	if err != nil {
+		return Score{}, err
+	}
+	if len(resp.Choices) == 0 {
+		return Score{}, fmt.Errorf("no response from LLM")
+	}
+
+	repaired, _, err := tools.RepairJSON(resp.Choices[0].Message.Content)
+	if err != nil {
+		return Score{}, fmt.Errorf("judge did not answer with JSON: %w", err)
+	}
+	var verdict judgeVerdict
+	if err := json.Unmarshal([]byte(repaired), &verdict); err != nil {
+		return Score{}, fmt.Errorf("judge did not answer with a verdict: %w", err)
+	}
+
+	value := min(max(verdict.Score, 0), 1)
+	passScore := s.PassScore
+	if passScore <= 0 {
+		passScore = DefaultJudgePassScore
+	}
+	return Score{Value: value, Passed: value >= passScore, Reason: verdict.Reason}, nil
+}
@@ -0,0 +1,297 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BenchmarkTarget is a provider and model to benchmark
+type BenchmarkTarget struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model,omitempty"`
+}
+
+// String returns the target as provider/model
+func (t BenchmarkTarget) String() string {
+	if t.Model == "" {
+		return t.Provider
+	}
+	return t.Provider + "/" + t.Model
+}
+
+// ParseBenchmarkTargets parses a comma-separated list of provider or provider:model entries
+func ParseBenchmarkTargets(spec string) ([]BenchmarkTarget, error) {
+	var targets []BenchmarkTarget
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		provider, model, _ := strings.Cut(entry, ":")
+		if provider == "" {
+			return nil, fmt.Errorf("invalid benchmark target %q", entry)
+		}
+		targets = append(targets, BenchmarkTarget{Provider: provider, Model: model})
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no benchmark targets given")
+	}
+	return targets, nil
+}
+
+// ModelPricing is the price in USD per 1000 tokens
+type ModelPricing struct {
+	PromptPer1K     float64 `json:"prompt_per_1k"`
+	CompletionPer1K float64 `json:"completion_per_1k"`
+	// ReasoningPer1K prices reasoning tokens; they are priced as completion tokens when zero
+	ReasoningPer1K float64 `json:"reasoning_per_1k,omitempty"`
+}
+
+// Cost returns the price of a request with the given usage
+func (p ModelPricing) Cost(usage Usage) float64 {
+	if p.ReasoningPer1K == 0 {
+		return float64(usage.PromptTokens)/1000*p.PromptPer1K + float64(usage.CompletionTokens)/1000*p.CompletionPer1K
+	}
+	return float64(usage.PromptTokens)/1000*p.PromptPer1K +
+		float64(usage.OutputTokens())/1000*p.CompletionPer1K +
+		float64(usage.ReasoningTokens)/1000*p.ReasoningPer1K
+}
+
+// BenchmarkConfig configures a provider benchmark
+type BenchmarkConfig struct {
+	Targets     []BenchmarkTarget `json:"targets"`
+	Prompts     []string          `json:"prompts"`
+	Runs        int               `json:"runs"`
+	MaxTokens   int               `json:"max_tokens"`
+	Temperature float64           `json:"temperature"`
+	Timeout     time.Duration     `json:"timeout"`
+
+	// RequestsPerMinute limits the request rate per provider; zero means no limit
+	RequestsPerMinute int `json:"requests_per_minute"`
+	// RateLimitRetries is how often a request rejected by a rate limit is retried
+	RateLimitRetries int           `json:"rate_limit_retries"`
+	RetryDelay       time.Duration `json:"retry_delay"`
+
+	// Pricing is keyed by provider/model, falling back to provider
+	Pricing map[string]ModelPricing `json:"pricing,omitempty"`
+}
+
+// DefaultBenchmarkConfig returns default benchmark configuration
+func DefaultBenchmarkConfig() *BenchmarkConfig {
+	return &BenchmarkConfig{
+		Runs:             1,
+		MaxTokens:        512,
+		Timeout:          2 * time.Minute,
+		RateLimitRetries: 3,
+		RetryDelay:       2 * time.Second,
+		Pricing:          make(map[string]ModelPricing),
+	}
+}
+
+// BenchmarkSample is the outcome of a single request
+type BenchmarkSample struct {
+	Prompt  string        `json:"prompt"`
+	Output  string        `json:"output,omitempty"`
+	Latency time.Duration `json:"latency"`
+	Usage   Usage         `json:"usage"`
+	Cost    float64       `json:"cost"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// BenchmarkResult aggregates the samples of a target
+type BenchmarkResult struct {
+	Target           BenchmarkTarget   `json:"target"`
+	Requests         int               `json:"requests"`
+	Errors           int               `json:"errors"`
+	LatencyP50       time.Duration     `json:"latency_p50"`
+	LatencyP90       time.Duration     `json:"latency_p90"`
+	LatencyP99       time.Duration     `json:"latency_p99"`
+	LatencyMean      time.Duration     `json:"latency_mean"`
+	PromptTokens     int               `json:"prompt_tokens"`
+	CompletionTokens int               `json:"completion_tokens"`
+	Cost             float64           `json:"cost"`
+	Samples          []BenchmarkSample `json:"samples"`
+}
+
+// RunBenchmark sends every prompt to every target and collects latency, token usage and
+// cost. Requests are sent one at a time and paced per provider to respect rate limits.
+func RunBenchmark(ctx context.Context, pm *ProviderManager, config *BenchmarkConfig) ([]BenchmarkResult, error) {
+	if config == nil {
+		config = DefaultBenchmarkConfig()
+	}
+	if len(config.Targets) == 0 {
+		return nil, fmt.Errorf("no benchmark targets given")
+	}
+	if len(config.Prompts) == 0 {
+		return nil, fmt.Errorf("no benchmark prompts given")
+	}
+	for _, target := range config.Targets {
+		if _, err := pm.GetProvider(target.Provider); err != nil {
+			return nil, err
+		}
+	}
+
+	runs := config.Runs
+	if runs <= 0 {
+		runs = 1
+	}
+	var interval time.Duration
+	if config.RequestsPerMinute > 0 {
+		interval = time.Minute / time.Duration(config.RequestsPerMinute)
+	}
+	lastRequest := make(map[string]time.Time)
+
+	results := make([]BenchmarkResult, 0, len(config.Targets))
+	for _, target := range config.Targets {
+		result := BenchmarkResult{Target: target}
+		pricing := config.pricing(target)
+
+		for run := 0; run < runs; run++ {
+			for _, prompt := range config.Prompts {
+				if interval > 0 {
+					if err := sleepContext(ctx, time.Until(lastRequest[target.Provider].Add(interval))); err != nil {
+						return results, err
+					}
+				}
+				lastRequest[target.Provider] = time.Now()
+
+				sample := runBenchmarkSample(ctx, pm, config, target, prompt)
+				sample.Cost = pricing.Cost(sample.Usage)
+				result.Samples = append(result.Samples, sample)
+
+				if err := ctx.Err(); err != nil {
+					return append(results, summarizeBenchmark(result)), err
+				}
+			}
+		}
+		results = append(results, summarizeBenchmark(result))
+	}
+	return results, nil
+}
+
+// runBenchmarkSample sends a prompt, retrying with backoff when the provider is rate limited
+func runBenchmarkSample(ctx context.Context, pm *ProviderManager, config *BenchmarkConfig, target BenchmarkTarget, prompt string) BenchmarkSample {
+	sample := BenchmarkSample{Prompt: prompt}
+	req := CompletionRequest{
+		Model:       target.Model,
+		Messages:    []Message{{Role: "user", Content: prompt}},
+		MaxTokens:   config.MaxTokens,
+		Temperature: config.Temperature,
+	}
+
+	delay := config.RetryDelay
+	for attempt := 0; ; attempt++ {
+		reqCtx := ctx
+		cancel := func() {}
+		if config.Timeout > 0 {
+			reqCtx, cancel = context.WithTimeout(ctx, config.Timeout)
+		}
+		start := time.Now()
+		resp, err := pm.Complete(reqCtx, target.Provider, req)
+		sample.Latency = time.Since(start)
+		cancel()
+
+		if err == nil {
+			sample.Usage = resp.Usage
+			if len(resp.Choices) > 0 {
+				sample.Output = resp.Choices[0].Message.Content
+			}
+			if sample.Usage.TotalTokens == 0 {
+				sample.Usage = estimateUsage(req, sample.Output)
+			}
+			sample.Error = ""
+			return sample
+		}
+
+		sample.Error = err.Error()
+		if attempt >= config.RateLimitRetries || !IsRateLimitError(err) {
+			return sample
+		}
+		if sleepContext(ctx, delay) != nil {
+			return sample
+		}
+		delay *= 2
+	}
+}
+
+// summarizeBenchmark computes the aggregate statistics of a result
+func summarizeBenchmark(result BenchmarkResult) BenchmarkResult {
+	var latencies []time.Duration
+	var total time.Duration
+	for _, sample := range result.Samples {
+		result.Requests++
+		if sample.Error != "" {
+			result.Errors++
+			continue
+		}
+		latencies = append(latencies, sample.Latency)
+		total += sample.Latency
+		result.PromptTokens += sample.Usage.PromptTokens
+		result.CompletionTokens += sample.Usage.CompletionTokens
+		result.Cost += sample.Cost
+	}
+	if len(latencies) == 0 {
+		return result
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	result.LatencyP50 = percentile(latencies, 50)
+	result.LatencyP90 = percentile(latencies, 90)
+	result.LatencyP99 = percentile(latencies, 99)
+	result.LatencyMean = total / time.Duration(len(latencies))
+	return result
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// pricing returns the pricing of a target
+func (c *BenchmarkConfig) pricing(target BenchmarkTarget) ModelPricing {
+	if pricing, exists := c.Pricing[target.String()]; exists {
+		return pricing
+	}
+	return c.Pricing[target.Provider]
+}
+
+// estimateUsage estimates token usage for providers that do not report it
+func estimateUsage(req CompletionRequest, output string) Usage {
+	counter := NewSimpleTokenCounter()
+	promptTokens, _ := counter.CountMessagesTokens(req.Messages)
+	completionTokens, _ := counter.CountTokens(output)
+	return Usage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+	}
+}
+
+// sleepContext waits for the duration or until the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rateLimitedProvider rejects its first requests with a rate limit error
+type rateLimitedProvider struct {
+	*GeminiProvider
+	rejections int
+	calls      int
+}
+
+func (p *rateLimitedProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	p.calls++
+	if p.calls <= p.rejections {
+		return nil, errors.New("status 429: too many requests")
+	}
+	return &CompletionResponse{
+		Choices: []Choice{{Message: Message{Role: "assistant", Content: "answer to " + req.Messages[0].Content}}},
+		Usage:   Usage{PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500},
+	}, nil
+}
+
+func TestParseBenchmarkTargets(t *testing.T) {
+	targets, err := ParseBenchmarkTargets("ollama, openai:gpt-4o-mini")
+	require.NoError(t, err)
+	assert.Equal(t, []BenchmarkTarget{{Provider: "ollama"}, {Provider: "openai", Model: "gpt-4o-mini"}}, targets)
+	assert.Equal(t, "openai/gpt-4o-mini", targets[1].String())
+
+	_, err = ParseBenchmarkTargets(" , ")
+	assert.Error(t, err)
+}
+
+func TestRunBenchmark(t *testing.T) {
+	gemini, err := NewGeminiProvider(&ProviderConfig{APIKey: "test-key", Model: "test-model"}) // pragma: allowlist secret
+	require.NoError(t, err)
+	provider := &rateLimitedProvider{GeminiProvider: gemini, rejections: 1}
+
+	pm := NewProviderManager()
+	require.NoError(t, pm.RegisterProvider("limited", provider))
+
+	config := DefaultBenchmarkConfig()
+	config.Targets = []BenchmarkTarget{{Provider: "limited", Model: "test-model"}}
+	config.Prompts = []string{"one", "two"}
+	config.Runs = 2
+	config.RetryDelay = time.Millisecond
+	config.Pricing["limited/test-model"] = ModelPricing{PromptPer1K: 0.5, CompletionPer1K: 2}
+
+	results, err := RunBenchmark(context.Background(), pm, config)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	result := results[0]
+	assert.Equal(t, 4, result.Requests)
+	assert.Equal(t, 0, result.Errors)
+	assert.Equal(t, 5, provider.calls, "the rate-limited request should be retried")
+	assert.Equal(t, 4000, result.PromptTokens)
+	assert.Equal(t, 2000, result.CompletionTokens)
+	assert.InDelta(t, 6.0, result.Cost, 1e-9)
+	assert.Equal(t, "answer to two", result.Samples[1].Output)
+	assert.LessOrEqual(t, result.LatencyP50, result.LatencyP99)
+
+	_, err = RunBenchmark(context.Background(), pm, &BenchmarkConfig{Targets: []BenchmarkTarget{{Provider: "missing"}}, Prompts: []string{"x"}})
+	assert.Error(t, err)
+}
+
+func TestPercentile(t *testing.T) {
+	latencies := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	assert.Equal(t, time.Duration(5), percentile(latencies, 50))
+	assert.Equal(t, time.Duration(9), percentile(latencies, 90))
+	assert.Equal(t, time.Duration(10), percentile(latencies, 99))
+}
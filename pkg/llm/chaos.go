@@ -0,0 +1,255 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrChaosInjected is the error injected by chaos wrappers when ChaosConfig.Err is not set
+var ErrChaosInjected = errors.New("chaos: injected failure")
+
+// ErrChaosTruncated ends a stream cut short by a chaos wrapper
+var ErrChaosTruncated = errors.New("chaos: stream truncated")
+
+// ChaosFault is a failure injected into a call
+type ChaosFault string
+
+const (
+	// ChaosFaultNone lets the call through unchanged
+	ChaosFaultNone ChaosFault = ""
+	// ChaosFaultError fails the call
+	ChaosFaultError ChaosFault = "error"
+	// ChaosFaultTruncate cuts the output short
+	ChaosFaultTruncate ChaosFault = "truncate"
+	// ChaosFaultEmpty replaces the output with an empty one
+	ChaosFaultEmpty ChaosFault = "empty"
+	// ChaosFaultLatency delays the call; it combines with the other faults
+	ChaosFaultLatency ChaosFault = "latency"
+)
+
+// ChaosConfig configures the failures injected by chaos wrappers. Rates are probabilities
+// between 0 and 1. At most one of the error, truncate and empty faults is injected per call,
+// so their rates should not add up to more than 1.
+type ChaosConfig struct {
+	// Seed makes the injected failures reproducible; the same seed and the same sequence of
+	// calls inject the same failures
+	Seed int64
+
+	ErrorRate float64
+	// Err is the injected error; defaults to ErrChaosInjected
+	Err error
+
+	LatencyRate float64
+	Latency     time.Duration
+
+	TruncateRate float64
+	// TruncateAfter is the number of chunks a truncated stream delivers before failing
+	TruncateAfter int
+
+	EmptyRate float64
+}
+
+// ChaosInjector decides which failures to inject, from a seeded random source. It is safe
+// for concurrent use, though concurrent calls make the order of decisions, and so the
+// failures of each call, depend on scheduling.
+type ChaosInjector struct {
+	config ChaosConfig
+	rng    *rand.Rand
+	counts map[ChaosFault]int
+	mu     sync.Mutex
+}
+
+// NewChaosInjector creates an injector for a configuration
+func NewChaosInjector(config ChaosConfig) *ChaosInjector {
+	return &ChaosInjector{
+		config: config,
+		rng:    rand.New(rand.NewSource(config.Seed)),
+		counts: make(map[ChaosFault]int),
+	}
+}
+
+// Config returns the injector's configuration
+func (c *ChaosInjector) Config() ChaosConfig {
+	return c.config
+}
+
+// Inject decides the fault of the next call, first sleeping for the configured latency when
+// latency is injected. It returns the context's error if the context ends during the delay.
+func (c *ChaosInjector) Inject(ctx context.Context) (ChaosFault, error) {
+	c.mu.Lock()
+	// Always draw both numbers so the sequence of decisions only depends on the seed
+	delay := c.rng.Float64() < c.config.LatencyRate && c.config.Latency > 0
+	roll := c.rng.Float64()
+	fault := ChaosFaultNone
+	switch {
+	case roll < c.config.ErrorRate:
+		fault = ChaosFaultError
+	case roll < c.config.ErrorRate+c.config.TruncateRate:
+		fault = ChaosFaultTruncate
+	case roll < c.config.ErrorRate+c.config.TruncateRate+c.config.EmptyRate:
+		fault = ChaosFaultEmpty
+	}
+	if delay {
+		c.counts[ChaosFaultLatency]++
+	}
+	if fault != ChaosFaultNone {
+		c.counts[fault]++
+	}
+	c.mu.Unlock()
+
+	if delay {
+		timer := time.NewTimer(c.config.Latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ChaosFaultNone, ctx.Err()
+		}
+	}
+	return fault, nil
+}
+
+// Err returns the injected error
+func (c *ChaosInjector) Err() error {
+	if c.config.Err != nil {
+		return c.config.Err
+	}
+	return ErrChaosInjected
+}
+
+// Counts returns the number of times each fault was injected
+func (c *ChaosInjector) Counts() map[ChaosFault]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := make(map[ChaosFault]int, len(c.counts))
+	for fault, count := range c.counts {
+		counts[fault] = count
+	}
+	return counts
+}
+
+// ChaosProvider wraps a provider to inject failures into its completions, for testing that
+// retries, fallbacks and error handling work. It is not meant for production use.
+type ChaosProvider struct {
+	Provider
+	injector *ChaosInjector
+}
+
+// NewChaosProvider wraps a provider with the failures of a configuration
+func NewChaosProvider(provider Provider, config ChaosConfig) *ChaosProvider {
+	return &ChaosProvider{Provider: provider, injector: NewChaosInjector(config)}
+}
+
+// Injector returns the provider's injector, to inspect the injected failures
+func (p *ChaosProvider) Injector() *ChaosInjector {
+	return p.injector
+}
+
+// Complete generates a completion, possibly failed, truncated or emptied
+func (p *ChaosProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	return p.complete(ctx, func() (*CompletionResponse, error) {
+		return p.Provider.Complete(ctx, req)
+	})
+}
+
+// CompleteWithMode generates a completion with explicit streaming mode, possibly failed,
+// truncated or emptied
+func (p *ChaosProvider) CompleteWithMode(ctx context.Context, req CompletionRequest, mode StreamMode) (*CompletionResponse, error) {
+	return p.complete(ctx, func() (*CompletionResponse, error) {
+		return p.Provider.CompleteWithMode(ctx, req, mode)
+	})
+}
+
+// CompleteStream generates a streaming completion, possibly failed, truncated or emptied
+func (p *ChaosProvider) CompleteStream(ctx context.Context, req CompletionRequest, callback StreamCallback) error {
+	return p.stream(ctx, callback, func(callback StreamCallback) error {
+		return p.Provider.CompleteStream(ctx, req, callback)
+	})
+}
+
+// CompleteStreamWithMode generates a streaming completion with explicit mode, possibly
+// failed, truncated or emptied
+func (p *ChaosProvider) CompleteStreamWithMode(ctx context.Context, req CompletionRequest, callback StreamCallback, mode StreamMode) error {
+	return p.stream(ctx, callback, func(callback StreamCallback) error {
+		return p.Provider.CompleteStreamWithMode(ctx, req, callback, mode)
+	})
+}
+
+// complete applies the next fault to a completion
+func (p *ChaosProvider) complete(ctx context.Context, complete func() (*CompletionResponse, error)) (*CompletionResponse, error) {
+	fault, err := p.injector.Inject(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch fault {
+	case ChaosFaultError:
+		return nil, p.injector.Err()
+	case ChaosFaultEmpty:
+		return emptyChaosResponse(), nil
+	}
+
+	resp, err := complete()
+	if err != nil || fault != ChaosFaultTruncate {
+		return resp, err
+	}
+	// Cut the answer in half, as a response stopped by the token limit
+	for i := range resp.Choices {
+		content := []rune(resp.Choices[i].Message.Content)
+		resp.Choices[i].Message.Content = string(content[:len(content)/2])
+		resp.Choices[i].Message.ToolCalls = nil
+		resp.Choices[i].FinishReason = "length"
+	}
+	return resp, nil
+}
+
+// stream applies the next fault to a stream
+func (p *ChaosProvider) stream(ctx context.Context, callback StreamCallback, stream func(StreamCallback) error) error {
+	fault, err := p.injector.Inject(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch fault {
+	case ChaosFaultError:
+		return p.injector.Err()
+	case ChaosFaultEmpty:
+		return callback(*emptyChaosResponse())
+	case ChaosFaultTruncate:
+		delivered := 0
+		limit := p.injector.Config().TruncateAfter
+		err := stream(func(chunk CompletionResponse) error {
+			if delivered >= limit {
+				return ErrChaosTruncated
+			}
+			delivered++
+			return callback(chunk)
+		})
+		if errors.Is(err, ErrChaosTruncated) {
+			return fmt.Errorf("stream failed after %d chunks: %w", delivered, ErrChaosTruncated)
+		}
+		return err
+	}
+	return stream(callback)
+}
+
+// emptyChaosResponse returns a response without content or tool calls
+func emptyChaosResponse() *CompletionResponse {
+	return &CompletionResponse{
+		ID:      fmt.Sprintf("chaos-%d", time.Now().UnixNano()),
+		Object:  "text_completion",
+		Created: time.Now().Unix(),
+		Choices: []Choice{{Message: Message{Role: "assistant"}, FinishReason: "stop"}},
+	}
+}
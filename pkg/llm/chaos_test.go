@@ -0,0 +1,80 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newChaosProvider(t *testing.T, config ChaosConfig) *ChaosProvider {
+	gemini, err := NewGeminiProvider(&ProviderConfig{APIKey: "test-key", Model: "test-model"}) // pragma: allowlist secret
+	require.NoError(t, err)
+	return NewChaosProvider(&echoProvider{GeminiProvider: gemini}, config)
+}
+
+func TestChaosProvider_Deterministic(t *testing.T) {
+	config := ChaosConfig{Seed: 42, ErrorRate: 0.3, TruncateRate: 0.2, EmptyRate: 0.2}
+	outcomes := func() []string {
+		provider := newChaosProvider(t, config)
+		var results []string
+		for i := 0; i < 50; i++ {
+			resp, err := provider.Complete(context.Background(), CompletionRequest{Messages: []Message{{Role: "user", Content: "hello world"}}})
+			if err != nil {
+				results = append(results, err.Error())
+				continue
+			}
+			results = append(results, resp.Choices[0].Message.Content)
+		}
+		return results
+	}
+
+	first := outcomes()
+	assert.Equal(t, first, outcomes(), "the same seed should inject the same failures")
+	assert.Contains(t, first, ErrChaosInjected.Error())
+	assert.Contains(t, first, "hello")
+	assert.Contains(t, first, "")
+	assert.Contains(t, first, "hello world")
+}
+
+func TestChaosProvider_Faults(t *testing.T) {
+	request := CompletionRequest{Messages: []Message{{Role: "user", Content: "one two three four"}}}
+	custom := errors.New("service unavailable")
+
+	provider := newChaosProvider(t, ChaosConfig{ErrorRate: 1, Err: custom})
+	_, err := provider.Complete(context.Background(), request)
+	assert.ErrorIs(t, err, custom)
+	assert.Equal(t, 1, provider.Injector().Counts()[ChaosFaultError])
+
+	// A truncated stream delivers some chunks, then fails
+	provider = newChaosProvider(t, ChaosConfig{TruncateRate: 1, TruncateAfter: 2})
+	var chunks []string
+	err = provider.CompleteStream(context.Background(), request, func(chunk CompletionResponse) error {
+		chunks = append(chunks, chunk.Choices[0].Delta.Content)
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrChaosTruncated)
+	assert.Equal(t, []string{"one", "two"}, chunks)
+
+	provider = newChaosProvider(t, ChaosConfig{EmptyRate: 1})
+	resp, err := provider.Complete(context.Background(), request)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Choices[0].Message.Content)
+
+	// Latency is cut short by the context
+	provider = newChaosProvider(t, ChaosConfig{LatencyRate: 1, Latency: time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = provider.Complete(ctx, request)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, provider.Injector().Counts()[ChaosFaultLatency])
+}
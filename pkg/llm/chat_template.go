@@ -0,0 +1,200 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Built-in chat template names
+const (
+	ChatTemplateLlama3 = "llama3"
+	ChatTemplateChatML = "chatml"
+	ChatTemplateGemma  = "gemma"
+	// ChatTemplateAuto selects the built-in template of the model's family from its name
+	ChatTemplateAuto = "auto"
+)
+
+// ChatTemplate formats messages into the raw prompt a model was trained on, for providers
+// calling a raw completion endpoint instead of a chat endpoint. Templates are Go templates
+// executed with a ChatTemplateData.
+type ChatTemplate struct {
+	Name string
+	// Stop holds the end-of-turn markers, sent as stop sequences so the model does not
+	// write the next turn
+	Stop     []string
+	template *template.Template
+}
+
+// ChatTemplateData is the data a chat template is executed with
+type ChatTemplateData struct {
+	// System joins the system messages, for templates placing them in a turn of their own
+	// or in the first user turn
+	System string
+	// Messages are the user, assistant and tool messages in order
+	Messages []Message
+}
+
+// NewChatTemplate parses a custom chat template. The template must end with the opening of
+// the assistant turn, so the model continues with its answer.
+func NewChatTemplate(name, text string, stop ...string) (*ChatTemplate, error) {
+	parsed, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chat template %s: %w", name, err)
+	}
+	return &ChatTemplate{Name: name, Stop: stop, template: parsed}, nil
+}
+
+// Format renders messages into a prompt, gathering the system messages into
+// ChatTemplateData.System
+func (t *ChatTemplate) Format(messages []Message) (string, error) {
+	var data ChatTemplateData
+	var system []string
+	for _, message := range messages {
+		if message.Role == "system" {
+			system = append(system, message.Content)
+			continue
+		}
+		data.Messages = append(data.Messages, message)
+	}
+	data.System = strings.Join(system, "\n\n")
+
+	var prompt strings.Builder
+	if err := t.template.Execute(&prompt, data); err != nil {
+		return "", fmt.Errorf("failed to format messages with chat template %s: %w", t.Name, err)
+	}
+	return prompt.String(), nil
+}
+
+// The beginning-of-text token is left out of the built-in templates, since the model's
+// tokenizer adds it
+var builtinChatTemplates = map[string]struct {
+	text string
+	stop []string
+}{
+	ChatTemplateLlama3: {
+		text: `{{if .System}}<|start_header_id|>system<|end_header_id|>
+
+{{.System}}<|eot_id|>{{end}}{{range .Messages}}<|start_header_id|>{{if eq .Role "tool"}}ipython{{else}}{{.Role}}{{end}}<|end_header_id|>
+
+{{.Content}}<|eot_id|>{{end}}<|start_header_id|>assistant<|end_header_id|>
+
+`,
+		stop: []string{"<|eot_id|>"},
+	},
+	ChatTemplateChatML: {
+		text: `{{if .System}}<|im_start|>system
+{{.System}}<|im_end|>
+{{end}}{{range .Messages}}<|im_start|>{{.Role}}
+{{.Content}}<|im_end|>
+{{end}}<|im_start|>assistant
+`,
+		stop: []string{"<|im_end|>"},
+	},
+	// Gemma has no system role: the system prompt opens the first user turn
+	ChatTemplateGemma: {
+		text: `{{range $i, $m := .Messages}}<start_of_turn>{{if eq $m.Role "assistant"}}model{{else}}user{{end}}
+{{if and (eq $i 0) $.System}}{{$.System}}
+
+{{end}}{{$m.Content}}<end_of_turn>
+{{end}}<start_of_turn>model
+`,
+		stop: []string{"<end_of_turn>"},
+	},
+}
+
+// chatTemplateFamilies map fragments of model names to the template of their family, checked
+// in order
+var chatTemplateFamilies = []struct {
+	fragment string
+	template string
+}{
+	{"llama3", ChatTemplateLlama3},
+	{"llama-3", ChatTemplateLlama3},
+	{"gemma", ChatTemplateGemma},
+	{"qwen", ChatTemplateChatML},
+	{"hermes", ChatTemplateChatML},
+	{"dolphin", ChatTemplateChatML},
+	{"chatml", ChatTemplateChatML},
+}
+
+var (
+	chatTemplates   = make(map[string]*ChatTemplate)
+	chatTemplatesMu sync.RWMutex
+)
+
+func init() {
+	for name, builtin := range builtinChatTemplates {
+		chatTemplates[name] = &ChatTemplate{
+			Name:     name,
+			Stop:     builtin.stop,
+			template: template.Must(template.New(name).Parse(builtin.text)),
+		}
+	}
+}
+
+// RegisterChatTemplate makes a template selectable by name in ProviderConfig.ChatTemplates,
+// replacing a built-in template of the same name
+func RegisterChatTemplate(chatTemplate *ChatTemplate) {
+	chatTemplatesMu.Lock()
+	defer chatTemplatesMu.Unlock()
+	chatTemplates[chatTemplate.Name] = chatTemplate
+}
+
+// GetChatTemplate returns a registered or built-in template by name
+func GetChatTemplate(name string) (*ChatTemplate, bool) {
+	chatTemplatesMu.RLock()
+	defer chatTemplatesMu.RUnlock()
+	chatTemplate, exists := chatTemplates[name]
+	return chatTemplate, exists
+}
+
+// ChatTemplateForModel returns the built-in template of a model's family, detected from its
+// name, e.g. "llama3.1:8b" or "qwen2.5:7b"
+func ChatTemplateForModel(model string) (*ChatTemplate, bool) {
+	name := strings.ToLower(model)
+	for _, family := range chatTemplateFamilies {
+		if strings.Contains(name, family.fragment) {
+			return GetChatTemplate(family.template)
+		}
+	}
+	return nil, false
+}
+
+// resolveChatTemplate returns the template configured for a model in ProviderConfig.ChatTemplates:
+// the entry of the model, of its name without the tag, or "*". It returns nil when no entry
+// matches, or when the entry is ChatTemplateAuto and the model's family is unknown.
+func resolveChatTemplate(config *ProviderConfig, model string) (*ChatTemplate, error) {
+	if len(config.ChatTemplates) == 0 {
+		return nil, nil
+	}
+	base, _, _ := strings.Cut(model, ":")
+	var name string
+	for _, key := range []string{model, base, "*"} {
+		if configured, exists := config.ChatTemplates[key]; exists {
+			name = configured
+			break
+		}
+	}
+
+	switch name {
+	case "":
+		return nil, nil
+	case ChatTemplateAuto:
+		chatTemplate, _ := ChatTemplateForModel(model)
+		return chatTemplate, nil
+	default:
+		chatTemplate, exists := GetChatTemplate(name)
+		if !exists {
+			return nil, fmt.Errorf("unknown chat template %s for model %s", name, model)
+		}
+		return chatTemplate, nil
+	}
+}
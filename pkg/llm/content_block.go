@@ -0,0 +1,180 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+)
+
+// ErrContentBlocked is returned when a provider's content filter blocked the request or
+// the response
+var ErrContentBlocked = errors.New("content blocked by provider")
+
+// Stages at which a provider blocks content
+const (
+	ContentBlockedPrompt   = "prompt"
+	ContentBlockedResponse = "response"
+)
+
+// ContentBlockedError is the common form of the block signals of providers: a finish reason
+// such as OpenAI's "content_filter" or Gemini's "SAFETY", or an error refusing the prompt
+type ContentBlockedError struct {
+	Provider string
+	Model    string
+	// Stage is ContentBlockedPrompt or ContentBlockedResponse
+	Stage string
+	// Reason is the provider's finish reason or error message
+	Reason string
+	// Categories are the filter categories that triggered, e.g. "hate" or "violence", when
+	// the provider reports them
+	Categories []string
+	// Err is the provider error the block was recognized in, if any
+	Err error
+}
+
+// Error implements the error interface
+func (e *ContentBlockedError) Error() string {
+	message := fmt.Sprintf("%v: provider %s blocked the %s", ErrContentBlocked, e.Provider, e.stage())
+	if e.Reason != "" {
+		message += fmt.Sprintf(" (%s)", e.Reason)
+	}
+	if len(e.Categories) > 0 {
+		message += fmt.Sprintf(", categories: %s", strings.Join(e.Categories, ", "))
+	}
+	return message
+}
+
+func (e *ContentBlockedError) stage() string {
+	if e.Stage == "" {
+		return ContentBlockedResponse
+	}
+	return e.Stage
+}
+
+// Unwrap returns ErrContentBlocked and the provider error
+func (e *ContentBlockedError) Unwrap() []error {
+	if e.Err != nil {
+		return []error{ErrContentBlocked, e.Err}
+	}
+	return []error{ErrContentBlocked}
+}
+
+// Is matches core.ErrPermanent: the same request would be blocked again, so the graph does
+// not retry the node
+func (e *ContentBlockedError) Is(target error) bool {
+	return target == core.ErrPermanent
+}
+
+// contentBlockFinishReasons are the finish reasons providers report when a safety or
+// content filter suppressed the answer, compared case-insensitively
+var contentBlockFinishReasons = map[string]bool{
+	"content_filter":       true, // OpenAI, Azure OpenAI
+	"safety":               true, // Gemini
+	"recitation":           true, // Gemini
+	"blocklist":            true, // Gemini
+	"prohibited_content":   true, // Gemini
+	"spii":                 true, // Gemini
+	"refusal":              true, // Anthropic
+	"guardrail_intervened": true, // Bedrock
+}
+
+// IsContentBlockFinishReason reports whether a finish reason means the provider blocked the
+// answer
+func IsContentBlockFinishReason(reason string) bool {
+	return contentBlockFinishReasons[strings.ToLower(reason)]
+}
+
+// contentBlockPatterns are error fragments used by common providers when they refuse a prompt
+var contentBlockPatterns = []string{
+	"content_filter",
+	"content_policy_violation",
+	"content management policy",
+	"responsible ai",
+	"prompt was blocked",
+	"blocked by safety",
+	"blockreason",
+}
+
+// IsContentBlockError reports whether an error is, or reads like, a provider refusing
+// content
+func IsContentBlockError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrContentBlocked) {
+		return true
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, pattern := range contentBlockPatterns {
+		if strings.Contains(message, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeContentBlock turns the block signals of a completion into a ContentBlockedError
+func normalizeContentBlock(providerName, model string, resp *CompletionResponse, err error) (*CompletionResponse, error) {
+	if err != nil {
+		return nil, contentBlockError(providerName, model, err)
+	}
+	if resp != nil && len(resp.Choices) > 0 && IsContentBlockFinishReason(resp.Choices[0].FinishReason) {
+		return nil, &ContentBlockedError{
+			Provider: providerName,
+			Model:    model,
+			Stage:    ContentBlockedResponse,
+			Reason:   resp.Choices[0].FinishReason,
+		}
+	}
+	return resp, nil
+}
+
+// contentBlockError returns the ContentBlockedError of a provider error refusing content,
+// with the provider and model filled in, or the error unchanged
+func contentBlockError(providerName, model string, err error) error {
+	var blocked *ContentBlockedError
+	if errors.As(err, &blocked) {
+		if blocked.Provider == "" {
+			blocked.Provider = providerName
+		}
+		if blocked.Model == "" {
+			blocked.Model = model
+		}
+		return err
+	}
+	if !IsContentBlockError(err) {
+		return err
+	}
+	return &ContentBlockedError{
+		Provider: providerName,
+		Model:    model,
+		Stage:    ContentBlockedPrompt,
+		Reason:   err.Error(),
+		Err:      err,
+	}
+}
+
+// blockingStream wraps a stream callback so a chunk with a block finish reason ends the
+// stream with a ContentBlockedError instead of being delivered
+func blockingStream(providerName, model string, callback StreamCallback) StreamCallback {
+	return func(chunk CompletionResponse) error {
+		if len(chunk.Choices) > 0 && IsContentBlockFinishReason(chunk.Choices[0].FinishReason) {
+			return &ContentBlockedError{
+				Provider: providerName,
+				Model:    model,
+				Stage:    ContentBlockedResponse,
+				Reason:   chunk.Choices[0].FinishReason,
+			}
+		}
+		return callback(chunk)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+)
+
+// filteringProvider signals blocks the way a provider does: a finish reason or an error
+type filteringProvider struct {
+	*GeminiProvider
+	finishReason string
+	err          error
+}
+
+func (p *filteringProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &CompletionResponse{Choices: []Choice{{Message: Message{Role: "assistant", Content: "partial"}, FinishReason: p.finishReason}}}, nil
+}
+
+func (p *filteringProvider) CompleteStream(ctx context.Context, req CompletionRequest, callback StreamCallback) error {
+	if err := callback(CompletionResponse{Choices: []Choice{{Delta: Message{Content: "par"}}}}); err != nil {
+		return err
+	}
+	return callback(CompletionResponse{Choices: []Choice{{Delta: Message{Content: "tial"}, FinishReason: p.finishReason}}})
+}
+
+func TestProviderManager_ContentBlocked(t *testing.T) {
+	gemini, err := NewGeminiProvider(&ProviderConfig{APIKey: "test-key", Model: "test-model"}) // pragma: allowlist secret
+	require.NoError(t, err)
+	provider := &filteringProvider{GeminiProvider: gemini}
+	pm := NewProviderManager()
+	require.NoError(t, pm.RegisterProvider("filtered", provider))
+	req := CompletionRequest{Model: "test-model", Messages: []Message{{Role: "user", Content: "Hi"}}}
+
+	// Block finish reasons become a ContentBlockedError
+	provider.finishReason = "SAFETY"
+	_, err = pm.Complete(context.Background(), "filtered", req)
+	var blocked *ContentBlockedError
+	require.ErrorAs(t, err, &blocked)
+	assert.Equal(t, "filtered", blocked.Provider)
+	assert.Equal(t, ContentBlockedResponse, blocked.Stage)
+	assert.Equal(t, "SAFETY", blocked.Reason)
+	assert.ErrorIs(t, err, ErrContentBlocked)
+	assert.ErrorIs(t, err, core.ErrPermanent)
+
+	// So does the chunk of a stream carrying one
+	var streamed string
+	err = pm.CompleteStream(context.Background(), "filtered", req, func(chunk CompletionResponse) error {
+		streamed += chunk.Choices[0].Delta.Content
+		return nil
+	})
+	require.ErrorAs(t, err, &blocked)
+	assert.Equal(t, "par", streamed)
+
+	// Errors refusing the prompt are recognized by their message
+	provider.err = errors.New("The response was filtered due to the prompt triggering Azure OpenAI's content management policy")
+	_, err = pm.Complete(context.Background(), "filtered", req)
+	require.ErrorAs(t, err, &blocked)
+	assert.Equal(t, ContentBlockedPrompt, blocked.Stage)
+	assert.ErrorIs(t, err, provider.err)
+
+	// Other responses and errors are unchanged
+	provider.err = errors.New("connection refused")
+	_, err = pm.Complete(context.Background(), "filtered", req)
+	assert.False(t, errors.Is(err, ErrContentBlocked))
+	provider.err, provider.finishReason = nil, "stop"
+	resp, err := pm.Complete(context.Background(), "filtered", req)
+	require.NoError(t, err)
+	assert.Equal(t, "partial", resp.Choices[0].Message.Content)
+}
+
+func TestOpenAIPromptBlock(t *testing.T) {
+	apiErr := &openai.APIError{
+		Code:    "content_filter",
+		Message: "The prompt was filtered",
+		InnerError: &openai.InnerError{ContentFilterResults: openai.ContentFilterResults{
+			Hate:     openai.Hate{Filtered: true},
+			Violence: openai.Violence{Filtered: true},
+		}},
+	}
+	blocked := openAIPromptBlock(apiErr)
+	require.NotNil(t, blocked)
+	assert.Equal(t, []string{"hate", "violence"}, blocked.Categories)
+	assert.Equal(t, ContentBlockedPrompt, blocked.Stage)
+	assert.Contains(t, blocked.Error(), "categories: hate, violence")
+
+	assert.Nil(t, openAIPromptBlock(&openai.APIError{Code: "rate_limit_exceeded", Message: "slow down"}))
+}
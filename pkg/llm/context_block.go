@@ -0,0 +1,104 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MessageKindContext marks the message carrying retrieved context, in Metadata["kind"]
+const MessageKindContext = "retrieved_context"
+
+// Metadata keys of a retrieved context message
+const (
+	messageKindKey    = "kind"
+	contextChunksKey  = "context_chunks"
+	contextSourcesKey = "source_ids"
+)
+
+// contextInstructions introduces the sources of a retrieved context message
+const contextInstructions = "Retrieved context. Each source is delimited by <source> tags carrying its index and ID. " +
+	"Cite the sources a statement is based on by index in square brackets, e.g. [1] or [1][3]."
+
+// noContextFound replaces the sources when nothing relevant was retrieved
+const noContextFound = "Retrieved context: no relevant sources were found."
+
+// ContextChunk is a retrieved passage given to the model as context
+type ContextChunk struct {
+	SourceID string  `json:"source_id"`
+	Title    string  `json:"title,omitempty"`
+	Content  string  `json:"content"`
+	Score    float64 `json:"score,omitempty"`
+}
+
+// NewContextMessage returns a system message carrying retrieved chunks, each delimited and
+// tagged with its source ID. The message is kept apart from the conversation history: it is
+// replaced rather than accumulated, and trimming drops its last chunks instead of the
+// message as a whole.
+func NewContextMessage(chunks []ContextChunk) Message {
+	sources := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		sources[i] = chunk.SourceID
+	}
+	return Message{
+		Role:    "system",
+		Content: RenderContextChunks(chunks),
+		Metadata: map[string]interface{}{
+			messageKindKey:    MessageKindContext,
+			contextChunksKey:  chunks,
+			contextSourcesKey: sources,
+		},
+	}
+}
+
+// RenderContextChunks renders chunks as the content of a context message
+func RenderContextChunks(chunks []ContextChunk) string {
+	if len(chunks) == 0 {
+		return noContextFound
+	}
+
+	var content strings.Builder
+	content.WriteString(contextInstructions)
+	for i, chunk := range chunks {
+		fmt.Fprintf(&content, "\n\n<source index=\"%d\" id=%q", i+1, chunk.SourceID)
+		if chunk.Title != "" {
+			fmt.Fprintf(&content, " title=%q", chunk.Title)
+		}
+		fmt.Fprintf(&content, ">\n%s\n</source>", strings.TrimSpace(chunk.Content))
+	}
+	return content.String()
+}
+
+// IsContextMessage reports whether a message carries retrieved context
+func IsContextMessage(message Message) bool {
+	kind, _ := message.Metadata[messageKindKey].(string)
+	return kind == MessageKindContext
+}
+
+// ContextChunks returns the chunks of a retrieved context message
+func ContextChunks(message Message) []ContextChunk {
+	chunks, _ := message.Metadata[contextChunksKey].([]ContextChunk)
+	return chunks
+}
+
+// dropLastContextChunk removes the last, least relevant chunk of the last context message
+// with more than one chunk, returning false when there is none
+func dropLastContextChunk(messages []Message) bool {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if !IsContextMessage(messages[i]) {
+			continue
+		}
+		chunks := ContextChunks(messages[i])
+		if len(chunks) <= 1 {
+			continue
+		}
+		messages[i] = NewContextMessage(chunks[:len(chunks)-1])
+		return true
+	}
+	return false
+}
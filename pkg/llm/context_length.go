@@ -0,0 +1,236 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
+)
+
+// ErrContextLengthExceeded is returned when a request does not fit in the model's context
+// window, even after trimming the oldest messages
+var ErrContextLengthExceeded = errors.New("context length exceeded")
+
+// ContextLengthErrorMatcher is implemented by providers that can recognize their own
+// context length errors
+type ContextLengthErrorMatcher interface {
+	IsContextLengthError(err error) bool
+}
+
+// contextLengthPatterns are error fragments used by common providers for context length errors
+var contextLengthPatterns = []string{
+	"context_length_exceeded",
+	"maximum context length",
+	"context length",
+	"context window",
+	"prompt is too long",
+	"input is too long",
+	"too many tokens",
+	"exceeds the maximum number of tokens",
+}
+
+// IsContextLengthError reports whether an error message indicates that the request exceeded
+// the model's context window. Rate limit errors are not, even when they mention too many
+// tokens per minute.
+func IsContextLengthError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrContextLengthExceeded) {
+		return true
+	}
+	if IsRateLimitError(err) {
+		return false
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, pattern := range contextLengthPatterns {
+		if strings.Contains(message, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// isContextLengthError uses the provider's matcher when available
+func isContextLengthError(provider Provider, err error) bool {
+	if matcher, ok := provider.(ContextLengthErrorMatcher); ok {
+		return matcher.IsContextLengthError(err)
+	}
+	return IsContextLengthError(err)
+}
+
+// TrimMessagesToFit drops the oldest non-system messages until the estimated size of the
+// conversation fits in maxTokens. System messages, pinned messages and the latest message
+// are always kept. Tool results whose assistant message was dropped are removed as well.
+// Once no such message is left, retrieved context messages lose their last chunks, down to
+// one chunk each.
+func TrimMessagesToFit(messages []Message, maxTokens int, counter TokenCounter) ([]Message, error) {
+	if counter == nil {
+		counter = NewSimpleTokenCounter()
+	}
+
+	trimmed := append([]Message{}, messages...)
+	for {
+		tokens, err := counter.CountMessagesTokens(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		if tokens <= maxTokens {
+			return trimmed, nil
+		}
+
+		oldest := oldestDroppable(trimmed)
+		if oldest < 0 {
+			if dropLastContextChunk(trimmed) {
+				continue
+			}
+			return trimmed, nil
+		}
+		trimmed = append(trimmed[:oldest], trimmed[oldest+1:]...)
+		for oldest < len(trimmed)-1 && trimmed[oldest].Role == "tool" && !trimmed[oldest].Pinned {
+			trimmed = append(trimmed[:oldest], trimmed[oldest+1:]...)
+		}
+	}
+}
+
+// oldestDroppable returns the index of the oldest unpinned non-system message other than
+// the latest message, or -1 if nothing can be dropped
+func oldestDroppable(messages []Message) int {
+	for i := 0; i < len(messages)-1; i++ {
+		if messages[i].Role != "system" && !messages[i].Pinned {
+			return i
+		}
+	}
+	return -1
+}
+
+// FitToContextWindow trims the messages of a request, as TrimMessagesToFit does, so that
+// they fit in a context window of the given size along with the system prompt and the
+// response. It returns false when nothing had to be, or could be, trimmed.
+func FitToContextWindow(req CompletionRequest, contextWindow int) (CompletionRequest, bool) {
+	if contextWindow <= 0 {
+		return req, false
+	}
+
+	// Leave room for the response and the estimation error of the token counter
+	counter := NewSimpleTokenCounter()
+	budget := contextWindow - req.MaxTokens
+	if req.SystemPrompt != "" {
+		promptTokens, _ := counter.CountTokens(req.SystemPrompt)
+		budget -= promptTokens
+	}
+	budget = budget * 9 / 10
+	if budget <= 0 {
+		return req, false
+	}
+
+	trimmed, err := TrimMessagesToFit(req.Messages, budget, counter)
+	if err != nil {
+		return req, false
+	}
+	// Trimming context chunks keeps the number of messages, so compare sizes instead
+	before, _ := counter.CountMessagesTokens(req.Messages)
+	after, _ := counter.CountMessagesTokens(trimmed)
+	if after >= before {
+		return req, false
+	}
+
+	req.Messages = trimmed
+	return req, true
+}
+
+// trimForRetry trims a request to the model's context window. It returns false when the
+// context window is unknown or nothing could be trimmed.
+func (pm *ProviderManager) trimForRetry(providerName string, req CompletionRequest) (CompletionRequest, bool) {
+	capabilities, err := pm.ModelCapabilities(providerName, req.Model)
+	if err != nil {
+		return req, false
+	}
+	return FitToContextWindow(req, capabilities.MaxContextTokens)
+}
+
+// completeWithContextRetry retries a completion once with trimmed messages when the
+// provider reports a context length error
+func (pm *ProviderManager) completeWithContextRetry(ctx context.Context, providerName string, provider Provider, req CompletionRequest, complete func(CompletionRequest) (*CompletionResponse, error)) (*CompletionResponse, error) {
+	resp, err := complete(req)
+	if err == nil || !isContextLengthError(provider, err) {
+		return resp, err
+	}
+	if !core.AllowRetry(ctx, core.RetryLayerProvider) {
+		return nil, fmt.Errorf("%w: %w (%w)", ErrContextLengthExceeded, err, core.ErrRetryBudgetExhausted)
+	}
+
+	trimmed, ok := pm.trimForRetry(pm.resolveProviderName(providerName), req)
+	if !ok {
+		return nil, fmt.Errorf("%w: %w", ErrContextLengthExceeded, err)
+	}
+	pm.logContextRetry(ctx, provider, req, trimmed)
+
+	resp, err = complete(trimmed)
+	if err != nil && isContextLengthError(provider, err) {
+		return nil, fmt.Errorf("%w: %w", ErrContextLengthExceeded, err)
+	}
+	return resp, err
+}
+
+// streamWithContextRetry retries a stream once with trimmed messages when the provider
+// reports a context length error before any chunk was delivered
+func (pm *ProviderManager) streamWithContextRetry(ctx context.Context, providerName string, provider Provider, req CompletionRequest, callback StreamCallback, stream func(CompletionRequest, StreamCallback) error) error {
+	delivered := false
+	tracked := func(chunk CompletionResponse) error {
+		delivered = true
+		return callback(chunk)
+	}
+
+	err := stream(req, tracked)
+	if err == nil || delivered || !isContextLengthError(provider, err) {
+		return err
+	}
+	if !core.AllowRetry(ctx, core.RetryLayerProvider) {
+		return fmt.Errorf("%w: %w (%w)", ErrContextLengthExceeded, err, core.ErrRetryBudgetExhausted)
+	}
+
+	trimmed, ok := pm.trimForRetry(pm.resolveProviderName(providerName), req)
+	if !ok {
+		return fmt.Errorf("%w: %w", ErrContextLengthExceeded, err)
+	}
+	pm.logContextRetry(ctx, provider, req, trimmed)
+
+	err = stream(trimmed, tracked)
+	if err != nil && !delivered && isContextLengthError(provider, err) {
+		return fmt.Errorf("%w: %w", ErrContextLengthExceeded, err)
+	}
+	return err
+}
+
+// resolveProviderName returns the default provider name for an empty name
+func (pm *ProviderManager) resolveProviderName(providerName string) string {
+	if providerName != "" {
+		return providerName
+	}
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.defaultProvider
+}
+
+// logContextRetry logs a retry with trimmed messages
+func (pm *ProviderManager) logContextRetry(ctx context.Context, provider Provider, req, trimmed CompletionRequest) {
+	logging.FromContext(ctx, pm.logger).WithFields(logrus.Fields{
+		"provider": provider.GetName(),
+		"model":    req.Model,
+		"messages": len(req.Messages),
+		"trimmed":  len(req.Messages) - len(trimmed.Messages),
+	}).Warn("Context length exceeded, retrying with trimmed messages")
+}
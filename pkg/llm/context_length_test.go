@@ -0,0 +1,194 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// contextLimitedProvider rejects requests whose messages exceed a token limit
+type contextLimitedProvider struct {
+	*GeminiProvider
+	limit    int
+	requests [][]Message
+}
+
+func (p *contextLimitedProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	p.requests = append(p.requests, req.Messages)
+
+	tokens, _ := NewSimpleTokenCounter().CountMessagesTokens(req.Messages)
+	if tokens > p.limit {
+		return nil, fmt.Errorf("This model's maximum context length is %d tokens, however you requested %d tokens", p.limit, tokens)
+	}
+	return &CompletionResponse{Choices: []Choice{{Message: Message{Role: "assistant", Content: "ok"}}}}, nil
+}
+
+func newContextLimitedManager(t *testing.T, limit, maxContext int) (*ProviderManager, *contextLimitedProvider) {
+	gemini, err := NewGeminiProvider(&ProviderConfig{APIKey: "test-key", Model: "test-model"}) // pragma: allowlist secret
+	require.NoError(t, err)
+
+	provider := &contextLimitedProvider{GeminiProvider: gemini, limit: limit}
+	pm := NewProviderManager()
+	require.NoError(t, pm.RegisterProvider("limited", provider))
+	pm.SetModelCapabilities("limited", "test-model", ProviderCapabilities{MaxContextTokens: maxContext})
+	return pm, provider
+}
+
+func longConversation() []Message {
+	messages := []Message{{Role: "system", Content: "You are helpful."}}
+	for i := 0; i < 10; i++ {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		messages = append(messages, Message{Role: role, Content: fmt.Sprintf("%02d%s", i, strings.Repeat("x", 38))})
+	}
+	return messages
+}
+
+func TestProviderManager_ContextLengthRetry(t *testing.T) {
+	pm, provider := newContextLimitedManager(t, 60, 60)
+	messages := longConversation()
+
+	resp, err := pm.Complete(context.Background(), "limited", CompletionRequest{Model: "test-model", Messages: messages})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp.Choices[0].Message.Content)
+
+	require.Len(t, provider.requests, 2)
+	retried := provider.requests[1]
+	assert.Less(t, len(retried), len(messages))
+	assert.Equal(t, "system", retried[0].Role)
+	assert.Equal(t, messages[len(messages)-1], retried[len(retried)-1])
+}
+
+func TestProviderManager_ContextLengthExceeded(t *testing.T) {
+	// Trimming cannot make the request fit
+	pm, provider := newContextLimitedManager(t, 5, 60)
+	_, err := pm.Complete(context.Background(), "limited", CompletionRequest{Model: "test-model", Messages: longConversation()})
+	assert.True(t, errors.Is(err, ErrContextLengthExceeded))
+	assert.Len(t, provider.requests, 2)
+
+	// Without a known context window there is no retry
+	pm, provider = newContextLimitedManager(t, 5, 0)
+	_, err = pm.Complete(context.Background(), "limited", CompletionRequest{Model: "test-model", Messages: longConversation()})
+	assert.True(t, errors.Is(err, ErrContextLengthExceeded))
+	assert.Len(t, provider.requests, 1)
+
+	// Other errors are returned unchanged
+	assert.False(t, IsContextLengthError(errors.New("rate limit exceeded")))
+}
+
+func TestIsContextLengthError_RateLimits(t *testing.T) {
+	assert.True(t, IsContextLengthError(errors.New("too many tokens in the prompt")))
+	assert.True(t, IsContextLengthError(errors.New("maximum context length is 8192 tokens, however you requested 14290 tokens")))
+
+	// Rate limits on tokens are not context length errors
+	assert.False(t, IsContextLengthError(errors.New("status 429: too many tokens per minute, retry after 20s")))
+	assert.False(t, IsContextLengthError(errors.New("rate_limit_error: too many tokens, slow down")))
+	assert.False(t, IsRateLimitError(errors.New("you requested 14290 tokens")))
+}
+
+func TestTrimMessagesToFit(t *testing.T) {
+	messages := []Message{
+		{Role: "system", Content: strings.Repeat("s", 40)},
+		{Role: "user", Content: strings.Repeat("u", 40)},
+		{Role: "assistant", ToolCalls: []ToolCall{{ID: "call_1"}}},
+		{Role: "tool", Content: strings.Repeat("t", 40), ToolCallID: "call_1"},
+		{Role: "user", Content: strings.Repeat("q", 40)},
+	}
+
+	trimmed, err := TrimMessagesToFit(messages, 25, nil)
+	require.NoError(t, err)
+
+	// The orphaned tool result is dropped together with its assistant message
+	require.Len(t, trimmed, 2)
+	assert.Equal(t, "system", trimmed[0].Role)
+	assert.Equal(t, messages[4], trimmed[1])
+}
+
+func TestTrimMessagesToFit_KeepsPinnedMessages(t *testing.T) {
+	messages := []Message{
+		{Role: "user", Content: "Always respond in French.", Pinned: true},
+		{Role: "assistant", Content: strings.Repeat("a", 40)},
+		{Role: "user", Content: strings.Repeat("u", 40)},
+		{Role: "assistant", Content: strings.Repeat("b", 40)},
+		{Role: "user", Content: strings.Repeat("q", 40)},
+	}
+
+	trimmed, err := TrimMessagesToFit(messages, 25, nil)
+	require.NoError(t, err)
+
+	require.Len(t, trimmed, 2)
+	assert.Equal(t, messages[0], trimmed[0])
+	assert.Equal(t, messages[4], trimmed[1])
+}
+
+func TestTrimMessagesToFit_TrimsContextChunksLast(t *testing.T) {
+	chunks := []ContextChunk{
+		{SourceID: "doc-1", Content: strings.Repeat("a", 80)},
+		{SourceID: "doc-2", Content: strings.Repeat("b", 80)},
+		{SourceID: "doc-3", Content: strings.Repeat("c", 80)},
+	}
+	messages := []Message{
+		{Role: "system", Content: "You are helpful."},
+		NewContextMessage(chunks),
+		{Role: "user", Content: strings.Repeat("u", 40)},
+		{Role: "assistant", Content: strings.Repeat("b", 40)},
+		{Role: "user", Content: strings.Repeat("q", 40)},
+	}
+
+	// History goes first, then the least relevant chunks, down to one
+	trimmed, err := TrimMessagesToFit(messages, 1, nil)
+	require.NoError(t, err)
+	require.Len(t, trimmed, 3)
+	assert.Equal(t, messages[4], trimmed[2])
+	require.True(t, IsContextMessage(trimmed[1]))
+	assert.Equal(t, chunks[:1], ContextChunks(trimmed[1]))
+	assert.NotContains(t, trimmed[1].Content, "doc-2")
+
+	// The input messages are left untouched
+	assert.Len(t, ContextChunks(messages[1]), 3)
+}
+
+func TestNewContextMessage(t *testing.T) {
+	message := NewContextMessage([]ContextChunk{
+		{SourceID: "doc-1", Title: "Go History", Content: "Go was released in 2009.\n"},
+		{SourceID: "doc-2", Content: "Go has goroutines."},
+	})
+
+	assert.Equal(t, "system", message.Role)
+	assert.True(t, IsContextMessage(message))
+	assert.Contains(t, message.Content, "<source index=\"1\" id=\"doc-1\" title=\"Go History\">\nGo was released in 2009.\n</source>")
+	assert.Contains(t, message.Content, "<source index=\"2\" id=\"doc-2\">\nGo has goroutines.\n</source>")
+	assert.False(t, IsContextMessage(Message{Role: "system", Content: "You are helpful."}))
+
+	// An empty block tells the model nothing relevant was found
+	assert.Contains(t, NewContextMessage(nil).Content, "no relevant sources")
+}
+
+func TestConversationHistory_SetPinned(t *testing.T) {
+	history := NewConversationHistory()
+	history.AddMessage(Message{Role: "user", Content: "Always respond in French."})
+	history.AddMessage(Message{Role: "assistant", Content: "D'accord."})
+
+	require.NoError(t, history.SetPinned(0, true))
+	assert.Error(t, history.SetPinned(2, true))
+
+	pinned := history.GetPinned()
+	require.Len(t, pinned, 1)
+	assert.Equal(t, "Always respond in French.", pinned[0].Content)
+
+	require.NoError(t, history.SetPinned(0, false))
+	assert.Empty(t, history.GetPinned())
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultContextWindow is the context window, in tokens, assumed for models missing from
+// the lookup table
+const DefaultContextWindow = 4096
+
+var (
+	contextWindowsMu sync.RWMutex
+	// contextWindows maps model name prefixes to their context window in tokens; the longest
+	// matching prefix wins, so "gpt-4o" takes precedence over "gpt-4"
+	contextWindows = map[string]int{
+		// OpenAI
+		"gpt-5":                  400000,
+		"gpt-4.1":                1047576,
+		"gpt-4o":                 128000,
+		"gpt-4-turbo":            128000,
+		"gpt-4-1106":             128000,
+		"gpt-4-0125":             128000,
+		"gpt-4-32k":              32768,
+		"gpt-4":                  8192,
+		"gpt-3.5-turbo":          16385,
+		"gpt-3.5-turbo-instruct": 4096,
+		"o1":                     200000,
+		"o1-mini":                128000,
+		"o3":                     200000,
+		"o4-mini":                200000,
+		// Gemini
+		"gemini-2.5":        1048576,
+		"gemini-2.0":        1048576,
+		"gemini-1.5-pro":    2097152,
+		"gemini-1.5-flash":  1048576,
+		"gemini-pro":        32768,
+		"gemini-pro-vision": 16384,
+		// Anthropic
+		"claude":   200000,
+		"claude-2": 100000,
+		// Open models, as named by Ollama and Hugging Face
+		"llama2":           4096,
+		"llama-2":          4096,
+		"llama3":           8192,
+		"llama-3":          8192,
+		"llama3.1":         131072,
+		"llama-3.1":        131072,
+		"llama3.2":         131072,
+		"llama-3.2":        131072,
+		"llama3.3":         131072,
+		"llama-3.3":        131072,
+		"codellama":        16384,
+		"mistral":          32768,
+		"mixtral":          32768,
+		"gemma":            8192,
+		"gemma3":           131072,
+		"qwen2":            32768,
+		"qwen2.5":          32768,
+		"qwen3":            40960,
+		"phi3":             4096,
+		"phi4":             16384,
+		"deepseek-r1":      131072,
+		"nomic-embed-text": 8192,
+	}
+)
+
+// RegisterContextWindow sets the context window, in tokens, of the models whose name
+// starts with prefix, adding to or replacing the lookup table entry
+func RegisterContextWindow(prefix string, tokens int) {
+	contextWindowsMu.Lock()
+	defer contextWindowsMu.Unlock()
+	contextWindows[strings.ToLower(prefix)] = tokens
+}
+
+// LookupContextWindow returns the context window of a model from the lookup table, and
+// whether the model matched an entry. Models are matched case-insensitively by the longest
+// prefix of their name, ignoring a namespace such as "meta-llama/", so that tags and
+// versions such as "llama3.1:8b" or "gpt-4o-2024-08-06" share their family's entry.
+func LookupContextWindow(model string) (int, bool) {
+	name := strings.ToLower(model)
+	if slash := strings.LastIndex(name, "/"); slash >= 0 {
+		name = name[slash+1:]
+	}
+
+	contextWindowsMu.RLock()
+	defer contextWindowsMu.RUnlock()
+
+	prefixes := make([]string, 0, len(contextWindows))
+	for prefix := range contextWindows {
+		if strings.HasPrefix(name, prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) == 0 {
+		return 0, false
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+	return contextWindows[prefixes[0]], true
+}
+
+// ContextWindowForModel returns the context window of a model from the lookup table, or
+// DefaultContextWindow for unknown models
+func ContextWindowForModel(model string) int {
+	if tokens, ok := LookupContextWindow(model); ok {
+		return tokens
+	}
+	return DefaultContextWindow
+}
+
+// contextWindowOverride returns the context window configured for a model in
+// ContextWindows, by exact name or "*", or 0 when none is
+func (c *ProviderConfig) contextWindowOverride(model string) int {
+	if c == nil {
+		return 0
+	}
+	if tokens, ok := c.ContextWindows[model]; ok {
+		return tokens
+	}
+	return c.ContextWindows["*"]
+}
+
+// contextWindow returns the context window of a model: the configured override, else the
+// lookup table, else DefaultContextWindow
+func (c *ProviderConfig) contextWindow(model string) int {
+	if tokens := c.contextWindowOverride(model); tokens > 0 {
+		return tokens
+	}
+	return ContextWindowForModel(model)
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupContextWindow(t *testing.T) {
+	tests := map[string]int{
+		"gpt-4o-2024-08-06":                     128000,
+		"gpt-4":                                 8192,
+		"GPT-4-Turbo":                           128000,
+		"llama3.1:8b":                           131072,
+		"llama3:latest":                         8192,
+		"meta-llama/Llama-3.1-8B-Instruct":      131072,
+		"gemini-1.5-flash-latest":               1048576,
+		"claude-3-5-sonnet-20241022":            200000,
+		"library/mistral:7b-instruct-v0.3-q4_0": 32768,
+	}
+	for model, expected := range tests {
+		tokens, ok := LookupContextWindow(model)
+		assert.True(t, ok, model)
+		assert.Equal(t, expected, tokens, model)
+	}
+
+	_, ok := LookupContextWindow("my-finetune")
+	assert.False(t, ok)
+	assert.Equal(t, DefaultContextWindow, ContextWindowForModel("my-finetune"))
+
+	RegisterContextWindow("My-Finetune", 65536)
+	assert.Equal(t, 65536, ContextWindowForModel("my-finetune-v2"))
+}
+
+func TestProviderConfig_ContextWindows(t *testing.T) {
+	provider, err := NewOpenAIProvider(&ProviderConfig{
+		APIKey:         "test-key", // pragma: allowlist secret
+		Model:          "gpt-4o",
+		ContextWindows: map[string]int{"gpt-4o": 32000, "*": 16000},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 32000, provider.Capabilities().MaxContextTokens)
+	assert.Equal(t, 16000, provider.ModelCapabilities("gpt-4").MaxContextTokens)
+}
+
+func TestOllamaProvider_ContextWindowDetection(t *testing.T) {
+	var shows atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/show", r.URL.Path)
+		shows.Add(1)
+
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		switch body["model"] {
+		case "llama3.1:8b":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"parameters": "stop                           \"<|eot_id|>\"\nnum_ctx                        16384",
+				"model_info": map[string]interface{}{"llama.context_length": 131072},
+			})
+		case "mistral":
+			json.NewEncoder(w).Encode(map[string]interface{}{"parameters": "temperature 0.7"})
+		default:
+			http.Error(w, `{"error": "model not found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewOllamaProvider(&ProviderConfig{Endpoint: server.URL, Model: "llama3.1:8b"})
+	require.NoError(t, err)
+
+	// The num_ctx of the Modelfile is the window Ollama runs the model with
+	assert.Equal(t, 16384, provider.Capabilities().MaxContextTokens)
+	assert.Equal(t, 16384, provider.ModelCapabilities("llama3.1:8b").MaxContextTokens)
+	assert.Equal(t, int32(1), shows.Load(), "the detected window should be cached")
+
+	// Without num_ctx, or without the model, the lookup table applies
+	assert.Equal(t, 32768, provider.ModelCapabilities("mistral").MaxContextTokens)
+	assert.Equal(t, DefaultContextWindow, provider.ModelCapabilities("unknown").MaxContextTokens)
+
+	// The num_ctx sent with every request and the configured override take precedence
+	provider.config.DefaultParams = map[string]interface{}{"num_ctx": 8192}
+	assert.Equal(t, 8192, provider.ModelCapabilities("llama3.1:8b").MaxContextTokens)
+	provider.config.ContextWindows = map[string]int{"llama3.1:8b": 4096}
+	assert.Equal(t, 4096, provider.ModelCapabilities("llama3.1:8b").MaxContextTokens)
+}
+
+func TestOllamaProvider_ContextWindowDetectionConcurrent(t *testing.T) {
+	var shows atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body["model"] == "slow" {
+			shows.Add(1)
+			<-release
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"parameters": "num_ctx 4096"})
+	}))
+	defer server.Close()
+
+	provider, err := NewOllamaProvider(&ProviderConfig{Endpoint: server.URL, Model: "slow"})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	windows := make([]int, 3)
+	for i := range windows {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			windows[i] = provider.ModelCapabilities("slow").MaxContextTokens
+		}(i)
+	}
+
+	// A slow lookup does not hold up the lookups of other models
+	assert.Equal(t, 4096, provider.ModelCapabilities("fast").MaxContextTokens)
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, []int{4096, 4096, 4096}, windows)
+	assert.Equal(t, int32(1), shows.Load(), "concurrent lookups of a model should share one request")
+}
+
+func TestFitToContextWindow(t *testing.T) {
+	req := CompletionRequest{Messages: longConversation(), MaxTokens: 100}
+
+	_, trimmed := FitToContextWindow(req, 100000)
+	assert.False(t, trimmed, "a conversation within the window should be left alone")
+	_, trimmed = FitToContextWindow(req, 0)
+	assert.False(t, trimmed, "an unknown window should not trim")
+
+	fitted, trimmed := FitToContextWindow(req, 200)
+	require.True(t, trimmed)
+	assert.Less(t, len(fitted.Messages), len(req.Messages))
+	assert.Equal(t, "system", fitted.Messages[0].Role)
+	assert.Equal(t, req.Messages[len(req.Messages)-1], fitted.Messages[len(fitted.Messages)-1])
+	assert.Len(t, req.Messages, 11, "the request passed in should not be modified")
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Parameters recognized by every provider. In ProviderConfig.DefaultParams or
+// CompletionRequest.Params they fill the request field of the same meaning when it is unset.
+const (
+	ParamTemperature     = "temperature"
+	ParamMaxTokens       = "max_tokens"
+	ParamSeed            = "seed"
+	ParamStop            = "stop"
+	ParamReasoningEffort = "reasoning_effort"
+)
+
+// Provider-specific parameters. The OpenAI provider recognizes top_p, presence_penalty,
+// frequency_penalty, user and parallel_tool_calls. The Ollama provider sends keep_alive and
+// format with the request and every other parameter, such as num_ctx, top_k or
+// repeat_penalty, as a generation option. Other parameters are ignored.
+const (
+	ParamTopP              = "top_p"
+	ParamPresencePenalty   = "presence_penalty"
+	ParamFrequencyPenalty  = "frequency_penalty"
+	ParamUser              = "user"
+	ParamParallelToolCalls = "parallel_tool_calls"
+	ParamKeepAlive         = "keep_alive"
+	ParamFormat            = "format"
+)
+
+// ApplyDefaultParams merges a provider's default parameters into a request. Precedence,
+// highest first:
+//
+//  1. the request's explicit fields, such as Temperature or MaxTokens
+//  2. the request's Params
+//  3. the provider's DefaultParams
+//  4. the provider's config fields, such as ProviderConfig.Temperature, and built-in defaults
+//
+// The common parameters are moved into their request fields; the provider-specific ones are
+// left in the returned request's Params. The request passed in is not modified.
+func ApplyDefaultParams(req CompletionRequest, defaults map[string]interface{}) CompletionRequest {
+	if len(defaults) == 0 && len(req.Params) == 0 {
+		return req
+	}
+
+	params := make(map[string]interface{}, len(defaults)+len(req.Params))
+	for key, value := range defaults {
+		params[key] = value
+	}
+	for key, value := range req.Params {
+		params[key] = value
+	}
+
+	if value, exists := params[ParamTemperature]; exists {
+		if req.Temperature == 0 {
+			req.Temperature, _ = paramFloat(value)
+		}
+		delete(params, ParamTemperature)
+	}
+	if value, exists := params[ParamMaxTokens]; exists {
+		if req.MaxTokens == 0 {
+			req.MaxTokens, _ = paramInt(value)
+		}
+		delete(params, ParamMaxTokens)
+	}
+	if value, exists := params[ParamSeed]; exists {
+		if seed, ok := paramInt(value); ok && req.Seed == nil {
+			req.Seed = &seed
+		}
+		delete(params, ParamSeed)
+	}
+	if value, exists := params[ParamStop]; exists {
+		if len(req.StopSequences) == 0 {
+			req.StopSequences = paramStrings(value)
+		}
+		delete(params, ParamStop)
+	}
+	if value, exists := params[ParamReasoningEffort]; exists {
+		if req.ReasoningEffort == "" {
+			req.ReasoningEffort = fmt.Sprint(value)
+		}
+		delete(params, ParamReasoningEffort)
+	}
+
+	req.Params = params
+	return req
+}
+
+// paramFloat converts a numeric parameter decoded from JSON, YAML or set in Go
+func paramFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// paramInt converts an integer parameter decoded from JSON, YAML or set in Go
+func paramInt(value interface{}) (int, bool) {
+	if f, ok := paramFloat(value); ok {
+		return int(f), true
+	}
+	return 0, false
+}
+
+// paramStrings converts a parameter holding a string or a list of strings
+func paramStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	default:
+		return nil
+	}
+}
@@ -68,6 +68,11 @@ func (p *GeminiProvider) Complete(ctx context.Context, req CompletionRequest) (*
 		responseText = "I understand your request. This is a mock Gemini response for demonstration purposes. In a real implementation, this would be powered by Google's Gemini API."
 	}
 
+	var metadata map[string]interface{}
+	if budget := p.thinkingBudget(req); budget > 0 {
+		metadata = map[string]interface{}{"thinking_budget": budget}
+	}
+
 	return &CompletionResponse{
 		ID:      fmt.Sprintf("gemini-mock-%d", time.Now().Unix()),
 		Object:  "chat.completion",
@@ -88,9 +93,33 @@ func (p *GeminiProvider) Complete(ctx context.Context, req CompletionRequest) (*
 			CompletionTokens: len(responseText) / 4,
 			TotalTokens:      (len(lastMessage.Content) + len(responseText)) / 4,
 		},
+		Metadata: metadata,
 	}, nil
 }
 
+// geminiThinkingBudgets maps reasoning effort to a thinking budget in tokens
+var geminiThinkingBudgets = map[string]int{
+	ReasoningEffortLow:    1024,
+	ReasoningEffortMedium: 8192,
+	ReasoningEffortHigh:   24576,
+}
+
+// thinkingBudget returns the thinking budget sent to Gemini thinking models, preferring an
+// explicit budget over one derived from the reasoning effort. Other models get none.
+func (p *GeminiProvider) thinkingBudget(req CompletionRequest) int {
+	model := req.Model
+	if model == "" {
+		model = p.config.Model
+	}
+	if !strings.Contains(model, "thinking") && !strings.HasPrefix(model, "gemini-2.5") {
+		return 0
+	}
+	if req.ThinkingBudgetTokens > 0 {
+		return req.ThinkingBudgetTokens
+	}
+	return geminiThinkingBudgets[req.ReasoningEffort]
+}
+
 // CompleteStream generates a streaming completion
 func (p *GeminiProvider) CompleteStream(ctx context.Context, req CompletionRequest, callback StreamCallback) error {
 	// Mock streaming implementation
@@ -283,22 +312,27 @@ func (p *GeminiProvider) SupportsToolCalls() bool {
 	return true
 }
 
-// GetMaxTokens returns the maximum tokens for a model
-func (p *GeminiProvider) GetMaxTokens(model string) int {
-	switch model {
-	case "gemini-pro":
-		return 32768
-	case "gemini-pro-vision":
-		return 16384
-	case "gemini-1.5-pro":
-		return 128000
-	case "gemini-1.5-flash":
-		return 32768
-	default:
-		return 32768
+// Capabilities returns the capabilities of the configured default model
+func (p *GeminiProvider) Capabilities() ProviderCapabilities {
+	return p.ModelCapabilities(p.config.Model)
+}
+
+// ModelCapabilities returns the capabilities of a specific model
+func (p *GeminiProvider) ModelCapabilities(model string) ProviderCapabilities {
+	return ProviderCapabilities{
+		SupportsStreaming: p.SupportsStreaming(),
+		SupportsTools:     p.SupportsToolCalls(),
+		SupportsVision:    strings.Contains(model, "vision") || strings.HasPrefix(model, "gemini-1.5"),
+		MaxContextTokens:  p.GetMaxTokens(model),
 	}
 }
 
+// GetMaxTokens returns the context window of a model: the override in ContextWindows, else
+// the lookup table
+func (p *GeminiProvider) GetMaxTokens(model string) int {
+	return p.config.contextWindow(model)
+}
+
 // Note: This is a mock implementation for demonstration purposes.
 // In a real implementation, you would:
 // 1. Use the official Google AI Go SDK when available
@@ -0,0 +1,71 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+// RequestInterceptor modifies a completion request before it is sent to a provider
+type RequestInterceptor func(req *CompletionRequest)
+
+// ResponseInterceptor modifies a completion response, or a streamed chunk, after it arrives
+type ResponseInterceptor func(resp *CompletionResponse)
+
+// AddRequestInterceptor registers an interceptor applied to every request sent through the
+// manager. Interceptors run in registration order.
+func (pm *ProviderManager) AddRequestInterceptor(interceptor RequestInterceptor) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.requestInterceptors = append(pm.requestInterceptors, interceptor)
+}
+
+// AddResponseInterceptor registers an interceptor applied to every response received through
+// the manager, including each chunk of a stream. Interceptors run in registration order.
+func (pm *ProviderManager) AddResponseInterceptor(interceptor ResponseInterceptor) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.responseInterceptors = append(pm.responseInterceptors, interceptor)
+}
+
+// interceptRequest applies the request interceptors to a copy of the request, so the
+// caller's messages are never modified
+func (pm *ProviderManager) interceptRequest(req CompletionRequest) CompletionRequest {
+	pm.mu.RLock()
+	interceptors := pm.requestInterceptors
+	pm.mu.RUnlock()
+
+	if len(interceptors) == 0 {
+		return req
+	}
+
+	req.Messages = append([]Message(nil), req.Messages...)
+	req.Tools = append([]ToolDefinition(nil), req.Tools...)
+	for _, interceptor := range interceptors {
+		interceptor(&req)
+	}
+	return req
+}
+
+// interceptResponse applies the response interceptors to a response
+func (pm *ProviderManager) interceptResponse(resp *CompletionResponse) {
+	if resp == nil {
+		return
+	}
+
+	pm.mu.RLock()
+	interceptors := pm.responseInterceptors
+	pm.mu.RUnlock()
+
+	for _, interceptor := range interceptors {
+		interceptor(resp)
+	}
+}
+
+// interceptStream wraps a stream callback so every chunk passes the response interceptors
+func (pm *ProviderManager) interceptStream(callback StreamCallback) StreamCallback {
+	return func(chunk CompletionResponse) error {
+		pm.interceptResponse(&chunk)
+		return callback(chunk)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoProvider replies with the content of the last message
+type echoProvider struct {
+	*GeminiProvider
+	requests []CompletionRequest
+}
+
+func (p *echoProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	p.requests = append(p.requests, req)
+	content := req.Messages[len(req.Messages)-1].Content
+	return &CompletionResponse{Choices: []Choice{{Message: Message{Role: "assistant", Content: content}}}}, nil
+}
+
+func (p *echoProvider) CompleteStream(ctx context.Context, req CompletionRequest, callback StreamCallback) error {
+	p.requests = append(p.requests, req)
+	for _, word := range strings.Fields(req.Messages[len(req.Messages)-1].Content) {
+		if err := callback(CompletionResponse{Choices: []Choice{{Delta: Message{Content: word}}}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestProviderManager_Interceptors(t *testing.T) {
+	gemini, err := NewGeminiProvider(&ProviderConfig{APIKey: "test-key", Model: "test-model"}) // pragma: allowlist secret
+	require.NoError(t, err)
+	provider := &echoProvider{GeminiProvider: gemini}
+
+	pm := NewProviderManager()
+	require.NoError(t, pm.RegisterProvider("echo", provider))
+
+	var order []string
+	pm.AddRequestInterceptor(func(req *CompletionRequest) {
+		order = append(order, "system")
+		req.Messages = append([]Message{{Role: "system", Content: "Be brief."}}, req.Messages...)
+	})
+	pm.AddRequestInterceptor(func(req *CompletionRequest) {
+		order = append(order, "scrub")
+		for i := range req.Messages {
+			req.Messages[i].Content = strings.ReplaceAll(req.Messages[i].Content, "555-0100", "[phone]")
+		}
+	})
+	pm.AddResponseInterceptor(func(resp *CompletionResponse) {
+		resp.Model = "tagged"
+	})
+
+	messages := []Message{{Role: "user", Content: "call 555-0100"}}
+	resp, err := pm.Complete(context.Background(), "echo", CompletionRequest{Messages: messages})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"system", "scrub"}, order)
+	sent := provider.requests[0].Messages
+	require.Len(t, sent, 2)
+	assert.Equal(t, "system", sent[0].Role)
+	assert.Equal(t, "call [phone]", sent[1].Content)
+	assert.Equal(t, "call 555-0100", messages[0].Content, "the caller's messages must not be modified")
+	assert.Equal(t, "tagged", resp.Model)
+	assert.Equal(t, "call [phone]", resp.Choices[0].Message.Content)
+
+	var chunks []CompletionResponse
+	err = pm.CompleteStream(context.Background(), "echo", CompletionRequest{Messages: messages}, func(chunk CompletionResponse) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, "[phone]", chunks[1].Choices[0].Delta.Content)
+	for _, chunk := range chunks {
+		assert.Equal(t, "tagged", chunk.Model)
+	}
+}
+
+func TestProviderManager_ModelAliases(t *testing.T) {
+	gemini, err := NewGeminiProvider(&ProviderConfig{APIKey: "test-key", Model: "test-model"}) // pragma: allowlist secret
+	require.NoError(t, err)
+	fast := &echoProvider{GeminiProvider: gemini}
+	smart := &echoProvider{GeminiProvider: gemini}
+
+	pm := NewProviderManager()
+	require.NoError(t, pm.RegisterProvider("fast-provider", fast))
+	require.NoError(t, pm.RegisterProvider("smart-provider", smart))
+
+	target, err := ParseModelAlias("fast-provider:small-model")
+	require.NoError(t, err)
+	pm.SetModelAlias("default", target)
+
+	req := CompletionRequest{Model: "default", Messages: []Message{{Role: "user", Content: "hi"}}}
+	_, err = pm.Complete(context.Background(), AliasProvider, req)
+	require.NoError(t, err)
+	require.Len(t, fast.requests, 1)
+	assert.Equal(t, "small-model", fast.requests[0].Model)
+
+	// Reloading remaps the alias without touching the callers
+	pm.SetModelAliases(map[string]ModelAlias{"default": {Provider: "smart-provider", Model: "large-model"}})
+	require.NoError(t, pm.CompleteStream(context.Background(), AliasProvider, req, func(CompletionResponse) error { return nil }))
+	require.Len(t, smart.requests, 1)
+	assert.Equal(t, "large-model", smart.requests[0].Model)
+	assert.Len(t, fast.requests, 1)
+
+	provider, model, err := pm.ResolveModel("fast-provider", "default")
+	require.NoError(t, err)
+	assert.Equal(t, "fast-provider", provider, "only the alias provider resolves aliases")
+	assert.Equal(t, "default", model)
+
+	_, err = pm.Complete(context.Background(), AliasProvider, CompletionRequest{Model: "missing"})
+	assert.ErrorContains(t, err, "model alias missing not defined")
+
+	for _, invalid := range []string{"gpt-4", ":model", "openai:", "alias:fast"} {
+		_, err := ParseModelAlias(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// JSONFieldEvent reports a top-level field of a streamed JSON object that is complete
+type JSONFieldEvent struct {
+	Field string          `json:"field"`
+	Value json.RawMessage `json:"value"`
+	// Index is the position of the field in the object
+	Index int `json:"index"`
+}
+
+// jsonScanState is the position of the parser within the streamed object
+type jsonScanState int
+
+const (
+	jsonSeekObject jsonScanState = iota
+	jsonExpectKey
+	jsonInKey
+	jsonExpectColon
+	jsonExpectValue
+	jsonInValue
+	jsonDone
+)
+
+// StreamingJSONParser accumulates streamed deltas of a JSON object and reports each
+// top-level field as soon as its value is complete, so a UI can progressively render
+// structured output. Incomplete input is buffered until enough of it arrives; text
+// before the opening brace, such as a code fence, is skipped.
+type StreamingJSONParser struct {
+	onField func(JSONFieldEvent) error
+
+	buffer     strings.Builder
+	text       string
+	pos        int
+	state      jsonScanState
+	keyStart   int
+	key        string
+	valueStart int
+	depth      int
+	inString   bool
+	escaped    bool
+	fields     map[string]json.RawMessage
+	order      []string
+	mu         sync.Mutex
+}
+
+// NewStreamingJSONParser creates a parser calling onField for every completed field;
+// onField may be nil when the fields are read with Fields
+func NewStreamingJSONParser(onField func(JSONFieldEvent) error) *StreamingJSONParser {
+	return &StreamingJSONParser{
+		onField: onField,
+		fields:  make(map[string]json.RawMessage),
+	}
+}
+
+// Write appends a streamed delta and emits the fields it completes
+func (p *StreamingJSONParser) Write(delta string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.buffer.WriteString(delta)
+	p.text = p.buffer.String()
+
+	for ; p.pos < len(p.text) && p.state != jsonDone; p.pos++ {
+		if err := p.scan(p.text[p.pos]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Callback wraps a stream callback so the content deltas of every chunk are parsed before
+// the chunk is forwarded; next may be nil
+func (p *StreamingJSONParser) Callback(next StreamCallback) StreamCallback {
+	return func(chunk CompletionResponse) error {
+		if len(chunk.Choices) > 0 {
+			if err := p.Write(chunk.Choices[0].Delta.Content); err != nil {
+				return err
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		return next(chunk)
+	}
+}
+
+// scan advances the parser by one byte of input
+func (p *StreamingJSONParser) scan(c byte) error {
+	switch p.state {
+	case jsonSeekObject:
+		if c == '{' {
+			p.state = jsonExpectKey
+		}
+
+	case jsonExpectKey:
+		switch {
+		case c == '"':
+			p.state = jsonInKey
+			p.keyStart = p.pos
+			p.escaped = false
+		case c == '}':
+			p.state = jsonDone
+		case c != ',' && !isJSONSpace(c):
+			return fmt.Errorf("unexpected %q at offset %d, expected a field name", c, p.pos)
+		}
+
+	case jsonInKey:
+		switch {
+		case p.escaped:
+			p.escaped = false
+		case c == '\\':
+			p.escaped = true
+		case c == '"':
+			if err := json.Unmarshal([]byte(p.text[p.keyStart:p.pos+1]), &p.key); err != nil {
+				return fmt.Errorf("invalid field name at offset %d: %w", p.keyStart, err)
+			}
+			p.state = jsonExpectColon
+		}
+
+	case jsonExpectColon:
+		if c == ':' {
+			p.state = jsonExpectValue
+		} else if !isJSONSpace(c) {
+			return fmt.Errorf("unexpected %q at offset %d, expected ':'", c, p.pos)
+		}
+
+	case jsonExpectValue:
+		if isJSONSpace(c) {
+			return nil
+		}
+		p.state = jsonInValue
+		p.valueStart = p.pos
+		p.depth = 0
+		p.inString = false
+		p.escaped = false
+		return p.scanValue(c)
+
+	case jsonInValue:
+		return p.scanValue(c)
+	}
+	return nil
+}
+
+// scanValue tracks nesting within a field value and emits the field once it is complete
+func (p *StreamingJSONParser) scanValue(c byte) error {
+	if p.inString {
+		switch {
+		case p.escaped:
+			p.escaped = false
+		case c == '\\':
+			p.escaped = true
+		case c == '"':
+			p.inString = false
+			if p.depth == 0 {
+				return p.emit(p.pos + 1)
+			}
+		}
+		return nil
+	}
+
+	switch c {
+	case '"':
+		p.inString = true
+	case '{', '[':
+		p.depth++
+	case '}', ']':
+		if p.depth == 0 {
+			// The object closes right after a literal value
+			if err := p.emit(p.pos); err != nil {
+				return err
+			}
+			p.state = jsonDone
+			return nil
+		}
+		p.depth--
+		if p.depth == 0 {
+			return p.emit(p.pos + 1)
+		}
+	case ',':
+		if p.depth == 0 {
+			return p.emit(p.pos)
+		}
+	default:
+		if isJSONSpace(c) && p.depth == 0 {
+			return p.emit(p.pos)
+		}
+	}
+	return nil
+}
+
+// emit records the value ending before end and reports the field
+func (p *StreamingJSONParser) emit(end int) error {
+	value := json.RawMessage(strings.TrimSpace(p.text[p.valueStart:end]))
+	if !json.Valid(value) {
+		return fmt.Errorf("invalid value for field %s: %s", p.key, value)
+	}
+
+	if _, exists := p.fields[p.key]; !exists {
+		p.order = append(p.order, p.key)
+	}
+	p.fields[p.key] = value
+	p.state = jsonExpectKey
+
+	if p.onField == nil {
+		return nil
+	}
+	return p.onField(JSONFieldEvent{Field: p.key, Value: value, Index: len(p.order) - 1})
+}
+
+// Fields returns the completed top-level fields in the order they arrived
+func (p *StreamingJSONParser) Fields() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fields := make([]string, len(p.order))
+	copy(fields, p.order)
+	return fields
+}
+
+// Partial decodes the fields completed so far
+func (p *StreamingJSONParser) Partial() map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	partial := make(map[string]interface{}, len(p.fields))
+	for field, value := range p.fields {
+		var decoded interface{}
+		if err := json.Unmarshal(value, &decoded); err == nil {
+			partial[field] = decoded
+		}
+	}
+	return partial
+}
+
+// Complete reports whether the closing brace of the object has arrived
+func (p *StreamingJSONParser) Complete() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state == jsonDone
+}
+
+// Decode unmarshals the complete object into v
+func (p *StreamingJSONParser) Decode(v interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state != jsonDone {
+		return fmt.Errorf("streamed JSON object is incomplete")
+	}
+	start := strings.IndexByte(p.text, '{')
+	return json.Unmarshal([]byte(p.text[start:p.pos]), v)
+}
+
+// isJSONSpace reports whether c is JSON whitespace
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamingJSONParser(t *testing.T) {
+	var events []JSONFieldEvent
+	parser := NewStreamingJSONParser(func(event JSONFieldEvent) error {
+		events = append(events, event)
+		return nil
+	})
+
+	document := "```json\n{\"name\": \"Ada \\\"the\\\" Countess\", \"age\": 36, \"tags\": [\"math\", {\"nested\": \"}\"}],\n \"active\": true}\n```"
+
+	// Stream the document a few bytes at a time
+	var fieldsSeen []int
+	for start := 0; start < len(document); start += 3 {
+		end := start + 3
+		if end > len(document) {
+			end = len(document)
+		}
+		require.NoError(t, parser.Write(document[start:end]))
+		fieldsSeen = append(fieldsSeen, len(events))
+	}
+
+	require.Len(t, events, 4)
+	assert.Equal(t, "name", events[0].Field)
+	assert.Equal(t, `"Ada \"the\" Countess"`, string(events[0].Value))
+	assert.Equal(t, "36", string(events[1].Value))
+	assert.Equal(t, `["math", {"nested": "}"}]`, string(events[2].Value))
+	assert.Equal(t, "active", events[3].Field)
+	assert.Equal(t, 3, events[3].Index)
+	assert.Less(t, fieldsSeen[5], 4, "fields should be emitted progressively")
+
+	assert.True(t, parser.Complete())
+	assert.Equal(t, []string{"name", "age", "tags", "active"}, parser.Fields())
+
+	var decoded struct {
+		Name   string `json:"name"`
+		Age    int    `json:"age"`
+		Active bool   `json:"active"`
+	}
+	require.NoError(t, parser.Decode(&decoded))
+	assert.Equal(t, 36, decoded.Age)
+	assert.True(t, decoded.Active)
+}
+
+func TestStreamingJSONParser_Partial(t *testing.T) {
+	parser := NewStreamingJSONParser(nil)
+	callback := parser.Callback(nil)
+
+	for _, delta := range []string{`{"title": "Rep`, `ort", "score": 0.`, `9`} {
+		require.NoError(t, callback(CompletionResponse{Choices: []Choice{{Delta: Message{Content: delta}}}}))
+	}
+
+	// The number may still grow, so only the title is complete
+	assert.Equal(t, map[string]interface{}{"title": "Report"}, parser.Partial())
+	assert.False(t, parser.Complete())
+	assert.Error(t, parser.Decode(&map[string]interface{}{}))
+
+	require.NoError(t, parser.Write("5}"))
+	assert.Equal(t, 0.95, parser.Partial()["score"])
+	assert.True(t, parser.Complete())
+}
+
+func TestStreamingJSONParser_InvalidValue(t *testing.T) {
+	parser := NewStreamingJSONParser(nil)
+	assert.Error(t, parser.Write(`{"a": nope, "b": 1}`))
+}
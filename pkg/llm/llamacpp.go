@@ -0,0 +1,493 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// llamaCppPlaceholderKey is sent to servers started without --api-key, which ignore it
+const llamaCppPlaceholderKey = "no-key"
+
+// LlamaCppProvider implements the Provider interface for a llama.cpp server. Requests are
+// sent to the server's OpenAI-compatible chat endpoint, except requests with a Grammar,
+// which use the native /completion endpoint so decoding is constrained to the grammar.
+type LlamaCppProvider struct {
+	client *http.Client
+	config *ProviderConfig
+	logger *logrus.Logger
+	// chat serves requests without a grammar through /v1/chat/completions
+	chat *OpenAIProvider
+}
+
+// LlamaCppCompletionRequest represents a request to the native /completion endpoint
+type LlamaCppCompletionRequest struct {
+	Prompt      string   `json:"prompt"`
+	Grammar     string   `json:"grammar,omitempty"`
+	NPredict    int      `json:"n_predict,omitempty"`
+	Temperature float64  `json:"temperature,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+	Stream      bool     `json:"stream"`
+	// Extra holds further sampling options, such as top_k, min_p or repeat_penalty, sent
+	// alongside the fields above
+	Extra map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON encodes the request with its extra options; the typed fields win over extra
+// options of the same name
+func (r LlamaCppCompletionRequest) MarshalJSON() ([]byte, error) {
+	type request LlamaCppCompletionRequest
+	data, err := json.Marshal(request(r))
+	if err != nil || len(r.Extra) == 0 {
+		return data, err
+	}
+
+	merged := make(map[string]interface{}, len(r.Extra))
+	for key, value := range r.Extra {
+		merged[key] = value
+	}
+	var typed map[string]interface{}
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return nil, err
+	}
+	for key, value := range typed {
+		merged[key] = value
+	}
+	return json.Marshal(merged)
+}
+
+// LlamaCppCompletionResponse represents a response, or a streamed chunk, of /completion
+type LlamaCppCompletionResponse struct {
+	Content         string `json:"content"`
+	Model           string `json:"model,omitempty"`
+	Stop            bool   `json:"stop"`
+	StoppedLimit    bool   `json:"stopped_limit,omitempty"`
+	TokensPredicted int    `json:"tokens_predicted,omitempty"`
+	TokensEvaluated int    `json:"tokens_evaluated,omitempty"`
+}
+
+// NewLlamaCppProvider creates a new llama.cpp provider. The endpoint defaults to the
+// server's default address; the API key is only needed when the server requires one.
+func NewLlamaCppProvider(config *ProviderConfig) (*LlamaCppProvider, error) {
+	endpoint := strings.TrimSuffix(config.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = "http://localhost:8080"
+	}
+	config.Endpoint = endpoint
+
+	apiKey := config.APIKey
+	if apiKey == "" {
+		apiKey = llamaCppPlaceholderKey
+	}
+	chatConfig := *config
+	chatConfig.APIKey = apiKey
+	chatConfig.Endpoint = endpoint + "/v1"
+	chat, err := NewOpenAIProvider(&chatConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create llama.cpp chat client: %w", err)
+	}
+
+	return &LlamaCppProvider{
+		client: &http.Client{Timeout: config.Timeout},
+		config: config,
+		logger: logrus.New(),
+		chat:   chat,
+	}, nil
+}
+
+// GetName returns the provider name
+func (p *LlamaCppProvider) GetName() string {
+	return "llamacpp"
+}
+
+// GetModels returns the model loaded by the server
+func (p *LlamaCppProvider) GetModels(ctx context.Context) ([]string, error) {
+	return p.chat.GetModels(ctx)
+}
+
+// Complete generates a completion, constrained to req.Grammar when one is set
+func (p *LlamaCppProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	if req.Grammar == "" {
+		return p.chat.Complete(ctx, req)
+	}
+
+	nativeReq, err := p.convertToLlamaCppRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.post(ctx, nativeReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var completion LlamaCppCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return p.convertFromLlamaCppResponse(completion, false), nil
+}
+
+// CompleteStream generates a streaming completion, constrained to req.Grammar when one is set
+func (p *LlamaCppProvider) CompleteStream(ctx context.Context, req CompletionRequest, callback StreamCallback) error {
+	if req.Grammar == "" {
+		return p.chat.CompleteStream(ctx, req, callback)
+	}
+
+	nativeReq, err := p.convertToLlamaCppRequest(ctx, req)
+	if err != nil {
+		return err
+	}
+	nativeReq.Stream = true
+	resp, err := p.post(ctx, nativeReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// The server streams server-sent events, one chunk per data line
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, found := strings.CutPrefix(scanner.Text(), "data: ")
+		if !found {
+			continue
+		}
+		var chunk LlamaCppCompletionResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("failed to decode stream response: %w", err)
+		}
+		if err := callback(*p.convertFromLlamaCppResponse(chunk, true)); err != nil {
+			return fmt.Errorf("callback error: %w", err)
+		}
+		if chunk.Stop {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stream response: %w", err)
+	}
+	return nil
+}
+
+// post sends a request to the native /completion endpoint
+func (p *LlamaCppProvider) post(ctx context.Context, nativeReq LlamaCppCompletionRequest) (*http.Response, error) {
+	reqBody, err := json.Marshal(nativeReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"endpoint":       p.config.Endpoint + "/completion",
+		"grammar_length": len(nativeReq.Grammar),
+	}).Debug("Sending grammar-constrained request to llama.cpp")
+
+	resp, err := p.do(ctx, "/completion", reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("llama.cpp API error: status %d, body: %s", resp.StatusCode, string(body))
+	}
+	return resp, nil
+}
+
+// do sends a JSON request to the server with the configured headers
+func (p *LlamaCppProvider) do(ctx context.Context, path string, body []byte) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.config.Endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.config.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	}
+	for key, value := range p.config.Headers {
+		httpReq.Header.Set(key, value)
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	return resp, nil
+}
+
+// applyTemplate renders messages into a prompt with the chat template of the loaded model,
+// using the server's /apply-template endpoint
+func (p *LlamaCppProvider) applyTemplate(ctx context.Context, messages []Message) (string, error) {
+	type templateMessage struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	templateMessages := make([]templateMessage, 0, len(messages))
+	for _, msg := range messages {
+		templateMessages = append(templateMessages, templateMessage{Role: msg.Role, Content: msg.Content})
+	}
+	body, err := json.Marshal(map[string]interface{}{"messages": templateMessages})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal template request: %w", err)
+	}
+
+	resp, err := p.do(ctx, "/apply-template", body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("llama.cpp template error: status %d, body: %s", resp.StatusCode, string(data))
+	}
+
+	var rendered struct {
+		Prompt string `json:"prompt"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rendered); err != nil {
+		return "", fmt.Errorf("failed to decode template response: %w", err)
+	}
+	return rendered.Prompt, nil
+}
+
+// convertToLlamaCppRequest converts our request format to a native /completion request.
+// Tools are not sent, since the grammar decides the shape of the answer.
+func (p *LlamaCppProvider) convertToLlamaCppRequest(ctx context.Context, req CompletionRequest) (LlamaCppCompletionRequest, error) {
+	req = ApplyDefaultParams(req, p.config.DefaultParams)
+
+	messages := req.Messages
+	if req.SystemPrompt != "" {
+		messages = append([]Message{{Role: "system", Content: req.SystemPrompt}}, messages...)
+	}
+	prompt, err := p.applyTemplate(ctx, messages)
+	if err != nil {
+		return LlamaCppCompletionRequest{}, err
+	}
+
+	temperature := req.Temperature
+	if temperature == 0 {
+		temperature = p.config.Temperature
+	}
+	maxTokens := req.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = p.config.MaxTokens
+	}
+
+	nativeReq := LlamaCppCompletionRequest{
+		Prompt:      prompt,
+		Grammar:     req.Grammar,
+		NPredict:    maxTokens,
+		Temperature: temperature,
+		Stop:        req.StopSequences,
+		Seed:        req.Seed,
+	}
+	if len(req.Params) > 0 {
+		nativeReq.Extra = req.Params
+	}
+	return nativeReq, nil
+}
+
+// convertFromLlamaCppResponse converts a /completion response or streamed chunk to our format
+func (p *LlamaCppProvider) convertFromLlamaCppResponse(resp LlamaCppCompletionResponse, chunk bool) *CompletionResponse {
+	choice := Choice{Index: 0}
+	message := Message{Role: "assistant", Content: resp.Content}
+	if chunk {
+		choice.Delta = message
+	} else {
+		choice.Message = message
+	}
+	if resp.Stop {
+		choice.FinishReason = "stop"
+		if resp.StoppedLimit {
+			choice.FinishReason = "length"
+		}
+	}
+
+	object := "text_completion"
+	if chunk {
+		object = "text_completion.chunk"
+	}
+	return &CompletionResponse{
+		ID:      fmt.Sprintf("llamacpp-%d", time.Now().UnixNano()),
+		Object:  object,
+		Created: time.Now().Unix(),
+		Model:   resp.Model,
+		Choices: []Choice{choice},
+		Usage: Usage{
+			PromptTokens:     resp.TokensEvaluated,
+			CompletionTokens: resp.TokensPredicted,
+			TotalTokens:      resp.TokensEvaluated + resp.TokensPredicted,
+		},
+	}
+}
+
+// IsHealthy checks that the server is up and its model is loaded
+func (p *LlamaCppProvider) IsHealthy(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.config.Endpoint+"/health", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create health check request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("llama.cpp health check failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("llama.cpp health check failed: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// GetConfig returns provider configuration
+func (p *LlamaCppProvider) GetConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        p.GetName(),
+		"endpoint":    p.config.Endpoint,
+		"model":       p.config.Model,
+		"temperature": p.config.Temperature,
+		"max_tokens":  p.config.MaxTokens,
+		"timeout":     p.config.Timeout,
+	}
+}
+
+// SetConfig updates provider configuration
+func (p *LlamaCppProvider) SetConfig(config map[string]interface{}) error {
+	if model, ok := config["model"].(string); ok {
+		p.config.Model = model
+	}
+	if temp, ok := config["temperature"].(float64); ok {
+		p.config.Temperature = temp
+	}
+	if maxTokens, ok := config["max_tokens"].(int); ok {
+		p.config.MaxTokens = maxTokens
+	}
+	if timeout, ok := config["timeout"].(time.Duration); ok {
+		p.config.Timeout = timeout
+		p.client.Timeout = timeout
+	}
+	return p.chat.SetConfig(config)
+}
+
+// Close closes the provider
+func (p *LlamaCppProvider) Close() error {
+	return nil
+}
+
+// SupportsStreaming returns whether the provider supports streaming
+func (p *LlamaCppProvider) SupportsStreaming() bool {
+	return true
+}
+
+// GetStreamingConfig returns the current streaming configuration
+func (p *LlamaCppProvider) GetStreamingConfig() *StreamingConfig {
+	if p.config.Streaming == nil {
+		p.config.Streaming = DefaultStreamingConfig()
+	}
+	return p.config.Streaming
+}
+
+// SetStreamingConfig updates the streaming configuration
+func (p *LlamaCppProvider) SetStreamingConfig(config *StreamingConfig) error {
+	if config == nil {
+		return fmt.Errorf("streaming config cannot be nil")
+	}
+	p.config.Streaming = config
+	return nil
+}
+
+// CompleteWithMode generates a completion with explicit streaming mode
+func (p *LlamaCppProvider) CompleteWithMode(ctx context.Context, req CompletionRequest, mode StreamMode) (*CompletionResponse, error) {
+	if req.Grammar == "" {
+		return p.chat.CompleteWithMode(ctx, req, mode)
+	}
+	switch mode {
+	case StreamModeForced:
+		return p.completeStreamingCollected(ctx, req)
+	case StreamModeAuto:
+		if req.Stream {
+			return p.completeStreamingCollected(ctx, req)
+		}
+		return p.Complete(ctx, req)
+	default:
+		return p.Complete(ctx, req)
+	}
+}
+
+// CompleteStreamWithMode generates a streaming completion with explicit mode
+func (p *LlamaCppProvider) CompleteStreamWithMode(ctx context.Context, req CompletionRequest, callback StreamCallback, mode StreamMode) error {
+	if req.Grammar == "" {
+		return p.chat.CompleteStreamWithMode(ctx, req, callback, mode)
+	}
+	if mode == StreamModeNone {
+		resp, err := p.Complete(ctx, req)
+		if err != nil {
+			return err
+		}
+		return callback(*resp)
+	}
+	return p.CompleteStream(ctx, req, callback)
+}
+
+// completeStreamingCollected streams a grammar-constrained completion and collects the
+// chunks into one response
+func (p *LlamaCppProvider) completeStreamingCollected(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	var content strings.Builder
+	var last *CompletionResponse
+
+	err := p.CompleteStream(ctx, req, func(chunk CompletionResponse) error {
+		if len(chunk.Choices) > 0 {
+			content.WriteString(chunk.Choices[0].Delta.Content)
+			last = &chunk
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if last == nil {
+		return nil, fmt.Errorf("llama.cpp stream ended without a response")
+	}
+
+	last.Choices[0].Message = Message{Role: "assistant", Content: content.String()}
+	last.Object = "text_completion"
+	last.Choices[0].Delta = Message{}
+	return last, nil
+}
+
+// SupportsToolCalls returns whether the provider supports tool calls; the server must be
+// started with --jinja for the chat endpoint to accept tools
+func (p *LlamaCppProvider) SupportsToolCalls() bool {
+	return true
+}
+
+// Capabilities returns the capabilities of the loaded model
+func (p *LlamaCppProvider) Capabilities() ProviderCapabilities {
+	return p.ModelCapabilities(p.config.Model)
+}
+
+// ModelCapabilities returns the capabilities of a specific model. The context size is set
+// when the server starts, so it is unknown unless configured in ContextWindows.
+func (p *LlamaCppProvider) ModelCapabilities(model string) ProviderCapabilities {
+	return ProviderCapabilities{
+		SupportsStreaming: p.SupportsStreaming(),
+		SupportsTools:     p.SupportsToolCalls(),
+		MaxContextTokens:  p.config.contextWindowOverride(model),
+	}
+}
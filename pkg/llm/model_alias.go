@@ -0,0 +1,102 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AliasProvider is the provider name of requests whose model is an alias. Agents configured
+// with provider "alias" and model "fast" run on whatever provider and model "fast" maps to
+// when each call is made.
+const AliasProvider = "alias"
+
+// ModelAlias is the provider and model an alias resolves to
+type ModelAlias struct {
+	Provider string `json:"provider" yaml:"provider"`
+	Model    string `json:"model" yaml:"model"`
+}
+
+// String returns the alias target as "provider:model"
+func (a ModelAlias) String() string {
+	return a.Provider + ":" + a.Model
+}
+
+// ParseModelAlias parses an alias target written as "provider:model", e.g. "openai:gpt-4o-mini"
+func ParseModelAlias(target string) (ModelAlias, error) {
+	provider, model, found := strings.Cut(strings.TrimSpace(target), ":")
+	if !found || provider == "" || model == "" {
+		return ModelAlias{}, fmt.Errorf("invalid model alias target %q, expected provider:model", target)
+	}
+	if provider == AliasProvider {
+		return ModelAlias{}, fmt.Errorf("model alias target %q must name a provider, not another alias", target)
+	}
+	return ModelAlias{Provider: provider, Model: model}, nil
+}
+
+// SetModelAlias maps an alias to a provider and model
+func (pm *ProviderManager) SetModelAlias(alias string, target ModelAlias) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if pm.modelAliases == nil {
+		pm.modelAliases = make(map[string]ModelAlias)
+	}
+	pm.modelAliases[alias] = target
+}
+
+// SetModelAliases replaces every alias at once, e.g. when reloading the configuration.
+// Calls in flight keep the target they resolved.
+func (pm *ProviderManager) SetModelAliases(aliases map[string]ModelAlias) {
+	replacement := make(map[string]ModelAlias, len(aliases))
+	for alias, target := range aliases {
+		replacement[alias] = target
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.modelAliases = replacement
+	pm.logger.WithField("aliases", len(replacement)).Info("Model aliases loaded")
+}
+
+// ModelAliases returns a copy of the defined aliases
+func (pm *ProviderManager) ModelAliases() map[string]ModelAlias {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	aliases := make(map[string]ModelAlias, len(pm.modelAliases))
+	for alias, target := range pm.modelAliases {
+		aliases[alias] = target
+	}
+	return aliases
+}
+
+// ResolveModel returns the provider and model a request runs on: the target of the alias
+// when the provider is AliasProvider, otherwise the given provider and model
+func (pm *ProviderManager) ResolveModel(providerName, model string) (string, string, error) {
+	if providerName != AliasProvider {
+		return providerName, model, nil
+	}
+
+	pm.mu.RLock()
+	target, exists := pm.modelAliases[model]
+	pm.mu.RUnlock()
+	if !exists {
+		return "", "", fmt.Errorf("model alias %s not defined", model)
+	}
+	return target.Provider, target.Model, nil
+}
+
+// resolveRequest resolves the alias of a request's model
+func (pm *ProviderManager) resolveRequest(providerName string, req CompletionRequest) (string, CompletionRequest, error) {
+	providerName, model, err := pm.ResolveModel(providerName, req.Model)
+	if err != nil {
+		return "", req, err
+	}
+	req.Model = model
+	return providerName, req, nil
+}
@@ -13,7 +13,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -26,12 +28,21 @@ type OllamaProvider struct {
 	logger   *logrus.Logger
 	models   []string
 	lastSync time.Time
+
+	// contextWindows caches the context window read from /api/show per model; the lookups in
+	// flight are closed once their model is cached, so a model is only looked up once
+	contextWindows       map[string]int
+	contextWindowLookups map[string]chan struct{}
+	contextWindowsMu     sync.Mutex
 }
 
-// OllamaRequest represents an Ollama API request
+// OllamaRequest represents an Ollama API request, to /api/chat, or to /api/generate with a
+// raw prompt formatted by a chat template
 type OllamaRequest struct {
 	Model     string          `json:"model"`
-	Messages  []OllamaMessage `json:"messages"`
+	Messages  []OllamaMessage `json:"messages,omitempty"`
+	Prompt    string          `json:"prompt,omitempty"`
+	Raw       bool            `json:"raw,omitempty"`
 	Stream    bool            `json:"stream,omitempty"`
 	Options   OllamaOptions   `json:"options,omitempty"`
 	Format    string          `json:"format,omitempty"`
@@ -51,6 +62,33 @@ type OllamaOptions struct {
 	TopK        int      `json:"top_k,omitempty"`
 	NumPredict  int      `json:"num_predict,omitempty"`
 	Stop        []string `json:"stop,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+	// Extra holds further options, such as num_ctx or repeat_penalty, sent alongside the
+	// fields above
+	Extra map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON encodes the options with their extra options; the typed fields win over
+// extra options of the same name
+func (o OllamaOptions) MarshalJSON() ([]byte, error) {
+	type options OllamaOptions
+	data, err := json.Marshal(options(o))
+	if err != nil || len(o.Extra) == 0 {
+		return data, err
+	}
+
+	merged := make(map[string]interface{}, len(o.Extra))
+	for key, value := range o.Extra {
+		merged[key] = value
+	}
+	var typed map[string]interface{}
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return nil, err
+	}
+	for key, value := range typed {
+		merged[key] = value
+	}
+	return json.Marshal(merged)
 }
 
 // OllamaResponse represents an Ollama API response
@@ -58,10 +96,24 @@ type OllamaResponse struct {
 	Model     string        `json:"model"`
 	CreatedAt time.Time     `json:"created_at"`
 	Message   OllamaMessage `json:"message"`
+	Response  string        `json:"response,omitempty"` // Answer of /api/generate
 	Done      bool          `json:"done"`
 	Error     string        `json:"error,omitempty"`
 }
 
+// path returns the endpoint of a request: /api/generate for raw prompts, /api/chat otherwise
+func (r OllamaRequest) path() string {
+	if r.Raw {
+		return "/api/generate"
+	}
+	return "/api/chat"
+}
+
+// content returns the text of a response from either endpoint
+func (r OllamaResponse) content() string {
+	return r.Message.Content + r.Response
+}
+
 // OllamaModelInfo represents information about an Ollama model
 type OllamaModelInfo struct {
 	Name       string    `json:"name"`
@@ -70,6 +122,25 @@ type OllamaModelInfo struct {
 	Digest     string    `json:"digest"`
 }
 
+// OllamaShowResponse represents the details of a model returned by /api/show
+type OllamaShowResponse struct {
+	// Parameters are the PARAMETER lines of the Modelfile, one "name value" pair per line
+	Parameters string                 `json:"parameters"`
+	ModelInfo  map[string]interface{} `json:"model_info"`
+}
+
+// NumCtx returns the num_ctx parameter of the model, or 0 when the Modelfile sets none
+func (r OllamaShowResponse) NumCtx() int {
+	for _, line := range strings.Split(r.Parameters, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "num_ctx" {
+			numCtx, _ := strconv.Atoi(fields[1])
+			return numCtx
+		}
+	}
+	return 0
+}
+
 // OllamaModelsResponse represents the response from the models endpoint
 type OllamaModelsResponse struct {
 	Models []OllamaModelInfo `json:"models"`
@@ -151,11 +222,11 @@ func (p *OllamaProvider) Complete(ctx context.Context, req CompletionRequest) (*
 
 	// Log request being sent to Ollama
 	p.logger.WithFields(logrus.Fields{
-		"endpoint": p.config.Endpoint + "/api/chat",
+		"endpoint": p.config.Endpoint + ollamaReq.path(),
 		"model":    ollamaReq.Model,
 	}).Debug("Sending request to Ollama")
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.config.Endpoint+"/api/chat", bytes.NewBuffer(reqBody))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.config.Endpoint+ollamaReq.path(), bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -176,7 +247,7 @@ func (p *OllamaProvider) Complete(ctx context.Context, req CompletionRequest) (*
 	// Read all streaming chunks until done=true
 	var completeResponse strings.Builder
 	var finalModel string
-	var finalRole string
+	finalRole := "assistant"
 
 	decoder := json.NewDecoder(resp.Body)
 	for {
@@ -193,9 +264,11 @@ func (p *OllamaProvider) Complete(ctx context.Context, req CompletionRequest) (*
 		}
 
 		// Accumulate the response content
-		completeResponse.WriteString(ollamaResp.Message.Content)
+		completeResponse.WriteString(ollamaResp.content())
 		finalModel = ollamaResp.Model
-		finalRole = ollamaResp.Message.Role
+		if ollamaResp.Message.Role != "" {
+			finalRole = ollamaResp.Message.Role
+		}
 
 		// Break when done
 		if ollamaResp.Done {
@@ -235,7 +308,7 @@ func (p *OllamaProvider) CompleteStream(ctx context.Context, req CompletionReque
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.config.Endpoint+"/api/chat", bytes.NewBuffer(reqBody))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.config.Endpoint+ollamaReq.path(), bytes.NewBuffer(reqBody))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -355,6 +428,8 @@ func (p *OllamaProvider) Close() error {
 
 // convertToOllamaRequest converts our request format to Ollama format
 func (p *OllamaProvider) convertToOllamaRequest(req CompletionRequest) OllamaRequest {
+	req = ApplyDefaultParams(req, p.config.DefaultParams)
+
 	var systemPrompt string
 	var filteredMessages []OllamaMessage
 
@@ -439,9 +514,24 @@ func (p *OllamaProvider) convertToOllamaRequest(req CompletionRequest) OllamaReq
 			Temperature: temperature,
 			NumPredict:  maxTokens,
 			Stop:        req.StopSequences,
+			Seed:        req.Seed,
 		},
 		KeepAlive: "5m",
 	}
+	p.applyChatTemplate(&ollamaReq, req)
+	for key, value := range req.Params {
+		switch key {
+		case ParamKeepAlive:
+			ollamaReq.KeepAlive = fmt.Sprint(value)
+		case ParamFormat:
+			ollamaReq.Format = fmt.Sprint(value)
+		default:
+			if ollamaReq.Options.Extra == nil {
+				ollamaReq.Options.Extra = make(map[string]interface{})
+			}
+			ollamaReq.Options.Extra[key] = value
+		}
+	}
 
 	// Log request details
 	p.logger.WithFields(logrus.Fields{
@@ -454,6 +544,30 @@ func (p *OllamaProvider) convertToOllamaRequest(req CompletionRequest) OllamaReq
 	return ollamaReq
 }
 
+// applyChatTemplate turns a request into a raw prompt for /api/generate when a chat template
+// is configured for its model, so the model sees the exact format it was trained on. The
+// request stays on /api/chat when the template fails.
+func (p *OllamaProvider) applyChatTemplate(ollamaReq *OllamaRequest, req CompletionRequest) {
+	chatTemplate, err := resolveChatTemplate(p.config, ollamaReq.Model)
+	if err == nil && chatTemplate != nil {
+		messages := req.Messages
+		if req.SystemPrompt != "" {
+			messages = append([]Message{{Role: "system", Content: req.SystemPrompt}}, messages...)
+		}
+		var prompt string
+		if prompt, err = chatTemplate.Format(messages); err == nil {
+			ollamaReq.Messages = nil
+			ollamaReq.Prompt = prompt
+			ollamaReq.Raw = true
+			ollamaReq.Options.Stop = append(append([]string(nil), ollamaReq.Options.Stop...), chatTemplate.Stop...)
+			return
+		}
+	}
+	if err != nil {
+		p.logger.WithError(err).WithField("model", ollamaReq.Model).Warn("Chat template not applied, using the chat endpoint")
+	}
+}
+
 // convertFromOllamaResponse converts Ollama response to our format
 func (p *OllamaProvider) convertFromOllamaResponse(resp OllamaResponse) *CompletionResponse {
 	message := Message{
@@ -488,7 +602,10 @@ func (p *OllamaProvider) convertFromOllamaResponse(resp OllamaResponse) *Complet
 func (p *OllamaProvider) convertFromOllamaStreamResponse(resp OllamaResponse) CompletionResponse {
 	delta := Message{
 		Role:    resp.Message.Role,
-		Content: resp.Message.Content,
+		Content: resp.content(),
+	}
+	if delta.Role == "" {
+		delta.Role = "assistant"
 	}
 
 	choice := Choice{
@@ -631,20 +748,112 @@ func (p *OllamaProvider) SupportsToolCalls() bool {
 	return false // Ollama doesn't support tool calls natively
 }
 
-// GetMaxTokens returns the maximum tokens for a model
+// Capabilities returns the capabilities of the configured default model
+func (p *OllamaProvider) Capabilities() ProviderCapabilities {
+	return p.ModelCapabilities(p.config.Model)
+}
+
+// ModelCapabilities returns the capabilities of a specific model
+func (p *OllamaProvider) ModelCapabilities(model string) ProviderCapabilities {
+	return ProviderCapabilities{
+		SupportsStreaming:  p.SupportsStreaming(),
+		SupportsTools:      p.SupportsToolCalls(),
+		SupportsEmbeddings: true,
+		SupportsVision:     strings.Contains(model, "llava") || strings.Contains(model, "vision"),
+		MaxContextTokens:   p.GetMaxTokens(model),
+	}
+}
+
+// GetMaxTokens returns the context window of a model: the override in ContextWindows, the
+// num_ctx sent with every request through DefaultParams, the num_ctx of the model read from
+// /api/show, else the lookup table. Ollama truncates prompts to num_ctx regardless of what
+// the model was trained on, so that is the window that counts.
 func (p *OllamaProvider) GetMaxTokens(model string) int {
-	switch {
-	case strings.Contains(model, "70b"):
-		return 4096
-	case strings.Contains(model, "34b"):
-		return 4096
-	case strings.Contains(model, "13b"):
-		return 4096
-	case strings.Contains(model, "7b"):
-		return 4096
-	default:
-		return 4096
+	if tokens := p.config.contextWindowOverride(model); tokens > 0 {
+		return tokens
+	}
+	if numCtx, ok := paramInt(p.config.DefaultParams["num_ctx"]); ok && numCtx > 0 {
+		return numCtx
+	}
+
+	tokens := p.config.contextWindow(model)
+	if model == "" {
+		return tokens
+	}
+
+	// The lock is not held during the request, so lookups of other models do not wait on it
+	p.contextWindowsMu.Lock()
+	if cachedTokens, cached := p.contextWindows[model]; cached {
+		p.contextWindowsMu.Unlock()
+		return cachedTokens
+	}
+	if pending, inFlight := p.contextWindowLookups[model]; inFlight {
+		p.contextWindowsMu.Unlock()
+		<-pending
+		p.contextWindowsMu.Lock()
+		defer p.contextWindowsMu.Unlock()
+		return p.contextWindows[model]
 	}
+	if p.contextWindowLookups == nil {
+		p.contextWindowLookups = make(map[string]chan struct{})
+	}
+	done := make(chan struct{})
+	p.contextWindowLookups[model] = done
+	p.contextWindowsMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), ollamaShowTimeout)
+	defer cancel()
+	show, err := p.ShowModel(ctx, model)
+	if err != nil {
+		p.logger.WithError(err).WithField("model", model).Debug("Could not read the context window of the model, using the lookup table")
+	} else if numCtx := show.NumCtx(); numCtx > 0 {
+		tokens = numCtx
+	}
+
+	// Failures are cached too, so an unreachable server costs a single request per model
+	p.contextWindowsMu.Lock()
+	defer p.contextWindowsMu.Unlock()
+	if p.contextWindows == nil {
+		p.contextWindows = make(map[string]int)
+	}
+	p.contextWindows[model] = tokens
+	delete(p.contextWindowLookups, model)
+	close(done)
+	return tokens
+}
+
+// ollamaShowTimeout bounds the /api/show request made when capabilities are first queried
+const ollamaShowTimeout = 5 * time.Second
+
+// ShowModel returns the details of a model from /api/show
+func (p *OllamaProvider) ShowModel(ctx context.Context, model string) (*OllamaShowResponse, error) {
+	body, err := json.Marshal(map[string]string{"model": model})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal show request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.Endpoint+"/api/show", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create show request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to show model: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to show model: status %d, body: %s", resp.StatusCode, string(respBody))
+	}
+
+	var show OllamaShowResponse
+	if err := json.NewDecoder(resp.Body).Decode(&show); err != nil {
+		return nil, fmt.Errorf("failed to decode show response: %w", err)
+	}
+	return &show, nil
 }
 
 // GetTokenLimit returns the token limit for a model
@@ -745,6 +954,51 @@ func (p *OllamaProvider) PullModel(ctx context.Context, model string) error {
 	return nil
 }
 
+// Embed generates embeddings for the given texts
+func (p *OllamaProvider) Embed(ctx context.Context, model string, texts []string) ([][]float64, error) {
+	embeddings := make([][]float64, 0, len(texts))
+
+	for _, text := range texts {
+		body, err := json.Marshal(map[string]string{
+			"model":  model,
+			"prompt": text,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal embedding request: %w", err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.Endpoint+"/api/embeddings", bytes.NewBuffer(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create embedding request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate embedding: %w", err)
+		}
+
+		var embedResp struct {
+			Embedding []float64 `json:"embedding"`
+		}
+		if resp.StatusCode != http.StatusOK {
+			respBody, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to generate embedding: status %d, body: %s", resp.StatusCode, string(respBody))
+		}
+		err = json.NewDecoder(resp.Body).Decode(&embedResp)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode embedding response: %w", err)
+		}
+
+		embeddings = append(embeddings, embedResp.Embedding)
+	}
+
+	return embeddings, nil
+}
+
 // DeleteModel deletes a model from Ollama
 func (p *OllamaProvider) DeleteModel(ctx context.Context, model string) error {
 	reqBody := map[string]string{
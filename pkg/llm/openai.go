@@ -8,6 +8,7 @@ package llm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -80,18 +81,105 @@ func (p *OpenAIProvider) Complete(ctx context.Context, req CompletionRequest) (*
 
 	resp, err := p.client.CreateChatCompletion(ctx, openaiReq)
 	if err != nil {
+		if blocked := openAIPromptBlock(err); blocked != nil {
+			return nil, blocked
+		}
 		return nil, fmt.Errorf("OpenAI completion failed: %w", err)
 	}
+	for _, choice := range resp.Choices {
+		if choice.FinishReason == openai.FinishReasonContentFilter {
+			return nil, &ContentBlockedError{
+				Provider:   p.GetName(),
+				Model:      resp.Model,
+				Stage:      ContentBlockedResponse,
+				Reason:     string(choice.FinishReason),
+				Categories: openAIFilterCategories(choice.ContentFilterResults),
+			}
+		}
+	}
 
 	return p.convertFromOpenAIResponse(resp), nil
 }
 
+// openAIPromptBlock returns the ContentBlockedError of an error refusing the prompt, with the
+// categories Azure OpenAI reports, or nil for other errors
+func openAIPromptBlock(err error) *ContentBlockedError {
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) {
+		return nil
+	}
+	code, _ := apiErr.Code.(string)
+	if code != "content_filter" && code != "content_policy_violation" && apiErr.InnerError == nil {
+		return nil
+	}
+
+	blocked := &ContentBlockedError{Provider: "openai", Stage: ContentBlockedPrompt, Reason: apiErr.Message, Err: err}
+	if apiErr.InnerError != nil {
+		blocked.Categories = openAIFilterCategories(apiErr.InnerError.ContentFilterResults)
+	}
+	return blocked
+}
+
+// openAIFilterCategories returns the content filter categories that were triggered
+func openAIFilterCategories(results openai.ContentFilterResults) []string {
+	var categories []string
+	for _, category := range []struct {
+		name     string
+		filtered bool
+	}{
+		{"hate", results.Hate.Filtered},
+		{"self_harm", results.SelfHarm.Filtered},
+		{"sexual", results.Sexual.Filtered},
+		{"violence", results.Violence.Filtered},
+		{"jailbreak", results.JailBreak.Filtered},
+		{"profanity", results.Profanity.Filtered},
+	} {
+		if category.filtered {
+			categories = append(categories, category.name)
+		}
+	}
+	return categories
+}
+
+// Embed generates embeddings for the given texts
+func (p *OpenAIProvider) Embed(ctx context.Context, model string, texts []string) ([][]float64, error) {
+	resp, err := p.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input: texts,
+		Model: openai.EmbeddingModel(model),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("OpenAI embedding failed: %w", err)
+	}
+
+	embeddings := make([][]float64, len(texts))
+	for _, data := range resp.Data {
+		if data.Index < 0 || data.Index >= len(embeddings) {
+			return nil, fmt.Errorf("OpenAI embedding returned unexpected index %d", data.Index)
+		}
+		vector := make([]float64, len(data.Embedding))
+		for i, value := range data.Embedding {
+			vector[i] = float64(value)
+		}
+		embeddings[data.Index] = vector
+	}
+
+	return embeddings, nil
+}
+
+// MaxEmbeddingBatchSize returns the maximum number of inputs per embeddings request
+func (p *OpenAIProvider) MaxEmbeddingBatchSize() int {
+	return 2048
+}
+
 // CompleteStream generates a streaming completion
 func (p *OpenAIProvider) CompleteStream(ctx context.Context, req CompletionRequest, callback StreamCallback) error {
 	openaiReq := p.convertToOpenAIRequest(req)
 
 	stream, err := p.client.CreateChatCompletionStream(ctx, openaiReq)
 	if err != nil {
+		if blocked := openAIPromptBlock(err); blocked != nil {
+			return blocked
+		}
 		return fmt.Errorf("OpenAI streaming failed: %w", err)
 	}
 	defer stream.Close()
@@ -104,6 +192,17 @@ func (p *OpenAIProvider) CompleteStream(ctx context.Context, req CompletionReque
 			}
 			return fmt.Errorf("stream error: %w", err)
 		}
+		for _, choice := range response.Choices {
+			if choice.FinishReason == openai.FinishReasonContentFilter {
+				return &ContentBlockedError{
+					Provider:   p.GetName(),
+					Model:      response.Model,
+					Stage:      ContentBlockedResponse,
+					Reason:     string(choice.FinishReason),
+					Categories: openAIFilterCategories(choice.ContentFilterResults),
+				}
+			}
+		}
 
 		// Convert to our format and call callback
 		converted := p.convertFromOpenAIStreamResponse(response)
@@ -175,6 +274,8 @@ func (p *OpenAIProvider) Close() error {
 
 // convertToOpenAIRequest converts our request format to OpenAI format
 func (p *OpenAIProvider) convertToOpenAIRequest(req CompletionRequest) openai.ChatCompletionRequest {
+	req = ApplyDefaultParams(req, p.config.DefaultParams)
+
 	messages := make([]openai.ChatCompletionMessage, len(req.Messages))
 	for i, msg := range req.Messages {
 		messages[i] = openai.ChatCompletionMessage{
@@ -212,6 +313,7 @@ func (p *OpenAIProvider) convertToOpenAIRequest(req CompletionRequest) openai.Ch
 		MaxTokens:   req.MaxTokens,
 		Stream:      req.Stream,
 		Stop:        req.StopSequences,
+		Seed:        req.Seed,
 	}
 
 	// Use default model if not specified
@@ -232,6 +334,19 @@ func (p *OpenAIProvider) convertToOpenAIRequest(req CompletionRequest) openai.Ch
 		openaiReq.MaxTokens = p.config.MaxTokens
 	}
 
+	p.applyOpenAIParams(&openaiReq, req.Params)
+
+	// Reasoning models take max_completion_tokens, which includes the thinking budget,
+	// and only support the default temperature
+	if isOpenAIReasoningModel(openaiReq.Model) {
+		openaiReq.ReasoningEffort = req.ReasoningEffort
+		if openaiReq.MaxTokens > 0 {
+			openaiReq.MaxCompletionTokens = openaiReq.MaxTokens + req.ThinkingBudgetTokens
+		}
+		openaiReq.MaxTokens = 0
+		openaiReq.Temperature = 0
+	}
+
 	// Convert tools
 	if len(req.Tools) > 0 {
 		tools := make([]openai.Tool, len(req.Tools))
@@ -308,20 +423,39 @@ func (p *OpenAIProvider) convertFromOpenAIResponse(resp openai.ChatCompletionRes
 	}
 
 	return &CompletionResponse{
-		ID:      resp.ID,
-		Object:  resp.Object,
-		Created: resp.Created,
-		Model:   resp.Model,
-		Choices: choices,
-		Usage: Usage{
-			PromptTokens:     resp.Usage.PromptTokens,
-			CompletionTokens: resp.Usage.CompletionTokens,
-			TotalTokens:      resp.Usage.TotalTokens,
-		},
+		ID:                resp.ID,
+		Object:            resp.Object,
+		Created:           resp.Created,
+		Model:             resp.Model,
+		Choices:           choices,
+		Usage:             convertOpenAIUsage(resp.Usage),
 		SystemFingerprint: resp.SystemFingerprint,
 	}
 }
 
+// convertOpenAIUsage converts OpenAI usage, including reasoning tokens, to our format
+func convertOpenAIUsage(usage openai.Usage) Usage {
+	converted := Usage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+	}
+	if usage.CompletionTokensDetails != nil {
+		converted.ReasoningTokens = usage.CompletionTokensDetails.ReasoningTokens
+	}
+	return converted
+}
+
+// isOpenAIReasoningModel reports whether a model is an o-series reasoning model
+func isOpenAIReasoningModel(model string) bool {
+	for _, prefix := range []string{"o1", "o3", "o4"} {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // convertFromOpenAIStreamResponse converts OpenAI stream response to our format
 func (p *OpenAIProvider) convertFromOpenAIStreamResponse(resp openai.ChatCompletionStreamResponse) CompletionResponse {
 	choices := make([]Choice, len(resp.Choices))
@@ -354,7 +488,7 @@ func (p *OpenAIProvider) convertFromOpenAIStreamResponse(resp openai.ChatComplet
 		}
 	}
 
-	return CompletionResponse{
+	response := CompletionResponse{
 		ID:                resp.ID,
 		Object:            resp.Object,
 		Created:           resp.Created,
@@ -362,6 +496,10 @@ func (p *OpenAIProvider) convertFromOpenAIStreamResponse(resp openai.ChatComplet
 		Choices:           choices,
 		SystemFingerprint: resp.SystemFingerprint,
 	}
+	if resp.Usage != nil {
+		response.Usage = convertOpenAIUsage(*resp.Usage)
+	}
+	return response
 }
 
 // GetDefaultModels returns commonly used OpenAI models
@@ -479,22 +617,37 @@ func (p *OpenAIProvider) SupportsToolCalls() bool {
 	return true
 }
 
-// GetMaxTokens returns the maximum tokens for a model
-func (p *OpenAIProvider) GetMaxTokens(model string) int {
-	switch {
-	case strings.Contains(model, "gpt-4-turbo"):
-		return 128000
-	case strings.Contains(model, "gpt-4"):
-		return 8192
-	case strings.Contains(model, "gpt-3.5-turbo-16k"):
-		return 16384
-	case strings.Contains(model, "gpt-3.5-turbo"):
-		return 4096
-	default:
-		return 4096
+// Capabilities returns the capabilities of the configured default model
+func (p *OpenAIProvider) Capabilities() ProviderCapabilities {
+	return p.ModelCapabilities(p.config.Model)
+}
+
+// ModelCapabilities returns the capabilities of a specific model
+func (p *OpenAIProvider) ModelCapabilities(model string) ProviderCapabilities {
+	return ProviderCapabilities{
+		SupportsStreaming:  p.SupportsStreaming(),
+		SupportsTools:      p.SupportsToolCalls(),
+		SupportsEmbeddings: true,
+		SupportsVision:     strings.Contains(model, "gpt-4o") || strings.Contains(model, "gpt-4-turbo") || strings.Contains(model, "vision"),
+		MaxContextTokens:   p.GetMaxTokens(model),
 	}
 }
 
+// IsContextLengthError reports whether an OpenAI error was caused by exceeding the context window
+func (p *OpenAIProvider) IsContextLengthError(err error) bool {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) && apiErr.Code == "context_length_exceeded" {
+		return true
+	}
+	return IsContextLengthError(err)
+}
+
+// GetMaxTokens returns the context window of a model: the override in ContextWindows, else
+// the lookup table
+func (p *OpenAIProvider) GetMaxTokens(model string) int {
+	return p.config.contextWindow(model)
+}
+
 // GetTokenLimit returns the token limit for a model
 func (p *OpenAIProvider) GetTokenLimit(model string) int {
 	return p.GetMaxTokens(model)
@@ -551,3 +704,29 @@ func (p *OpenAIProvider) GetProviderInfo() map[string]interface{} {
 		"api_version":        "v1",
 	}
 }
+
+// applyOpenAIParams sets the OpenAI-specific parameters of a request
+func (p *OpenAIProvider) applyOpenAIParams(openaiReq *openai.ChatCompletionRequest, params map[string]interface{}) {
+	for key, value := range params {
+		switch key {
+		case ParamTopP:
+			if f, ok := paramFloat(value); ok {
+				openaiReq.TopP = float32(f)
+			}
+		case ParamPresencePenalty:
+			if f, ok := paramFloat(value); ok {
+				openaiReq.PresencePenalty = float32(f)
+			}
+		case ParamFrequencyPenalty:
+			if f, ok := paramFloat(value); ok {
+				openaiReq.FrequencyPenalty = float32(f)
+			}
+		case ParamUser:
+			openaiReq.User = fmt.Sprint(value)
+		case ParamParallelToolCalls:
+			openaiReq.ParallelToolCalls = value
+		default:
+			p.logger.WithField("param", key).Debug("Ignoring parameter not recognized by OpenAI")
+		}
+	}
+}
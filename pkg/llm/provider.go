@@ -8,11 +8,16 @@ package llm
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
 )
 
 // Message represents a message in a conversation
@@ -23,6 +28,7 @@ type Message struct {
 	ToolCalls  []ToolCall             `json:"tool_calls,omitempty"`
 	ToolCallID string                 `json:"tool_call_id,omitempty"`
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Pinned     bool                   `json:"pinned,omitempty"` // Never trimmed from the context window
 }
 
 // ToolCall represents a tool call in a message
@@ -63,8 +69,34 @@ type CompletionRequest struct {
 	Stream        bool             `json:"stream,omitempty"`
 	SystemPrompt  string           `json:"system_prompt,omitempty"`
 	StopSequences []string         `json:"stop_sequences,omitempty"`
+	// MaxStreamTokens is a client-side cap on the approximate number of streamed tokens.
+	// Unlike MaxTokens it is enforced by cancelling the upstream request.
+	MaxStreamTokens int `json:"max_stream_tokens,omitempty"`
+	// StreamIdleTimeout fails a stream with ErrStreamStalled when no chunk arrives for this
+	// long; defaults to the manager's SetStreamIdleTimeout
+	StreamIdleTimeout time.Duration `json:"stream_idle_timeout,omitempty"`
+	// ReasoningEffort is passed to reasoning models (low, medium or high) and ignored by others
+	ReasoningEffort string `json:"reasoning_effort,omitempty"`
+	// ThinkingBudgetTokens caps the tokens a reasoning model may spend thinking
+	ThinkingBudgetTokens int `json:"thinking_budget_tokens,omitempty"`
+	// Seed requests deterministic sampling from providers that support it; others ignore it.
+	// Compare SystemFingerprint across responses to detect backend changes.
+	Seed *int `json:"seed,omitempty"`
+	// Params are provider-specific parameters, such as num_ctx for Ollama; they override the
+	// provider's DefaultParams, see ApplyDefaultParams
+	Params map[string]interface{} `json:"params,omitempty"`
+	// Grammar is a GBNF grammar the answer must follow, guaranteeing its structure. Only the
+	// llama.cpp provider supports it; other providers ignore it.
+	Grammar string `json:"grammar,omitempty"`
 }
 
+// Reasoning effort levels
+const (
+	ReasoningEffortLow    = "low"
+	ReasoningEffortMedium = "medium"
+	ReasoningEffortHigh   = "high"
+)
+
 // CompletionResponse represents a response from completion
 type CompletionResponse struct {
 	ID                string                 `json:"id"`
@@ -98,6 +130,13 @@ type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+	// ReasoningTokens is the part of CompletionTokens a reasoning model spent thinking
+	ReasoningTokens int `json:"reasoning_tokens,omitempty"`
+}
+
+// OutputTokens returns the completion tokens that were not spent on reasoning
+func (u Usage) OutputTokens() int {
+	return u.CompletionTokens - u.ReasoningTokens
 }
 
 // StreamCallback is called for each streaming chunk
@@ -141,10 +180,97 @@ type Provider interface {
 	// SetStreamingConfig updates the streaming configuration
 	SetStreamingConfig(config *StreamingConfig) error
 
+	// Capabilities returns the features supported by the provider's default model
+	Capabilities() ProviderCapabilities
+
 	// Close closes the provider and cleans up resources
 	Close() error
 }
 
+// Feature represents an optional provider feature
+type Feature string
+
+const (
+	FeatureStreaming  Feature = "streaming"
+	FeatureTools      Feature = "tools"
+	FeatureVision     Feature = "vision"
+	FeatureEmbeddings Feature = "embeddings"
+)
+
+// ProviderCapabilities describes the features supported by a provider or model
+type ProviderCapabilities struct {
+	SupportsStreaming  bool `json:"supports_streaming"`
+	SupportsTools      bool `json:"supports_tools"`
+	SupportsVision     bool `json:"supports_vision"`
+	SupportsEmbeddings bool `json:"supports_embeddings"`
+	MaxContextTokens   int  `json:"max_context_tokens"`
+}
+
+// Supports returns true if the given feature is supported
+func (c ProviderCapabilities) Supports(feature Feature) bool {
+	switch feature {
+	case FeatureStreaming:
+		return c.SupportsStreaming
+	case FeatureTools:
+		return c.SupportsTools
+	case FeatureVision:
+		return c.SupportsVision
+	case FeatureEmbeddings:
+		return c.SupportsEmbeddings
+	default:
+		return false
+	}
+}
+
+// ModelCapabilityProvider is implemented by providers whose capabilities vary by model
+type ModelCapabilityProvider interface {
+	// ModelCapabilities returns the features supported by the given model
+	ModelCapabilities(model string) ProviderCapabilities
+}
+
+// EmbeddingProvider is implemented by providers that can generate text embeddings
+type EmbeddingProvider interface {
+	// Embed returns one embedding vector per input text
+	Embed(ctx context.Context, model string, texts []string) ([][]float64, error)
+}
+
+// EmbeddingBatchLimiter is implemented by embedding providers that limit the number of
+// texts per Embed call
+type EmbeddingBatchLimiter interface {
+	// MaxEmbeddingBatchSize returns the maximum number of texts per call
+	MaxEmbeddingBatchSize() int
+}
+
+// UnsupportedFeatureError is returned when a provider or model lacks a requested feature
+type UnsupportedFeatureError struct {
+	Provider string
+	Model    string
+	Feature  Feature
+}
+
+// Error implements the error interface
+func (e *UnsupportedFeatureError) Error() string {
+	if e.Model != "" {
+		return fmt.Sprintf("provider %s does not support %s for model %s", e.Provider, e.Feature, e.Model)
+	}
+	return fmt.Sprintf("provider %s does not support %s", e.Provider, e.Feature)
+}
+
+// rateLimitStatusPattern matches a 429 status code but not the digits of a token count
+var rateLimitStatusPattern = regexp.MustCompile(`\b429\b`)
+
+// IsRateLimitError reports whether a provider rejected a request because of a rate limit
+func IsRateLimitError(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	return rateLimitStatusPattern.MatchString(message) ||
+		strings.Contains(message, "rate limit") ||
+		strings.Contains(message, "rate_limit") ||
+		strings.Contains(message, "too many requests")
+}
+
 // ProviderConfig represents provider configuration
 type ProviderConfig struct {
 	Name        string                 `json:"name"`
@@ -160,6 +286,17 @@ type ProviderConfig struct {
 	Headers     map[string]string      `json:"headers,omitempty"`
 	Streaming   *StreamingConfig       `json:"streaming,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	// DefaultParams are merged into every request to the provider; the request's fields and
+	// Params take precedence, see ApplyDefaultParams
+	DefaultParams map[string]interface{} `json:"default_params,omitempty"`
+	// ChatTemplates maps models, or "*" for all of them, to the chat template formatting their
+	// prompt: a built-in or registered template name, or "auto" to detect it from the model
+	// name. Providers with a raw completion endpoint, such as Ollama, then send the
+	// formatted prompt instead of calling their chat endpoint.
+	ChatTemplates map[string]string `json:"chat_templates,omitempty"`
+	// ContextWindows maps models, or "*" for all of them, to their context window in tokens,
+	// overriding the window detected from the server or the lookup table
+	ContextWindows map[string]int `json:"context_windows,omitempty"`
 }
 
 // DefaultProviderConfig returns default provider configuration
@@ -209,20 +346,48 @@ func DefaultStreamingConfig() *StreamingConfig {
 
 // ProviderManager manages multiple LLM providers
 type ProviderManager struct {
-	providers       map[string]Provider
-	defaultProvider string
-	mu              sync.RWMutex
-	logger          *logrus.Logger
+	providers         map[string]Provider
+	defaultProvider   string
+	modelCapabilities map[string]map[string]ProviderCapabilities
+	mu                sync.RWMutex
+	logger            *logrus.Logger
+
+	requestInterceptors  []RequestInterceptor
+	responseInterceptors []ResponseInterceptor
+
+	// Default idle timeout between stream chunks
+	streamIdleTimeout time.Duration
+
+	// Provider and model of each alias, see AliasProvider
+	modelAliases map[string]ModelAlias
 }
 
 // NewProviderManager creates a new provider manager
 func NewProviderManager() *ProviderManager {
 	return &ProviderManager{
-		providers: make(map[string]Provider),
-		logger:    logrus.New(),
+		providers:         make(map[string]Provider),
+		modelCapabilities: make(map[string]map[string]ProviderCapabilities),
+		logger:            logrus.New(),
 	}
 }
 
+// SetLogger sets the logger used by the provider manager
+func (pm *ProviderManager) SetLogger(logger *logrus.Logger) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.logger = logger
+}
+
+// logCall logs an outgoing LLM call with the correlation fields from the context
+func (pm *ProviderManager) logCall(ctx context.Context, provider Provider, req CompletionRequest) {
+	logging.FromContext(ctx, pm.logger).WithFields(logrus.Fields{
+		"provider": provider.GetName(),
+		"model":    req.Model,
+		"messages": len(req.Messages),
+		"tools":    len(req.Tools),
+	}).Debug("LLM call")
+}
+
 // RegisterProvider registers a new provider
 func (pm *ProviderManager) RegisterProvider(name string, provider Provider) error {
 	pm.mu.Lock()
@@ -327,8 +492,12 @@ func (pm *ProviderManager) ListProviders() []string {
 
 // Complete generates a completion using the specified provider (or default)
 func (pm *ProviderManager) Complete(ctx context.Context, providerName string, req CompletionRequest) (*CompletionResponse, error) {
+	providerName, req, err := pm.resolveRequest(providerName, req)
+	if err != nil {
+		return nil, err
+	}
+
 	var provider Provider
-	var err error
 
 	if providerName == "" {
 		provider, err = pm.GetDefaultProvider()
@@ -340,13 +509,27 @@ func (pm *ProviderManager) Complete(ctx context.Context, providerName string, re
 		return nil, err
 	}
 
-	return provider.Complete(ctx, req)
+	req = pm.interceptRequest(req)
+	pm.logCall(ctx, provider, req)
+	resp, err := pm.completeWithContextRetry(ctx, providerName, provider, req, func(req CompletionRequest) (*CompletionResponse, error) {
+		return provider.Complete(ctx, req)
+	})
+	resp, err = normalizeContentBlock(pm.resolveProviderName(providerName), req.Model, resp, err)
+	if err != nil {
+		return nil, err
+	}
+	pm.interceptResponse(resp)
+	return resp, nil
 }
 
 // CompleteStream generates a streaming completion using the specified provider (or default)
 func (pm *ProviderManager) CompleteStream(ctx context.Context, providerName string, req CompletionRequest, callback StreamCallback) error {
+	providerName, req, err := pm.resolveRequest(providerName, req)
+	if err != nil {
+		return err
+	}
+
 	var provider Provider
-	var err error
 
 	if providerName == "" {
 		provider, err = pm.GetDefaultProvider()
@@ -358,13 +541,30 @@ func (pm *ProviderManager) CompleteStream(ctx context.Context, providerName stri
 		return err
 	}
 
-	return provider.CompleteStream(ctx, req, callback)
+	req = pm.interceptRequest(req)
+	pm.logCall(ctx, provider, req)
+	name := pm.resolveProviderName(providerName)
+	err = pm.streamWithContextRetry(ctx, providerName, provider, req, blockingStream(name, req.Model, pm.interceptStream(callback)), func(req CompletionRequest, callback StreamCallback) error {
+		return limitStreamTokens(ctx, req.MaxStreamTokens, callback, func(ctx context.Context, callback StreamCallback) error {
+			return watchStreamIdle(ctx, pm.streamIdleTimeoutFor(req), callback, func(ctx context.Context, callback StreamCallback) error {
+				return provider.CompleteStream(ctx, req, callback)
+			})
+		})
+	})
+	if err != nil {
+		return contentBlockError(name, req.Model, err)
+	}
+	return nil
 }
 
 // CompleteWithMode generates a completion with explicit streaming mode
 func (pm *ProviderManager) CompleteWithMode(ctx context.Context, providerName string, req CompletionRequest, mode StreamMode) (*CompletionResponse, error) {
+	providerName, req, err := pm.resolveRequest(providerName, req)
+	if err != nil {
+		return nil, err
+	}
+
 	var provider Provider
-	var err error
 
 	if providerName == "" {
 		provider, err = pm.GetDefaultProvider()
@@ -376,13 +576,27 @@ func (pm *ProviderManager) CompleteWithMode(ctx context.Context, providerName st
 		return nil, err
 	}
 
-	return provider.CompleteWithMode(ctx, req, mode)
+	req = pm.interceptRequest(req)
+	pm.logCall(ctx, provider, req)
+	resp, err := pm.completeWithContextRetry(ctx, providerName, provider, req, func(req CompletionRequest) (*CompletionResponse, error) {
+		return provider.CompleteWithMode(ctx, req, mode)
+	})
+	resp, err = normalizeContentBlock(pm.resolveProviderName(providerName), req.Model, resp, err)
+	if err != nil {
+		return nil, err
+	}
+	pm.interceptResponse(resp)
+	return resp, nil
 }
 
 // CompleteStreamWithMode generates a streaming completion with explicit mode
 func (pm *ProviderManager) CompleteStreamWithMode(ctx context.Context, providerName string, req CompletionRequest, callback StreamCallback, mode StreamMode) error {
+	providerName, req, err := pm.resolveRequest(providerName, req)
+	if err != nil {
+		return err
+	}
+
 	var provider Provider
-	var err error
 
 	if providerName == "" {
 		provider, err = pm.GetDefaultProvider()
@@ -394,7 +608,73 @@ func (pm *ProviderManager) CompleteStreamWithMode(ctx context.Context, providerN
 		return err
 	}
 
-	return provider.CompleteStreamWithMode(ctx, req, callback, mode)
+	req = pm.interceptRequest(req)
+	pm.logCall(ctx, provider, req)
+	name := pm.resolveProviderName(providerName)
+	err = pm.streamWithContextRetry(ctx, providerName, provider, req, blockingStream(name, req.Model, pm.interceptStream(callback)), func(req CompletionRequest, callback StreamCallback) error {
+		return limitStreamTokens(ctx, req.MaxStreamTokens, callback, func(ctx context.Context, callback StreamCallback) error {
+			return watchStreamIdle(ctx, pm.streamIdleTimeoutFor(req), callback, func(ctx context.Context, callback StreamCallback) error {
+				return provider.CompleteStreamWithMode(ctx, req, callback, mode)
+			})
+		})
+	})
+	if err != nil {
+		return contentBlockError(name, req.Model, err)
+	}
+	return nil
+}
+
+// errStreamTokenLimit stops a provider stream once the token budget is reached
+var errStreamTokenLimit = errors.New("stream token limit reached")
+
+// limitStreamTokens runs a stream and cancels it once approximately maxTokens tokens have
+// been emitted. The chunk that reaches the budget is delivered with a "length" finish
+// reason and the stream returns cleanly.
+func limitStreamTokens(ctx context.Context, maxTokens int, callback StreamCallback, stream func(context.Context, StreamCallback) error) error {
+	if maxTokens <= 0 {
+		return stream(ctx, callback)
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Tokens are approximated like SimpleTokenCounter: ~4 characters per token
+	emittedChars := 0
+	limited := false
+
+	err := stream(streamCtx, func(chunk CompletionResponse) error {
+		if limited {
+			return errStreamTokenLimit
+		}
+
+		for _, choice := range chunk.Choices {
+			emittedChars += len(choice.Delta.Content)
+		}
+		reached := emittedChars/4 >= maxTokens
+		if reached {
+			choices := make([]Choice, len(chunk.Choices))
+			copy(choices, chunk.Choices)
+			for i := range choices {
+				choices[i].FinishReason = "length"
+			}
+			chunk.Choices = choices
+		}
+
+		if err := callback(chunk); err != nil {
+			return err
+		}
+		if reached {
+			limited = true
+			cancel()
+			return errStreamTokenLimit
+		}
+		return nil
+	})
+
+	if limited && ctx.Err() == nil {
+		return nil
+	}
+	return err
 }
 
 // EnableStreaming enables streaming for a provider
@@ -439,6 +719,111 @@ func (pm *ProviderManager) SetStreamingMode(providerName string, mode StreamMode
 	return provider.SetStreamingConfig(config)
 }
 
+// Capabilities returns the capabilities of a provider
+func (pm *ProviderManager) Capabilities(providerName string) (ProviderCapabilities, error) {
+	provider, err := pm.GetProvider(providerName)
+	if err != nil {
+		return ProviderCapabilities{}, err
+	}
+
+	return provider.Capabilities(), nil
+}
+
+// ModelCapabilities returns the capabilities of a provider for a specific model.
+// Overrides registered with SetModelCapabilities take precedence over the provider's own values.
+func (pm *ProviderManager) ModelCapabilities(providerName, model string) (ProviderCapabilities, error) {
+	providerName, model, err := pm.ResolveModel(providerName, model)
+	if err != nil {
+		return ProviderCapabilities{}, err
+	}
+
+	provider, err := pm.GetProvider(providerName)
+	if err != nil {
+		return ProviderCapabilities{}, err
+	}
+
+	pm.mu.RLock()
+	override, exists := pm.modelCapabilities[providerName][model]
+	pm.mu.RUnlock()
+	if exists {
+		return override, nil
+	}
+
+	if model != "" {
+		if modelProvider, ok := provider.(ModelCapabilityProvider); ok {
+			return modelProvider.ModelCapabilities(model), nil
+		}
+	}
+
+	return provider.Capabilities(), nil
+}
+
+// SetModelCapabilities overrides the capabilities reported for a provider model
+func (pm *ProviderManager) SetModelCapabilities(providerName, model string, capabilities ProviderCapabilities) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if pm.modelCapabilities[providerName] == nil {
+		pm.modelCapabilities[providerName] = make(map[string]ProviderCapabilities)
+	}
+	pm.modelCapabilities[providerName][model] = capabilities
+}
+
+// RequireFeatures returns an UnsupportedFeatureError if the model lacks any of the given features
+func (pm *ProviderManager) RequireFeatures(providerName, model string, features ...Feature) error {
+	capabilities, err := pm.ModelCapabilities(providerName, model)
+	if err != nil {
+		return err
+	}
+
+	for _, feature := range features {
+		if !capabilities.Supports(feature) {
+			return &UnsupportedFeatureError{Provider: providerName, Model: model, Feature: feature}
+		}
+	}
+
+	return nil
+}
+
+// Embed generates embeddings for the given texts using a provider
+func (pm *ProviderManager) Embed(ctx context.Context, providerName, model string, texts []string) ([][]float64, error) {
+	providerName, model, err := pm.ResolveModel(providerName, model)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := pm.GetProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	embedder, ok := provider.(EmbeddingProvider)
+	if !ok {
+		return nil, &UnsupportedFeatureError{Provider: providerName, Model: model, Feature: FeatureEmbeddings}
+	}
+
+	logging.FromContext(ctx, pm.logger).WithFields(logrus.Fields{
+		"provider": provider.GetName(),
+		"model":    model,
+		"texts":    len(texts),
+	}).Debug("Embedding call")
+
+	return embedder.Embed(ctx, model, texts)
+}
+
+// MaxEmbeddingBatchSize returns the maximum number of texts per Embed call of a provider,
+// or zero when the provider does not limit it
+func (pm *ProviderManager) MaxEmbeddingBatchSize(providerName string) (int, error) {
+	provider, err := pm.GetProvider(providerName)
+	if err != nil {
+		return 0, err
+	}
+	if limiter, ok := provider.(EmbeddingBatchLimiter); ok {
+		return limiter.MaxEmbeddingBatchSize(), nil
+	}
+	return 0, nil
+}
+
 // HealthCheck checks the health of all providers
 func (pm *ProviderManager) HealthCheck(ctx context.Context) map[string]error {
 	pm.mu.RLock()
@@ -582,6 +967,41 @@ func (ch *ConversationHistory) GetLastN(n int) []Message {
 	return messages
 }
 
+// SetPinned pins or unpins the message at index so context trimming always keeps it
+func (ch *ConversationHistory) SetPinned(index int, pinned bool) error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	if index < 0 || index >= len(ch.messages) {
+		return fmt.Errorf("message index %d out of range", index)
+	}
+	ch.messages[index].Pinned = pinned
+	return nil
+}
+
+// GetPinned returns the pinned messages
+func (ch *ConversationHistory) GetPinned() []Message {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	var pinned []Message
+	for _, message := range ch.messages {
+		if message.Pinned {
+			pinned = append(pinned, message)
+		}
+	}
+	return pinned
+}
+
+// Truncate drops the messages after the first n, e.g. to undo the messages of a turn
+func (ch *ConversationHistory) Truncate(n int) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if n >= 0 && n < len(ch.messages) {
+		ch.messages = ch.messages[:n]
+	}
+}
+
 // Clear clears the conversation history
 func (ch *ConversationHistory) Clear() {
 	ch.mu.Lock()
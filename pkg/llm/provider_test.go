@@ -7,6 +7,12 @@
 package llm
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -395,6 +401,408 @@ func TestProviderManager(t *testing.T) {
 	}
 }
 
+func TestProviderManager_Capabilities(t *testing.T) {
+	manager := NewProviderManager()
+	provider, err := NewOllamaProvider(&ProviderConfig{Type: "ollama", Model: "llama2"})
+	if err != nil {
+		t.Fatalf("NewOllamaProvider() failed: %v", err)
+	}
+	if err := manager.RegisterProvider("ollama", provider); err != nil {
+		t.Fatalf("RegisterProvider() failed: %v", err)
+	}
+
+	capabilities, err := manager.Capabilities("ollama")
+	if err != nil {
+		t.Fatalf("Capabilities() failed: %v", err)
+	}
+	if !capabilities.SupportsStreaming || capabilities.SupportsTools || capabilities.SupportsVision {
+		t.Errorf("Unexpected default capabilities: %+v", capabilities)
+	}
+
+	// Capabilities vary by model
+	capabilities, _ = manager.ModelCapabilities("ollama", "llava")
+	if !capabilities.SupportsVision {
+		t.Error("llava should support vision")
+	}
+
+	// Overrides take precedence over provider defaults
+	manager.SetModelCapabilities("ollama", "llama3.1", ProviderCapabilities{SupportsStreaming: true, SupportsTools: true, MaxContextTokens: 131072})
+	capabilities, _ = manager.ModelCapabilities("ollama", "llama3.1")
+	if !capabilities.SupportsTools || capabilities.MaxContextTokens != 131072 {
+		t.Errorf("Override not applied: %+v", capabilities)
+	}
+
+	if err := manager.RequireFeatures("ollama", "llama3.1", FeatureTools); err != nil {
+		t.Errorf("RequireFeatures() should pass for overridden model: %v", err)
+	}
+
+	err = manager.RequireFeatures("ollama", "llama2", FeatureStreaming, FeatureTools)
+	unsupported, ok := err.(*UnsupportedFeatureError)
+	if !ok || unsupported.Feature != FeatureTools {
+		t.Errorf("Expected unsupported tools error, got %v", err)
+	}
+
+	if _, err := manager.Capabilities("missing"); err == nil {
+		t.Error("Capabilities() should fail for unknown provider")
+	}
+}
+
+func TestProviderManager_Embed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embeddings" {
+			http.NotFound(w, r)
+			return
+		}
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"embedding": []float64{float64(len(req["prompt"])), 0.5},
+		})
+	}))
+	defer server.Close()
+
+	manager := NewProviderManager()
+	provider, err := NewOllamaProvider(&ProviderConfig{Type: "ollama", Endpoint: server.URL})
+	if err != nil {
+		t.Fatalf("NewOllamaProvider() failed: %v", err)
+	}
+	manager.RegisterProvider("ollama", provider)
+
+	embeddings, err := manager.Embed(context.Background(), "ollama", "nomic-embed-text", []string{"a", "abc"})
+	if err != nil {
+		t.Fatalf("Embed() failed: %v", err)
+	}
+	if len(embeddings) != 2 || embeddings[1][0] != 3 {
+		t.Errorf("Unexpected embeddings: %v", embeddings)
+	}
+
+	if _, err := manager.Embed(context.Background(), "missing", "model", []string{"a"}); err == nil {
+		t.Error("Embed() should fail for unknown provider")
+	}
+}
+
+func TestReasoningParameters(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "42"}}],
+			"usage": {"prompt_tokens": 10, "completion_tokens": 300, "total_tokens": 310,
+				"completion_tokens_details": {"reasoning_tokens": 256}}}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider(&ProviderConfig{APIKey: "test-key", Endpoint: server.URL}) // pragma: allowlist secret
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider() failed: %v", err)
+	}
+	resp, err := provider.Complete(context.Background(), CompletionRequest{
+		Model:                "o3-mini",
+		Messages:             []Message{{Role: "user", Content: "What is 6 x 7?"}},
+		MaxTokens:            100,
+		Temperature:          0.2,
+		ReasoningEffort:      ReasoningEffortHigh,
+		ThinkingBudgetTokens: 400,
+	})
+	if err != nil {
+		t.Fatalf("Complete() failed: %v", err)
+	}
+	if body["reasoning_effort"] != "high" || body["max_completion_tokens"] != float64(500) {
+		t.Errorf("Expected reasoning parameters in the request, got %v", body)
+	}
+	if _, exists := body["max_tokens"]; exists {
+		t.Error("Reasoning models do not accept max_tokens")
+	}
+	if resp.Usage.ReasoningTokens != 256 || resp.Usage.OutputTokens() != 44 {
+		t.Errorf("Expected reasoning tokens to be reported separately, got %+v", resp.Usage)
+	}
+
+	// Other models ignore the reasoning parameters
+	if _, err := provider.Complete(context.Background(), CompletionRequest{
+		Model:           "gpt-4o",
+		Messages:        []Message{{Role: "user", Content: "hi"}},
+		MaxTokens:       100,
+		ReasoningEffort: ReasoningEffortLow,
+	}); err != nil {
+		t.Fatalf("Complete() failed: %v", err)
+	}
+	if _, exists := body["reasoning_effort"]; exists || body["max_tokens"] != float64(100) {
+		t.Errorf("Expected reasoning parameters to be ignored, got %v", body)
+	}
+
+	gemini, _ := NewGeminiProvider(&ProviderConfig{APIKey: "test-key", Model: "gemini-2.5-flash"}) // pragma: allowlist secret
+	if budget := gemini.thinkingBudget(CompletionRequest{ReasoningEffort: ReasoningEffortMedium}); budget != 8192 {
+		t.Errorf("Expected the effort to map to a thinking budget, got %d", budget)
+	}
+	if budget := gemini.thinkingBudget(CompletionRequest{Model: "gemini-pro", ThinkingBudgetTokens: 512}); budget != 0 {
+		t.Errorf("Expected no thinking budget for a model without thinking, got %d", budget)
+	}
+
+	pricing := ModelPricing{PromptPer1K: 1, CompletionPer1K: 2, ReasoningPer1K: 4}
+	if cost := pricing.Cost(resp.Usage); cost != 0.01+0.088+1.024 {
+		t.Errorf("Unexpected cost %v", cost)
+	}
+}
+
+func TestSeedParameter(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "42"}}], "system_fingerprint": "fp_44709d6fcb"}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider(&ProviderConfig{APIKey: "test-key", Endpoint: server.URL}) // pragma: allowlist secret
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider() failed: %v", err)
+	}
+	seed := 1234
+	resp, err := provider.Complete(context.Background(), CompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []Message{{Role: "user", Content: "What is 6 x 7?"}},
+		Seed:     &seed,
+	})
+	if err != nil {
+		t.Fatalf("Complete() failed: %v", err)
+	}
+	if body["seed"] != float64(1234) {
+		t.Errorf("Expected the seed in the request, got %v", body)
+	}
+	if resp.SystemFingerprint != "fp_44709d6fcb" {
+		t.Errorf("Expected the system fingerprint in the response, got %q", resp.SystemFingerprint)
+	}
+
+	// Unseeded requests leave the choice to the provider
+	if _, err := provider.Complete(context.Background(), CompletionRequest{Model: "gpt-4o", Messages: []Message{{Role: "user", Content: "hi"}}}); err != nil {
+		t.Fatalf("Complete() failed: %v", err)
+	}
+	if _, exists := body["seed"]; exists {
+		t.Errorf("Expected no seed without one in the request, got %v", body)
+	}
+
+	ollama, _ := NewOllamaProvider(&ProviderConfig{Endpoint: "http://localhost:11434", Model: "llama2"})
+	ollamaReq := ollama.convertToOllamaRequest(CompletionRequest{Messages: []Message{{Role: "user", Content: "hi"}}, Seed: &seed})
+	if ollamaReq.Options.Seed == nil || *ollamaReq.Options.Seed != 1234 {
+		t.Errorf("Expected the seed in the Ollama options, got %+v", ollamaReq.Options)
+	}
+}
+
+func TestDefaultParams(t *testing.T) {
+	defaults := map[string]interface{}{"temperature": 0.2, "max_tokens": 300, "top_p": 0.9, "num_ctx": 8192}
+
+	// Explicit fields win over the request's params, which win over the defaults
+	req := ApplyDefaultParams(CompletionRequest{Temperature: 0.5, Params: map[string]interface{}{"top_p": 0.5, "max_tokens": 100}}, defaults)
+	if req.Temperature != 0.5 || req.MaxTokens != 100 {
+		t.Errorf("Expected explicit fields over params over defaults, got temperature %v and max tokens %v", req.Temperature, req.MaxTokens)
+	}
+	if req.Params["top_p"] != 0.5 || req.Params["num_ctx"] != 8192 || len(req.Params) != 2 {
+		t.Errorf("Expected the provider-specific params to remain, got %v", req.Params)
+	}
+
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "ok"}}]}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider(&ProviderConfig{APIKey: "test-key", Endpoint: server.URL, DefaultParams: defaults}) // pragma: allowlist secret
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider() failed: %v", err)
+	}
+	if _, err := provider.Complete(context.Background(), CompletionRequest{Model: "gpt-4o", Messages: []Message{{Role: "user", Content: "hi"}}}); err != nil {
+		t.Fatalf("Complete() failed: %v", err)
+	}
+	if body["temperature"] != 0.2 || body["max_tokens"] != float64(300) || body["top_p"] != 0.9 {
+		t.Errorf("Expected the OpenAI defaults in the request, got %v", body)
+	}
+	if _, exists := body["num_ctx"]; exists {
+		t.Errorf("Expected parameters OpenAI does not recognize to be left out, got %v", body)
+	}
+
+	// Ollama sends its own parameters as options
+	ollama, _ := NewOllamaProvider(&ProviderConfig{
+		Endpoint:      "http://localhost:11434",
+		Model:         "llama2",
+		DefaultParams: map[string]interface{}{"num_ctx": 8192, "keep_alive": "30m", "temperature": 0.1},
+	})
+	ollamaReq := ollama.convertToOllamaRequest(CompletionRequest{Messages: []Message{{Role: "user", Content: "hi"}}})
+	data, _ := json.Marshal(ollamaReq)
+	var encoded struct {
+		KeepAlive string                 `json:"keep_alive"`
+		Options   map[string]interface{} `json:"options"`
+	}
+	json.Unmarshal(data, &encoded)
+	if encoded.KeepAlive != "30m" || encoded.Options["num_ctx"] != float64(8192) || encoded.Options["temperature"] != 0.1 {
+		t.Errorf("Expected the Ollama defaults in the request, got %s", data)
+	}
+}
+
+func TestLlamaCppProvider(t *testing.T) {
+	var paths []string
+	var completionBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/apply-template":
+			messages, _ := body["messages"].([]interface{})
+			w.Write([]byte(fmt.Sprintf(`{"prompt": "<%d messages>"}`, len(messages))))
+		case "/completion":
+			completionBody = body
+			if body["stream"] == true {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Write([]byte(`data: {"content": "{\"ok\"", "stop": false}` + "\n\n"))
+				w.Write([]byte(`data: {"content": ": true}", "stop": true, "tokens_predicted": 4}` + "\n\n"))
+				return
+			}
+			w.Write([]byte(`{"content": "{\"ok\": true}", "stop": true, "stopped_limit": false, "tokens_predicted": 4, "tokens_evaluated": 12}`))
+		case "/v1/chat/completions":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "chat"}}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewLlamaCppProvider(&ProviderConfig{Endpoint: server.URL, DefaultParams: map[string]interface{}{"top_k": 20}})
+	if err != nil {
+		t.Fatalf("NewLlamaCppProvider() failed: %v", err)
+	}
+	messages := []Message{{Role: "system", Content: "Answer in JSON"}, {Role: "user", Content: "ok?"}}
+
+	// Without a grammar the OpenAI-compatible endpoint is used
+	resp, err := provider.Complete(context.Background(), CompletionRequest{Messages: messages})
+	if err != nil {
+		t.Fatalf("Complete() failed: %v", err)
+	}
+	if resp.Choices[0].Message.Content != "chat" || paths[len(paths)-1] != "/v1/chat/completions" {
+		t.Errorf("Expected a chat completion, got %q from %v", resp.Choices[0].Message.Content, paths)
+	}
+
+	// A grammar sends the templated prompt to the native endpoint
+	grammar := `root ::= "{\"ok\": " ("true" | "false") "}"`
+	resp, err = provider.Complete(context.Background(), CompletionRequest{Messages: messages, Grammar: grammar, MaxTokens: 64})
+	if err != nil {
+		t.Fatalf("Complete() with a grammar failed: %v", err)
+	}
+	if resp.Choices[0].Message.Content != `{"ok": true}` || resp.Choices[0].FinishReason != "stop" || resp.Usage.TotalTokens != 16 {
+		t.Errorf("Expected the constrained completion, got %+v", resp)
+	}
+	if completionBody["prompt"] != "<2 messages>" || completionBody["grammar"] != grammar || completionBody["n_predict"] != float64(64) || completionBody["top_k"] != float64(20) {
+		t.Errorf("Expected the prompt, grammar and params in the native request, got %v", completionBody)
+	}
+
+	// Streamed constrained completions arrive as server-sent events
+	var content strings.Builder
+	err = provider.CompleteStream(context.Background(), CompletionRequest{Messages: messages, Grammar: grammar}, func(chunk CompletionResponse) error {
+		content.WriteString(chunk.Choices[0].Delta.Content)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("CompleteStream() failed: %v", err)
+	}
+	if content.String() != `{"ok": true}` {
+		t.Errorf("Expected the streamed constrained completion, got %q", content.String())
+	}
+}
+
+func TestChatTemplates(t *testing.T) {
+	messages := []Message{
+		{Role: "system", Content: "Be brief."},
+		{Role: "user", Content: "Hi"},
+		{Role: "assistant", Content: "Hello!"},
+		{Role: "user", Content: "Bye"},
+	}
+
+	llama3, _ := ChatTemplateForModel("llama3.1:8b")
+	prompt, err := llama3.Format(messages)
+	if err != nil {
+		t.Fatalf("Format() failed: %v", err)
+	}
+	expected := "<|start_header_id|>system<|end_header_id|>\n\nBe brief.<|eot_id|>" +
+		"<|start_header_id|>user<|end_header_id|>\n\nHi<|eot_id|>" +
+		"<|start_header_id|>assistant<|end_header_id|>\n\nHello!<|eot_id|>" +
+		"<|start_header_id|>user<|end_header_id|>\n\nBye<|eot_id|>" +
+		"<|start_header_id|>assistant<|end_header_id|>\n\n"
+	if prompt != expected {
+		t.Errorf("Expected the Llama 3 prompt %q, got %q", expected, prompt)
+	}
+
+	// Gemma has no system turn, so the system prompt opens the first user turn
+	gemma, _ := ChatTemplateForModel("gemma2:9b")
+	prompt, _ = gemma.Format(messages)
+	expected = "<start_of_turn>user\nBe brief.\n\nHi<end_of_turn>\n<start_of_turn>model\nHello!<end_of_turn>\n" +
+		"<start_of_turn>user\nBye<end_of_turn>\n<start_of_turn>model\n"
+	if prompt != expected {
+		t.Errorf("Expected the Gemma prompt %q, got %q", expected, prompt)
+	}
+	if _, exists := ChatTemplateForModel("mystery-model"); exists {
+		t.Error("Expected no template for an unknown family")
+	}
+
+	// Custom templates are Go templates
+	custom, err := NewChatTemplate("plain", "{{.System}}{{range .Messages}}\n{{.Role}}: {{.Content}}{{end}}\nassistant:", "\nuser:")
+	if err != nil {
+		t.Fatalf("NewChatTemplate() failed: %v", err)
+	}
+	RegisterChatTemplate(custom)
+	if _, err := NewChatTemplate("broken", "{{.Messages"); err == nil {
+		t.Error("Expected an invalid template to be rejected")
+	}
+
+	var paths []string
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		if r.URL.Path == "/api/generate" {
+			w.Write([]byte(`{"model": "m", "response": "See", "done": false}` + "\n" + `{"model": "m", "response": " you", "done": true}`))
+			return
+		}
+		w.Write([]byte(`{"model": "m", "message": {"role": "assistant", "content": "chat"}, "done": true}`))
+	}))
+	defer server.Close()
+
+	// Models with a template get a raw prompt on the generate endpoint
+	provider, _ := NewOllamaProvider(&ProviderConfig{
+		Endpoint:      server.URL,
+		ChatTemplates: map[string]string{"tinyllama": "plain", "*": ChatTemplateAuto},
+	})
+	resp, err := provider.Complete(context.Background(), CompletionRequest{Model: "qwen2.5:7b", Messages: messages})
+	if err != nil {
+		t.Fatalf("Complete() failed: %v", err)
+	}
+	if resp.Choices[0].Message.Content != "See you" || paths[0] != "/api/generate" || body["raw"] != true {
+		t.Errorf("Expected a raw generate request, got %q from %v with %v", resp.Choices[0].Message.Content, paths, body)
+	}
+	if prompt, _ := body["prompt"].(string); !strings.HasPrefix(prompt, "<|im_start|>system\nBe brief.<|im_end|>") {
+		t.Errorf("Expected the ChatML prompt, got %q", prompt)
+	}
+	if options, _ := body["options"].(map[string]interface{}); fmt.Sprint(options["stop"]) != "[<|im_end|>]" {
+		t.Errorf("Expected the end-of-turn marker as stop sequence, got %v", options["stop"])
+	}
+
+	provider.Complete(context.Background(), CompletionRequest{Model: "tinyllama:latest", Messages: messages})
+	if prompt, _ := body["prompt"].(string); prompt != "Be brief.\nuser: Hi\nassistant: Hello!\nuser: Bye\nassistant:" {
+		t.Errorf("Expected the custom prompt, got %q", prompt)
+	}
+
+	// Models of unknown families stay on the chat endpoint
+	resp, err = provider.Complete(context.Background(), CompletionRequest{Model: "mystery-model", Messages: messages})
+	if err != nil || resp.Choices[0].Message.Content != "chat" || paths[len(paths)-1] != "/api/chat" {
+		t.Errorf("Expected a chat request, got %v from %v", err, paths)
+	}
+}
+
 func TestConversationHistory(t *testing.T) {
 	// Test creating new conversation history
 	history := NewConversationHistory()
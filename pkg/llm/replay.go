@@ -0,0 +1,388 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrNoRecording is returned by a ReplayProvider for a request its cassette did not record
+var ErrNoRecording = errors.New("replay: no recorded response for request")
+
+// CassetteInteraction is a recorded request and its response. Streamed responses keep their
+// chunks so they are replayed as they were received.
+type CassetteInteraction struct {
+	Key      string               `json:"key"`
+	Model    string               `json:"model,omitempty"`
+	Messages []Message            `json:"messages"`
+	Response *CompletionResponse  `json:"response,omitempty"`
+	Chunks   []CompletionResponse `json:"chunks,omitempty"`
+}
+
+// Cassette holds the completions recorded by a RecordingProvider and replayed by a
+// ReplayProvider, so evaluations and tests run without calling a model
+type Cassette struct {
+	mu           sync.Mutex
+	Interactions []CassetteInteraction `json:"interactions"`
+	played       map[string]int
+}
+
+// NewCassette creates an empty cassette
+func NewCassette() *Cassette {
+	return &Cassette{}
+}
+
+// LoadCassette reads a cassette saved as JSON
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette: %w", err)
+	}
+	return &cassette, nil
+}
+
+// Save writes the cassette as JSON
+func (c *Cassette) Save(path string) error {
+	c.mu.Lock()
+	data, err := json.MarshalIndent(c, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Len returns the number of recorded interactions
+func (c *Cassette) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.Interactions)
+}
+
+// Record adds an interaction for a request
+func (c *Cassette) Record(req CompletionRequest, resp *CompletionResponse, chunks []CompletionResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Interactions = append(c.Interactions, CassetteInteraction{
+		Key:      RequestKey(req),
+		Model:    req.Model,
+		Messages: req.Messages,
+		Response: resp,
+		Chunks:   chunks,
+	})
+}
+
+// Lookup returns the interaction recorded for a request. A request recorded several times is
+// answered with its recordings in order, the last one repeating once all were played.
+func (c *Cassette) Lookup(req CompletionRequest) (CassetteInteraction, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := RequestKey(req)
+	var matches []int
+	for i, interaction := range c.Interactions {
+		if interaction.Key == key {
+			matches = append(matches, i)
+		}
+	}
+	if len(matches) == 0 {
+		return CassetteInteraction{}, false
+	}
+
+	if c.played == nil {
+		c.played = make(map[string]int)
+	}
+	index := c.played[key]
+	if index >= len(matches) {
+		index = len(matches) - 1
+	}
+	c.played[key]++
+	return c.Interactions[matches[index]], true
+}
+
+// Rewind replays every interaction from its first recording again
+func (c *Cassette) Rewind() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.played = nil
+}
+
+// RequestKey identifies a request by its model, messages and tools. Tool call IDs, which
+// differ from run to run, and sampling parameters are left out.
+func RequestKey(req CompletionRequest) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "model:%s\nsystem:%s\n", req.Model, req.SystemPrompt)
+	for _, message := range req.Messages {
+		fmt.Fprintf(hash, "%s:%s:%s\n", message.Role, message.Name, message.Content)
+		for _, call := range message.ToolCalls {
+			fmt.Fprintf(hash, "call:%s:%s\n", call.Function.Name, call.Function.Arguments)
+		}
+	}
+	for _, tool := range req.Tools {
+		fmt.Fprintf(hash, "tool:%s\n", tool.Function.Name)
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
+
+// RecordingProvider wraps a provider to record its completions on a cassette
+type RecordingProvider struct {
+	Provider
+	cassette *Cassette
+}
+
+// NewRecordingProvider wraps a provider, recording its completions on the cassette
+func NewRecordingProvider(provider Provider, cassette *Cassette) *RecordingProvider {
+	return &RecordingProvider{Provider: provider, cassette: cassette}
+}
+
+// Cassette returns the cassette the provider records on
+func (p *RecordingProvider) Cassette() *Cassette {
+	return p.cassette
+}
+
+// Complete generates and records a completion
+func (p *RecordingProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	resp, err := p.Provider.Complete(ctx, req)
+	if err == nil {
+		p.cassette.Record(req, resp, nil)
+	}
+	return resp, err
+}
+
+// CompleteWithMode generates and records a completion with explicit streaming mode
+func (p *RecordingProvider) CompleteWithMode(ctx context.Context, req CompletionRequest, mode StreamMode) (*CompletionResponse, error) {
+	resp, err := p.Provider.CompleteWithMode(ctx, req, mode)
+	if err == nil {
+		p.cassette.Record(req, resp, nil)
+	}
+	return resp, err
+}
+
+// CompleteStream generates and records a streaming completion
+func (p *RecordingProvider) CompleteStream(ctx context.Context, req CompletionRequest, callback StreamCallback) error {
+	return p.record(req, callback, func(callback StreamCallback) error {
+		return p.Provider.CompleteStream(ctx, req, callback)
+	})
+}
+
+// CompleteStreamWithMode generates and records a streaming completion with explicit mode
+func (p *RecordingProvider) CompleteStreamWithMode(ctx context.Context, req CompletionRequest, callback StreamCallback, mode StreamMode) error {
+	return p.record(req, callback, func(callback StreamCallback) error {
+		return p.Provider.CompleteStreamWithMode(ctx, req, callback, mode)
+	})
+}
+
+// record records the chunks of a stream that completed
+func (p *RecordingProvider) record(req CompletionRequest, callback StreamCallback, stream func(StreamCallback) error) error {
+	var chunks []CompletionResponse
+	err := stream(func(chunk CompletionResponse) error {
+		chunks = append(chunks, chunk)
+		return callback(chunk)
+	})
+	if err == nil {
+		p.cassette.Record(req, nil, chunks)
+	}
+	return err
+}
+
+// ReplayProvider answers requests with the completions recorded on a cassette, failing with
+// ErrNoRecording for requests it did not record. It never calls a model, so runs against it
+// are deterministic.
+type ReplayProvider struct {
+	name     string
+	cassette *Cassette
+}
+
+// NewReplayProvider creates a provider replaying the cassette under the given name
+func NewReplayProvider(name string, cassette *Cassette) *ReplayProvider {
+	return &ReplayProvider{name: name, cassette: cassette}
+}
+
+// Cassette returns the cassette the provider replays
+func (p *ReplayProvider) Cassette() *Cassette {
+	return p.cassette
+}
+
+// GetName returns the provider name
+func (p *ReplayProvider) GetName() string {
+	return p.name
+}
+
+// GetModels returns the models of the recorded requests
+func (p *ReplayProvider) GetModels(ctx context.Context) ([]string, error) {
+	p.cassette.mu.Lock()
+	defer p.cassette.mu.Unlock()
+
+	seen := make(map[string]bool)
+	var models []string
+	for _, interaction := range p.cassette.Interactions {
+		if interaction.Model != "" && !seen[interaction.Model] {
+			seen[interaction.Model] = true
+			models = append(models, interaction.Model)
+		}
+	}
+	return models, nil
+}
+
+// Complete returns the recorded completion of the request
+func (p *ReplayProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	interaction, err := p.lookup(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if interaction.Response != nil {
+		return copyResponse(interaction.Response)
+	}
+	return joinChunks(interaction.Chunks), nil
+}
+
+// CompleteWithMode returns the recorded completion of the request
+func (p *ReplayProvider) CompleteWithMode(ctx context.Context, req CompletionRequest, mode StreamMode) (*CompletionResponse, error) {
+	return p.Complete(ctx, req)
+}
+
+// CompleteStream replays the recorded chunks of the request, or its recorded completion as a
+// single chunk
+func (p *ReplayProvider) CompleteStream(ctx context.Context, req CompletionRequest, callback StreamCallback) error {
+	interaction, err := p.lookup(ctx, req)
+	if err != nil {
+		return err
+	}
+	if interaction.Response != nil {
+		resp, err := copyResponse(interaction.Response)
+		if err != nil {
+			return err
+		}
+		for i := range resp.Choices {
+			resp.Choices[i].Delta = resp.Choices[i].Message
+		}
+		return callback(*resp)
+	}
+	for _, chunk := range interaction.Chunks {
+		if err := callback(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CompleteStreamWithMode replays the recorded chunks of the request
+func (p *ReplayProvider) CompleteStreamWithMode(ctx context.Context, req CompletionRequest, callback StreamCallback, mode StreamMode) error {
+	return p.CompleteStream(ctx, req, callback)
+}
+
+// IsHealthy always succeeds
+func (p *ReplayProvider) IsHealthy(ctx context.Context) error {
+	return nil
+}
+
+// GetConfig returns the provider configuration
+func (p *ReplayProvider) GetConfig() map[string]interface{} {
+	return map[string]interface{}{"name": p.name, "interactions": p.cassette.Len()}
+}
+
+// SetConfig does nothing, a replay has no configuration
+func (p *ReplayProvider) SetConfig(config map[string]interface{}) error {
+	return nil
+}
+
+// SupportsStreaming returns true, recorded completions can be replayed as streams
+func (p *ReplayProvider) SupportsStreaming() bool {
+	return true
+}
+
+// GetStreamingConfig returns the streaming configuration
+func (p *ReplayProvider) GetStreamingConfig() *StreamingConfig {
+	return &StreamingConfig{Enabled: true, Mode: StreamModeAuto}
+}
+
+// SetStreamingConfig does nothing, a replay streams as recorded
+func (p *ReplayProvider) SetStreamingConfig(config *StreamingConfig) error {
+	return nil
+}
+
+// Capabilities returns the features a replay supports
+func (p *ReplayProvider) Capabilities() ProviderCapabilities {
+	return ProviderCapabilities{SupportsStreaming: true, SupportsTools: true}
+}
+
+// Close does nothing
+func (p *ReplayProvider) Close() error {
+	return nil
+}
+
+// lookup finds the interaction recorded for a request
+func (p *ReplayProvider) lookup(ctx context.Context, req CompletionRequest) (CassetteInteraction, error) {
+	if err := ctx.Err(); err != nil {
+		return CassetteInteraction{}, err
+	}
+	interaction, ok := p.cassette.Lookup(req)
+	if !ok {
+		return CassetteInteraction{}, fmt.Errorf("%w (key %s)", ErrNoRecording, RequestKey(req))
+	}
+	return interaction, nil
+}
+
+// copyResponse copies a recorded response, so callers may change it without changing the
+// recording
+func copyResponse(resp *CompletionResponse) (*CompletionResponse, error) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	var copied CompletionResponse
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, err
+	}
+	return &copied, nil
+}
+
+// joinChunks assembles the content and tool calls of recorded chunks into a single completion
+func joinChunks(chunks []CompletionResponse) *CompletionResponse {
+	resp := &CompletionResponse{Object: "text_completion"}
+	var content strings.Builder
+	message := Message{Role: "assistant"}
+	finishReason := "stop"
+	for _, chunk := range chunks {
+		resp.ID, resp.Model = chunk.ID, chunk.Model
+		if chunk.Usage.TotalTokens > 0 {
+			resp.Usage = chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			content.WriteString(choice.Delta.Content)
+			for _, delta := range choice.Delta.ToolCalls {
+				// A fragment with an ID starts a call, the others continue its arguments
+				if delta.ID != "" || len(message.ToolCalls) == 0 {
+					message.ToolCalls = append(message.ToolCalls, delta)
+					continue
+				}
+				current := &message.ToolCalls[len(message.ToolCalls)-1]
+				current.Function.Name += delta.Function.Name
+				current.Function.Arguments += delta.Function.Arguments
+			}
+			if choice.FinishReason != "" {
+				finishReason = choice.FinishReason
+			}
+		}
+	}
+	message.Content = content.String()
+	resp.Choices = []Choice{{Message: message, FinishReason: finishReason}}
+	return resp
+}
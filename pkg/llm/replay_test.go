@@ -0,0 +1,91 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayProvider_RecordAndReplay(t *testing.T) {
+	gemini, err := NewGeminiProvider(&ProviderConfig{APIKey: "test-key", Model: "test-model"}) // pragma: allowlist secret
+	require.NoError(t, err)
+	cassette := NewCassette()
+	recorder := NewRecordingProvider(&echoProvider{GeminiProvider: gemini}, cassette)
+
+	ctx := context.Background()
+	req := CompletionRequest{Model: "test-model", Messages: []Message{{Role: "user", Content: "hello there"}}}
+	_, err = recorder.Complete(ctx, req)
+	require.NoError(t, err)
+	streamReq := CompletionRequest{Model: "test-model", Messages: []Message{{Role: "user", Content: "stream me"}}}
+	require.NoError(t, recorder.CompleteStream(ctx, streamReq, func(chunk CompletionResponse) error { return nil }))
+	require.Equal(t, 2, cassette.Len())
+
+	// The cassette survives a round trip through a file
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	require.NoError(t, cassette.Save(path))
+	loaded, err := LoadCassette(path)
+	require.NoError(t, err)
+
+	replay := NewReplayProvider("replay", loaded)
+	resp, err := replay.Complete(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "hello there", resp.Choices[0].Message.Content)
+
+	var streamed []string
+	require.NoError(t, replay.CompleteStream(ctx, streamReq, func(chunk CompletionResponse) error {
+		streamed = append(streamed, chunk.Choices[0].Delta.Content)
+		return nil
+	}))
+	assert.Equal(t, []string{"stream", "me"}, streamed)
+
+	// A recorded stream answers a plain completion with its chunks joined
+	resp, err = replay.Complete(ctx, streamReq)
+	require.NoError(t, err)
+	assert.Equal(t, "streamme", resp.Choices[0].Message.Content)
+
+	// Tool call IDs and sampling parameters do not change the key
+	withCall := CompletionRequest{Model: "test-model", Temperature: 0.9, Messages: []Message{
+		{Role: "assistant", ToolCalls: []ToolCall{{ID: "call_1", Function: FunctionCall{Name: "search", Arguments: "{}"}}}},
+	}}
+	rerun := withCall
+	rerun.Temperature = 0
+	rerun.Messages = []Message{{Role: "assistant", ToolCalls: []ToolCall{{ID: "call_2", Function: FunctionCall{Name: "search", Arguments: "{}"}}}}}
+	assert.Equal(t, RequestKey(withCall), RequestKey(rerun))
+
+	_, err = replay.Complete(ctx, CompletionRequest{Messages: []Message{{Role: "user", Content: "never recorded"}}})
+	assert.True(t, errors.Is(err, ErrNoRecording))
+}
+
+func TestCassette_RepeatedRequests(t *testing.T) {
+	cassette := NewCassette()
+	req := CompletionRequest{Messages: []Message{{Role: "user", Content: "roll a die"}}}
+	for _, roll := range []string{"2", "5"} {
+		cassette.Record(req, &CompletionResponse{Choices: []Choice{{Message: Message{Role: "assistant", Content: roll}}}}, nil)
+	}
+
+	replay := NewReplayProvider("replay", cassette)
+	var rolls []string
+	for i := 0; i < 3; i++ {
+		resp, err := replay.Complete(context.Background(), req)
+		require.NoError(t, err)
+		rolls = append(rolls, resp.Choices[0].Message.Content)
+	}
+	// Recordings are replayed in order and the last one repeats
+	assert.Equal(t, "2,5,5", strings.Join(rolls, ","))
+
+	cassette.Rewind()
+	resp, err := replay.Complete(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "2", resp.Choices[0].Message.Content)
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrStreamStalled is returned when a stream delivers no chunk within its idle timeout
+var ErrStreamStalled = errors.New("stream stalled")
+
+// StreamStalledError reports a stalled stream with the content delivered before it stalled
+type StreamStalledError struct {
+	IdleTimeout time.Duration
+	Chunks      int
+	// Content is the text streamed before the stall
+	Content string
+}
+
+// Error implements the error interface
+func (e *StreamStalledError) Error() string {
+	return fmt.Sprintf("%s: no chunk received for %s after %d chunks", ErrStreamStalled, e.IdleTimeout, e.Chunks)
+}
+
+// Unwrap makes errors.Is(err, ErrStreamStalled) match
+func (e *StreamStalledError) Unwrap() error {
+	return ErrStreamStalled
+}
+
+// SetStreamIdleTimeout sets the idle timeout of the streams whose request does not set
+// StreamIdleTimeout; zero disables it
+func (pm *ProviderManager) SetStreamIdleTimeout(timeout time.Duration) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.streamIdleTimeout = timeout
+}
+
+// streamIdleTimeoutFor returns the idle timeout of a request
+func (pm *ProviderManager) streamIdleTimeoutFor(req CompletionRequest) time.Duration {
+	if req.StreamIdleTimeout > 0 {
+		return req.StreamIdleTimeout
+	}
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.streamIdleTimeout
+}
+
+// watchStreamIdle runs a stream and cancels it when no chunk arrives within the idle timeout,
+// counted from the start of the request and then from each chunk. A stalled stream returns a
+// *StreamStalledError without waiting for the provider to notice the cancellation, and its
+// later chunks are dropped. The idle timeout never extends the context's own deadline.
+func watchStreamIdle(ctx context.Context, timeout time.Duration, callback StreamCallback, stream func(context.Context, StreamCallback) error) error {
+	if timeout <= 0 {
+		return stream(ctx, callback)
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var content strings.Builder
+	chunks := 0
+	stopped := false
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- stream(streamCtx, func(chunk CompletionResponse) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if stopped {
+				return ErrStreamStalled
+			}
+
+			// Time spent in the callback is not idle time
+			timer.Stop()
+			defer timer.Reset(timeout)
+
+			chunks++
+			for _, choice := range chunk.Choices {
+				content.WriteString(choice.Delta.Content)
+			}
+			return callback(chunk)
+		})
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		mu.Lock()
+		defer mu.Unlock()
+		stopped = true
+		cancel()
+		return &StreamStalledError{IdleTimeout: timeout, Chunks: chunks, Content: content.String()}
+	}
+}
@@ -8,6 +8,9 @@ package llm
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -382,3 +385,118 @@ func TestRealisticStreamingScenario(t *testing.T) {
 	t.Logf("Streaming test completed: %d chunks, %v total time, %v processing time",
 		len(chunks), totalTime, totalLatency)
 }
+
+// chattyProvider streams a fixed number of chunks unless its context is cancelled
+type chattyProvider struct {
+	*GeminiProvider
+	chunks    int
+	streamed  int
+	cancelled bool
+}
+
+func (p *chattyProvider) CompleteStreamWithMode(ctx context.Context, req CompletionRequest, callback StreamCallback, mode StreamMode) error {
+	return p.CompleteStream(ctx, req, callback)
+}
+
+func (p *chattyProvider) CompleteStream(ctx context.Context, req CompletionRequest, callback StreamCallback) error {
+	for i := 0; i < p.chunks; i++ {
+		if ctx.Err() != nil {
+			p.cancelled = true
+			return ctx.Err()
+		}
+		p.streamed++
+		if err := callback(CompletionResponse{
+			Choices: []Choice{{Delta: Message{Role: "assistant", Content: "word"}}},
+		}); err != nil {
+			p.cancelled = ctx.Err() != nil
+			return err
+		}
+	}
+	return nil
+}
+
+func TestProviderManager_MaxStreamTokens(t *testing.T) {
+	gemini, err := NewGeminiProvider(&ProviderConfig{APIKey: "test-key"}) // pragma: allowlist secret
+	require.NoError(t, err)
+
+	provider := &chattyProvider{GeminiProvider: gemini, chunks: 50}
+	pm := NewProviderManager()
+	require.NoError(t, pm.RegisterProvider("chatty", provider))
+
+	var received []CompletionResponse
+	err = pm.CompleteStreamWithMode(context.Background(), "chatty", CompletionRequest{
+		Messages:        []Message{{Role: "user", Content: "Talk forever"}},
+		MaxStreamTokens: 5,
+	}, func(chunk CompletionResponse) error {
+		received = append(received, chunk)
+		return nil
+	}, StreamModeForced)
+
+	require.NoError(t, err)
+	assert.Len(t, received, 5)
+	assert.Equal(t, 5, provider.streamed)
+	assert.True(t, provider.cancelled, "upstream request should be cancelled")
+	assert.Empty(t, received[3].Choices[0].FinishReason)
+	assert.Equal(t, "length", received[4].Choices[0].FinishReason)
+
+	// Without a cap the whole stream is delivered
+	provider.streamed = 0
+	received = nil
+	err = pm.CompleteStream(context.Background(), "chatty", CompletionRequest{}, func(chunk CompletionResponse) error {
+		received = append(received, chunk)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, received, 50)
+
+	// Callback errors are still reported
+	err = pm.CompleteStream(context.Background(), "chatty", CompletionRequest{MaxStreamTokens: 1}, func(chunk CompletionResponse) error {
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestProviderManager_StreamIdleTimeout(t *testing.T) {
+	released := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, word := range []string{"Hello", " world"} {
+			fmt.Fprintf(w, `{"model": "llama3", "message": {"role": "assistant", "content": %q}, "done": false}`+"\n", word)
+			w.(http.Flusher).Flush()
+		}
+		// Stall without closing the stream until the client gives up
+		<-r.Context().Done()
+		close(released)
+	}))
+	defer server.Close()
+
+	provider, err := NewOllamaProvider(&ProviderConfig{Type: "ollama", Endpoint: server.URL})
+	require.NoError(t, err)
+	pm := NewProviderManager()
+	require.NoError(t, pm.RegisterProvider("ollama", provider))
+	pm.SetStreamIdleTimeout(time.Minute)
+
+	var received []string
+	start := time.Now()
+	err = pm.CompleteStreamWithMode(context.Background(), "ollama", CompletionRequest{
+		Model:             "llama3",
+		Messages:          []Message{{Role: "user", Content: "Hi"}},
+		StreamIdleTimeout: 100 * time.Millisecond,
+	}, func(chunk CompletionResponse) error {
+		received = append(received, chunk.Choices[0].Delta.Content)
+		return nil
+	}, StreamModeForced)
+
+	require.ErrorIs(t, err, ErrStreamStalled)
+	var stalled *StreamStalledError
+	require.ErrorAs(t, err, &stalled)
+	assert.Equal(t, 2, stalled.Chunks)
+	assert.Equal(t, "Hello world", stalled.Content)
+	assert.Equal(t, []string{"Hello", " world"}, received)
+	assert.Less(t, time.Since(start), 5*time.Second, "the request timeout overrides the manager default")
+
+	select {
+	case <-released:
+	case <-time.After(5 * time.Second):
+		t.Error("Expected the stalled upstream request to be cancelled")
+	}
+}
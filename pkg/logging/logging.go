@@ -0,0 +1,129 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+// Package logging provides helpers for correlating structured log entries
+// across sessions and executions.
+package logging
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Log field names used for correlation
+const (
+	FieldSessionID   = "session_id"
+	FieldExecutionID = "execution_id"
+	FieldAgentID     = "agent_id"
+	FieldRequestID   = "request_id"
+)
+
+type contextKey string
+
+const (
+	sessionIDKey   contextKey = "logging_session_id"
+	executionIDKey contextKey = "logging_execution_id"
+	agentIDKey     contextKey = "logging_agent_id"
+	requestIDKey   contextKey = "logging_request_id"
+)
+
+// WithSessionID returns a context carrying the given session ID
+func WithSessionID(ctx context.Context, sessionID string) context.Context {
+	if sessionID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionIDKey, sessionID)
+}
+
+// WithExecutionID returns a context carrying the given execution ID
+func WithExecutionID(ctx context.Context, executionID string) context.Context {
+	if executionID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, executionIDKey, executionID)
+}
+
+// WithAgentID returns a context carrying the ID of the agent running an execution
+func WithAgentID(ctx context.Context, agentID string) context.Context {
+	if agentID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, agentIDKey, agentID)
+}
+
+// WithRequestID returns a context carrying the ID of the HTTP request being served
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	if requestID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// SessionID returns the session ID stored in the context, if any
+func SessionID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	sessionID, _ := ctx.Value(sessionIDKey).(string)
+	return sessionID
+}
+
+// ExecutionID returns the execution ID stored in the context, if any
+func ExecutionID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	executionID, _ := ctx.Value(executionIDKey).(string)
+	return executionID
+}
+
+// AgentID returns the agent ID stored in the context, if any
+func AgentID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	agentID, _ := ctx.Value(agentIDKey).(string)
+	return agentID
+}
+
+// RequestID returns the request ID stored in the context, if any
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// NewCorrelatedLogger creates a child logger bound to the given session and execution IDs
+func NewCorrelatedLogger(logger *logrus.Logger, sessionID, executionID string) *logrus.Entry {
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+
+	fields := logrus.Fields{}
+	if sessionID != "" {
+		fields[FieldSessionID] = sessionID
+	}
+	if executionID != "" {
+		fields[FieldExecutionID] = executionID
+	}
+
+	return logger.WithFields(fields)
+}
+
+// FromContext creates a child logger bound to the correlation IDs stored in the context
+func FromContext(ctx context.Context, logger *logrus.Logger) *logrus.Entry {
+	entry := NewCorrelatedLogger(logger, SessionID(ctx), ExecutionID(ctx))
+	if ctx != nil {
+		if requestID := RequestID(ctx); requestID != "" {
+			entry = entry.WithField(FieldRequestID, requestID)
+		}
+		entry = entry.WithContext(ctx)
+	}
+	return entry
+}
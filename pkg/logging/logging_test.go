@@ -0,0 +1,75 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestContextIDs(t *testing.T) {
+	ctx := WithExecutionID(WithSessionID(context.Background(), "session-1"), "exec-1")
+
+	if got := SessionID(ctx); got != "session-1" {
+		t.Errorf("Expected session-1, got %q", got)
+	}
+	if got := ExecutionID(ctx); got != "exec-1" {
+		t.Errorf("Expected exec-1, got %q", got)
+	}
+
+	// Empty IDs leave the context untouched
+	if WithSessionID(ctx, "") != ctx {
+		t.Error("WithSessionID with empty ID should return the same context")
+	}
+	if SessionID(context.Background()) != "" {
+		t.Error("SessionID should be empty for a bare context")
+	}
+}
+
+func TestFromContext_JSONFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	ctx := WithExecutionID(WithSessionID(context.Background(), "session-1"), "exec-1")
+	ctx = WithRequestID(ctx, "req-1")
+	FromContext(ctx, logger).WithField("tool", "calculator").Info("Tool executed")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse log entry: %v", err)
+	}
+
+	if entry[FieldSessionID] != "session-1" {
+		t.Errorf("Expected session_id field, got %v", entry)
+	}
+	if entry[FieldExecutionID] != "exec-1" {
+		t.Errorf("Expected execution_id field, got %v", entry)
+	}
+	if entry[FieldRequestID] != "req-1" {
+		t.Errorf("Expected request_id field, got %v", entry)
+	}
+	if entry["tool"] != "calculator" {
+		t.Errorf("Expected tool field, got %v", entry)
+	}
+}
+
+func TestNewCorrelatedLogger_OmitsEmptyFields(t *testing.T) {
+	entry := NewCorrelatedLogger(logrus.New(), "", "exec-1")
+
+	if _, exists := entry.Data[FieldSessionID]; exists {
+		t.Error("session_id should be omitted when empty")
+	}
+	if entry.Data[FieldExecutionID] != "exec-1" {
+		t.Errorf("Expected execution_id field, got %v", entry.Data)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+// Package metrics provides the histograms and Prometheus text exposition helpers shared by
+// the tool and database metrics.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the latency histograms
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Histogram counts observations in buckets
+type Histogram struct {
+	// Buckets are the upper bounds of the buckets, ascending; an implicit last bucket holds
+	// the larger observations
+	Buckets []float64 `json:"buckets"`
+	// Counts are the observations in each bucket, not cumulative, with the implicit bucket last
+	Counts []uint64 `json:"counts"`
+	Sum    float64  `json:"sum"`
+	Count  uint64   `json:"count"`
+}
+
+// NewHistogram creates a histogram with the given ascending bucket bounds
+func NewHistogram(buckets []float64) Histogram {
+	return Histogram{Buckets: buckets, Counts: make([]uint64, len(buckets)+1)}
+}
+
+// Observe records a value
+func (h *Histogram) Observe(value float64) {
+	h.Counts[sort.SearchFloat64s(h.Buckets, value)]++
+	h.Sum += value
+	h.Count++
+}
+
+// Merge adds the observations of a histogram with the same buckets
+func (h *Histogram) Merge(other Histogram) {
+	for i, count := range other.Counts {
+		h.Counts[i] += count
+	}
+	h.Sum += other.Sum
+	h.Count += other.Count
+}
+
+// Clone returns a copy of the histogram that does not share its counts
+func (h Histogram) Clone() Histogram {
+	h.Counts = append([]uint64(nil), h.Counts...)
+	return h
+}
+
+// Mean returns the mean of the observations, zero when there are none
+func (h Histogram) Mean() float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / float64(h.Count)
+}
+
+// Quantile estimates the q-quantile of the observations by linear interpolation within its
+// bucket, like Prometheus' histogram_quantile. Observations above the last bound are
+// estimated at that bound.
+func (h Histogram) Quantile(q float64) float64 {
+	if h.Count == 0 || len(h.Buckets) == 0 {
+		return 0
+	}
+	rank := q * float64(h.Count)
+	var cumulative uint64
+	for i, bound := range h.Buckets {
+		previous := cumulative
+		cumulative += h.Counts[i]
+		if float64(cumulative) >= rank && h.Counts[i] > 0 {
+			lower := 0.0
+			if i > 0 {
+				lower = h.Buckets[i-1]
+			}
+			return lower + (bound-lower)*(rank-float64(previous))/float64(h.Counts[i])
+		}
+	}
+	return h.Buckets[len(h.Buckets)-1]
+}
+
+// WritePrometheus writes the histogram in the Prometheus text exposition format, as the
+// series of a metric with the given labels
+func (h Histogram) WritePrometheus(out io.Writer, name, labels string) {
+	var cumulative uint64
+	for i, bound := range h.Buckets {
+		cumulative += h.Counts[i]
+		fmt.Fprintf(out, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+	}
+	fmt.Fprintf(out, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.Count)
+	fmt.Fprintf(out, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(h.Sum, 'g', -1, 64))
+	fmt.Fprintf(out, "%s_count{%s} %d\n", name, labels, h.Count)
+}
+
+// EscapeLabel escapes a Prometheus label value
+func EscapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
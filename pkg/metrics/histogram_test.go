@@ -0,0 +1,48 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHistogram_Quantile(t *testing.T) {
+	histogram := NewHistogram([]float64{1, 2, 4})
+	for _, value := range []float64{0.5, 1.5, 1.5, 3} {
+		histogram.Observe(value)
+	}
+	if median := histogram.Quantile(0.5); median != 1.5 {
+		t.Errorf("expected a median of 1.5, got %v", median)
+	}
+	if mean := histogram.Mean(); mean != 1.625 {
+		t.Errorf("expected a mean of 1.625, got %v", mean)
+	}
+}
+
+func TestHistogram_WritePrometheus(t *testing.T) {
+	histogram := NewHistogram([]float64{1, 2})
+	histogram.Observe(0.5)
+	merged := histogram.Clone()
+	merged.Merge(histogram)
+	merged.Observe(5)
+
+	var out strings.Builder
+	merged.WritePrometheus(&out, "latency_seconds", `path="`+EscapeLabel(`a"b`)+`"`)
+	expected := `latency_seconds_bucket{path="a\"b",le="1"} 2
+latency_seconds_bucket{path="a\"b",le="2"} 2
+latency_seconds_bucket{path="a\"b",le="+Inf"} 3
+latency_seconds_sum{path="a\"b"} 6
+latency_seconds_count{path="a\"b"} 3
+`
+	if out.String() != expected {
+		t.Errorf("unexpected exposition:\n%s", out.String())
+	}
+	if histogram.Count != 1 {
+		t.Errorf("expected merging a clone to leave the histogram alone, got %d observations", histogram.Count)
+	}
+}
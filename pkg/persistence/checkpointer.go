@@ -343,6 +343,9 @@ func (c *FileCheckpointer) Close() error {
 	return nil
 }
 
+// beforeNodePhase marks the checkpoints taken before a node runs
+const beforeNodePhase = "before_node"
+
 // CheckpointManager manages checkpointing for graph execution
 type CheckpointManager struct {
 	checkpointer Checkpointer
@@ -376,6 +379,54 @@ func (cm *CheckpointManager) SaveCheckpoint(ctx context.Context, threadID, nodeI
 	return cm.checkpointer.Save(ctx, checkpoint)
 }
 
+// SaveNodeCheckpoint saves the state a graph execution starts a node with, implementing
+// core.NodeCheckpointer
+func (cm *CheckpointManager) SaveNodeCheckpoint(ctx context.Context, threadID, nodeID string, stepID int, state *core.BaseState) error {
+	if !cm.enabled {
+		return nil
+	}
+
+	checkpoint := &Checkpoint{
+		ID:        fmt.Sprintf("before-%s-%d", nodeID, stepID),
+		ThreadID:  threadID,
+		State:     state,
+		Metadata:  map[string]interface{}{"phase": beforeNodePhase},
+		CreatedAt: time.Now(),
+		NodeID:    nodeID,
+		StepID:    stepID,
+	}
+
+	return cm.checkpointer.Save(ctx, checkpoint)
+}
+
+// LoadNodeCheckpoint loads the state of the latest execution of a thread taken before the
+// node ran, implementing core.NodeCheckpointer
+func (cm *CheckpointManager) LoadNodeCheckpoint(ctx context.Context, threadID, nodeID string) (*core.BaseState, error) {
+	checkpoints, err := cm.ListCheckpoints(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *CheckpointMetadata
+	for _, checkpoint := range checkpoints {
+		if checkpoint.NodeID != nodeID || checkpoint.Metadata["phase"] != beforeNodePhase {
+			continue
+		}
+		if latest == nil || checkpoint.CreatedAt.After(latest.CreatedAt) {
+			latest = checkpoint
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no checkpoint before node %s in thread %s", nodeID, threadID)
+	}
+
+	checkpoint, err := cm.LoadCheckpoint(ctx, threadID, latest.ID)
+	if err != nil {
+		return nil, err
+	}
+	return checkpoint.State, nil
+}
+
 // LoadCheckpoint loads a checkpoint
 func (cm *CheckpointManager) LoadCheckpoint(ctx context.Context, threadID, checkpointID string) (*Checkpoint, error) {
 	if !cm.enabled {
@@ -0,0 +1,63 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+)
+
+func TestGraph_ResumeFromNode(t *testing.T) {
+	fetches := 0
+	graph := core.NewGraph("resume")
+	graph.Config.RetryAttempts = 0
+	graph.AddNode("fetch", "Fetch", func(ctx context.Context, state *core.BaseState) (*core.BaseState, error) {
+		fetches++
+		state.Set("data", "expensive result")
+		return state, nil
+	})
+	graph.AddNode("process", "Process", func(ctx context.Context, state *core.BaseState) (*core.BaseState, error) {
+		divisor, _ := state.Get("divisor")
+		if divisor == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		state.Set("result", 100/divisor.(int))
+		return state, nil
+	})
+	graph.AddEdge("fetch", "process", nil)
+	graph.SetStartNode("fetch")
+	graph.AddEndNode("process")
+	graph.SetCheckpointer(NewCheckpointManager(NewMemoryCheckpointer()))
+
+	initial := core.NewBaseState()
+	initial.Set("divisor", 0)
+	ctx := core.WithThreadID(context.Background(), "thread-1")
+	if _, err := graph.Execute(ctx, initial); err == nil {
+		t.Fatal("Expected the execution to fail at the process node")
+	}
+
+	result, err := graph.ResumeFromNode(context.Background(), "thread-1", "process", map[string]interface{}{"divisor": 4})
+	if err != nil {
+		t.Fatalf("ResumeFromNode() failed: %v", err)
+	}
+	if value, _ := result.Get("result"); value != 25 {
+		t.Errorf("Expected the resumed result 25, got %v", value)
+	}
+	if data, _ := result.Get("data"); data != "expensive result" {
+		t.Errorf("Expected the checkpointed state to be kept, got %v", data)
+	}
+	if fetches != 1 {
+		t.Errorf("Expected the nodes before the resumed node not to run again, got %d fetches", fetches)
+	}
+
+	if _, err := graph.ResumeFromNode(context.Background(), "unknown-thread", "process", nil); err == nil {
+		t.Error("Expected an error resuming a thread without checkpoints")
+	}
+}
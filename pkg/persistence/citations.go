@@ -0,0 +1,142 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// maxSnippetChars bounds the document excerpt kept in a citation
+const maxSnippetChars = 200
+
+// CitationInstructions asks the model to cite the numbered sources it uses inline
+const CitationInstructions = "Answer the question using only the numbered sources below. " +
+	"After each statement, cite the sources it is based on with their number in square brackets, e.g. [1] or [1][3]. " +
+	"If the sources do not contain the answer, say so and cite nothing."
+
+// citationMarker matches inline markers such as [1] and [2, 3]
+var citationMarker = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// Citation links an inline [n] marker in an answer to the retrieved document it cites
+type Citation struct {
+	Marker        int    `json:"marker"`
+	SourceID      string `json:"source_id"`
+	DocumentTitle string `json:"document_title,omitempty"`
+	Snippet       string `json:"snippet"`
+}
+
+// RAGResponse is an answer generated from retrieved documents with the sources it cites
+type RAGResponse struct {
+	Answer    string      `json:"answer"`
+	Citations []Citation  `json:"citations"`
+	Sources   []*Document `json:"sources"`
+}
+
+// DocumentTitle returns the "title" metadata of a document, if any
+func DocumentTitle(doc *Document) string {
+	title, _ := doc.Metadata["title"].(string)
+	return title
+}
+
+// CitationMessages builds the prompt asking the model to answer a question from numbered
+// sources and cite them inline
+func CitationMessages(question string, documents []*Document) []llm.Message {
+	return []llm.Message{
+		{Role: "system", Content: CitationInstructions + "\n\nSources:\n\n" + FormatRetrievedContext(documents)},
+		{Role: "user", Content: question},
+	}
+}
+
+// ContextChunks converts retrieved documents to the chunks of a structured context message,
+// tagged with the document IDs
+func ContextChunks(documents []*Document) []llm.ContextChunk {
+	chunks := make([]llm.ContextChunk, 0, len(documents))
+	for _, doc := range documents {
+		chunks = append(chunks, llm.ContextChunk{
+			SourceID: doc.ID,
+			Title:    DocumentTitle(doc),
+			Content:  doc.Content,
+			Score:    doc.Score,
+		})
+	}
+	return chunks
+}
+
+// ContextMessage returns retrieved documents as a structured context message, numbered as
+// CitationMessages numbers them so ExtractCitations maps the answer's markers back
+func ContextMessage(documents []*Document) llm.Message {
+	return llm.NewContextMessage(ContextChunks(documents))
+}
+
+// ExtractCitations maps the [n] markers in an answer to the documents they number, in order
+// of first appearance. Markers that do not number a document are ignored.
+func ExtractCitations(answer string, documents []*Document) []Citation {
+	citations := []Citation{}
+	seen := make(map[int]bool)
+
+	for _, match := range citationMarker.FindAllStringSubmatch(answer, -1) {
+		for _, number := range strings.Split(match[1], ",") {
+			marker, err := strconv.Atoi(strings.TrimSpace(number))
+			if err != nil || marker < 1 || marker > len(documents) || seen[marker] {
+				continue
+			}
+			seen[marker] = true
+
+			doc := documents[marker-1]
+			citations = append(citations, Citation{
+				Marker:        marker,
+				SourceID:      doc.ID,
+				DocumentTitle: DocumentTitle(doc),
+				Snippet:       snippet(doc.Content),
+			})
+		}
+	}
+	return citations
+}
+
+// AnswerWithCitations asks a model to answer a question from retrieved documents and returns
+// the answer with the structured citations parsed from its markers
+func AnswerWithCitations(ctx context.Context, manager *llm.ProviderManager, provider, model, question string, documents []*Document) (*RAGResponse, error) {
+	resp, err := manager.Complete(ctx, provider, llm.CompletionRequest{
+		Model:       model,
+		Messages:    CitationMessages(question, documents),
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate answer: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from LLM")
+	}
+
+	answer := resp.Choices[0].Message.Content
+	return &RAGResponse{
+		Answer:    answer,
+		Citations: ExtractCitations(answer, documents),
+		Sources:   documents,
+	}, nil
+}
+
+// snippet returns the beginning of a text, cut at a word boundary
+func snippet(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) <= maxSnippetChars {
+		return text
+	}
+
+	cut := text[:maxSnippetChars]
+	if space := strings.LastIndex(cut, " "); space > 0 {
+		cut = cut[:space]
+	}
+	return cut + "..."
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+func TestExtractCitations(t *testing.T) {
+	documents := []*Document{
+		{ID: "doc-1", Content: "Go was released in 2009.", Metadata: map[string]interface{}{"title": "Go History"}},
+		{ID: "doc-2", Content: "Go has goroutines.", Metadata: map[string]interface{}{}},
+		{ID: "doc-3", Content: strings.Repeat("channels ", 40), Metadata: map[string]interface{}{}},
+	}
+
+	answer := "Go appeared in 2009 [1]. It supports concurrency [3][2] through goroutines [2, 1]. See also [7]."
+	citations := ExtractCitations(answer, documents)
+
+	if len(citations) != 3 {
+		t.Fatalf("Expected 3 citations, got %d: %v", len(citations), citations)
+	}
+	expected := []struct {
+		marker   int
+		sourceID string
+	}{{1, "doc-1"}, {3, "doc-3"}, {2, "doc-2"}}
+	for i, want := range expected {
+		if citations[i].Marker != want.marker || citations[i].SourceID != want.sourceID {
+			t.Errorf("Citation %d: expected [%d] %s, got [%d] %s", i, want.marker, want.sourceID, citations[i].Marker, citations[i].SourceID)
+		}
+	}
+	if citations[0].DocumentTitle != "Go History" || citations[0].Snippet != "Go was released in 2009." {
+		t.Errorf("Unexpected citation details: %+v", citations[0])
+	}
+	if len(citations[1].Snippet) > maxSnippetChars+3 || !strings.HasSuffix(citations[1].Snippet, "...") {
+		t.Errorf("Expected a truncated snippet, got %q", citations[1].Snippet)
+	}
+
+	if citations := ExtractCitations("No sources support this.", documents); len(citations) != 0 {
+		t.Errorf("Expected no citations, got %v", citations)
+	}
+}
+
+func TestCitationMessages(t *testing.T) {
+	documents := []*Document{{ID: "doc-1", Content: "Go was released in 2009.", Metadata: map[string]interface{}{"title": "Go History"}}}
+
+	messages := CitationMessages("When was Go released?", documents)
+	if len(messages) != 2 || messages[1].Content != "When was Go released?" {
+		t.Fatalf("Unexpected messages: %v", messages)
+	}
+	if !strings.Contains(messages[0].Content, "[1] Go History") {
+		t.Errorf("Expected numbered sources in the prompt, got %q", messages[0].Content)
+	}
+}
+
+func TestContextMessage(t *testing.T) {
+	documents := []*Document{
+		{ID: "doc-1", Content: "Go was released in 2009.", Metadata: map[string]interface{}{"title": "Go History"}, Score: 0.9},
+		{ID: "doc-2", Content: "Go has goroutines.", Metadata: map[string]interface{}{}},
+	}
+
+	message := ContextMessage(documents)
+	if !llm.IsContextMessage(message) || message.Role != "system" {
+		t.Fatalf("Expected a system context message, got %+v", message)
+	}
+	chunks := llm.ContextChunks(message)
+	if len(chunks) != 2 || chunks[0].SourceID != "doc-1" || chunks[0].Title != "Go History" || chunks[0].Score != 0.9 {
+		t.Errorf("Unexpected chunks: %+v", chunks)
+	}
+	if !strings.Contains(message.Content, `<source index="2" id="doc-2">`) {
+		t.Errorf("Expected the second document tagged with its ID, got %q", message.Content)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// DefaultCompressionMinSize is the size in bytes below which content is stored uncompressed
+const DefaultCompressionMinSize = 1024
+
+// CompressionCodec compresses stored content. Codecs are looked up by name when content is
+// read back, so a codec must keep its name once content has been stored with it.
+type CompressionCodec interface {
+	Name() string
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// GzipCodec compresses content with gzip
+type GzipCodec struct {
+	// Level is the gzip compression level; zero uses the default level
+	Level int
+}
+
+// Name returns the codec name
+func (c GzipCodec) Name() string {
+	return "gzip"
+}
+
+// Compress gzips data
+func (c GzipCodec) Compress(data []byte) ([]byte, error) {
+	level := c.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	var buf bytes.Buffer
+	writer, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress gunzips data
+func (c GzipCodec) Decompress(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]CompressionCodec{"gzip": GzipCodec{}}
+)
+
+// RegisterCompressionCodec makes a codec available to compression settings and to the
+// decompression of content stored with it
+func RegisterCompressionCodec(codec CompressionCodec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[codec.Name()] = codec
+}
+
+// compressionCodec returns the registered codec of a name
+func compressionCodec(name string) (CompressionCodec, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, exists := codecs[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown compression codec: %s", name)
+	}
+	return codec, nil
+}
+
+// Compression compresses stored content of at least MinSize bytes with Codec. A nil
+// *Compression stores content as is.
+type Compression struct {
+	Codec   CompressionCodec
+	MinSize int
+}
+
+// NewCompression returns the compression of a registered codec; a minSize of zero or less
+// uses DefaultCompressionMinSize. An empty codec name disables compression and returns nil.
+func NewCompression(codecName string, minSize int) (*Compression, error) {
+	if codecName == "" {
+		return nil, nil
+	}
+	codec, err := compressionCodec(codecName)
+	if err != nil {
+		return nil, err
+	}
+	if minSize <= 0 {
+		minSize = DefaultCompressionMinSize
+	}
+	return &Compression{Codec: codec, MinSize: minSize}, nil
+}
+
+// compress returns the stored form of data and the name of the codec it was compressed
+// with, or "" when it is stored as is because it is small or does not compress
+func (c *Compression) compress(data []byte) ([]byte, string, error) {
+	if c == nil || c.Codec == nil || len(data) < c.MinSize {
+		return data, "", nil
+	}
+	compressed, err := c.Codec.Compress(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to compress with %s: %w", c.Codec.Name(), err)
+	}
+	if len(compressed) >= len(data) {
+		return data, "", nil
+	}
+	return compressed, c.Codec.Name(), nil
+}
+
+// decompress restores data stored with the named codec; "" means it was stored as is
+func decompress(codecName string, data []byte) ([]byte, error) {
+	if codecName == "" {
+		return data, nil
+	}
+	codec, err := compressionCodec(codecName)
+	if err != nil {
+		return nil, err
+	}
+	decompressed, err := codec.Decompress(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress with %s: %w", codecName, err)
+	}
+	return decompressed, nil
+}
+
+// compressedValueMarker starts the Redis values stored compressed, followed by the codec
+// name and a colon. Uncompressed values are JSON objects, which cannot start with it.
+const compressedValueMarker = "~"
+
+// encodeValue returns the stored form of a value in a key-value store, which marks the
+// codec in the value itself since there is no column to record it in
+func (c *Compression) encodeValue(data []byte) ([]byte, error) {
+	stored, codecName, err := c.compress(data)
+	if err != nil || codecName == "" {
+		return stored, err
+	}
+	return append([]byte(compressedValueMarker+codecName+":"), stored...), nil
+}
+
+// decodeValue restores a value stored with encodeValue, with or without compression
+func decodeValue(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(compressedValueMarker)) {
+		return data, nil
+	}
+	header, payload, found := strings.Cut(string(data[len(compressedValueMarker):]), ":")
+	if !found {
+		return nil, fmt.Errorf("malformed compressed value")
+	}
+	return decompress(header, []byte(payload))
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+func TestCompression(t *testing.T) {
+	compression, err := NewCompression("gzip", 64)
+	if err != nil {
+		t.Fatalf("NewCompression() failed: %v", err)
+	}
+	large := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog ", 20))
+
+	stored, codecName, err := compression.compress(large)
+	if err != nil || codecName != "gzip" || len(stored) >= len(large) {
+		t.Fatalf("Expected large content to be gzipped, got %d bytes with %q (%v)", len(stored), codecName, err)
+	}
+	restored, err := decompress(codecName, stored)
+	if err != nil || !bytes.Equal(restored, large) {
+		t.Errorf("Expected the content back, got %q (%v)", restored, err)
+	}
+
+	// Small content and disabled compression are stored as is
+	if _, codecName, _ := compression.compress([]byte("short")); codecName != "" {
+		t.Errorf("Expected content under the threshold not to be compressed, got %q", codecName)
+	}
+	var disabled *Compression
+	if _, codecName, _ := disabled.compress(large); codecName != "" {
+		t.Errorf("Expected a nil compression to store content as is, got %q", codecName)
+	}
+
+	// Key-value stores mark compressed values, and read plain values as before
+	value, err := compression.encodeValue(large)
+	if err != nil || !bytes.HasPrefix(value, []byte("~gzip:")) {
+		t.Fatalf("Expected a marked compressed value, got %q (%v)", value[:8], err)
+	}
+	for _, stored := range [][]byte{value, []byte(`{"id": "cp-1"}`)} {
+		if decoded, err := decodeValue(stored); err != nil || (!bytes.Equal(decoded, large) && !bytes.Equal(decoded, stored)) {
+			t.Errorf("Expected the value back, got %q (%v)", decoded, err)
+		}
+	}
+
+	if _, err := NewCompression("zstd", 0); err == nil {
+		t.Error("Expected an unregistered codec to be rejected")
+	}
+	if compression, err := NewCompression("", 0); compression != nil || err != nil {
+		t.Errorf("Expected an empty codec to disable compression, got %v (%v)", compression, err)
+	}
+}
+
+func TestSessionManager_CompressedMessages(t *testing.T) {
+	conn := &messageStoreConnection{MockConnection: MockConnection{config: &DatabaseConfig{Compression: "gzip", CompressionMinSize: 100}}}
+	manager := NewSessionManager(conn)
+	ctx := context.Background()
+
+	long := strings.Repeat("A long answer that repeats itself. ", 50)
+	for _, content := range []string{"hi", long} {
+		if err := manager.AppendMessage(ctx, "thread-1", llm.Message{Role: "assistant", Content: content}); err != nil {
+			t.Fatalf("AppendMessage() failed: %v", err)
+		}
+	}
+
+	// Rows: id, thread_id, message_type, role, content, ..., compression, content_compressed
+	if conn.rows[0][9] != "" || conn.rows[0][4] != "hi" {
+		t.Errorf("Expected the short message to be stored as is, got %v", conn.rows[0])
+	}
+	if conn.rows[1][9] != "gzip" || conn.rows[1][4] != "" || len(conn.rows[1][10].([]byte)) >= len(long) {
+		t.Errorf("Expected the long message to be stored gzipped, got codec %v", conn.rows[1][9])
+	}
+
+	messages, err := manager.LoadContext(ctx, "thread-1")
+	if err != nil {
+		t.Fatalf("LoadContext() failed: %v", err)
+	}
+	if len(messages) != 2 || messages[0].Content != "hi" || messages[1].Content != long {
+		t.Errorf("Expected the messages back uncompressed, got %v", messages)
+	}
+}
@@ -12,11 +12,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	_ "github.com/lib/pq"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
 )
@@ -67,6 +69,23 @@ type DatabaseConfig struct {
 	EmbeddingModel      string  `json:"embedding_model"`
 	EmbeddingDimension  int     `json:"embedding_dimension"`
 	SimilarityThreshold float64 `json:"similarity_threshold"`
+	// EmbeddingMismatchPolicy is "refuse" (default) or "warn"
+	EmbeddingMismatchPolicy EmbeddingMismatchPolicy `json:"embedding_mismatch_policy"`
+
+	// Compression is the codec compressing stored messages and Redis checkpoints, such as
+	// "gzip"; empty stores them as is
+	Compression string `json:"compression,omitempty"`
+	// CompressionMinSize is the size in bytes below which content is not compressed;
+	// defaults to DefaultCompressionMinSize
+	CompressionMinSize int `json:"compression_min_size,omitempty"`
+
+	// LogQueries logs every query at debug level with its duration and redacted arguments
+	LogQueries bool `json:"log_queries,omitempty"`
+	// SlowQueryThreshold, such as "500ms", logs slower queries at warn level even when
+	// LogQueries is off; empty disables it
+	SlowQueryThreshold string `json:"slow_query_threshold,omitempty"`
+	// TraceQueries starts an OpenTelemetry span per query
+	TraceQueries bool `json:"trace_queries,omitempty"`
 }
 
 // DatabaseConnection represents a database connection interface
@@ -86,13 +105,19 @@ type PostgresConnection struct {
 	db     *sql.DB
 	config *DatabaseConfig
 	logger *logrus.Logger
+
+	metrics            *QueryMetrics
+	slowQueryThreshold time.Duration
+	tracerProvider     trace.TracerProvider
+	traceMu            sync.RWMutex
 }
 
 // NewPostgresConnection creates a new PostgreSQL connection
 func NewPostgresConnection(config *DatabaseConfig) (*PostgresConnection, error) {
 	conn := &PostgresConnection{
-		config: config,
-		logger: logrus.New(),
+		config:  config,
+		logger:  logrus.New(),
+		metrics: NewQueryMetrics(),
 	}
 
 	if err := conn.Connect(); err != nil {
@@ -156,6 +181,14 @@ func (p *PostgresConnection) Connect() error {
 		db.SetConnMaxLifetime(5 * time.Minute) // Default
 	}
 
+	if p.config.SlowQueryThreshold != "" {
+		threshold, err := time.ParseDuration(p.config.SlowQueryThreshold)
+		if err != nil {
+			p.logger.WithError(err).Warn("Invalid slow query threshold, slow queries are not logged")
+		}
+		p.slowQueryThreshold = threshold
+	}
+
 	p.db = db
 	return p.Ping()
 }
@@ -192,25 +225,36 @@ func (p *PostgresConnection) GetConfig() *DatabaseConfig {
 
 // ExecuteQuery executes a query without returning results
 func (p *PostgresConnection) ExecuteQuery(ctx context.Context, query string, args ...interface{}) error {
+	ctx, done := p.traceQuery(ctx, query, args)
 	_, err := p.db.ExecContext(ctx, query, args...)
+	done(err)
 	return err
 }
 
 // QueryRow executes a query that returns a single row
 func (p *PostgresConnection) QueryRow(ctx context.Context, query string, args ...interface{}) interface{} {
-	return p.db.QueryRowContext(ctx, query, args...)
+	ctx, done := p.traceQuery(ctx, query, args)
+	row := p.db.QueryRowContext(ctx, query, args...)
+	done(row.Err())
+	return row
 }
 
-// QueryRows executes a query that returns multiple rows
+// QueryRows executes a query that returns multiple rows; the rows are read after the query
+// is traced
 func (p *PostgresConnection) QueryRows(ctx context.Context, query string, args ...interface{}) (interface{}, error) {
-	return p.db.QueryContext(ctx, query, args...)
+	ctx, done := p.traceQuery(ctx, query, args)
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	done(err)
+	return rows, err
 }
 
 // PostgresCheckpointer implements database-based checkpointing with PostgreSQL
 type PostgresCheckpointer struct {
-	conn   *PostgresConnection
-	config *DatabaseConfig
-	logger *logrus.Logger
+	conn       *PostgresConnection
+	config     *DatabaseConfig
+	logger     *logrus.Logger
+	metaMu     sync.RWMutex
+	vectorMeta *VectorStoreMetadata
 }
 
 // NewPostgresCheckpointer creates a new PostgreSQL checkpointer
@@ -277,6 +321,44 @@ func (p *PostgresCheckpointer) initSchema() error {
 		FOREIGN KEY (thread_id) REFERENCES threads(id) ON DELETE CASCADE
 	);
 
+	-- Messages of each thread, with the rolling summaries of long conversations
+	CREATE TABLE IF NOT EXISTS thread_messages (
+		id BIGSERIAL PRIMARY KEY,
+		thread_id VARCHAR(255) NOT NULL,
+		message_type VARCHAR(32) NOT NULL DEFAULT 'message',
+		role VARCHAR(32) NOT NULL,
+		content TEXT,
+		tool_call_id VARCHAR(255),
+		tool_calls JSONB,
+		metadata JSONB,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		compression VARCHAR(32),
+		content_compressed BYTEA,
+		pinned BOOLEAN NOT NULL DEFAULT FALSE,
+		FOREIGN KEY (thread_id) REFERENCES threads(id) ON DELETE CASCADE
+	);
+
+	-- Migration: compressed message content, for tables created before it was supported
+	ALTER TABLE thread_messages ADD COLUMN IF NOT EXISTS compression VARCHAR(32);
+	ALTER TABLE thread_messages ADD COLUMN IF NOT EXISTS content_compressed BYTEA;
+	-- Migration: pinned messages, for tables created before pins were stored
+	ALTER TABLE thread_messages ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE;
+
+	-- Tool audit log recording every tool invocation
+	CREATE TABLE IF NOT EXISTS tool_audit_log (
+		id VARCHAR(255) PRIMARY KEY,
+		agent_id VARCHAR(255),
+		session_id VARCHAR(255),
+		execution_id VARCHAR(255),
+		tool_name VARCHAR(255) NOT NULL,
+		arguments TEXT,
+		result_bytes INTEGER,
+		duration_ms BIGINT,
+		success BOOLEAN NOT NULL,
+		error TEXT,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+	);
+
 	-- Create indexes for better performance
 	CREATE INDEX IF NOT EXISTS idx_checkpoints_thread_id ON checkpoints(thread_id);
 	CREATE INDEX IF NOT EXISTS idx_checkpoints_created_at ON checkpoints(created_at);
@@ -285,6 +367,10 @@ func (p *PostgresCheckpointer) initSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_sessions_thread_id ON sessions(thread_id);
 	CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
 	CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions(expires_at);
+	CREATE INDEX IF NOT EXISTS idx_thread_messages_thread_id ON thread_messages(thread_id, id);
+	CREATE INDEX IF NOT EXISTS idx_thread_messages_summaries ON thread_messages(thread_id, id) WHERE message_type = 'summary';
+	CREATE INDEX IF NOT EXISTS idx_tool_audit_log_execution_id ON tool_audit_log(execution_id);
+	CREATE INDEX IF NOT EXISTS idx_tool_audit_log_session_id ON tool_audit_log(session_id);
 	`
 
 	if err := p.conn.ExecuteQuery(context.Background(), schema); err != nil {
@@ -319,7 +405,7 @@ func (p *PostgresCheckpointer) initRAGSchema() error {
 			thread_id VARCHAR(255),
 			content TEXT NOT NULL,
 			metadata JSONB,
-			embedding vector(%d),
+			embedding vector(%[1]d),
 			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
 			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
 			FOREIGN KEY (thread_id) REFERENCES threads(id) ON DELETE CASCADE
@@ -332,20 +418,20 @@ func (p *PostgresCheckpointer) initRAGSchema() error {
 			user_id VARCHAR(255),
 			content TEXT NOT NULL,
 			memory_type VARCHAR(50) DEFAULT 'conversation',
-			embedding vector(%d),
+			embedding vector(%[1]d),
 			metadata JSONB,
 			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
 			FOREIGN KEY (thread_id) REFERENCES threads(id) ON DELETE CASCADE
 		);
 
 		-- Vector indexes for similarity search
-		CREATE INDEX IF NOT EXISTS idx_documents_embedding ON documents USING ivfflat (embedding vector_cosine_ops);
-		CREATE INDEX IF NOT EXISTS idx_memory_embedding ON memory USING ivfflat (embedding vector_cosine_ops);
+		CREATE INDEX IF NOT EXISTS idx_documents_embedding ON documents USING ivfflat (embedding %[2]s);
+		CREATE INDEX IF NOT EXISTS idx_memory_embedding ON memory USING ivfflat (embedding %[2]s);
 		CREATE INDEX IF NOT EXISTS idx_documents_thread_id ON documents(thread_id);
 		CREATE INDEX IF NOT EXISTS idx_memory_thread_id ON memory(thread_id);
 		CREATE INDEX IF NOT EXISTS idx_memory_user_id ON memory(user_id);
 		CREATE INDEX IF NOT EXISTS idx_memory_type ON memory(memory_type);
-		`, vectorDim, vectorDim)
+		`, vectorDim, vectorOperatorClass(p.config.VectorMetric)) + collectionSchema
 	} else {
 		// Fallback without vector support
 		vectorSchema = `
@@ -382,7 +468,11 @@ func (p *PostgresCheckpointer) initRAGSchema() error {
 		`
 	}
 
-	return p.conn.ExecuteQuery(context.Background(), vectorSchema)
+	if err := p.conn.ExecuteQuery(context.Background(), vectorSchema); err != nil {
+		return err
+	}
+
+	return p.initVectorMetadata(context.Background())
 }
 
 // Save saves a checkpoint to PostgreSQL
@@ -536,6 +626,11 @@ func (p *PostgresCheckpointer) Close() error {
 	return p.conn.Close()
 }
 
+// Connection returns the checkpointer's connection, to serve its metrics or trace its queries
+func (p *PostgresCheckpointer) Connection() *PostgresConnection {
+	return p.conn
+}
+
 // RAG-specific methods
 
 // SaveDocument saves a document for RAG
@@ -575,32 +670,52 @@ func (p *PostgresCheckpointer) SaveDocument(ctx context.Context, doc *Document)
 
 // SearchDocuments performs similarity search on documents
 func (p *PostgresCheckpointer) SearchDocuments(ctx context.Context, threadID string, queryEmbedding []float64, limit int) ([]*Document, error) {
+	if err := p.checkQueryEmbedding(ctx, "", len(queryEmbedding)); err != nil {
+		return nil, err
+	}
+	return p.searchDocuments(ctx, threadID, queryEmbedding, limit, nil)
+}
+
+// searchDocuments runs the similarity search query, keeping the documents whose metadata
+// contains the filter
+func (p *PostgresCheckpointer) searchDocuments(ctx context.Context, threadID string, queryEmbedding []float64, limit int, filter map[string]interface{}) ([]*Document, error) {
 	if !p.config.EnableRAG {
 		return nil, fmt.Errorf("RAG is not enabled")
 	}
 
 	var query string
-	var args []interface{}
+	args := []interface{}{threadID}
 
-	if p.config.Type == DatabaseTypePgVector && queryEmbedding != nil {
-		query = `
-			SELECT id, thread_id, content, metadata, embedding, created_at, updated_at
+	conditions := "thread_id = $1"
+	if len(filter) > 0 {
+		filterData, err := json.Marshal(filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata filter: %w", err)
+		}
+		args = append(args, filterData)
+		conditions += fmt.Sprintf(" AND metadata @> $%d", len(args))
+	}
+
+	vectorSearch := p.config.Type == DatabaseTypePgVector && queryEmbedding != nil
+	if vectorSearch {
+		args = append(args, queryEmbedding, limit)
+		query = fmt.Sprintf(`
+			SELECT id, thread_id, content, metadata, embedding, embedding %s $%d AS distance, created_at, updated_at
 			FROM documents
-			WHERE thread_id = $1
-			ORDER BY embedding <-> $2
-			LIMIT $3
-		`
-		args = []interface{}{threadID, queryEmbedding, limit}
+			WHERE %s
+			ORDER BY distance
+			LIMIT $%d
+		`, vectorDistanceOperator(p.config.VectorMetric), len(args)-1, conditions, len(args))
 	} else {
 		// Fallback to text search
-		query = `
+		args = append(args, limit)
+		query = fmt.Sprintf(`
 			SELECT id, thread_id, content, metadata, created_at, updated_at
 			FROM documents
-			WHERE thread_id = $1
+			WHERE %s
 			ORDER BY created_at DESC
-			LIMIT $2
-		`
-		args = []interface{}{threadID, limit}
+			LIMIT $%d
+		`, conditions, len(args))
 	}
 
 	rows, err := p.conn.QueryRows(ctx, query, args...)
@@ -614,13 +729,15 @@ func (p *PostgresCheckpointer) SearchDocuments(ctx context.Context, threadID str
 		var doc Document
 		var metadataData []byte
 		var embedding interface{}
+		var distance float64
 
-		if p.config.Type == DatabaseTypePgVector {
-			err := rows.(*sql.Rows).Scan(&doc.ID, &doc.ThreadID, &doc.Content, &metadataData, &embedding, &doc.CreatedAt, &doc.UpdatedAt)
+		if vectorSearch {
+			err := rows.(*sql.Rows).Scan(&doc.ID, &doc.ThreadID, &doc.Content, &metadataData, &embedding, &distance, &doc.CreatedAt, &doc.UpdatedAt)
 			if err != nil {
 				return nil, fmt.Errorf("failed to scan document: %w", err)
 			}
 			// Handle embedding conversion if needed
+			doc.Score = SimilarityFromDistance(p.config.VectorMetric, distance)
 		} else {
 			err := rows.(*sql.Rows).Scan(&doc.ID, &doc.ThreadID, &doc.Content, &metadataData, &doc.CreatedAt, &doc.UpdatedAt)
 			if err != nil {
@@ -636,19 +753,30 @@ func (p *PostgresCheckpointer) SearchDocuments(ctx context.Context, threadID str
 		documents = append(documents, &doc)
 	}
 
+	// Results are ordered by distance, so filtering the top results gives the same documents
+	// as filtering before the limit. Text search results have no score to filter on.
+	if vectorSearch {
+		documents = FilterBySimilarity(documents, p.config.SimilarityThreshold)
+	}
 	return documents, nil
 }
 
 // RedisCheckpointer implements Redis-based checkpointing
 type RedisCheckpointer struct {
-	client *redis.Client
-	config *DatabaseConfig
-	logger *logrus.Logger
-	ttl    time.Duration
+	client      *redis.Client
+	config      *DatabaseConfig
+	logger      *logrus.Logger
+	ttl         time.Duration
+	compression *Compression
 }
 
 // NewRedisCheckpointer creates a new Redis checkpointer
 func NewRedisCheckpointer(config *DatabaseConfig) (*RedisCheckpointer, error) {
+	compression, err := NewCompression(config.Compression, config.CompressionMinSize)
+	if err != nil {
+		return nil, err
+	}
+
 	client := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%d", config.Host, config.Port),
 		Password: config.Password,
@@ -664,10 +792,11 @@ func NewRedisCheckpointer(config *DatabaseConfig) (*RedisCheckpointer, error) {
 	}
 
 	return &RedisCheckpointer{
-		client: client,
-		config: config,
-		logger: logrus.New(),
-		ttl:    24 * time.Hour, // Default TTL
+		client:      client,
+		config:      config,
+		logger:      logrus.New(),
+		ttl:         24 * time.Hour, // Default TTL
+		compression: compression,
 	}, nil
 }
 
@@ -677,6 +806,10 @@ func (r *RedisCheckpointer) Save(ctx context.Context, checkpoint *Checkpoint) er
 	if err != nil {
 		return fmt.Errorf("failed to marshal checkpoint: %w", err)
 	}
+	data, err = r.compression.encodeValue(data)
+	if err != nil {
+		return err
+	}
 
 	key := fmt.Sprintf("checkpoint:%s:%s", checkpoint.ThreadID, checkpoint.ID)
 
@@ -697,16 +830,20 @@ func (r *RedisCheckpointer) Save(ctx context.Context, checkpoint *Checkpoint) er
 func (r *RedisCheckpointer) Load(ctx context.Context, threadID, checkpointID string) (*Checkpoint, error) {
 	key := fmt.Sprintf("checkpoint:%s:%s", threadID, checkpointID)
 
-	data, err := r.client.Get(ctx, key).Result()
+	stored, err := r.client.Get(ctx, key).Bytes()
 	if err != nil {
 		if err == redis.Nil {
 			return nil, fmt.Errorf("checkpoint %s not found in thread %s", checkpointID, threadID)
 		}
 		return nil, fmt.Errorf("failed to load checkpoint from Redis: %w", err)
 	}
+	data, err := decodeValue(stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint from Redis: %w", err)
+	}
 
 	var checkpoint Checkpoint
-	if err := json.Unmarshal([]byte(data), &checkpoint); err != nil {
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal checkpoint: %w", err)
 	}
 
@@ -773,14 +910,22 @@ type Document struct {
 	Content   string                 `json:"content"`
 	Metadata  map[string]interface{} `json:"metadata"`
 	Embedding []float64              `json:"embedding,omitempty"`
-	CreatedAt time.Time              `json:"created_at"`
-	UpdatedAt time.Time              `json:"updated_at"`
+	// Collection is the named collection of the document, when it was returned by one
+	Collection string `json:"collection,omitempty"`
+	// Score is the similarity to the query when the document was returned by a search
+	Score     float64   `json:"score,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // SessionManager manages user sessions and threads
 type SessionManager struct {
-	conn   DatabaseConnection
-	logger *logrus.Logger
+	conn           DatabaseConnection
+	logger         *logrus.Logger
+	titleGenerator TitleGenerator
+	summarizer     ConversationSummarizer
+	summaryEvery   int
+	compression    *Compression
 }
 
 // Session represents a user session
@@ -804,10 +949,31 @@ type Thread struct {
 
 // NewSessionManager creates a new session manager
 func NewSessionManager(conn DatabaseConnection) *SessionManager {
-	return &SessionManager{
+	sm := &SessionManager{
 		conn:   conn,
 		logger: logrus.New(),
 	}
+	if conn != nil {
+		if config := conn.GetConfig(); config != nil {
+			compression, err := NewCompression(config.Compression, config.CompressionMinSize)
+			if err != nil {
+				sm.logger.WithError(err).Warn("Message compression disabled")
+			}
+			sm.compression = compression
+		}
+	}
+	return sm
+}
+
+// Connection returns the session manager's database connection
+func (sm *SessionManager) Connection() DatabaseConnection {
+	return sm.conn
+}
+
+// SetCompression sets the compression of the message content stored from now on; messages
+// are read back whatever their compression. Nil stores content as is.
+func (sm *SessionManager) SetCompression(compression *Compression) {
+	sm.compression = compression
 }
 
 // CreateSession creates a new session
@@ -834,6 +1000,10 @@ func (sm *SessionManager) CreateSession(ctx context.Context, session *Session) e
 
 // GetSession retrieves a session
 func (sm *SessionManager) GetSession(ctx context.Context, sessionID string) (*Session, error) {
+	if sm.conn == nil {
+		return nil, fmt.Errorf("session store not configured")
+	}
+
 	query := `
 		SELECT id, thread_id, user_id, metadata, created_at, expires_at
 		FROM sessions
@@ -0,0 +1,259 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ExportFormat is a conversation export format
+type ExportFormat string
+
+const (
+	ExportFormatJSON     ExportFormat = "json"
+	ExportFormatMarkdown ExportFormat = "markdown"
+	ExportFormatHTML     ExportFormat = "html"
+)
+
+// ParseExportFormat parses an export format name; md is accepted for Markdown
+func ParseExportFormat(name string) (ExportFormat, error) {
+	switch strings.ToLower(name) {
+	case "", "json":
+		return ExportFormatJSON, nil
+	case "md", "markdown":
+		return ExportFormatMarkdown, nil
+	case "html":
+		return ExportFormatHTML, nil
+	default:
+		return "", fmt.Errorf("unsupported export format: %s", name)
+	}
+}
+
+// ContentType returns the MIME type of the format
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportFormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	case ExportFormatHTML:
+		return "text/html; charset=utf-8"
+	default:
+		return "application/json"
+	}
+}
+
+// FileExtension returns the file extension of the format
+func (f ExportFormat) FileExtension() string {
+	switch f {
+	case ExportFormatMarkdown:
+		return "md"
+	case ExportFormatHTML:
+		return "html"
+	default:
+		return "json"
+	}
+}
+
+// ConversationToolCall is a tool call made by an assistant message
+type ConversationToolCall struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+// ConversationMessage is a single message of an exported conversation
+type ConversationMessage struct {
+	Role      string                 `json:"role"`
+	Content   string                 `json:"content"`
+	Timestamp time.Time              `json:"timestamp"`
+	ToolCalls []ConversationToolCall `json:"tool_calls,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Pinned    bool                   `json:"pinned,omitempty"`
+}
+
+// Conversation is an exportable conversation of a session
+type Conversation struct {
+	SessionID  string                 `json:"session_id"`
+	ThreadID   string                 `json:"thread_id,omitempty"`
+	UserID     string                 `json:"user_id,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt  time.Time              `json:"created_at,omitempty"`
+	ExportedAt time.Time              `json:"exported_at"`
+	Messages   []ConversationMessage  `json:"messages"`
+}
+
+// NewConversation creates a conversation for a session; session may be nil
+func NewConversation(sessionID string, session *Session) *Conversation {
+	conversation := &Conversation{
+		SessionID:  sessionID,
+		ExportedAt: time.Now(),
+		Messages:   []ConversationMessage{},
+	}
+	if session != nil {
+		conversation.ThreadID = session.ThreadID
+		conversation.UserID = session.UserID
+		conversation.Metadata = session.Metadata
+		conversation.CreatedAt = session.CreatedAt
+	}
+	return conversation
+}
+
+// SortMessages orders the messages by timestamp, keeping the order of equal timestamps
+func (c *Conversation) SortMessages() {
+	sort.SliceStable(c.Messages, func(i, j int) bool {
+		return c.Messages[i].Timestamp.Before(c.Messages[j].Timestamp)
+	})
+}
+
+// ExportConversation writes a conversation in the given format
+func ExportConversation(w io.Writer, conversation *Conversation, format ExportFormat) error {
+	switch format {
+	case ExportFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(conversation)
+	case ExportFormatMarkdown:
+		return exportMarkdown(w, conversation)
+	case ExportFormatHTML:
+		return conversationHTMLTemplate.Execute(w, conversation)
+	default:
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// exportMarkdown writes a conversation as Markdown with a section per message
+func exportMarkdown(w io.Writer, conversation *Conversation) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Conversation %s\n\n", conversation.SessionID)
+	if conversation.UserID != "" {
+		fmt.Fprintf(&b, "- **User:** %s\n", conversation.UserID)
+	}
+	if conversation.ThreadID != "" {
+		fmt.Fprintf(&b, "- **Thread:** %s\n", conversation.ThreadID)
+	}
+	if !conversation.CreatedAt.IsZero() {
+		fmt.Fprintf(&b, "- **Created:** %s\n", conversation.CreatedAt.Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "- **Exported:** %s\n", conversation.ExportedAt.Format(time.RFC3339))
+	writeMarkdownMetadata(&b, conversation.Metadata)
+
+	for _, message := range conversation.Messages {
+		fmt.Fprintf(&b, "\n## %s", roleTitle(message.Role))
+		if !message.Timestamp.IsZero() {
+			fmt.Fprintf(&b, " — %s", message.Timestamp.Format(time.RFC3339))
+		}
+		if message.Pinned {
+			b.WriteString(" (pinned)")
+		}
+		b.WriteString("\n\n")
+		if message.Content != "" {
+			b.WriteString(message.Content)
+			b.WriteString("\n")
+		}
+
+		for _, call := range message.ToolCalls {
+			fmt.Fprintf(&b, "\n**Tool call:** `%s`\n", call.Name)
+			if call.Arguments != "" {
+				fmt.Fprintf(&b, "\n```json\n%s\n```\n", call.Arguments)
+			}
+		}
+		writeMarkdownMetadata(&b, message.Metadata)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMarkdownMetadata writes metadata as a sorted bullet list
+func writeMarkdownMetadata(b *strings.Builder, metadata map[string]interface{}) {
+	if len(metadata) == 0 {
+		return
+	}
+	b.WriteString("\n<details><summary>Metadata</summary>\n\n")
+	for _, key := range sortedKeys(metadata) {
+		fmt.Fprintf(b, "- `%s`: %v\n", key, metadata[key])
+	}
+	b.WriteString("\n</details>\n")
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// roleTitle returns a heading for a message role
+func roleTitle(role string) string {
+	if role == "" {
+		return "Message"
+	}
+	return strings.ToUpper(role[:1]) + role[1:]
+}
+
+// conversationHTMLTemplate renders a self-contained HTML page
+var conversationHTMLTemplate = template.Must(template.New("conversation").Funcs(template.FuncMap{
+	"title":      roleTitle,
+	"sortedKeys": sortedKeys,
+	"timestamp":  func(t time.Time) string { return t.Format(time.RFC3339) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Conversation {{.SessionID}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; max-width: 860px; margin: 2rem auto; padding: 0 1rem; color: #1f2328; }
+header { border-bottom: 1px solid #d0d7de; margin-bottom: 1.5rem; }
+.message { border: 1px solid #d0d7de; border-radius: 8px; padding: 0.75rem 1rem; margin-bottom: 1rem; }
+.message.user { background: #f6f8fa; }
+.message.assistant { background: #ffffff; }
+.message.system, .message.tool { background: #fff8c5; }
+.role { font-weight: 600; }
+.time { color: #656d76; font-size: 0.85rem; margin-left: 0.5rem; }
+.content { white-space: pre-wrap; margin-top: 0.5rem; }
+pre { background: #f6f8fa; padding: 0.5rem; border-radius: 6px; overflow-x: auto; }
+dl { font-size: 0.85rem; color: #656d76; }
+</style>
+</head>
+<body>
+<header>
+<h1>Conversation {{.SessionID}}</h1>
+<p>{{if .UserID}}User: {{.UserID}} · {{end}}{{if .ThreadID}}Thread: {{.ThreadID}} · {{end}}Exported: {{timestamp .ExportedAt}}</p>
+{{- with .Metadata}}
+<dl>{{range $key := sortedKeys .}}<dt>{{$key}}</dt><dd>{{index $.Metadata $key}}</dd>{{end}}</dl>
+{{- end}}
+</header>
+{{- range .Messages}}
+<section class="message {{.Role}}">
+<span class="role">{{title .Role}}</span>{{if not .Timestamp.IsZero}}<span class="time">{{timestamp .Timestamp}}</span>{{end}}
+{{- if .Content}}
+<div class="content">{{.Content}}</div>
+{{- end}}
+{{- range .ToolCalls}}
+<p>Tool call: <code>{{.Name}}</code></p>
+{{- if .Arguments}}
+<pre>{{.Arguments}}</pre>
+{{- end}}
+{{- end}}
+{{- with .Metadata}}
+{{- $metadata := .}}
+<dl>{{range $key := sortedKeys .}}<dt>{{$key}}</dt><dd>{{index $metadata $key}}</dd>{{end}}</dl>
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,83 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testConversation() *Conversation {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	conversation := NewConversation("session-1", &Session{ID: "session-1", UserID: "alice", Metadata: map[string]interface{}{"channel": "web"}})
+	conversation.Messages = []ConversationMessage{
+		{Role: "assistant", Content: "It is <b>sunny</b>.", Timestamp: start.Add(time.Second),
+			ToolCalls: []ConversationToolCall{{ID: "call_1", Name: "weather", Arguments: `{"city":"Paris"}`}},
+			Metadata:  map[string]interface{}{"agent_id": "helper"}},
+		{Role: "user", Content: "What's the weather?", Timestamp: start},
+	}
+	conversation.SortMessages()
+	return conversation
+}
+
+func TestParseExportFormat(t *testing.T) {
+	for name, expected := range map[string]ExportFormat{"": ExportFormatJSON, "md": ExportFormatMarkdown, "HTML": ExportFormatHTML} {
+		format, err := ParseExportFormat(name)
+		if err != nil || format != expected {
+			t.Errorf("ParseExportFormat(%q) = %v, %v; expected %v", name, format, err, expected)
+		}
+	}
+	if _, err := ParseExportFormat("pdf"); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+func TestExportConversation(t *testing.T) {
+	conversation := testConversation()
+	if conversation.Messages[0].Role != "user" {
+		t.Fatalf("Expected messages sorted by time, got %v first", conversation.Messages[0].Role)
+	}
+
+	var markdown bytes.Buffer
+	if err := ExportConversation(&markdown, conversation, ExportFormatMarkdown); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"# Conversation session-1", "**User:** alice", "## User — 2024-05-01T12:00:00Z", "## Assistant", "**Tool call:** `weather`", `{"city":"Paris"}`, "`agent_id`: helper", "`channel`: web"} {
+		if !strings.Contains(markdown.String(), expected) {
+			t.Errorf("Expected Markdown export to contain %q:\n%s", expected, markdown.String())
+		}
+	}
+
+	var html bytes.Buffer
+	if err := ExportConversation(&html, conversation, ExportFormatHTML); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html.String(), "<!DOCTYPE html>") || !strings.Contains(html.String(), "<style>") {
+		t.Error("Expected a self-contained HTML document")
+	}
+	if !strings.Contains(html.String(), "It is &lt;b&gt;sunny&lt;/b&gt;.") {
+		t.Error("Expected message content to be escaped in HTML export")
+	}
+	if !strings.Contains(html.String(), "<code>weather</code>") {
+		t.Error("Expected tool calls in HTML export")
+	}
+
+	var data bytes.Buffer
+	if err := ExportConversation(&data, conversation, ExportFormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Conversation
+	if err := json.Unmarshal(data.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Messages) != 2 || decoded.Messages[1].ToolCalls[0].Name != "weather" {
+		t.Errorf("Unexpected JSON export: %s", data.String())
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+const (
+	defaultIngestBatchSize   = 100
+	defaultIngestConcurrency = 4
+)
+
+// DocumentWriter stores embedded documents
+type DocumentWriter interface {
+	// InsertDocuments inserts documents and their embeddings into a table
+	InsertDocuments(ctx context.Context, table string, docs []*Document) error
+}
+
+// IngestConfig configures document ingestion
+type IngestConfig struct {
+	// Table receives the documents; defaults to the documents table
+	Table string `json:"table"`
+	// BatchSize is the number of documents embedded per call
+	BatchSize int `json:"batch_size"`
+	// MaxBatchSize is the provider's limit of texts per call, see
+	// llm.ProviderManager.MaxEmbeddingBatchSize; zero means no limit
+	MaxBatchSize int `json:"max_batch_size"`
+	// Concurrency is the number of batches embedded at the same time
+	Concurrency int `json:"concurrency"`
+	// Dimension of the embeddings; any dimension is accepted when zero
+	Dimension int `json:"dimension"`
+	// MaxRetries is how often a rate-limited batch is retried before it fails
+	MaxRetries int `json:"max_retries"`
+	// RetryDelay is the initial backoff after a rate limit, doubled on every retry
+	RetryDelay time.Duration `json:"retry_delay"`
+	// Progress is called after each batch completes
+	Progress func(progress IngestProgress) `json:"-"`
+}
+
+// DefaultIngestConfig returns default ingestion configuration
+func DefaultIngestConfig() *IngestConfig {
+	return &IngestConfig{
+		Table:       DocumentsTable,
+		BatchSize:   defaultIngestBatchSize,
+		Concurrency: defaultIngestConcurrency,
+		MaxRetries:  3,
+		RetryDelay:  time.Second,
+	}
+}
+
+// IngestProgress reports the state of a running ingestion
+type IngestProgress struct {
+	Processed int `json:"processed"`
+	Failed    int `json:"failed"`
+	Total     int `json:"total"`
+}
+
+// IngestBatchError records a batch that could not be embedded or stored
+type IngestBatchError struct {
+	DocumentIDs []string `json:"document_ids"`
+	Err         error    `json:"-"`
+}
+
+// Error implements the error interface
+func (e *IngestBatchError) Error() string {
+	return fmt.Sprintf("batch of %d documents starting at %s: %v", len(e.DocumentIDs), e.DocumentIDs[0], e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *IngestBatchError) Unwrap() error {
+	return e.Err
+}
+
+// IngestResult summarizes an ingestion
+type IngestResult struct {
+	Total    int                 `json:"total"`
+	Stored   int                 `json:"stored"`
+	Failed   int                 `json:"failed"`
+	Batches  int                 `json:"batches"`
+	Errors   []*IngestBatchError `json:"errors,omitempty"`
+	Duration time.Duration       `json:"duration"`
+}
+
+// IngestDocuments embeds documents in batches, running up to config.Concurrency batches at
+// once, and stores them. A rate-limited batch pauses every worker and is retried with
+// backoff. Failed batches do not stop the ingestion; they are listed in the result and
+// reported together in the returned error.
+func IngestDocuments(ctx context.Context, store DocumentWriter, embed EmbedFunc, docs []*Document, config *IngestConfig) (*IngestResult, error) {
+	if config == nil {
+		config = DefaultIngestConfig()
+	}
+	table := config.Table
+	if table == "" {
+		table = DocumentsTable
+	}
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultIngestBatchSize
+	}
+	if config.MaxBatchSize > 0 && batchSize > config.MaxBatchSize {
+		batchSize = config.MaxBatchSize
+	}
+	concurrency := config.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultIngestConcurrency
+	}
+
+	startTime := time.Now()
+	result := &IngestResult{Total: len(docs)}
+
+	batches := make(chan []*Document)
+	go func() {
+		defer close(batches)
+		for start := 0; start < len(docs); start += batchSize {
+			end := start + batchSize
+			if end > len(docs) {
+				end = len(docs)
+			}
+			select {
+			case batches <- docs[start:end]:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	ingester := &batchIngester{store: store, embed: embed, table: table, config: config}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				err := ingester.ingest(ctx, batch)
+
+				mu.Lock()
+				result.Batches++
+				if err != nil {
+					ids := make([]string, len(batch))
+					for j, doc := range batch {
+						ids[j] = doc.ID
+					}
+					result.Errors = append(result.Errors, &IngestBatchError{DocumentIDs: ids, Err: err})
+					result.Failed += len(batch)
+				} else {
+					result.Stored += len(batch)
+				}
+				if config.Progress != nil {
+					config.Progress(IngestProgress{Processed: result.Stored + result.Failed, Failed: result.Failed, Total: result.Total})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	result.Duration = time.Since(startTime)
+	if err := ctx.Err(); err != nil {
+		return result, fmt.Errorf("ingestion interrupted after %d documents: %w", result.Stored+result.Failed, err)
+	}
+	if len(result.Errors) > 0 {
+		errs := make([]error, len(result.Errors))
+		for i, batchErr := range result.Errors {
+			errs[i] = batchErr
+		}
+		return result, fmt.Errorf("%d of %d documents failed to ingest: %w", result.Failed, result.Total, errors.Join(errs...))
+	}
+	return result, nil
+}
+
+// batchIngester embeds and stores batches, sharing rate-limit backoff between workers
+type batchIngester struct {
+	store  DocumentWriter
+	embed  EmbedFunc
+	table  string
+	config *IngestConfig
+
+	mu          sync.Mutex
+	pausedUntil time.Time
+}
+
+// ingest embeds and stores a single batch
+func (bi *batchIngester) ingest(ctx context.Context, batch []*Document) error {
+	texts := make([]string, len(batch))
+	for i, doc := range batch {
+		texts[i] = doc.Content
+	}
+
+	delay := bi.config.RetryDelay
+	if delay <= 0 {
+		delay = time.Second
+	}
+
+	var embeddings [][]float64
+	for attempt := 0; ; attempt++ {
+		if err := bi.waitForRateLimit(ctx); err != nil {
+			return err
+		}
+
+		var err error
+		embeddings, err = bi.embed(ctx, texts)
+		if err == nil {
+			break
+		}
+		if attempt >= bi.config.MaxRetries || !llm.IsRateLimitError(err) {
+			return fmt.Errorf("failed to embed documents: %w", err)
+		}
+		bi.pause(delay)
+		delay *= 2
+	}
+
+	if len(embeddings) != len(batch) {
+		return fmt.Errorf("embedding returned %d vectors for %d documents", len(embeddings), len(batch))
+	}
+	for i, doc := range batch {
+		if bi.config.Dimension > 0 && len(embeddings[i]) != bi.config.Dimension {
+			return fmt.Errorf("document %s has embedding dimension %d, expected %d", doc.ID, len(embeddings[i]), bi.config.Dimension)
+		}
+		doc.Embedding = embeddings[i]
+	}
+
+	if err := bi.store.InsertDocuments(ctx, bi.table, batch); err != nil {
+		return fmt.Errorf("failed to store documents: %w", err)
+	}
+	return nil
+}
+
+// pause holds back every worker until the backoff has passed
+func (bi *batchIngester) pause(delay time.Duration) {
+	bi.mu.Lock()
+	defer bi.mu.Unlock()
+
+	if until := time.Now().Add(delay); until.After(bi.pausedUntil) {
+		bi.pausedUntil = until
+	}
+}
+
+// waitForRateLimit waits until the current backoff has passed
+func (bi *batchIngester) waitForRateLimit(ctx context.Context) error {
+	bi.mu.Lock()
+	wait := time.Until(bi.pausedUntil)
+	bi.mu.Unlock()
+
+	if wait <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
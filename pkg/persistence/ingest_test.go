@@ -0,0 +1,155 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memoryDocumentWriter collects ingested documents
+type memoryDocumentWriter struct {
+	mu   sync.Mutex
+	docs map[string]*Document
+}
+
+func (w *memoryDocumentWriter) InsertDocuments(ctx context.Context, table string, docs []*Document) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.docs == nil {
+		w.docs = make(map[string]*Document)
+	}
+	for _, doc := range docs {
+		w.docs[doc.ID] = doc
+	}
+	return nil
+}
+
+func ingestTestDocuments(count int) []*Document {
+	docs := make([]*Document, count)
+	for i := range docs {
+		docs[i] = &Document{ID: fmt.Sprintf("doc-%03d", i), Content: strings.Repeat("x", i+1)}
+	}
+	return docs
+}
+
+// slowEmbedder simulates the latency of an embedding provider
+func slowEmbedder(latency time.Duration, batchSizes *[]int, mu *sync.Mutex) EmbedFunc {
+	return func(ctx context.Context, texts []string) ([][]float64, error) {
+		if batchSizes != nil {
+			mu.Lock()
+			*batchSizes = append(*batchSizes, len(texts))
+			mu.Unlock()
+		}
+		time.Sleep(latency)
+
+		embeddings := make([][]float64, len(texts))
+		for i, text := range texts {
+			embeddings[i] = []float64{float64(len(text)), 1}
+		}
+		return embeddings, nil
+	}
+}
+
+func TestIngestDocuments(t *testing.T) {
+	writer := &memoryDocumentWriter{}
+	var batchSizes []int
+	var mu sync.Mutex
+
+	config := DefaultIngestConfig()
+	config.BatchSize = 10
+	config.MaxBatchSize = 4
+	config.Concurrency = 3
+	var progressCalls int32
+	config.Progress = func(progress IngestProgress) { atomic.AddInt32(&progressCalls, 1) }
+
+	result, err := IngestDocuments(context.Background(), writer, slowEmbedder(time.Millisecond, &batchSizes, &mu), ingestTestDocuments(10), config)
+	if err != nil {
+		t.Fatalf("Ingestion failed: %v", err)
+	}
+	if result.Stored != 10 || result.Batches != 3 || len(writer.docs) != 10 {
+		t.Errorf("Expected 10 documents in 3 batches, got %+v", result)
+	}
+	for _, size := range batchSizes {
+		if size > 4 {
+			t.Errorf("Batch of %d documents exceeds the provider limit", size)
+		}
+	}
+	if atomic.LoadInt32(&progressCalls) != 3 {
+		t.Errorf("Expected progress after every batch, got %d calls", progressCalls)
+	}
+	if embedding := writer.docs["doc-004"].Embedding; embedding[0] != 5 {
+		t.Errorf("Unexpected embedding %v", embedding)
+	}
+}
+
+func TestIngestDocuments_CollectsErrors(t *testing.T) {
+	writer := &memoryDocumentWriter{}
+	var rateLimited int32
+
+	embed := func(ctx context.Context, texts []string) ([][]float64, error) {
+		if len(texts[0]) == 5 {
+			return nil, errors.New("model unavailable")
+		}
+		// The first call is rejected by the provider's rate limit
+		if atomic.CompareAndSwapInt32(&rateLimited, 0, 1) {
+			return nil, errors.New("status 429: rate limit exceeded")
+		}
+		return slowEmbedder(0, nil, nil)(ctx, texts)
+	}
+
+	config := DefaultIngestConfig()
+	config.BatchSize = 2
+	config.Concurrency = 2
+	config.RetryDelay = time.Millisecond
+
+	result, err := IngestDocuments(context.Background(), writer, embed, ingestTestDocuments(8), config)
+	if err == nil {
+		t.Fatal("Expected the failed batch to be reported")
+	}
+	if result.Stored != 6 || result.Failed != 2 || len(result.Errors) != 1 {
+		t.Errorf("Expected one failed batch and the rest stored, got %+v", result)
+	}
+	if ids := result.Errors[0].DocumentIDs; ids[0] != "doc-004" || ids[1] != "doc-005" {
+		t.Errorf("Unexpected failed documents %v", ids)
+	}
+	if !strings.Contains(err.Error(), "model unavailable") {
+		t.Errorf("Expected the batch error in the report, got %v", err)
+	}
+}
+
+func benchmarkIngestDocuments(b *testing.B, concurrency int) {
+	docs := ingestTestDocuments(200)
+	config := DefaultIngestConfig()
+	config.BatchSize = 10
+	config.Concurrency = concurrency
+	embed := slowEmbedder(2*time.Millisecond, nil, nil)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := IngestDocuments(context.Background(), &memoryDocumentWriter{}, embed, docs, config); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkIngestDocuments_Serial embeds one batch at a time
+func BenchmarkIngestDocuments_Serial(b *testing.B) {
+	benchmarkIngestDocuments(b, 1)
+}
+
+// BenchmarkIngestDocuments_Concurrent embeds eight batches at a time
+func BenchmarkIngestDocuments_Concurrent(b *testing.B) {
+	benchmarkIngestDocuments(b, 8)
+}
@@ -0,0 +1,309 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/metrics"
+)
+
+// tracerName names the tracer of the database spans
+const tracerName = "github.com/piotrlaczkowski/GoLangGraph/pkg/persistence"
+
+// QueryMetrics tracks the queries of a connection by operation and outcome, such as
+// "INSERT checkpoints" and "error"
+type QueryMetrics struct {
+	buckets []float64
+	series  map[querySeriesKey]*metrics.Histogram
+	mu      sync.Mutex
+}
+
+type querySeriesKey struct {
+	operation string
+	outcome   string
+}
+
+// NewQueryMetrics creates query metrics with latency buckets in seconds, the default latency
+// buckets when none are given
+func NewQueryMetrics(buckets ...float64) *QueryMetrics {
+	if len(buckets) == 0 {
+		buckets = metrics.DefaultLatencyBuckets
+	}
+	return &QueryMetrics{buckets: buckets, series: make(map[querySeriesKey]*metrics.Histogram)}
+}
+
+// Observe records a query. sql.ErrNoRows counts as a success, the query ran.
+func (m *QueryMetrics) Observe(operation string, duration time.Duration, err error) {
+	outcome := "success"
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		outcome = "error"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := querySeriesKey{operation: operation, outcome: outcome}
+	histogram, exists := m.series[key]
+	if !exists {
+		created := metrics.NewHistogram(m.buckets)
+		histogram = &created
+		m.series[key] = histogram
+	}
+	histogram.Observe(duration.Seconds())
+}
+
+// Latency returns the latency histogram of an operation across outcomes, in seconds
+func (m *QueryMetrics) Latency(operation string) metrics.Histogram {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	latency := metrics.NewHistogram(m.buckets)
+	for key, histogram := range m.series {
+		if key.operation != operation {
+			continue
+		}
+		latency.Merge(*histogram)
+	}
+	return latency
+}
+
+// writePrometheus writes the query counts and latencies labelled by database, operation and
+// outcome
+func (m *QueryMetrics) writePrometheus(out io.Writer, database string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]querySeriesKey, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].operation != keys[j].operation {
+			return keys[i].operation < keys[j].operation
+		}
+		return keys[i].outcome < keys[j].outcome
+	})
+
+	labels := func(key querySeriesKey) string {
+		return fmt.Sprintf(`database="%s",operation="%s",outcome="%s"`,
+			metrics.EscapeLabel(database), metrics.EscapeLabel(key.operation), metrics.EscapeLabel(key.outcome))
+	}
+	fmt.Fprint(out, "# HELP golanggraph_db_queries_total Database queries by operation and outcome.\n")
+	fmt.Fprint(out, "# TYPE golanggraph_db_queries_total counter\n")
+	for _, key := range keys {
+		fmt.Fprintf(out, "golanggraph_db_queries_total{%s} %d\n", labels(key), m.series[key].Count)
+	}
+	fmt.Fprint(out, "# HELP golanggraph_db_query_duration_seconds Database query latency in seconds.\n")
+	fmt.Fprint(out, "# TYPE golanggraph_db_query_duration_seconds histogram\n")
+	for _, key := range keys {
+		m.series[key].WritePrometheus(out, "golanggraph_db_query_duration_seconds", labels(key))
+	}
+}
+
+// SetTracerProvider sets the provider of the query spans, the global OpenTelemetry provider
+// by default. Spans are only started when the configuration enables TraceQueries.
+func (p *PostgresConnection) SetTracerProvider(provider trace.TracerProvider) {
+	p.traceMu.Lock()
+	defer p.traceMu.Unlock()
+	p.tracerProvider = provider
+}
+
+// tracer returns the tracer of the query spans
+func (p *PostgresConnection) tracer() trace.Tracer {
+	p.traceMu.RLock()
+	provider := p.tracerProvider
+	p.traceMu.RUnlock()
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(tracerName)
+}
+
+// Metrics returns the metrics of the connection's queries
+func (p *PostgresConnection) Metrics() *QueryMetrics {
+	return p.metrics
+}
+
+// PoolStats returns the statistics of the connection pool
+func (p *PostgresConnection) PoolStats() sql.DBStats {
+	if p.db == nil {
+		return sql.DBStats{}
+	}
+	return p.db.Stats()
+}
+
+// WritePrometheus writes the query metrics and the connection pool statistics in the
+// Prometheus text exposition format, labelled by database
+func (p *PostgresConnection) WritePrometheus(w io.Writer) error {
+	var out strings.Builder
+	database := p.config.Database
+	if p.metrics != nil {
+		p.metrics.writePrometheus(&out, database)
+	}
+
+	stats := p.PoolStats()
+	label := fmt.Sprintf(`database="%s"`, metrics.EscapeLabel(database))
+	gauges := []struct {
+		name, help, kind string
+		value            string
+	}{
+		{"golanggraph_db_connections_max_open", "Maximum number of open connections.", "gauge", strconv.Itoa(stats.MaxOpenConnections)},
+		{"golanggraph_db_connections_open", "Open connections, in use and idle.", "gauge", strconv.Itoa(stats.OpenConnections)},
+		{"golanggraph_db_connections_in_use", "Connections in use.", "gauge", strconv.Itoa(stats.InUse)},
+		{"golanggraph_db_connections_idle", "Idle connections.", "gauge", strconv.Itoa(stats.Idle)},
+		{"golanggraph_db_connection_waits_total", "Times a query waited for a connection.", "counter", strconv.FormatInt(stats.WaitCount, 10)},
+		{"golanggraph_db_connection_wait_seconds_total", "Time spent waiting for a connection in seconds.", "counter",
+			strconv.FormatFloat(stats.WaitDuration.Seconds(), 'g', -1, 64)},
+	}
+	for _, gauge := range gauges {
+		fmt.Fprintf(&out, "# HELP %s %s\n# TYPE %s %s\n%s{%s} %s\n", gauge.name, gauge.help, gauge.name, gauge.kind, gauge.name, label, gauge.value)
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// traceQuery instruments a query: it starts a span when TraceQueries is enabled and returns
+// the function ending it, which records the query in the metrics and logs it at debug level
+// with LogQueries, or at warn level when it was slower than SlowQueryThreshold
+func (p *PostgresConnection) traceQuery(ctx context.Context, query string, args []interface{}) (context.Context, func(error)) {
+	operation := queryOperation(query)
+	var span trace.Span
+	if p.config.TraceQueries {
+		ctx, span = p.tracer().Start(ctx, "db "+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", "postgresql"),
+				attribute.String("db.name", p.config.Database),
+				attribute.String("db.operation", operation),
+				attribute.String("db.statement", compactQuery(query)),
+			))
+	}
+
+	start := time.Now()
+	return ctx, func(err error) {
+		duration := time.Since(start)
+		if p.metrics != nil {
+			p.metrics.Observe(operation, duration, err)
+		}
+		failed := err != nil && !errors.Is(err, sql.ErrNoRows)
+		if span != nil {
+			if failed {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}
+
+		slow := p.slowQueryThreshold > 0 && duration >= p.slowQueryThreshold
+		if !slow && !p.config.LogQueries {
+			return
+		}
+		entry := logging.FromContext(ctx, p.logger).WithFields(logrus.Fields{
+			"operation":   operation,
+			"duration_ms": duration.Milliseconds(),
+			"args":        redactArgs(args),
+		})
+		if failed {
+			entry = entry.WithError(err)
+		}
+		if slow {
+			entry.WithField("query", compactQuery(query)).Warn("Slow database query")
+			return
+		}
+		entry.Debug("Database query")
+	}
+}
+
+// queryOperation names a query by its statement and table, such as "INSERT checkpoints";
+// leading comments are skipped
+func queryOperation(query string) string {
+	var words []string
+	for _, line := range strings.Split(query, "\n") {
+		if trimmed := strings.TrimSpace(line); !strings.HasPrefix(trimmed, "--") {
+			words = append(words, strings.Fields(trimmed)...)
+		}
+	}
+	if len(words) == 0 {
+		return "UNKNOWN"
+	}
+
+	statement := strings.ToUpper(words[0])
+	var tableAfter string
+	switch statement {
+	case "SELECT", "DELETE":
+		tableAfter = "FROM"
+	case "INSERT":
+		tableAfter = "INTO"
+	case "UPDATE":
+		if len(words) > 1 {
+			return statement + " " + tableName(words[1])
+		}
+	}
+	if tableAfter != "" {
+		for i, word := range words[:len(words)-1] {
+			if strings.EqualFold(word, tableAfter) {
+				return statement + " " + tableName(words[i+1])
+			}
+		}
+	}
+	return statement
+}
+
+// tableName strips the column list or punctuation following a table name
+func tableName(word string) string {
+	if end := strings.IndexAny(word, "(,;"); end >= 0 {
+		word = word[:end]
+	}
+	return word
+}
+
+// compactQuery collapses the whitespace of a query to single spaces
+func compactQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// redactArgs renders query arguments for logs: numbers, booleans, times and NULLs as they
+// are, and strings and bytes by their size only, since they may hold conversation content or
+// credentials
+func redactArgs(args []interface{}) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		switch value := arg.(type) {
+		case nil:
+			redacted[i] = "NULL"
+		case string:
+			redacted[i] = fmt.Sprintf("<string %d bytes>", len(value))
+		case []byte:
+			redacted[i] = fmt.Sprintf("<bytes %d bytes>", len(value))
+		case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			redacted[i] = fmt.Sprint(value)
+		case time.Time:
+			redacted[i] = value.Format(time.RFC3339)
+		default:
+			redacted[i] = fmt.Sprintf("<%T>", arg)
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// stubDriver is a database/sql driver whose statements take delay and fail when their query
+// contains "fail"
+type stubDriver struct {
+	delay time.Duration
+}
+
+func (d *stubDriver) Open(name string) (driver.Conn, error) { return &stubConn{driver: d}, nil }
+
+type stubConn struct{ driver *stubDriver }
+
+func (c *stubConn) Prepare(query string) (driver.Stmt, error) {
+	return &stubStmt{conn: c, query: query}, nil
+}
+func (c *stubConn) Close() error              { return nil }
+func (c *stubConn) Begin() (driver.Tx, error) { return nil, errors.New("transactions not supported") }
+
+type stubStmt struct {
+	conn  *stubConn
+	query string
+}
+
+func (s *stubStmt) Close() error  { return nil }
+func (s *stubStmt) NumInput() int { return -1 }
+func (s *stubStmt) run() error {
+	time.Sleep(s.conn.driver.delay)
+	if strings.Contains(s.query, "fail") {
+		return errors.New("relation does not exist")
+	}
+	return nil
+}
+func (s *stubStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), s.run()
+}
+func (s *stubStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if err := s.run(); err != nil {
+		return nil, err
+	}
+	return &stubRows{}, nil
+}
+
+type stubRows struct{}
+
+func (r *stubRows) Columns() []string              { return []string{"id"} }
+func (r *stubRows) Close() error                   { return nil }
+func (r *stubRows) Next(dest []driver.Value) error { return io.EOF }
+
+func newStubConnection(t *testing.T, config *DatabaseConfig, delay time.Duration) (*PostgresConnection, *bytes.Buffer) {
+	db := sql.OpenDB(driverConnector{driver: &stubDriver{delay: delay}})
+	t.Cleanup(func() { db.Close() })
+
+	var logs bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&logs)
+	logger.SetLevel(logrus.DebugLevel)
+	return &PostgresConnection{db: db, config: config, logger: logger, metrics: NewQueryMetrics()}, &logs
+}
+
+type driverConnector struct{ driver *stubDriver }
+
+func (c driverConnector) Connect(ctx context.Context) (driver.Conn, error) { return c.driver.Open("") }
+func (c driverConnector) Driver() driver.Driver                            { return c.driver }
+
+func TestPostgresConnection_TraceQueries(t *testing.T) {
+	conn, logs := newStubConnection(t, &DatabaseConfig{Database: "agents", LogQueries: true, TraceQueries: true}, 0)
+	recorder := tracetest.NewSpanRecorder()
+	conn.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx := context.Background()
+	require.NoError(t, conn.ExecuteQuery(ctx, "INSERT INTO checkpoints (id, data) VALUES ($1, $2)", "cp-1", "secret state"))
+	_, err := conn.QueryRows(ctx, "SELECT id FROM fail_table WHERE thread_id = $1", 7)
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "db INSERT checkpoints", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("db.statement", "INSERT INTO checkpoints (id, data) VALUES ($1, $2)"))
+	assert.Equal(t, "db SELECT fail_table", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+
+	// Queries are logged at debug level with their string arguments redacted
+	assert.Contains(t, logs.String(), "Database query")
+	assert.Contains(t, logs.String(), "<string 12 bytes>")
+	assert.NotContains(t, logs.String(), "secret state")
+
+	assert.Equal(t, uint64(1), conn.Metrics().Latency("INSERT checkpoints").Count)
+	var metrics strings.Builder
+	require.NoError(t, conn.WritePrometheus(&metrics))
+	assert.Contains(t, metrics.String(), `golanggraph_db_queries_total{database="agents",operation="INSERT checkpoints",outcome="success"} 1`)
+	assert.Contains(t, metrics.String(), `golanggraph_db_queries_total{database="agents",operation="SELECT fail_table",outcome="error"} 1`)
+	assert.Contains(t, metrics.String(), `golanggraph_db_connections_idle{database="agents"} 1`)
+	assert.Contains(t, metrics.String(), "# TYPE golanggraph_db_connection_waits_total counter")
+}
+
+func TestPostgresConnection_SlowQueries(t *testing.T) {
+	conn, logs := newStubConnection(t, &DatabaseConfig{Database: "agents"}, 20*time.Millisecond)
+	conn.slowQueryThreshold = 10 * time.Millisecond
+
+	// Without LogQueries only slow queries are logged, at warn level
+	require.NoError(t, conn.ExecuteQuery(context.Background(), "UPDATE threads SET updated_at = NOW() WHERE id = $1", "t-1"))
+	assert.Contains(t, logs.String(), "level=warning")
+	assert.Contains(t, logs.String(), "Slow database query")
+	assert.Contains(t, logs.String(), "operation=\"UPDATE threads\"")
+}
+
+func TestQueryOperation(t *testing.T) {
+	tests := map[string]string{
+		"SELECT checkpoint_data FROM checkpoints WHERE id = $1":        "SELECT checkpoints",
+		"insert into documents(id, content) values ($1, $2)":           "INSERT documents",
+		"\n\t-- Threads table\n\tCREATE TABLE IF NOT EXISTS threads (": "CREATE",
+		"DELETE FROM sessions WHERE id = $1":                           "DELETE sessions",
+		"":                                                             "UNKNOWN",
+	}
+	for query, expected := range tests {
+		assert.Equal(t, expected, queryOperation(query), query)
+	}
+}
@@ -0,0 +1,476 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Table names used by the re-embedding process
+const (
+	DocumentsTable       = "documents"
+	ReindexStagingTable  = "documents_reindex"
+	ReindexPreviousTable = "documents_previous"
+)
+
+const defaultReindexBatchSize = 100
+
+// EmbedFunc generates one embedding per input text using the new model
+type EmbedFunc func(ctx context.Context, texts []string) ([][]float64, error)
+
+// ReindexStore is implemented by document stores that support re-embedding
+type ReindexStore interface {
+	// CountDocuments returns the number of documents in a table
+	CountDocuments(ctx context.Context, table string) (int, error)
+
+	// LastDocumentID returns the highest document ID in a table, or "" if it is empty or missing
+	LastDocumentID(ctx context.Context, table string) (string, error)
+
+	// ListDocuments returns documents ordered by ID, starting after the given ID
+	ListDocuments(ctx context.Context, table, afterID string, limit int) ([]*Document, error)
+
+	// CreateDocumentTable creates a document table with the given embedding dimension
+	CreateDocumentTable(ctx context.Context, table string, dimension int) error
+
+	// DocumentTableDimension returns the embedding dimension of a table, or 0 if it is missing
+	DocumentTableDimension(ctx context.Context, table string) (int, error)
+
+	// InsertDocuments inserts documents and their embeddings into a table
+	InsertDocuments(ctx context.Context, table string, docs []*Document) error
+
+	// SwapDocumentTable atomically replaces the documents table with the given table
+	SwapDocumentTable(ctx context.Context, table string, model string, dimension int) error
+}
+
+// ReindexConfig configures a re-embedding run
+type ReindexConfig struct {
+	// Dimension of the new embeddings; detected from the first batch when zero
+	Dimension int `json:"dimension"`
+	// BatchSize is the number of documents embedded per call
+	BatchSize int `json:"batch_size"`
+	// Progress is called after each batch is stored
+	Progress func(progress ReindexProgress) `json:"-"`
+}
+
+// DefaultReindexConfig returns default re-embedding configuration
+func DefaultReindexConfig() *ReindexConfig {
+	return &ReindexConfig{
+		BatchSize: defaultReindexBatchSize,
+	}
+}
+
+// ReindexProgress reports the state of a running re-embedding
+type ReindexProgress struct {
+	Processed int    `json:"processed"`
+	Total     int    `json:"total"`
+	LastID    string `json:"last_id"`
+}
+
+// ReindexResult summarizes a completed re-embedding
+type ReindexResult struct {
+	Model     string        `json:"model"`
+	Dimension int           `json:"dimension"`
+	Total     int           `json:"total"`
+	Embedded  int           `json:"embedded"`
+	Resumed   int           `json:"resumed"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// ReindexWithModel re-embeds every stored document with a new model into a staging
+// table, verifies the document counts and then swaps it in place of the documents table.
+// Interrupted runs resume from the last document written to the staging table.
+func ReindexWithModel(ctx context.Context, store ReindexStore, embed EmbedFunc, newModel string, config *ReindexConfig) (*ReindexResult, error) {
+	if config == nil {
+		config = DefaultReindexConfig()
+	}
+	if newModel == "" {
+		return nil, fmt.Errorf("embedding model cannot be empty")
+	}
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultReindexBatchSize
+	}
+
+	startTime := time.Now()
+	result := &ReindexResult{Model: newModel, Dimension: config.Dimension}
+
+	total, err := store.CountDocuments(ctx, DocumentsTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count documents: %w", err)
+	}
+	result.Total = total
+
+	// Resume after the last document already written to the staging table
+	lastID, err := store.LastDocumentID(ctx, ReindexStagingTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect staging table: %w", err)
+	}
+	tableReady := false
+	if lastID != "" {
+		if result.Resumed, err = store.CountDocuments(ctx, ReindexStagingTable); err != nil {
+			return nil, fmt.Errorf("failed to count staged documents: %w", err)
+		}
+
+		// The staging table was created with the dimension of the interrupted run
+		staged, err := store.DocumentTableDimension(ctx, ReindexStagingTable)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read staging table dimension: %w", err)
+		}
+		if staged > 0 {
+			if result.Dimension != 0 && result.Dimension != staged {
+				return nil, fmt.Errorf("staging table has embedding dimension %d, expected %d", staged, result.Dimension)
+			}
+			result.Dimension = staged
+			tableReady = true
+		}
+	}
+	processed := result.Resumed
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("reindex interrupted after %d documents: %w", processed, err)
+		}
+
+		docs, err := store.ListDocuments(ctx, DocumentsTable, lastID, batchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list documents: %w", err)
+		}
+		if len(docs) == 0 {
+			break
+		}
+
+		texts := make([]string, len(docs))
+		for i, doc := range docs {
+			texts[i] = doc.Content
+		}
+
+		embeddings, err := embed(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed documents after %s: %w", docs[0].ID, err)
+		}
+		if len(embeddings) != len(docs) {
+			return nil, fmt.Errorf("embedding returned %d vectors for %d documents", len(embeddings), len(docs))
+		}
+
+		for i, doc := range docs {
+			if result.Dimension == 0 {
+				result.Dimension = len(embeddings[i])
+			}
+			if len(embeddings[i]) != result.Dimension {
+				return nil, fmt.Errorf("document %s has embedding dimension %d, expected %d", doc.ID, len(embeddings[i]), result.Dimension)
+			}
+			doc.Embedding = embeddings[i]
+		}
+
+		// Create the staging table lazily so the dimension can be detected
+		if !tableReady {
+			if err := store.CreateDocumentTable(ctx, ReindexStagingTable, result.Dimension); err != nil {
+				return nil, fmt.Errorf("failed to create staging table: %w", err)
+			}
+			tableReady = true
+		}
+
+		if err := store.InsertDocuments(ctx, ReindexStagingTable, docs); err != nil {
+			return nil, fmt.Errorf("failed to store re-embedded documents: %w", err)
+		}
+
+		lastID = docs[len(docs)-1].ID
+		processed += len(docs)
+		result.Embedded += len(docs)
+
+		if config.Progress != nil {
+			config.Progress(ReindexProgress{Processed: processed, Total: total, LastID: lastID})
+		}
+	}
+
+	if result.Dimension == 0 {
+		return nil, fmt.Errorf("embedding dimension unknown: no documents were embedded, set ReindexConfig.Dimension")
+	}
+	if !tableReady {
+		if err := store.CreateDocumentTable(ctx, ReindexStagingTable, result.Dimension); err != nil {
+			return nil, fmt.Errorf("failed to create staging table: %w", err)
+		}
+	}
+
+	// Verify that every document made it into the staging table before swapping
+	staged, err := store.CountDocuments(ctx, ReindexStagingTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count staged documents: %w", err)
+	}
+	current, err := store.CountDocuments(ctx, DocumentsTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count documents: %w", err)
+	}
+	if staged != current {
+		return nil, fmt.Errorf("document count mismatch: %d documents, %d re-embedded", current, staged)
+	}
+
+	if err := store.SwapDocumentTable(ctx, ReindexStagingTable, newModel, result.Dimension); err != nil {
+		return nil, fmt.Errorf("failed to swap document tables: %w", err)
+	}
+
+	result.Duration = time.Since(startTime)
+	return result, nil
+}
+
+var tableNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// validateTableName guards table names that are interpolated into SQL
+func validateTableName(table string) error {
+	if !tableNamePattern.MatchString(table) {
+		return fmt.Errorf("invalid table name: %s", table)
+	}
+	return nil
+}
+
+// formatVector formats an embedding as a pgvector literal
+func formatVector(embedding []float64) string {
+	values := make([]string, len(embedding))
+	for i, value := range embedding {
+		values[i] = strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return "[" + strings.Join(values, ",") + "]"
+}
+
+// CountDocuments returns the number of documents in a table
+func (p *PostgresCheckpointer) CountDocuments(ctx context.Context, table string) (int, error) {
+	if err := validateTableName(table); err != nil {
+		return 0, err
+	}
+
+	var count int
+	row := p.conn.QueryRow(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", table)).(*sql.Row)
+	if err := row.Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count %s: %w", table, err)
+	}
+	return count, nil
+}
+
+// LastDocumentID returns the highest document ID in a table, or "" if it is empty or missing
+func (p *PostgresCheckpointer) LastDocumentID(ctx context.Context, table string) (string, error) {
+	if err := validateTableName(table); err != nil {
+		return "", err
+	}
+
+	var exists bool
+	row := p.conn.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", table).(*sql.Row)
+	if err := row.Scan(&exists); err != nil {
+		return "", fmt.Errorf("failed to check table %s: %w", table, err)
+	}
+	if !exists {
+		return "", nil
+	}
+
+	var lastID sql.NullString
+	row = p.conn.QueryRow(ctx, fmt.Sprintf("SELECT MAX(id) FROM %s", table)).(*sql.Row)
+	if err := row.Scan(&lastID); err != nil {
+		return "", fmt.Errorf("failed to read last document ID from %s: %w", table, err)
+	}
+	return lastID.String, nil
+}
+
+// ListDocuments returns documents ordered by ID, starting after the given ID
+func (p *PostgresCheckpointer) ListDocuments(ctx context.Context, table, afterID string, limit int) ([]*Document, error) {
+	if err := validateTableName(table); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, thread_id, content, metadata, created_at, updated_at
+		FROM %s
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+	`, table)
+
+	rows, err := p.conn.QueryRows(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	defer rows.(*sql.Rows).Close()
+
+	var documents []*Document
+	for rows.(*sql.Rows).Next() {
+		var doc Document
+		var threadID sql.NullString
+		var metadataData []byte
+
+		if err := rows.(*sql.Rows).Scan(&doc.ID, &threadID, &doc.Content, &metadataData, &doc.CreatedAt, &doc.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		doc.ThreadID = threadID.String
+
+		if len(metadataData) > 0 {
+			if err := json.Unmarshal(metadataData, &doc.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+			}
+		}
+
+		documents = append(documents, &doc)
+	}
+
+	return documents, rows.(*sql.Rows).Err()
+}
+
+// CreateDocumentTable creates a document table with the given embedding dimension
+func (p *PostgresCheckpointer) CreateDocumentTable(ctx context.Context, table string, dimension int) error {
+	if err := validateTableName(table); err != nil {
+		return err
+	}
+	if dimension <= 0 {
+		return fmt.Errorf("invalid embedding dimension: %d", dimension)
+	}
+
+	schema := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %[1]s (
+			id VARCHAR(255) PRIMARY KEY,
+			thread_id VARCHAR(255),
+			content TEXT NOT NULL,
+			metadata JSONB,
+			embedding vector(%[2]d),
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			FOREIGN KEY (thread_id) REFERENCES threads(id) ON DELETE CASCADE
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_%[1]s_embedding ON %[1]s USING ivfflat (embedding %[3]s);
+		CREATE INDEX IF NOT EXISTS idx_%[1]s_thread_id ON %[1]s(thread_id);
+	`, table, dimension, vectorOperatorClass(p.config.VectorMetric))
+
+	if err := p.conn.ExecuteQuery(ctx, schema); err != nil {
+		return fmt.Errorf("failed to create table %s: %w", table, err)
+	}
+	return nil
+}
+
+// DocumentTableDimension returns the embedding dimension of a table, or 0 if it is missing
+func (p *PostgresCheckpointer) DocumentTableDimension(ctx context.Context, table string) (int, error) {
+	if err := validateTableName(table); err != nil {
+		return 0, err
+	}
+
+	// pgvector stores the dimension of a vector column as its type modifier
+	var dimension sql.NullInt64
+	row := p.conn.QueryRow(ctx, `
+		SELECT a.atttypmod
+		FROM pg_attribute a
+		WHERE a.attrelid = to_regclass($1) AND a.attname = 'embedding' AND NOT a.attisdropped
+	`, table).(*sql.Row)
+	if err := row.Scan(&dimension); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read embedding dimension of %s: %w", table, err)
+	}
+	if dimension.Int64 < 0 {
+		return 0, nil
+	}
+	return int(dimension.Int64), nil
+}
+
+// InsertDocuments inserts documents and their embeddings into a table
+func (p *PostgresCheckpointer) InsertDocuments(ctx context.Context, table string, docs []*Document) error {
+	if err := validateTableName(table); err != nil {
+		return err
+	}
+
+	tx, err := p.conn.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (id, thread_id, content, metadata, embedding, created_at, updated_at)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5::vector, $6, $7)
+		ON CONFLICT (id) DO UPDATE SET
+			content = EXCLUDED.content,
+			metadata = EXCLUDED.metadata,
+			embedding = EXCLUDED.embedding,
+			updated_at = EXCLUDED.updated_at
+	`, table)
+
+	for _, doc := range docs {
+		metadataData, err := json.Marshal(doc.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to marshal metadata for %s: %w", doc.ID, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, query, doc.ID, doc.ThreadID, doc.Content, metadataData,
+			formatVector(doc.Embedding), doc.CreatedAt, doc.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to insert document %s: %w", doc.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SwapDocumentTable atomically replaces the documents table with the given table.
+// The replaced table is kept as documents_previous until the next swap.
+func (p *PostgresCheckpointer) SwapDocumentTable(ctx context.Context, table string, model string, dimension int) error {
+	if err := validateTableName(table); err != nil {
+		return err
+	}
+
+	tx, err := p.conn.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := copyExtraDocumentColumns(ctx, tx, table); err != nil {
+		return err
+	}
+
+	statements := []string{
+		fmt.Sprintf("DROP TABLE IF EXISTS %s", ReindexPreviousTable),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", DocumentsTable, ReindexPreviousTable),
+		fmt.Sprintf("ALTER INDEX IF EXISTS idx_%s_embedding RENAME TO idx_%s_embedding", DocumentsTable, ReindexPreviousTable),
+		fmt.Sprintf("ALTER INDEX IF EXISTS idx_%s_thread_id RENAME TO idx_%s_thread_id", DocumentsTable, ReindexPreviousTable),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", table, DocumentsTable),
+		fmt.Sprintf("ALTER INDEX IF EXISTS idx_%s_embedding RENAME TO idx_%s_embedding", table, DocumentsTable),
+		fmt.Sprintf("ALTER INDEX IF EXISTS idx_%s_thread_id RENAME TO idx_%s_thread_id", table, DocumentsTable),
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to execute %q: %w", statement, err)
+		}
+	}
+
+	update := fmt.Sprintf("UPDATE %s SET embedding_model = $1, embedding_dimension = $2, updated_at = NOW() WHERE name = $3", VectorMetadataTable)
+	if _, err := tx.ExecContext(ctx, update, model, dimension, DocumentsTable); err != nil {
+		return fmt.Errorf("failed to update vector metadata: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit table swap: %w", err)
+	}
+
+	p.metaMu.Lock()
+	p.vectorMeta = nil
+	p.metaMu.Unlock()
+
+	p.config.EmbeddingModel = model
+	p.config.EmbeddingDimension = dimension
+	p.config.VectorDimension = dimension
+
+	p.logger.WithFields(logrus.Fields{
+		"model":     model,
+		"dimension": dimension,
+	}).Info("Document table swapped")
+
+	return nil
+}
@@ -0,0 +1,251 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// memoryReindexStore is an in-memory ReindexStore for testing
+type memoryReindexStore struct {
+	tables     map[string]map[string]*Document
+	dimensions map[string]int
+	model      string
+	failAfter  int
+	inserted   int
+}
+
+func newMemoryReindexStore(count int) *memoryReindexStore {
+	store := &memoryReindexStore{
+		tables:     map[string]map[string]*Document{DocumentsTable: {}},
+		dimensions: map[string]int{DocumentsTable: 2},
+	}
+	for i := 0; i < count; i++ {
+		id := fmt.Sprintf("doc-%02d", i)
+		store.tables[DocumentsTable][id] = &Document{ID: id, Content: strings.Repeat("x", i+1)}
+	}
+	return store
+}
+
+func (s *memoryReindexStore) CountDocuments(ctx context.Context, table string) (int, error) {
+	docs, exists := s.tables[table]
+	if !exists {
+		return 0, fmt.Errorf("table %s does not exist", table)
+	}
+	return len(docs), nil
+}
+
+func (s *memoryReindexStore) LastDocumentID(ctx context.Context, table string) (string, error) {
+	lastID := ""
+	for id := range s.tables[table] {
+		if id > lastID {
+			lastID = id
+		}
+	}
+	return lastID, nil
+}
+
+func (s *memoryReindexStore) ListDocuments(ctx context.Context, table, afterID string, limit int) ([]*Document, error) {
+	var ids []string
+	for id := range s.tables[table] {
+		if id > afterID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	docs := make([]*Document, len(ids))
+	for i, id := range ids {
+		copied := *s.tables[table][id]
+		docs[i] = &copied
+	}
+	return docs, nil
+}
+
+func (s *memoryReindexStore) CreateDocumentTable(ctx context.Context, table string, dimension int) error {
+	if _, exists := s.tables[table]; !exists {
+		s.tables[table] = map[string]*Document{}
+		s.dimensions[table] = dimension
+	}
+	return nil
+}
+
+func (s *memoryReindexStore) DocumentTableDimension(ctx context.Context, table string) (int, error) {
+	if _, exists := s.tables[table]; !exists {
+		return 0, nil
+	}
+	return s.dimensions[table], nil
+}
+
+func (s *memoryReindexStore) InsertDocuments(ctx context.Context, table string, docs []*Document) error {
+	// Batches are inserted atomically
+	if s.failAfter > 0 && s.inserted+len(docs) > s.failAfter {
+		return fmt.Errorf("connection lost")
+	}
+	for _, doc := range docs {
+		if len(doc.Embedding) != s.dimensions[table] {
+			return fmt.Errorf("expected %d dimensions, got %d", s.dimensions[table], len(doc.Embedding))
+		}
+		s.tables[table][doc.ID] = doc
+		s.inserted++
+	}
+	return nil
+}
+
+func (s *memoryReindexStore) SwapDocumentTable(ctx context.Context, table string, model string, dimension int) error {
+	s.tables[ReindexPreviousTable] = s.tables[DocumentsTable]
+	s.tables[DocumentsTable] = s.tables[table]
+	s.dimensions[DocumentsTable] = dimension
+	delete(s.tables, table)
+	s.model = model
+	return nil
+}
+
+// lengthEmbedder produces three-dimensional embeddings derived from the text length
+func lengthEmbedder(calls *int) EmbedFunc {
+	return func(ctx context.Context, texts []string) ([][]float64, error) {
+		*calls++
+		embeddings := make([][]float64, len(texts))
+		for i, text := range texts {
+			embeddings[i] = []float64{float64(len(text)), 1, 0}
+		}
+		return embeddings, nil
+	}
+}
+
+func TestReindexWithModel(t *testing.T) {
+	store := newMemoryReindexStore(5)
+	var calls int
+	var progress []ReindexProgress
+
+	result, err := ReindexWithModel(context.Background(), store, lengthEmbedder(&calls), "new-model", &ReindexConfig{
+		BatchSize: 2,
+		Progress:  func(p ReindexProgress) { progress = append(progress, p) },
+	})
+	if err != nil {
+		t.Fatalf("ReindexWithModel() failed: %v", err)
+	}
+
+	if result.Total != 5 || result.Embedded != 5 || result.Dimension != 3 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 embedding batches, got %d", calls)
+	}
+	if len(progress) != 3 || progress[2].Processed != 5 || progress[2].Total != 5 {
+		t.Errorf("Unexpected progress reports: %+v", progress)
+	}
+
+	// The dimension change is applied through the swapped table
+	if store.model != "new-model" || store.dimensions[DocumentsTable] != 3 {
+		t.Errorf("Documents table was not swapped: model=%s dimension=%d", store.model, store.dimensions[DocumentsTable])
+	}
+	if len(store.tables[DocumentsTable]["doc-03"].Embedding) != 3 {
+		t.Error("Swapped documents should carry the new embeddings")
+	}
+	if len(store.tables[ReindexPreviousTable]) != 5 {
+		t.Error("Previous documents table should be kept")
+	}
+}
+
+func TestReindexWithModel_Resume(t *testing.T) {
+	store := newMemoryReindexStore(5)
+	store.failAfter = 3
+	var calls int
+
+	_, err := ReindexWithModel(context.Background(), store, lengthEmbedder(&calls), "new-model", &ReindexConfig{BatchSize: 2})
+	if err == nil {
+		t.Fatal("Expected interrupted reindex to fail")
+	}
+	if _, exists := store.tables[ReindexPreviousTable]; exists {
+		t.Fatal("Tables must not be swapped after a failure")
+	}
+
+	store.failAfter = 0
+	result, err := ReindexWithModel(context.Background(), store, lengthEmbedder(&calls), "new-model", &ReindexConfig{BatchSize: 2})
+	if err != nil {
+		t.Fatalf("Resumed ReindexWithModel() failed: %v", err)
+	}
+
+	if result.Resumed != 2 || result.Embedded != 3 {
+		t.Errorf("Expected 2 resumed and 3 embedded documents, got %+v", result)
+	}
+	if len(store.tables[DocumentsTable]) != 5 {
+		t.Errorf("Expected 5 documents after swap, got %d", len(store.tables[DocumentsTable]))
+	}
+}
+
+func TestReindexWithModel_ResumeFullyStaged(t *testing.T) {
+	store := newMemoryReindexStore(4)
+	var calls int
+
+	// Every document is staged but the run stops before the swap
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := ReindexWithModel(ctx, store, func(ctx context.Context, texts []string) ([][]float64, error) {
+		embeddings, err := lengthEmbedder(&calls)(ctx, texts)
+		if calls == 2 {
+			cancel()
+		}
+		return embeddings, err
+	}, "new-model", &ReindexConfig{BatchSize: 2})
+	if err == nil {
+		t.Fatal("Expected interrupted reindex to fail")
+	}
+
+	// Nothing is left to embed, so the dimension comes from the staging table
+	result, err := ReindexWithModel(context.Background(), store, lengthEmbedder(&calls), "new-model", &ReindexConfig{BatchSize: 2})
+	if err != nil {
+		t.Fatalf("Resumed ReindexWithModel() failed: %v", err)
+	}
+	if result.Resumed != 4 || result.Embedded != 0 || result.Dimension != 3 {
+		t.Errorf("Expected 4 resumed documents of dimension 3, got %+v", result)
+	}
+	if store.dimensions[DocumentsTable] != 3 {
+		t.Errorf("Expected the swapped table to have dimension 3, got %d", store.dimensions[DocumentsTable])
+	}
+
+	store = newMemoryReindexStore(2)
+	store.tables[ReindexStagingTable] = map[string]*Document{"doc-00": {ID: "doc-00"}}
+	store.dimensions[ReindexStagingTable] = 3
+	if _, err := ReindexWithModel(context.Background(), store, lengthEmbedder(&calls), "new-model", &ReindexConfig{Dimension: 4}); err == nil {
+		t.Error("Expected error when the staging table has another dimension")
+	}
+}
+
+func TestReindexWithModel_Errors(t *testing.T) {
+	var calls int
+	if _, err := ReindexWithModel(context.Background(), newMemoryReindexStore(1), lengthEmbedder(&calls), "", nil); err == nil {
+		t.Error("Expected error for empty model")
+	}
+
+	short := func(ctx context.Context, texts []string) ([][]float64, error) {
+		return [][]float64{{1}}, nil
+	}
+	if _, err := ReindexWithModel(context.Background(), newMemoryReindexStore(3), short, "new-model", nil); err == nil {
+		t.Error("Expected error when the embedder returns too few vectors")
+	}
+
+	if _, err := ReindexWithModel(context.Background(), newMemoryReindexStore(0), lengthEmbedder(&calls), "new-model", nil); err == nil {
+		t.Error("Expected error when the dimension cannot be detected")
+	}
+}
+
+func TestFormatVector(t *testing.T) {
+	if got := formatVector([]float64{0.5, -1, 2}); got != "[0.5,-1,2]" {
+		t.Errorf("Unexpected vector literal: %s", got)
+	}
+	if err := validateTableName("documents; DROP TABLE threads"); err == nil {
+		t.Error("Expected invalid table name to be rejected")
+	}
+}
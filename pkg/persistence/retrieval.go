@@ -0,0 +1,146 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/rag"
+)
+
+// NoRelevantContext is given to the model instead of retrieved documents when none of
+// them passes the similarity threshold
+const NoRelevantContext = "No relevant context found."
+
+// vectorDistanceOperator returns the pgvector distance operator for a vector metric
+func vectorDistanceOperator(metric string) string {
+	switch metric {
+	case "euclidean":
+		return "<->"
+	case "dot_product":
+		return "<#>"
+	default:
+		return "<=>"
+	}
+}
+
+// vectorOperatorClass returns the pgvector index operator class matching the distance
+// operator of a vector metric, so searches can use the index
+func vectorOperatorClass(metric string) string {
+	switch metric {
+	case "euclidean":
+		return "vector_l2_ops"
+	case "dot_product":
+		return "vector_ip_ops"
+	default:
+		return "vector_cosine_ops"
+	}
+}
+
+// SimilarityFromDistance converts a pgvector distance to a similarity score, where a
+// higher score means a closer match. Cosine similarity is 1 minus the cosine distance,
+// dot product similarity is the inner product and euclidean similarity is 1/(1+distance).
+func SimilarityFromDistance(metric string, distance float64) float64 {
+	switch metric {
+	case "euclidean":
+		return 1 / (1 + distance)
+	case "dot_product":
+		// pgvector's <#> operator returns the negative inner product
+		return -distance
+	default:
+		return 1 - distance
+	}
+}
+
+// FilterBySimilarity drops the documents scoring below the threshold, keeping the order of
+// the others. A threshold of zero or less keeps every document.
+func FilterBySimilarity(documents []*Document, threshold float64) []*Document {
+	if threshold <= 0 {
+		return documents
+	}
+
+	relevant := make([]*Document, 0, len(documents))
+	for _, doc := range documents {
+		if doc.Score >= threshold {
+			relevant = append(relevant, doc)
+		}
+	}
+	return relevant
+}
+
+// FormatRetrievedContext renders retrieved documents as context for a model prompt, numbered
+// [1], [2]... so answers can cite them. When no document was retrieved it returns
+// NoRelevantContext, so the model is told nothing relevant was found rather than given an
+// empty or unrelated context.
+func FormatRetrievedContext(documents []*Document) string {
+	if len(documents) == 0 {
+		return NoRelevantContext
+	}
+
+	var context strings.Builder
+	for i, doc := range documents {
+		if i > 0 {
+			context.WriteString("\n\n")
+		}
+		fmt.Fprintf(&context, "[%d]", i+1)
+		if title := DocumentTitle(doc); title != "" {
+			fmt.Fprintf(&context, " %s", title)
+		}
+		fmt.Fprintf(&context, " (relevance %.2f):\n%s", doc.Score, doc.Content)
+	}
+	return context.String()
+}
+
+// ThreadVectorStore searches the documents of a thread, implementing rag.VectorStore
+type ThreadVectorStore struct {
+	checkpointer *PostgresCheckpointer
+	threadID     string
+	model        string
+}
+
+// VectorStore returns a vector store over the documents of a thread, for the vector search
+// tool. Query embeddings are checked against the stored vectors as for SearchDocumentsWithModel.
+func (p *PostgresCheckpointer) VectorStore(threadID, model string) *ThreadVectorStore {
+	return &ThreadVectorStore{checkpointer: p, threadID: threadID, model: model}
+}
+
+// Retriever returns a retriever over the documents of a thread, embedding queries with the
+// provider and model
+func (p *PostgresCheckpointer) Retriever(threadID, model string, embedder llm.Provider) (*rag.VectorRetriever, error) {
+	return rag.NewVectorRetriever(p.VectorStore(threadID, model), embedder, model)
+}
+
+// SimilaritySearch returns the documents closest to the embedding whose metadata contains the filter
+func (s *ThreadVectorStore) SimilaritySearch(ctx context.Context, embedding []float64, topK int, filter map[string]interface{}) ([]rag.RetrievedChunk, error) {
+	if err := s.checkpointer.checkQueryEmbedding(ctx, s.model, len(embedding)); err != nil {
+		return nil, err
+	}
+
+	documents, err := s.checkpointer.searchDocuments(ctx, s.threadID, embedding, topK, filter)
+	if err != nil {
+		return nil, err
+	}
+	return RetrievedChunks(documents), nil
+}
+
+// RetrievedChunks converts documents to the chunks returned by retrievers
+func RetrievedChunks(documents []*Document) []rag.RetrievedChunk {
+	chunks := make([]rag.RetrievedChunk, len(documents))
+	for i, doc := range documents {
+		chunks[i] = rag.RetrievedChunk{
+			ID:       doc.ID,
+			Content:  doc.Content,
+			Score:    doc.Score,
+			Title:    DocumentTitle(doc),
+			Metadata: doc.Metadata,
+		}
+	}
+	return chunks
+}
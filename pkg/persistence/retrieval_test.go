@@ -0,0 +1,75 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSimilarityFromDistance(t *testing.T) {
+	tests := []struct {
+		metric   string
+		distance float64
+		expected float64
+	}{
+		{"cosine", 0.25, 0.75},
+		{"", 0.25, 0.75},
+		{"euclidean", 1, 0.5},
+		{"dot_product", -0.8, 0.8},
+	}
+	for _, test := range tests {
+		if got := SimilarityFromDistance(test.metric, test.distance); got != test.expected {
+			t.Errorf("SimilarityFromDistance(%q, %v) = %v, expected %v", test.metric, test.distance, got, test.expected)
+		}
+	}
+}
+
+func TestVectorOperatorClass(t *testing.T) {
+	// Each metric is indexed with the operator class of its distance operator
+	expected := map[string]string{
+		"cosine":      "vector_cosine_ops",
+		"":            "vector_cosine_ops",
+		"euclidean":   "vector_l2_ops",
+		"dot_product": "vector_ip_ops",
+	}
+	for metric, class := range expected {
+		if got := vectorOperatorClass(metric); got != class {
+			t.Errorf("vectorOperatorClass(%q) = %s, expected %s", metric, got, class)
+		}
+	}
+}
+
+func TestFilterBySimilarity(t *testing.T) {
+	documents := []*Document{
+		{ID: "a", Score: 0.9},
+		{ID: "b", Score: 0.7},
+		{ID: "c", Score: 0.4},
+	}
+
+	relevant := FilterBySimilarity(documents, 0.7)
+	if len(relevant) != 2 || relevant[0].ID != "a" || relevant[1].ID != "b" {
+		t.Errorf("Expected documents a and b, got %v", relevant)
+	}
+	if len(FilterBySimilarity(documents, 0.95)) != 0 {
+		t.Error("Expected no document above a 0.95 threshold")
+	}
+	if len(FilterBySimilarity(documents, 0)) != len(documents) {
+		t.Error("A zero threshold should keep every document")
+	}
+}
+
+func TestFormatRetrievedContext(t *testing.T) {
+	if got := FormatRetrievedContext(nil); got != NoRelevantContext {
+		t.Errorf("Expected %q without documents, got %q", NoRelevantContext, got)
+	}
+
+	context := FormatRetrievedContext([]*Document{{Content: "Go was released in 2009", Score: 0.82}})
+	if !strings.Contains(context, "Go was released in 2009") || !strings.Contains(context, "0.82") {
+		t.Errorf("Expected the document content and score, got %q", context)
+	}
+}
@@ -0,0 +1,343 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// Types of the stored messages of a thread
+const (
+	// MessageTypeMessage is a message of the conversation
+	MessageTypeMessage = "message"
+	// MessageTypeSummary is a rolling summary of the conversation up to the message before it
+	MessageTypeSummary = "summary"
+)
+
+// summaryContextPrefix introduces the summary at the start of a context
+const summaryContextPrefix = "Summary of the earlier conversation:\n"
+
+// summaryPrompt asks the model to extend the running summary with the new messages
+const summaryPrompt = "You maintain a running summary of a conversation. Update the summary with the new messages. " +
+	"Keep the facts, decisions, user preferences and open questions; drop small talk. Respond with the summary only."
+
+// StoredMessage is a message or summary stored for a thread
+type StoredMessage struct {
+	ID        int64       `json:"id"`
+	ThreadID  string      `json:"thread_id"`
+	Type      string      `json:"type"`
+	Message   llm.Message `json:"message"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// ConversationSummarizer folds new messages into the previous summary of a conversation,
+// which is empty for the first summary
+type ConversationSummarizer func(ctx context.Context, previousSummary string, messages []llm.Message) (string, error)
+
+// rowIterator is the part of *sql.Rows read by the session manager
+type rowIterator interface {
+	Next() bool
+	Scan(dest ...interface{}) error
+	Err() error
+	Close() error
+}
+
+// NewLLMConversationSummarizer returns a summarizer asking a model to update the summary
+func NewLLMConversationSummarizer(manager *llm.ProviderManager, provider, model string) ConversationSummarizer {
+	return func(ctx context.Context, previousSummary string, messages []llm.Message) (string, error) {
+		var transcript strings.Builder
+		if previousSummary != "" {
+			fmt.Fprintf(&transcript, "Current summary:\n%s\n\nNew messages:\n", previousSummary)
+		}
+		for _, message := range messages {
+			fmt.Fprintf(&transcript, "%s: %s\n", message.Role, message.Content)
+		}
+
+		resp, err := manager.Complete(ctx, provider, llm.CompletionRequest{
+			Model: model,
+			Messages: []llm.Message{
+				{Role: "system", Content: summaryPrompt},
+				{Role: "user", Content: transcript.String()},
+			},
+			Temperature: 0.2,
+		})
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Choices) == 0 {
+			return "", fmt.Errorf("no response from LLM")
+		}
+		return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+	}
+}
+
+// SetSummarizer makes AppendMessage summarize a thread every n messages with the
+// summarizer, so LoadContext returns the latest summary and the messages after it instead
+// of the full history. A nil summarizer or n <= 0 disables summaries.
+func (sm *SessionManager) SetSummarizer(n int, summarizer ConversationSummarizer) {
+	sm.summaryEvery = n
+	sm.summarizer = summarizer
+}
+
+// AppendMessage stores a message of a thread and, when the thread has grown by the
+// configured number of messages since its last summary, stores a new summary. A failed
+// summary is logged and retried with the next message.
+func (sm *SessionManager) AppendMessage(ctx context.Context, threadID string, message llm.Message) error {
+	if sm.conn == nil {
+		return fmt.Errorf("session store not configured")
+	}
+	if err := sm.insertMessage(ctx, threadID, MessageTypeMessage, message); err != nil {
+		return err
+	}
+
+	if sm.summarizer == nil || sm.summaryEvery <= 0 {
+		return nil
+	}
+	summary, messages, err := sm.loadSinceSummary(ctx, threadID)
+	if err != nil {
+		sm.logger.WithError(err).Warn("Failed to load messages to summarize")
+		return nil
+	}
+	if len(messages) >= sm.summaryEvery {
+		if _, err := sm.summarize(ctx, threadID, summary, messages); err != nil {
+			sm.logger.WithError(err).WithField("thread_id", threadID).Warn("Conversation summary failed")
+		}
+	}
+	return nil
+}
+
+// SummarizeThread summarizes the messages stored since the last summary of a thread, and
+// returns the new summary
+func (sm *SessionManager) SummarizeThread(ctx context.Context, threadID string) (string, error) {
+	if sm.conn == nil {
+		return "", fmt.Errorf("session store not configured")
+	}
+	if sm.summarizer == nil {
+		return "", fmt.Errorf("no conversation summarizer configured")
+	}
+	summary, messages, err := sm.loadSinceSummary(ctx, threadID)
+	if err != nil {
+		return "", err
+	}
+	if len(messages) == 0 {
+		return summaryText(summary), nil
+	}
+	return sm.summarize(ctx, threadID, summary, messages)
+}
+
+// LoadContext returns the messages to build a prompt from: the latest summary of the
+// thread as a system message, followed by the messages stored after it
+func (sm *SessionManager) LoadContext(ctx context.Context, threadID string) ([]llm.Message, error) {
+	if sm.conn == nil {
+		return nil, fmt.Errorf("session store not configured")
+	}
+	summary, messages, err := sm.loadSinceSummary(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+
+	assembled := make([]llm.Message, 0, len(messages)+1)
+	if summary != nil {
+		assembled = append(assembled, SummaryMessage(summaryText(summary)))
+	}
+	for _, stored := range messages {
+		assembled = append(assembled, stored.Message)
+	}
+	return assembled, nil
+}
+
+// PinMessage pins or unpins the stored message at index among the messages of a thread, in
+// the order they were appended, so LoadContext returns it pinned
+func (sm *SessionManager) PinMessage(ctx context.Context, threadID string, index int, pinned bool) error {
+	if sm.conn == nil {
+		return fmt.Errorf("session store not configured")
+	}
+	if index < 0 {
+		return fmt.Errorf("message index %d out of range", index)
+	}
+
+	query := `
+		UPDATE thread_messages SET pinned = $3
+		WHERE id = (
+			SELECT id FROM thread_messages
+			WHERE thread_id = $1 AND message_type = $4
+			ORDER BY id
+			OFFSET $2 LIMIT 1
+		)
+	`
+	if err := sm.conn.ExecuteQuery(ctx, query, threadID, index, pinned, MessageTypeMessage); err != nil {
+		return fmt.Errorf("failed to pin message: %w", err)
+	}
+	return nil
+}
+
+// SummaryMessage returns the system message carrying a conversation summary in a context
+func SummaryMessage(summary string) llm.Message {
+	return llm.Message{
+		Role:     "system",
+		Content:  summaryContextPrefix + summary,
+		Metadata: map[string]interface{}{"type": MessageTypeSummary},
+	}
+}
+
+// summarize folds the messages into the previous summary and stores the result
+func (sm *SessionManager) summarize(ctx context.Context, threadID string, previous *StoredMessage, messages []StoredMessage) (string, error) {
+	conversation := make([]llm.Message, len(messages))
+	for i, stored := range messages {
+		conversation[i] = stored.Message
+	}
+
+	summary, err := sm.summarizer(ctx, summaryText(previous), conversation)
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize thread %s: %w", threadID, err)
+	}
+	if strings.TrimSpace(summary) == "" {
+		return "", fmt.Errorf("failed to summarize thread %s: empty summary", threadID)
+	}
+
+	if err := sm.insertMessage(ctx, threadID, MessageTypeSummary, llm.Message{Role: "system", Content: summary}); err != nil {
+		return "", err
+	}
+	sm.logger.WithField("thread_id", threadID).WithField("messages", len(messages)).Debug("Conversation summarized")
+	return summary, nil
+}
+
+// insertMessage stores a message or summary of a thread
+func (sm *SessionManager) insertMessage(ctx context.Context, threadID, messageType string, message llm.Message) error {
+	toolCallsData, err := json.Marshal(message.ToolCalls)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tool calls: %w", err)
+	}
+	metadataData, err := json.Marshal(message.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	// Large content is stored compressed in its own column, leaving content empty
+	content := message.Content
+	compressed, codecName, err := sm.compression.compress([]byte(message.Content))
+	if err != nil {
+		return fmt.Errorf("failed to store message: %w", err)
+	}
+	if codecName != "" {
+		content = ""
+	} else {
+		compressed = nil
+	}
+
+	query := `
+		INSERT INTO thread_messages (thread_id, message_type, role, content, tool_call_id, tool_calls, metadata, created_at, compression, content_compressed, pinned)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	if err := sm.conn.ExecuteQuery(ctx, query,
+		threadID,
+		messageType,
+		message.Role,
+		content,
+		message.ToolCallID,
+		toolCallsData,
+		metadataData,
+		time.Now(),
+		codecName,
+		compressed,
+		message.Pinned,
+	); err != nil {
+		return fmt.Errorf("failed to store message: %w", err)
+	}
+	return nil
+}
+
+// loadSinceSummary loads the latest summary of a thread, nil when there is none, and the
+// messages stored after it in order
+func (sm *SessionManager) loadSinceSummary(ctx context.Context, threadID string) (*StoredMessage, []StoredMessage, error) {
+	query := `
+		SELECT id, thread_id, message_type, role, content, tool_call_id, tool_calls, metadata, created_at,
+			COALESCE(compression, ''), content_compressed, pinned
+		FROM thread_messages
+		WHERE thread_id = $1 AND id >= COALESCE(
+			(SELECT MAX(id) FROM thread_messages WHERE thread_id = $1 AND message_type = $2), 0)
+		ORDER BY id
+	`
+
+	result, err := sm.conn.QueryRows(ctx, query, threadID, MessageTypeSummary)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load messages: %w", err)
+	}
+	rows, ok := result.(rowIterator)
+	if !ok {
+		return nil, nil, fmt.Errorf("failed to load messages: unexpected result %T", result)
+	}
+	defer rows.Close()
+
+	var summary *StoredMessage
+	var messages []StoredMessage
+	for rows.Next() {
+		var stored StoredMessage
+		var toolCallsData, metadataData, compressed []byte
+		var codecName string
+
+		err := rows.Scan(
+			&stored.ID,
+			&stored.ThreadID,
+			&stored.Type,
+			&stored.Message.Role,
+			&stored.Message.Content,
+			&stored.Message.ToolCallID,
+			&toolCallsData,
+			&metadataData,
+			&stored.CreatedAt,
+			&codecName,
+			&compressed,
+			&stored.Message.Pinned,
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		if codecName != "" {
+			content, err := decompress(codecName, compressed)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read message %d: %w", stored.ID, err)
+			}
+			stored.Message.Content = string(content)
+		}
+		if len(toolCallsData) > 0 {
+			if err := json.Unmarshal(toolCallsData, &stored.Message.ToolCalls); err != nil {
+				return nil, nil, fmt.Errorf("failed to unmarshal tool calls: %w", err)
+			}
+		}
+		if len(metadataData) > 0 {
+			if err := json.Unmarshal(metadataData, &stored.Message.Metadata); err != nil {
+				return nil, nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+			}
+		}
+
+		if stored.Type == MessageTypeSummary {
+			summary = &stored
+			messages = nil
+			continue
+		}
+		messages = append(messages, stored)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to load messages: %w", err)
+	}
+	return summary, messages, nil
+}
+
+// summaryText returns the text of a stored summary, or "" when there is none
+func summaryText(summary *StoredMessage) string {
+	if summary == nil {
+		return ""
+	}
+	return summary.Message.Content
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// messageStoreConnection keeps the thread_messages rows in memory
+type messageStoreConnection struct {
+	MockConnection
+	rows [][]interface{}
+}
+
+func (c *messageStoreConnection) ExecuteQuery(ctx context.Context, query string, args ...interface{}) error {
+	switch {
+	case strings.Contains(query, "INSERT INTO thread_messages"):
+		// id, thread_id, message_type, role, content, tool_call_id, tool_calls, metadata, created_at,
+		// compression, content_compressed, pinned
+		c.rows = append(c.rows, append([]interface{}{int64(len(c.rows) + 1)}, args...))
+	case strings.Contains(query, "UPDATE thread_messages SET pinned"):
+		// thread_id, index, pinned, message_type
+		index := 0
+		for _, row := range c.rows {
+			if row[1] != args[0] || row[2] != args[3] {
+				continue
+			}
+			if index == args[1] {
+				row[11] = args[2]
+				break
+			}
+			index++
+		}
+	}
+	return nil
+}
+
+func (c *messageStoreConnection) QueryRows(ctx context.Context, query string, args ...interface{}) (interface{}, error) {
+	var matching [][]interface{}
+	for _, row := range c.rows {
+		if row[1] != args[0] {
+			continue
+		}
+		if row[2] == MessageTypeSummary {
+			matching = nil
+		}
+		matching = append(matching, row)
+	}
+	return &memoryRows{rows: matching, index: -1}, nil
+}
+
+// memoryRows iterates over rows kept in memory
+type memoryRows struct {
+	rows  [][]interface{}
+	index int
+}
+
+func (r *memoryRows) Next() bool {
+	r.index++
+	return r.index < len(r.rows)
+}
+
+func (r *memoryRows) Scan(dest ...interface{}) error {
+	for i, value := range r.rows[r.index] {
+		switch d := dest[i].(type) {
+		case *int64:
+			*d = value.(int64)
+		case *string:
+			*d = value.(string)
+		case *[]byte:
+			*d = value.([]byte)
+		case *time.Time:
+			*d = value.(time.Time)
+		case *bool:
+			*d = value.(bool)
+		default:
+			return fmt.Errorf("unsupported destination %T", dest[i])
+		}
+	}
+	return nil
+}
+
+func (r *memoryRows) Err() error   { return nil }
+func (r *memoryRows) Close() error { return nil }
+
+func TestSessionManager_RollingSummary(t *testing.T) {
+	conn := &messageStoreConnection{}
+	manager := NewSessionManager(conn)
+	ctx := context.Background()
+
+	var calls []string
+	manager.SetSummarizer(3, func(ctx context.Context, previous string, messages []llm.Message) (string, error) {
+		var contents []string
+		for _, message := range messages {
+			contents = append(contents, message.Content)
+		}
+		calls = append(calls, previous+"|"+strings.Join(contents, ","))
+		return fmt.Sprintf("summary %d", len(calls)), nil
+	})
+
+	for i := 1; i <= 7; i++ {
+		role := "user"
+		if i%2 == 0 {
+			role = "assistant"
+		}
+		if err := manager.AppendMessage(ctx, "thread-1", llm.Message{Role: role, Content: fmt.Sprintf("m%d", i)}); err != nil {
+			t.Fatalf("AppendMessage() failed: %v", err)
+		}
+	}
+
+	// Every third message folds the new messages into the previous summary
+	expected := []string{"|m1,m2,m3", "summary 1|m4,m5,m6"}
+	if strings.Join(calls, ";") != strings.Join(expected, ";") {
+		t.Errorf("Expected summarizer calls %v, got %v", expected, calls)
+	}
+
+	messages, err := manager.LoadContext(ctx, "thread-1")
+	if err != nil {
+		t.Fatalf("LoadContext() failed: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("Expected the summary and one recent message, got %+v", messages)
+	}
+	if messages[0].Role != "system" || messages[0].Content != summaryContextPrefix+"summary 2" || messages[0].Metadata["type"] != MessageTypeSummary {
+		t.Errorf("Expected the latest summary first, got %+v", messages[0])
+	}
+	if messages[1].Content != "m7" || messages[1].Role != "user" {
+		t.Errorf("Expected the message after the summary, got %+v", messages[1])
+	}
+
+	// Summarizing on demand covers the messages since the last summary
+	summary, err := manager.SummarizeThread(ctx, "thread-1")
+	if err != nil || summary != "summary 3" {
+		t.Errorf("Expected a third summary, got %q, %v", summary, err)
+	}
+
+	// A failing summarizer does not lose the message
+	manager.SetSummarizer(1, func(ctx context.Context, previous string, messages []llm.Message) (string, error) {
+		return "", errors.New("model unavailable")
+	})
+	if err := manager.AppendMessage(ctx, "thread-1", llm.Message{Role: "user", Content: "m8"}); err != nil {
+		t.Errorf("Expected the message to be stored despite the failed summary, got %v", err)
+	}
+	messages, _ = manager.LoadContext(ctx, "thread-1")
+	if last := messages[len(messages)-1]; last.Content != "m8" {
+		t.Errorf("Expected the stored message in the context, got %+v", last)
+	}
+
+	if _, err := NewSessionManager(nil).LoadContext(ctx, "thread-1"); err == nil {
+		t.Error("Expected an error without a session store")
+	}
+}
+
+func TestSessionManager_PinMessage(t *testing.T) {
+	manager := NewSessionManager(&messageStoreConnection{})
+	ctx := context.Background()
+
+	for _, message := range []llm.Message{
+		{Role: "user", Content: "My account is 42"},
+		{Role: "assistant", Content: "Noted"},
+		{Role: "user", Content: "Always answer in French", Pinned: true},
+	} {
+		if err := manager.AppendMessage(ctx, "thread-1", message); err != nil {
+			t.Fatalf("AppendMessage() failed: %v", err)
+		}
+	}
+	if err := manager.PinMessage(ctx, "thread-1", 0, true); err != nil {
+		t.Fatalf("PinMessage() failed: %v", err)
+	}
+	if err := manager.PinMessage(ctx, "thread-1", 2, false); err != nil {
+		t.Fatalf("PinMessage() failed: %v", err)
+	}
+
+	// The stored flags come back with the context
+	messages, err := manager.LoadContext(ctx, "thread-1")
+	if err != nil {
+		t.Fatalf("LoadContext() failed: %v", err)
+	}
+	if !messages[0].Pinned || messages[1].Pinned || messages[2].Pinned {
+		t.Errorf("Expected only the first message pinned, got %+v", messages)
+	}
+
+	if err := manager.PinMessage(ctx, "thread-1", -1, true); err == nil {
+		t.Error("Expected an error for a negative index")
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+const (
+	// DefaultThreadTitle is used when a conversation has no text to derive a title from
+	DefaultThreadTitle = "New Conversation"
+
+	maxTitleWords = 5
+	maxTitleChars = 50
+)
+
+// titlePrompt asks the model for a short conversation title
+const titlePrompt = "Summarize this conversation in 5 words or fewer. Respond with the title only, without quotes or punctuation at the end."
+
+// TitleGenerator produces a title for a conversation from its first exchange
+type TitleGenerator func(ctx context.Context, messages []llm.Message) (string, error)
+
+// NewLLMTitleGenerator returns a title generator asking a model to summarize the conversation
+func NewLLMTitleGenerator(manager *llm.ProviderManager, provider, model string) TitleGenerator {
+	return func(ctx context.Context, messages []llm.Message) (string, error) {
+		var transcript strings.Builder
+		for _, message := range messages {
+			fmt.Fprintf(&transcript, "%s: %s\n", message.Role, message.Content)
+		}
+
+		resp, err := manager.Complete(ctx, provider, llm.CompletionRequest{
+			Model: model,
+			Messages: []llm.Message{
+				{Role: "system", Content: titlePrompt},
+				{Role: "user", Content: transcript.String()},
+			},
+			Temperature: 0.2,
+			MaxTokens:   20,
+		})
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Choices) == 0 {
+			return "", fmt.Errorf("no response from LLM")
+		}
+		return resp.Choices[0].Message.Content, nil
+	}
+}
+
+// HeuristicTitle derives a title from the first words of a text
+func HeuristicTitle(text string) string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return DefaultThreadTitle
+	}
+
+	var title strings.Builder
+	for i, word := range words {
+		if i >= maxTitleWords || title.Len()+len(word) > maxTitleChars {
+			break
+		}
+		if i > 0 {
+			title.WriteString(" ")
+		}
+		title.WriteString(word)
+	}
+	if title.Len() == 0 {
+		return words[0][:maxTitleChars-3] + "..."
+	}
+	return title.String()
+}
+
+// cleanTitle strips the quotes, labels and trailing punctuation models add around titles
+func cleanTitle(title string) string {
+	title = strings.TrimSpace(strings.SplitN(strings.TrimSpace(title), "\n", 2)[0])
+	if lower := strings.ToLower(title); strings.HasPrefix(lower, "title:") {
+		title = strings.TrimSpace(title[len("title:"):])
+	}
+	title = strings.Trim(title, "\"'`*# ")
+	title = strings.TrimRight(title, ".!?:;,")
+	if len(title) > maxTitleChars {
+		title = HeuristicTitle(title)
+	}
+	return title
+}
+
+// SetTitleGenerator sets the generator used to title threads; nil restores the
+// first-words heuristic
+func (sm *SessionManager) SetTitleGenerator(generator TitleGenerator) {
+	sm.titleGenerator = generator
+}
+
+// GenerateTitle titles a conversation with the configured generator, falling back to the
+// first words of the opening message when the generator is disabled or fails
+func (sm *SessionManager) GenerateTitle(ctx context.Context, messages []llm.Message) string {
+	var opening string
+	for _, message := range messages {
+		if message.Role == "user" {
+			opening = message.Content
+			break
+		}
+	}
+
+	if sm.titleGenerator != nil {
+		title, err := sm.titleGenerator(ctx, messages)
+		if err == nil {
+			if title = cleanTitle(title); title != "" {
+				return title
+			}
+		}
+		sm.logger.WithError(err).Warn("Title generation failed, using the opening words")
+	}
+	return HeuristicTitle(opening)
+}
+
+// TitleThread generates a title from the first exchange of a thread and stores it as the
+// thread name
+func (sm *SessionManager) TitleThread(ctx context.Context, threadID string, messages []llm.Message) (string, error) {
+	title := sm.GenerateTitle(ctx, messages)
+	if sm.conn == nil {
+		return title, fmt.Errorf("session store not configured")
+	}
+
+	query := `
+		UPDATE threads SET name = $1, updated_at = $2
+		WHERE id = $3
+	`
+	if err := sm.conn.ExecuteQuery(ctx, query, title, time.Now(), threadID); err != nil {
+		return title, fmt.Errorf("failed to update thread title: %w", err)
+	}
+	return title, nil
+}
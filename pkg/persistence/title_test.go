@@ -0,0 +1,83 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// recordingConnection records the arguments of executed queries
+type recordingConnection struct {
+	MockConnection
+	args [][]interface{}
+}
+
+func (c *recordingConnection) ExecuteQuery(ctx context.Context, query string, args ...interface{}) error {
+	c.args = append(c.args, args)
+	return nil
+}
+
+func TestHeuristicTitle(t *testing.T) {
+	tests := map[string]string{
+		"":                                       DefaultThreadTitle,
+		"How do I bake sourdough bread at home?": "How do I bake sourdough",
+		strings.Repeat("x", 60):                  strings.Repeat("x", 47) + "...",
+	}
+	for input, expected := range tests {
+		if got := HeuristicTitle(input); got != expected {
+			t.Errorf("HeuristicTitle(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestSessionManager_TitleThread(t *testing.T) {
+	conn := &recordingConnection{}
+	manager := NewSessionManager(conn)
+	messages := []llm.Message{
+		{Role: "user", Content: "How do I bake sourdough bread at home?"},
+		{Role: "assistant", Content: "Start with an active starter..."},
+	}
+
+	// Without a generator the opening words are used
+	title, err := manager.TitleThread(context.Background(), "thread-1", messages)
+	if err != nil {
+		t.Fatalf("TitleThread() failed: %v", err)
+	}
+	if title != "How do I bake sourdough" {
+		t.Errorf("Unexpected heuristic title %q", title)
+	}
+
+	manager.SetTitleGenerator(func(ctx context.Context, messages []llm.Message) (string, error) {
+		return "Title: \"Baking Sourdough Bread at Home.\"\n", nil
+	})
+	title, err = manager.TitleThread(context.Background(), "thread-1", messages)
+	if err != nil {
+		t.Fatalf("TitleThread() failed: %v", err)
+	}
+	if title != "Baking Sourdough Bread at Home" {
+		t.Errorf("Expected the cleaned generated title, got %q", title)
+	}
+	if last := conn.args[len(conn.args)-1]; last[0] != title || last[2] != "thread-1" {
+		t.Errorf("Expected the thread name to be updated, got %v", last)
+	}
+
+	manager.SetTitleGenerator(func(ctx context.Context, messages []llm.Message) (string, error) {
+		return "", errors.New("model unavailable")
+	})
+	if title := manager.GenerateTitle(context.Background(), messages); title != "How do I bake sourdough" {
+		t.Errorf("Expected the heuristic fallback, got %q", title)
+	}
+
+	if _, err := NewSessionManager(nil).TitleThread(context.Background(), "thread-1", messages); err == nil {
+		t.Error("Expected an error without a session store")
+	}
+}
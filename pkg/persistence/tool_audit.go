@@ -0,0 +1,91 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
+)
+
+// PostgresCheckpointer records tool invocations in the tool_audit_log table
+var _ tools.ToolAuditor = (*PostgresCheckpointer)(nil)
+
+// AuditToolCall records a tool invocation in the tool_audit_log table
+func (p *PostgresCheckpointer) AuditToolCall(ctx context.Context, event *tools.ToolAuditEvent) error {
+	query := `
+		INSERT INTO tool_audit_log (id, agent_id, session_id, execution_id, tool_name, arguments,
+			result_bytes, duration_ms, success, error, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	err := p.conn.ExecuteQuery(ctx, query,
+		event.ID,
+		event.AgentID,
+		event.SessionID,
+		event.ExecutionID,
+		event.ToolName,
+		event.Arguments,
+		event.ResultBytes,
+		event.Duration.Milliseconds(),
+		event.Success,
+		event.Error,
+		event.Timestamp,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record tool audit event: %w", err)
+	}
+	return nil
+}
+
+// ListToolAuditEvents lists the tool invocations of an execution, oldest first
+func (p *PostgresCheckpointer) ListToolAuditEvents(ctx context.Context, executionID string) ([]*tools.ToolAuditEvent, error) {
+	query := `
+		SELECT id, agent_id, session_id, execution_id, tool_name, arguments,
+			result_bytes, duration_ms, success, error, created_at
+		FROM tool_audit_log
+		WHERE execution_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := p.conn.QueryRows(ctx, query, executionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tool audit events: %w", err)
+	}
+	defer rows.(*sql.Rows).Close()
+
+	var events []*tools.ToolAuditEvent
+	for rows.(*sql.Rows).Next() {
+		var event tools.ToolAuditEvent
+		var durationMs int64
+
+		err := rows.(*sql.Rows).Scan(
+			&event.ID,
+			&event.AgentID,
+			&event.SessionID,
+			&event.ExecutionID,
+			&event.ToolName,
+			&event.Arguments,
+			&event.ResultBytes,
+			&durationMs,
+			&event.Success,
+			&event.Error,
+			&event.Timestamp,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tool audit event: %w", err)
+		}
+
+		event.Duration = time.Duration(durationMs) * time.Millisecond
+		events = append(events, &event)
+	}
+
+	return events, rows.(*sql.Rows).Err()
+}
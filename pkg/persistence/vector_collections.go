@@ -0,0 +1,340 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/rag"
+)
+
+// Tables of the named vector collections
+const (
+	VectorCollectionsTable   = "vector_collections"
+	CollectionDocumentsTable = "collection_documents"
+)
+
+// ErrCollectionNotFound is returned for operations on a collection that was not created
+var ErrCollectionNotFound = errors.New("vector collection not found")
+
+// VectorCollection is a named set of documents embedded by one model. Collections share a
+// table, but each records its own embedding model and dimension and is searched alone.
+type VectorCollection struct {
+	Name           string    `json:"name"`
+	EmbeddingModel string    `json:"embedding_model"`
+	Dimension      int       `json:"dimension"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// checkEmbedding verifies that an embedding of a model can be stored in or compared with
+// the collection
+func (c *VectorCollection) checkEmbedding(model string, dimension int) error {
+	metadata := &VectorStoreMetadata{EmbeddingModel: c.EmbeddingModel, EmbeddingDimension: c.Dimension}
+	if err := CheckEmbeddingCompatibility(metadata, model, dimension); err != nil {
+		return fmt.Errorf("collection %s: %w", c.Name, err)
+	}
+	return nil
+}
+
+// collectionSchema creates the tables of the named collections. Embeddings are stored in an
+// untyped vector column so collections can have different dimensions; each collection gets
+// a partial index on its rows, cast to its dimension.
+const collectionSchema = `
+	CREATE TABLE IF NOT EXISTS vector_collections (
+		name VARCHAR(255) PRIMARY KEY,
+		embedding_model VARCHAR(255) NOT NULL,
+		dimension INTEGER NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+	);
+
+	CREATE TABLE IF NOT EXISTS collection_documents (
+		collection VARCHAR(255) NOT NULL REFERENCES vector_collections(name) ON DELETE CASCADE,
+		id VARCHAR(255) NOT NULL,
+		thread_id VARCHAR(255),
+		content TEXT NOT NULL,
+		metadata JSONB,
+		embedding vector NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		PRIMARY KEY (collection, id)
+	);
+`
+
+// requireCollections checks that the store supports named collections
+func (p *PostgresCheckpointer) requireCollections() error {
+	if !p.config.EnableRAG {
+		return fmt.Errorf("RAG is not enabled")
+	}
+	if p.config.Type != DatabaseTypePgVector {
+		return fmt.Errorf("vector collections require pgvector")
+	}
+	return nil
+}
+
+// CreateCollection creates a named collection for the embeddings of a model, with a partial
+// vector index over its documents. Creating an existing collection with the same settings
+// returns it; different settings are refused, since its vectors could not be compared.
+func (p *PostgresCheckpointer) CreateCollection(ctx context.Context, name, model string, dimension int) (*VectorCollection, error) {
+	if err := p.requireCollections(); err != nil {
+		return nil, err
+	}
+	if err := validateTableName(name); err != nil {
+		return nil, fmt.Errorf("invalid collection name: %s", name)
+	}
+	if model == "" || dimension <= 0 {
+		return nil, fmt.Errorf("collection %s needs an embedding model and a positive dimension", name)
+	}
+
+	insert := fmt.Sprintf(`
+		INSERT INTO %s (name, embedding_model, dimension, created_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (name) DO NOTHING
+	`, VectorCollectionsTable)
+	if err := p.conn.ExecuteQuery(ctx, insert, name, model, dimension); err != nil {
+		return nil, fmt.Errorf("failed to create collection %s: %w", name, err)
+	}
+
+	collection, err := p.Collection(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := collection.checkEmbedding(model, dimension); err != nil {
+		return nil, fmt.Errorf("collection %s already exists with model %s and dimension %d", name, collection.EmbeddingModel, collection.Dimension)
+	}
+
+	// The name is validated, so it is safe to interpolate into the index predicate
+	index := fmt.Sprintf(`
+		CREATE INDEX IF NOT EXISTS idx_collection_%[1]s_embedding ON %[2]s
+		USING ivfflat ((embedding::vector(%[3]d)) %[4]s)
+		WHERE collection = '%[1]s'
+	`, name, CollectionDocumentsTable, dimension, vectorOperatorClass(p.config.VectorMetric))
+	if err := p.conn.ExecuteQuery(ctx, index); err != nil {
+		return nil, fmt.Errorf("failed to index collection %s: %w", name, err)
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"collection": name,
+		"model":      model,
+		"dimension":  dimension,
+	}).Info("Vector collection ready")
+	return collection, nil
+}
+
+// Collection returns a named collection, or ErrCollectionNotFound
+func (p *PostgresCheckpointer) Collection(ctx context.Context, name string) (*VectorCollection, error) {
+	if err := p.requireCollections(); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`SELECT name, embedding_model, dimension, created_at FROM %s WHERE name = $1`, VectorCollectionsTable)
+	row := p.conn.QueryRow(ctx, query, name).(*sql.Row)
+
+	var collection VectorCollection
+	if err := row.Scan(&collection.Name, &collection.EmbeddingModel, &collection.Dimension, &collection.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %s", ErrCollectionNotFound, name)
+		}
+		return nil, fmt.Errorf("failed to load collection %s: %w", name, err)
+	}
+	return &collection, nil
+}
+
+// ListCollections returns the collections ordered by name
+func (p *PostgresCheckpointer) ListCollections(ctx context.Context) ([]*VectorCollection, error) {
+	if err := p.requireCollections(); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`SELECT name, embedding_model, dimension, created_at FROM %s ORDER BY name`, VectorCollectionsTable)
+	rows, err := p.conn.QueryRows(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	defer rows.(*sql.Rows).Close()
+
+	var collections []*VectorCollection
+	for rows.(*sql.Rows).Next() {
+		var collection VectorCollection
+		if err := rows.(*sql.Rows).Scan(&collection.Name, &collection.EmbeddingModel, &collection.Dimension, &collection.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan collection: %w", err)
+		}
+		collections = append(collections, &collection)
+	}
+	return collections, rows.(*sql.Rows).Err()
+}
+
+// DropCollection deletes a collection with its documents and index
+func (p *PostgresCheckpointer) DropCollection(ctx context.Context, name string) error {
+	if _, err := p.Collection(ctx, name); err != nil {
+		return err
+	}
+	statements := []string{
+		fmt.Sprintf("DROP INDEX IF EXISTS idx_collection_%s_embedding", name),
+		fmt.Sprintf("DELETE FROM %s WHERE name = '%s'", VectorCollectionsTable, name),
+	}
+	for _, statement := range statements {
+		if err := p.conn.ExecuteQuery(ctx, statement); err != nil {
+			return fmt.Errorf("failed to drop collection %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// UpsertCollectionDocuments stores documents embedded by a model in a collection. Every
+// embedding must match the collection's model and dimension, so vectors of another model
+// can never be mixed into it.
+func (p *PostgresCheckpointer) UpsertCollectionDocuments(ctx context.Context, name, model string, docs []*Document) error {
+	collection, err := p.Collection(ctx, name)
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		if len(doc.Embedding) == 0 {
+			return fmt.Errorf("collection %s: document %s has no embedding", name, doc.ID)
+		}
+		if err := collection.checkEmbedding(model, len(doc.Embedding)); err != nil {
+			return fmt.Errorf("document %s: %w", doc.ID, err)
+		}
+	}
+
+	tx, err := p.conn.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (collection, id, thread_id, content, metadata, embedding, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6::vector, $7, $8)
+		ON CONFLICT (collection, id) DO UPDATE SET
+			thread_id = EXCLUDED.thread_id,
+			content = EXCLUDED.content,
+			metadata = EXCLUDED.metadata,
+			embedding = EXCLUDED.embedding,
+			updated_at = EXCLUDED.updated_at
+	`, CollectionDocumentsTable)
+
+	now := time.Now()
+	for _, doc := range docs {
+		metadataData, err := json.Marshal(doc.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to marshal metadata for %s: %w", doc.ID, err)
+		}
+		createdAt, updatedAt := doc.CreatedAt, doc.UpdatedAt
+		if createdAt.IsZero() {
+			createdAt = now
+		}
+		if updatedAt.IsZero() {
+			updatedAt = now
+		}
+		if _, err := tx.ExecContext(ctx, query, name, doc.ID, doc.ThreadID, doc.Content, metadataData,
+			formatVector(doc.Embedding), createdAt, updatedAt); err != nil {
+			return fmt.Errorf("failed to upsert document %s: %w", doc.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// SearchCollection returns the documents of a collection closest to a query embedding,
+// keeping those whose metadata contains the filter. The query must be embedded by the
+// collection's model: a query meant for another collection is refused rather than compared
+// with vectors of a different space.
+func (p *PostgresCheckpointer) SearchCollection(ctx context.Context, name, model string, queryEmbedding []float64, limit int, filter map[string]interface{}) ([]*Document, error) {
+	collection, err := p.Collection(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := collection.checkEmbedding(model, len(queryEmbedding)); err != nil {
+		return nil, err
+	}
+
+	vectorType := fmt.Sprintf("vector(%d)", collection.Dimension)
+	args := []interface{}{name, formatVector(queryEmbedding)}
+	conditions := "collection = $1"
+	if len(filter) > 0 {
+		filterData, err := json.Marshal(filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata filter: %w", err)
+		}
+		args = append(args, filterData)
+		conditions += fmt.Sprintf(" AND metadata @> $%d", len(args))
+	}
+	args = append(args, limit)
+
+	// The distance expression matches the collection's partial index
+	query := fmt.Sprintf(`
+		SELECT id, COALESCE(thread_id, ''), content, metadata, embedding::%[1]s %[2]s $2::%[1]s AS distance, created_at, updated_at
+		FROM %[3]s
+		WHERE %[4]s
+		ORDER BY distance
+		LIMIT $%[5]d
+	`, vectorType, vectorDistanceOperator(p.config.VectorMetric), CollectionDocumentsTable, conditions, len(args))
+
+	rows, err := p.conn.QueryRows(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search collection %s: %w", name, err)
+	}
+	defer rows.(*sql.Rows).Close()
+
+	var documents []*Document
+	for rows.(*sql.Rows).Next() {
+		doc := Document{Collection: name}
+		var metadataData []byte
+		var distance float64
+		if err := rows.(*sql.Rows).Scan(&doc.ID, &doc.ThreadID, &doc.Content, &metadataData, &distance, &doc.CreatedAt, &doc.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		if len(metadataData) > 0 {
+			if err := json.Unmarshal(metadataData, &doc.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+			}
+		}
+		doc.Score = SimilarityFromDistance(p.config.VectorMetric, distance)
+		documents = append(documents, &doc)
+	}
+	if err := rows.(*sql.Rows).Err(); err != nil {
+		return nil, err
+	}
+	return FilterBySimilarity(documents, p.config.SimilarityThreshold), nil
+}
+
+// CollectionVectorStore searches a named collection, implementing rag.VectorStore
+type CollectionVectorStore struct {
+	checkpointer *PostgresCheckpointer
+	collection   string
+	model        string
+}
+
+// CollectionStore returns a vector store over a named collection, for the vector search
+// tool. Query embeddings must be produced by the collection's model.
+func (p *PostgresCheckpointer) CollectionStore(collection, model string) *CollectionVectorStore {
+	return &CollectionVectorStore{checkpointer: p, collection: collection, model: model}
+}
+
+// CollectionRetriever returns a retriever over a named collection, embedding queries with
+// the provider and the collection's model
+func (p *PostgresCheckpointer) CollectionRetriever(collection, model string, embedder llm.Provider) (*rag.VectorRetriever, error) {
+	return rag.NewVectorRetriever(p.CollectionStore(collection, model), embedder, model)
+}
+
+// SimilaritySearch returns the documents of the collection closest to the embedding whose
+// metadata contains the filter
+func (s *CollectionVectorStore) SimilaritySearch(ctx context.Context, embedding []float64, topK int, filter map[string]interface{}) ([]rag.RetrievedChunk, error) {
+	documents, err := s.checkpointer.SearchCollection(ctx, s.collection, s.model, embedding, topK, filter)
+	if err != nil {
+		return nil, err
+	}
+	return RetrievedChunks(documents), nil
+}
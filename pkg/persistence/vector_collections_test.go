@@ -0,0 +1,48 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestVectorCollection_CheckEmbedding(t *testing.T) {
+	collection := &VectorCollection{Name: "openai_docs", EmbeddingModel: "text-embedding-3-small", Dimension: 1536}
+
+	if err := collection.checkEmbedding("text-embedding-3-small", 1536); err != nil {
+		t.Errorf("Expected matching embedding to be accepted, got %v", err)
+	}
+
+	var mismatch *EmbeddingMismatchError
+	err := collection.checkEmbedding("nomic-embed-text", 768)
+	if !errors.As(err, &mismatch) || mismatch.QueryModel != "nomic-embed-text" {
+		t.Fatalf("Expected cross-collection query to be rejected, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "openai_docs") {
+		t.Errorf("Expected error to name the collection, got %v", err)
+	}
+}
+
+func TestCreateCollection_Validation(t *testing.T) {
+	ctx := context.Background()
+	checkpointer := &PostgresCheckpointer{config: &DatabaseConfig{Type: DatabaseTypePgVector, EnableRAG: true}}
+
+	if _, err := checkpointer.CreateCollection(ctx, "docs; DROP TABLE documents", "model", 3); err == nil {
+		t.Error("Expected invalid collection name to be rejected")
+	}
+	if _, err := checkpointer.CreateCollection(ctx, "docs", "model", 0); err == nil {
+		t.Error("Expected collection without dimension to be rejected")
+	}
+
+	checkpointer.config.Type = DatabaseTypePostgres
+	if _, err := checkpointer.CreateCollection(ctx, "docs", "model", 3); err == nil {
+		t.Error("Expected collections to require pgvector")
+	}
+}
@@ -0,0 +1,345 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// VectorMetadataTable stores the schema version and embedding settings of the vector store
+const VectorMetadataTable = "vector_store_metadata"
+
+// VectorSchemaVersion is the schema version of a freshly initialized vector store
+const VectorSchemaVersion = 1
+
+// EmbeddingMismatchPolicy controls what happens when a query embedding is incompatible
+// with the stored vectors
+type EmbeddingMismatchPolicy string
+
+const (
+	EmbeddingMismatchRefuse EmbeddingMismatchPolicy = "refuse"
+	EmbeddingMismatchWarn   EmbeddingMismatchPolicy = "warn"
+)
+
+// VectorStoreMetadata describes how the stored vectors were produced
+type VectorStoreMetadata struct {
+	SchemaVersion      int       `json:"schema_version"`
+	EmbeddingModel     string    `json:"embedding_model"`
+	EmbeddingDimension int       `json:"embedding_dimension"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// EmbeddingMismatchError is returned when a query embedding does not match the stored vectors
+type EmbeddingMismatchError struct {
+	StoredModel     string
+	StoredDimension int
+	QueryModel      string
+	QueryDimension  int
+}
+
+// Error implements the error interface
+func (e *EmbeddingMismatchError) Error() string {
+	if e.QueryDimension > 0 && e.StoredDimension > 0 && e.QueryDimension != e.StoredDimension {
+		return fmt.Sprintf("query embedding has dimension %d but stored vectors have dimension %d",
+			e.QueryDimension, e.StoredDimension)
+	}
+	return fmt.Sprintf("query embedding model %s does not match stored embedding model %s, reindex the documents first",
+		e.QueryModel, e.StoredModel)
+}
+
+// CheckEmbeddingCompatibility verifies that a query embedding can be compared with the
+// stored vectors. Empty models and zero dimensions are not checked.
+func CheckEmbeddingCompatibility(metadata *VectorStoreMetadata, model string, dimension int) error {
+	if metadata == nil {
+		return nil
+	}
+
+	mismatch := &EmbeddingMismatchError{
+		StoredModel:     metadata.EmbeddingModel,
+		StoredDimension: metadata.EmbeddingDimension,
+		QueryModel:      model,
+		QueryDimension:  dimension,
+	}
+	if dimension > 0 && metadata.EmbeddingDimension > 0 && dimension != metadata.EmbeddingDimension {
+		return mismatch
+	}
+	if model != "" && metadata.EmbeddingModel != "" && model != metadata.EmbeddingModel {
+		return mismatch
+	}
+	return nil
+}
+
+// VectorColumn is a column added to a table by a migration
+type VectorColumn struct {
+	Table string `json:"table"`
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+}
+
+// VectorMigration is a non-destructive schema change applied by Migrate
+type VectorMigration struct {
+	Version     int            `json:"version"`
+	Description string         `json:"description"`
+	Columns     []VectorColumn `json:"columns"`
+}
+
+var columnTypePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_ (),]*$`)
+
+// validate checks that a migration only contains safe identifiers and types
+func (m VectorMigration) validate() error {
+	if m.Version <= 0 {
+		return fmt.Errorf("migration version must be positive, got %d", m.Version)
+	}
+	for _, column := range m.Columns {
+		if err := validateTableName(column.Table); err != nil {
+			return fmt.Errorf("migration %d: %w", m.Version, err)
+		}
+		if err := validateTableName(column.Name); err != nil {
+			return fmt.Errorf("migration %d: invalid column name: %s", m.Version, column.Name)
+		}
+		if !columnTypePattern.MatchString(column.Type) {
+			return fmt.Errorf("migration %d: invalid column type: %s", m.Version, column.Type)
+		}
+	}
+	return nil
+}
+
+// pendingMigrations returns the migrations newer than the current version, ordered by version
+func pendingMigrations(current int, migrations []VectorMigration) ([]VectorMigration, error) {
+	seen := make(map[int]bool)
+	var pending []VectorMigration
+
+	for _, migration := range migrations {
+		if err := migration.validate(); err != nil {
+			return nil, err
+		}
+		if seen[migration.Version] {
+			return nil, fmt.Errorf("duplicate migration version: %d", migration.Version)
+		}
+		seen[migration.Version] = true
+
+		if migration.Version > current {
+			pending = append(pending, migration)
+		}
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Version < pending[j].Version
+	})
+	return pending, nil
+}
+
+// initVectorMetadata creates the metadata table and records the current embedding settings
+func (p *PostgresCheckpointer) initVectorMetadata(ctx context.Context) error {
+	dimension := p.config.EmbeddingDimension
+	if dimension == 0 {
+		dimension = p.config.VectorDimension
+	}
+	if dimension == 0 && p.config.Type == DatabaseTypePgVector {
+		dimension = 1536 // Matches the default documents table dimension
+	}
+
+	schema := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			name VARCHAR(255) PRIMARY KEY,
+			schema_version INTEGER NOT NULL,
+			embedding_model VARCHAR(255),
+			embedding_dimension INTEGER,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		);
+	`, VectorMetadataTable)
+	if err := p.conn.ExecuteQuery(ctx, schema); err != nil {
+		return fmt.Errorf("failed to create vector metadata table: %w", err)
+	}
+
+	insert := fmt.Sprintf(`
+		INSERT INTO %s (name, schema_version, embedding_model, embedding_dimension, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (name) DO NOTHING
+	`, VectorMetadataTable)
+	if err := p.conn.ExecuteQuery(ctx, insert, DocumentsTable, VectorSchemaVersion, p.config.EmbeddingModel, dimension); err != nil {
+		return fmt.Errorf("failed to record vector metadata: %w", err)
+	}
+
+	metadata, err := p.VectorMetadata(ctx)
+	if err != nil {
+		return err
+	}
+	if err := CheckEmbeddingCompatibility(metadata, p.config.EmbeddingModel, p.config.EmbeddingDimension); err != nil {
+		p.logger.WithError(err).Warn("Configured embedding settings do not match the stored vectors")
+	}
+	return nil
+}
+
+// VectorMetadata returns the schema version and embedding settings of the stored vectors
+func (p *PostgresCheckpointer) VectorMetadata(ctx context.Context) (*VectorStoreMetadata, error) {
+	query := fmt.Sprintf(`
+		SELECT schema_version, COALESCE(embedding_model, ''), COALESCE(embedding_dimension, 0), updated_at
+		FROM %s WHERE name = $1
+	`, VectorMetadataTable)
+
+	var metadata VectorStoreMetadata
+	row := p.conn.QueryRow(ctx, query, DocumentsTable)
+	if err := row.(*sql.Row).Scan(&metadata.SchemaVersion, &metadata.EmbeddingModel, &metadata.EmbeddingDimension, &metadata.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("failed to load vector metadata: %w", err)
+	}
+
+	p.metaMu.Lock()
+	p.vectorMeta = &metadata
+	p.metaMu.Unlock()
+
+	return &metadata, nil
+}
+
+// Migrate applies the migrations newer than the recorded schema version. Migrations only
+// add columns, so existing documents and embeddings are kept.
+func (p *PostgresCheckpointer) Migrate(ctx context.Context, migrations ...VectorMigration) error {
+	if !p.config.EnableRAG {
+		return fmt.Errorf("RAG is not enabled")
+	}
+
+	metadata, err := p.VectorMetadata(ctx)
+	if err != nil {
+		return err
+	}
+
+	pending, err := pendingMigrations(metadata.SchemaVersion, migrations)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	tx, err := p.conn.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, migration := range pending {
+		for _, column := range migration.Columns {
+			statement := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", column.Table, column.Name, column.Type)
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("migration %d failed: %w", migration.Version, err)
+			}
+		}
+
+		update := fmt.Sprintf("UPDATE %s SET schema_version = $1, updated_at = NOW() WHERE name = $2", VectorMetadataTable)
+		if _, err := tx.ExecContext(ctx, update, migration.Version, DocumentsTable); err != nil {
+			return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+		}
+
+		p.logger.WithFields(logrus.Fields{
+			"version":     migration.Version,
+			"description": migration.Description,
+		}).Info("Applied vector store migration")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migrations: %w", err)
+	}
+
+	_, err = p.VectorMetadata(ctx)
+	return err
+}
+
+// SearchDocumentsWithModel performs similarity search after checking that the query
+// embedding was produced by the model the stored vectors were embedded with
+func (p *PostgresCheckpointer) SearchDocumentsWithModel(ctx context.Context, threadID, model string, queryEmbedding []float64, limit int) ([]*Document, error) {
+	if err := p.checkQueryEmbedding(ctx, model, len(queryEmbedding)); err != nil {
+		return nil, err
+	}
+	return p.searchDocuments(ctx, threadID, queryEmbedding, limit, nil)
+}
+
+// checkQueryEmbedding applies the configured mismatch policy to a query embedding
+func (p *PostgresCheckpointer) checkQueryEmbedding(ctx context.Context, model string, dimension int) error {
+	if p.config.Type != DatabaseTypePgVector || dimension == 0 {
+		return nil
+	}
+
+	p.metaMu.RLock()
+	metadata := p.vectorMeta
+	p.metaMu.RUnlock()
+
+	if metadata == nil {
+		var err error
+		if metadata, err = p.VectorMetadata(ctx); err != nil {
+			return err
+		}
+	}
+
+	err := CheckEmbeddingCompatibility(metadata, model, dimension)
+	if err == nil {
+		return nil
+	}
+	if p.config.EmbeddingMismatchPolicy == EmbeddingMismatchWarn {
+		p.logger.WithError(err).Warn("Searching documents with an incompatible embedding")
+		return nil
+	}
+	return err
+}
+
+// copyExtraDocumentColumns adds columns created by migrations on the documents table to a
+// re-embedded table and copies their values, so a table swap keeps migrated data
+func copyExtraDocumentColumns(ctx context.Context, tx *sql.Tx, table string) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT a.attname, format_type(a.atttypid, a.atttypmod)
+		FROM pg_attribute a
+		WHERE a.attrelid = $1::regclass AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum
+	`, DocumentsTable)
+	if err != nil {
+		return fmt.Errorf("failed to inspect document columns: %w", err)
+	}
+
+	baseColumns := map[string]bool{
+		"id": true, "thread_id": true, "content": true, "metadata": true,
+		"embedding": true, "created_at": true, "updated_at": true,
+	}
+	var extra [][2]string
+	for rows.Next() {
+		var name, columnType string
+		if err := rows.Scan(&name, &columnType); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan document column: %w", err)
+		}
+		if baseColumns[name] {
+			continue
+		}
+		if err := validateTableName(name); err != nil {
+			rows.Close()
+			return fmt.Errorf("unsupported document column: %s", name)
+		}
+		extra = append(extra, [2]string{name, columnType})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, column := range extra {
+		statements := []string{
+			fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", table, column[0], column[1]),
+			fmt.Sprintf("UPDATE %[1]s t SET %[2]s = d.%[2]s FROM %[3]s d WHERE t.id = d.id", table, column[0], DocumentsTable),
+		}
+		for _, statement := range statements {
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("failed to copy column %s: %w", column[0], err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package persistence
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckEmbeddingCompatibility(t *testing.T) {
+	metadata := &VectorStoreMetadata{SchemaVersion: 1, EmbeddingModel: "text-embedding-3-small", EmbeddingDimension: 1536}
+
+	if err := CheckEmbeddingCompatibility(metadata, "text-embedding-3-small", 1536); err != nil {
+		t.Errorf("Expected matching embedding to be compatible, got %v", err)
+	}
+	if err := CheckEmbeddingCompatibility(metadata, "", 1536); err != nil {
+		t.Errorf("Expected unknown model to be compatible, got %v", err)
+	}
+	if err := CheckEmbeddingCompatibility(nil, "other", 3); err != nil {
+		t.Errorf("Expected missing metadata to be compatible, got %v", err)
+	}
+
+	var mismatch *EmbeddingMismatchError
+	err := CheckEmbeddingCompatibility(metadata, "nomic-embed-text", 1536)
+	if !errors.As(err, &mismatch) || mismatch.QueryModel != "nomic-embed-text" {
+		t.Errorf("Expected model mismatch error, got %v", err)
+	}
+
+	err = CheckEmbeddingCompatibility(metadata, "", 768)
+	if !errors.As(err, &mismatch) || mismatch.QueryDimension != 768 {
+		t.Errorf("Expected dimension mismatch error, got %v", err)
+	}
+}
+
+func TestPendingMigrations(t *testing.T) {
+	migrations := []VectorMigration{
+		{Version: 3, Description: "Add chunk index", Columns: []VectorColumn{{Table: "documents", Name: "chunk_index", Type: "INTEGER"}}},
+		{Version: 1, Description: "Baseline"},
+		{Version: 2, Description: "Add source", Columns: []VectorColumn{{Table: "documents", Name: "source", Type: "VARCHAR(1024)"}}},
+	}
+
+	pending, err := pendingMigrations(1, migrations)
+	if err != nil {
+		t.Fatalf("pendingMigrations() failed: %v", err)
+	}
+	if len(pending) != 2 || pending[0].Version != 2 || pending[1].Version != 3 {
+		t.Errorf("Expected migrations 2 and 3 in order, got %+v", pending)
+	}
+
+	if pending, _ := pendingMigrations(3, migrations); len(pending) != 0 {
+		t.Errorf("Expected no pending migrations, got %+v", pending)
+	}
+
+	invalid := [][]VectorMigration{
+		{{Version: 0}},
+		{{Version: 2}, {Version: 2}},
+		{{Version: 2, Columns: []VectorColumn{{Table: "documents", Name: "source; DROP TABLE documents", Type: "TEXT"}}}},
+		{{Version: 2, Columns: []VectorColumn{{Table: "documents", Name: "source", Type: "TEXT; DROP TABLE documents"}}}},
+	}
+	for i, migrations := range invalid {
+		if _, err := pendingMigrations(0, migrations); err == nil {
+			t.Errorf("Expected invalid migrations %d to be rejected", i)
+		}
+	}
+}
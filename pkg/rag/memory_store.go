@@ -0,0 +1,117 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package rag
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// MemoryStore is a VectorStore keeping chunks and their embeddings in memory, scored by
+// cosine similarity. It suits tests, prototypes and corpora small enough to scan.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	chunks     []RetrievedChunk
+	embeddings [][]float64
+}
+
+// NewMemoryStore creates an empty in-memory vector store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Add stores a chunk with its embedding, replacing a stored chunk with the same ID. All
+// embeddings must have the same dimensions.
+func (s *MemoryStore) Add(chunk RetrievedChunk, embedding []float64) error {
+	if chunk.ID == "" {
+		return fmt.Errorf("chunk ID is required")
+	}
+	if len(embedding) == 0 {
+		return fmt.Errorf("embedding of chunk %s is empty", chunk.ID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.embeddings) > 0 && len(s.embeddings[0]) != len(embedding) {
+		return fmt.Errorf("embedding of chunk %s has %d dimensions, expected %d", chunk.ID, len(embedding), len(s.embeddings[0]))
+	}
+	for i := range s.chunks {
+		if s.chunks[i].ID == chunk.ID {
+			s.chunks[i], s.embeddings[i] = chunk, embedding
+			return nil
+		}
+	}
+	s.chunks = append(s.chunks, chunk)
+	s.embeddings = append(s.embeddings, embedding)
+	return nil
+}
+
+// Delete removes a chunk by ID and reports whether it was stored
+func (s *MemoryStore) Delete(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.chunks {
+		if s.chunks[i].ID == id {
+			s.chunks = append(s.chunks[:i], s.chunks[i+1:]...)
+			s.embeddings = append(s.embeddings[:i], s.embeddings[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of stored chunks
+func (s *MemoryStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.chunks)
+}
+
+// SimilaritySearch returns the topK chunks with the highest cosine similarity to the
+// embedding whose metadata contains the filter
+func (s *MemoryStore) SimilaritySearch(ctx context.Context, embedding []float64, topK int, filter map[string]interface{}) ([]RetrievedChunk, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.embeddings) > 0 && len(s.embeddings[0]) != len(embedding) {
+		return nil, fmt.Errorf("query embedding has %d dimensions, expected %d", len(embedding), len(s.embeddings[0]))
+	}
+
+	results := make([]RetrievedChunk, 0, len(s.chunks))
+	for i, chunk := range s.chunks {
+		if !MatchesMetadataFilter(chunk.Metadata, filter) {
+			continue
+		}
+		chunk.Score = cosineSimilarity(embedding, s.embeddings[i])
+		results = append(results, chunk)
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if topK > 0 && len(results) > topK {
+		results = results[:topK]
+	}
+	return results, nil
+}
+
+// cosineSimilarity returns the cosine of the angle between two vectors of the same length,
+// or 0 when either is zero
+func cosineSimilarity(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
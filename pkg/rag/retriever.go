@@ -0,0 +1,157 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+// Package rag defines the retrieval side of retrieval-augmented generation: the Retriever
+// interface that agents and the vector search tool depend on, and its vector store
+// implementation. Other backends, such as Elasticsearch, OpenSearch or a hosted retrieval
+// API, plug in by implementing Retriever.
+package rag
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// RetrievedChunk is a passage returned by a retriever
+type RetrievedChunk struct {
+	ID      string  `json:"id"`
+	Content string  `json:"content"`
+	Score   float64 `json:"score"`
+	// Title names the source of the chunk when the backend provides one; otherwise the
+	// "title" metadata is used
+	Title    string                 `json:"title,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// RetrieveOptions narrow down a retrieval
+type RetrieveOptions struct {
+	// TopK is the maximum number of chunks to return; retrievers choose a default when it
+	// is zero
+	TopK int `json:"top_k,omitempty"`
+	// Filter keeps only the chunks whose metadata contains every key and value
+	Filter map[string]interface{} `json:"filter,omitempty"`
+	// MinScore drops the chunks scoring below it; zero keeps every chunk
+	MinScore float64 `json:"min_score,omitempty"`
+}
+
+// Retriever returns the chunks most relevant to a query, best first
+type Retriever interface {
+	Retrieve(ctx context.Context, query string, options RetrieveOptions) ([]RetrievedChunk, error)
+}
+
+// RetrieverFunc adapts a function to the Retriever interface
+type RetrieverFunc func(ctx context.Context, query string, options RetrieveOptions) ([]RetrievedChunk, error)
+
+// Retrieve calls f
+func (f RetrieverFunc) Retrieve(ctx context.Context, query string, options RetrieveOptions) ([]RetrievedChunk, error) {
+	return f(ctx, query, options)
+}
+
+// DefaultTopK is the number of chunks returned when RetrieveOptions.TopK is zero
+const DefaultTopK = 5
+
+// VectorStore searches stored chunks by embedding similarity
+type VectorStore interface {
+	// SimilaritySearch returns the topK chunks closest to the embedding, best first, keeping
+	// only the chunks whose metadata contains every key and value of the filter
+	SimilaritySearch(ctx context.Context, embedding []float64, topK int, filter map[string]interface{}) ([]RetrievedChunk, error)
+}
+
+// VectorRetriever retrieves chunks from a vector store, embedding queries with a provider
+type VectorRetriever struct {
+	Store    VectorStore
+	Embedder llm.EmbeddingProvider
+	// Model is the embedding model, which must be the one the stored vectors were made with
+	Model string
+}
+
+// NewVectorRetriever creates a retriever over a vector store. The embedder must implement
+// llm.EmbeddingProvider.
+func NewVectorRetriever(store VectorStore, embedder llm.Provider, model string) (*VectorRetriever, error) {
+	if store == nil {
+		return nil, fmt.Errorf("vector store is required")
+	}
+	embeddingProvider, ok := embedder.(llm.EmbeddingProvider)
+	if !ok {
+		return nil, &llm.UnsupportedFeatureError{Provider: embedder.GetName(), Feature: llm.FeatureEmbeddings}
+	}
+	return &VectorRetriever{Store: store, Embedder: embeddingProvider, Model: model}, nil
+}
+
+// Retrieve embeds the query and returns the most similar chunks
+func (r *VectorRetriever) Retrieve(ctx context.Context, query string, options RetrieveOptions) ([]RetrievedChunk, error) {
+	if query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	topK := options.TopK
+	if topK <= 0 {
+		topK = DefaultTopK
+	}
+
+	embeddings, err := r.Embedder.Embed(ctx, r.Model, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(embeddings) != 1 {
+		return nil, fmt.Errorf("expected 1 query embedding, got %d", len(embeddings))
+	}
+
+	chunks, err := r.Store.SimilaritySearch(ctx, embeddings[0], topK, options.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("vector search failed: %w", err)
+	}
+	return FilterByScore(chunks, options.MinScore), nil
+}
+
+// FilterByScore drops the chunks scoring below minScore, keeping the order of the others. A
+// minScore of zero or less keeps every chunk.
+func FilterByScore(chunks []RetrievedChunk, minScore float64) []RetrievedChunk {
+	if minScore <= 0 {
+		return chunks
+	}
+
+	relevant := make([]RetrievedChunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		if chunk.Score >= minScore {
+			relevant = append(relevant, chunk)
+		}
+	}
+	return relevant
+}
+
+// MatchesMetadataFilter reports whether metadata has every key and value of a filter, for
+// stores filtering in memory. Values are compared by their printed form, so a filter
+// decoded from JSON matches integer metadata.
+func MatchesMetadataFilter(metadata, filter map[string]interface{}) bool {
+	for key, expected := range filter {
+		value, exists := metadata[key]
+		if !exists || fmt.Sprint(value) != fmt.Sprint(expected) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContextChunks converts retrieved chunks to the chunks of a structured context message,
+// tagged with the chunk IDs
+func ContextChunks(chunks []RetrievedChunk) []llm.ContextChunk {
+	contextChunks := make([]llm.ContextChunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		title := chunk.Title
+		if title == "" {
+			title, _ = chunk.Metadata["title"].(string)
+		}
+		contextChunks = append(contextChunks, llm.ContextChunk{
+			SourceID: chunk.ID,
+			Title:    title,
+			Content:  chunk.Content,
+			Score:    chunk.Score,
+		})
+	}
+	return contextChunks
+}
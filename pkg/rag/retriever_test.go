@@ -0,0 +1,113 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package rag
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// keywordEmbedder embeds texts as the counts of a fixed list of keywords
+type keywordEmbedder struct {
+	*llm.GeminiProvider
+	keywords []string
+	model    string
+}
+
+func (e *keywordEmbedder) Embed(ctx context.Context, model string, texts []string) ([][]float64, error) {
+	e.model = model
+	embeddings := make([][]float64, len(texts))
+	for i, text := range texts {
+		for _, keyword := range e.keywords {
+			embeddings[i] = append(embeddings[i], float64(strings.Count(text, keyword)))
+		}
+	}
+	return embeddings, nil
+}
+
+func TestVectorRetriever_MemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	chunks := []struct {
+		chunk     RetrievedChunk
+		embedding []float64
+	}{
+		{RetrievedChunk{ID: "go", Content: "Go has goroutines", Metadata: map[string]interface{}{"lang": "go", "title": "Go tour"}}, []float64{1, 0}},
+		{RetrievedChunk{ID: "both", Content: "Go and Rust", Metadata: map[string]interface{}{"lang": "mixed"}}, []float64{1, 1}},
+		{RetrievedChunk{ID: "rust", Content: "Rust has ownership", Metadata: map[string]interface{}{"lang": "rust"}}, []float64{0, 1}},
+	}
+	for _, c := range chunks {
+		if err := store.Add(c.chunk, c.embedding); err != nil {
+			t.Fatalf("Add() failed: %v", err)
+		}
+	}
+	if err := store.Add(RetrievedChunk{ID: "bad"}, []float64{1, 2, 3}); err == nil {
+		t.Error("Expected an embedding of other dimensions to be rejected")
+	}
+
+	if _, err := NewVectorRetriever(store, &llm.GeminiProvider{}, "embed"); err == nil {
+		t.Error("Expected an error for a provider without embeddings")
+	}
+	embedder := &keywordEmbedder{keywords: []string{"go", "rust"}}
+	retriever, err := NewVectorRetriever(store, embedder, "embed")
+	if err != nil {
+		t.Fatalf("NewVectorRetriever() failed: %v", err)
+	}
+
+	results, err := retriever.Retrieve(context.Background(), "go", RetrieveOptions{TopK: 2})
+	if err != nil {
+		t.Fatalf("Retrieve() failed: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != "go" || results[1].ID != "both" || embedder.model != "embed" {
+		t.Errorf("Expected the go chunks, best first, got %+v", results)
+	}
+
+	results, _ = retriever.Retrieve(context.Background(), "go", RetrieveOptions{MinScore: 0.9})
+	if len(results) != 1 || results[0].ID != "go" {
+		t.Errorf("Expected chunks below the minimum score to be dropped, got %+v", results)
+	}
+
+	results, _ = retriever.Retrieve(context.Background(), "go", RetrieveOptions{Filter: map[string]interface{}{"lang": "rust"}})
+	if len(results) != 1 || results[0].ID != "rust" {
+		t.Errorf("Expected the filter to apply, got %+v", results)
+	}
+
+	if _, err := retriever.Retrieve(context.Background(), "", RetrieveOptions{}); err == nil {
+		t.Error("Expected an empty query to be rejected")
+	}
+
+	// Re-adding an ID replaces the chunk
+	store.Add(RetrievedChunk{ID: "rust", Content: "Rust has traits"}, []float64{0, 1})
+	if !store.Delete("both") || store.Delete("both") || store.Len() != 2 {
+		t.Errorf("Expected 2 chunks after replacing and deleting, got %d", store.Len())
+	}
+}
+
+func TestContextChunks(t *testing.T) {
+	chunks := ContextChunks([]RetrievedChunk{
+		{ID: "a", Content: "first", Score: 0.9, Metadata: map[string]interface{}{"title": "From metadata"}},
+		{ID: "b", Content: "second", Score: 0.5, Title: "Explicit", Metadata: map[string]interface{}{"title": "Ignored"}},
+	})
+	if len(chunks) != 2 || chunks[0].SourceID != "a" || chunks[0].Title != "From metadata" || chunks[1].Title != "Explicit" || chunks[1].Score != 0.5 {
+		t.Errorf("Unexpected context chunks: %+v", chunks)
+	}
+}
+
+func TestRetrieverFunc(t *testing.T) {
+	var received RetrieveOptions
+	var retriever Retriever = RetrieverFunc(func(ctx context.Context, query string, options RetrieveOptions) ([]RetrievedChunk, error) {
+		received = options
+		return []RetrievedChunk{{ID: "hit", Content: query}}, nil
+	})
+
+	results, err := retriever.Retrieve(context.Background(), "hello", RetrieveOptions{TopK: 3})
+	if err != nil || len(results) != 1 || results[0].Content != "hello" || received.TopK != 3 {
+		t.Errorf("Unexpected results %+v (%v), options %+v", results, err, received)
+	}
+}
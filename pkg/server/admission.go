@@ -0,0 +1,180 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/sirupsen/logrus"
+)
+
+// AdmissionConfig sheds new agent requests while the host's CPU or memory usage is above a
+// threshold, protecting a single-node deployment from running out of memory under bursts.
+// Requests already admitted run to completion.
+type AdmissionConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// MaxCPUPercent is the CPU usage above which requests are rejected; zero disables the check
+	MaxCPUPercent float64 `json:"max_cpu_percent" yaml:"max_cpu_percent"`
+	// MaxMemoryPercent is the memory usage above which requests are rejected; zero disables the check
+	MaxMemoryPercent float64 `json:"max_memory_percent" yaml:"max_memory_percent"`
+	// Hysteresis is how many percentage points usage must fall below a threshold before
+	// requests are admitted again, so the server does not flap around the threshold
+	Hysteresis float64 `json:"hysteresis" yaml:"hysteresis"`
+	// SampleInterval is how long a reading is reused before the resources are measured again
+	SampleInterval time.Duration `json:"sample_interval" yaml:"sample_interval"`
+}
+
+// DefaultAdmissionConfig returns a disabled admission configuration with usable thresholds
+func DefaultAdmissionConfig() AdmissionConfig {
+	return AdmissionConfig{
+		MaxCPUPercent:    90,
+		MaxMemoryPercent: 90,
+		Hysteresis:       5,
+		SampleInterval:   time.Second,
+	}
+}
+
+// ResourceUsage is a reading of the host's resources
+type ResourceUsage struct {
+	CPUPercent    float64   `json:"cpu_percent"`
+	MemoryPercent float64   `json:"memory_percent"`
+	SampledAt     time.Time `json:"sampled_at"`
+}
+
+// ResourceSampler measures the host's resources
+type ResourceSampler func(ctx context.Context) (ResourceUsage, error)
+
+// SystemResourceSampler measures the CPU usage since the previous call and the memory in use
+func SystemResourceSampler(ctx context.Context) (ResourceUsage, error) {
+	percents, err := cpu.PercentWithContext(ctx, 0, false)
+	if err != nil {
+		return ResourceUsage{}, fmt.Errorf("failed to read CPU usage: %w", err)
+	}
+	memory, err := mem.VirtualMemoryWithContext(ctx)
+	if err != nil {
+		return ResourceUsage{}, fmt.Errorf("failed to read memory usage: %w", err)
+	}
+
+	usage := ResourceUsage{MemoryPercent: memory.UsedPercent, SampledAt: time.Now()}
+	if len(percents) > 0 {
+		usage.CPUPercent = percents[0]
+	}
+	return usage, nil
+}
+
+// AdmissionStatus is the state of an admission controller reported on the health endpoint
+type AdmissionStatus struct {
+	ResourceUsage
+	Overloaded bool `json:"overloaded"`
+}
+
+// AdmissionController decides whether the server accepts new agent requests
+type AdmissionController struct {
+	config  AdmissionConfig
+	sampler ResourceSampler
+	logger  *logrus.Logger
+
+	mu         sync.Mutex
+	usage      ResourceUsage
+	overloaded bool
+}
+
+// NewAdmissionController creates an admission controller; a nil sampler measures the host
+// with SystemResourceSampler
+func NewAdmissionController(config AdmissionConfig, sampler ResourceSampler) *AdmissionController {
+	if sampler == nil {
+		sampler = SystemResourceSampler
+	}
+	return &AdmissionController{
+		config:  config,
+		sampler: sampler,
+		logger:  logrus.New(),
+	}
+}
+
+// Admit reports whether a new request may start. Resources are measured at most once per
+// sample interval; when they cannot be read, the previous decision stands.
+func (ac *AdmissionController) Admit(ctx context.Context) bool {
+	return !ac.Status(ctx).Overloaded
+}
+
+// Status returns the latest reading, measuring the resources again when it is stale
+func (ac *AdmissionController) Status(ctx context.Context) AdmissionStatus {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	if ac.usage.SampledAt.IsZero() || time.Since(ac.usage.SampledAt) >= ac.config.SampleInterval {
+		usage, err := ac.sampler(ctx)
+		if err != nil {
+			ac.logger.WithError(err).Warn("Failed to measure resources for admission control")
+		} else {
+			if usage.SampledAt.IsZero() {
+				usage.SampledAt = time.Now()
+			}
+			ac.usage = usage
+			ac.update()
+		}
+	}
+	return AdmissionStatus{ResourceUsage: ac.usage, Overloaded: ac.overloaded}
+}
+
+// update applies the thresholds to the latest reading: an overloaded controller recovers
+// only once every resource is below its threshold minus the hysteresis
+func (ac *AdmissionController) update() {
+	exceeds := func(value, threshold, margin float64) bool {
+		return threshold > 0 && value >= threshold-margin
+	}
+
+	if ac.overloaded {
+		margin := ac.config.Hysteresis
+		if !exceeds(ac.usage.CPUPercent, ac.config.MaxCPUPercent, margin) &&
+			!exceeds(ac.usage.MemoryPercent, ac.config.MaxMemoryPercent, margin) {
+			ac.overloaded = false
+			ac.logger.WithFields(logrus.Fields{
+				"cpu_percent":    ac.usage.CPUPercent,
+				"memory_percent": ac.usage.MemoryPercent,
+			}).Info("Resources recovered, admitting requests")
+		}
+		return
+	}
+
+	if exceeds(ac.usage.CPUPercent, ac.config.MaxCPUPercent, 0) || exceeds(ac.usage.MemoryPercent, ac.config.MaxMemoryPercent, 0) {
+		ac.overloaded = true
+		ac.logger.WithFields(logrus.Fields{
+			"cpu_percent":    ac.usage.CPUPercent,
+			"memory_percent": ac.usage.MemoryPercent,
+		}).Warn("Resources above admission thresholds, shedding new requests")
+	}
+}
+
+// SetAdmissionController sets the controller shedding agent requests; nil admits every request
+func (s *Server) SetAdmissionController(controller *AdmissionController) {
+	s.admission = controller
+}
+
+// admit wraps a handler starting agent or graph work, rejecting it with 503 while the
+// admission controller reports the server overloaded
+func (s *Server) admit(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.admission != nil && !s.admission.Admit(r.Context()) {
+			retryAfter := s.reconnectAdvice(reconnectReasonOverloaded, 0).RetryAfterMs
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(float64(retryAfter)/1000))))
+			writeProblem(w, r, NewProblem(http.StatusServiceUnavailable, ProblemOverloaded,
+				"The server is overloaded, retry later"))
+			return
+		}
+		handler(w, r)
+	}
+}
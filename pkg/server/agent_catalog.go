@@ -0,0 +1,251 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/agent"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
+)
+
+// AgentCatalogEntry describes a registered agent for clients that render input forms and
+// validate requests before submitting them
+type AgentCatalogEntry struct {
+	ID           string                 `json:"id"`
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	Type         agent.AgentType        `json:"type"`
+	Model        string                 `json:"model,omitempty"`
+	Provider     string                 `json:"provider,omitempty"`
+	InputSchema  map[string]interface{} `json:"input_schema"`
+	OutputSchema map[string]interface{} `json:"output_schema"`
+	Tools        []llm.Function         `json:"tools"`
+	Endpoints    map[string]string      `json:"endpoints"`
+	// Examples are only included in the detail of a single agent
+	Examples []AgentExampleRequest `json:"examples,omitempty"`
+}
+
+// AgentExampleRequest is a request that executes an agent
+type AgentExampleRequest struct {
+	Description string                 `json:"description,omitempty"`
+	Method      string                 `json:"method"`
+	Path        string                 `json:"path"`
+	Body        map[string]interface{} `json:"body"`
+}
+
+// newAgentCatalogEntry builds the catalog entry of an agent. Schemas and the description
+// come from the "input_schema", "output_schema" and "description" metadata when set, and
+// the tools from the registry the agent runs with.
+func newAgentCatalogEntry(id string, config *agent.AgentConfig, metadata map[string]interface{}, toolRegistry *tools.ToolRegistry, endpoints map[string]string) AgentCatalogEntry {
+	defaults := defaultAgentSchema(string(config.Type))
+
+	entry := AgentCatalogEntry{
+		ID:           id,
+		Name:         config.Name,
+		Description:  fmt.Sprintf("%s agent using %s model", config.Type, config.Model),
+		Type:         config.Type,
+		Model:        config.Model,
+		Provider:     config.Provider,
+		InputSchema:  defaults["input"].(map[string]interface{}),
+		OutputSchema: defaults["output"].(map[string]interface{}),
+		Tools:        []llm.Function{},
+		Endpoints:    endpoints,
+	}
+
+	if description, ok := metadata["description"].(string); ok && description != "" {
+		entry.Description = description
+	}
+	if schema, ok := metadata["input_schema"].(map[string]interface{}); ok {
+		entry.InputSchema = schema
+	}
+	if schema, ok := metadata["output_schema"].(map[string]interface{}); ok {
+		entry.OutputSchema = schema
+	}
+
+	if toolRegistry != nil {
+		for _, definition := range toolRegistry.GetDefinitions(config.Tools) {
+			entry.Tools = append(entry.Tools, definition.Function)
+		}
+	}
+
+	return entry
+}
+
+// withExamples adds example requests for the agent's execute endpoint. Examples set in the
+// "examples" metadata are used as request bodies; otherwise one is derived from the input schema.
+func (entry AgentCatalogEntry) withExamples(metadata map[string]interface{}) AgentCatalogEntry {
+	path := entry.Endpoints["execute"]
+
+	if examples, ok := metadata["examples"].([]map[string]interface{}); ok && len(examples) > 0 {
+		for _, body := range examples {
+			entry.Examples = append(entry.Examples, AgentExampleRequest{Method: http.MethodPost, Path: path, Body: body})
+		}
+		return entry
+	}
+
+	entry.Examples = []AgentExampleRequest{{
+		Description: "Minimal request with the required fields",
+		Method:      http.MethodPost,
+		Path:        path,
+		Body:        exampleBody(entry.InputSchema),
+	}}
+	return entry
+}
+
+// exampleBody fills the required properties of an object schema with their example, default
+// or a placeholder value of the property's type
+func exampleBody(schema map[string]interface{}) map[string]interface{} {
+	body := make(map[string]interface{})
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	var required []string
+	switch names := schema["required"].(type) {
+	case []string:
+		required = names
+	case []interface{}:
+		for _, name := range names {
+			if s, ok := name.(string); ok {
+				required = append(required, s)
+			}
+		}
+	}
+
+	for _, name := range required {
+		property, _ := properties[name].(map[string]interface{})
+		body[name] = exampleValue(property)
+	}
+	return body
+}
+
+// exampleValue returns a sample value for a property schema
+func exampleValue(property map[string]interface{}) interface{} {
+	if example, ok := property["example"]; ok {
+		return example
+	}
+	if value, ok := property["default"]; ok {
+		return value
+	}
+	if values, ok := property["enum"].([]interface{}); ok && len(values) > 0 {
+		return values[0]
+	}
+
+	switch property["type"] {
+	case "integer", "number":
+		return 1
+	case "boolean":
+		return true
+	case "array":
+		return []interface{}{}
+	case "object":
+		return exampleBody(property)
+	default:
+		return "Hello"
+	}
+}
+
+// autoAgentEndpoints returns the generated endpoints of an auto-server agent
+func (as *AutoServer) autoAgentEndpoints(agentID string) map[string]string {
+	basePath := fmt.Sprintf("%s/%s", as.config.BasePath, agentID)
+	return map[string]string{
+		"execute":      basePath,
+		"stream":       basePath + "/stream",
+		"conversation": basePath + "/conversation",
+		"status":       basePath + "/status",
+		"schema":       "/schemas/" + agentID,
+	}
+}
+
+// agentCatalogEntry builds the catalog entry of a served auto-server agent
+func (as *AutoServer) agentCatalogEntry(agentID string) (AgentCatalogEntry, map[string]interface{}, bool) {
+	instance, exists := as.agentInstances[agentID]
+	if !exists {
+		return AgentCatalogEntry{}, nil, false
+	}
+	metadata := as.agentMetadata[agentID]
+	return newAgentCatalogEntry(agentID, instance.GetConfig(), metadata, as.toolRegistry, as.autoAgentEndpoints(agentID)), metadata, true
+}
+
+// handleAgentCatalog lists the served agents with their schemas and tools
+func (as *AutoServer) handleAgentCatalog(w http.ResponseWriter, r *http.Request) {
+	ids := make([]string, 0, len(as.agentInstances))
+	for agentID := range as.agentInstances {
+		ids = append(ids, agentID)
+	}
+	sort.Strings(ids)
+
+	agents := make([]AgentCatalogEntry, 0, len(ids))
+	for _, agentID := range ids {
+		if entry, _, ok := as.agentCatalogEntry(agentID); ok {
+			agents = append(agents, entry)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"agents":      agents,
+		"total_count": len(agents),
+		"timestamp":   time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// handleAgentCatalogEntry returns the catalog entry of one agent with example requests
+func (as *AutoServer) handleAgentCatalogEntry(w http.ResponseWriter, r *http.Request) {
+	agentID := mux.Vars(r)["agentId"]
+
+	entry, metadata, ok := as.agentCatalogEntry(agentID)
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "Agent not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry.withExamples(metadata))
+}
+
+// executeRequestSchema describes the body of the standard server's agent execute endpoint
+func executeRequestSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"input": map[string]interface{}{
+				"type":        "string",
+				"description": "Input message for the agent",
+				"minLength":   1,
+			},
+			"session_id":       map[string]interface{}{"type": "string"},
+			"stream":           map[string]interface{}{"type": "boolean"},
+			"soft_deadline_ms": map[string]interface{}{"type": "integer", "minimum": 0},
+			"pin_input":        map[string]interface{}{"type": "boolean"},
+		},
+		"required": []string{"input"},
+	}
+}
+
+// agentCatalogEntry builds the catalog entry of an agent served by the standard server.
+// The input schema is the execute request body, since agents take their input as text.
+func (s *Server) agentCatalogEntry(agentID string, instance *agent.Agent) (AgentCatalogEntry, map[string]interface{}) {
+	basePath := "/api/v1/agents/" + agentID
+	endpoints := map[string]string{
+		"execute":      basePath + "/execute",
+		"history":      basePath + "/history",
+		"conversation": basePath + "/conversation",
+		"stream":       "/api/v1/ws/agents/" + agentID + "/stream",
+	}
+
+	metadata := s.agentManager.definitionMetadata(agentID)
+	entry := newAgentCatalogEntry(agentID, instance.GetConfig(), metadata, s.agentManager.toolRegistry, endpoints)
+	entry.InputSchema = executeRequestSchema()
+	return entry, metadata
+}
@@ -14,6 +14,9 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/agent"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
 )
 
 // handleHealth handles health check requests
@@ -94,7 +97,7 @@ func (as *AutoServer) handleAgentInfo(w http.ResponseWriter, r *http.Request) {
 
 	metadata, exists := as.agentMetadata[agentID]
 	if !exists {
-		http.Error(w, "Agent not found", http.StatusNotFound)
+		writeError(w, r, http.StatusNotFound, "Agent not found")
 		return
 	}
 
@@ -144,20 +147,20 @@ func (as *AutoServer) createAgentHandler(agentID string) http.HandlerFunc {
 
 		agent, exists := as.agentInstances[agentID]
 		if !exists {
-			http.Error(w, "Agent not found", http.StatusNotFound)
+			writeError(w, r, http.StatusNotFound, "Agent not found")
 			return
 		}
 
 		// Parse request body
 		var requestData map[string]interface{}
 		if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			writeBodyError(w, r, err)
 			return
 		}
 
 		// Execute agent
 		start := time.Now()
-		ctx := context.Background()
+		ctx := logging.WithSessionID(context.Background(), requestSessionID(r, requestData))
 
 		// Convert requestData to string
 		var input string
@@ -174,18 +177,9 @@ func (as *AutoServer) createAgentHandler(agentID string) http.HandlerFunc {
 			}
 		}
 
-		result, err := agent.Execute(ctx, input)
+		result, err := agent.ExecuteWithOptions(ctx, input, requestExecuteOptions(r, requestData))
 		if err != nil {
-			response := map[string]interface{}{
-				"success":         false,
-				"agent_id":        agentID,
-				"error":           err.Error(),
-				"processing_time": time.Since(start).String(),
-				"timestamp":       time.Now().UTC().Format(time.RFC3339),
-			}
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(response)
+			writeExecutionError(w, r, err)
 			return
 		}
 
@@ -248,9 +242,9 @@ func (as *AutoServer) createAgentStreamHandler(agentID string) http.HandlerFunc
 			return
 		}
 
-		agent, exists := as.agentInstances[agentID]
+		agentInstance, exists := as.agentInstances[agentID]
 		if !exists {
-			http.Error(w, "Agent not found", http.StatusNotFound)
+			writeError(w, r, http.StatusNotFound, "Agent not found")
 			return
 		}
 
@@ -263,19 +257,19 @@ func (as *AutoServer) createAgentStreamHandler(agentID string) http.HandlerFunc
 		// Parse request body
 		var requestData map[string]interface{}
 		if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			writeBodyError(w, r, err)
 			return
 		}
 
 		// Stream response
 		flusher, ok := w.(http.Flusher)
 		if !ok {
-			http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+			writeError(w, r, http.StatusInternalServerError, "Streaming not supported")
 			return
 		}
 
 		// Execute agent (simplified streaming implementation)
-		ctx := context.Background()
+		ctx := logging.WithSessionID(context.Background(), requestSessionID(r, requestData))
 
 		// Convert requestData to string
 		var input string
@@ -292,23 +286,32 @@ func (as *AutoServer) createAgentStreamHandler(agentID string) http.HandlerFunc
 			}
 		}
 
-		result, err := agent.Execute(ctx, input)
+		events, err := agentInstance.StreamWithOptions(ctx, input, requestExecuteOptions(r, requestData))
 		if err != nil {
 			fmt.Fprintf(w, "data: {\"error\": \"%s\"}\n\n", err.Error())
 			flusher.Flush()
 			return
 		}
 
-		output := result.Output
-		responseData, _ := json.Marshal(map[string]interface{}{
-			"success":  true,
-			"agent_id": agentID,
-			"output":   output,
-			"complete": true,
-		})
-
-		fmt.Fprintf(w, "data: %s\n\n", responseData)
-		flusher.Flush()
+		// Send tokens, in the requested granularity, and tool activity as they happen
+		for event := range events {
+			switch event.Type {
+			case agent.StreamEventError:
+				fmt.Fprintf(w, "data: {\"error\": \"%s\"}\n\n", event.Error)
+			case agent.StreamEventDone:
+				responseData, _ := json.Marshal(map[string]interface{}{
+					"success":  true,
+					"agent_id": agentID,
+					"output":   event.Content,
+					"complete": true,
+				})
+				fmt.Fprintf(w, "data: %s\n\n", responseData)
+			default:
+				eventData, _ := json.Marshal(event)
+				fmt.Fprintf(w, "data: %s\n\n", eventData)
+			}
+			flusher.Flush()
+		}
 	}
 }
 
@@ -317,7 +320,7 @@ func (as *AutoServer) createConversationHandler(agentID string) http.HandlerFunc
 	return func(w http.ResponseWriter, r *http.Request) {
 		agent, exists := as.agentInstances[agentID]
 		if !exists {
-			http.Error(w, "Agent not found", http.StatusNotFound)
+			writeError(w, r, http.StatusNotFound, "Agent not found")
 			return
 		}
 
@@ -338,7 +341,7 @@ func (as *AutoServer) createConversationHandler(agentID string) http.HandlerFunc
 			// Add to conversation
 			var requestData map[string]interface{}
 			if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-				http.Error(w, "Invalid JSON", http.StatusBadRequest)
+				writeBodyError(w, r, err)
 				return
 			}
 
@@ -365,17 +368,44 @@ func (as *AutoServer) createConversationHandler(agentID string) http.HandlerFunc
 			json.NewEncoder(w).Encode(response)
 
 		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		}
 	}
 }
 
+// requestSessionID returns the session ID from the request body, falling back to the request context
+func requestSessionID(r *http.Request, requestData map[string]interface{}) string {
+	if sessionID, ok := requestData[logging.FieldSessionID].(string); ok && sessionID != "" {
+		return sessionID
+	}
+	return logging.SessionID(r.Context())
+}
+
+// requestExecuteOptions returns the execution options of a request: the response locale
+// comes from the "locale" field, falling back to the Accept-Language header
+func requestExecuteOptions(r *http.Request, requestData map[string]interface{}) *agent.ExecuteOptions {
+	locale, _ := requestData["locale"].(string)
+	granularity, _ := requestData["granularity"].(string)
+	return &agent.ExecuteOptions{
+		Locale: requestLocale(r, locale),
+		Stream: agent.StreamOptions{Granularity: agent.StreamGranularity(granularity)},
+	}
+}
+
+// requestLocale returns the locale requested in a body, or else the Accept-Language header
+func requestLocale(r *http.Request, locale string) string {
+	if locale != "" {
+		return locale
+	}
+	return agent.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+}
+
 // createStatusHandler creates a handler for agent status
 func (as *AutoServer) createStatusHandler(agentID string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		agent, exists := as.agentInstances[agentID]
 		if !exists {
-			http.Error(w, "Agent not found", http.StatusNotFound)
+			writeError(w, r, http.StatusNotFound, "Agent not found")
 			return
 		}
 
@@ -455,7 +485,7 @@ func (as *AutoServer) handleAgentSchema(w http.ResponseWriter, r *http.Request)
 
 	metadata, exists := as.agentMetadata[agentID]
 	if !exists {
-		http.Error(w, "Agent not found", http.StatusNotFound)
+		writeError(w, r, http.StatusNotFound, "Agent not found")
 		return
 	}
 
@@ -476,13 +506,13 @@ func (as *AutoServer) handleValidateSchema(w http.ResponseWriter, r *http.Reques
 	agentID := vars["agentId"]
 
 	if _, exists := as.agentMetadata[agentID]; !exists {
-		http.Error(w, "Agent not found", http.StatusNotFound)
+		writeError(w, r, http.StatusNotFound, "Agent not found")
 		return
 	}
 
 	var data map[string]interface{}
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		writeBodyError(w, r, err)
 		return
 	}
 
@@ -533,7 +563,7 @@ func (as *AutoServer) handleAgentMetrics(w http.ResponseWriter, r *http.Request)
 	agentID := vars["agentId"]
 
 	if _, exists := as.agentInstances[agentID]; !exists {
-		http.Error(w, "Agent not found", http.StatusNotFound)
+		writeError(w, r, http.StatusNotFound, "Agent not found")
 		return
 	}
 
@@ -571,9 +601,11 @@ func (as *AutoServer) getAgentCapabilities() []map[string]interface{} {
 }
 
 func (as *AutoServer) generateAgentSchema(agentID string, metadata map[string]interface{}) map[string]interface{} {
-	// Generate a basic schema based on agent type
-	agentType := fmt.Sprintf("%v", metadata["type"])
+	return defaultAgentSchema(fmt.Sprintf("%v", metadata["type"]))
+}
 
+// defaultAgentSchema generates a basic input and output schema based on the agent type
+func defaultAgentSchema(agentType string) map[string]interface{} {
 	schema := map[string]interface{}{
 		"input": map[string]interface{}{
 			"type": "object",
@@ -742,7 +774,7 @@ func (as *AutoServer) generateChatHTML(agents []map[string]string) string {
                 if (data.success) {
                     addAgentMessage(data.output.response || JSON.stringify(data.output));
                 } else {
-                    addErrorMessage('Error: ' + (data.error || 'Unknown error'));
+                    addErrorMessage('Error: ' + (data.detail || 'Unknown error'));
                 }
             } catch (error) {
                 addErrorMessage('Connection error: ' + error.message);
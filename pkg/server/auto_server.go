@@ -8,6 +8,7 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -17,6 +18,7 @@ import (
 
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/agent"
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
 )
 
@@ -33,6 +35,9 @@ type AutoServer struct {
 	agentInstances map[string]*agent.Agent
 	agentMetadata  map[string]map[string]interface{}
 
+	// Agent definitions whose Init hook ran, in initialization order
+	initialized []string
+
 	// Metrics tracking
 	startTime    time.Time
 	requestCount int64
@@ -49,6 +54,7 @@ type AutoServerConfig struct {
 	EnableMetricsAPI bool                   `yaml:"enable_metrics_api" json:"enable_metrics_api"`
 	EnableCORS       bool                   `yaml:"enable_cors" json:"enable_cors"`
 	SchemaValidation bool                   `yaml:"schema_validation" json:"schema_validation"`
+	EnablePreflight  bool                   `yaml:"enable_preflight" json:"enable_preflight"`
 	OllamaEndpoint   string                 `yaml:"ollama_endpoint" json:"ollama_endpoint"`
 	LLMProviders     map[string]interface{} `yaml:"llm_providers" json:"llm_providers"`
 	ServerTimeout    time.Duration          `yaml:"server_timeout" json:"server_timeout"`
@@ -189,6 +195,10 @@ func (as *AutoServer) generateSystemEndpoints() {
 	// Agent info
 	as.router.HandleFunc("/agents/{agentId}", as.handleAgentInfo).Methods("GET", "OPTIONS")
 
+	// Machine-readable agent catalog
+	as.router.HandleFunc("/api/v1/agents", as.handleAgentCatalog).Methods("GET", "OPTIONS")
+	as.router.HandleFunc("/api/v1/agents/{agentId}", as.handleAgentCatalogEntry).Methods("GET", "OPTIONS")
+
 	as.logger.Info("Generated system endpoints")
 }
 
@@ -196,12 +206,29 @@ func (as *AutoServer) generateSystemEndpoints() {
 func (as *AutoServer) generateAgentEndpoints() error {
 	definitions := as.registry.ListDefinitions()
 
+	timeout := as.config.ServerTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
 	for _, agentID := range definitions {
 		definition, exists := as.registry.GetDefinition(agentID)
 		if !exists {
 			continue
 		}
 
+		// Open the agent's resources before serving it
+		if !as.isInitialized(agentID) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			err := agent.InitDefinition(ctx, definition)
+			cancel()
+			if err != nil {
+				as.logger.WithError(err).WithField("agent_id", agentID).Error("Agent failed to initialize, not serving it")
+				continue
+			}
+			as.initialized = append(as.initialized, agentID)
+		}
+
 		// Create agent instance
 		agentInstance, err := as.registry.CreateAgentFromDefinition(agentID, as.llmManager, as.toolRegistry)
 		if err != nil {
@@ -209,6 +236,17 @@ func (as *AutoServer) generateAgentEndpoints() error {
 			continue
 		}
 
+		// Refuse agents whose provider, model or tools are misconfigured
+		if as.config.EnablePreflight {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			err := agentInstance.Preflight(ctx)
+			cancel()
+			if err != nil {
+				as.logger.WithError(err).WithField("agent_id", agentID).Error("Agent failed preflight, not serving it")
+				continue
+			}
+		}
+
 		as.agentInstances[agentID] = agentInstance
 		as.agentMetadata[agentID] = definition.GetMetadata()
 
@@ -233,6 +271,35 @@ func (as *AutoServer) generateAgentEndpoints() error {
 	return nil
 }
 
+// isInitialized reports whether the Init hook of an agent definition already ran
+func (as *AutoServer) isInitialized(agentID string) bool {
+	for _, id := range as.initialized {
+		if id == agentID {
+			return true
+		}
+	}
+	return false
+}
+
+// Close calls the Close hook of every initialized agent definition, in reverse
+// initialization order. Start calls it once the HTTP server has shut down.
+func (as *AutoServer) Close() error {
+	var errs []error
+	for i := len(as.initialized) - 1; i >= 0; i-- {
+		agentID := as.initialized[i]
+		definition, exists := as.registry.GetDefinition(agentID)
+		if !exists {
+			continue
+		}
+		if err := agent.CloseDefinition(definition); err != nil {
+			as.logger.WithError(err).WithField("agent_id", agentID).Error("Failed to close agent")
+			errs = append(errs, fmt.Errorf("agent %s: %w", agentID, err))
+		}
+	}
+	as.initialized = nil
+	return errors.Join(errs...)
+}
+
 // generateWebInterfaces creates web UI endpoints
 func (as *AutoServer) generateWebInterfaces() {
 	// Root handler redirects to chat
@@ -279,8 +346,13 @@ func (as *AutoServer) generateMetricsEndpoints() {
 
 // applyMiddleware applies configured middleware
 func (as *AutoServer) applyMiddleware() {
-	// Always apply metrics middleware
+	// Always apply request IDs, metrics, session correlation and request size middleware
+	as.router.NotFoundHandler = problemHandler(http.StatusNotFound, "No route matches the request")
+	as.router.MethodNotAllowedHandler = problemHandler(http.StatusMethodNotAllowed, "Method not allowed")
+	as.router.Use(requestIDMiddleware)
 	as.router.Use(as.metricsMiddleware())
+	as.router.Use(sessionMiddleware)
+	as.router.Use(maxBytesMiddleware(as.config.MaxRequestSize))
 
 	for _, middleware := range as.config.Middleware {
 		switch middleware {
@@ -296,7 +368,8 @@ func (as *AutoServer) applyMiddleware() {
 	}
 }
 
-// Start starts the auto-server
+// Start starts the auto-server. Agent Init hooks run before the server listens and
+// Close hooks run after it has shut down.
 func (as *AutoServer) Start(ctx context.Context) error {
 	if err := as.GenerateEndpoints(); err != nil {
 		return fmt.Errorf("failed to generate endpoints: %w", err)
@@ -329,7 +402,9 @@ func (as *AutoServer) Start(ctx context.Context) error {
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	return server.Shutdown(shutdownCtx)
+	// Agents are closed only after in-flight requests have drained
+	shutdownErr := server.Shutdown(shutdownCtx)
+	return errors.Join(shutdownErr, as.Close())
 }
 
 // printEndpoints prints all available endpoints
@@ -340,6 +415,8 @@ func (as *AutoServer) printEndpoints() {
 	as.logger.Info("   GET  /capabilities - System capabilities")
 	as.logger.Info("   GET  /agents - List all agents")
 	as.logger.Info("   GET  /agents/{agentId} - Agent information")
+	as.logger.Info("   GET  /api/v1/agents - Agent catalog with schemas and tools")
+	as.logger.Info("   GET  /api/v1/agents/{agentId} - Agent catalog entry with example requests")
 
 	if as.config.EnableWebUI {
 		as.logger.Info("🎨 Web Interfaces:")
@@ -429,7 +506,7 @@ func loggingMiddleware(logger *logrus.Logger) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
 			next.ServeHTTP(w, r)
-			logger.WithFields(logrus.Fields{
+			logging.FromContext(r.Context(), logger).WithFields(logrus.Fields{
 				"method":   r.Method,
 				"path":     r.URL.Path,
 				"duration": time.Since(start),
@@ -445,7 +522,7 @@ func recoveryMiddleware(logger *logrus.Logger) func(http.Handler) http.Handler {
 			defer func() {
 				if err := recover(); err != nil {
 					logger.WithField("error", err).Error("Panic recovered")
-					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					writeError(w, r, http.StatusInternalServerError, "Internal Server Error")
 				}
 			}()
 			next.ServeHTTP(w, r)
@@ -415,6 +415,94 @@ func TestAutoServerAgentInfoEndpoint(t *testing.T) {
 	})
 }
 
+func TestAutoServerAgentCatalog(t *testing.T) {
+	server := NewAutoServer(nil)
+
+	definition := agent.NewBaseAgentDefinition(&agent.AgentConfig{
+		Name:     "CatalogAgent",
+		Type:     agent.AgentTypeChat,
+		Model:    "llama3.2",
+		Provider: "ollama",
+		Tools:    []string{"calculator"},
+	})
+	definition.SetMetadata("description", "Answers arithmetic questions")
+	definition.SetMetadata("input_schema", map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"question":  map[string]interface{}{"type": "string", "example": "What is 2+2?"},
+			"precision": map[string]interface{}{"type": "integer"},
+		},
+		"required": []string{"question", "precision"},
+	})
+	if err := server.RegisterAgent("catalog_test", definition); err != nil {
+		t.Fatalf("Failed to register agent: %v", err)
+	}
+	if err := server.GenerateEndpoints(); err != nil {
+		t.Fatalf("Failed to generate endpoints: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/agents", nil)
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var list struct {
+		Agents []AgentCatalogEntry `json:"agents"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	var found *AgentCatalogEntry
+	for i := range list.Agents {
+		if list.Agents[i].ID == "catalog_test" {
+			found = &list.Agents[i]
+		}
+	}
+	if found == nil {
+		t.Fatalf("Expected catalog_test in the catalog, got %+v", list.Agents)
+	}
+	if found.Description != "Answers arithmetic questions" || found.Endpoints["execute"] != "/api/catalog_test" {
+		t.Errorf("Unexpected catalog entry %+v", found)
+	}
+	if len(found.Tools) != 1 || found.Tools[0].Name != "calculator" || found.Tools[0].Parameters == nil {
+		t.Errorf("Expected the calculator tool definition, got %+v", found.Tools)
+	}
+	if len(found.Examples) != 0 {
+		t.Error("The catalog list should not include examples")
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/agents/catalog_test", nil)
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var entry AgentCatalogEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if entry.InputSchema["required"] == nil {
+		t.Errorf("Expected the declared input schema, got %v", entry.InputSchema)
+	}
+	if len(entry.Examples) != 1 {
+		t.Fatalf("Expected one example request, got %+v", entry.Examples)
+	}
+	body := entry.Examples[0].Body
+	if body["question"] != "What is 2+2?" || body["precision"] != float64(1) {
+		t.Errorf("Unexpected example body %v", body)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/agents/nonexistent", nil)
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
 // Test agent execution endpoint (just check that endpoint exists - full execution testing requires Ollama)
 func TestAutoServerAgentExecutionEndpoint(t *testing.T) {
 	server := NewAutoServer(nil)
@@ -462,6 +550,18 @@ func TestAutoServerAgentExecutionEndpoint(t *testing.T) {
 			t.Errorf("Expected status 400 for invalid JSON, got %d", w.Code)
 		}
 	})
+
+	t.Run("oversized body", func(t *testing.T) {
+		body := `{"message": "` + strings.Repeat("x", int(server.config.MaxRequestSize)) + `"}`
+		req := httptest.NewRequest("POST", "/api/exec_test", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected status 413 for an oversized body, got %d", w.Code)
+		}
+	})
 }
 
 // Test CORS middleware
@@ -606,6 +706,44 @@ func TestAutoServerLifecycle(t *testing.T) {
 	}
 }
 
+func TestAutoServerAgentLifecycleHooks(t *testing.T) {
+	server := NewAutoServer(nil)
+
+	var events []string
+	definition := &lifecycleDefinition{
+		BaseAgentDefinition: agent.NewBaseAgentDefinition(&agent.AgentConfig{
+			Name:     "LifecycleAgent",
+			Type:     agent.AgentTypeChat,
+			Model:    "llama3.2",
+			Provider: "ollama",
+		}),
+		events: &events,
+	}
+	if err := server.RegisterAgent("lifecycle-test", definition); err != nil {
+		t.Fatalf("Expected no error registering agent, got %v", err)
+	}
+
+	// Generating endpoints twice must not initialize the agent twice
+	for i := 0; i < 2; i++ {
+		if err := server.GenerateEndpoints(); err != nil {
+			t.Fatalf("Expected no error generating endpoints, got %v", err)
+		}
+	}
+	if len(events) != 1 || events[0] != "init LifecycleAgent" {
+		t.Fatalf("Expected a single Init call, got %v", events)
+	}
+
+	if err := server.Close(); err != nil {
+		t.Fatalf("Expected no error closing agents, got %v", err)
+	}
+	if err := server.Close(); err != nil {
+		t.Fatalf("Expected no error closing agents twice, got %v", err)
+	}
+	if len(events) != 2 || events[1] != "close LifecycleAgent" {
+		t.Errorf("Expected a single Close call after Init, got %v", events)
+	}
+}
+
 // Benchmark agent registration
 func BenchmarkAutoServerAgentRegistration(b *testing.B) {
 	server := NewAutoServer(nil)
@@ -0,0 +1,317 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/agent"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
+)
+
+// ErrAgentNotFound is returned when an execution targets an agent the server does not serve
+var ErrAgentNotFound = errors.New("agent not found")
+
+// UnattendedExecution is an agent execution no client waits for, such as a scheduled or
+// batch run. Failed executions are retried and then dead-lettered rather than lost.
+type UnattendedExecution struct {
+	// Source names what started the execution, e.g. "scheduler" or "batch"
+	Source    string `json:"source"`
+	AgentID   string `json:"agent_id"`
+	Input     string `json:"input"`
+	SessionID string `json:"session_id,omitempty"`
+}
+
+// DeadLetter is an unattended execution that failed every attempt
+type DeadLetter struct {
+	ID string `json:"id"`
+	UnattendedExecution
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// DeadLetterStore keeps failed unattended executions until they are retried or discarded
+type DeadLetterStore interface {
+	// Add stores a dead letter, replacing one with the same ID
+	Add(ctx context.Context, letter *DeadLetter) error
+
+	// List returns the dead letters, oldest failure first
+	List(ctx context.Context) ([]*DeadLetter, error)
+
+	// Get returns a dead letter, or nil if there is none
+	Get(ctx context.Context, id string) (*DeadLetter, error)
+
+	// Remove deletes a dead letter
+	Remove(ctx context.Context, id string) error
+}
+
+// MemoryDeadLetterStore is an in-process DeadLetterStore
+type MemoryDeadLetterStore struct {
+	letters map[string]*DeadLetter
+	mu      sync.Mutex
+}
+
+// NewMemoryDeadLetterStore creates an in-memory dead-letter store
+func NewMemoryDeadLetterStore() *MemoryDeadLetterStore {
+	return &MemoryDeadLetterStore{
+		letters: make(map[string]*DeadLetter),
+	}
+}
+
+// Add stores a dead letter
+func (s *MemoryDeadLetterStore) Add(ctx context.Context, letter *DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := *letter
+	s.letters[letter.ID] = &stored
+	return nil
+}
+
+// List returns the dead letters, oldest failure first
+func (s *MemoryDeadLetterStore) List(ctx context.Context) ([]*DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	letters := make([]*DeadLetter, 0, len(s.letters))
+	for _, letter := range s.letters {
+		copied := *letter
+		letters = append(letters, &copied)
+	}
+	sort.Slice(letters, func(i, j int) bool {
+		return letters[i].FailedAt.Before(letters[j].FailedAt)
+	})
+	return letters, nil
+}
+
+// Get returns a dead letter
+func (s *MemoryDeadLetterStore) Get(ctx context.Context, id string) (*DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	letter, exists := s.letters[id]
+	if !exists {
+		return nil, nil
+	}
+	copied := *letter
+	return &copied, nil
+}
+
+// Remove deletes a dead letter
+func (s *MemoryDeadLetterStore) Remove(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.letters, id)
+	return nil
+}
+
+// DeadLetterConfig configures the retries of unattended executions before they are
+// dead-lettered
+type DeadLetterConfig struct {
+	// MaxRetries is the number of retries after the first attempt
+	MaxRetries int `json:"max_retries" yaml:"max_retries"`
+	// InitialBackoff is the wait before the first retry; it doubles with each retry
+	InitialBackoff time.Duration `json:"initial_backoff" yaml:"initial_backoff"`
+	// MaxBackoff caps the wait between retries
+	MaxBackoff time.Duration `json:"max_backoff" yaml:"max_backoff"`
+}
+
+// DefaultDeadLetterConfig returns the default retry policy of unattended executions
+func DefaultDeadLetterConfig() DeadLetterConfig {
+	return DeadLetterConfig{
+		MaxRetries:     3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+	}
+}
+
+// backoff returns the wait before a retry, starting from 1
+func (c DeadLetterConfig) backoff(retry int) time.Duration {
+	delay := c.InitialBackoff
+	for i := 1; i < retry && delay < c.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if c.MaxBackoff > 0 && delay > c.MaxBackoff {
+		delay = c.MaxBackoff
+	}
+	return delay
+}
+
+// SetDeadLetterStore replaces the store failed unattended executions are written to
+func (s *Server) SetDeadLetterStore(store DeadLetterStore) {
+	s.deadLetters = store
+}
+
+// ExecuteUnattended runs an agent execution no client waits for, retrying failures with
+// exponential backoff. An execution failing every attempt is written to the dead-letter
+// store, where it can be listed and retried through the API, and its last error returned.
+func (s *Server) ExecuteUnattended(ctx context.Context, job UnattendedExecution) (*agent.AgentExecution, error) {
+	policy := s.config.DeadLetter
+
+	var lastErr error
+	for attempt := 0; attempt <= policy.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(policy.backoff(attempt)):
+			}
+		}
+
+		execution, err := s.runAgent(ctx, job)
+		if err == nil {
+			return execution, nil
+		}
+		lastErr = err
+		// Retrying cannot help a missing agent or an execution whose context has ended
+		if errors.Is(err, ErrAgentNotFound) || ctx.Err() != nil {
+			return nil, s.deadLetter(ctx, &DeadLetter{UnattendedExecution: job, Attempts: attempt + 1}, err)
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"source":   job.Source,
+			"agent_id": job.AgentID,
+			"attempt":  attempt + 1,
+			"error":    err,
+		}).Warn("Unattended execution failed")
+	}
+
+	return nil, s.deadLetter(ctx, &DeadLetter{UnattendedExecution: job, Attempts: policy.MaxRetries + 1}, lastErr)
+}
+
+// runAgent runs one attempt of an unattended execution
+func (s *Server) runAgent(ctx context.Context, job UnattendedExecution) (*agent.AgentExecution, error) {
+	if s.agentManager == nil {
+		return nil, fmt.Errorf("%w: %s", ErrAgentNotFound, job.AgentID)
+	}
+	agentInstance, exists := s.agentManager.GetAgent(job.AgentID)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrAgentNotFound, job.AgentID)
+	}
+
+	ctx = logging.WithSessionID(ctx, job.SessionID)
+	unlock, err := s.lockSession(ctx, job.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return agentInstance.Execute(ctx, job.Input)
+}
+
+// deadLetter records a failed execution, returning its error
+func (s *Server) deadLetter(ctx context.Context, letter *DeadLetter, cause error) error {
+	if letter.ID == "" {
+		letter.ID = uuid.New().String()
+	}
+	letter.Error = cause.Error()
+	letter.FailedAt = time.Now()
+
+	fields := logrus.Fields{
+		"dead_letter_id": letter.ID,
+		"source":         letter.Source,
+		"agent_id":       letter.AgentID,
+		"attempts":       letter.Attempts,
+		"error":          cause,
+	}
+	if s.deadLetters == nil {
+		s.logger.WithFields(fields).Error("Unattended execution failed; no dead-letter store to record it")
+		return cause
+	}
+	// The store outlives the request that failed, so a cancelled context must not lose it
+	if err := s.deadLetters.Add(context.WithoutCancel(ctx), letter); err != nil {
+		s.logger.WithFields(fields).WithField("store_error", err).Error("Failed to dead-letter unattended execution")
+		return cause
+	}
+	s.logger.WithFields(fields).Error("Dead-lettered unattended execution")
+	return cause
+}
+
+// handleListDeadLetters lists the dead-lettered executions
+func (s *Server) handleListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if s.deadLetters == nil {
+		writeError(w, r, http.StatusNotFound, "Dead-letter store not configured")
+		return
+	}
+	letters, err := s.deadLetters.List(r.Context())
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"dead_letters": letters,
+		"count":        len(letters),
+	})
+}
+
+// handleRetryDeadLetter runs a dead-lettered execution once more. It is removed on success;
+// on failure it stays with its attempt count and error updated.
+func (s *Server) handleRetryDeadLetter(w http.ResponseWriter, r *http.Request) {
+	letter, ok := s.findDeadLetter(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
+	defer cancel()
+
+	execution, err := s.runAgent(ctx, letter.UnattendedExecution)
+	if err != nil {
+		letter.Attempts++
+		writeExecutionError(w, r, s.deadLetter(ctx, letter, err))
+		return
+	}
+	if err := s.deadLetters.Remove(r.Context(), letter.ID); err != nil {
+		s.logger.WithError(err).WithField("dead_letter_id", letter.ID).Warn("Failed to remove retried dead letter")
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"execution": execution,
+	})
+}
+
+// handleDeleteDeadLetter discards a dead-lettered execution
+func (s *Server) handleDeleteDeadLetter(w http.ResponseWriter, r *http.Request) {
+	letter, ok := s.findDeadLetter(w, r)
+	if !ok {
+		return
+	}
+	if err := s.deadLetters.Remove(r.Context(), letter.ID); err != nil {
+		writeError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findDeadLetter returns the dead letter named in the route, writing the error response
+// when there is none
+func (s *Server) findDeadLetter(w http.ResponseWriter, r *http.Request) (*DeadLetter, bool) {
+	if s.deadLetters == nil {
+		writeError(w, r, http.StatusNotFound, "Dead-letter store not configured")
+		return nil, false
+	}
+	letter, err := s.deadLetters.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	if letter == nil {
+		writeError(w, r, http.StatusNotFound, "Dead letter not found")
+		return nil, false
+	}
+	return letter, true
+}
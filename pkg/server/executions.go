@@ -0,0 +1,145 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// ExecutionIDHeader carries the ID of an agent execution. Clients may choose the ID of an
+// execution with it, to cancel the execution before its response arrives; otherwise the
+// server generates one. The response of the execution includes it.
+const ExecutionIDHeader = "X-Execution-ID"
+
+// ErrExecutionCancelled is returned for an execution cancelled with CancelExecution
+var ErrExecutionCancelled = errors.New("execution cancelled")
+
+// ErrExecutionExists is returned when a client reuses the ID of an execution in flight
+var ErrExecutionExists = errors.New("an execution with this id is in flight")
+
+// ActiveExecution describes an execution in flight
+type ActiveExecution struct {
+	ID        string    `json:"id"`
+	AgentID   string    `json:"agent_id"`
+	SessionID string    `json:"session_id,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// runningExecution is an execution in flight with the function cancelling it
+type runningExecution struct {
+	ActiveExecution
+	cancel context.CancelCauseFunc
+}
+
+// executionTracker holds the executions in flight by ID
+type executionTracker struct {
+	running map[string]*runningExecution
+	mu      sync.Mutex
+}
+
+// trackExecution registers an execution and returns its context, cancelled by
+// CancelExecution, and the function unregistering it once done
+func (s *Server) trackExecution(ctx context.Context, execution ActiveExecution) (context.Context, func(), error) {
+	s.executions.mu.Lock()
+	defer s.executions.mu.Unlock()
+
+	if s.executions.running == nil {
+		s.executions.running = make(map[string]*runningExecution)
+	}
+	if _, exists := s.executions.running[execution.ID]; exists {
+		return ctx, nil, ErrExecutionExists
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	running := &runningExecution{ActiveExecution: execution, cancel: cancel}
+	s.executions.running[execution.ID] = running
+	return ctx, func() {
+		s.executions.mu.Lock()
+		if s.executions.running[execution.ID] == running {
+			delete(s.executions.running, execution.ID)
+		}
+		s.executions.mu.Unlock()
+		cancel(nil)
+	}, nil
+}
+
+// CancelExecution cancels an execution in flight, stopping its LLM and tool calls. It
+// reports whether the execution was found.
+func (s *Server) CancelExecution(id string) bool {
+	s.executions.mu.Lock()
+	running, exists := s.executions.running[id]
+	s.executions.mu.Unlock()
+
+	if !exists {
+		return false
+	}
+	running.cancel(ErrExecutionCancelled)
+	return true
+}
+
+// ActiveExecutions returns the executions in flight, oldest first
+func (s *Server) ActiveExecutions() []ActiveExecution {
+	s.executions.mu.Lock()
+	defer s.executions.mu.Unlock()
+
+	executions := make([]ActiveExecution, 0, len(s.executions.running))
+	for _, running := range s.executions.running {
+		executions = append(executions, running.ActiveExecution)
+	}
+	sort.Slice(executions, func(i, j int) bool {
+		return executions[i].StartedAt.Before(executions[j].StartedAt)
+	})
+	return executions
+}
+
+// requestExecutionID returns the execution ID chosen by the client, or a new one
+func requestExecutionID(r *http.Request) (string, bool) {
+	id := r.Header.Get(ExecutionIDHeader)
+	if id == "" {
+		return uuid.New().String(), true
+	}
+	return id, validRequestID(id)
+}
+
+// cancellationError returns ErrExecutionCancelled for an execution cancelled with
+// CancelExecution, and err otherwise
+func cancellationError(ctx context.Context, err error) error {
+	if cause := context.Cause(ctx); errors.Is(cause, ErrExecutionCancelled) {
+		return cause
+	}
+	return err
+}
+
+// handleListExecutions lists the executions in flight
+func (s *Server) handleListExecutions(w http.ResponseWriter, r *http.Request) {
+	executions := s.ActiveExecutions()
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"executions": executions,
+		"count":      len(executions),
+	})
+}
+
+// handleCancelExecution cancels an execution in flight
+func (s *Server) handleCancelExecution(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if !s.CancelExecution(id) {
+		writeError(w, r, http.StatusNotFound, "No execution in flight with this id")
+		return
+	}
+	s.writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"execution_id": id,
+		"status":       "cancelling",
+	})
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/agent"
+)
+
+// SetInteractionRecorder sets the recorder that feedback on executions is attached to; use
+// the recorder set on the agents' AgentConfig.InteractionRecorder
+func (s *Server) SetInteractionRecorder(recorder *agent.InteractionRecorder) {
+	s.interactionRecorder = recorder
+}
+
+// handleExecutionFeedback attaches a client's rating to the recorded interaction of an
+// execution, identified by the execution ID
+func (s *Server) handleExecutionFeedback(w http.ResponseWriter, r *http.Request) {
+	if s.interactionRecorder == nil {
+		writeError(w, r, http.StatusNotFound, "Interaction recording not enabled")
+		return
+	}
+
+	var feedback agent.InteractionFeedback
+	if err := json.NewDecoder(r.Body).Decode(&feedback); err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	executionID := mux.Vars(r)["id"]
+	if err := s.interactionRecorder.Feedback(r.Context(), executionID, feedback); err != nil {
+		if errors.Is(err, agent.ErrInteractionNotFound) {
+			writeError(w, r, http.StatusNotFound, "No recorded interaction awaits feedback for this execution")
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"execution_id": executionID,
+		"feedback":     feedback,
+	})
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+)
+
+// graphStreamBufferSize is the number of node results buffered for a slow client before
+// the graph execution waits
+const graphStreamBufferSize = 16
+
+// RegisterGraph makes a graph available to the graph endpoints under its ID
+func (s *Server) RegisterGraph(graph *core.Graph) {
+	s.graphsMu.Lock()
+	defer s.graphsMu.Unlock()
+	s.graphs[graph.ID] = graph
+}
+
+// getGraph returns a registered graph, falling back to the graph of an agent with the ID
+func (s *Server) getGraph(id string) (*core.Graph, bool) {
+	s.graphsMu.RLock()
+	graph, exists := s.graphs[id]
+	s.graphsMu.RUnlock()
+	if exists {
+		return graph, true
+	}
+
+	if s.agentManager != nil {
+		if agentInstance, exists := s.agentManager.GetAgent(id); exists {
+			return agentInstance.GetGraph(), true
+		}
+	}
+	return nil, false
+}
+
+// listGraphs returns the IDs of the registered graphs
+func (s *Server) listGraphs() []string {
+	s.graphsMu.RLock()
+	defer s.graphsMu.RUnlock()
+
+	ids := make([]string, 0, len(s.graphs))
+	for id := range s.graphs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// handleStreamGraph runs a graph and streams the state after every node as server-sent
+// "state" events, followed by a "done" event with the final state or an "error" event.
+// A client disconnect cancels the execution. A stream ended by a server shutdown or an
+// overload ends with a "reconnect" event whose retry field advises the reconnection delay.
+func (s *Server) handleStreamGraph(w http.ResponseWriter, r *http.Request) {
+	graphID := mux.Vars(r)["id"]
+	graph, exists := s.getGraph(graphID)
+	if !exists {
+		writeError(w, r, http.StatusNotFound, "Graph not found")
+		return
+	}
+
+	var request struct {
+		Input string                 `json:"input"`
+		State map[string]interface{} `json:"state"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, r, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	state := core.NewBaseState()
+	for key, value := range request.State {
+		state.Set(key, value)
+	}
+	if request.Input != "" {
+		state.Set("input", request.Input)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// The execution stops when the client disconnects or the server stops
+	ctx, cancel := s.streamContext(r.Context())
+	defer cancel()
+	results, errs := graph.ExecuteStream(ctx, state, graphStreamBufferSize)
+
+	steps := 0
+	final := state
+	for result := range results {
+		steps++
+		final = result.State
+		writeSSEEvent(w, "state", map[string]interface{}{
+			"graph_id":  graphID,
+			"step":      steps,
+			"node_id":   result.NodeID,
+			"duration":  result.Duration.String(),
+			"timestamp": result.Timestamp,
+			"state":     result.State.GetAll(),
+		})
+		flusher.Flush()
+	}
+
+	if err := <-errs; err != nil {
+		if r.Context().Err() != nil {
+			s.logger.WithField("graph_id", graphID).Info("Graph stream client disconnected")
+			return
+		}
+		if advice, transient := s.reconnectAdviceFor(err); transient {
+			writeSSEReconnect(w, advice)
+			flusher.Flush()
+			return
+		}
+		writeSSEEvent(w, "error", map[string]interface{}{
+			"graph_id": graphID,
+			"step":     steps,
+			"error":    err.Error(),
+		})
+		flusher.Flush()
+		return
+	}
+
+	writeSSEEvent(w, "done", map[string]interface{}{
+		"graph_id":  graphID,
+		"steps":     steps,
+		"state":     final.GetAll(),
+		"timestamp": time.Now(),
+	})
+	flusher.Flush()
+}
+
+// writeSSEEvent writes a named server-sent event with a JSON payload
+func writeSSEEvent(w io.Writer, event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		payload, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+}
@@ -0,0 +1,347 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
+)
+
+const (
+	// IdempotencyKeyHeader carries the client-chosen key that identifies a retried request
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses served from the idempotency store
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// IdempotentResponse is a stored response replayed for repeated requests
+type IdempotentResponse struct {
+	StatusCode  int         `json:"status_code"`
+	Header      http.Header `json:"header"`
+	Body        []byte      `json:"body"`
+	RequestHash string      `json:"request_hash"`
+}
+
+// IdempotencyStore stores responses by idempotency key
+type IdempotencyStore interface {
+	// Get returns the stored response for a key, or nil if there is none
+	Get(ctx context.Context, key string) (*IdempotentResponse, error)
+
+	// Reserve marks a key as in progress; it returns false if the key is already in use
+	Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+	// Save stores the response for a reserved key
+	Save(ctx context.Context, key string, response *IdempotentResponse, ttl time.Duration) error
+
+	// Release drops a reservation so the request can be retried
+	Release(ctx context.Context, key string) error
+}
+
+// memoryIdempotencyEntry is a reservation (nil response) or a stored response
+type memoryIdempotencyEntry struct {
+	response  *IdempotentResponse
+	expiresAt time.Time
+}
+
+// memoryIdempotencySweepInterval is how often writes to a MemoryIdempotencyStore remove the
+// expired entries of keys that are never looked up again
+const memoryIdempotencySweepInterval = time.Minute
+
+// MemoryIdempotencyStore is an in-process IdempotencyStore
+type MemoryIdempotencyStore struct {
+	entries   map[string]*memoryIdempotencyEntry
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+// NewMemoryIdempotencyStore creates an in-memory idempotency store
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		entries: make(map[string]*memoryIdempotencyEntry),
+	}
+}
+
+// Get returns the stored response for a key
+func (s *MemoryIdempotencyStore) Get(ctx context.Context, key string) (*IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.entry(key)
+	if entry == nil {
+		return nil, nil
+	}
+	return entry.response, nil
+}
+
+// Reserve marks a key as in progress
+func (s *MemoryIdempotencyStore) Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.entry(key) != nil {
+		return false, nil
+	}
+	s.sweep()
+	s.entries[key] = &memoryIdempotencyEntry{expiresAt: time.Now().Add(ttl)}
+	return true, nil
+}
+
+// Save stores the response for a key
+func (s *MemoryIdempotencyStore) Save(ctx context.Context, key string, response *IdempotentResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+	s.entries[key] = &memoryIdempotencyEntry{response: response, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+// Release drops a key
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// entry returns a live entry, removing it if it has expired. Callers hold the lock.
+func (s *MemoryIdempotencyStore) entry(key string) *memoryIdempotencyEntry {
+	entry, exists := s.entries[key]
+	if !exists {
+		return nil
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(s.entries, key)
+		return nil
+	}
+	return entry
+}
+
+// sweep removes every expired entry, at most once per sweep interval. Callers hold the lock.
+func (s *MemoryIdempotencyStore) sweep() {
+	now := time.Now()
+	if now.Sub(s.lastSweep) < memoryIdempotencySweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// redisIdempotencyPending marks a reserved key in Redis
+const redisIdempotencyPending = "pending"
+
+// RedisIdempotencyStore is an IdempotencyStore shared between server instances through Redis
+type RedisIdempotencyStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisIdempotencyStore creates a Redis idempotency store; keys are namespaced by prefix
+func NewRedisIdempotencyStore(client *redis.Client, prefix string) *RedisIdempotencyStore {
+	if prefix == "" {
+		prefix = "idempotency:"
+	}
+	return &RedisIdempotencyStore{client: client, prefix: prefix}
+}
+
+// Get returns the stored response for a key
+func (s *RedisIdempotencyStore) Get(ctx context.Context, key string) (*IdempotentResponse, error) {
+	data, err := s.client.Get(ctx, s.prefix+key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotent response: %w", err)
+	}
+	if string(data) == redisIdempotencyPending {
+		return nil, nil
+	}
+
+	var response IdempotentResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode idempotent response: %w", err)
+	}
+	return &response, nil
+}
+
+// Reserve marks a key as in progress
+func (s *RedisIdempotencyStore) Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	reserved, err := s.client.SetNX(ctx, s.prefix+key, redisIdempotencyPending, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	return reserved, nil
+}
+
+// Save stores the response for a key
+func (s *RedisIdempotencyStore) Save(ctx context.Context, key string, response *IdempotentResponse, ttl time.Duration) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotent response: %w", err)
+	}
+	if err := s.client.Set(ctx, s.prefix+key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release drops a key
+func (s *RedisIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.prefix+key).Err()
+}
+
+// SetIdempotencyStore replaces the store used for Idempotency-Key requests
+func (s *Server) SetIdempotencyStore(store IdempotencyStore) {
+	s.idempotencyStore = store
+}
+
+// responseRecorder captures a response so it can be stored for replay. Flushed responses
+// are streams and stop being captured.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+	streamed   bool
+}
+
+func (rr *responseRecorder) WriteHeader(statusCode int) {
+	rr.statusCode = statusCode
+	rr.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rr *responseRecorder) Write(data []byte) (int, error) {
+	if rr.statusCode == 0 {
+		rr.statusCode = http.StatusOK
+	}
+	if !rr.streamed {
+		rr.body.Write(data)
+	}
+	return rr.ResponseWriter.Write(data)
+}
+
+// Flush sends the buffered response to the client, as streaming handlers do after each event
+func (rr *responseRecorder) Flush() {
+	rr.streamed = true
+	rr.body.Reset()
+	if flusher, ok := rr.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// replayable reports whether a response may be stored for replay. Server errors and
+// transient client errors such as 429 are retried instead, and streams cannot be replayed.
+func (rr *responseRecorder) replayable() bool {
+	switch {
+	case rr.statusCode == 0 || rr.statusCode >= http.StatusInternalServerError:
+		return false
+	case rr.statusCode == http.StatusRequestTimeout || rr.statusCode == http.StatusConflict ||
+		rr.statusCode == http.StatusTooEarly || rr.statusCode == http.StatusTooManyRequests:
+		return false
+	case rr.streamed || strings.HasPrefix(rr.Header().Get("Content-Type"), "text/event-stream"):
+		return false
+	}
+	return true
+}
+
+// idempotencyMiddleware replays the stored response for POST requests that repeat an
+// Idempotency-Key. Reusing a key with a different request body is rejected.
+func (s *Server) idempotencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idempotencyKey := r.Header.Get(IdempotencyKeyHeader)
+		if idempotencyKey == "" || r.Method != http.MethodPost || s.idempotencyStore == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeBodyError(w, r, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(hash[:])
+		key := r.Method + " " + r.URL.Path + " " + idempotencyKey
+		ttl := s.config.IdempotencyTTL
+		if ttl <= 0 {
+			ttl = 24 * time.Hour
+		}
+		ctx := r.Context()
+		logger := logging.FromContext(ctx, s.logger).WithField("idempotency_key", idempotencyKey)
+
+		stored, err := s.idempotencyStore.Get(ctx, key)
+		if err != nil {
+			logger.WithError(err).Warn("Idempotency store unavailable, processing request")
+			next.ServeHTTP(w, r)
+			return
+		}
+		if stored != nil {
+			if stored.RequestHash != requestHash {
+				writeError(w, r, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request")
+				return
+			}
+			for name, values := range stored.Header {
+				w.Header()[name] = values
+			}
+			w.Header().Set(IdempotentReplayedHeader, "true")
+			w.WriteHeader(stored.StatusCode)
+			w.Write(stored.Body)
+			return
+		}
+
+		reserved, err := s.idempotencyStore.Reserve(ctx, key, ttl)
+		if err != nil {
+			logger.WithError(err).Warn("Idempotency store unavailable, processing request")
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !reserved {
+			writeError(w, r, http.StatusConflict, "A request with this Idempotency-Key is already in progress")
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		storeCtx := context.WithoutCancel(ctx)
+		if !recorder.replayable() {
+			if err := s.idempotencyStore.Release(storeCtx, key); err != nil {
+				logger.WithError(err).Warn("Failed to release idempotency key")
+			}
+			return
+		}
+
+		response := &IdempotentResponse{
+			StatusCode:  recorder.statusCode,
+			Header:      w.Header().Clone(),
+			Body:        recorder.body.Bytes(),
+			RequestHash: requestHash,
+		}
+		if err := s.idempotencyStore.Save(storeCtx, key, response, ttl); err != nil {
+			logger.WithError(err).Warn("Failed to store idempotent response")
+		}
+	})
+}
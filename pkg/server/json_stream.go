@@ -0,0 +1,59 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/agent"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
+)
+
+// structuredStream collects the answer of an execution streamed as JSON, so a usable object
+// can be sent even when the stream is cut short
+type structuredStream struct {
+	partial strings.Builder
+}
+
+// add records the tokens of the answer
+func (s *structuredStream) add(event agent.StreamEvent) {
+	if event.Type == agent.StreamEventToken {
+		s.partial.WriteString(event.Content)
+	}
+}
+
+// result is the final event of the stream: complete with the object when the answer, or
+// what was streamed of it, is or can be repaired into JSON, incomplete otherwise
+func (s *structuredStream) result(execution *agent.AgentExecution, interrupted bool) WSStructured {
+	text := s.partial.String()
+	if !interrupted && execution != nil && execution.Output != "" {
+		text = execution.Output
+	}
+
+	repaired, err := tools.RepairPartialJSON(text)
+	var object interface{}
+	if err == nil {
+		err = json.Unmarshal([]byte(repaired), &object)
+	}
+	if err != nil {
+		return WSStructured{
+			WSEnvelope: WSEnvelope{Type: WSTypeIncomplete},
+			Partial:    text,
+			Error:      err.Error(),
+			Timestamp:  time.Now(),
+		}
+	}
+	return WSStructured{
+		WSEnvelope:  WSEnvelope{Type: WSTypeComplete},
+		Object:      object,
+		Repaired:    !json.Valid([]byte(strings.TrimSpace(text))),
+		Interrupted: interrupted,
+		Timestamp:   time.Now(),
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/agent"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
+)
+
+// ProblemContentType is the media type of error responses (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// RequestIDHeader carries the ID of a request; a valid ID sent by the client is reused,
+// otherwise the server generates one. Every response and problem document includes it.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the length of a request ID accepted from a client
+const maxRequestIDLength = 128
+
+// ProblemTypeBase prefixes the type URI of every problem code
+const ProblemTypeBase = "https://golanggraph.dev/problems/"
+
+// Problem codes. The type URI of a problem is ProblemTypeBase followed by its code; codes
+// are stable, so clients can branch on them.
+const (
+	ProblemInvalidRequest        = "invalid-request"
+	ProblemNotFound              = "not-found"
+	ProblemMethodNotAllowed      = "method-not-allowed"
+	ProblemConflict              = "conflict"
+	ProblemRequestTooLarge       = "request-too-large"
+	ProblemUnprocessable         = "unprocessable-request"
+	ProblemRateLimited           = "rate-limited"
+	ProblemContextLengthExceeded = "context-length-exceeded"
+	ProblemUnsupportedFeature    = "unsupported-feature"
+	ProblemAgentNotReady         = "agent-not-ready"
+	ProblemServiceUnavailable    = "service-unavailable"
+	ProblemOverloaded            = "overloaded"
+	ProblemTimeout               = "timeout"
+	ProblemStreamStalled         = "stream-stalled"
+	ProblemEmptyResponse         = "empty-response"
+	ProblemContentBlocked        = "content-blocked"
+	ProblemExecutionCancelled    = "execution-cancelled"
+	ProblemInternal              = "internal-error"
+)
+
+// problemTitles are the short, human-readable summaries of the problem codes
+var problemTitles = map[string]string{
+	ProblemInvalidRequest:        "Invalid request",
+	ProblemNotFound:              "Resource not found",
+	ProblemMethodNotAllowed:      "Method not allowed",
+	ProblemConflict:              "Conflict",
+	ProblemRequestTooLarge:       "Request body too large",
+	ProblemUnprocessable:         "Unprocessable request",
+	ProblemRateLimited:           "Rate limit exceeded",
+	ProblemContextLengthExceeded: "Context length exceeded",
+	ProblemUnsupportedFeature:    "Feature not supported",
+	ProblemAgentNotReady:         "Agent not ready",
+	ProblemServiceUnavailable:    "Service unavailable",
+	ProblemOverloaded:            "Server overloaded",
+	ProblemTimeout:               "Request timed out",
+	ProblemStreamStalled:         "Stream stalled",
+	ProblemEmptyResponse:         "Empty model response",
+	ProblemContentBlocked:        "Response blocked by content filter",
+	ProblemExecutionCancelled:    "Execution cancelled",
+	ProblemInternal:              "Internal server error",
+}
+
+// Problem is an RFC 7807 problem document
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// NewProblem creates the problem document of a code
+func NewProblem(status int, code, detail string) *Problem {
+	title, exists := problemTitles[code]
+	if !exists {
+		title = http.StatusText(status)
+	}
+	return &Problem{
+		Type:   ProblemTypeBase + code,
+		Title:  title,
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// problemCodeForStatus returns the problem code of an error without a more specific cause
+func problemCodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return ProblemInvalidRequest
+	case http.StatusNotFound:
+		return ProblemNotFound
+	case http.StatusMethodNotAllowed:
+		return ProblemMethodNotAllowed
+	case http.StatusConflict:
+		return ProblemConflict
+	case http.StatusRequestEntityTooLarge:
+		return ProblemRequestTooLarge
+	case http.StatusUnprocessableEntity:
+		return ProblemUnprocessable
+	case http.StatusTooManyRequests:
+		return ProblemRateLimited
+	case http.StatusServiceUnavailable:
+		return ProblemServiceUnavailable
+	case http.StatusGatewayTimeout:
+		return ProblemTimeout
+	default:
+		return ProblemInternal
+	}
+}
+
+// problemForError maps the agent and LLM errors to their status and problem code
+func problemForError(err error) (int, string) {
+	var unsupportedErr *llm.UnsupportedFeatureError
+	var preflightErr *agent.PreflightError
+	var emptyErr *agent.EmptyResponseError
+	switch {
+	case errors.Is(err, agent.ErrRateLimited):
+		return http.StatusTooManyRequests, ProblemRateLimited
+	case errors.Is(err, ErrSessionBusy), errors.Is(err, ErrExecutionExists):
+		return http.StatusConflict, ProblemConflict
+	case errors.Is(err, ErrExecutionCancelled):
+		return http.StatusConflict, ProblemExecutionCancelled
+	case errors.Is(err, ErrAgentNotFound):
+		return http.StatusNotFound, ProblemNotFound
+	case errors.Is(err, llm.ErrContextLengthExceeded):
+		return http.StatusUnprocessableEntity, ProblemContextLengthExceeded
+	case errors.As(err, &unsupportedErr):
+		return http.StatusUnprocessableEntity, ProblemUnsupportedFeature
+	case errors.As(err, &preflightErr):
+		return http.StatusServiceUnavailable, ProblemAgentNotReady
+	case errors.Is(err, llm.ErrContentBlocked), errors.As(err, &emptyErr) && emptyErr.Blocked:
+		return http.StatusUnprocessableEntity, ProblemContentBlocked
+	case errors.Is(err, agent.ErrEmptyResponse):
+		return http.StatusBadGateway, ProblemEmptyResponse
+	case errors.Is(err, llm.ErrStreamStalled):
+		return http.StatusGatewayTimeout, ProblemStreamStalled
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, ProblemTimeout
+	default:
+		return http.StatusInternalServerError, ProblemInternal
+	}
+}
+
+// writeProblem writes a problem document for the request; every error response of the
+// servers goes through it
+func writeProblem(w http.ResponseWriter, r *http.Request, problem *Problem) {
+	if r != nil {
+		problem.Instance = r.URL.Path
+		problem.RequestID = logging.RequestID(r.Context())
+	}
+	if problem.RequestID == "" {
+		problem.RequestID = w.Header().Get(RequestIDHeader)
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// writeError writes the problem document of a status code
+func writeError(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	writeProblem(w, r, NewProblem(status, problemCodeForStatus(status), detail))
+}
+
+// writeExecutionError writes the problem document of a failed agent execution; a
+// rate-limited execution also tells the client when to retry
+func writeExecutionError(w http.ResponseWriter, r *http.Request, err error) {
+	var rateLimitErr *agent.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rateLimitErr.RetryAfter.Seconds()))))
+	}
+	status, code := problemForError(err)
+	writeProblem(w, r, NewProblem(status, code, err.Error()))
+}
+
+// writeBodyError writes the problem document for a request body that could not be decoded
+func writeBodyError(w http.ResponseWriter, r *http.Request, err error) {
+	if limit, tooLarge := isBodyTooLarge(err); tooLarge {
+		writeBodyTooLarge(w, r, limit)
+		return
+	}
+	writeError(w, r, http.StatusBadRequest, "Invalid request body")
+}
+
+// writeBodyTooLarge writes the 413 problem document for an oversized request body
+func writeBodyTooLarge(w http.ResponseWriter, r *http.Request, limit int64) {
+	writeError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body exceeds %d bytes", limit))
+}
+
+// requestIDMiddleware assigns every request an ID, echoed in the X-Request-ID response
+// header and bound to the request context for problem documents and log correlation
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), requestID)))
+	})
+}
+
+// validRequestID reports whether a client-supplied request ID is safe to reuse
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestID {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// problemHandler answers the requests no route matched with a problem document
+func problemHandler(status int, detail string) http.Handler {
+	return requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, status, detail)
+	}))
+}
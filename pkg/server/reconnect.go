@@ -0,0 +1,141 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/agent"
+)
+
+// Reasons a stream is ended with reconnection advice
+const (
+	reconnectReasonShutdown   = "server_shutdown"
+	reconnectReasonOverloaded = "overloaded"
+)
+
+// ReconnectPolicy is the reconnection delay advised to streaming clients when the server ends
+// a stream for a transient reason, such as a shutdown or an overload. Each client is advised
+// the base delay plus a random jitter, so clients do not all reconnect at the same moment.
+type ReconnectPolicy struct {
+	BaseDelay time.Duration `json:"base_delay"`
+	MaxJitter time.Duration `json:"max_jitter"`
+}
+
+// DefaultReconnectPolicy returns the default reconnection advice
+func DefaultReconnectPolicy() ReconnectPolicy {
+	return ReconnectPolicy{
+		BaseDelay: 2 * time.Second,
+		MaxJitter: 3 * time.Second,
+	}
+}
+
+// Delay returns a jittered reconnection delay of at least minimum
+func (p ReconnectPolicy) Delay(minimum time.Duration) time.Duration {
+	delay := p.BaseDelay
+	if p.MaxJitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(p.MaxJitter)))
+	}
+	if delay < minimum {
+		delay = minimum
+	}
+	return delay
+}
+
+// reconnectAdvice is the payload telling a streaming client when to reconnect
+type reconnectAdvice struct {
+	Reason       string `json:"reason"`
+	RetryAfterMs int64  `json:"retry_after_ms"`
+}
+
+// reconnectAdviceFor returns the advice for a stream ended by an execution error, or false
+// when the error is not transient
+func (s *Server) reconnectAdviceFor(err error) (reconnectAdvice, bool) {
+	if s.isClosing() {
+		return s.reconnectAdvice(reconnectReasonShutdown, 0), true
+	}
+
+	var rateLimitErr *agent.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return s.reconnectAdvice(reconnectReasonOverloaded, rateLimitErr.RetryAfter), true
+	}
+	return reconnectAdvice{}, false
+}
+
+// reconnectAdvice builds the advice for a reason with the configured policy
+func (s *Server) reconnectAdvice(reason string, minimum time.Duration) reconnectAdvice {
+	policy := s.config.Reconnect
+	if policy == (ReconnectPolicy{}) {
+		policy = DefaultReconnectPolicy()
+	}
+	delay := policy.Delay(minimum)
+	return reconnectAdvice{Reason: reason, RetryAfterMs: delay.Milliseconds()}
+}
+
+// writeSSEReconnect writes a "reconnect" event whose retry field sets the client's
+// reconnection delay
+func writeSSEReconnect(w io.Writer, advice reconnectAdvice) {
+	payload, _ := json.Marshal(advice)
+	fmt.Fprintf(w, "retry: %d\nevent: reconnect\ndata: %s\n\n", advice.RetryAfterMs, payload)
+}
+
+// closeWebSocket closes a WebSocket with a close code for the reason and the advice as its
+// JSON close reason
+func closeWebSocket(conn *websocket.Conn, advice reconnectAdvice) error {
+	code := websocket.CloseTryAgainLater
+	if advice.Reason == reconnectReasonShutdown {
+		code = websocket.CloseServiceRestart
+	}
+
+	payload, _ := json.Marshal(advice)
+	message := websocket.FormatCloseMessage(code, string(payload))
+	return conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+}
+
+// isClosing reports whether Stop was called
+func (s *Server) isClosing() bool {
+	select {
+	case <-s.closing:
+		return true
+	default:
+		return false
+	}
+}
+
+// streamContext returns a context for a streamed execution, cancelled when the client
+// disconnects or the server stops
+func (s *Server) streamContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-s.closing:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// closeWebSockets closes the open WebSockets, advising clients when to reconnect
+func (s *Server) closeWebSockets() {
+	s.wsConnectionsMu.Lock()
+	defer s.wsConnectionsMu.Unlock()
+
+	for id, conn := range s.wsConnections {
+		if err := closeWebSocket(conn, s.reconnectAdvice(reconnectReasonShutdown, 0)); err != nil {
+			s.logger.WithError(err).WithField("connection", id).Warn("Failed to close WebSocket")
+		}
+	}
+}
@@ -9,8 +9,11 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -20,11 +23,19 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/agent"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/persistence"
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
 )
 
+// SessionIDHeader is the request header used to correlate logs with a conversation session
+const SessionIDHeader = "X-Session-ID"
+
+// DefaultPreflightTimeout bounds the preflight of an agent, which may call its provider
+const DefaultPreflightTimeout = 30 * time.Second
+
 // ServerConfig represents server configuration
 type ServerConfig struct {
 	Host           string        `json:"host"`
@@ -36,6 +47,38 @@ type ServerConfig struct {
 	StaticDir      string        `json:"static_dir"`
 	DevMode        bool          `json:"dev_mode"`
 	LogLevel       string        `json:"log_level"`
+	IdempotencyTTL time.Duration `json:"idempotency_ttl"`
+
+	// MaxRequestBytes limits request bodies and WebSocket messages; zero disables the limit
+	MaxRequestBytes int64 `json:"max_request_bytes"`
+
+	// PreflightTimeout bounds the preflight of created and registered agents
+	PreflightTimeout time.Duration `json:"preflight_timeout"`
+
+	// Reconnect is the reconnection delay advised to streaming clients on shutdown or overload
+	Reconnect ReconnectPolicy `json:"reconnect"`
+
+	// Admission sheds new agent requests while CPU or memory usage is too high
+	Admission AdmissionConfig `json:"admission"`
+
+	// WorkQueue runs executions on a worker pool, interactive requests first
+	WorkQueue WorkQueueConfig `json:"work_queue"`
+
+	// AllowConcurrentPerSession lets executions of the same session run at once. By default
+	// they are serialized, so interleaved messages cannot corrupt the conversation history.
+	AllowConcurrentPerSession bool `json:"allow_concurrent_per_session"`
+	// RejectBusySession answers 409 Conflict to an execution of a busy session instead of
+	// queuing it
+	RejectBusySession bool `json:"reject_busy_session"`
+
+	// SessionStore selects the session locker; a Redis store shares the locks between replicas
+	SessionStore SessionStoreConfig `json:"session_store"`
+
+	// TLS serves HTTPS, requiring client certificates when a client CA is set; nil serves HTTP
+	TLS *TLSConfig `json:"tls,omitempty"`
+
+	// DeadLetter sets the retries of unattended executions before they are dead-lettered
+	DeadLetter DeadLetterConfig `json:"dead_letter"`
 }
 
 // DefaultServerConfig returns default server configuration
@@ -50,6 +93,15 @@ func DefaultServerConfig() *ServerConfig {
 		StaticDir:      "./static",
 		DevMode:        false,
 		LogLevel:       "info",
+		IdempotencyTTL: 24 * time.Hour,
+
+		MaxRequestBytes: 10 << 20, // 10MB
+		Reconnect:       DefaultReconnectPolicy(),
+		Admission:       DefaultAdmissionConfig(),
+		WorkQueue:       DefaultWorkQueueConfig(),
+		DeadLetter:      DefaultDeadLetterConfig(),
+
+		PreflightTimeout: DefaultPreflightTimeout,
 	}
 }
 
@@ -67,9 +119,46 @@ type Server struct {
 	agentManager   *AgentManager
 	sessionManager *persistence.SessionManager
 
+	// Sheds agent requests under resource pressure; nil admits every request
+	admission *AdmissionController
+
+	// Schedules executions by priority; nil runs them immediately
+	queue *WorkQueue
+
+	// Serializes the executions of a session
+	sessionLocker SessionLocker
+	// Why the configured session store could not be used; Start fails with it
+	sessionStoreErr error
+
+	// Certificates served over HTTPS, reloaded by ReloadCertificates
+	certificates *certificateStore
+
+	// Stored responses for requests with an Idempotency-Key
+	idempotencyStore IdempotencyStore
+
+	// Unattended executions that failed every attempt
+	deadLetters DeadLetterStore
+
+	// Recorded interactions that clients rate through the feedback endpoint
+	interactionRecorder *agent.InteractionRecorder
+
+	// Agent executions in flight, cancelled by ID
+	executions executionTracker
+
+	// Metrics served at /metrics after the tool metrics
+	metricsSources []MetricsSource
+
+	// Graphs served by the graph endpoints
+	graphs   map[string]*core.Graph
+	graphsMu sync.RWMutex
+
 	// WebSocket connections
 	wsConnections   map[string]*websocket.Conn
 	wsConnectionsMu sync.RWMutex
+
+	// Closed when Stop is called, ending streams with reconnection advice
+	closing   chan struct{}
+	closeOnce sync.Once
 }
 
 // NewServer creates a new server
@@ -79,10 +168,14 @@ func NewServer(config *ServerConfig) *Server {
 	}
 
 	server := &Server{
-		config:        config,
-		router:        mux.NewRouter(),
-		logger:        logrus.New(),
-		wsConnections: make(map[string]*websocket.Conn),
+		config:           config,
+		router:           mux.NewRouter(),
+		logger:           logrus.New(),
+		idempotencyStore: NewMemoryIdempotencyStore(),
+		deadLetters:      NewMemoryDeadLetterStore(),
+		graphs:           make(map[string]*core.Graph),
+		wsConnections:    make(map[string]*websocket.Conn),
+		closing:          make(chan struct{}),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true // Allow all origins for development
@@ -90,6 +183,21 @@ func NewServer(config *ServerConfig) *Server {
 		},
 	}
 
+	if config.Admission.Enabled {
+		server.admission = NewAdmissionController(config.Admission, nil)
+	}
+	if config.WorkQueue.Enabled {
+		server.queue = NewWorkQueue(config.WorkQueue)
+	}
+
+	// Falling back to in-memory locks would silently stop serializing sessions across replicas
+	locker, err := NewSessionLocker(config.SessionStore)
+	if err != nil {
+		server.logger.WithError(err).Error("Session store unavailable")
+		server.sessionStoreErr = fmt.Errorf("session store: %w", err)
+	}
+	server.sessionLocker = locker
+
 	server.setupRoutes()
 	return server
 }
@@ -106,6 +214,9 @@ func (s *Server) SetToolRegistry(registry *tools.ToolRegistry) {
 
 // SetAgentManager sets the agent manager
 func (s *Server) SetAgentManager(manager *AgentManager) {
+	if manager != nil && s.config.PreflightTimeout > 0 {
+		manager.SetPreflightTimeout(s.config.PreflightTimeout)
+	}
 	s.agentManager = manager
 }
 
@@ -116,25 +227,35 @@ func (s *Server) SetSessionManager(manager *persistence.SessionManager) {
 
 // setupRoutes sets up HTTP routes
 func (s *Server) setupRoutes() {
+	// Requests no route matches get problem documents too
+	s.router.NotFoundHandler = problemHandler(http.StatusNotFound, "No route matches the request")
+	s.router.MethodNotAllowedHandler = problemHandler(http.StatusMethodNotAllowed, "Method not allowed")
+
 	// Enable CORS if configured
 	if s.config.EnableCORS {
 		s.router.Use(s.corsMiddleware)
 	}
 
 	// Middleware
+	s.router.Use(requestIDMiddleware)
+	s.router.Use(sessionMiddleware)
 	s.router.Use(s.loggingMiddleware)
 	s.router.Use(s.authMiddleware)
+	s.router.Use(maxBytesMiddleware(s.config.MaxRequestBytes))
+	s.router.Use(s.idempotencyMiddleware)
 
 	// API routes
 	api := s.router.PathPrefix("/api/v1").Subrouter()
 
 	// Health check
 	api.HandleFunc("/health", s.handleHealth).Methods("GET", "OPTIONS")
+	api.HandleFunc("/queue", s.handleQueueStats).Methods("GET")
 
 	// LLM providers
 	api.HandleFunc("/providers", s.handleListProviders).Methods("GET")
 	api.HandleFunc("/providers/{name}/models", s.handleGetProviderModels).Methods("GET")
 	api.HandleFunc("/providers/{name}/health", s.handleProviderHealth).Methods("GET")
+	api.HandleFunc("/providers/{name}/capabilities", s.handleProviderCapabilities).Methods("GET")
 
 	// Agents
 	api.HandleFunc("/agents", s.handleListAgents).Methods("GET")
@@ -142,19 +263,31 @@ func (s *Server) setupRoutes() {
 	api.HandleFunc("/agents/{id}", s.handleGetAgent).Methods("GET")
 	api.HandleFunc("/agents/{id}", s.handleUpdateAgent).Methods("PUT")
 	api.HandleFunc("/agents/{id}", s.handleDeleteAgent).Methods("DELETE")
-	api.HandleFunc("/agents/{id}/execute", s.handleExecuteAgent).Methods("POST")
+	api.HandleFunc("/agents/{id}/execute", s.admit(s.schedule(PriorityHigh, s.handleExecuteAgent))).Methods("POST")
 	api.HandleFunc("/agents/{id}/history", s.handleGetAgentHistory).Methods("GET")
+	api.HandleFunc("/agents/{id}/conversation", s.handleGetAgentConversation).Methods("GET")
+	api.HandleFunc("/agents/{id}/conversation/{index}/pin", s.handlePinAgentMessage).Methods("PUT")
+	api.HandleFunc("/executions", s.handleListExecutions).Methods("GET")
+	api.HandleFunc("/executions/{id}", s.handleCancelExecution).Methods("DELETE")
+	api.HandleFunc("/executions/{id}/feedback", s.handleExecutionFeedback).Methods("POST")
 
 	// Graphs
 	api.HandleFunc("/graphs", s.handleListGraphs).Methods("GET")
 	api.HandleFunc("/graphs/{id}", s.handleGetGraph).Methods("GET")
 	api.HandleFunc("/graphs/{id}/topology", s.handleGetGraphTopology).Methods("GET")
-	api.HandleFunc("/graphs/{id}/execute", s.handleExecuteGraph).Methods("POST")
+	api.HandleFunc("/graphs/{id}/execute", s.admit(s.schedule(PriorityNormal, s.handleExecuteGraph))).Methods("POST")
+	api.HandleFunc("/graphs/{id}/stream", s.admit(s.schedule(PriorityNormal, s.handleStreamGraph))).Methods("POST")
 	api.HandleFunc("/graphs/{id}/interrupt", s.handleInterruptGraph).Methods("POST")
 
+	// Dead-lettered unattended executions
+	api.HandleFunc("/dead-letters", s.handleListDeadLetters).Methods("GET")
+	api.HandleFunc("/dead-letters/{id}", s.handleDeleteDeadLetter).Methods("DELETE")
+	api.HandleFunc("/dead-letters/{id}/retry", s.admit(s.schedule(PriorityLow, s.handleRetryDeadLetter))).Methods("POST")
+
 	// Sessions and threads
 	api.HandleFunc("/sessions", s.handleCreateSession).Methods("POST")
 	api.HandleFunc("/sessions/{id}", s.handleGetSession).Methods("GET")
+	api.HandleFunc("/sessions/{id}/export", s.handleExportSession).Methods("GET")
 	api.HandleFunc("/threads", s.handleCreateThread).Methods("POST")
 	api.HandleFunc("/threads/{id}", s.handleGetThread).Methods("GET")
 	api.HandleFunc("/threads/{id}/checkpoints", s.handleListCheckpoints).Methods("GET")
@@ -162,10 +295,14 @@ func (s *Server) setupRoutes() {
 	// Tools
 	api.HandleFunc("/tools", s.handleListTools).Methods("GET")
 	api.HandleFunc("/tools/{name}", s.handleGetTool).Methods("GET")
+	api.HandleFunc("/tools/{name}/metrics", s.handleGetToolMetrics).Methods("GET")
+
+	// Prometheus metrics
+	s.router.HandleFunc("/metrics", s.handlePrometheusMetrics).Methods("GET")
 
 	// WebSocket endpoints
-	api.HandleFunc("/ws/agents/{id}/stream", s.handleAgentWebSocket)
-	api.HandleFunc("/ws/graphs/{id}/stream", s.handleGraphWebSocket)
+	api.HandleFunc("/ws/agents/{id}/stream", s.admit(s.handleAgentWebSocket))
+	api.HandleFunc("/ws/graphs/{id}/stream", s.admit(s.handleGraphWebSocket))
 
 	// Dev mode specific routes
 	if s.config.DevMode {
@@ -191,6 +328,10 @@ func (s *Server) setupRoutes() {
 
 // Start starts the server
 func (s *Server) Start() error {
+	if err := s.checkSessionStore(); err != nil {
+		return err
+	}
+
 	s.server = &http.Server{
 		Addr:           fmt.Sprintf("%s:%d", s.config.Host, s.config.Port),
 		Handler:        s.router,
@@ -199,18 +340,45 @@ func (s *Server) Start() error {
 		MaxHeaderBytes: s.config.MaxHeaderBytes,
 	}
 
+	if s.config.TLS.Enabled() {
+		certificates, err := newCertificateStore(*s.config.TLS)
+		if err != nil {
+			return err
+		}
+		s.certificates = certificates
+		s.server.TLSConfig = certificates.tlsConfig()
+	}
+
 	s.logger.WithFields(logrus.Fields{
 		"host": s.config.Host,
 		"port": s.config.Port,
+		"tls":  s.certificates != nil,
+		"mtls": s.config.TLS.Enabled() && s.config.TLS.ClientCAFile != "",
 	}).Info("Starting GoLangGraph server")
 
+	if s.certificates != nil {
+		return s.server.ListenAndServeTLS("", "")
+	}
 	return s.server.ListenAndServe()
 }
 
-// Stop stops the server
+// Stop stops the server. Agents registered from definitions are closed after the HTTP
+// server has shut down and in-flight requests have drained.
 func (s *Server) Stop(ctx context.Context) error {
 	s.logger.Info("Stopping GoLangGraph server")
-	return s.server.Shutdown(ctx)
+
+	// Streams end first, telling clients when to reconnect, so Shutdown does not wait on them
+	s.closeOnce.Do(func() { close(s.closing) })
+	s.closeWebSockets()
+
+	err := s.server.Shutdown(ctx)
+	if s.agentManager != nil {
+		if closeErr := s.agentManager.Close(); closeErr != nil {
+			s.logger.WithError(closeErr).Error("Failed to close agents")
+			err = errors.Join(err, closeErr)
+		}
+	}
+	return err
 }
 
 // Middleware
@@ -235,7 +403,7 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 		start := time.Now()
 		next.ServeHTTP(w, r)
 
-		s.logger.WithFields(logrus.Fields{
+		logging.FromContext(r.Context(), s.logger).WithFields(logrus.Fields{
 			"method":   r.Method,
 			"path":     r.URL.Path,
 			"duration": time.Since(start),
@@ -244,6 +412,50 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// sessionMiddleware binds the caller's session ID to the request context for log correlation
+func sessionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sessionID := r.Header.Get(SessionIDHeader)
+		if sessionID == "" {
+			sessionID = r.URL.Query().Get(logging.FieldSessionID)
+		}
+
+		if sessionID != "" {
+			r = r.WithContext(logging.WithSessionID(r.Context(), sessionID))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// maxBytesMiddleware rejects request bodies larger than limit with 413 Request Entity Too Large
+func maxBytesMiddleware(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if limit <= 0 || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.ContentLength > limit {
+				writeBodyTooLarge(w, r, limit)
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isBodyTooLarge reports whether reading a request body failed because of the size limit
+func isBodyTooLarge(err error) (int64, bool) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return maxBytesErr.Limit, true
+	}
+	return 0, false
+}
+
 func (s *Server) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Simple authentication - in production, implement proper JWT/OAuth
@@ -274,13 +486,24 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 		health["providers"] = providerHealth
 	}
 
+	if s.admission != nil {
+		status := s.admission.Status(r.Context())
+		health["resources"] = status
+		if status.Overloaded {
+			health["status"] = "overloaded"
+		}
+	}
+	if s.queue != nil {
+		health["queue"] = s.queue.Stats()
+	}
+
 	s.writeJSON(w, http.StatusOK, health)
 }
 
 // Provider handlers
 func (s *Server) handleListProviders(w http.ResponseWriter, r *http.Request) {
 	if s.llmManager == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "LLM manager not available")
+		writeError(w, r, http.StatusServiceUnavailable, "LLM manager not available")
 		return
 	}
 
@@ -292,7 +515,7 @@ func (s *Server) handleListProviders(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleGetProviderModels(w http.ResponseWriter, r *http.Request) {
 	if s.llmManager == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "LLM manager not available")
+		writeError(w, r, http.StatusServiceUnavailable, "LLM manager not available")
 		return
 	}
 
@@ -304,7 +527,7 @@ func (s *Server) handleGetProviderModels(w http.ResponseWriter, r *http.Request)
 
 	models, err := s.llmManager.GetProviderModels(ctx, providerName)
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -316,7 +539,7 @@ func (s *Server) handleGetProviderModels(w http.ResponseWriter, r *http.Request)
 
 func (s *Server) handleProviderHealth(w http.ResponseWriter, r *http.Request) {
 	if s.llmManager == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "LLM manager not available")
+		writeError(w, r, http.StatusServiceUnavailable, "LLM manager not available")
 		return
 	}
 
@@ -325,7 +548,7 @@ func (s *Server) handleProviderHealth(w http.ResponseWriter, r *http.Request) {
 
 	provider, err := s.llmManager.GetProvider(providerName)
 	if err != nil {
-		s.writeError(w, http.StatusNotFound, err.Error())
+		writeError(w, r, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -345,34 +568,109 @@ func (s *Server) handleProviderHealth(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (s *Server) handleProviderCapabilities(w http.ResponseWriter, r *http.Request) {
+	if s.llmManager == nil {
+		writeError(w, r, http.StatusServiceUnavailable, "LLM manager not available")
+		return
+	}
+
+	vars := mux.Vars(r)
+	providerName := vars["name"]
+	model := r.URL.Query().Get("model")
+
+	capabilities, err := s.llmManager.ModelCapabilities(providerName, model)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"provider":     providerName,
+		"model":        model,
+		"capabilities": capabilities,
+	})
+}
+
+// checkFeatures returns an error if the provider model lacks any of the requested features
+func (s *Server) checkFeatures(providerName, model string, features ...llm.Feature) error {
+	if s.llmManager == nil || len(features) == 0 {
+		return nil
+	}
+
+	var unsupported *llm.UnsupportedFeatureError
+	if err := s.llmManager.RequireFeatures(providerName, model, features...); errors.As(err, &unsupported) {
+		return err
+	}
+	return nil
+}
+
+// preflightAgent runs the agent preflight checks and logs why a configuration is refused
+func (s *Server) preflightAgent(ctx context.Context, config *agent.AgentConfig) error {
+	if err := s.agentManager.Preflight(ctx, config); err != nil {
+		s.logger.WithError(err).WithField("agent", config.Name).Warn("Agent failed preflight")
+		return err
+	}
+	return nil
+}
+
+// requiredFeatures returns the provider features an agent configuration depends on
+func requiredFeatures(config *agent.AgentConfig) []llm.Feature {
+	var features []llm.Feature
+	if config.EnableStreaming {
+		features = append(features, llm.FeatureStreaming)
+	}
+	return features
+}
+
 // Agent handlers
 func (s *Server) handleListAgents(w http.ResponseWriter, r *http.Request) {
 	if s.agentManager == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Agent manager not available")
+		writeError(w, r, http.StatusServiceUnavailable, "Agent manager not available")
 		return
 	}
 
 	agents := s.agentManager.ListAgents()
+	sort.Strings(agents)
+
+	catalog := make([]AgentCatalogEntry, 0, len(agents))
+	for _, agentID := range agents {
+		if instance, exists := s.agentManager.GetAgent(agentID); exists {
+			entry, _ := s.agentCatalogEntry(agentID, instance)
+			catalog = append(catalog, entry)
+		}
+	}
+
 	s.writeJSON(w, http.StatusOK, map[string]interface{}{
-		"agents": agents,
+		"agents":  agents,
+		"catalog": catalog,
 	})
 }
 
 func (s *Server) handleCreateAgent(w http.ResponseWriter, r *http.Request) {
 	if s.agentManager == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Agent manager not available")
+		writeError(w, r, http.StatusServiceUnavailable, "Agent manager not available")
 		return
 	}
 
 	var config agent.AgentConfig
 	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeBodyError(w, r, err)
+		return
+	}
+
+	if err := s.checkFeatures(config.Provider, config.Model, requiredFeatures(&config)...); err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.preflightAgent(r.Context(), &config); err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	agentInstance, err := s.agentManager.CreateAgent(&config)
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -383,7 +681,7 @@ func (s *Server) handleCreateAgent(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleGetAgent(w http.ResponseWriter, r *http.Request) {
 	if s.agentManager == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Agent manager not available")
+		writeError(w, r, http.StatusServiceUnavailable, "Agent manager not available")
 		return
 	}
 
@@ -392,18 +690,20 @@ func (s *Server) handleGetAgent(w http.ResponseWriter, r *http.Request) {
 
 	agentInstance, exists := s.agentManager.GetAgent(agentID)
 	if !exists {
-		s.writeError(w, http.StatusNotFound, "Agent not found")
+		writeError(w, r, http.StatusNotFound, "Agent not found")
 		return
 	}
 
+	entry, metadata := s.agentCatalogEntry(agentID, agentInstance)
 	s.writeJSON(w, http.StatusOK, map[string]interface{}{
-		"agent": agentInstance.GetConfig(),
+		"agent":   agentInstance.GetConfig(),
+		"catalog": entry.withExamples(metadata),
 	})
 }
 
 func (s *Server) handleExecuteAgent(w http.ResponseWriter, r *http.Request) {
 	if s.agentManager == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Agent manager not available")
+		writeError(w, r, http.StatusServiceUnavailable, "Agent manager not available")
 		return
 	}
 
@@ -411,29 +711,86 @@ func (s *Server) handleExecuteAgent(w http.ResponseWriter, r *http.Request) {
 	agentID := vars["id"]
 
 	var request struct {
-		Input  string `json:"input"`
-		Stream bool   `json:"stream"`
+		Input     string `json:"input"`
+		Stream    bool   `json:"stream"`
+		SessionID string `json:"session_id"`
+		// SoftDeadlineMS returns the best partial answer after this many milliseconds
+		SoftDeadlineMS int `json:"soft_deadline_ms"`
+		// PinInput keeps the input in context however long the conversation grows
+		PinInput bool `json:"pin_input"`
+		// Locale is the response language, defaulting to the Accept-Language header
+		Locale string `json:"locale"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeBodyError(w, r, err)
 		return
 	}
 
 	agentInstance, exists := s.agentManager.GetAgent(agentID)
 	if !exists {
-		s.writeError(w, http.StatusNotFound, "Agent not found")
+		writeError(w, r, http.StatusNotFound, "Agent not found")
+		return
+	}
+
+	if request.Stream {
+		config := agentInstance.GetConfig()
+		if err := s.checkFeatures(config.Provider, config.Model, llm.FeatureStreaming); err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	executionID, valid := requestExecutionID(r)
+	if !valid {
+		writeError(w, r, http.StatusBadRequest, "Invalid "+ExecutionIDHeader+" header")
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
+	ctx, cancel := context.WithTimeout(logging.WithSessionID(r.Context(), request.SessionID), 5*time.Minute)
 	defer cancel()
 
-	execution, err := agentInstance.Execute(ctx, request.Input)
+	// Executions of a session run one at a time, so their messages do not interleave
+	sessionID := request.SessionID
+	if sessionID == "" {
+		sessionID = logging.SessionID(r.Context())
+	}
+
+	// The execution can be cancelled by its ID from the moment it waits for its session
+	ctx, untrack, err := s.trackExecution(ctx, ActiveExecution{
+		ID:        executionID,
+		AgentID:   agentID,
+		SessionID: sessionID,
+		StartedAt: time.Now(),
+	})
+	if err != nil {
+		writeExecutionError(w, r, err)
+		return
+	}
+	defer untrack()
+	w.Header().Set(ExecutionIDHeader, executionID)
+
+	unlock, err := s.lockSession(ctx, sessionID)
+	if err != nil {
+		writeExecutionError(w, r, cancellationError(ctx, err))
+		return
+	}
+	defer unlock()
+
+	options := &agent.ExecuteOptions{
+		SoftDeadline: time.Duration(request.SoftDeadlineMS) * time.Millisecond,
+		PinInput:     request.PinInput,
+		Locale:       requestLocale(r, request.Locale),
+		ExecutionID:  executionID,
+	}
+	execution, err := agentInstance.ExecuteWithOptions(ctx, request.Input, options)
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		writeExecutionError(w, r, cancellationError(ctx, err))
 		return
 	}
+	if request.SessionID != "" {
+		s.titleSessionThread(ctx, agentInstance, request.SessionID, execution)
+	}
 
 	s.writeJSON(w, http.StatusOK, map[string]interface{}{
 		"execution": execution,
@@ -442,7 +799,7 @@ func (s *Server) handleExecuteAgent(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleUpdateAgent(w http.ResponseWriter, r *http.Request) {
 	if s.agentManager == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Agent manager not available")
+		writeError(w, r, http.StatusServiceUnavailable, "Agent manager not available")
 		return
 	}
 
@@ -451,27 +808,39 @@ func (s *Server) handleUpdateAgent(w http.ResponseWriter, r *http.Request) {
 
 	var config agent.AgentConfig
 	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeBodyError(w, r, err)
 		return
 	}
 
 	// Ensure the ID matches
 	config.ID = agentID
 
+	if err := s.checkFeatures(config.Provider, config.Model, requiredFeatures(&config)...); err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.preflightAgent(r.Context(), &config); err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	agentInstance, err := s.agentManager.CreateAgent(&config)
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	entry, metadata := s.agentCatalogEntry(agentID, agentInstance)
 	s.writeJSON(w, http.StatusOK, map[string]interface{}{
-		"agent": agentInstance.GetConfig(),
+		"agent":   agentInstance.GetConfig(),
+		"catalog": entry.withExamples(metadata),
 	})
 }
 
 func (s *Server) handleDeleteAgent(w http.ResponseWriter, r *http.Request) {
 	if s.agentManager == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Agent manager not available")
+		writeError(w, r, http.StatusServiceUnavailable, "Agent manager not available")
 		return
 	}
 
@@ -486,7 +855,7 @@ func (s *Server) handleDeleteAgent(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleGetAgentHistory(w http.ResponseWriter, r *http.Request) {
 	if s.agentManager == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Agent manager not available")
+		writeError(w, r, http.StatusServiceUnavailable, "Agent manager not available")
 		return
 	}
 
@@ -495,7 +864,7 @@ func (s *Server) handleGetAgentHistory(w http.ResponseWriter, r *http.Request) {
 
 	agentInstance, exists := s.agentManager.GetAgent(agentID)
 	if !exists {
-		s.writeError(w, http.StatusNotFound, "Agent not found")
+		writeError(w, r, http.StatusNotFound, "Agent not found")
 		return
 	}
 
@@ -507,10 +876,81 @@ func (s *Server) handleGetAgentHistory(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (s *Server) handleGetAgentConversation(w http.ResponseWriter, r *http.Request) {
+	if s.agentManager == nil {
+		writeError(w, r, http.StatusServiceUnavailable, "Agent manager not available")
+		return
+	}
+
+	agentID := mux.Vars(r)["id"]
+	agentInstance, exists := s.agentManager.GetAgent(agentID)
+	if !exists {
+		writeError(w, r, http.StatusNotFound, "Agent not found")
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"agent_id": agentID,
+		"messages": agentInstance.GetConversation(),
+	})
+}
+
+func (s *Server) handlePinAgentMessage(w http.ResponseWriter, r *http.Request) {
+	if s.agentManager == nil {
+		writeError(w, r, http.StatusServiceUnavailable, "Agent manager not available")
+		return
+	}
+
+	vars := mux.Vars(r)
+	agentID := vars["id"]
+	index, err := strconv.Atoi(vars["index"])
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "Message index must be an integer")
+		return
+	}
+
+	request := struct {
+		Pinned bool `json:"pinned"`
+	}{Pinned: true}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeBodyError(w, r, err)
+			return
+		}
+	}
+
+	agentInstance, exists := s.agentManager.GetAgent(agentID)
+	if !exists {
+		writeError(w, r, http.StatusNotFound, "Agent not found")
+		return
+	}
+	if err := agentInstance.PinMessage(index, request.Pinned); err != nil {
+		writeError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	// Store the pin with the messages of the session's thread so it outlives the process
+	if sessionID := logging.SessionID(r.Context()); sessionID != "" && s.sessionManager != nil {
+		session, err := s.sessionManager.GetSession(r.Context(), sessionID)
+		if err == nil && session.ThreadID != "" {
+			if err := s.sessionManager.PinMessage(r.Context(), session.ThreadID, index, request.Pinned); err != nil {
+				s.logger.WithError(err).WithField("thread_id", session.ThreadID).Error("Failed to store message pin")
+				writeError(w, r, http.StatusInternalServerError, "Failed to store message pin")
+				return
+			}
+		}
+	}
+
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"agent_id": agentID,
+		"index":    index,
+		"pinned":   request.Pinned,
+	})
+}
+
 func (s *Server) handleListGraphs(w http.ResponseWriter, r *http.Request) {
-	// For now, return empty list - would need graph manager
 	s.writeJSON(w, http.StatusOK, map[string]interface{}{
-		"graphs": []string{},
+		"graphs": s.listGraphs(),
 	})
 }
 
@@ -549,7 +989,7 @@ func (s *Server) handleExecuteGraph(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeBodyError(w, r, err)
 		return
 	}
 
@@ -574,7 +1014,7 @@ func (s *Server) handleInterruptGraph(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
 	if s.sessionManager == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Session manager not available")
+		writeError(w, r, http.StatusServiceUnavailable, "Session manager not available")
 		return
 	}
 
@@ -583,7 +1023,7 @@ func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeBodyError(w, r, err)
 		return
 	}
 
@@ -599,7 +1039,7 @@ func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
 
 	err := s.sessionManager.CreateSession(ctx, session)
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -610,7 +1050,7 @@ func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleGetSession(w http.ResponseWriter, r *http.Request) {
 	if s.sessionManager == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Session manager not available")
+		writeError(w, r, http.StatusServiceUnavailable, "Session manager not available")
 		return
 	}
 
@@ -622,7 +1062,7 @@ func (s *Server) handleGetSession(w http.ResponseWriter, r *http.Request) {
 
 	session, err := s.sessionManager.GetSession(ctx, sessionID)
 	if err != nil {
-		s.writeError(w, http.StatusNotFound, err.Error())
+		writeError(w, r, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -631,9 +1071,127 @@ func (s *Server) handleGetSession(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (s *Server) handleExportSession(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["id"]
+
+	format, err := persistence.ParseExportFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	// The session record is optional; the messages come from the agent executions
+	var session *persistence.Session
+	if s.sessionManager != nil {
+		session, _ = s.sessionManager.GetSession(ctx, sessionID)
+	}
+
+	conversation := persistence.NewConversation(sessionID, session)
+	if s.agentManager != nil {
+		for _, agentID := range s.agentManager.ListAgents() {
+			if agentInstance, exists := s.agentManager.GetAgent(agentID); exists {
+				conversation.Messages = append(conversation.Messages, sessionMessages(agentInstance, sessionID)...)
+			}
+		}
+	}
+	conversation.SortMessages()
+
+	if session == nil && len(conversation.Messages) == 0 {
+		writeError(w, r, http.StatusNotFound, fmt.Sprintf("session %s not found", sessionID))
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"conversation-%s.%s\"", sessionID, format.FileExtension()))
+	if err := persistence.ExportConversation(w, conversation, format); err != nil {
+		s.logger.WithError(err).Error("Failed to export conversation")
+	}
+}
+
+// sessionMessages converts the executions of an agent in a session into conversation messages
+func sessionMessages(agentInstance *agent.Agent, sessionID string) []persistence.ConversationMessage {
+	config := agentInstance.GetConfig()
+
+	var messages []persistence.ConversationMessage
+	for _, execution := range agentInstance.GetExecutionHistory() {
+		if execution.Metadata[logging.FieldSessionID] != sessionID {
+			continue
+		}
+
+		pinned, _ := execution.Metadata["input_pinned"].(bool)
+		messages = append(messages, persistence.ConversationMessage{
+			Role:      "user",
+			Content:   execution.Input,
+			Timestamp: execution.Timestamp,
+			Pinned:    pinned,
+		})
+
+		reply := persistence.ConversationMessage{
+			Role:      "assistant",
+			Content:   execution.Output,
+			Timestamp: execution.Timestamp.Add(execution.Duration),
+			Metadata: map[string]interface{}{
+				"agent_id":   config.ID,
+				"agent_name": config.Name,
+				"duration":   execution.Duration.String(),
+			},
+		}
+		if execution.Error != nil {
+			reply.Metadata["error"] = execution.Error.Error()
+		}
+		for _, call := range execution.ToolCalls {
+			reply.ToolCalls = append(reply.ToolCalls, persistence.ConversationToolCall{
+				ID:        call.ID,
+				Name:      call.Function.Name,
+				Arguments: call.Function.Arguments,
+			})
+		}
+		messages = append(messages, reply)
+	}
+	return messages
+}
+
+// titleSessionThread titles the thread of a session in the background after its first exchange
+func (s *Server) titleSessionThread(ctx context.Context, agentInstance *agent.Agent, sessionID string, execution *agent.AgentExecution) {
+	if s.sessionManager == nil || !execution.Success {
+		return
+	}
+
+	exchanges := 0
+	for _, past := range agentInstance.GetExecutionHistory() {
+		if past.Metadata[logging.FieldSessionID] == sessionID {
+			exchanges++
+		}
+	}
+	if exchanges != 1 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+
+		session, err := s.sessionManager.GetSession(ctx, sessionID)
+		if err != nil || session.ThreadID == "" {
+			return
+		}
+		messages := []llm.Message{
+			{Role: "user", Content: execution.Input},
+			{Role: "assistant", Content: execution.Output},
+		}
+		if _, err := s.sessionManager.TitleThread(ctx, session.ThreadID, messages); err != nil {
+			s.logger.WithError(err).WithField("thread_id", session.ThreadID).Warn("Failed to title thread")
+		}
+	}()
+}
+
 func (s *Server) handleCreateThread(w http.ResponseWriter, r *http.Request) {
 	if s.sessionManager == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Session manager not available")
+		writeError(w, r, http.StatusServiceUnavailable, "Session manager not available")
 		return
 	}
 
@@ -642,7 +1200,7 @@ func (s *Server) handleCreateThread(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeBodyError(w, r, err)
 		return
 	}
 
@@ -659,7 +1217,7 @@ func (s *Server) handleCreateThread(w http.ResponseWriter, r *http.Request) {
 
 	err := s.sessionManager.CreateThread(ctx, thread)
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -670,7 +1228,7 @@ func (s *Server) handleCreateThread(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleGetThread(w http.ResponseWriter, r *http.Request) {
 	if s.sessionManager == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Session manager not available")
+		writeError(w, r, http.StatusServiceUnavailable, "Session manager not available")
 		return
 	}
 
@@ -682,7 +1240,7 @@ func (s *Server) handleGetThread(w http.ResponseWriter, r *http.Request) {
 
 	thread, err := s.sessionManager.GetThread(ctx, threadID)
 	if err != nil {
-		s.writeError(w, http.StatusNotFound, err.Error())
+		writeError(w, r, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -704,7 +1262,7 @@ func (s *Server) handleListCheckpoints(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleListTools(w http.ResponseWriter, r *http.Request) {
 	if s.toolRegistry == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Tool registry not available")
+		writeError(w, r, http.StatusServiceUnavailable, "Tool registry not available")
 		return
 	}
 
@@ -716,7 +1274,7 @@ func (s *Server) handleListTools(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleGetTool(w http.ResponseWriter, r *http.Request) {
 	if s.toolRegistry == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Tool registry not available")
+		writeError(w, r, http.StatusServiceUnavailable, "Tool registry not available")
 		return
 	}
 
@@ -725,7 +1283,7 @@ func (s *Server) handleGetTool(w http.ResponseWriter, r *http.Request) {
 
 	tool, exists := s.toolRegistry.GetTool(toolName)
 	if !exists {
-		s.writeError(w, http.StatusNotFound, "Tool not found")
+		writeError(w, r, http.StatusNotFound, "Tool not found")
 		return
 	}
 
@@ -744,6 +1302,9 @@ func (s *Server) handleGraphWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer conn.Close()
+	if s.config.MaxRequestBytes > 0 {
+		conn.SetReadLimit(s.config.MaxRequestBytes)
+	}
 
 	// Store connection
 	s.wsConnectionsMu.Lock()
@@ -787,12 +1348,14 @@ func (s *Server) handleAgentWebSocket(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	agentID := vars["id"]
 
-	conn, err := s.upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		s.logger.WithError(err).Error("Failed to upgrade WebSocket")
+	conn, ok := s.upgradeAgentWebSocket(w, r)
+	if !ok {
 		return
 	}
 	defer conn.Close()
+	if s.config.MaxRequestBytes > 0 {
+		conn.SetReadLimit(s.config.MaxRequestBytes)
+	}
 
 	// Store connection
 	s.wsConnectionsMu.Lock()
@@ -806,11 +1369,21 @@ func (s *Server) handleAgentWebSocket(w http.ResponseWriter, r *http.Request) {
 		s.wsConnectionsMu.Unlock()
 	}()
 
-	// Handle WebSocket messages
+	if conn.Subprotocol() == WebSocketSubprotocol {
+		s.serveAgentWebSocket(r, conn, agentID)
+		return
+	}
+
+	// Handle legacy WebSocket messages
 	for {
 		var message struct {
-			Type  string `json:"type"`
-			Input string `json:"input"`
+			Type      string `json:"type"`
+			Input     string `json:"input"`
+			SessionID string `json:"session_id"`
+			// Granularity coalesces streamed tokens into words or sentences
+			Granularity agent.StreamGranularity `json:"granularity"`
+			// Structured ends the stream with the answer parsed as JSON, repaired when cut short
+			Structured bool `json:"structured"`
 		}
 
 		err := conn.ReadJSON(&message)
@@ -822,14 +1395,30 @@ func (s *Server) handleAgentWebSocket(w http.ResponseWriter, r *http.Request) {
 		if message.Type == "execute" && s.agentManager != nil {
 			agentInstance, exists := s.agentManager.GetAgent(agentID)
 			if exists {
-				go s.streamAgentExecution(conn, agentInstance, message.Input)
+				sessionID := message.SessionID
+				if sessionID == "" {
+					sessionID = logging.SessionID(r.Context())
+				}
+				options := &agent.ExecuteOptions{Stream: agent.StreamOptions{Granularity: message.Granularity}}
+				go s.streamAgentExecution(conn, agentInstance, message.Input, sessionID, options, message.Structured)
 			}
 		}
 	}
 }
 
-func (s *Server) streamAgentExecution(conn *websocket.Conn, agent *agent.Agent, input string) {
-	ctx := context.Background()
+// streamAgentExecution streams an execution over a WebSocket. A structured stream sends a
+// complete event with the answer as a JSON object, or an incomplete event, ahead of the
+// result or error event; an interrupted answer is repaired into the nearest valid object.
+func (s *Server) streamAgentExecution(conn *websocket.Conn, agentInstance *agent.Agent, input, sessionID string, options *agent.ExecuteOptions, structured bool) {
+	ctx, cancel := s.streamContext(logging.WithSessionID(context.Background(), sessionID))
+	defer cancel()
+
+	unlock, err := s.lockSession(ctx, sessionID)
+	if err != nil {
+		s.writeStreamError(conn, err)
+		return
+	}
+	defer unlock()
 
 	// Send start message
 	conn.WriteJSON(map[string]interface{}{
@@ -837,23 +1426,59 @@ func (s *Server) streamAgentExecution(conn *websocket.Conn, agent *agent.Agent,
 		"timestamp": time.Now(),
 	})
 
-	// Execute agent
-	execution, err := agent.Execute(ctx, input)
-
+	// Forward tokens, thoughts and tool calls as the agent produces them
+	events, err := agentInstance.StreamWithOptions(ctx, input, options)
 	if err != nil {
-		conn.WriteJSON(map[string]interface{}{
-			"type":  "error",
-			"error": err.Error(),
-		})
+		s.writeStreamError(conn, err)
 		return
 	}
+	var answer *structuredStream
+	if structured {
+		answer = &structuredStream{}
+	}
+	ended := false
+	for event := range events {
+		if answer != nil {
+			answer.add(event)
+		}
+		switch event.Type {
+		case agent.StreamEventError:
+			ended = true
+			if answer != nil {
+				conn.WriteJSON(answer.result(event.Execution, true))
+			}
+			s.writeStreamError(conn, event.Err)
+		case agent.StreamEventDone:
+			ended = true
+			if answer != nil {
+				conn.WriteJSON(answer.result(event.Execution, false))
+			}
+			conn.WriteJSON(map[string]interface{}{
+				"type":      "result",
+				"execution": event.Execution,
+				"timestamp": time.Now(),
+			})
+		default:
+			conn.WriteJSON(event)
+		}
+	}
+
+	// The final event is dropped when the stream is cancelled
+	if answer != nil && !ended {
+		conn.WriteJSON(answer.result(nil, true))
+	}
+}
 
-	// Send result
+// writeStreamError sends the error of a streamed execution, closing the connection with
+// reconnection advice when the error is transient
+func (s *Server) writeStreamError(conn *websocket.Conn, err error) {
 	conn.WriteJSON(map[string]interface{}{
-		"type":      "result",
-		"execution": execution,
-		"timestamp": time.Now(),
+		"type":  "error",
+		"error": err.Error(),
 	})
+	if advice, transient := s.reconnectAdviceFor(err); transient {
+		closeWebSocket(conn, advice)
+	}
 }
 
 // Utility functions
@@ -863,13 +1488,6 @@ func (s *Server) writeJSON(w http.ResponseWriter, status int, data interface{})
 	json.NewEncoder(w).Encode(data)
 }
 
-func (s *Server) writeError(w http.ResponseWriter, status int, message string) {
-	s.writeJSON(w, status, map[string]interface{}{
-		"error":     message,
-		"timestamp": time.Now(),
-	})
-}
-
 // Dev mode handlers
 func (s *Server) handleDebugDashboard(w http.ResponseWriter, r *http.Request) {
 	dashboardHTML := `
@@ -912,7 +1530,7 @@ func (s *Server) handleDebugDashboard(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleDebugAgents(w http.ResponseWriter, r *http.Request) {
 	if s.agentManager == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Agent manager not available")
+		writeError(w, r, http.StatusServiceUnavailable, "Agent manager not available")
 		return
 	}
 
@@ -1055,25 +1673,25 @@ func (s *Server) handlePlaygroundTest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeBodyError(w, r, err)
 		return
 	}
 
 	// Test with the first available agent
 	if s.agentManager == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Agent manager not available")
+		writeError(w, r, http.StatusServiceUnavailable, "Agent manager not available")
 		return
 	}
 
 	agents := s.agentManager.ListAgents()
 	if len(agents) == 0 {
-		s.writeError(w, http.StatusNotFound, "No agents available")
+		writeError(w, r, http.StatusNotFound, "No agents available")
 		return
 	}
 
 	agentInstance, exists := s.agentManager.GetAgent(agents[0])
 	if !exists {
-		s.writeError(w, http.StatusNotFound, "Agent not found")
+		writeError(w, r, http.StatusNotFound, "Agent not found")
 		return
 	}
 
@@ -1082,7 +1700,7 @@ func (s *Server) handlePlaygroundTest(w http.ResponseWriter, r *http.Request) {
 
 	execution, err := agentInstance.Execute(ctx, request.Input)
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		writeExecutionError(w, r, err)
 		return
 	}
 
@@ -1103,18 +1721,18 @@ func (s *Server) handlePlaygroundAgentTest(w http.ResponseWriter, r *http.Reques
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid request body")
+		writeBodyError(w, r, err)
 		return
 	}
 
 	if s.agentManager == nil {
-		s.writeError(w, http.StatusServiceUnavailable, "Agent manager not available")
+		writeError(w, r, http.StatusServiceUnavailable, "Agent manager not available")
 		return
 	}
 
 	agentInstance, exists := s.agentManager.GetAgent(agentID)
 	if !exists {
-		s.writeError(w, http.StatusNotFound, "Agent not found")
+		writeError(w, r, http.StatusNotFound, "Agent not found")
 		return
 	}
 
@@ -1123,7 +1741,7 @@ func (s *Server) handlePlaygroundAgentTest(w http.ResponseWriter, r *http.Reques
 
 	execution, err := agentInstance.Execute(ctx, request.Input)
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		writeExecutionError(w, r, err)
 		return
 	}
 
@@ -1138,20 +1756,32 @@ func (s *Server) handlePlaygroundAgentTest(w http.ResponseWriter, r *http.Reques
 // AgentManager manages multiple agents
 type AgentManager struct {
 	agents       map[string]*agent.Agent
+	definitions  map[string]agent.AgentDefinition
+	initOrder    []string
 	llmManager   *llm.ProviderManager
 	toolRegistry *tools.ToolRegistry
 	mu           sync.RWMutex
+
+	// Bounds each agent preflight
+	preflightTimeout time.Duration
 }
 
 // NewAgentManager creates a new agent manager
 func NewAgentManager(llmManager *llm.ProviderManager, toolRegistry *tools.ToolRegistry) *AgentManager {
 	return &AgentManager{
-		agents:       make(map[string]*agent.Agent),
-		llmManager:   llmManager,
-		toolRegistry: toolRegistry,
+		agents:           make(map[string]*agent.Agent),
+		definitions:      make(map[string]agent.AgentDefinition),
+		llmManager:       llmManager,
+		toolRegistry:     toolRegistry,
+		preflightTimeout: DefaultPreflightTimeout,
 	}
 }
 
+// SetPreflightTimeout sets how long an agent preflight may take before the agent is refused
+func (am *AgentManager) SetPreflightTimeout(timeout time.Duration) {
+	am.preflightTimeout = timeout
+}
+
 // CreateAgent creates a new agent
 func (am *AgentManager) CreateAgent(config *agent.AgentConfig) (*agent.Agent, error) {
 	am.mu.Lock()
@@ -1163,6 +1793,109 @@ func (am *AgentManager) CreateAgent(config *agent.AgentConfig) (*agent.Agent, er
 	return agentInstance, nil
 }
 
+// Preflight checks an agent configuration against the manager's providers and tools
+// without registering the agent
+func (am *AgentManager) Preflight(ctx context.Context, config *agent.AgentConfig) error {
+	return am.preflight(ctx, agent.NewAgent(config, am.llmManager, am.toolRegistry))
+}
+
+// preflight runs the preflight checks of an agent within the preflight timeout
+func (am *AgentManager) preflight(ctx context.Context, agentInstance *agent.Agent) error {
+	ctx, cancel := context.WithTimeout(ctx, am.preflightTimeout)
+	defer cancel()
+	return agentInstance.Preflight(ctx)
+}
+
+// RegisterAgent adds an existing agent, such as a graph-backed agent, to the manager.
+// Agents that fail preflight, or whose preflight outlasts the preflight timeout, are refused.
+func (am *AgentManager) RegisterAgent(agentInstance *agent.Agent) error {
+	if err := am.preflight(context.Background(), agentInstance); err != nil {
+		return err
+	}
+
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	id := agentInstance.GetConfig().ID
+	if _, exists := am.agents[id]; exists {
+		return fmt.Errorf("agent %s already registered", id)
+	}
+
+	am.agents[id] = agentInstance
+	return nil
+}
+
+// RegisterDefinition creates an agent from a definition and registers it under the given ID.
+// The definition's Init hook runs before the agent is created; when registration fails
+// after Init, the definition is closed again.
+func (am *AgentManager) RegisterDefinition(ctx context.Context, id string, definition agent.AgentDefinition) (*agent.Agent, error) {
+	if err := definition.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid agent definition %s: %w", id, err)
+	}
+	if err := definition.Initialize(am.llmManager, am.toolRegistry); err != nil {
+		return nil, fmt.Errorf("failed to initialize agent definition %s: %w", id, err)
+	}
+	if err := agent.InitDefinition(ctx, definition); err != nil {
+		return nil, fmt.Errorf("agent %s init failed: %w", id, err)
+	}
+
+	agentInstance, err := definition.CreateAgent()
+	if err == nil {
+		if config := agentInstance.GetConfig(); config.ID != id {
+			config.ID = id
+			agentInstance.UpdateConfig(config)
+		}
+		err = am.RegisterAgent(agentInstance)
+	}
+	if err != nil {
+		if closeErr := agent.CloseDefinition(definition); closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+		return nil, err
+	}
+
+	am.mu.Lock()
+	am.definitions[id] = definition
+	am.initOrder = append(am.initOrder, id)
+	am.mu.Unlock()
+
+	return agentInstance, nil
+}
+
+// Close calls the Close hook of every agent registered from a definition, in reverse
+// registration order. Server.Stop calls it once the HTTP server has shut down.
+func (am *AgentManager) Close() error {
+	am.mu.Lock()
+	order := am.initOrder
+	definitions := am.definitions
+	am.initOrder = nil
+	am.definitions = make(map[string]agent.AgentDefinition)
+	am.mu.Unlock()
+
+	var errs []error
+	for i := len(order) - 1; i >= 0; i-- {
+		definition, exists := definitions[order[i]]
+		if !exists {
+			continue
+		}
+		if err := agent.CloseDefinition(definition); err != nil {
+			errs = append(errs, fmt.Errorf("agent %s: %w", order[i], err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// definitionMetadata returns the metadata of the definition an agent was registered from
+func (am *AgentManager) definitionMetadata(id string) map[string]interface{} {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+
+	if definition, exists := am.definitions[id]; exists {
+		return definition.GetMetadata()
+	}
+	return nil
+}
+
 // GetAgent retrieves an agent by ID
 func (am *AgentManager) GetAgent(id string) (*agent.Agent, bool) {
 	am.mu.RLock()
@@ -1184,10 +1917,22 @@ func (am *AgentManager) ListAgents() []string {
 	return ids
 }
 
-// DeleteAgent removes an agent
+// DeleteAgent removes an agent. Agents registered from a definition are closed; DeleteAgent
+// has no error path, so errors returned by Close are dropped.
 func (am *AgentManager) DeleteAgent(id string) {
 	am.mu.Lock()
-	defer am.mu.Unlock()
-
+	definition, hasDefinition := am.definitions[id]
 	delete(am.agents, id)
+	delete(am.definitions, id)
+	for i, initID := range am.initOrder {
+		if initID == id {
+			am.initOrder = append(am.initOrder[:i], am.initOrder[i+1:]...)
+			break
+		}
+	}
+	am.mu.Unlock()
+
+	if hasDefinition {
+		_ = agent.CloseDefinition(definition)
+	}
 }
@@ -9,12 +9,20 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/gorilla/websocket"
+
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/agent"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
 	"github.com/piotrlaczkowski/GoLangGraph/pkg/tools"
 )
@@ -233,6 +241,348 @@ func TestAgentManager(t *testing.T) {
 	}
 }
 
+func TestAgentManager_RegisterGraphAgent(t *testing.T) {
+	graph := core.NewGraph("echo")
+	graph.AddNode("echo", "Echo", func(ctx context.Context, state *core.BaseState) (*core.BaseState, error) {
+		text, _ := state.Get("text")
+		state.Set("echo", fmt.Sprintf("echo: %v", text))
+		return state, nil
+	})
+	graph.SetStartNode("echo")
+	graph.AddEndNode("echo")
+
+	llmManager := llm.NewProviderManager()
+	manager := NewAgentManager(llmManager, tools.NewToolRegistry())
+	graphAgent := agent.NewGraphAgent(graph, "text", "echo")
+	if err := manager.RegisterAgent(graphAgent); err != nil {
+		t.Fatalf("Failed to register graph agent: %v", err)
+	}
+	if err := manager.RegisterAgent(graphAgent); err == nil {
+		t.Error("Registering the same agent twice should fail")
+	}
+
+	server := NewServer(nil)
+	server.SetLLMManager(llmManager)
+	server.SetAgentManager(manager)
+
+	req := httptest.NewRequest("POST", "/api/v1/agents/"+graph.ID+"/execute", strings.NewReader(`{"input": "ping"}`))
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Execute returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), "echo: ping") {
+		t.Errorf("Expected graph output in response, got %s", rr.Body.String())
+	}
+}
+
+// lifecycleDefinition records the lifecycle hooks called on it
+type lifecycleDefinition struct {
+	*agent.BaseAgentDefinition
+	events  *[]string
+	initErr error
+}
+
+func (d *lifecycleDefinition) Init(ctx context.Context) error {
+	*d.events = append(*d.events, "init "+d.GetConfig().Name)
+	return d.initErr
+}
+
+func (d *lifecycleDefinition) Close() error {
+	*d.events = append(*d.events, "close "+d.GetConfig().Name)
+	return nil
+}
+
+func TestAgentManager_DefinitionLifecycle(t *testing.T) {
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", &MockProvider{}); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+	manager := NewAgentManager(llmManager, tools.NewToolRegistry())
+
+	var events []string
+	newDefinition := func(name string) *lifecycleDefinition {
+		return &lifecycleDefinition{
+			BaseAgentDefinition: agent.NewBaseAgentDefinition(&agent.AgentConfig{
+				Name:     name,
+				Type:     agent.AgentTypeChat,
+				Provider: "mock",
+				Model:    "mock-model",
+			}),
+			events: &events,
+		}
+	}
+
+	for _, name := range []string{"first", "second", "third"} {
+		if _, err := manager.RegisterDefinition(context.Background(), name, newDefinition(name)); err != nil {
+			t.Fatalf("RegisterDefinition(%s) failed: %v", name, err)
+		}
+	}
+	if _, exists := manager.GetAgent("second"); !exists {
+		t.Fatal("Expected the agent to be registered under its definition ID")
+	}
+
+	failing := newDefinition("failing")
+	failing.initErr = fmt.Errorf("database unavailable")
+	if _, err := manager.RegisterDefinition(context.Background(), "failing", failing); err == nil {
+		t.Error("Expected a failing Init to refuse the agent")
+	}
+	if _, exists := manager.GetAgent("failing"); exists {
+		t.Error("An agent whose Init failed should not be registered")
+	}
+
+	manager.DeleteAgent("second")
+	server := NewServer(nil)
+	server.SetAgentManager(manager)
+	server.server = &http.Server{}
+	if err := server.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() failed: %v", err)
+	}
+
+	expected := []string{"init first", "init second", "init third", "init failing", "close second", "close third", "close first"}
+	if strings.Join(events, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected lifecycle events %v, got %v", expected, events)
+	}
+}
+
+func TestServer_AgentCatalog(t *testing.T) {
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", &MockProvider{}); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+	manager := NewAgentManager(llmManager, tools.NewToolRegistry())
+	definition := agent.NewBaseAgentDefinition(&agent.AgentConfig{
+		Name:     "Calculator",
+		Type:     agent.AgentTypeChat,
+		Provider: "mock",
+		Model:    "mock-model",
+		Tools:    []string{"calculator", "time"},
+	})
+	definition.SetMetadata("description", "Does arithmetic")
+	if _, err := manager.RegisterDefinition(context.Background(), "calc", definition); err != nil {
+		t.Fatalf("RegisterDefinition() failed: %v", err)
+	}
+
+	server := NewServer(nil)
+	server.SetAgentManager(manager)
+
+	req := httptest.NewRequest("GET", "/api/v1/agents", nil)
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("List returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	var list struct {
+		Agents  []string            `json:"agents"`
+		Catalog []AgentCatalogEntry `json:"catalog"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(list.Agents) != 1 || len(list.Catalog) != 1 {
+		t.Fatalf("Expected one agent in the list and catalog, got %+v", list)
+	}
+	entry := list.Catalog[0]
+	if entry.ID != "calc" || entry.Description != "Does arithmetic" || len(entry.Tools) != 2 {
+		t.Errorf("Unexpected catalog entry %+v", entry)
+	}
+	if entry.Endpoints["execute"] != "/api/v1/agents/calc/execute" {
+		t.Errorf("Unexpected execute endpoint %q", entry.Endpoints["execute"])
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/agents/calc", nil)
+	rr = httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+
+	var detail struct {
+		Catalog AgentCatalogEntry `json:"catalog"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &detail); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(detail.Catalog.Examples) != 1 || detail.Catalog.Examples[0].Body["input"] != "Hello" {
+		t.Errorf("Expected an example execute request, got %+v", detail.Catalog.Examples)
+	}
+}
+
+func TestServer_StreamReconnectAdvice(t *testing.T) {
+	server := NewServer(nil)
+	server.config.Reconnect = ReconnectPolicy{BaseDelay: time.Second, MaxJitter: time.Second}
+
+	streamGraph := func(fn core.NodeFunc) (string, map[string]interface{}) {
+		graph := core.NewGraph("stream")
+		graph.Config.RetryAttempts = 0
+		graph.AddNode("work", "Work", fn)
+		graph.SetStartNode("work")
+		graph.AddEndNode("work")
+		server.RegisterGraph(graph)
+
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/graphs/"+graph.ID+"/stream", nil))
+
+		body := strings.TrimSpace(rr.Body.String())
+		lines := strings.Split(body, "\n")
+		if len(lines) != 3 || lines[1] != "event: reconnect" {
+			t.Fatalf("Expected a reconnect event, got %q", body)
+		}
+		var advice map[string]interface{}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), &advice); err != nil {
+			t.Fatalf("Invalid reconnect payload %q: %v", lines[2], err)
+		}
+		return lines[0], advice
+	}
+
+	// An overloaded agent advises waiting at least until it accepts executions again
+	retry, advice := streamGraph(func(ctx context.Context, state *core.BaseState) (*core.BaseState, error) {
+		return nil, &agent.RateLimitError{Agent: "busy", RetryAfter: 5 * time.Second}
+	})
+	if retry != "retry: 5000" || advice["reason"] != reconnectReasonOverloaded {
+		t.Errorf("Expected a 5s overload backoff, got %q %v", retry, advice)
+	}
+
+	// Stopping the server ends running streams with a jittered backoff
+	retry, advice = streamGraph(func(ctx context.Context, state *core.BaseState) (*core.BaseState, error) {
+		server.closeOnce.Do(func() { close(server.closing) })
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if advice["reason"] != reconnectReasonShutdown {
+		t.Errorf("Expected a shutdown reason, got %v", advice)
+	}
+	if delay := advice["retry_after_ms"].(float64); delay < 1000 || delay >= 2000 || retry != fmt.Sprintf("retry: %.0f", delay) {
+		t.Errorf("Expected a jittered delay between 1s and 2s, got %q %v", retry, advice)
+	}
+}
+
+func TestServer_ProblemResponses(t *testing.T) {
+	config := DefaultServerConfig()
+	config.StaticDir = ""
+	server := NewServer(config)
+
+	// Missing resources are reported as problem documents echoing the client's request ID
+	req := httptest.NewRequest("GET", "/api/v1/agents/missing", nil)
+	req.Header.Set(RequestIDHeader, "req-42")
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %v, got %v", http.StatusServiceUnavailable, rr.Code)
+	}
+	if contentType := rr.Header().Get("Content-Type"); contentType != ProblemContentType {
+		t.Errorf("Expected content type %s, got %s", ProblemContentType, contentType)
+	}
+	if rr.Header().Get(RequestIDHeader) != "req-42" {
+		t.Errorf("Expected the request ID header to be echoed, got %q", rr.Header().Get(RequestIDHeader))
+	}
+
+	var problem Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Failed to decode problem: %v", err)
+	}
+	if problem.Type != ProblemTypeBase+ProblemServiceUnavailable || problem.Status != http.StatusServiceUnavailable {
+		t.Errorf("Unexpected problem type or status: %+v", problem)
+	}
+	if problem.Instance != "/api/v1/agents/missing" || problem.RequestID != "req-42" || problem.Title == "" {
+		t.Errorf("Unexpected problem: %+v", problem)
+	}
+
+	// Unmatched routes get a generated request ID
+	req = httptest.NewRequest("GET", "/api/v1/nowhere", nil)
+	rr = httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	problem = Problem{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Failed to decode problem: %v", err)
+	}
+	if rr.Code != http.StatusNotFound || problem.Type != ProblemTypeBase+ProblemNotFound {
+		t.Errorf("Expected a not-found problem, got %v %+v", rr.Code, problem)
+	}
+	if problem.RequestID == "" || problem.RequestID != rr.Header().Get(RequestIDHeader) {
+		t.Errorf("Expected a generated request ID, got %q", problem.RequestID)
+	}
+
+	// Agent and LLM errors map to stable problem codes
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{&agent.RateLimitError{Agent: "busy", RetryAfter: time.Second}, http.StatusTooManyRequests, ProblemRateLimited},
+		{fmt.Errorf("chat: %w", llm.ErrContextLengthExceeded), http.StatusUnprocessableEntity, ProblemContextLengthExceeded},
+		{&llm.UnsupportedFeatureError{Provider: "mock", Feature: llm.FeatureVision}, http.StatusUnprocessableEntity, ProblemUnsupportedFeature},
+		{&agent.PreflightError{AgentName: "a"}, http.StatusServiceUnavailable, ProblemAgentNotReady},
+		{&llm.StreamStalledError{}, http.StatusGatewayTimeout, ProblemStreamStalled},
+		{fmt.Errorf("chat failed: %w", &agent.EmptyResponseError{Agent: "a", FinishReason: "stop"}), http.StatusBadGateway, ProblemEmptyResponse},
+		{&agent.EmptyResponseError{Agent: "a", FinishReason: "content_filter", Blocked: true}, http.StatusUnprocessableEntity, ProblemContentBlocked},
+		{fmt.Errorf("chat failed: %w", &llm.ContentBlockedError{Provider: "mock", Categories: []string{"hate"}}), http.StatusUnprocessableEntity, ProblemContentBlocked},
+		{context.DeadlineExceeded, http.StatusGatewayTimeout, ProblemTimeout},
+		{fmt.Errorf("boom"), http.StatusInternalServerError, ProblemInternal},
+	}
+	for _, tc := range cases {
+		status, code := problemForError(tc.err)
+		if status != tc.status || code != tc.code {
+			t.Errorf("Expected %v %s for %v, got %v %s", tc.status, tc.code, tc.err, status, code)
+		}
+	}
+}
+
+func TestServer_AdmissionControl(t *testing.T) {
+	readings := []float64{50, 95, 88, 80}
+	var sampled int
+	sampler := func(ctx context.Context) (ResourceUsage, error) {
+		usage := ResourceUsage{CPUPercent: 10, MemoryPercent: readings[sampled]}
+		if sampled < len(readings)-1 {
+			sampled++
+		}
+		return usage, nil
+	}
+
+	config := AdmissionConfig{Enabled: true, MaxMemoryPercent: 90, Hysteresis: 5}
+	controller := NewAdmissionController(config, sampler)
+
+	// Shedding starts above the threshold and stops only below the hysteresis band
+	for i, expected := range []bool{true, false, false, true} {
+		if admitted := controller.Admit(context.Background()); admitted != expected {
+			t.Errorf("Reading %d: expected admitted=%v, got %v", i, expected, admitted)
+		}
+	}
+
+	server := NewServer(nil)
+	server.SetAdmissionController(NewAdmissionController(config, func(ctx context.Context) (ResourceUsage, error) {
+		return ResourceUsage{CPUPercent: 20, MemoryPercent: 97}, nil
+	}))
+
+	req := httptest.NewRequest("POST", "/api/v1/agents/assistant/execute", strings.NewReader(`{"input": "hi"}`))
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %v, got %v", http.StatusServiceUnavailable, rr.Code)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+	var problem Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil || problem.Type != ProblemTypeBase+ProblemOverloaded {
+		t.Errorf("Expected an overloaded problem, got %s", rr.Body.String())
+	}
+
+	// The health endpoint reports the readings
+	req = httptest.NewRequest("GET", "/api/v1/health", nil)
+	rr = httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	var health struct {
+		Status    string          `json:"status"`
+		Resources AdmissionStatus `json:"resources"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &health); err != nil {
+		t.Fatalf("Failed to decode health: %v", err)
+	}
+	if health.Status != "overloaded" || !health.Resources.Overloaded || health.Resources.MemoryPercent != 97 {
+		t.Errorf("Expected the resource readings in the health response, got %s", rr.Body.String())
+	}
+}
+
 func TestServer_SetMethods(t *testing.T) {
 	server := NewServer(nil)
 
@@ -251,96 +601,1195 @@ func TestServer_SetMethods(t *testing.T) {
 	// These methods don't return anything, so we just test they don't panic
 }
 
-// MockProvider for testing
-type MockProvider struct{}
+func TestServer_ProviderCapabilities(t *testing.T) {
+	server := NewServer(nil)
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", &MockProvider{}); err != nil {
+		t.Fatal(err)
+	}
+	llmManager.SetModelCapabilities("mock", "batch-model", llm.ProviderCapabilities{SupportsTools: true})
+	toolRegistry := tools.NewToolRegistry()
+	server.SetLLMManager(llmManager)
+	server.SetAgentManager(NewAgentManager(llmManager, toolRegistry))
 
-func (m *MockProvider) GetName() string { return "mock" }
-func (m *MockProvider) GetModels(ctx context.Context) ([]string, error) {
-	return []string{"mock-model"}, nil
-}
+	req := httptest.NewRequest("GET", "/api/v1/providers/mock/capabilities?model=batch-model", nil)
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
 
-func (m *MockProvider) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
-	return &llm.CompletionResponse{
-		ID:      "mock-response",
-		Object:  "chat.completion",
-		Created: time.Now().Unix(),
-		Model:   "mock-model",
-		Choices: []llm.Choice{
-			{
-				Index: 0,
-				Message: llm.Message{
-					Role:    "assistant",
-					Content: "Mock response",
-				},
-				FinishReason: "stop",
-			},
-		},
-		Usage: llm.Usage{
-			PromptTokens:     10,
-			CompletionTokens: 5,
-			TotalTokens:      15,
-		},
-	}, nil
-}
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Capabilities returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
 
-func (m *MockProvider) CompleteStream(ctx context.Context, req llm.CompletionRequest, callback llm.StreamCallback) error {
-	return nil
-}
+	var response struct {
+		Capabilities llm.ProviderCapabilities `json:"capabilities"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal("Failed to unmarshal capabilities response")
+	}
+	if response.Capabilities.SupportsStreaming || !response.Capabilities.SupportsTools {
+		t.Errorf("Expected model override, got %+v", response.Capabilities)
+	}
 
-func (m *MockProvider) CompleteWithMode(ctx context.Context, req llm.CompletionRequest, mode llm.StreamMode) (*llm.CompletionResponse, error) {
-	return m.Complete(ctx, req)
-}
+	// Requesting streaming on a model without it is rejected
+	body := `{"name": "streamer", "type": "chat", "provider": "mock", "model": "batch-model", "enable_streaming": true}`
+	req = httptest.NewRequest("POST", "/api/v1/agents", strings.NewReader(body))
+	rr = httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
 
-func (m *MockProvider) CompleteStreamWithMode(ctx context.Context, req llm.CompletionRequest, callback llm.StreamCallback, mode llm.StreamMode) error {
-	return m.CompleteStream(ctx, req, callback)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %v for unsupported streaming, got %v", http.StatusBadRequest, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "does not support streaming") {
+		t.Errorf("Expected unsupported feature error, got %s", rr.Body.String())
+	}
 }
 
-func (m *MockProvider) SupportsStreaming() bool { return true }
+func TestServer_CreateAgentPreflight(t *testing.T) {
+	server := NewServer(nil)
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", &MockProvider{}); err != nil {
+		t.Fatal(err)
+	}
+	server.SetLLMManager(llmManager)
+	server.SetAgentManager(NewAgentManager(llmManager, tools.NewToolRegistry()))
 
-func (m *MockProvider) GetStreamingConfig() *llm.StreamingConfig {
-	return &llm.StreamingConfig{
-		Mode:      llm.StreamModeNone,
-		ChunkSize: 100,
-		KeepAlive: false,
+	body := `{"id": "broken", "name": "broken", "type": "chat", "provider": "mock", "model": "mock-model", "tools": ["missing-tool"]}`
+	req := httptest.NewRequest("POST", "/api/v1/agents", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %v for a broken agent, got %v", http.StatusBadRequest, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "tool missing-tool is not registered") {
+		t.Errorf("Expected preflight error, got %s", rr.Body.String())
+	}
+	if _, exists := server.agentManager.GetAgent("broken"); exists {
+		t.Error("Broken agent should not be registered")
+	}
+
+	body = `{"id": "healthy", "name": "healthy", "type": "chat", "provider": "mock", "model": "mock-model", "tools": ["calculator"]}`
+	req = httptest.NewRequest("POST", "/api/v1/agents", strings.NewReader(body))
+	rr = httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("Expected status %v for a healthy agent, got %v: %s", http.StatusCreated, rr.Code, rr.Body.String())
 	}
 }
 
-func (m *MockProvider) SetStreamingConfig(config *llm.StreamingConfig) error { return nil }
+func TestServer_PreflightTimeout(t *testing.T) {
+	manager := NewAgentManager(llm.NewProviderManager(), tools.NewToolRegistry())
+	if manager.preflightTimeout != DefaultPreflightTimeout {
+		t.Errorf("Expected the default preflight timeout, got %v", manager.preflightTimeout)
+	}
 
-func (m *MockProvider) IsHealthy(ctx context.Context) error           { return nil }
-func (m *MockProvider) GetConfig() map[string]interface{}             { return map[string]interface{}{} }
-func (m *MockProvider) SetConfig(config map[string]interface{}) error { return nil }
-func (m *MockProvider) Close() error                                  { return nil }
+	// The server's preflight timeout applies to agents created over HTTP and registered alike
+	config := DefaultServerConfig()
+	config.PreflightTimeout = 5 * time.Second
+	NewServer(config).SetAgentManager(manager)
+	if manager.preflightTimeout != 5*time.Second {
+		t.Errorf("Expected the configured preflight timeout, got %v", manager.preflightTimeout)
+	}
+}
 
-// Benchmark tests
-func BenchmarkServer_HealthCheck(b *testing.B) {
+func TestServer_IdempotencyKey(t *testing.T) {
 	server := NewServer(nil)
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", &MockProvider{}); err != nil {
+		t.Fatal(err)
+	}
+	server.SetLLMManager(llmManager)
+	server.SetAgentManager(NewAgentManager(llmManager, tools.NewToolRegistry()))
 
-	req, err := http.NewRequest("GET", "/api/v1/health", nil)
+	agentInstance, err := server.agentManager.CreateAgent(&agent.AgentConfig{
+		ID: "idempotent", Name: "idempotent", Type: agent.AgentTypeChat, Provider: "mock", Model: "mock-model",
+	})
 	if err != nil {
-		b.Fatal(err)
+		t.Fatal(err)
 	}
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
+	execute := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/agents/idempotent/execute", strings.NewReader(body))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
 		rr := httptest.NewRecorder()
 		server.router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	first := execute(`{"input": "hello"}`)
+	if first.Code != http.StatusOK {
+		t.Fatalf("Expected status %v, got %v: %s", http.StatusOK, first.Code, first.Body.String())
+	}
+	second := execute(`{"input": "hello"}`)
+	if second.Code != http.StatusOK {
+		t.Fatalf("Expected status %v, got %v", http.StatusOK, second.Code)
+	}
+	if second.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Error("Expected the repeated request to be replayed")
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Error("Expected the replayed response to match the original response")
+	}
+	if history := agentInstance.GetExecutionHistory(); len(history) != 1 {
+		t.Errorf("Expected the agent to run once, ran %d times", len(history))
+	}
+
+	conflict := execute(`{"input": "goodbye"}`)
+	if conflict.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status %v for a reused key, got %v", http.StatusUnprocessableEntity, conflict.Code)
 	}
 }
 
-func BenchmarkAgentManager_CreateAgent(b *testing.B) {
-	llmManager := llm.NewProviderManager()
-	toolRegistry := tools.NewToolRegistry()
-	manager := NewAgentManager(llmManager, toolRegistry)
+func TestServer_IdempotencyKeyRetries(t *testing.T) {
+	server := NewServer(nil)
+	calls := 0
+	handler := server.idempotencyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			writeError(w, r, http.StatusTooManyRequests, "Rate limit exceeded")
+			return
+		}
+		server.writeJSON(w, http.StatusOK, map[string]int{"calls": calls})
+	}))
 
-	config := &agent.AgentConfig{
-		Name:         "benchmark-agent",
-		Type:         agent.AgentTypeChat,
-		SystemPrompt: "You are a benchmark agent",
+	execute := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/agents/limited/execute", strings.NewReader(`{"input": "hello"}`))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
 	}
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		manager.CreateAgent(config)
+	if rr := execute(); rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status %v, got %v", http.StatusTooManyRequests, rr.Code)
+	}
+	// A rate-limited request is not replayed, its retry runs
+	rr := execute()
+	if rr.Code != http.StatusOK || rr.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Fatalf("Expected the retry to run, got %v: %s", rr.Code, rr.Body.String())
+	}
+	if rr := execute(); rr.Header().Get(IdempotentReplayedHeader) != "true" || calls != 2 {
+		t.Errorf("Expected the successful response to be replayed, handler ran %d times", calls)
+	}
+}
+
+func TestMemoryIdempotencyStore_Expiry(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	ctx := context.Background()
+
+	reserved, err := store.Reserve(ctx, "key", time.Hour)
+	if err != nil || !reserved {
+		t.Fatalf("Expected the key to be reserved, got %v, %v", reserved, err)
+	}
+	if reserved, _ := store.Reserve(ctx, "key", time.Hour); reserved {
+		t.Error("Expected a reserved key to be refused")
+	}
+
+	if err := store.Save(ctx, "key", &IdempotentResponse{StatusCode: http.StatusOK}, 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if response, _ := store.Get(ctx, "key"); response == nil || response.StatusCode != http.StatusOK {
+		t.Errorf("Expected the stored response, got %v", response)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if response, _ := store.Get(ctx, "key"); response != nil {
+		t.Errorf("Expected the response to expire, got %v", response)
+	}
+	if reserved, _ := store.Reserve(ctx, "key", time.Hour); !reserved {
+		t.Error("Expected an expired key to be reusable")
+	}
+
+	// Expired keys that are never looked up again are swept by later writes
+	if err := store.Save(ctx, "once", &IdempotentResponse{StatusCode: http.StatusOK}, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	store.lastSweep = time.Time{}
+	if _, err := store.Reserve(ctx, "other", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := store.entries["once"]; exists {
+		t.Error("Expected the expired key to be swept")
+	}
+}
+
+func TestServer_ExportSession(t *testing.T) {
+	server := NewServer(nil)
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", &MockProvider{}); err != nil {
+		t.Fatal(err)
+	}
+	server.SetLLMManager(llmManager)
+	server.SetAgentManager(NewAgentManager(llmManager, tools.NewToolRegistry()))
+
+	if _, err := server.agentManager.CreateAgent(&agent.AgentConfig{
+		ID: "exporter", Name: "exporter", Type: agent.AgentTypeChat, Provider: "mock", Model: "mock-model",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("POST", "/api/v1/agents/exporter/execute", strings.NewReader(`{"input": "hello there", "session_id": "s-1"}`))
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %v, got %v: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/sessions/s-1/export?format=md", nil)
+	rr = httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %v, got %v: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/markdown") {
+		t.Errorf("Expected Markdown content type, got %s", rr.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rr.Body.String(), "## User") || !strings.Contains(rr.Body.String(), "hello there") {
+		t.Errorf("Expected the conversation in the export, got %s", rr.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/sessions/unknown/export", nil)
+	rr = httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %v for an unknown session, got %v", http.StatusNotFound, rr.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/sessions/s-1/export?format=pdf", nil)
+	rr = httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %v for an unsupported format, got %v", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestServer_MaxRequestBytes(t *testing.T) {
+	config := DefaultServerConfig()
+	config.MaxRequestBytes = 64
+	server := NewServer(config)
+	llmManager := llm.NewProviderManager()
+	server.SetLLMManager(llmManager)
+	server.SetAgentManager(NewAgentManager(llmManager, tools.NewToolRegistry()))
+
+	body := `{"name": "big", "system_prompt": "` + strings.Repeat("x", 128) + `"}`
+
+	req := httptest.NewRequest("POST", "/api/v1/agents", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status %v, got %v", http.StatusRequestEntityTooLarge, rr.Code)
+	}
+	var response map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil || response["type"] != ProblemTypeBase+ProblemRequestTooLarge {
+		t.Errorf("Expected a problem document, got %s", rr.Body.String())
+	}
+
+	// Bodies without a Content-Length are limited while they are read
+	req = httptest.NewRequest("POST", "/api/v1/agents", strings.NewReader(body))
+	req.ContentLength = -1
+	rr = httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status %v for a streamed body, got %v", http.StatusRequestEntityTooLarge, rr.Code)
+	}
+}
+
+func TestServer_AgentRateLimit(t *testing.T) {
+	server := NewServer(nil)
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", &MockProvider{}); err != nil {
+		t.Fatal(err)
+	}
+	server.SetLLMManager(llmManager)
+	server.SetAgentManager(NewAgentManager(llmManager, tools.NewToolRegistry()))
+
+	if _, err := server.agentManager.CreateAgent(&agent.AgentConfig{
+		ID: "reporter", Name: "reporter", Type: agent.AgentTypeChat, Provider: "mock", Model: "mock-model",
+		RateLimit: &agent.AgentRateLimit{RequestsPerMinute: 2},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	execute := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/agents/reporter/execute", strings.NewReader(`{"input": "report"}`))
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := execute(); rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %v, got %v: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	rr := execute()
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status %v, got %v", http.StatusTooManyRequests, rr.Code)
+	}
+	if retryAfter := rr.Header().Get("Retry-After"); retryAfter != "30" {
+		t.Errorf("Expected Retry-After of 30 seconds, got %q", retryAfter)
+	}
+}
+
+func TestServer_StreamGraph(t *testing.T) {
+	graph := core.NewGraph("pipeline")
+	for _, id := range []string{"fetch", "summarize"} {
+		id := id
+		graph.AddNode(id, id, func(ctx context.Context, state *core.BaseState) (*core.BaseState, error) {
+			input, _ := state.Get("input")
+			state.Set(id, fmt.Sprintf("%s(%v)", id, input))
+			return state, nil
+		})
+	}
+	graph.AddEdge("fetch", "summarize", nil)
+	graph.SetStartNode("fetch")
+	graph.AddEndNode("summarize")
+
+	server := NewServer(nil)
+	server.RegisterGraph(graph)
+
+	req := httptest.NewRequest("POST", "/api/v1/graphs/"+graph.ID+"/stream", strings.NewReader(`{"input": "docs", "state": {"user": "ada"}}`))
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %v: %s", rr.Code, rr.Body.String())
+	}
+
+	var events []string
+	var payloads []map[string]interface{}
+	for _, block := range strings.Split(strings.TrimSpace(rr.Body.String()), "\n\n") {
+		lines := strings.SplitN(block, "\n", 2)
+		events = append(events, strings.TrimPrefix(lines[0], "event: "))
+		var payload map[string]interface{}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &payload); err != nil {
+			t.Fatalf("Invalid event payload %q: %v", lines[1], err)
+		}
+		payloads = append(payloads, payload)
+	}
+
+	if strings.Join(events, ",") != "state,state,done" {
+		t.Fatalf("Expected two state events and a done event, got %v", events)
+	}
+	if payloads[0]["node_id"] != "fetch" || payloads[1]["node_id"] != "summarize" {
+		t.Errorf("Expected node-by-node progress, got %v", payloads)
+	}
+	if _, exists := payloads[0]["state"].(map[string]interface{})["summarize"]; exists {
+		t.Error("Expected the first event to carry the intermediate state")
+	}
+	final := payloads[2]["state"].(map[string]interface{})
+	if final["summarize"] != "summarize(docs)" || final["user"] != "ada" {
+		t.Errorf("Unexpected final state %v", final)
+	}
+
+	// Streams are not stored for replay, a retry with the same key streams again
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("POST", "/api/v1/graphs/"+graph.ID+"/stream", strings.NewReader(`{"input": "docs"}`))
+		req.Header.Set(IdempotencyKeyHeader, "stream-1")
+		rr = httptest.NewRecorder()
+		server.router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK || rr.Header().Get(IdempotentReplayedHeader) != "" || !strings.Contains(rr.Body.String(), "event: done") {
+			t.Fatalf("Expected stream %d to run, got %v: %s", i+1, rr.Code, rr.Body.String())
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	server.router.ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/graphs/missing/stream", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %v for an unknown graph, got %v", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestServer_PinConversationMessages(t *testing.T) {
+	server := NewServer(nil)
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", &MockProvider{}); err != nil {
+		t.Fatal(err)
+	}
+	server.SetLLMManager(llmManager)
+	server.SetAgentManager(NewAgentManager(llmManager, tools.NewToolRegistry()))
+
+	if _, err := server.agentManager.CreateAgent(&agent.AgentConfig{
+		ID: "assistant", Name: "assistant", Type: agent.AgentTypeChat, Provider: "mock", Model: "mock-model",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := serve("POST", "/api/v1/agents/assistant/execute", `{"input": "Always respond in French.", "pin_input": true}`); rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %v, got %v: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if rr := serve("PUT", "/api/v1/agents/assistant/conversation/1/pin", ""); rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %v, got %v: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if rr := serve("PUT", "/api/v1/agents/assistant/conversation/9/pin", `{"pinned": true}`); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %v for an unknown message, got %v", http.StatusNotFound, rr.Code)
+	}
+
+	rr := serve("GET", "/api/v1/agents/assistant/conversation", "")
+	var response struct {
+		Messages []llm.Message `json:"messages"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode conversation: %v", err)
+	}
+	if len(response.Messages) != 2 || !response.Messages[0].Pinned || !response.Messages[1].Pinned {
+		t.Errorf("Expected both messages to be pinned, got %+v", response.Messages)
+	}
+
+	if rr := serve("PUT", "/api/v1/agents/assistant/conversation/1/pin", `{"pinned": false}`); rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %v, got %v", http.StatusOK, rr.Code)
+	}
+	agentInstance, _ := server.agentManager.GetAgent("assistant")
+	if conversation := agentInstance.GetConversation(); conversation[1].Pinned {
+		t.Error("Expected the reply to be unpinned")
+	}
+}
+
+// MockProvider for testing
+type MockProvider struct{}
+
+func (m *MockProvider) GetName() string { return "mock" }
+func (m *MockProvider) GetModels(ctx context.Context) ([]string, error) {
+	return []string{"mock-model"}, nil
+}
+
+func (m *MockProvider) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	return &llm.CompletionResponse{
+		ID:      "mock-response",
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   "mock-model",
+		Choices: []llm.Choice{
+			{
+				Index: 0,
+				Message: llm.Message{
+					Role:    "assistant",
+					Content: "Mock response",
+				},
+				FinishReason: "stop",
+			},
+		},
+		Usage: llm.Usage{
+			PromptTokens:     10,
+			CompletionTokens: 5,
+			TotalTokens:      15,
+		},
+	}, nil
+}
+
+func (m *MockProvider) CompleteStream(ctx context.Context, req llm.CompletionRequest, callback llm.StreamCallback) error {
+	return nil
+}
+
+func (m *MockProvider) CompleteWithMode(ctx context.Context, req llm.CompletionRequest, mode llm.StreamMode) (*llm.CompletionResponse, error) {
+	return m.Complete(ctx, req)
+}
+
+func (m *MockProvider) CompleteStreamWithMode(ctx context.Context, req llm.CompletionRequest, callback llm.StreamCallback, mode llm.StreamMode) error {
+	return m.CompleteStream(ctx, req, callback)
+}
+
+func (m *MockProvider) SupportsStreaming() bool { return true }
+
+func (m *MockProvider) Capabilities() llm.ProviderCapabilities {
+	return llm.ProviderCapabilities{SupportsStreaming: true, SupportsTools: true, MaxContextTokens: 4096}
+}
+
+func (m *MockProvider) GetStreamingConfig() *llm.StreamingConfig {
+	return &llm.StreamingConfig{
+		Mode:      llm.StreamModeNone,
+		ChunkSize: 100,
+		KeepAlive: false,
+	}
+}
+
+func (m *MockProvider) SetStreamingConfig(config *llm.StreamingConfig) error { return nil }
+
+func (m *MockProvider) IsHealthy(ctx context.Context) error           { return nil }
+func (m *MockProvider) GetConfig() map[string]interface{}             { return map[string]interface{}{} }
+func (m *MockProvider) SetConfig(config map[string]interface{}) error { return nil }
+func (m *MockProvider) Close() error                                  { return nil }
+
+// Benchmark tests
+func BenchmarkServer_HealthCheck(b *testing.B) {
+	server := NewServer(nil)
+
+	req, err := http.NewRequest("GET", "/api/v1/health", nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, req)
+	}
+}
+
+func BenchmarkAgentManager_CreateAgent(b *testing.B) {
+	llmManager := llm.NewProviderManager()
+	toolRegistry := tools.NewToolRegistry()
+	manager := NewAgentManager(llmManager, toolRegistry)
+
+	config := &agent.AgentConfig{
+		Name:         "benchmark-agent",
+		Type:         agent.AgentTypeChat,
+		SystemPrompt: "You are a benchmark agent",
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		manager.CreateAgent(config)
+	}
+}
+
+func TestWorkQueue_Priorities(t *testing.T) {
+	// acquireInOrder queues executions one after another and records the order they start
+	acquireInOrder := func(queue *WorkQueue, priorities []Priority, gap time.Duration) []Priority {
+		hold, err := queue.Acquire(context.Background(), PriorityNormal)
+		if err != nil {
+			t.Fatalf("Acquire() failed: %v", err)
+		}
+
+		var mu sync.Mutex
+		var started []Priority
+		var wg sync.WaitGroup
+		for i, priority := range priorities {
+			wg.Add(1)
+			go func(priority Priority) {
+				defer wg.Done()
+				release, err := queue.Acquire(context.Background(), priority)
+				if err != nil {
+					t.Errorf("Acquire() failed: %v", err)
+					return
+				}
+				mu.Lock()
+				started = append(started, priority)
+				mu.Unlock()
+				release()
+			}(priority)
+			for queue.Stats().Depth != i+1 {
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(gap)
+		}
+
+		hold()
+		wg.Wait()
+		return started
+	}
+
+	// Higher priorities start first, then arrival order
+	queue := NewWorkQueue(WorkQueueConfig{Workers: 1, AgingInterval: time.Hour})
+	started := acquireInOrder(queue, []Priority{PriorityLow, PriorityNormal, PriorityHigh, PriorityLow}, 0)
+	expected := []Priority{PriorityHigh, PriorityNormal, PriorityLow, PriorityLow}
+	for i := range expected {
+		if started[i] != expected[i] {
+			t.Fatalf("Expected start order %v, got %v", expected, started)
+		}
+	}
+
+	// Waiting long enough lifts a low-priority execution above a newer high-priority one
+	queue = NewWorkQueue(WorkQueueConfig{Workers: 1, AgingInterval: time.Millisecond})
+	started = acquireInOrder(queue, []Priority{PriorityLow, PriorityHigh}, 20*time.Millisecond)
+	if started[0] != PriorityLow {
+		t.Errorf("Expected the aged execution to start first, got %v", started)
+	}
+
+	stats := queue.Stats()
+	if stats.Depth != 0 || stats.Running != 0 || stats.Priorities["low"].Started != 1 || stats.Priorities["low"].MaxWaitMs < 20 {
+		t.Errorf("Unexpected queue stats: %+v", stats)
+	}
+
+	// A full queue rejects, and a cancelled wait leaves it
+	queue = NewWorkQueue(WorkQueueConfig{Workers: 1, MaxDepth: 1})
+	hold, _ := queue.Acquire(context.Background(), PriorityHigh)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := queue.Acquire(ctx, PriorityLow)
+		done <- err
+	}()
+	for queue.Stats().Depth != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := queue.Acquire(context.Background(), PriorityHigh); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) || queue.Stats().Depth != 0 {
+		t.Errorf("Expected the cancelled wait to leave the queue, got %v", err)
+	}
+	hold()
+}
+
+func TestServer_WorkQueue(t *testing.T) {
+	server := NewServer(nil)
+	server.SetWorkQueue(NewWorkQueue(WorkQueueConfig{Workers: 1, MaxDepth: 1}))
+
+	req := httptest.NewRequest("POST", "/api/v1/graphs/missing/execute", strings.NewReader(`{}`))
+	req.Header.Set(PriorityHeader, "urgent")
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown priority to be rejected, got %v", rr.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/queue", nil)
+	rr = httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	var stats WorkQueueStats
+	if err := json.Unmarshal(rr.Body.Bytes(), &stats); err != nil || stats.Workers != 1 || len(stats.Priorities) != 3 {
+		t.Errorf("Expected the queue metrics, got %s", rr.Body.String())
+	}
+}
+
+func TestServer_SessionLock(t *testing.T) {
+	server := NewServer(nil)
+	ctx := context.Background()
+
+	unlock, err := server.lockSession(ctx, "session-1")
+	if err != nil {
+		t.Fatalf("lockSession() failed: %v", err)
+	}
+
+	// Another session and executions without a session are not blocked
+	other, err := server.lockSession(ctx, "session-2")
+	if err != nil {
+		t.Fatalf("Expected another session to run, got %v", err)
+	}
+	other()
+	if _, err := server.lockSession(ctx, ""); err != nil {
+		t.Fatalf("Expected executions without a session to run, got %v", err)
+	}
+
+	// A concurrent execution of the session queues until the first one ends
+	acquired := make(chan error)
+	go func() {
+		release, err := server.lockSession(ctx, "session-1")
+		if err == nil {
+			release()
+		}
+		acquired <- err
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("Expected the second execution to wait")
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlock()
+	if err := <-acquired; err != nil {
+		t.Errorf("Expected the queued execution to run, got %v", err)
+	}
+
+	// Busy sessions are rejected with 409 when configured
+	server.config.RejectBusySession = true
+	unlock, _ = server.lockSession(ctx, "session-1")
+	_, err = server.lockSession(ctx, "session-1")
+	if !errors.Is(err, ErrSessionBusy) {
+		t.Fatalf("Expected ErrSessionBusy, got %v", err)
+	}
+	if status, code := problemForError(err); status != http.StatusConflict || code != ProblemConflict {
+		t.Errorf("Expected 409 Conflict, got %d %s", status, code)
+	}
+
+	// Concurrency can be allowed
+	server.config.AllowConcurrentPerSession = true
+	if _, err := server.lockSession(ctx, "session-1"); err != nil {
+		t.Errorf("Expected concurrent executions to be allowed, got %v", err)
+	}
+	unlock()
+}
+
+func TestMemorySessionLocker_Expiry(t *testing.T) {
+	locker := NewMemorySessionLocker()
+	ctx := context.Background()
+
+	token, acquired, _ := locker.TryLock(ctx, "s", 10*time.Millisecond)
+	if !acquired {
+		t.Fatal("Expected the lock to be acquired")
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// An expired lock can be taken over, and its former holder cannot release or refresh it
+	newToken, acquired, _ := locker.TryLock(ctx, "s", time.Minute)
+	if !acquired {
+		t.Fatal("Expected the expired lock to be taken over")
+	}
+	locker.Unlock(ctx, "s", token)
+	if err := locker.Refresh(ctx, "s", token, time.Minute); err == nil {
+		t.Error("Expected refreshing a lost lock to fail")
+	}
+	if _, acquired, _ := locker.TryLock(ctx, "s", time.Minute); acquired {
+		t.Error("Expected the new holder to keep the lock")
+	}
+	locker.Unlock(ctx, "s", newToken)
+	if _, acquired, _ := locker.TryLock(ctx, "s", time.Minute); !acquired {
+		t.Error("Expected the released lock to be acquired")
+	}
+}
+
+func TestNewServer_SessionStore(t *testing.T) {
+	if _, ok := NewServer(nil).sessionLocker.(*MemorySessionLocker); !ok {
+		t.Error("Expected in-memory session locks by default")
+	}
+
+	config := DefaultServerConfig()
+	config.SessionStore = SessionStoreConfig{Type: SessionStoreRedis, Addr: "localhost:6379"}
+	if _, ok := NewServer(config).sessionLocker.(*RedisSessionLocker); !ok {
+		t.Error("Expected Redis session locks with a Redis session store")
+	}
+
+	// A Redis session store that cannot be built or reached fails startup and executions
+	// instead of falling back to locks that replicas do not share
+	config.SessionStore = SessionStoreConfig{Type: SessionStoreRedis}
+	server := NewServer(config)
+	if _, err := server.lockSession(context.Background(), "session-1"); err == nil {
+		t.Error("Expected executions to fail without the configured session store")
+	}
+	if err := server.Start(); err == nil || !strings.Contains(err.Error(), "session store") {
+		t.Errorf("Expected startup to fail without the configured session store, got %v", err)
+	}
+
+	config.SessionStore = SessionStoreConfig{Type: SessionStoreRedis, Addr: "127.0.0.1:1"}
+	if err := NewServer(config).Start(); err == nil || !strings.Contains(err.Error(), "session store") {
+		t.Errorf("Expected startup to fail with an unreachable session store, got %v", err)
+	}
+
+	if _, err := NewSessionLocker(SessionStoreConfig{Type: SessionStoreRedis}); err == nil {
+		t.Error("Expected an error for a Redis session store without an address")
+	}
+	if _, err := NewSessionLocker(SessionStoreConfig{Type: "etcd"}); err == nil {
+		t.Error("Expected an error for an unknown session store")
+	}
+}
+
+// failingProvider is a provider whose completions always fail, without the graph retrying them
+type failingProvider struct {
+	MockProvider
+}
+
+func (p *failingProvider) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	return nil, fmt.Errorf("provider unavailable: %w", core.ErrPermanent)
+}
+
+func TestServer_DeadLetters(t *testing.T) {
+	config := DefaultServerConfig()
+	config.DeadLetter = DeadLetterConfig{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+	server := NewServer(config)
+	llmManager := llm.NewProviderManager()
+	llmManager.RegisterProvider("mock", &MockProvider{})
+	llmManager.RegisterProvider("failing", &failingProvider{})
+	server.SetLLMManager(llmManager)
+	server.SetAgentManager(NewAgentManager(llmManager, tools.NewToolRegistry()))
+	ctx := context.Background()
+
+	// Executions failing every retry are dead-lettered with their input and attempt count
+	if _, err := server.agentManager.CreateAgent(&agent.AgentConfig{
+		ID: "nightly", Name: "nightly", Type: agent.AgentTypeChat, Provider: "failing", Model: "mock-model",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	job := UnattendedExecution{Source: "scheduler", AgentID: "nightly", Input: "summarize the day"}
+	if _, err := server.ExecuteUnattended(ctx, job); err == nil {
+		t.Fatal("Expected the execution to fail")
+	}
+
+	// Missing agents are dead-lettered without retrying
+	if _, err := server.ExecuteUnattended(ctx, UnattendedExecution{Source: "batch", AgentID: "missing"}); !errors.Is(err, ErrAgentNotFound) {
+		t.Fatalf("Expected ErrAgentNotFound, got %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/dead-letters", nil)
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	var listed struct {
+		DeadLetters []*DeadLetter `json:"dead_letters"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &listed); err != nil || len(listed.DeadLetters) != 2 {
+		t.Fatalf("Expected two dead letters, got %s", rr.Body.String())
+	}
+	letter := listed.DeadLetters[0]
+	if letter.UnattendedExecution != job || letter.Attempts != 3 || letter.Error == "" {
+		t.Errorf("Expected the input, error and three attempts, got %+v", letter)
+	}
+	if missing := listed.DeadLetters[1]; missing.Attempts != 1 {
+		t.Errorf("Expected one attempt for a missing agent, got %d", missing.Attempts)
+	}
+
+	// A retry failing again keeps the dead letter with one more attempt
+	retry := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/dead-letters/"+id+"/retry", nil)
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := retry(letter.ID); rr.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected the retry to fail, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if stored, _ := server.deadLetters.Get(ctx, letter.ID); stored == nil || stored.Attempts != 4 {
+		t.Fatalf("Expected the dead letter to stay with four attempts, got %+v", stored)
+	}
+
+	// A successful retry removes it
+	server.agentManager.DeleteAgent("nightly")
+	if _, err := server.agentManager.CreateAgent(&agent.AgentConfig{
+		ID: "nightly", Name: "nightly", Type: agent.AgentTypeChat, Provider: "mock", Model: "mock-model",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if rr := retry(letter.ID); rr.Code != http.StatusOK {
+		t.Fatalf("Expected the retry to succeed, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if stored, _ := server.deadLetters.Get(ctx, letter.ID); stored != nil {
+		t.Error("Expected the retried dead letter to be removed")
+	}
+	if rr := retry("unknown"); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown dead letter, got %d", rr.Code)
+	}
+}
+
+func TestServer_ExecutionFeedback(t *testing.T) {
+	server := NewServer(DefaultServerConfig())
+	llmManager := llm.NewProviderManager()
+	llmManager.RegisterProvider("mock", &MockProvider{})
+	server.SetLLMManager(llmManager)
+	server.SetAgentManager(NewAgentManager(llmManager, tools.NewToolRegistry()))
+
+	feedback := func(id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/executions/"+id+"/feedback", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := feedback("any", `{"rating": 1}`); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without a recorder, got %d", rr.Code)
+	}
+
+	var output strings.Builder
+	recorder, err := agent.NewInteractionRecorder(agent.InteractionRecorderConfig{
+		Sink:           agent.NewJSONLInteractionSink(&output),
+		FeedbackWindow: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.SetInteractionRecorder(recorder)
+	assistant, err := server.agentManager.CreateAgent(&agent.AgentConfig{
+		ID: "assistant", Name: "assistant", Type: agent.AgentTypeChat, Provider: "mock", Model: "mock-model",
+		InteractionRecorder: recorder,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	execution, err := assistant.Execute(context.Background(), "Hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rr := feedback(execution.ID, `{"rating": 1, "comment": "great"}`); rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(output.String(), `"feedback":{"rating":1,"comment":"great"}`) {
+		t.Errorf("Expected the interaction written with its feedback, got %q", output.String())
+	}
+	if rr := feedback(execution.ID, `{"rating": -1}`); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 once the interaction is written, got %d", rr.Code)
+	}
+}
+
+func TestStructuredStream_Result(t *testing.T) {
+	stream := &structuredStream{}
+	for _, token := range []string{`{"title": "Go",`, ` "summary": "A fa`} {
+		stream.add(agent.StreamEvent{Type: agent.StreamEventToken, Content: token})
+	}
+
+	// An interrupted answer is repaired into the nearest valid object
+	result := stream.result(nil, true)
+	object, _ := result.Object.(map[string]interface{})
+	if result.Type != WSTypeComplete || !result.Repaired || object["summary"] != "A fa" {
+		t.Errorf("Expected the repaired object, got %v", result)
+	}
+
+	// A finished answer is parsed from the execution output
+	result = stream.result(&agent.AgentExecution{Output: `{"title": "Go", "summary": "A fast language"}`}, false)
+	object, _ = result.Object.(map[string]interface{})
+	if result.Type != WSTypeComplete || result.Repaired || object["summary"] != "A fast language" {
+		t.Errorf("Expected the parsed object, got %v", result)
+	}
+
+	// Answers holding no JSON are reported incomplete
+	prose := &structuredStream{}
+	prose.add(agent.StreamEvent{Type: agent.StreamEventToken, Content: "Sorry, I cannot"})
+	if result := prose.result(nil, true); result.Type != WSTypeIncomplete || result.Partial != "Sorry, I cannot" {
+		t.Errorf("Expected an incomplete event, got %v", result)
+	}
+}
+
+// streamingMockProvider streams a fixed answer
+type streamingMockProvider struct {
+	MockProvider
+}
+
+func (m *streamingMockProvider) CompleteStream(ctx context.Context, req llm.CompletionRequest, callback llm.StreamCallback) error {
+	for _, fragment := range []string{"Hello", " there"} {
+		if err := callback(llm.CompletionResponse{Choices: []llm.Choice{{Delta: llm.Message{Content: fragment}}}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *streamingMockProvider) CompleteStreamWithMode(ctx context.Context, req llm.CompletionRequest, callback llm.StreamCallback, mode llm.StreamMode) error {
+	return m.CompleteStream(ctx, req, callback)
+}
+
+func TestServer_WebSocketSubprotocol(t *testing.T) {
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", &streamingMockProvider{}); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+	manager := NewAgentManager(llmManager, tools.NewToolRegistry())
+	definition := agent.NewBaseAgentDefinition(&agent.AgentConfig{
+		Name:     "Echo",
+		Type:     agent.AgentTypeChat,
+		Provider: "mock",
+		Model:    "mock-model",
+	})
+	if _, err := manager.RegisterDefinition(context.Background(), "echo", definition); err != nil {
+		t.Fatalf("RegisterDefinition() failed: %v", err)
+	}
+	server := NewServer(nil)
+	server.SetAgentManager(manager)
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http") + "/api/v1/ws/agents/echo/stream"
+
+	// The versioned protocol is negotiated and answers with typed messages
+	dialer := websocket.Dialer{Subprotocols: []string{"golanggraph.v2", WebSocketSubprotocol}}
+	conn, _, err := dialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial() failed: %v", err)
+	}
+	defer conn.Close()
+	if conn.Subprotocol() != WebSocketSubprotocol {
+		t.Fatalf("Expected %s to be negotiated, got %q", WebSocketSubprotocol, conn.Subprotocol())
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	if err := conn.WriteJSON(map[string]interface{}{"v": 2, "type": "chat", "id": "0", "input": "Hi"}); err != nil {
+		t.Fatal(err)
+	}
+	var rejected WSError
+	if err := conn.ReadJSON(&rejected); err != nil || rejected.Type != WSTypeError || rejected.Code != ProblemInvalidRequest {
+		t.Fatalf("Expected a message of another version to be rejected, got %+v (%v)", rejected, err)
+	}
+
+	if err := conn.WriteJSON(WSChat{WSEnvelope: envelope(WSTypeChat, "1"), Input: "Hi"}); err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for {
+		var message WSEnvelope
+		if err := conn.ReadJSON(&message); err != nil {
+			t.Fatalf("ReadJSON() failed after %v: %v", types, err)
+		}
+		if message.Version != WebSocketProtocolVersion || message.ID != "1" {
+			t.Fatalf("Expected messages of version 1 for chat 1, got %+v", message)
+		}
+		types = append(types, message.Type)
+		if message.Type == WSTypeResult || message.Type == WSTypeError {
+			break
+		}
+	}
+	if types[0] != WSTypeStart || types[1] != WSTypeToken || types[len(types)-1] != WSTypeResult {
+		t.Errorf("Expected a start and a result message, got %v", types)
+	}
+
+	// Cancelling an unknown execution is reported
+	if err := conn.WriteJSON(WSCancel{WSEnvelope: envelope(WSTypeCancel, "unknown")}); err != nil {
+		t.Fatal(err)
+	}
+	var notFound WSError
+	if err := conn.ReadJSON(&notFound); err != nil || notFound.Code != ProblemNotFound {
+		t.Errorf("Expected a not-found error, got %+v (%v)", notFound, err)
+	}
+
+	// Clients requesting only unsupported versions are closed
+	dialer = websocket.Dialer{Subprotocols: []string{"golanggraph.v2"}}
+	unsupported, _, err := dialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial() failed: %v", err)
+	}
+	defer unsupported.Close()
+	unsupported.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, _, err := unsupported.ReadMessage(); !websocket.IsCloseError(err, CloseUnsupportedProtocol) {
+		t.Errorf("Expected close code %d, got %v", CloseUnsupportedProtocol, err)
+	}
+
+	// Legacy clients keep the free-form messages
+	legacy, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial() failed: %v", err)
+	}
+	defer legacy.Close()
+	if legacy.Subprotocol() != "" {
+		t.Errorf("Expected no protocol for legacy clients, got %q", legacy.Subprotocol())
+	}
+}
+
+// blockingProvider answers only once its request is cancelled
+type blockingProvider struct {
+	MockProvider
+	started chan struct{}
+}
+
+func (p *blockingProvider) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	p.started <- struct{}{}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (p *blockingProvider) CompleteWithMode(ctx context.Context, req llm.CompletionRequest, mode llm.StreamMode) (*llm.CompletionResponse, error) {
+	return p.Complete(ctx, req)
+}
+
+func TestServer_CancelExecution(t *testing.T) {
+	provider := &blockingProvider{started: make(chan struct{}, 1)}
+	llmManager := llm.NewProviderManager()
+	if err := llmManager.RegisterProvider("mock", provider); err != nil {
+		t.Fatalf("Failed to register provider: %v", err)
+	}
+	manager := NewAgentManager(llmManager, tools.NewToolRegistry())
+	definition := agent.NewBaseAgentDefinition(&agent.AgentConfig{
+		Name:     "Slow",
+		Type:     agent.AgentTypeChat,
+		Provider: "mock",
+		Model:    "mock-model",
+	})
+	if _, err := manager.RegisterDefinition(context.Background(), "slow", definition); err != nil {
+		t.Fatalf("RegisterDefinition() failed: %v", err)
+	}
+	server := NewServer(nil)
+	server.SetAgentManager(manager)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		req := httptest.NewRequest("POST", "/api/v1/agents/slow/execute", strings.NewReader(`{"input": "Take your time"}`))
+		req.Header.Set(ExecutionIDHeader, "exec-1")
+		rr := httptest.NewRecorder()
+		server.router.ServeHTTP(rr, req)
+		done <- rr
+	}()
+
+	select {
+	case <-provider.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The execution did not start")
+	}
+
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/executions", nil))
+	var listed struct {
+		Executions []ActiveExecution `json:"executions"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &listed)
+	if len(listed.Executions) != 1 || listed.Executions[0].ID != "exec-1" || listed.Executions[0].AgentID != "slow" {
+		t.Fatalf("Expected the execution to be listed, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	server.router.ServeHTTP(rr, httptest.NewRequest("DELETE", "/api/v1/executions/exec-1", nil))
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response *httptest.ResponseRecorder
+	select {
+	case response = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The cancelled execution did not end")
+	}
+	var problem Problem
+	json.Unmarshal(response.Body.Bytes(), &problem)
+	if response.Code != http.StatusConflict || problem.Type != ProblemTypeBase+ProblemExecutionCancelled {
+		t.Errorf("Expected an execution-cancelled problem, got %d: %s", response.Code, response.Body.String())
+	}
+	if response.Header().Get(ExecutionIDHeader) != "exec-1" {
+		t.Errorf("Expected the execution ID header, got %q", response.Header().Get(ExecutionIDHeader))
+	}
+
+	// Finished executions are no longer listed, nor cancellable
+	if executions := server.ActiveExecutions(); len(executions) != 0 {
+		t.Errorf("Expected no execution in flight, got %v", executions)
+	}
+	rr = httptest.NewRecorder()
+	server.router.ServeHTTP(rr, httptest.NewRequest("DELETE", "/api/v1/executions/exec-1", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", rr.Code)
+	}
+}
+
+// metricsFunc writes metrics with a function
+type metricsFunc func(w io.Writer) error
+
+func (f metricsFunc) WritePrometheus(w io.Writer) error { return f(w) }
+
+func TestServer_ToolMetrics(t *testing.T) {
+	server := NewServer(nil)
+	toolRegistry := tools.NewToolRegistry()
+	server.SetToolRegistry(toolRegistry)
+
+	calculator, _ := toolRegistry.GetTool("calculator")
+	if _, err := toolRegistry.ExecuteTool(context.Background(), calculator, `{"expression": "2+2"}`); err != nil {
+		t.Fatalf("ExecuteTool() failed: %v", err)
+	}
+
+	// Other sources, such as database connections, are served after the tool metrics
+	server.AddMetricsSource(metricsFunc(func(w io.Writer) error {
+		_, err := io.WriteString(w, "golanggraph_db_connections_in_use{database=\"agents\"} 2\n")
+		return err
+	}))
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	rr := httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("Unexpected metrics response %v %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rr.Body.String(), `golanggraph_tool_calls_total{tool="calculator",outcome="success"} 1`) {
+		t.Errorf("Expected the calculator call in the metrics, got:\n%s", rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), `golanggraph_db_connections_in_use{database="agents"} 2`) {
+		t.Errorf("Expected the database metrics, got:\n%s", rr.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/tools/calculator/metrics", nil)
+	rr = httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	var response struct {
+		Metrics   tools.ToolStats `json:"metrics"`
+		ErrorRate float64         `json:"error_rate"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal tool metrics: %v", err)
+	}
+	if response.Metrics.Calls != 1 || response.ErrorRate != 0 {
+		t.Errorf("Unexpected tool metrics %+v", response)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/tools/unknown/metrics", nil)
+	rr = httptest.NewRecorder()
+	server.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected %v for an unknown tool, got %v", http.StatusNotFound, rr.Code)
 	}
 }
@@ -0,0 +1,294 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// ErrSessionBusy is returned when a session already has an execution in progress and the
+// server rejects concurrent executions instead of queuing them
+var ErrSessionBusy = errors.New("session has an execution in progress")
+
+const (
+	// sessionLockTTL bounds how long the lock of a crashed server blocks a session; held
+	// locks are refreshed well before it expires
+	sessionLockTTL = 30 * time.Second
+	// sessionLockPollInterval is how often a queued execution retries a busy session
+	sessionLockPollInterval = 50 * time.Millisecond
+)
+
+// SessionLocker serializes the executions of a session. Locks expire after their TTL unless
+// refreshed, so a crashed holder cannot block a session forever.
+type SessionLocker interface {
+	// TryLock acquires the lock of a session, returning false if another execution holds it
+	TryLock(ctx context.Context, sessionID string, ttl time.Duration) (token string, acquired bool, err error)
+
+	// Refresh extends a held lock
+	Refresh(ctx context.Context, sessionID, token string, ttl time.Duration) error
+
+	// Unlock releases a held lock; a lock taken over after expiring is left alone
+	Unlock(ctx context.Context, sessionID, token string) error
+}
+
+// memorySessionLock is a held lock of a MemorySessionLocker
+type memorySessionLock struct {
+	token     string
+	expiresAt time.Time
+}
+
+// MemorySessionLocker is an in-process SessionLocker
+type MemorySessionLocker struct {
+	locks map[string]*memorySessionLock
+	mu    sync.Mutex
+}
+
+// NewMemorySessionLocker creates an in-memory session locker
+func NewMemorySessionLocker() *MemorySessionLocker {
+	return &MemorySessionLocker{
+		locks: make(map[string]*memorySessionLock),
+	}
+}
+
+// TryLock acquires the lock of a session
+func (l *MemorySessionLocker) TryLock(ctx context.Context, sessionID string, ttl time.Duration) (string, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if lock, exists := l.locks[sessionID]; exists && time.Now().Before(lock.expiresAt) {
+		return "", false, nil
+	}
+	token := uuid.New().String()
+	l.locks[sessionID] = &memorySessionLock{token: token, expiresAt: time.Now().Add(ttl)}
+	return token, true, nil
+}
+
+// Refresh extends a held lock
+func (l *MemorySessionLocker) Refresh(ctx context.Context, sessionID, token string, ttl time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock, exists := l.locks[sessionID]
+	if !exists || lock.token != token {
+		return fmt.Errorf("lock of session %s was lost", sessionID)
+	}
+	lock.expiresAt = time.Now().Add(ttl)
+	return nil
+}
+
+// Unlock releases a held lock
+func (l *MemorySessionLocker) Unlock(ctx context.Context, sessionID, token string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if lock, exists := l.locks[sessionID]; exists && lock.token == token {
+		delete(l.locks, sessionID)
+	}
+	return nil
+}
+
+// redisRefreshScript extends a lock only while it holds the caller's token
+var redisRefreshScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// redisUnlockScript deletes a lock only while it holds the caller's token
+var redisUnlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// RedisSessionLocker is a SessionLocker shared between server instances through Redis, so
+// replicas behind a load balancer serialize the executions of a session too
+type RedisSessionLocker struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisSessionLocker creates a Redis session locker; keys are namespaced by prefix
+func NewRedisSessionLocker(client *redis.Client, prefix string) *RedisSessionLocker {
+	if prefix == "" {
+		prefix = "session-lock:"
+	}
+	return &RedisSessionLocker{client: client, prefix: prefix}
+}
+
+// TryLock acquires the lock of a session
+func (l *RedisSessionLocker) TryLock(ctx context.Context, sessionID string, ttl time.Duration) (string, bool, error) {
+	token := uuid.New().String()
+	acquired, err := l.client.SetNX(ctx, l.prefix+sessionID, token, ttl).Result()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to lock session: %w", err)
+	}
+	return token, acquired, nil
+}
+
+// Refresh extends a held lock
+func (l *RedisSessionLocker) Refresh(ctx context.Context, sessionID, token string, ttl time.Duration) error {
+	refreshed, err := redisRefreshScript.Run(ctx, l.client, []string{l.prefix + sessionID}, token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("failed to refresh session lock: %w", err)
+	}
+	if refreshed == 0 {
+		return fmt.Errorf("lock of session %s was lost", sessionID)
+	}
+	return nil
+}
+
+// Unlock releases a held lock
+func (l *RedisSessionLocker) Unlock(ctx context.Context, sessionID, token string) error {
+	return redisUnlockScript.Run(ctx, l.client, []string{l.prefix + sessionID}, token).Err()
+}
+
+// Session store types
+const (
+	// SessionStoreMemory keeps session state in the server process
+	SessionStoreMemory = "memory"
+	// SessionStoreRedis shares session state between replicas through Redis
+	SessionStoreRedis = "redis"
+)
+
+// SessionStoreConfig selects where the state shared by the executions of a session is kept.
+// With Redis, replicas using the same store serialize the executions of a session together.
+type SessionStoreConfig struct {
+	// Type is memory, the default, or redis
+	Type     string `json:"type" yaml:"type"`
+	Addr     string `json:"addr,omitempty" yaml:"addr,omitempty"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+	DB       int    `json:"db,omitempty" yaml:"db,omitempty"`
+	// KeyPrefix namespaces the session lock keys; "session-lock:" by default
+	KeyPrefix string `json:"key_prefix,omitempty" yaml:"key_prefix,omitempty"`
+}
+
+// NewSessionLocker creates the session locker of a session store
+func NewSessionLocker(config SessionStoreConfig) (SessionLocker, error) {
+	switch config.Type {
+	case "", SessionStoreMemory:
+		return NewMemorySessionLocker(), nil
+	case SessionStoreRedis:
+		if config.Addr == "" {
+			return nil, fmt.Errorf("redis session store requires an address")
+		}
+		client := redis.NewClient(&redis.Options{
+			Addr:     config.Addr,
+			Password: config.Password,
+			DB:       config.DB,
+		})
+		return NewRedisSessionLocker(client, config.KeyPrefix), nil
+	default:
+		return nil, fmt.Errorf("unsupported session store type: %s", config.Type)
+	}
+}
+
+// Ping checks that Redis is reachable
+func (l *RedisSessionLocker) Ping(ctx context.Context) error {
+	if err := l.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to reach redis: %w", err)
+	}
+	return nil
+}
+
+// sessionStorePingTimeout bounds the check that the session store is reachable at startup
+const sessionStorePingTimeout = 5 * time.Second
+
+// SetSessionLocker replaces the locker serializing the executions of a session, e.g. with a
+// RedisSessionLocker when replicas share sessions
+func (s *Server) SetSessionLocker(locker SessionLocker) {
+	s.sessionLocker = locker
+	s.sessionStoreErr = nil
+}
+
+// checkSessionStore fails when the configured session store could not be built or, for
+// Redis, reached
+func (s *Server) checkSessionStore() error {
+	if s.sessionStoreErr != nil {
+		return s.sessionStoreErr
+	}
+	redisLocker, ok := s.sessionLocker.(*RedisSessionLocker)
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sessionStorePingTimeout)
+	defer cancel()
+	if err := redisLocker.Ping(ctx); err != nil {
+		return fmt.Errorf("session store: %w", err)
+	}
+	return nil
+}
+
+// lockSession waits until no other execution of a session runs, or fails with
+// ErrSessionBusy when busy sessions are rejected. The returned function releases the lock;
+// executions without a session, or with AllowConcurrentPerSession set, are not serialized.
+func (s *Server) lockSession(ctx context.Context, sessionID string) (func(), error) {
+	if sessionID == "" || s.config.AllowConcurrentPerSession {
+		return func() {}, nil
+	}
+	if s.sessionStoreErr != nil {
+		return nil, s.sessionStoreErr
+	}
+	if s.sessionLocker == nil {
+		return func() {}, nil
+	}
+
+	for {
+		token, acquired, err := s.sessionLocker.TryLock(ctx, sessionID, sessionLockTTL)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			return s.holdSessionLock(sessionID, token), nil
+		}
+		if s.config.RejectBusySession {
+			return nil, fmt.Errorf("%w: %s", ErrSessionBusy, sessionID)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(sessionLockPollInterval):
+		}
+	}
+}
+
+// holdSessionLock refreshes a session lock until the returned function releases it
+func (s *Server) holdSessionLock(sessionID, token string) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(sessionLockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := s.sessionLocker.Refresh(context.Background(), sessionID, token, sessionLockTTL); err != nil {
+					s.logger.WithError(err).WithField("session_id", sessionID).Warn("Failed to refresh session lock")
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			if err := s.sessionLocker.Unlock(context.Background(), sessionID, token); err != nil {
+				s.logger.WithError(err).WithField("session_id", sessionID).Warn("Failed to release session lock")
+			}
+		})
+	}
+}
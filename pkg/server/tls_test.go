@@ -0,0 +1,166 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCertificate is a certificate with its key, signed by a parent or self-signed
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCertificate(t *testing.T, name string, parent *testCertificate, isCA bool) *testCertificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCertificate{cert: cert, key: key, der: der}
+}
+
+// write stores the certificate and key as PEM files
+func (c *testCertificate) write(t *testing.T, dir, name string) (string, string) {
+	t.Helper()
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	certFile, keyFile := filepath.Join(dir, name+".pem"), filepath.Join(dir, name+"-key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile
+}
+
+func (c *testCertificate) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func TestServer_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCertificate(t, "test-ca", nil, true)
+	serverCert := newTestCertificate(t, "server", ca, false)
+	client := newTestCertificate(t, "client", ca, false)
+	stranger := newTestCertificate(t, "stranger", nil, false)
+
+	caFile, _ := ca.write(t, dir, "ca")
+	certFile, keyFile := serverCert.write(t, dir, "server")
+
+	server := NewServer(&ServerConfig{TLS: &TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile, MinVersion: "1.3"}})
+	certificates, err := newCertificateStore(*server.config.TLS)
+	if err != nil {
+		t.Fatalf("newCertificateStore() failed: %v", err)
+	}
+	server.certificates = certificates
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", certificates.tlsConfig())
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	httpServer := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	})}
+	go httpServer.Serve(listener)
+	defer httpServer.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	get := func(certificates ...tls.Certificate) (*http.Response, error) {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certificates}}}
+		return client.Get("https://" + listener.Addr().String())
+	}
+
+	resp, err := get(client.tlsCertificate())
+	if err != nil {
+		t.Fatalf("Expected a client certificate signed by the CA to be accepted, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.TLS.Version != tls.VersionTLS13 {
+		t.Errorf("Expected TLS 1.3, got %x", resp.TLS.Version)
+	}
+
+	if resp, err := get(); err == nil {
+		resp.Body.Close()
+		t.Error("Expected a connection without a client certificate to be rejected")
+	}
+	if resp, err := get(stranger.tlsCertificate()); err == nil {
+		resp.Body.Close()
+		t.Error("Expected a client certificate of another CA to be rejected")
+	}
+
+	// A rotated certificate is served after a reload
+	rotated := newTestCertificate(t, "rotated", ca, false)
+	rotated.write(t, dir, "server")
+	if err := server.ReloadCertificates(); err != nil {
+		t.Fatalf("ReloadCertificates() failed: %v", err)
+	}
+	resp, err = get(client.tlsCertificate())
+	if err != nil {
+		t.Fatalf("Request after reload failed: %v", err)
+	}
+	resp.Body.Close()
+	if served := resp.TLS.PeerCertificates[0].Subject.CommonName; served != "rotated" {
+		t.Errorf("Expected the rotated certificate, got %s", served)
+	}
+
+	// A broken file keeps the current certificate
+	os.WriteFile(keyFile, []byte("not a key"), 0o600)
+	if err := server.ReloadCertificates(); err == nil {
+		t.Error("Expected reloading an invalid key to fail")
+	}
+}
+
+func TestTLSConfig_Validation(t *testing.T) {
+	if (*TLSConfig)(nil).Enabled() || (&TLSConfig{}).Enabled() {
+		t.Error("Expected TLS to be disabled without a certificate")
+	}
+	if _, err := newCertificateStore(TLSConfig{CertFile: "cert.pem"}); err == nil {
+		t.Error("Expected a missing key file to be rejected")
+	}
+	if _, err := newCertificateStore(TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem", MinVersion: "1.0"}); err == nil {
+		t.Error("Expected TLS 1.0 to be rejected")
+	}
+	if err := NewServer(nil).ReloadCertificates(); err == nil {
+		t.Error("Expected reloading without TLS to fail")
+	}
+}
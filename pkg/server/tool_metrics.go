@@ -0,0 +1,92 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package server
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/gorilla/mux"
+)
+
+// prometheusContentType is the content type of the Prometheus text exposition format
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// MetricsSource writes metrics in the Prometheus text exposition format, such as the query
+// and connection pool metrics of a *persistence.PostgresConnection
+type MetricsSource interface {
+	WritePrometheus(w io.Writer) error
+}
+
+// AddMetricsSource serves the metrics of a source at /metrics. The database connection of
+// the session manager is served without being added.
+func (s *Server) AddMetricsSource(source MetricsSource) {
+	s.metricsSources = append(s.metricsSources, source)
+}
+
+// prometheusSources returns the sources served at /metrics besides the tool metrics
+func (s *Server) prometheusSources() []MetricsSource {
+	sources := s.metricsSources
+	if s.sessionManager == nil {
+		return sources
+	}
+	connection, ok := s.sessionManager.Connection().(MetricsSource)
+	if !ok {
+		return sources
+	}
+	for _, source := range sources {
+		if reflect.TypeOf(source).Comparable() && source == connection {
+			return sources
+		}
+	}
+	return append([]MetricsSource{connection}, sources...)
+}
+
+// handlePrometheusMetrics serves the tool metrics and those of the metrics sources in the
+// Prometheus text exposition format
+func (s *Server) handlePrometheusMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", prometheusContentType)
+	w.WriteHeader(http.StatusOK)
+	if s.toolRegistry != nil && s.toolRegistry.Metrics() != nil {
+		if err := s.toolRegistry.Metrics().WritePrometheus(w); err != nil {
+			s.logger.WithError(err).Warn("Failed to write metrics")
+			return
+		}
+	}
+	for _, source := range s.prometheusSources() {
+		if err := source.WritePrometheus(w); err != nil {
+			s.logger.WithError(err).Warn("Failed to write metrics")
+			return
+		}
+	}
+}
+
+// handleGetToolMetrics returns the call metrics of a tool, with the error rate and latency
+// quantiles computed
+func (s *Server) handleGetToolMetrics(w http.ResponseWriter, r *http.Request) {
+	if s.toolRegistry == nil || s.toolRegistry.Metrics() == nil {
+		writeError(w, r, http.StatusServiceUnavailable, "Tool metrics not available")
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	if _, exists := s.toolRegistry.GetTool(name); !exists {
+		writeError(w, r, http.StatusNotFound, "Tool not found")
+		return
+	}
+
+	stats, _ := s.toolRegistry.Metrics().Stats(name)
+	stats.Tool = name
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"metrics":     stats,
+		"error_rate":  stats.ErrorRate(),
+		"latency_p50": stats.Latency.Quantile(0.5),
+		"latency_p95": stats.Latency.Quantile(0.95),
+		"latency_p99": stats.Latency.Quantile(0.99),
+	})
+}
@@ -0,0 +1,338 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PriorityHeader is the request header setting the priority of an execution: "high", "normal"
+// or "low"
+const PriorityHeader = "X-Priority"
+
+// ErrQueueFull is returned when the work queue has no room for another waiting execution
+var ErrQueueFull = errors.New("work queue full")
+
+// Priority is the scheduling priority of an execution
+type Priority int
+
+const (
+	// PriorityLow is for batch and scheduled jobs
+	PriorityLow Priority = iota
+	// PriorityNormal is for graph executions without a priority
+	PriorityNormal
+	// PriorityHigh is for interactive requests such as chat
+	PriorityHigh
+)
+
+// String returns the name of the priority
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityHigh:
+		return "high"
+	default:
+		return "normal"
+	}
+}
+
+// ParsePriority parses a priority name, case-insensitively
+func ParsePriority(name string) (Priority, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "low", "batch":
+		return PriorityLow, nil
+	case "normal", "":
+		return PriorityNormal, nil
+	case "high", "interactive":
+		return PriorityHigh, nil
+	}
+	return PriorityNormal, fmt.Errorf("unknown priority: %s", name)
+}
+
+// WorkQueueConfig configures the scheduling of executions by priority
+type WorkQueueConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Workers is the number of executions run at once
+	Workers int `json:"workers" yaml:"workers"`
+	// MaxDepth is the number of executions that may wait for a worker; more are rejected
+	MaxDepth int `json:"max_depth" yaml:"max_depth"`
+	// AgingInterval raises a waiting execution by one priority level each time it elapses,
+	// so low-priority work is not starved under a steady stream of interactive requests
+	AgingInterval time.Duration `json:"aging_interval" yaml:"aging_interval"`
+}
+
+// DefaultWorkQueueConfig returns a disabled work queue configuration with usable limits
+func DefaultWorkQueueConfig() WorkQueueConfig {
+	return WorkQueueConfig{
+		Workers:       8,
+		MaxDepth:      100,
+		AgingInterval: 5 * time.Second,
+	}
+}
+
+// PriorityQueueStats are the metrics of a priority level
+type PriorityQueueStats struct {
+	Depth     int   `json:"depth"`
+	Started   int64 `json:"started"`
+	Rejected  int64 `json:"rejected"`
+	AvgWaitMs int64 `json:"avg_wait_ms"`
+	MaxWaitMs int64 `json:"max_wait_ms"`
+}
+
+// WorkQueueStats are the metrics of a work queue
+type WorkQueueStats struct {
+	Workers    int                           `json:"workers"`
+	Running    int                           `json:"running"`
+	Depth      int                           `json:"depth"`
+	Priorities map[string]PriorityQueueStats `json:"priorities"`
+}
+
+// queuedExecution is an execution waiting for a worker
+type queuedExecution struct {
+	priority Priority
+	enqueued time.Time
+	seq      uint64
+	ready    chan struct{}
+}
+
+// waitStats accumulates the wait times of a priority level
+type waitStats struct {
+	started  int64
+	rejected int64
+	total    time.Duration
+	max      time.Duration
+}
+
+// WorkQueue schedules executions on a fixed number of workers, starting the waiting
+// execution with the highest priority first. A waiting execution gains a priority level per
+// aging interval, and executions of the same effective priority start in arrival order.
+type WorkQueue struct {
+	config WorkQueueConfig
+
+	mu      sync.Mutex
+	running int
+	waiting []*queuedExecution
+	seq     uint64
+	stats   map[Priority]*waitStats
+}
+
+// NewWorkQueue creates a work queue; zero limits use the defaults
+func NewWorkQueue(config WorkQueueConfig) *WorkQueue {
+	defaults := DefaultWorkQueueConfig()
+	if config.Workers <= 0 {
+		config.Workers = defaults.Workers
+	}
+	if config.MaxDepth <= 0 {
+		config.MaxDepth = defaults.MaxDepth
+	}
+	if config.AgingInterval <= 0 {
+		config.AgingInterval = defaults.AgingInterval
+	}
+	return &WorkQueue{
+		config: config,
+		stats:  make(map[Priority]*waitStats),
+	}
+}
+
+// Acquire waits for a worker for an execution of a priority, returning the function that
+// frees the worker once the execution ends. It fails with ErrQueueFull when too many
+// executions are waiting, or with the context's error if it ends first.
+func (q *WorkQueue) Acquire(ctx context.Context, priority Priority) (func(), error) {
+	q.mu.Lock()
+	stats := q.statsFor(priority)
+	if q.running < q.config.Workers && len(q.waiting) == 0 {
+		q.running++
+		stats.started++
+		q.mu.Unlock()
+		return q.releaseFunc(), nil
+	}
+	if len(q.waiting) >= q.config.MaxDepth {
+		stats.rejected++
+		q.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+	q.seq++
+	execution := &queuedExecution{priority: priority, enqueued: time.Now(), seq: q.seq, ready: make(chan struct{})}
+	q.waiting = append(q.waiting, execution)
+	q.mu.Unlock()
+
+	select {
+	case <-execution.ready:
+		return q.releaseFunc(), nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		select {
+		case <-execution.ready:
+			// A worker was handed over as the context ended; pass it on
+			q.running--
+			q.dispatch()
+		default:
+			q.remove(execution)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// releaseFunc returns a function freeing a worker once
+func (q *WorkQueue) releaseFunc() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			q.running--
+			q.dispatch()
+		})
+	}
+}
+
+// dispatch starts waiting executions while workers are free; it is called with the lock held
+func (q *WorkQueue) dispatch() {
+	now := time.Now()
+	for q.running < q.config.Workers && len(q.waiting) > 0 {
+		next := 0
+		for i := 1; i < len(q.waiting); i++ {
+			if q.before(q.waiting[i], q.waiting[next], now) {
+				next = i
+			}
+		}
+		execution := q.waiting[next]
+		q.waiting = append(q.waiting[:next], q.waiting[next+1:]...)
+
+		waited := now.Sub(execution.enqueued)
+		stats := q.statsFor(execution.priority)
+		stats.started++
+		stats.total += waited
+		if waited > stats.max {
+			stats.max = waited
+		}
+		q.running++
+		close(execution.ready)
+	}
+}
+
+// before reports whether an execution should start before another
+func (q *WorkQueue) before(a, b *queuedExecution, now time.Time) bool {
+	pa, pb := q.effectivePriority(a, now), q.effectivePriority(b, now)
+	if pa != pb {
+		return pa > pb
+	}
+	return a.seq < b.seq
+}
+
+// effectivePriority is the priority of an execution raised by the time it waited
+func (q *WorkQueue) effectivePriority(execution *queuedExecution, now time.Time) int {
+	return int(execution.priority) + int(now.Sub(execution.enqueued)/q.config.AgingInterval)
+}
+
+// remove drops a waiting execution; it is called with the lock held
+func (q *WorkQueue) remove(execution *queuedExecution) {
+	for i, waiting := range q.waiting {
+		if waiting == execution {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+// statsFor returns the wait statistics of a priority; it is called with the lock held
+func (q *WorkQueue) statsFor(priority Priority) *waitStats {
+	stats, exists := q.stats[priority]
+	if !exists {
+		stats = &waitStats{}
+		q.stats[priority] = stats
+	}
+	return stats
+}
+
+// Stats returns the queue depth and wait times by priority
+func (q *WorkQueue) Stats() WorkQueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	result := WorkQueueStats{
+		Workers:    q.config.Workers,
+		Running:    q.running,
+		Depth:      len(q.waiting),
+		Priorities: make(map[string]PriorityQueueStats),
+	}
+	for _, priority := range []Priority{PriorityLow, PriorityNormal, PriorityHigh} {
+		stats := q.statsFor(priority)
+		level := PriorityQueueStats{
+			Started:   stats.started,
+			Rejected:  stats.rejected,
+			MaxWaitMs: stats.max.Milliseconds(),
+		}
+		if stats.started > 0 {
+			level.AvgWaitMs = (stats.total / time.Duration(stats.started)).Milliseconds()
+		}
+		result.Priorities[priority.String()] = level
+	}
+	for _, execution := range q.waiting {
+		level := result.Priorities[execution.priority.String()]
+		level.Depth++
+		result.Priorities[execution.priority.String()] = level
+	}
+	return result
+}
+
+// SetWorkQueue sets the queue scheduling executions by priority; nil runs them immediately
+func (s *Server) SetWorkQueue(queue *WorkQueue) {
+	s.queue = queue
+}
+
+// schedule wraps a handler running an execution so it waits for a worker of the work queue.
+// The priority is read from the X-Priority header, defaulting to the endpoint's priority.
+func (s *Server) schedule(priority Priority, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.queue == nil {
+			handler(w, r)
+			return
+		}
+		if header := r.Header.Get(PriorityHeader); header != "" {
+			parsed, err := ParsePriority(header)
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, err.Error())
+				return
+			}
+			priority = parsed
+		}
+
+		release, err := s.queue.Acquire(r.Context(), priority)
+		if err != nil {
+			if errors.Is(err, ErrQueueFull) {
+				retryAfter := s.reconnectAdvice(reconnectReasonOverloaded, 0).RetryAfterMs
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(float64(retryAfter)/1000))))
+				writeProblem(w, r, NewProblem(http.StatusServiceUnavailable, ProblemOverloaded,
+					"Too many executions are waiting, retry later"))
+			}
+			// A client gone while waiting gets no response
+			return
+		}
+		defer release()
+		handler(w, r)
+	}
+}
+
+// handleQueueStats reports the work queue's depth and wait times
+func (s *Server) handleQueueStats(w http.ResponseWriter, r *http.Request) {
+	if s.queue == nil {
+		writeError(w, r, http.StatusNotFound, "Work queue not enabled")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.queue.Stats())
+}
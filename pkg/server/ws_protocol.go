@@ -0,0 +1,360 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/agent"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
+)
+
+const (
+	// WebSocketSubprotocol is the versioned WebSocket protocol of the agent streams. Clients
+	// request it in the Sec-WebSocket-Protocol header to exchange typed WSMessage values;
+	// clients requesting no protocol keep the legacy free-form messages.
+	WebSocketSubprotocol = "golanggraph.v1"
+	// WebSocketProtocolVersion is the version carried by every message of the protocol
+	WebSocketProtocolVersion = 1
+
+	// subprotocolPrefix starts the names of every version of the protocol
+	subprotocolPrefix = "golanggraph."
+)
+
+// CloseUnsupportedProtocol is the close code sent to clients requesting only versions of
+// the protocol the server does not speak
+const CloseUnsupportedProtocol = 4406
+
+// Types of the messages of the protocol
+const (
+	// Sent by clients
+	WSTypeChat   = "chat"
+	WSTypeCancel = "cancel"
+
+	// Sent by the server
+	WSTypeStart      = "start"
+	WSTypeToken      = "token"
+	WSTypeThought    = "thought"
+	WSTypeToolCall   = "tool_call"
+	WSTypeToolResult = "tool_result"
+	WSTypeComplete   = "complete"
+	WSTypeIncomplete = "incomplete"
+	WSTypeResult     = "result"
+	WSTypeCancelled  = "cancelled"
+	WSTypeError      = "error"
+)
+
+// WSEnvelope is the header of every message: the protocol version, the type discriminating
+// the message, and the ID of the chat message the message belongs to. Messages of the legacy
+// protocol have no version.
+type WSEnvelope struct {
+	Version int    `json:"v,omitempty"`
+	Type    string `json:"type"`
+	ID      string `json:"id,omitempty"`
+}
+
+// WSChat asks the agent of the connection to run an execution
+type WSChat struct {
+	WSEnvelope
+	Input     string `json:"input"`
+	SessionID string `json:"session_id,omitempty"`
+	// Granularity coalesces streamed tokens into words or sentences
+	Granularity agent.StreamGranularity `json:"granularity,omitempty"`
+	// Structured ends the stream with the answer parsed as JSON, repaired when cut short
+	Structured bool `json:"structured,omitempty"`
+}
+
+// WSCancel stops the execution started by the chat message with the same ID
+type WSCancel struct {
+	WSEnvelope
+}
+
+// WSStart acknowledges a chat message as its execution starts
+type WSStart struct {
+	WSEnvelope
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// WSToken carries a token of the answer, or a thought with the thought type
+type WSToken struct {
+	WSEnvelope
+	Content string `json:"content"`
+}
+
+// WSToolEvent announces a tool call, or carries its result with the tool result type
+type WSToolEvent struct {
+	WSEnvelope
+	ToolName  string `json:"tool_name"`
+	Arguments string `json:"arguments,omitempty"`
+	Content   string `json:"content,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// WSStructured carries the answer of a structured chat as a JSON object, with the complete
+// type, or what was streamed of it with the incomplete type
+type WSStructured struct {
+	WSEnvelope
+	Object      interface{} `json:"object,omitempty"`
+	Repaired    bool        `json:"repaired,omitempty"`
+	Interrupted bool        `json:"interrupted,omitempty"`
+	Partial     string      `json:"partial,omitempty"`
+	Error       string      `json:"error,omitempty"`
+	Timestamp   time.Time   `json:"timestamp"`
+}
+
+// WSResult is the last message of a successful execution
+type WSResult struct {
+	WSEnvelope
+	Execution *agent.AgentExecution `json:"execution"`
+}
+
+// WSError reports a failed execution or an invalid message; Code is a problem code such
+// as "rate-limited"
+type WSError struct {
+	WSEnvelope
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// negotiateSubprotocol picks the protocol of a WebSocket request: the supported version
+// when requested, none for legacy clients, or an error when the client only requests
+// versions the server does not speak
+func negotiateSubprotocol(r *http.Request) (string, error) {
+	var requested []string
+	for _, protocol := range websocket.Subprotocols(r) {
+		if protocol == WebSocketSubprotocol {
+			return WebSocketSubprotocol, nil
+		}
+		if strings.HasPrefix(protocol, subprotocolPrefix) {
+			requested = append(requested, protocol)
+		}
+	}
+	if len(requested) > 0 {
+		return "", fmt.Errorf("unsupported protocol %s, the server speaks %s", strings.Join(requested, ", "), WebSocketSubprotocol)
+	}
+	return "", nil
+}
+
+// upgradeAgentWebSocket upgrades an agent stream request with the negotiated protocol.
+// Clients requesting only unsupported versions are closed with CloseUnsupportedProtocol.
+func (s *Server) upgradeAgentWebSocket(w http.ResponseWriter, r *http.Request) (*websocket.Conn, bool) {
+	protocol, negotiationErr := negotiateSubprotocol(r)
+	header := http.Header{}
+	if protocol != "" {
+		header.Set("Sec-WebSocket-Protocol", protocol)
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, header)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to upgrade WebSocket")
+		return nil, false
+	}
+	if negotiationErr != nil {
+		message := websocket.FormatCloseMessage(CloseUnsupportedProtocol, negotiationErr.Error())
+		conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+		conn.Close()
+		return nil, false
+	}
+	return conn, true
+}
+
+// wsSession serves the versioned protocol on a connection: writes are serialized, and the
+// executions in flight can be cancelled by the ID of their chat message
+type wsSession struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+	running map[string]context.CancelFunc
+	mu      sync.Mutex
+}
+
+// write sends a message of the protocol
+func (ws *wsSession) write(message interface{}) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	return ws.conn.WriteJSON(message)
+}
+
+// envelope returns the header of a message of the server
+func envelope(messageType, id string) WSEnvelope {
+	return WSEnvelope{Version: WebSocketProtocolVersion, Type: messageType, ID: id}
+}
+
+// serveAgentWebSocket reads the messages of a connection speaking the versioned protocol
+func (s *Server) serveAgentWebSocket(r *http.Request, conn *websocket.Conn, agentID string) {
+	ws := &wsSession{conn: conn, running: make(map[string]context.CancelFunc)}
+	defer func() {
+		ws.mu.Lock()
+		for _, cancel := range ws.running {
+			cancel()
+		}
+		ws.mu.Unlock()
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			s.logger.WithError(err).Debug("WebSocket closed")
+			return
+		}
+
+		var header WSEnvelope
+		if err := json.Unmarshal(data, &header); err != nil {
+			ws.write(WSError{WSEnvelope: envelope(WSTypeError, ""), Code: ProblemInvalidRequest, Message: "invalid message: " + err.Error()})
+			continue
+		}
+		if header.Version != WebSocketProtocolVersion {
+			ws.write(WSError{WSEnvelope: envelope(WSTypeError, header.ID), Code: ProblemInvalidRequest,
+				Message: fmt.Sprintf("unsupported message version %d, expected %d", header.Version, WebSocketProtocolVersion)})
+			continue
+		}
+
+		switch header.Type {
+		case WSTypeChat:
+			var chat WSChat
+			if err := json.Unmarshal(data, &chat); err != nil || chat.ID == "" {
+				ws.write(WSError{WSEnvelope: envelope(WSTypeError, header.ID), Code: ProblemInvalidRequest, Message: "chat messages need an id and an input"})
+				continue
+			}
+			s.startWebSocketChat(r, ws, agentID, chat)
+		case WSTypeCancel:
+			ws.mu.Lock()
+			cancel, exists := ws.running[header.ID]
+			ws.mu.Unlock()
+			if !exists {
+				ws.write(WSError{WSEnvelope: envelope(WSTypeError, header.ID), Code: ProblemNotFound, Message: "no execution in flight with this id"})
+				continue
+			}
+			cancel()
+		default:
+			ws.write(WSError{WSEnvelope: envelope(WSTypeError, header.ID), Code: ProblemInvalidRequest, Message: fmt.Sprintf("unknown message type %q", header.Type)})
+		}
+	}
+}
+
+// startWebSocketChat starts the execution of a chat message, streaming its events
+func (s *Server) startWebSocketChat(r *http.Request, ws *wsSession, agentID string, chat WSChat) {
+	if s.agentManager == nil {
+		ws.write(WSError{WSEnvelope: envelope(WSTypeError, chat.ID), Code: ProblemServiceUnavailable, Message: "agent manager not configured"})
+		return
+	}
+	agentInstance, exists := s.agentManager.GetAgent(agentID)
+	if !exists {
+		ws.write(WSError{WSEnvelope: envelope(WSTypeError, chat.ID), Code: ProblemNotFound, Message: ErrAgentNotFound.Error()})
+		return
+	}
+	sessionID := chat.SessionID
+	if sessionID == "" {
+		sessionID = logging.SessionID(r.Context())
+	}
+
+	ctx, cancel := s.streamContext(logging.WithSessionID(context.Background(), sessionID))
+	ws.mu.Lock()
+	if _, exists := ws.running[chat.ID]; exists {
+		ws.mu.Unlock()
+		cancel()
+		ws.write(WSError{WSEnvelope: envelope(WSTypeError, chat.ID), Code: ProblemConflict, Message: "an execution with this id is in flight"})
+		return
+	}
+	ws.running[chat.ID] = cancel
+	ws.mu.Unlock()
+
+	go func() {
+		defer func() {
+			ws.mu.Lock()
+			delete(ws.running, chat.ID)
+			ws.mu.Unlock()
+			cancel()
+		}()
+		s.streamWebSocketChat(ctx, ws, agentInstance, sessionID, chat)
+	}()
+}
+
+// streamWebSocketChat runs a chat execution and sends its events as typed messages
+func (s *Server) streamWebSocketChat(ctx context.Context, ws *wsSession, agentInstance *agent.Agent, sessionID string, chat WSChat) {
+	writeError := func(err error) {
+		if errors.Is(err, context.Canceled) && !s.isClosing() {
+			ws.write(envelope(WSTypeCancelled, chat.ID))
+			return
+		}
+		_, code := problemForError(err)
+		ws.write(WSError{WSEnvelope: envelope(WSTypeError, chat.ID), Code: code, Message: err.Error()})
+		if advice, transient := s.reconnectAdviceFor(err); transient {
+			closeWebSocket(ws.conn, advice)
+		}
+	}
+
+	unlock, err := s.lockSession(ctx, sessionID)
+	if err != nil {
+		writeError(err)
+		return
+	}
+	defer unlock()
+
+	options := &agent.ExecuteOptions{Stream: agent.StreamOptions{Granularity: chat.Granularity}}
+	events, err := agentInstance.StreamWithOptions(ctx, chat.Input, options)
+	if err != nil {
+		writeError(err)
+		return
+	}
+	ws.write(WSStart{WSEnvelope: envelope(WSTypeStart, chat.ID), Timestamp: time.Now()})
+
+	var answer *structuredStream
+	if chat.Structured {
+		answer = &structuredStream{}
+	}
+	ended := false
+	for event := range events {
+		if answer != nil {
+			answer.add(event)
+		}
+		switch event.Type {
+		case agent.StreamEventToken:
+			ws.write(WSToken{WSEnvelope: envelope(WSTypeToken, chat.ID), Content: event.Content})
+		case agent.StreamEventThought:
+			ws.write(WSToken{WSEnvelope: envelope(WSTypeThought, chat.ID), Content: event.Content})
+		case agent.StreamEventToolCall:
+			ws.write(WSToolEvent{WSEnvelope: envelope(WSTypeToolCall, chat.ID), ToolName: event.ToolName, Arguments: event.Arguments})
+		case agent.StreamEventToolResult:
+			ws.write(WSToolEvent{WSEnvelope: envelope(WSTypeToolResult, chat.ID), ToolName: event.ToolName, Content: event.Content, Error: event.Error})
+		case agent.StreamEventError:
+			ended = true
+			if answer != nil {
+				ws.write(structuredMessage(chat.ID, answer.result(event.Execution, true)))
+			}
+			writeError(event.Err)
+		case agent.StreamEventDone:
+			ended = true
+			if answer != nil {
+				ws.write(structuredMessage(chat.ID, answer.result(event.Execution, false)))
+			}
+			ws.write(WSResult{WSEnvelope: envelope(WSTypeResult, chat.ID), Execution: event.Execution})
+		}
+	}
+
+	// The final event is dropped when the execution is cancelled
+	if !ended {
+		if answer != nil {
+			ws.write(structuredMessage(chat.ID, answer.result(nil, true)))
+		}
+		writeError(context.Canceled)
+	}
+}
+
+// structuredMessage addresses the final event of a structured stream to a chat message
+func structuredMessage(id string, message WSStructured) WSStructured {
+	message.WSEnvelope = envelope(message.Type, id)
+	return message
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
+)
+
+// RedactedValue replaces the values of redacted argument fields in audit events
+const RedactedValue = "[REDACTED]"
+
+// ToolAuditEvent records a tool invocation
+type ToolAuditEvent struct {
+	ID          string    `json:"id"`
+	Timestamp   time.Time `json:"timestamp"`
+	AgentID     string    `json:"agent_id,omitempty"`
+	SessionID   string    `json:"session_id,omitempty"`
+	ExecutionID string    `json:"execution_id,omitempty"`
+	ToolName    string    `json:"tool_name"`
+	// Arguments are the call arguments with the tool's redacted fields replaced
+	Arguments   string        `json:"arguments"`
+	ResultBytes int           `json:"result_bytes"`
+	Duration    time.Duration `json:"duration"`
+	Success     bool          `json:"success"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// ToolAuditor records the tool invocations of agents, e.g. for compliance
+type ToolAuditor interface {
+	AuditToolCall(ctx context.Context, event *ToolAuditEvent) error
+}
+
+// SetAuditor makes the registry record every ExecuteTool call with the auditor; nil disables auditing
+func (tr *ToolRegistry) SetAuditor(auditor ToolAuditor) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.auditor = auditor
+}
+
+// SetAuditRedactedFields sets the argument fields of a tool whose values are redacted from
+// audit events, matched case-insensitively at any depth of the JSON arguments
+func (tr *ToolRegistry) SetAuditRedactedFields(name string, fields ...string) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if tr.auditRedactedFields == nil {
+		tr.auditRedactedFields = make(map[string][]string)
+	}
+	tr.auditRedactedFields[name] = fields
+}
+
+// ExecuteTool runs a tool within its limits, records the invocation in the metrics and with
+// the auditor, and caps the result with LimitResult. Metrics and audit events count the bytes
+// the tool returned. A failure to record is logged rather than failing the call, which has
+// already taken effect.
+func (tr *ToolRegistry) ExecuteTool(ctx context.Context, tool Tool, args string) (string, error) {
+	if err := tr.takeQuota(ctx, tool.GetName()); err != nil {
+		tr.observeCall(ctx, tool.GetName(), 0, 0, err)
+		return "", err
+	}
+
+	start := time.Now()
+	result, err := tool.Execute(ctx, args)
+	duration := time.Since(start)
+	tr.observeCall(ctx, tool.GetName(), duration, len(result), err)
+
+	tr.mu.RLock()
+	auditor := tr.auditor
+	redacted := tr.auditRedactedFields[tool.GetName()]
+	logger := tr.logger
+	tr.mu.RUnlock()
+
+	if auditor != nil {
+		event := &ToolAuditEvent{
+			ID:          uuid.New().String(),
+			Timestamp:   start,
+			AgentID:     logging.AgentID(ctx),
+			SessionID:   logging.SessionID(ctx),
+			ExecutionID: logging.ExecutionID(ctx),
+			ToolName:    tool.GetName(),
+			Arguments:   RedactArguments(args, redacted),
+			ResultBytes: len(result),
+			Duration:    duration,
+			Success:     err == nil,
+		}
+		if err != nil {
+			event.Error = err.Error()
+		}
+
+		// Record the call even when the execution was cancelled
+		if auditErr := auditor.AuditToolCall(context.WithoutCancel(ctx), event); auditErr != nil {
+			logging.FromContext(ctx, logger).WithError(auditErr).WithField("tool", tool.GetName()).Warn("Failed to record tool audit event")
+		}
+	}
+
+	if err == nil {
+		result = tr.LimitResult(ctx, tool.GetName(), result)
+	}
+	return result, err
+}
+
+// RedactArguments replaces the values of the given fields in JSON arguments. Arguments that
+// are not JSON are redacted entirely when any field is to be redacted, since their sensitive
+// parts cannot be located.
+func RedactArguments(args string, fields []string) string {
+	if len(fields) == 0 {
+		return args
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(args), &decoded); err != nil {
+		return RedactedValue
+	}
+
+	redacted := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		redacted[strings.ToLower(field)] = true
+	}
+
+	encoded, err := json.Marshal(redactValue(decoded, redacted))
+	if err != nil {
+		return RedactedValue
+	}
+	return string(encoded)
+}
+
+// redactValue redacts the fields of the objects in a decoded JSON value
+func redactValue(value interface{}, fields map[string]bool) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range typed {
+			if fields[strings.ToLower(key)] {
+				typed[key] = RedactedValue
+			} else {
+				typed[key] = redactValue(nested, fields)
+			}
+		}
+	case []interface{}:
+		for i, nested := range typed {
+			typed[i] = redactValue(nested, fields)
+		}
+	}
+	return value
+}
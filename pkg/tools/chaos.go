@@ -0,0 +1,98 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package tools
+
+import (
+	"context"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// ChaosRegistry is a tool registry whose tools fail as configured, for testing that agents
+// and graphs handle tool failures. It is not meant for production use.
+type ChaosRegistry struct {
+	*ToolRegistry
+	injector *llm.ChaosInjector
+	targets  map[string]bool
+}
+
+// NewChaosRegistry wraps the tools of a registry, in place, with the failures of a
+// configuration: errors, added latency, truncated results and empty results. Only the named
+// tools fail when names are given. Agents use the wrapped tools through ChaosRegistry's
+// embedded ToolRegistry.
+func NewChaosRegistry(registry *ToolRegistry, config llm.ChaosConfig, toolNames ...string) *ChaosRegistry {
+	chaos := &ChaosRegistry{ToolRegistry: registry, injector: llm.NewChaosInjector(config)}
+	if len(toolNames) > 0 {
+		chaos.targets = make(map[string]bool, len(toolNames))
+		for _, name := range toolNames {
+			chaos.targets[name] = true
+		}
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	for name, tool := range registry.tools {
+		registry.tools[name] = chaos.wrap(tool)
+	}
+	return chaos
+}
+
+// RegisterTool registers a tool wrapped with the configured failures
+func (r *ChaosRegistry) RegisterTool(tool Tool) error {
+	return r.ToolRegistry.RegisterTool(r.wrap(tool))
+}
+
+// Injector returns the registry's injector, to inspect the injected failures
+func (r *ChaosRegistry) Injector() *llm.ChaosInjector {
+	return r.injector
+}
+
+// wrap returns a tool with the configured failures, unless it is not targeted
+func (r *ChaosRegistry) wrap(tool Tool) Tool {
+	if _, wrapped := tool.(*chaosTool); wrapped {
+		return tool
+	}
+	if r.targets != nil && !r.targets[tool.GetName()] {
+		return tool
+	}
+	return &chaosTool{Tool: tool, injector: r.injector}
+}
+
+// chaosTool injects failures into the executions of a tool
+type chaosTool struct {
+	Tool
+	injector *llm.ChaosInjector
+}
+
+// Idempotent forwards the wrapped tool's declaration, so wrapping a tool does not change
+// whether its calls are retried
+func (t *chaosTool) Idempotent() bool {
+	tool, ok := t.Tool.(IdempotentTool)
+	return ok && tool.Idempotent()
+}
+
+// Execute executes the tool, possibly failed, truncated or emptied
+func (t *chaosTool) Execute(ctx context.Context, args string) (string, error) {
+	fault, err := t.injector.Inject(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	switch fault {
+	case llm.ChaosFaultError:
+		return "", t.injector.Err()
+	case llm.ChaosFaultEmpty:
+		return "", nil
+	}
+
+	result, err := t.Tool.Execute(ctx, args)
+	if err != nil || fault != llm.ChaosFaultTruncate {
+		return result, err
+	}
+	runes := []rune(result)
+	return string(runes[:len(runes)/2]), nil
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ToolExample shows the model a call of a tool and the result it returns
+type ToolExample struct {
+	// Description says what the call is for, e.g. "Add two numbers"
+	Description string `json:"description"`
+	// Input is the JSON arguments of the call
+	Input string `json:"input"`
+	// Output is the result the call returns; optional
+	Output string `json:"output,omitempty"`
+}
+
+// ExampleTool is implemented by tools that ship usage examples for the model. Examples
+// improve tool selection and argument formatting, especially for smaller models.
+type ExampleTool interface {
+	// GetUsageExamples returns example calls of the tool
+	GetUsageExamples() []ToolExample
+}
+
+// SetToolExamples sets the usage examples shown to the model with a tool's description,
+// overriding the tool's default examples. No examples removes them.
+func (tr *ToolRegistry) SetToolExamples(name string, examples []ToolExample) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if tr.toolExamples == nil {
+		tr.toolExamples = make(map[string][]ToolExample)
+	}
+	tr.toolExamples[name] = examples
+}
+
+// Examples returns the effective usage examples for a tool
+func (tr *ToolRegistry) Examples(name string) []ToolExample {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+	return tr.examples(name)
+}
+
+// examples returns the examples of a tool; the caller must hold the lock
+func (tr *ToolRegistry) examples(name string) []ToolExample {
+	if examples, exists := tr.toolExamples[name]; exists {
+		return examples
+	}
+	if described, ok := tr.tools[name].(ExampleTool); ok {
+		return described.GetUsageExamples()
+	}
+	return nil
+}
+
+// formatExamples renders examples as a list for a tool description
+func formatExamples(examples []ToolExample) string {
+	if len(examples) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString("Examples:")
+	for _, example := range examples {
+		fmt.Fprintf(&builder, "\n- %s: %s", example.Description, example.Input)
+		if example.Output != "" {
+			fmt.Fprintf(&builder, " -> %s", example.Output)
+		}
+	}
+	return builder.String()
+}
+
+// writeExampleTurns renders examples as the Action / Action Input / Observation turns of a
+// prompt for models without native tool calling
+func writeExampleTurns(builder *strings.Builder, name string, examples []ToolExample) {
+	for _, example := range examples {
+		fmt.Fprintf(builder, "\n\n%s:\nAction: %s\nAction Input: %s", example.Description, name, example.Input)
+		if example.Output != "" {
+			fmt.Fprintf(builder, "\nObservation: %s", example.Output)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ToolFactory builds a tool from its configuration block
+type ToolFactory func(config map[string]interface{}) (Tool, error)
+
+// ToolSpec declares a tool in configuration files
+type ToolSpec struct {
+	// Name is the name of the factory building the tool
+	Name string `json:"name" yaml:"name"`
+	// Enabled defaults to true when omitted
+	Enabled *bool                  `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Config  map[string]interface{} `json:"config,omitempty" yaml:"config,omitempty"`
+	// AuditRedact lists the argument fields redacted from the tool's audit events
+	AuditRedact []string `json:"audit_redact,omitempty" yaml:"audit_redact,omitempty"`
+	// Limit caps the calls of the tool, e.g. to protect the quota of its API key
+	Limit *ToolLimit `json:"limit,omitempty" yaml:"limit,omitempty"`
+}
+
+// IsEnabled reports whether the tool should be built
+func (s *ToolSpec) IsEnabled() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+var (
+	factories   = make(map[string]ToolFactory)
+	factoriesMu sync.RWMutex
+)
+
+func init() {
+	builtins := map[string]func() Tool{
+		"web_search":            func() Tool { return NewWebSearchTool() },
+		"file_read":             func() Tool { return NewFileReadTool() },
+		"file_write":            func() Tool { return NewFileWriteTool() },
+		"file_list":             func() Tool { return NewFileListTool() },
+		"shell":                 func() Tool { return NewShellTool() },
+		"http_request":          func() Tool { return NewHTTPTool() },
+		"calculator":            func() Tool { return NewCalculatorTool() },
+		"time":                  func() Tool { return NewTimeTool() },
+		"json_schema_validator": func() Tool { return NewJSONSchemaValidatorTool() },
+		"scheduling":            func() Tool { return newDefaultSchedulingTool() },
+	}
+	for name, newTool := range builtins {
+		RegisterFactory(name, configuredFactory(newTool))
+	}
+}
+
+// configuredFactory adapts a tool constructor to a factory applying the config through SetConfig
+func configuredFactory(newTool func() Tool) ToolFactory {
+	return func(config map[string]interface{}) (Tool, error) {
+		tool := newTool()
+		if len(config) > 0 {
+			if err := tool.SetConfig(config); err != nil {
+				return nil, err
+			}
+		}
+		return tool, nil
+	}
+}
+
+// RegisterFactory registers a tool factory globally, usually from an init function,
+// so tools declared in configuration can be built by name
+func RegisterFactory(name string, factory ToolFactory) error {
+	if factory == nil {
+		return fmt.Errorf("tool factory %s is nil", name)
+	}
+
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if _, exists := factories[name]; exists {
+		return fmt.Errorf("tool factory %s already registered", name)
+	}
+	factories[name] = factory
+	return nil
+}
+
+// ListFactories returns the names of the registered tool factories
+func ListFactories() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BuildTool builds a tool with the factory registered under name
+func BuildTool(name string, config map[string]interface{}) (Tool, error) {
+	factoriesMu.RLock()
+	factory, exists := factories[name]
+	factoriesMu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("no tool factory registered for %s", name)
+	}
+	tool, err := factory(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build tool %s: %w", name, err)
+	}
+	return tool, nil
+}
+
+// RegisterFromSpecs builds the enabled tools of a configuration and registers them,
+// replacing registered tools of the same name. Disabled tools are unregistered.
+func (tr *ToolRegistry) RegisterFromSpecs(specs []ToolSpec) error {
+	for _, spec := range specs {
+		if !spec.IsEnabled() {
+			tr.UnregisterTool(spec.Name)
+			continue
+		}
+
+		tool, err := BuildTool(spec.Name, spec.Config)
+		if err != nil {
+			return err
+		}
+		tr.UnregisterTool(tool.GetName())
+		if err := tr.RegisterTool(tool); err != nil {
+			return err
+		}
+		if len(spec.AuditRedact) > 0 {
+			tr.SetAuditRedactedFields(tool.GetName(), spec.AuditRedact...)
+		}
+		tr.SetToolLimit(tool.GetName(), spec.Limit)
+	}
+	return nil
+}
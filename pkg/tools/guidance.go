@@ -0,0 +1,102 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// GuidedTool is implemented by tools that ship default usage guidance for the model
+type GuidedTool interface {
+	// GetGuidance returns constraints on when and how the tool may be used
+	GetGuidance() string
+}
+
+// SetToolGuidance sets the usage guidance shown to the model with a tool's description,
+// overriding the tool's default guidance. An empty string removes the guidance.
+func (tr *ToolRegistry) SetToolGuidance(name, guidance string) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if tr.toolGuidance == nil {
+		tr.toolGuidance = make(map[string]string)
+	}
+	tr.toolGuidance[name] = guidance
+}
+
+// Guidance returns the effective usage guidance for a tool
+func (tr *ToolRegistry) Guidance(name string) string {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+	return tr.guidance(name)
+}
+
+// guidance returns the guidance of a tool; the caller must hold the lock
+func (tr *ToolRegistry) guidance(name string) string {
+	if guidance, exists := tr.toolGuidance[name]; exists {
+		return guidance
+	}
+	if guided, ok := tr.tools[name].(GuidedTool); ok {
+		return guided.GetGuidance()
+	}
+	return ""
+}
+
+// definition returns a tool's definition with its examples and guidance appended to the
+// description; the caller must hold the lock
+func (tr *ToolRegistry) definition(tool Tool) llm.ToolDefinition {
+	definition := tool.GetDefinition()
+	description := definition.Function.Description
+	if examples := formatExamples(tr.examples(tool.GetName())); examples != "" {
+		description += "\n\n" + examples
+	}
+	if guidance := strings.TrimSpace(tr.guidance(tool.GetName())); guidance != "" {
+		description += "\n\nUsage guidance: " + guidance
+	}
+	definition.Function.Description = strings.TrimSpace(description)
+	return definition
+}
+
+// ToolPrompt assembles the tool-description section of a prompt for models without native
+// tool calling, including each tool's guidance and its examples as few-shot tool-use turns
+func (tr *ToolRegistry) ToolPrompt(toolNames []string) string {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+
+	var builder, examples strings.Builder
+	for _, name := range toolNames {
+		tool, exists := tr.tools[name]
+		if !exists {
+			continue
+		}
+		if builder.Len() == 0 {
+			builder.WriteString("You have access to the following tools:\n")
+		}
+
+		def := tool.GetDefinition()
+		description := strings.TrimSpace(def.Function.Description)
+		if guidance := strings.TrimSpace(tr.guidance(name)); guidance != "" {
+			description = strings.TrimSpace(description + " Usage guidance: " + guidance)
+		}
+		parameters, _ := json.Marshal(def.Function.Parameters)
+		fmt.Fprintf(&builder, "- %s: %s Parameters: %s\n", def.Function.Name, description, parameters)
+		writeExampleTurns(&examples, def.Function.Name, tr.examples(name))
+	}
+	if builder.Len() == 0 {
+		return ""
+	}
+
+	builder.WriteString("\nTo use a tool, respond with:\nAction: <tool name>\nAction Input: <JSON arguments>")
+	if examples.Len() > 0 {
+		builder.WriteString("\n\nExamples of tool use:")
+		builder.WriteString(examples.String())
+	}
+	return builder.String()
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package tools
+
+// IdempotentTool is implemented by tools that declare whether running the same call twice is
+// safe, which decides whether a failed node that ran the tool may be retried automatically.
+//
+// Return true only when repeating a call with the same arguments has the same effect as
+// running it once: reads, searches, computations, or writes that overwrite a value. Return
+// false when a call creates, appends, sends or charges something. Tools that do not
+// implement IdempotentTool are treated as non-idempotent.
+type IdempotentTool interface {
+	Idempotent() bool
+}
+
+// SetRetryable overrides whether the calls of a tool may be retried automatically. Setting
+// it to true opts a non-idempotent tool into retries.
+func (tr *ToolRegistry) SetRetryable(name string, retryable bool) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if tr.toolRetryable == nil {
+		tr.toolRetryable = make(map[string]bool)
+	}
+	tr.toolRetryable[name] = retryable
+}
+
+// Retryable reports whether the calls of a tool may be retried automatically: the registry
+// override when set, otherwise the tool's own declaration
+func (tr *ToolRegistry) Retryable(name string) bool {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+
+	if retryable, exists := tr.toolRetryable[name]; exists {
+		return retryable
+	}
+	tool, ok := tr.tools[name].(IdempotentTool)
+	return ok && tool.Idempotent()
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// RepairJSON turns almost-valid JSON emitted by smaller models into valid JSON. It strips
+// markdown code fences and surrounding prose, quotes single-quoted strings and bare keys,
+// drops trailing commas, converts Python literals and closes unterminated strings and
+// brackets. It reports whether the input had to be changed and fails only when the repaired
+// text is still not valid JSON. Empty input is returned unchanged.
+func RepairJSON(input string) (string, bool, error) {
+	if strings.TrimSpace(input) == "" || json.Valid([]byte(input)) {
+		return input, false, nil
+	}
+
+	repaired := repairJSON(extractJSON(input))
+	if !json.Valid([]byte(repaired)) {
+		return input, false, fmt.Errorf("malformed JSON could not be repaired: %s", input)
+	}
+	return repaired, true, nil
+}
+
+// RepairPartialJSON completes an object or array cut off mid-stream to the nearest valid
+// value. Open strings and brackets are closed; when the text ends inside a key or after a
+// colon, members are dropped from the end until the value is valid. It fails when the text
+// holds no object or array.
+func RepairPartialJSON(partial string) (string, error) {
+	text := extractJSON(partial)
+	if !strings.HasPrefix(text, "{") && !strings.HasPrefix(text, "[") {
+		return "", fmt.Errorf("partial JSON holds no object or array: %s", partial)
+	}
+
+	for {
+		if repaired, _, err := RepairJSON(text); err == nil {
+			return repaired, nil
+		}
+		cut := strings.LastIndexByte(text, ',')
+		if cut < 0 {
+			cut = strings.LastIndexAny(text, "{[") + 1
+		}
+		if cut <= 0 || cut >= len(text) {
+			return "", fmt.Errorf("partial JSON could not be repaired: %s", partial)
+		}
+		text = text[:cut]
+	}
+}
+
+// extractJSON strips code fences and any text around the first object or array
+func extractJSON(input string) string {
+	text := strings.TrimSpace(input)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```")
+		if newline := strings.IndexByte(text, '\n'); newline >= 0 {
+			text = text[newline+1:]
+		}
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	}
+	if start := strings.IndexAny(text, "{["); start > 0 {
+		text = text[start:]
+	}
+	return strings.TrimSpace(text)
+}
+
+// repairJSON rewrites the text token by token, keeping track of open brackets
+func repairJSON(text string) string {
+	out := make([]byte, 0, len(text)+16)
+	var stack []byte
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '"' || c == '\'':
+			str, n := readJSONString(text[i:])
+			out = append(out, str...)
+			i += n
+			continue
+		case c == '{' || c == '[':
+			stack = append(stack, c)
+			out = append(out, c)
+		case c == '}' || c == ']':
+			out = trimTrailingComma(out)
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			out = append(out, c)
+			if len(stack) == 0 {
+				// Ignore anything after the top-level value
+				return string(out)
+			}
+		case isIdentStart(c):
+			word := readIdent(text[i:])
+			i += len(word)
+			rest := strings.TrimLeft(text[i:], " \t\r\n")
+			switch {
+			case strings.HasPrefix(rest, ":"):
+				out = append(out, quoteJSON(word)...)
+			case word == "true" || word == "false" || word == "null":
+				out = append(out, word...)
+			case word == "True" || word == "False":
+				out = append(out, strings.ToLower(word)...)
+			case word == "None":
+				out = append(out, "null"...)
+			default:
+				out = append(out, quoteJSON(word)...)
+			}
+			continue
+		default:
+			out = append(out, c)
+		}
+		i++
+	}
+
+	// Close whatever the model left open
+	out = trimTrailingComma(out)
+	for j := len(stack) - 1; j >= 0; j-- {
+		if stack[j] == '{' {
+			out = append(out, '}')
+		} else {
+			out = append(out, ']')
+		}
+	}
+	return string(out)
+}
+
+// readJSONString reads a single- or double-quoted string and returns it as a JSON string
+// along with the number of bytes consumed. Unterminated strings are closed.
+func readJSONString(text string) (string, int) {
+	quote := text[0]
+	var b strings.Builder
+	b.WriteByte('"')
+
+	i := 1
+	for i < len(text) {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text):
+			next := text[i+1]
+			if next == '\'' {
+				b.WriteByte('\'')
+			} else {
+				b.WriteByte(c)
+				b.WriteByte(next)
+			}
+			i += 2
+			continue
+		case c == quote:
+			b.WriteByte('"')
+			return b.String(), i + 1
+		case c == '"':
+			b.WriteString(`\"`)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteByte(c)
+		}
+		i++
+	}
+
+	b.WriteByte('"')
+	return b.String(), i
+}
+
+// readIdent reads a bare word such as an unquoted key or literal
+func readIdent(text string) string {
+	end := 0
+	for end < len(text) && isIdentByte(text[end]) {
+		end++
+	}
+	return text[:end]
+}
+
+// isIdentStart reports whether a byte can start a bare word; numbers are left alone
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+// isIdentByte reports whether a byte can be part of a bare word
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c == '-' || c == '.' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c >= 0x80
+}
+
+// quoteJSON returns a word as a JSON string
+func quoteJSON(word string) string {
+	quoted, _ := json.Marshal(word)
+	return string(quoted)
+}
+
+// trimTrailingComma removes a comma left before a closing bracket
+func trimTrailingComma(out []byte) []byte {
+	end := len(out)
+	for end > 0 && strings.IndexByte(" \t\r\n", out[end-1]) >= 0 {
+		end--
+	}
+	if end > 0 && out[end-1] == ',' {
+		return append(out[:end-1], out[end:]...)
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package tools
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]interface{}
+		repaired bool
+	}{
+		{"valid", `{"query": "go"}`, map[string]interface{}{"query": "go"}, false},
+		{"trailing comma", `{"query": "go", "limit": 5,}`, map[string]interface{}{"query": "go", "limit": float64(5)}, true},
+		{"single quotes", `{'query': 'it\'s "go"'}`, map[string]interface{}{"query": `it's "go"`}, true},
+		{"unquoted keys", `{query: "go", max_results: -2.5}`, map[string]interface{}{"query": "go", "max_results": -2.5}, true},
+		{"python literals", `{"safe": True, "page": None, "tags": ['a', 'b',],}`, map[string]interface{}{"safe": true, "page": nil, "tags": []interface{}{"a", "b"}}, true},
+		{"code fence", "```json\n{\"query\": \"go\"}\n```", map[string]interface{}{"query": "go"}, true},
+		{"surrounding prose", `Arguments: {"query": "go"} hope this helps`, map[string]interface{}{"query": "go"}, true},
+		{"unterminated", `{"query": "multi` + "\n" + `line`, map[string]interface{}{"query": "multi\nline"}, true},
+		{"nested unclosed", `{"filter": {"lang": "go", "tags": ["a"`, map[string]interface{}{"filter": map[string]interface{}{"lang": "go", "tags": []interface{}{"a"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, repaired, err := RepairJSON(tt.input)
+			if err != nil {
+				t.Fatalf("RepairJSON() failed: %v", err)
+			}
+			if repaired != tt.repaired {
+				t.Errorf("Expected repaired=%v, got %v", tt.repaired, repaired)
+			}
+
+			var value map[string]interface{}
+			if err := json.Unmarshal([]byte(output), &value); err != nil {
+				t.Fatalf("Repaired output %q is not valid JSON: %v", output, err)
+			}
+			if !reflect.DeepEqual(value, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, value)
+			}
+		})
+	}
+}
+
+func TestRepairJSON_Unrepairable(t *testing.T) {
+	if _, _, err := RepairJSON(`{"query": "go" "limit": 5}`); err == nil {
+		t.Error("Expected an error for JSON that cannot be repaired")
+	}
+	if output, repaired, err := RepairJSON(""); err != nil || repaired || output != "" {
+		t.Errorf("Expected empty input to pass through, got %q, %v, %v", output, repaired, err)
+	}
+}
+
+func TestRepairPartialJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"complete", `{"title": "Go"}`, `{"title": "Go"}`},
+		{"open string", `{"title": "Go", "summary": "A fast`, `{"title": "Go", "summary": "A fast"}`},
+		{"after colon", `{"title": "Go", "tags": ["fast", "typed"], "year":`, `{"title": "Go", "tags": ["fast", "typed"]}`},
+		{"inside key", `{"title": "Go", "yea`, `{"title": "Go"}`},
+		{"nested", `{"book": {"title":`, `{"book": {}}`},
+		{"array", "```json\n[1, 2, 3", `[1, 2, 3]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := RepairPartialJSON(tt.input)
+			if err != nil {
+				t.Fatalf("RepairPartialJSON() failed: %v", err)
+			}
+			var value, expected interface{}
+			if err := json.Unmarshal([]byte(output), &value); err != nil {
+				t.Fatalf("Repaired output %q is not valid JSON: %v", output, err)
+			}
+			json.Unmarshal([]byte(tt.expected), &expected)
+			if !reflect.DeepEqual(value, expected) {
+				t.Errorf("Expected %s, got %s", tt.expected, output)
+			}
+		})
+	}
+
+	for _, input := range []string{"", "The answer is", `"title"`} {
+		if _, err := RepairPartialJSON(input); err == nil {
+			t.Errorf("Expected %q not to be repaired", input)
+		}
+	}
+}
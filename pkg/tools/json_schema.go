@@ -0,0 +1,147 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/core"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// JSONSchemaValidatorTool validates JSON data against a JSON schema and reports every
+// violation. The schema is passed with each call, or fixed with the "schema" config key so
+// callers only pass the data.
+type JSONSchemaValidatorTool struct {
+	schema   interface{}
+	compiled *core.JSONSchema
+}
+
+// JSONSchemaValidation is the result of the JSON schema validator tool
+type JSONSchemaValidation struct {
+	Valid  bool                   `json:"valid"`
+	Errors []core.SchemaViolation `json:"errors,omitempty"`
+}
+
+// NewJSONSchemaValidatorTool creates a JSON schema validator tool
+func NewJSONSchemaValidatorTool() *JSONSchemaValidatorTool {
+	return &JSONSchemaValidatorTool{}
+}
+
+func (t *JSONSchemaValidatorTool) GetName() string {
+	return "json_schema_validator"
+}
+
+// Idempotent reports that validation is safe to repeat
+func (t *JSONSchemaValidatorTool) Idempotent() bool {
+	return true
+}
+
+func (t *JSONSchemaValidatorTool) GetDescription() string {
+	return "Validate JSON data against a JSON schema and list every violation"
+}
+
+func (t *JSONSchemaValidatorTool) GetDefinition() llm.ToolDefinition {
+	properties := map[string]interface{}{
+		"data": map[string]interface{}{
+			"description": "The JSON value to validate",
+		},
+	}
+	required := []string{"data"}
+	if t.compiled == nil {
+		properties["schema"] = map[string]interface{}{
+			"type":        "object",
+			"description": "The JSON schema the data must satisfy",
+		}
+		required = append(required, "schema")
+	}
+
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.Function{
+			Name:        t.GetName(),
+			Description: t.GetDescription(),
+			Parameters: map[string]interface{}{
+				"type":       "object",
+				"properties": properties,
+				"required":   required,
+			},
+		},
+	}
+}
+
+func (t *JSONSchemaValidatorTool) Execute(ctx context.Context, args string) (string, error) {
+	var params struct {
+		Schema json.RawMessage `json:"schema"`
+		Data   json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if len(params.Data) == 0 {
+		return "", fmt.Errorf("data is required")
+	}
+
+	schema := t.compiled
+	if len(params.Schema) > 0 {
+		var err error
+		if schema, err = core.CompileJSONSchema([]byte(params.Schema)); err != nil {
+			return "", err
+		}
+	}
+	if schema == nil {
+		return "", fmt.Errorf("schema is required")
+	}
+
+	violations, err := schema.ValidateJSON(params.Data)
+	if err != nil {
+		return "", err
+	}
+	result, err := json.Marshal(JSONSchemaValidation{Valid: len(violations) == 0, Errors: violations})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+	return string(result), nil
+}
+
+func (t *JSONSchemaValidatorTool) Validate(args string) error {
+	var params map[string]interface{}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return fmt.Errorf("invalid JSON arguments: %w", err)
+	}
+	if _, exists := params["data"]; !exists {
+		return fmt.Errorf("data is required")
+	}
+	if _, exists := params["schema"]; !exists && t.compiled == nil {
+		return fmt.Errorf("schema is required")
+	}
+	return nil
+}
+
+func (t *JSONSchemaValidatorTool) GetConfig() map[string]interface{} {
+	config := map[string]interface{}{}
+	if t.schema != nil {
+		config["schema"] = t.schema
+	}
+	return config
+}
+
+func (t *JSONSchemaValidatorTool) SetConfig(config map[string]interface{}) error {
+	schema, exists := config["schema"]
+	if !exists {
+		return nil
+	}
+	compiled, err := core.CompileJSONSchema(schema)
+	if err != nil {
+		return err
+	}
+	t.schema = schema
+	t.compiled = compiled
+	return nil
+}
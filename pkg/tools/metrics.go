@@ -0,0 +1,263 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/metrics"
+)
+
+// ToolOutcome is how a tool call ended
+type ToolOutcome string
+
+const (
+	ToolOutcomeSuccess ToolOutcome = "success"
+	ToolOutcomeError   ToolOutcome = "error"
+	// ToolOutcomeTimeout is a call whose deadline passed
+	ToolOutcomeTimeout ToolOutcome = "timeout"
+	// ToolOutcomeCancelled is a call whose context was cancelled
+	ToolOutcomeCancelled ToolOutcome = "cancelled"
+	// ToolOutcomeRateLimited is a call rejected by the tool's limits; it did not run
+	ToolOutcomeRateLimited ToolOutcome = "rate_limited"
+)
+
+var (
+	// DefaultLatencyBuckets are the upper bounds, in seconds, of the tool latency histograms
+	DefaultLatencyBuckets = metrics.DefaultLatencyBuckets
+	// DefaultResultSizeBuckets are the upper bounds, in bytes, of the result size histograms
+	DefaultResultSizeBuckets = []float64{64, 256, 1024, 4096, 16384, 65536, 262144, 1048576}
+)
+
+// ToolStats are the metrics of a tool's calls
+type ToolStats struct {
+	Tool string `json:"tool"`
+	// Calls counts every call, including those rejected by the tool's limits
+	Calls uint64 `json:"calls"`
+	// Errors counts the calls that ran and failed, timeouts and cancellations included
+	Errors   uint64                 `json:"errors"`
+	Outcomes map[ToolOutcome]uint64 `json:"outcomes"`
+	// Latency, in seconds, and ResultBytes cover the calls that ran
+	Latency     metrics.Histogram `json:"latency_seconds"`
+	ResultBytes metrics.Histogram `json:"result_bytes"`
+}
+
+// ErrorRate returns the share of the calls that ran and failed
+func (s ToolStats) ErrorRate() float64 {
+	if s.Latency.Count == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Latency.Count)
+}
+
+// toolSeries are the metrics of a tool's calls with one outcome
+type toolSeries struct {
+	calls       uint64
+	latency     metrics.Histogram
+	resultBytes metrics.Histogram
+}
+
+type seriesKey struct {
+	tool    string
+	outcome ToolOutcome
+}
+
+// ToolMetrics tracks the calls of tools by tool and outcome. A registry records its calls in
+// its own metrics unless given shared ones with SetMetrics.
+type ToolMetrics struct {
+	latencyBuckets    []float64
+	resultSizeBuckets []float64
+	series            map[seriesKey]*toolSeries
+	mu                sync.Mutex
+}
+
+// NewToolMetrics creates tool metrics with the default buckets
+func NewToolMetrics() *ToolMetrics {
+	return NewToolMetricsWithBuckets(DefaultLatencyBuckets, DefaultResultSizeBuckets)
+}
+
+// NewToolMetricsWithBuckets creates tool metrics with the given ascending bucket bounds, in
+// seconds for latency and in bytes for result sizes
+func NewToolMetricsWithBuckets(latencyBuckets, resultSizeBuckets []float64) *ToolMetrics {
+	return &ToolMetrics{
+		latencyBuckets:    latencyBuckets,
+		resultSizeBuckets: resultSizeBuckets,
+		series:            make(map[seriesKey]*toolSeries),
+	}
+}
+
+// Observe records a tool call. Calls rejected by the tool's limits only count.
+func (m *ToolMetrics) Observe(tool string, outcome ToolOutcome, duration time.Duration, resultBytes int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := seriesKey{tool: tool, outcome: outcome}
+	series, exists := m.series[key]
+	if !exists {
+		series = &toolSeries{
+			latency:     metrics.NewHistogram(m.latencyBuckets),
+			resultBytes: metrics.NewHistogram(m.resultSizeBuckets),
+		}
+		m.series[key] = series
+	}
+	series.calls++
+	if outcome != ToolOutcomeRateLimited {
+		series.latency.Observe(duration.Seconds())
+		series.resultBytes.Observe(float64(resultBytes))
+	}
+}
+
+// Stats returns the metrics of a tool, and whether it has been called
+func (m *ToolMetrics) Stats(tool string) (ToolStats, bool) {
+	for _, stats := range m.Snapshot() {
+		if stats.Tool == tool {
+			return stats, true
+		}
+	}
+	return ToolStats{}, false
+}
+
+// Snapshot returns the metrics of every tool called, by name
+func (m *ToolMetrics) Snapshot() []ToolStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	byTool := make(map[string]*ToolStats)
+	for key, series := range m.series {
+		stats, exists := byTool[key.tool]
+		if !exists {
+			stats = &ToolStats{
+				Tool:        key.tool,
+				Outcomes:    make(map[ToolOutcome]uint64),
+				Latency:     metrics.NewHistogram(m.latencyBuckets),
+				ResultBytes: metrics.NewHistogram(m.resultSizeBuckets),
+			}
+			byTool[key.tool] = stats
+		}
+		stats.Calls += series.calls
+		stats.Outcomes[key.outcome] += series.calls
+		switch key.outcome {
+		case ToolOutcomeError, ToolOutcomeTimeout, ToolOutcomeCancelled:
+			stats.Errors += series.calls
+		}
+		stats.Latency.Merge(series.latency)
+		stats.ResultBytes.Merge(series.resultBytes)
+	}
+
+	snapshot := make([]ToolStats, 0, len(byTool))
+	for _, stats := range byTool {
+		snapshot = append(snapshot, *stats)
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].Tool < snapshot[j].Tool
+	})
+	return snapshot
+}
+
+// Reset clears the metrics
+func (m *ToolMetrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.series = make(map[seriesKey]*toolSeries)
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition format, labelled by
+// tool and outcome
+func (m *ToolMetrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	keys := make([]seriesKey, 0, len(m.series))
+	series := make(map[seriesKey]toolSeries, len(m.series))
+	for key, value := range m.series {
+		keys = append(keys, key)
+		series[key] = toolSeries{calls: value.calls, latency: value.latency.Clone(), resultBytes: value.resultBytes.Clone()}
+	}
+	m.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].tool != keys[j].tool {
+			return keys[i].tool < keys[j].tool
+		}
+		return keys[i].outcome < keys[j].outcome
+	})
+
+	var out strings.Builder
+	out.WriteString("# HELP golanggraph_tool_calls_total Tool calls by tool and outcome.\n")
+	out.WriteString("# TYPE golanggraph_tool_calls_total counter\n")
+	for _, key := range keys {
+		fmt.Fprintf(&out, "golanggraph_tool_calls_total{%s} %d\n", key.labels(), series[key].calls)
+	}
+
+	histograms := []struct {
+		name, help string
+		get        func(toolSeries) metrics.Histogram
+	}{
+		{"golanggraph_tool_call_duration_seconds", "Tool call latency in seconds.", func(s toolSeries) metrics.Histogram { return s.latency }},
+		{"golanggraph_tool_result_bytes", "Tool result size in bytes.", func(s toolSeries) metrics.Histogram { return s.resultBytes }},
+	}
+	for _, histogram := range histograms {
+		fmt.Fprintf(&out, "# HELP %s %s\n# TYPE %s histogram\n", histogram.name, histogram.help, histogram.name)
+		for _, key := range keys {
+			if key.outcome == ToolOutcomeRateLimited {
+				continue
+			}
+			histogram.get(series[key]).WritePrometheus(&out, histogram.name, key.labels())
+		}
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// labels returns the Prometheus labels of a series
+func (k seriesKey) labels() string {
+	return fmt.Sprintf(`tool="%s",outcome="%s"`, metrics.EscapeLabel(k.tool), metrics.EscapeLabel(string(k.outcome)))
+}
+
+// toolOutcome classifies the end of a tool call
+func toolOutcome(ctx context.Context, err error) ToolOutcome {
+	switch {
+	case err == nil:
+		return ToolOutcomeSuccess
+	case errors.Is(err, ErrToolQuotaExceeded):
+		return ToolOutcomeRateLimited
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return ToolOutcomeTimeout
+	case errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled):
+		return ToolOutcomeCancelled
+	default:
+		return ToolOutcomeError
+	}
+}
+
+// SetMetrics makes the registry record its calls in shared metrics, e.g. those of several
+// registries served together; nil disables the metrics
+func (tr *ToolRegistry) SetMetrics(metrics *ToolMetrics) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.metrics = metrics
+}
+
+// Metrics returns the metrics the registry records its calls in, nil when disabled
+func (tr *ToolRegistry) Metrics() *ToolMetrics {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+	return tr.metrics
+}
+
+// observeCall records a tool call in the registry's metrics
+func (tr *ToolRegistry) observeCall(ctx context.Context, tool string, duration time.Duration, resultBytes int, err error) {
+	if metrics := tr.Metrics(); metrics != nil {
+		metrics.Observe(tool, toolOutcome(ctx, err), duration, resultBytes)
+	}
+}
@@ -0,0 +1,423 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// Notification channels
+const (
+	NotificationChannelEmail   = "email"
+	NotificationChannelSlack   = "slack"
+	NotificationChannelWebhook = "webhook"
+)
+
+// SMTPConfig configures the mail server used for email notifications
+type SMTPConfig struct {
+	Host string `json:"host"`
+	// Port defaults to 587
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"-"`
+	From     string `json:"from"`
+}
+
+// SMTPConfigFromEnv reads the mail server from environment variables such as
+// GOLANGGRAPH_SMTP_HOST, GOLANGGRAPH_SMTP_PORT, GOLANGGRAPH_SMTP_USERNAME,
+// GOLANGGRAPH_SMTP_PASSWORD and GOLANGGRAPH_SMTP_FROM; it returns nil when no host is set
+func SMTPConfigFromEnv(prefix string) *SMTPConfig {
+	env := func(name string) string {
+		return os.Getenv(prefix + "_SMTP_" + name)
+	}
+	if env("HOST") == "" {
+		return nil
+	}
+	port, _ := strconv.Atoi(env("PORT"))
+	return &SMTPConfig{
+		Host:     env("HOST"),
+		Port:     port,
+		Username: env("USERNAME"),
+		Password: env("PASSWORD"),
+		From:     env("FROM"),
+	}
+}
+
+// NotificationConfig configures the notification tool. Only configured channels can be used,
+// and every recipient must be allowed, so a manipulated model cannot message arbitrary people.
+type NotificationConfig struct {
+	// SMTP enables the email channel
+	SMTP *SMTPConfig `json:"smtp,omitempty"`
+	// SlackWebhookURL enables the slack channel; recipients are channels such as "#alerts"
+	SlackWebhookURL string `json:"slack_webhook_url,omitempty"`
+	// Webhooks enable the webhook channel; recipients are the names of these URLs
+	Webhooks map[string]string `json:"webhooks,omitempty"`
+	// AllowedChannels restricts the channels the model may use; empty allows every configured one
+	AllowedChannels []string `json:"allowed_channels,omitempty"`
+	// AllowedRecipients lists the email addresses, domains such as "@example.com" and Slack
+	// channels that may receive notifications. Emails and Slack channels not listed are refused.
+	AllowedRecipients []string `json:"allowed_recipients,omitempty"`
+	// Timeout bounds each delivery; defaults to 30 seconds
+	Timeout time.Duration `json:"timeout"`
+}
+
+// NotificationResult is the delivery status returned to the model
+type NotificationResult struct {
+	Channel    string   `json:"channel"`
+	To         []string `json:"to"`
+	Status     string   `json:"status"`
+	StatusCode int      `json:"status_code,omitempty"`
+}
+
+// NotificationTool sends emails, Slack messages and webhook notifications
+type NotificationTool struct {
+	config            NotificationConfig
+	allowedChannels   map[string]bool
+	allowedRecipients map[string]bool
+	client            *http.Client
+	sendMail          func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewNotificationTool creates a notification tool for the configured channels
+func NewNotificationTool(config NotificationConfig) (*NotificationTool, error) {
+	if config.SMTP == nil && config.SlackWebhookURL == "" && len(config.Webhooks) == 0 {
+		return nil, fmt.Errorf("at least one notification channel must be configured")
+	}
+	if config.SMTP != nil {
+		if config.SMTP.Host == "" || config.SMTP.From == "" {
+			return nil, fmt.Errorf("SMTP host and from address are required")
+		}
+		if config.SMTP.Port == 0 {
+			config.SMTP.Port = 587
+		}
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	tool := &NotificationTool{
+		config:            config,
+		allowedChannels:   make(map[string]bool),
+		allowedRecipients: make(map[string]bool),
+		client:            &http.Client{Timeout: config.Timeout},
+		sendMail:          smtp.SendMail,
+	}
+	for _, channel := range config.AllowedChannels {
+		tool.allowedChannels[strings.ToLower(channel)] = true
+	}
+	for _, recipient := range config.AllowedRecipients {
+		tool.allowedRecipients[strings.ToLower(recipient)] = true
+	}
+	return tool, nil
+}
+
+func (t *NotificationTool) GetName() string {
+	return "send_notification"
+}
+
+// Idempotent reports that notifications are not safe to send twice
+func (t *NotificationTool) Idempotent() bool {
+	return false
+}
+
+func (t *NotificationTool) GetDescription() string {
+	return fmt.Sprintf("Send a notification by %s. Only allowed recipients can be reached.", strings.Join(t.channels(), ", "))
+}
+
+func (t *NotificationTool) GetDefinition() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.Function{
+			Name:        t.GetName(),
+			Description: t.GetDescription(),
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"channel": map[string]interface{}{
+						"type": "string",
+						"enum": t.channels(),
+					},
+					"to": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Email addresses, a Slack channel or a webhook name",
+					},
+					"subject": map[string]interface{}{
+						"type":        "string",
+						"description": "Subject or title of the notification",
+					},
+					"body": map[string]interface{}{
+						"type":        "string",
+						"description": "Text of the notification",
+					},
+				},
+				"required": []string{"channel", "to", "body"},
+			},
+		},
+	}
+}
+
+// notificationArgs are the arguments of the tool; to may be a string or a list
+type notificationArgs struct {
+	Channel string          `json:"channel"`
+	To      json.RawMessage `json:"to"`
+	Subject string          `json:"subject"`
+	Body    string          `json:"body"`
+}
+
+// recipients returns the recipients of the arguments
+func (a notificationArgs) recipients() ([]string, error) {
+	var list []string
+	if err := json.Unmarshal(a.To, &list); err != nil {
+		var single string
+		if err := json.Unmarshal(a.To, &single); err != nil {
+			return nil, fmt.Errorf("to must be a string or a list of strings")
+		}
+		list = []string{single}
+	}
+	recipients := make([]string, 0, len(list))
+	for _, recipient := range list {
+		if recipient = strings.TrimSpace(recipient); recipient != "" {
+			recipients = append(recipients, recipient)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("at least one recipient is required")
+	}
+	return recipients, nil
+}
+
+func (t *NotificationTool) Execute(ctx context.Context, args string) (string, error) {
+	var params notificationArgs
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	recipients, err := params.recipients()
+	if err != nil {
+		return "", err
+	}
+
+	result, err := t.Send(ctx, strings.ToLower(params.Channel), recipients, params.Subject, params.Body)
+	if err != nil {
+		return "", err
+	}
+	output, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+	return string(output), nil
+}
+
+// Send delivers a notification after checking the channel and recipients
+func (t *NotificationTool) Send(ctx context.Context, channel string, to []string, subject, body string) (*NotificationResult, error) {
+	if err := t.CheckRecipients(channel, to); err != nil {
+		return nil, err
+	}
+	if body == "" {
+		return nil, fmt.Errorf("body is required")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.config.Timeout)
+	defer cancel()
+
+	result := &NotificationResult{Channel: channel, To: to, Status: "sent"}
+	switch channel {
+	case NotificationChannelEmail:
+		return result, t.sendEmail(to, subject, body)
+	case NotificationChannelSlack:
+		text := body
+		if subject != "" {
+			text = fmt.Sprintf("*%s*\n%s", subject, body)
+		}
+		code, err := t.post(ctx, t.config.SlackWebhookURL, map[string]interface{}{"channel": to[0], "text": text})
+		result.StatusCode = code
+		return result, err
+	default:
+		code, err := t.post(ctx, t.config.Webhooks[to[0]], map[string]interface{}{"subject": subject, "body": body})
+		result.StatusCode = code
+		return result, err
+	}
+}
+
+// CheckRecipients rejects channels that are not configured or allowed and recipients that
+// are not allowed
+func (t *NotificationTool) CheckRecipients(channel string, to []string) error {
+	if !t.channelConfigured(channel) {
+		return fmt.Errorf("notification channel %s is not configured", channel)
+	}
+	if len(t.allowedChannels) > 0 && !t.allowedChannels[channel] {
+		return fmt.Errorf("notification channel %s is not allowed", channel)
+	}
+	if len(to) == 0 {
+		return fmt.Errorf("at least one recipient is required")
+	}
+
+	switch channel {
+	case NotificationChannelEmail:
+		for _, recipient := range to {
+			address, err := mail.ParseAddress(recipient)
+			if err != nil || address.Name != "" {
+				return fmt.Errorf("invalid email address: %s", recipient)
+			}
+			if !t.emailAllowed(address.Address) {
+				return fmt.Errorf("recipient %s is not allowed", recipient)
+			}
+		}
+	case NotificationChannelSlack:
+		if len(to) != 1 || !t.allowedRecipients[strings.ToLower(to[0])] {
+			return fmt.Errorf("slack channel %s is not allowed", strings.Join(to, ", "))
+		}
+	case NotificationChannelWebhook:
+		if _, exists := t.config.Webhooks[to[0]]; len(to) != 1 || !exists {
+			return fmt.Errorf("webhook %s is not configured", strings.Join(to, ", "))
+		}
+	}
+	return nil
+}
+
+// emailAllowed reports whether an address or its domain is allowed
+func (t *NotificationTool) emailAllowed(address string) bool {
+	address = strings.ToLower(address)
+	if t.allowedRecipients[address] {
+		return true
+	}
+	at := strings.LastIndex(address, "@")
+	return at >= 0 && t.allowedRecipients[address[at:]]
+}
+
+// channelConfigured reports whether a channel has a backend
+func (t *NotificationTool) channelConfigured(channel string) bool {
+	switch channel {
+	case NotificationChannelEmail:
+		return t.config.SMTP != nil
+	case NotificationChannelSlack:
+		return t.config.SlackWebhookURL != ""
+	case NotificationChannelWebhook:
+		return len(t.config.Webhooks) > 0
+	}
+	return false
+}
+
+// channels returns the channels the model may use
+func (t *NotificationTool) channels() []string {
+	var channels []string
+	for _, channel := range []string{NotificationChannelEmail, NotificationChannelSlack, NotificationChannelWebhook} {
+		if t.channelConfigured(channel) && (len(t.allowedChannels) == 0 || t.allowedChannels[channel]) {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
+// sendEmail sends a plain-text email through the SMTP server
+func (t *NotificationTool) sendEmail(to []string, subject, body string) error {
+	smtpConfig := t.config.SMTP
+	// Header values must not carry line breaks, which would inject headers
+	subject = strings.NewReplacer("\r", " ", "\n", " ").Replace(subject)
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", smtpConfig.From)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", subject)
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	message.WriteString(body)
+
+	var auth smtp.Auth
+	if smtpConfig.Username != "" {
+		auth = smtp.PlainAuth("", smtpConfig.Username, smtpConfig.Password, smtpConfig.Host)
+	}
+	addr := fmt.Sprintf("%s:%d", smtpConfig.Host, smtpConfig.Port)
+	if err := t.sendMail(addr, auth, smtpConfig.From, to, message.Bytes()); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// post sends a JSON payload to a webhook URL
+func (t *NotificationTool) post(ctx context.Context, url string, payload map[string]interface{}) (int, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("notification rejected with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (t *NotificationTool) Validate(args string) error {
+	var params notificationArgs
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if params.Channel == "" {
+		return fmt.Errorf("channel is required")
+	}
+	if _, err := params.recipients(); err != nil {
+		return err
+	}
+	if params.Body == "" {
+		return fmt.Errorf("body is required")
+	}
+	return nil
+}
+
+func (t *NotificationTool) GetConfig() map[string]interface{} {
+	recipients := make([]string, 0, len(t.allowedRecipients))
+	for recipient := range t.allowedRecipients {
+		recipients = append(recipients, recipient)
+	}
+	sort.Strings(recipients)
+
+	return map[string]interface{}{
+		"channels":           t.channels(),
+		"allowed_recipients": recipients,
+		"timeout":            t.config.Timeout,
+	}
+}
+
+func (t *NotificationTool) SetConfig(config map[string]interface{}) error {
+	if recipients, ok := config["allowed_recipients"].([]string); ok {
+		t.allowedRecipients = make(map[string]bool)
+		for _, recipient := range recipients {
+			t.allowedRecipients[strings.ToLower(recipient)] = true
+		}
+	}
+	if timeout, ok := config["timeout"].(time.Duration); ok && timeout > 0 {
+		t.config.Timeout = timeout
+		t.client.Timeout = timeout
+	}
+	return nil
+}
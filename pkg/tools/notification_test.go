@@ -0,0 +1,108 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+)
+
+func TestNotificationTool(t *testing.T) {
+	var received []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		received = append(received, payload)
+	}))
+	defer server.Close()
+
+	tool, err := NewNotificationTool(NotificationConfig{
+		SMTP:              &SMTPConfig{Host: "smtp.example.com", From: "agent@example.com"},
+		SlackWebhookURL:   server.URL,
+		Webhooks:          map[string]string{"oncall": server.URL},
+		AllowedRecipients: []string{"#alerts", "@example.com", "boss@partner.com"},
+	})
+	if err != nil {
+		t.Fatalf("NewNotificationTool() failed: %v", err)
+	}
+
+	var sentTo []string
+	var sentMessage string
+	tool.sendMail = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		sentTo, sentMessage = to, string(msg)
+		return nil
+	}
+
+	ctx := context.Background()
+	result, err := tool.Execute(ctx, `{"channel": "email", "to": ["ops@example.com", "boss@partner.com"], "subject": "Report\r\nBcc: x@evil.com", "body": "Done"}`)
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if !strings.Contains(result, `"status":"sent"`) || len(sentTo) != 2 {
+		t.Errorf("Expected the email to be sent to both recipients, got %s", result)
+	}
+	if strings.Contains(sentMessage, "\r\nBcc:") {
+		t.Errorf("Expected the subject not to inject headers, got %q", sentMessage)
+	}
+
+	if _, err := tool.Execute(ctx, `{"channel": "slack", "to": "#alerts", "subject": "Deploy", "body": "Finished"}`); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if _, err := tool.Execute(ctx, `{"channel": "webhook", "to": "oncall", "body": "Disk full"}`); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if len(received) != 2 || received[0]["channel"] != "#alerts" || received[1]["body"] != "Disk full" {
+		t.Errorf("Expected the Slack and webhook payloads, got %v", received)
+	}
+
+	// Recipients outside the allow-list are refused
+	rejected := []string{
+		`{"channel": "email", "to": "someone@evil.com", "body": "hi"}`,
+		`{"channel": "email", "to": "Ops <ops@example.com>", "body": "hi"}`,
+		`{"channel": "slack", "to": "#general", "body": "hi"}`,
+		`{"channel": "webhook", "to": "https://evil.com/hook", "body": "hi"}`,
+		`{"channel": "sms", "to": "+100000000", "body": "hi"}`,
+	}
+	for _, args := range rejected {
+		if _, err := tool.Execute(ctx, args); err == nil {
+			t.Errorf("Expected %s to be refused", args)
+		}
+	}
+	if len(received) != 2 {
+		t.Errorf("Expected no more deliveries, got %v", received)
+	}
+
+	if tool.Idempotent() {
+		t.Error("Expected notifications not to be retried")
+	}
+}
+
+func TestNotificationTool_AllowedChannels(t *testing.T) {
+	tool, err := NewNotificationTool(NotificationConfig{
+		SMTP:            &SMTPConfig{Host: "smtp.example.com", From: "agent@example.com"},
+		Webhooks:        map[string]string{"oncall": "http://localhost"},
+		AllowedChannels: []string{"webhook"},
+	})
+	if err != nil {
+		t.Fatalf("NewNotificationTool() failed: %v", err)
+	}
+	if channels := tool.GetConfig()["channels"].([]string); len(channels) != 1 || channels[0] != "webhook" {
+		t.Errorf("Expected only the webhook channel, got %v", channels)
+	}
+	if err := tool.CheckRecipients("email", []string{"ops@example.com"}); err == nil {
+		t.Error("Expected the email channel to be refused")
+	}
+
+	if _, err := NewNotificationTool(NotificationConfig{}); err == nil {
+		t.Error("Expected a configuration without channels to be rejected")
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// ToolCall is a call of a registered tool with its JSON arguments
+type ToolCall struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ToolResult is the outcome of a ToolCall
+type ToolResult struct {
+	Call     ToolCall      `json:"call"`
+	Output   string        `json:"output,omitempty"`
+	Error    error         `json:"-"`
+	Duration time.Duration `json:"duration"`
+}
+
+// ToolCallsFromLLM converts the tool calls of a model response
+func ToolCallsFromLLM(calls []llm.ToolCall) []ToolCall {
+	converted := make([]ToolCall, len(calls))
+	for i, call := range calls {
+		converted[i] = ToolCall{ID: call.ID, Name: call.Function.Name, Arguments: call.Function.Arguments}
+	}
+	return converted
+}
+
+// ExecuteParallel runs independent tool calls concurrently, at most maxConcurrency at a time
+// (all at once when maxConcurrency <= 0), and returns their results in the order of the
+// calls. A failing call reports its error in its result without stopping the others. When
+// ctx is cancelled, the calls not started yet fail with the context error, which is also
+// returned once the running calls have finished.
+func ExecuteParallel(ctx context.Context, registry *ToolRegistry, calls []ToolCall, maxConcurrency int) ([]ToolResult, error) {
+	results := make([]ToolResult, len(calls))
+	if maxConcurrency <= 0 || maxConcurrency > len(calls) {
+		maxConcurrency = len(calls)
+	}
+
+	slots := make(chan struct{}, maxConcurrency)
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			for j := i; j < len(calls); j++ {
+				results[j] = ToolResult{Call: calls[j], Error: err}
+			}
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, call ToolCall) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = registry.executeCall(ctx, call)
+		}(i, call)
+	}
+	wg.Wait()
+
+	return results, ctx.Err()
+}
+
+// executeCall runs a single call, turning a panicking tool into an error so it cannot bring
+// down the other calls
+func (tr *ToolRegistry) executeCall(ctx context.Context, call ToolCall) (result ToolResult) {
+	result.Call = call
+	start := time.Now()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result.Error = fmt.Errorf("tool %s panicked: %v", call.Name, recovered)
+		}
+		result.Duration = time.Since(start)
+	}()
+
+	tool, exists := tr.GetTool(call.Name)
+	if !exists {
+		result.Error = fmt.Errorf("tool %s not found", call.Name)
+		return result
+	}
+	result.Output, result.Error = tr.ExecuteTool(ctx, tool, call.Arguments)
+	return result
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/logging"
+)
+
+// ErrToolQuotaExceeded is returned by ExecuteTool when a tool's rate limit or quota is used up
+var ErrToolQuotaExceeded = errors.New("tool quota exceeded")
+
+// ToolLimit caps how often a tool may be called, e.g. to keep agents from draining the quota
+// of a third-party API key. Each limit counts the calls of a calendar window in UTC; zero
+// disables it.
+type ToolLimit struct {
+	RequestsPerMinute int `json:"requests_per_minute,omitempty" yaml:"requests_per_minute,omitempty"`
+	Daily             int `json:"daily,omitempty" yaml:"daily,omitempty"`
+	Monthly           int `json:"monthly,omitempty" yaml:"monthly,omitempty"`
+}
+
+// ToolQuotaError reports a rejected tool call and when the window of the exceeded limit ends
+type ToolQuotaError struct {
+	Tool string
+	// Period is the window of the exceeded limit: "minute", "day" or "month"
+	Period     string
+	Limit      int
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *ToolQuotaError) Error() string {
+	return fmt.Sprintf("%v for tool %s: %d calls per %s, retry after %s",
+		ErrToolQuotaExceeded, e.Tool, e.Limit, e.Period, e.RetryAfter.Round(time.Second))
+}
+
+// Unwrap returns ErrToolQuotaExceeded
+func (e *ToolQuotaError) Unwrap() error {
+	return ErrToolQuotaExceeded
+}
+
+// QuotaWindow is the counter of a tool's calls in one window of a limit
+type QuotaWindow struct {
+	Key   string
+	Limit int
+	// ExpiresAt ends the window; the counter may be dropped once it has passed
+	ExpiresAt time.Time
+}
+
+// QuotaCounter counts the calls of tools per window
+type QuotaCounter interface {
+	// Take counts a call in every window when none of them has reached its limit, and
+	// returns -1. Otherwise the call is not counted in any window and the index of the first
+	// full window is returned, so rejected calls do not use up the other limits.
+	Take(ctx context.Context, windows []QuotaWindow) (int, error)
+}
+
+// memoryQuotaCount is a counter of a MemoryQuotaCounter
+type memoryQuotaCount struct {
+	count     int64
+	expiresAt time.Time
+}
+
+// MemoryQuotaCounter is an in-process QuotaCounter
+type MemoryQuotaCounter struct {
+	counts map[string]*memoryQuotaCount
+	mu     sync.Mutex
+}
+
+// NewMemoryQuotaCounter creates an in-memory quota counter
+func NewMemoryQuotaCounter() *MemoryQuotaCounter {
+	return &MemoryQuotaCounter{counts: make(map[string]*memoryQuotaCount)}
+}
+
+// Take counts a call in every window unless one of them is full
+func (c *MemoryQuotaCounter) Take(ctx context.Context, windows []QuotaWindow) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	counts := make([]*memoryQuotaCount, len(windows))
+	for i, window := range windows {
+		count, exists := c.counts[window.Key]
+		if !exists || !now.Before(count.expiresAt) {
+			// Windows are calendar based, so a new window also means the old ones are done
+			for k, old := range c.counts {
+				if !now.Before(old.expiresAt) {
+					delete(c.counts, k)
+				}
+			}
+			count = &memoryQuotaCount{expiresAt: window.ExpiresAt}
+			c.counts[window.Key] = count
+		}
+		if count.count >= int64(window.Limit) {
+			return i, nil
+		}
+		counts[i] = count
+	}
+
+	for _, count := range counts {
+		count.count++
+	}
+	return -1, nil
+}
+
+// redisTakeScript counts a call in every window of KEYS, whose limits and expiry times in
+// milliseconds alternate in ARGV, unless one of them is full
+var redisTakeScript = redis.NewScript(`
+for i, key in ipairs(KEYS) do
+	local count = tonumber(redis.call("GET", key) or "0")
+	if count >= tonumber(ARGV[2 * i - 1]) then
+		return i - 1
+	end
+end
+for i, key in ipairs(KEYS) do
+	redis.call("INCR", key)
+	redis.call("PEXPIREAT", key, ARGV[2 * i])
+end
+return -1`)
+
+// RedisQuotaCounter is a QuotaCounter shared between replicas through Redis, so the quota of
+// an API key holds for the whole deployment
+type RedisQuotaCounter struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisQuotaCounter creates a Redis quota counter; keys are namespaced by prefix
+func NewRedisQuotaCounter(client *redis.Client, prefix string) *RedisQuotaCounter {
+	if prefix == "" {
+		prefix = "golanggraph:tool-quota:"
+	}
+	return &RedisQuotaCounter{client: client, prefix: prefix}
+}
+
+// Take counts a call in every window unless one of them is full, atomically for replicas
+func (c *RedisQuotaCounter) Take(ctx context.Context, windows []QuotaWindow) (int, error) {
+	keys := make([]string, len(windows))
+	args := make([]interface{}, 0, 2*len(windows))
+	for i, window := range windows {
+		keys[i] = c.prefix + window.Key
+		args = append(args, window.Limit, window.ExpiresAt.UnixMilli())
+	}
+	full, err := redisTakeScript.Run(ctx, c.client, keys, args...).Int()
+	if err != nil {
+		return 0, err
+	}
+	return full, nil
+}
+
+// defaultQuotaCounter is shared by the registries of the process, so separate registries
+// holding the same tool draw from the same quota
+var defaultQuotaCounter QuotaCounter = NewMemoryQuotaCounter()
+
+// SetToolLimit sets the rate limit and quotas of a tool; nil removes them
+func (tr *ToolRegistry) SetToolLimit(name string, limit *ToolLimit) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if tr.toolLimits == nil {
+		tr.toolLimits = make(map[string]*ToolLimit)
+	}
+	if limit == nil {
+		delete(tr.toolLimits, name)
+		return
+	}
+	tr.toolLimits[name] = limit
+}
+
+// SetQuotaCounter sets the counter of the tool limits, e.g. a RedisQuotaCounter to share
+// them between replicas; nil restores the process-wide in-memory counter
+func (tr *ToolRegistry) SetQuotaCounter(counter QuotaCounter) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.quotaCounter = counter
+}
+
+// takeQuota counts a call of a tool against its limits, returning a ToolQuotaError when one
+// is used up. A failing counter is logged rather than blocking the tool.
+func (tr *ToolRegistry) takeQuota(ctx context.Context, name string) error {
+	tr.mu.RLock()
+	limit := tr.toolLimits[name]
+	counter := tr.quotaCounter
+	logger := tr.logger
+	tr.mu.RUnlock()
+
+	if limit == nil {
+		return nil
+	}
+	if counter == nil {
+		counter = defaultQuotaCounter
+	}
+
+	now := time.Now().UTC()
+	minute := now.Truncate(time.Minute)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	limits := []struct {
+		period string
+		limit  int
+		start  time.Time
+		end    time.Time
+	}{
+		{"minute", limit.RequestsPerMinute, minute, minute.Add(time.Minute)},
+		{"day", limit.Daily, day, day.AddDate(0, 0, 1)},
+		{"month", limit.Monthly, month, month.AddDate(0, 1, 0)},
+	}
+
+	var windows []QuotaWindow
+	var periods []int
+	for i, window := range limits {
+		if window.limit <= 0 {
+			continue
+		}
+		windows = append(windows, QuotaWindow{
+			Key:       fmt.Sprintf("%s:%s:%d", name, window.period, window.start.Unix()),
+			Limit:     window.limit,
+			ExpiresAt: window.end,
+		})
+		periods = append(periods, i)
+	}
+	if len(windows) == 0 {
+		return nil
+	}
+
+	full, err := counter.Take(ctx, windows)
+	if err != nil {
+		logging.FromContext(ctx, logger).WithError(err).WithField("tool", name).Warn("Failed to count tool quota")
+		return nil
+	}
+	if full >= 0 && full < len(windows) {
+		window := limits[periods[full]]
+		return &ToolQuotaError{Tool: name, Period: window.period, Limit: window.limit, RetryAfter: window.end.Sub(now)}
+	}
+	return nil
+}
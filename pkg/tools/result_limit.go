@@ -0,0 +1,115 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// ResultSummarizer condenses an oversized tool result to fit within maxBytes
+type ResultSummarizer func(ctx context.Context, toolName, result string, maxBytes int) (string, error)
+
+// SetMaxResultBytes sets the maximum size of tool results for all tools (0 disables the limit)
+func (tr *ToolRegistry) SetMaxResultBytes(maxBytes int) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.maxResultBytes = maxBytes
+}
+
+// SetToolMaxResultBytes overrides the maximum result size for a single tool (0 disables the limit)
+func (tr *ToolRegistry) SetToolMaxResultBytes(name string, maxBytes int) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if tr.toolResultLimits == nil {
+		tr.toolResultLimits = make(map[string]int)
+	}
+	tr.toolResultLimits[name] = maxBytes
+}
+
+// MaxResultBytes returns the effective result size limit for a tool
+func (tr *ToolRegistry) MaxResultBytes(name string) int {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+	if maxBytes, exists := tr.toolResultLimits[name]; exists {
+		return maxBytes
+	}
+	return tr.maxResultBytes
+}
+
+// SetResultSummarizer makes the registry summarize oversized results instead of truncating them
+func (tr *ToolRegistry) SetResultSummarizer(summarizer ResultSummarizer) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.resultSummarizer = summarizer
+}
+
+// LimitResult applies the configured size limit to a tool result. Oversized results are
+// summarized when a summarizer is configured, falling back to truncation on failure.
+func (tr *ToolRegistry) LimitResult(ctx context.Context, name, result string) string {
+	maxBytes := tr.MaxResultBytes(name)
+	if maxBytes <= 0 || len(result) <= maxBytes {
+		return result
+	}
+
+	tr.mu.RLock()
+	summarizer := tr.resultSummarizer
+	logger := tr.logger
+	tr.mu.RUnlock()
+
+	if summarizer != nil {
+		summary, err := summarizer(ctx, name, result, maxBytes)
+		if err == nil {
+			return TruncateResult(summary, maxBytes)
+		}
+		logger.WithError(err).WithField("tool", name).Warn("Failed to summarize tool result, truncating")
+	}
+
+	return TruncateResult(result, maxBytes)
+}
+
+// TruncateResult cuts a result to maxBytes on a UTF-8 boundary and appends a truncation marker
+func TruncateResult(result string, maxBytes int) string {
+	if maxBytes <= 0 || len(result) <= maxBytes {
+		return result
+	}
+
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(result[cut]) {
+		cut--
+	}
+
+	return fmt.Sprintf("%s\n... [truncated %d bytes]", result[:cut], len(result)-cut)
+}
+
+// NewLLMResultSummarizer creates a summarizer that condenses tool results with an LLM
+func NewLLMResultSummarizer(llmManager *llm.ProviderManager, provider, model string) ResultSummarizer {
+	return func(ctx context.Context, toolName, result string, maxBytes int) (string, error) {
+		resp, err := llmManager.Complete(ctx, provider, llm.CompletionRequest{
+			Model: model,
+			Messages: []llm.Message{
+				{
+					Role: "system",
+					Content: fmt.Sprintf("Summarize the output of the %s tool in at most %d bytes. "+
+						"Keep facts, numbers and identifiers that may be needed to answer the user's request.", toolName, maxBytes),
+				},
+				{Role: "user", Content: result},
+			},
+		})
+		if err != nil {
+			return "", fmt.Errorf("summarization failed: %w", err)
+		}
+		if len(resp.Choices) == 0 {
+			return "", fmt.Errorf("summarization returned no choices")
+		}
+
+		return resp.Choices[0].Message.Content, nil
+	}
+}
@@ -0,0 +1,650 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+// Scheduling operations
+const (
+	SchedulingParse               = "parse"
+	SchedulingAddBusinessDays     = "add_business_days"
+	SchedulingBusinessDaysBetween = "business_days_between"
+	SchedulingIsBusinessDay       = "is_business_day"
+	SchedulingNextBusinessTime    = "next_business_time"
+)
+
+// Holiday is a day off: a date such as "2025-12-26", or a month and day such as "12-25"
+// observed every year
+type Holiday struct {
+	Date string `json:"date" yaml:"date"`
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+}
+
+// BusinessHours are the working hours of the business days
+type BusinessHours struct {
+	// Start and End are times of day such as "09:00" and "17:30"
+	Start string `json:"start" yaml:"start"`
+	End   string `json:"end" yaml:"end"`
+	// Days are the business days; Monday to Friday when empty
+	Days []time.Weekday `json:"days,omitempty" yaml:"days,omitempty"`
+}
+
+// SchedulingConfig configures the scheduling tool's calendar
+type SchedulingConfig struct {
+	// Timezone is the IANA timezone dates are computed in; defaults to UTC
+	Timezone string    `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+	Holidays []Holiday `json:"holidays,omitempty" yaml:"holidays,omitempty"`
+	// BusinessHours default to 09:00 to 17:00, Monday to Friday
+	BusinessHours *BusinessHours `json:"business_hours,omitempty" yaml:"business_hours,omitempty"`
+}
+
+// SchedulingResult is the structured answer of the scheduling tool
+type SchedulingResult struct {
+	// Timestamp is the resulting moment in RFC 3339
+	Timestamp   string `json:"timestamp,omitempty"`
+	Date        string `json:"date,omitempty"`
+	Weekday     string `json:"weekday,omitempty"`
+	Description string `json:"description"`
+	// BusinessDay tells whether the date is a business day
+	BusinessDay *bool `json:"business_day,omitempty"`
+	// Holiday is the name of the holiday on the date, if any
+	Holiday string `json:"holiday,omitempty"`
+	// BusinessDays is the count of business_days_between
+	BusinessDays *int `json:"business_days,omitempty"`
+}
+
+// SchedulingTool computes dates for scheduling: business-day arithmetic aware of holidays
+// and business hours, and relative dates such as "next monday at 9am"
+type SchedulingTool struct {
+	config     SchedulingConfig
+	location   *time.Location
+	fixed      map[string]string
+	recurring  map[string]string
+	businessOn map[time.Weekday]bool
+	openAt     time.Duration
+	closeAt    time.Duration
+	now        func() time.Time
+}
+
+// NewSchedulingTool creates a scheduling tool with a calendar
+func NewSchedulingTool(config SchedulingConfig) (*SchedulingTool, error) {
+	tool := &SchedulingTool{now: time.Now}
+	if err := tool.configure(config); err != nil {
+		return nil, err
+	}
+	return tool, nil
+}
+
+// newDefaultSchedulingTool creates a scheduling tool in UTC with default business hours and
+// no holidays, for the tool factory to configure
+func newDefaultSchedulingTool() *SchedulingTool {
+	tool, err := NewSchedulingTool(SchedulingConfig{})
+	if err != nil {
+		panic(fmt.Sprintf("default scheduling config is invalid: %v", err))
+	}
+	return tool
+}
+
+// configure validates a calendar and indexes it
+func (t *SchedulingTool) configure(config SchedulingConfig) error {
+	if config.Timezone == "" {
+		config.Timezone = "UTC"
+	}
+	location, err := time.LoadLocation(config.Timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	}
+	if config.BusinessHours == nil {
+		config.BusinessHours = &BusinessHours{Start: "09:00", End: "17:00"}
+	}
+	openAt, err := parseClock(config.BusinessHours.Start)
+	if err != nil {
+		return fmt.Errorf("invalid business hours start: %w", err)
+	}
+	closeAt, err := parseClock(config.BusinessHours.End)
+	if err != nil {
+		return fmt.Errorf("invalid business hours end: %w", err)
+	}
+	if closeAt <= openAt {
+		return fmt.Errorf("business hours must end after they start")
+	}
+
+	fixed := make(map[string]string)
+	recurring := make(map[string]string)
+	for _, holiday := range config.Holidays {
+		name := holiday.Name
+		if name == "" {
+			name = "holiday"
+		}
+		if _, err := time.Parse("2006-01-02", holiday.Date); err == nil {
+			fixed[holiday.Date] = name
+		} else if _, err := time.Parse("01-02", holiday.Date); err == nil {
+			recurring[holiday.Date] = name
+		} else {
+			return fmt.Errorf("invalid holiday date %q, expected YYYY-MM-DD or MM-DD", holiday.Date)
+		}
+	}
+
+	days := config.BusinessHours.Days
+	if len(days) == 0 {
+		days = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	}
+	businessOn := make(map[time.Weekday]bool, len(days))
+	for _, day := range days {
+		businessOn[day] = true
+	}
+
+	t.config = config
+	t.location = location
+	t.fixed = fixed
+	t.recurring = recurring
+	t.businessOn = businessOn
+	t.openAt = openAt
+	t.closeAt = closeAt
+	return nil
+}
+
+func (t *SchedulingTool) GetName() string {
+	return "scheduling"
+}
+
+// Idempotent reports that date computations are safe to repeat
+func (t *SchedulingTool) Idempotent() bool {
+	return true
+}
+
+func (t *SchedulingTool) GetDescription() string {
+	return fmt.Sprintf("Compute dates for scheduling in %s: parse relative dates such as \"next monday at 9am\" or "+
+		"\"3 business days from now\", add business days, count business days between dates, check business days "+
+		"and find the next business hour. Weekends and holidays are skipped.", t.config.Timezone)
+}
+
+// GetUsageExamples shows the model the operations
+func (t *SchedulingTool) GetUsageExamples() []ToolExample {
+	return []ToolExample{
+		{Description: "Resolve a relative date", Input: `{"operation": "parse", "expression": "next monday at 9am"}`},
+		{Description: "Deadline three business days out", Input: `{"operation": "add_business_days", "days": 3}`},
+		{Description: "Business days left until a date", Input: `{"operation": "business_days_between", "to": "2025-12-31"}`},
+	}
+}
+
+func (t *SchedulingTool) GetDefinition() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.Function{
+			Name:        t.GetName(),
+			Description: t.GetDescription(),
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"operation": map[string]interface{}{
+						"type": "string",
+						"enum": []string{SchedulingParse, SchedulingAddBusinessDays, SchedulingBusinessDaysBetween,
+							SchedulingIsBusinessDay, SchedulingNextBusinessTime},
+					},
+					"expression": map[string]interface{}{
+						"type":        "string",
+						"description": "Date to parse, e.g. \"tomorrow at 14:30\", \"in 2 weeks\" or \"2025-07-01\"",
+					},
+					"from": map[string]interface{}{
+						"type":        "string",
+						"description": "Start date or relative date (default: now)",
+					},
+					"to": map[string]interface{}{
+						"type":        "string",
+						"description": "End date or relative date, for business_days_between",
+					},
+					"date": map[string]interface{}{
+						"type":        "string",
+						"description": "Date to check, for is_business_day (default: today)",
+					},
+					"days": map[string]interface{}{
+						"type":        "integer",
+						"description": "Business days to add; negative to go back",
+					},
+					"timezone": map[string]interface{}{
+						"type":        "string",
+						"description": fmt.Sprintf("Timezone of the dates (default: %s)", t.config.Timezone),
+					},
+				},
+				"required": []string{"operation"},
+			},
+		},
+	}
+}
+
+// schedulingArgs are the arguments of the tool
+type schedulingArgs struct {
+	Operation  string `json:"operation"`
+	Expression string `json:"expression"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Date       string `json:"date"`
+	Days       int    `json:"days"`
+	Timezone   string `json:"timezone"`
+}
+
+func (t *SchedulingTool) Execute(ctx context.Context, args string) (string, error) {
+	var params schedulingArgs
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	location := t.location
+	if params.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(params.Timezone); err != nil {
+			return "", fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
+	now := t.now().In(location)
+
+	result, err := t.run(params, now)
+	if err != nil {
+		return "", err
+	}
+	output, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+	return string(output), nil
+}
+
+// run performs an operation relative to now
+func (t *SchedulingTool) run(params schedulingArgs, now time.Time) (*SchedulingResult, error) {
+	switch params.Operation {
+	case SchedulingParse:
+		if params.Expression == "" {
+			return nil, fmt.Errorf("expression is required")
+		}
+		moment, err := t.ParseRelative(params.Expression, now)
+		if err != nil {
+			return nil, err
+		}
+		return t.describe(moment), nil
+
+	case SchedulingAddBusinessDays:
+		from, err := t.resolve(params.From, now)
+		if err != nil {
+			return nil, err
+		}
+		moment := t.AddBusinessDays(from, params.Days)
+		result := t.describe(moment)
+		result.Description = fmt.Sprintf("%d business days from %s: %s", params.Days, from.Format("Monday, 2 January 2006"), result.Description)
+		return result, nil
+
+	case SchedulingBusinessDaysBetween:
+		from, err := t.resolve(params.From, now)
+		if err != nil {
+			return nil, err
+		}
+		if params.To == "" {
+			return nil, fmt.Errorf("to is required")
+		}
+		to, err := t.ParseRelative(params.To, now)
+		if err != nil {
+			return nil, err
+		}
+		days := t.BusinessDaysBetween(from, to)
+		return &SchedulingResult{
+			BusinessDays: &days,
+			Description: fmt.Sprintf("%d business days from %s to %s", days,
+				from.Format("Monday, 2 January 2006"), to.Format("Monday, 2 January 2006")),
+		}, nil
+
+	case SchedulingIsBusinessDay:
+		date, err := t.resolve(params.Date, now)
+		if err != nil {
+			return nil, err
+		}
+		result := t.describe(date)
+		switch {
+		case result.Holiday != "":
+			result.Description = fmt.Sprintf("%s is not a business day: %s", date.Format("Monday, 2 January 2006"), result.Holiday)
+		case !*result.BusinessDay:
+			result.Description = fmt.Sprintf("%s is not a business day", date.Format("Monday, 2 January 2006"))
+		default:
+			result.Description = fmt.Sprintf("%s is a business day", date.Format("Monday, 2 January 2006"))
+		}
+		return result, nil
+
+	case SchedulingNextBusinessTime:
+		from, err := t.resolve(params.From, now)
+		if err != nil {
+			return nil, err
+		}
+		return t.describe(t.NextBusinessTime(from)), nil
+
+	default:
+		return nil, fmt.Errorf("unknown operation %q", params.Operation)
+	}
+}
+
+// resolve parses an optional date argument, defaulting to now
+func (t *SchedulingTool) resolve(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return now, nil
+	}
+	return t.ParseRelative(value, now)
+}
+
+// describe returns the structured result of a moment
+func (t *SchedulingTool) describe(moment time.Time) *SchedulingResult {
+	business := t.IsBusinessDay(moment)
+	return &SchedulingResult{
+		Timestamp:   moment.Format(time.RFC3339),
+		Date:        moment.Format("2006-01-02"),
+		Weekday:     moment.Weekday().String(),
+		Description: moment.Format("Monday, 2 January 2006 at 15:04 MST"),
+		BusinessDay: &business,
+		Holiday:     t.holiday(moment),
+	}
+}
+
+// holiday returns the name of the holiday on a date, or an empty string
+func (t *SchedulingTool) holiday(date time.Time) string {
+	if name, exists := t.fixed[date.Format("2006-01-02")]; exists {
+		return name
+	}
+	return t.recurring[date.Format("01-02")]
+}
+
+// IsBusinessDay reports whether a date is a business day that is not a holiday
+func (t *SchedulingTool) IsBusinessDay(date time.Time) bool {
+	return t.businessOn[date.Weekday()] && t.holiday(date) == ""
+}
+
+// AddBusinessDays moves a moment by a number of business days, keeping its time of day.
+// Adding zero days moves a moment on a day off to the next business day.
+func (t *SchedulingTool) AddBusinessDays(from time.Time, days int) time.Time {
+	step := 1
+	if days < 0 {
+		step, days = -1, -days
+	}
+	moment := from
+	if days == 0 {
+		for !t.IsBusinessDay(moment) {
+			moment = moment.AddDate(0, 0, 1)
+		}
+		return moment
+	}
+	for days > 0 {
+		moment = moment.AddDate(0, 0, step)
+		if t.IsBusinessDay(moment) {
+			days--
+		}
+	}
+	return moment
+}
+
+// BusinessDaysBetween counts the business days after from up to and including to, negative
+// when to is before from
+func (t *SchedulingTool) BusinessDaysBetween(from, to time.Time) int {
+	sign := 1
+	start, end := dateOf(from), dateOf(to.In(from.Location()))
+	if end.Before(start) {
+		sign, start, end = -1, end, start
+	}
+	count := 0
+	for day := start.AddDate(0, 0, 1); !day.After(end); day = day.AddDate(0, 0, 1) {
+		if t.IsBusinessDay(day) {
+			count++
+		}
+	}
+	return sign * count
+}
+
+// NextBusinessTime returns the moment itself within business hours, or the opening of the
+// next business hours
+func (t *SchedulingTool) NextBusinessTime(from time.Time) time.Time {
+	if t.IsBusinessDay(from) {
+		clock := time.Duration(from.Hour())*time.Hour + time.Duration(from.Minute())*time.Minute + time.Duration(from.Second())*time.Second
+		if clock < t.openAt {
+			return atClock(from, t.openAt)
+		}
+		if clock < t.closeAt {
+			return from
+		}
+	}
+	return atClock(t.AddBusinessDays(from, 1), t.openAt)
+}
+
+var (
+	// clockSuffix matches a time of day ending an expression, e.g. "at 9am" or "14:30"
+	clockSuffix = regexp.MustCompile(`(?:^|\s+)(?:at\s+)?(noon|midnight|\d{1,2}(?::\d{2})?\s*(?:am|pm)|\d{1,2}:\d{2})$`)
+	// bareHourSuffix matches an hour introduced by "at", e.g. "at 9"
+	bareHourSuffix = regexp.MustCompile(`(?:^|\s+)at\s+(\d{1,2})$`)
+	// offsetPattern matches "in 3 days", "3 business days from now" and "2 weeks ago"
+	offsetPattern = regexp.MustCompile(`^(?:in\s+)?(\d+|a|an|one|two|three|four|five|six|seven|eight|nine|ten)\s+` +
+		`(business\s+days?|minutes?|hours?|days?|weeks?|months?|years?)(?:\s+(from\s+now|from\s+today|from\s+tomorrow|later|ago))?$`)
+)
+
+var numberWords = map[string]int{"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10}
+
+// ParseRelative parses an absolute or relative date against now, in now's location:
+// ISO dates and times, "now", "today", "tomorrow", "yesterday", weekdays such as "next
+// monday", "next week", "next business day", and offsets such as "in 3 days", "2 weeks
+// ago" or "3 business days from now", each optionally followed by a time of day such as
+// "at 9am" or "14:30"
+func (t *SchedulingTool) ParseRelative(expression string, now time.Time) (time.Time, error) {
+	location := now.Location()
+	text := strings.Join(strings.Fields(strings.ToLower(expression)), " ")
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"} {
+		if moment, err := time.ParseInLocation(layout, text, location); err == nil {
+			return moment.In(location), nil
+		}
+		if layout == time.RFC3339 {
+			if moment, err := time.Parse(layout, strings.ToUpper(text)); err == nil {
+				return moment.In(location), nil
+			}
+		}
+	}
+
+	clock, hasClock, rest, err := splitClock(text)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	moment, err := t.parseDay(rest, now, hasClock)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot parse date %q: %w", expression, err)
+	}
+	if hasClock {
+		moment = atClock(moment, clock)
+	}
+	return moment, nil
+}
+
+// parseDay parses the day part of an expression; with a time of day, a bare expression
+// means today
+func (t *SchedulingTool) parseDay(text string, now time.Time, hasClock bool) (time.Time, error) {
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "on "), ",")
+	switch text {
+	case "", "now":
+		if text == "" && !hasClock {
+			return time.Time{}, fmt.Errorf("empty expression")
+		}
+		return now, nil
+	case "today":
+		return now, nil
+	case "tomorrow":
+		return now.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	case "next week":
+		return now.AddDate(0, 0, 7), nil
+	case "next month":
+		return now.AddDate(0, 1, 0), nil
+	case "next business day":
+		return t.AddBusinessDays(now, 1), nil
+	}
+
+	if weekday, qualifier, ok := parseWeekday(text); ok {
+		days := (int(weekday) - int(now.Weekday()) + 7) % 7
+		if days == 0 && qualifier != "this" {
+			days = 7
+		}
+		if qualifier == "last" {
+			days = -((int(now.Weekday()) - int(weekday) + 7) % 7)
+			if days == 0 {
+				days = -7
+			}
+		}
+		return now.AddDate(0, 0, days), nil
+	}
+
+	if match := offsetPattern.FindStringSubmatch(text); match != nil {
+		amount, exists := numberWords[match[1]]
+		if !exists {
+			amount, _ = strconv.Atoi(match[1])
+		}
+		base := now
+		switch match[3] {
+		case "ago":
+			amount = -amount
+		case "from tomorrow":
+			base = now.AddDate(0, 0, 1)
+		}
+		unit := strings.TrimSuffix(match[2], "s")
+		switch {
+		case strings.HasPrefix(unit, "business"):
+			return t.AddBusinessDays(base, amount), nil
+		case unit == "minute":
+			return base.Add(time.Duration(amount) * time.Minute), nil
+		case unit == "hour":
+			return base.Add(time.Duration(amount) * time.Hour), nil
+		case unit == "day":
+			return base.AddDate(0, 0, amount), nil
+		case unit == "week":
+			return base.AddDate(0, 0, 7*amount), nil
+		case unit == "month":
+			return base.AddDate(0, amount, 0), nil
+		default:
+			return base.AddDate(amount, 0, 0), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized expression %q", text)
+}
+
+// splitClock removes a trailing time of day from an expression
+func splitClock(text string) (time.Duration, bool, string, error) {
+	if match := clockSuffix.FindStringSubmatchIndex(text); match != nil {
+		clock, err := parseClock(text[match[2]:match[3]])
+		return clock, true, strings.TrimSpace(text[:match[0]]), err
+	}
+	if match := bareHourSuffix.FindStringSubmatchIndex(text); match != nil {
+		clock, err := parseClock(text[match[2]:match[3]] + ":00")
+		return clock, true, strings.TrimSpace(text[:match[0]]), err
+	}
+	return 0, false, text, nil
+}
+
+// parseClock parses a time of day such as "09:00", "9am", "2:30 pm", "noon" or "midnight"
+func parseClock(value string) (time.Duration, error) {
+	value = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(value)), " ", "")
+	switch value {
+	case "noon":
+		return 12 * time.Hour, nil
+	case "midnight":
+		return 0, nil
+	}
+
+	for _, layout := range []string{"15:04", "3pm", "3:04pm"} {
+		if clock, err := time.Parse(layout, value); err == nil {
+			return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid time of day %q", value)
+}
+
+// parseWeekday parses "monday", "next monday", "this monday" or "last monday"
+func parseWeekday(text string) (time.Weekday, string, bool) {
+	qualifier := ""
+	if fields := strings.Fields(text); len(fields) == 2 {
+		qualifier, text = fields[0], fields[1]
+		if qualifier != "next" && qualifier != "this" && qualifier != "last" {
+			return 0, "", false
+		}
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		if text == name || text == name[:3] {
+			return day, qualifier, true
+		}
+	}
+	return 0, "", false
+}
+
+// dateOf returns the midnight starting a moment's day
+func dateOf(moment time.Time) time.Time {
+	return atClock(moment, 0)
+}
+
+// atClock returns the time of day on a moment's day, in its location, so days changing
+// to or from daylight saving time keep the wall clock
+func atClock(moment time.Time, clock time.Duration) time.Time {
+	year, month, day := moment.Date()
+	return time.Date(year, month, day, int(clock/time.Hour), int(clock%time.Hour/time.Minute), 0, 0, moment.Location())
+}
+
+func (t *SchedulingTool) Validate(args string) error {
+	var params schedulingArgs
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	switch params.Operation {
+	case SchedulingParse:
+		if params.Expression == "" {
+			return fmt.Errorf("expression is required")
+		}
+	case SchedulingBusinessDaysBetween:
+		if params.To == "" {
+			return fmt.Errorf("to is required")
+		}
+	case SchedulingAddBusinessDays, SchedulingIsBusinessDay, SchedulingNextBusinessTime:
+	case "":
+		return fmt.Errorf("operation is required")
+	default:
+		return fmt.Errorf("unknown operation %q", params.Operation)
+	}
+	return nil
+}
+
+func (t *SchedulingTool) GetConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"timezone":       t.config.Timezone,
+		"holidays":       t.config.Holidays,
+		"business_hours": t.config.BusinessHours,
+	}
+}
+
+// SetConfig replaces the fields of the calendar given as "timezone", "holidays" and
+// "business_hours", in the form of SchedulingConfig
+func (t *SchedulingTool) SetConfig(config map[string]interface{}) error {
+	encoded, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("invalid scheduling config: %w", err)
+	}
+	updated := t.config
+	if err := json.Unmarshal(encoded, &updated); err != nil {
+		return fmt.Errorf("invalid scheduling config: %w", err)
+	}
+	return t.configure(updated)
+}
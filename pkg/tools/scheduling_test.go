@@ -0,0 +1,121 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSchedulingTool(t *testing.T) {
+	tool, err := NewSchedulingTool(SchedulingConfig{
+		Timezone: "Europe/Paris",
+		Holidays: []Holiday{{Date: "2026-10-19", Name: "Team day"}, {Date: "12-25", Name: "Christmas"}},
+	})
+	if err != nil {
+		t.Fatalf("NewSchedulingTool() failed: %v", err)
+	}
+	paris, _ := time.LoadLocation("Europe/Paris")
+	// Thursday afternoon, the week before the switch from daylight saving time
+	tool.now = func() time.Time { return time.Date(2026, 10, 15, 14, 0, 0, 0, paris) }
+
+	run := func(args string) SchedulingResult {
+		t.Helper()
+		output, err := tool.Execute(context.Background(), args)
+		if err != nil {
+			t.Fatalf("Execute(%s) failed: %v", args, err)
+		}
+		var result SchedulingResult
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("Failed to decode %s: %v", output, err)
+		}
+		return result
+	}
+
+	tests := map[string]string{
+		`{"operation": "parse", "expression": "next monday at 9am"}`:                "2026-10-19T09:00:00+02:00",
+		`{"operation": "parse", "expression": "Tomorrow at 14:30"}`:                 "2026-10-16T14:30:00+02:00",
+		`{"operation": "parse", "expression": "in 2 hours"}`:                        "2026-10-15T16:00:00+02:00",
+		`{"operation": "parse", "expression": "last friday at noon"}`:               "2026-10-09T12:00:00+02:00",
+		`{"operation": "parse", "expression": "3 business days from now"}`:          "2026-10-21T14:00:00+02:00",
+		`{"operation": "add_business_days", "days": -1}`:                            "2026-10-14T14:00:00+02:00",
+		`{"operation": "add_business_days", "from": "2026-10-23 10:00", "days": 1}`: "2026-10-26T10:00:00+01:00",
+		`{"operation": "next_business_time", "from": "2026-10-16 18:00"}`:           "2026-10-20T09:00:00+02:00",
+		`{"operation": "next_business_time"}`:                                       "2026-10-15T14:00:00+02:00",
+		`{"operation": "parse", "expression": "monday", "timezone": "Asia/Tokyo"}`:  "2026-10-19T21:00:00+09:00",
+	}
+	for args, expected := range tests {
+		if result := run(args); result.Timestamp != expected {
+			t.Errorf("Execute(%s) = %s, expected %s", args, result.Timestamp, expected)
+		}
+	}
+
+	result := run(`{"operation": "parse", "expression": "next monday at 9am"}`)
+	if result.Weekday != "Monday" || result.Holiday != "Team day" || *result.BusinessDay {
+		t.Errorf("Expected the holiday to be reported, got %+v", result)
+	}
+
+	result = run(`{"operation": "business_days_between", "to": "2026-10-23"}`)
+	if result.BusinessDays == nil || *result.BusinessDays != 5 {
+		t.Errorf("Expected 5 business days, got %+v", result)
+	}
+
+	result = run(`{"operation": "is_business_day", "date": "2027-12-25"}`)
+	if *result.BusinessDay || result.Holiday != "Christmas" {
+		t.Errorf("Expected the recurring holiday, got %+v", result)
+	}
+
+	for _, args := range []string{
+		`{"operation": "parse", "expression": "whenever"}`,
+		`{"operation": "parse", "expression": "tomorrow at 25:00"}`,
+		`{"operation": "teleport"}`,
+		`{"operation": "parse", "expression": "today", "timezone": "Mars/Olympus"}`,
+	} {
+		if _, err := tool.Execute(context.Background(), args); err == nil {
+			t.Errorf("Expected Execute(%s) to fail", args)
+		}
+	}
+}
+
+func TestSchedulingTool_Config(t *testing.T) {
+	invalid := []SchedulingConfig{
+		{Timezone: "Nowhere/City"},
+		{Holidays: []Holiday{{Date: "25/12"}}},
+		{BusinessHours: &BusinessHours{Start: "18:00", End: "09:00"}},
+	}
+	for _, config := range invalid {
+		if _, err := NewSchedulingTool(config); err == nil {
+			t.Errorf("Expected %+v to be rejected", config)
+		}
+	}
+
+	built, err := BuildTool("scheduling", map[string]interface{}{
+		"timezone": "America/New_York",
+		"holidays": []interface{}{map[string]interface{}{"date": "07-04", "name": "Independence Day"}},
+		"business_hours": map[string]interface{}{
+			"start": "08:00",
+			"end":   "16:00",
+			"days":  []interface{}{1, 2, 3, 4},
+		},
+	})
+	if err != nil {
+		t.Fatalf("BuildTool() failed: %v", err)
+	}
+	tool := built.(*SchedulingTool)
+	newYork, _ := time.LoadLocation("America/New_York")
+	if tool.IsBusinessDay(time.Date(2030, 7, 4, 12, 0, 0, 0, newYork)) {
+		t.Error("Expected the configured holiday to be observed")
+	}
+	if tool.IsBusinessDay(time.Date(2030, 7, 5, 12, 0, 0, 0, newYork)) {
+		t.Error("Expected Friday to be off with a four-day week")
+	}
+	if next := tool.NextBusinessTime(time.Date(2030, 7, 8, 7, 0, 0, 0, newYork)); next.Hour() != 8 {
+		t.Errorf("Expected business hours to open at 8, got %v", next)
+	}
+}
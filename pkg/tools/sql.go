@@ -0,0 +1,631 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package tools
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	_ "github.com/lib/pq"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+)
+
+const defaultSQLMaxRows = 100
+
+// defaultSQLSchema is the schema of allowed tables named without one
+const defaultSQLSchema = "public"
+
+// SQLConfig configures the SQL query tool
+type SQLConfig struct {
+	// Driver is the database/sql driver name (default: postgres)
+	Driver string `json:"driver"`
+	// DSN is the data source name passed to the driver
+	DSN string `json:"dsn"`
+	// ReadOnly rejects every statement except SELECT and runs queries in a read-only transaction
+	ReadOnly bool `json:"read_only"`
+	// AllowedTables restricts the tables that may be queried, schema-qualified outside the
+	// public schema; empty allows all tables
+	AllowedTables []string `json:"allowed_tables"`
+	// MaxRows caps the number of rows returned to the model
+	MaxRows int `json:"max_rows"`
+	// Timeout bounds the execution time of a single query
+	Timeout time.Duration `json:"timeout"`
+}
+
+// SQLColumn describes a column of a table or query result
+type SQLColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// SQLTable describes a table the model may query
+type SQLTable struct {
+	Name    string      `json:"name"`
+	Columns []SQLColumn `json:"columns"`
+}
+
+// SQLResult is the structured result of a query
+type SQLResult struct {
+	Columns   []SQLColumn              `json:"columns"`
+	Rows      []map[string]interface{} `json:"rows"`
+	RowCount  int                      `json:"row_count"`
+	Truncated bool                     `json:"truncated"`
+}
+
+// SQLTool executes parameterized queries against a SQL database
+type SQLTool struct {
+	db            *sql.DB
+	readOnly      bool
+	allowedTables map[string]bool
+	maxRows       int
+	timeout       time.Duration
+	schema        []SQLTable
+}
+
+// NewSQLTool connects to the configured database and loads the schema exposed to the model
+func NewSQLTool(config SQLConfig) (*SQLTool, error) {
+	if config.Driver == "" {
+		config.Driver = "postgres"
+	}
+	if config.DSN == "" {
+		return nil, fmt.Errorf("DSN is required")
+	}
+
+	db, err := sql.Open(config.Driver, config.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	tool, err := NewSQLToolWithDB(db, config)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return tool, nil
+}
+
+// NewSQLToolWithDB creates a SQL tool on an existing connection pool
+func NewSQLToolWithDB(db *sql.DB, config SQLConfig) (*SQLTool, error) {
+	tool := &SQLTool{
+		db:            db,
+		readOnly:      config.ReadOnly,
+		allowedTables: make(map[string]bool),
+		maxRows:       config.MaxRows,
+		timeout:       config.Timeout,
+	}
+	if tool.maxRows <= 0 {
+		tool.maxRows = defaultSQLMaxRows
+	}
+	if tool.timeout <= 0 {
+		tool.timeout = 30 * time.Second
+	}
+	for _, table := range config.AllowedTables {
+		tool.allowedTables[strings.ToLower(table)] = true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tool.timeout)
+	defer cancel()
+
+	if err := tool.LoadSchema(ctx); err != nil {
+		return nil, err
+	}
+	return tool, nil
+}
+
+// LoadSchema refreshes the tables and columns exposed to the model
+func (t *SQLTool) LoadSchema(ctx context.Context) error {
+	rows, err := t.db.QueryContext(ctx, `
+		SELECT table_name, column_name, data_type
+		FROM information_schema.columns
+		WHERE table_schema NOT IN ('pg_catalog', 'information_schema', 'mysql', 'performance_schema', 'sys')
+		ORDER BY table_name, ordinal_position`)
+	if err != nil {
+		return fmt.Errorf("failed to load schema: %w", err)
+	}
+	defer rows.Close()
+
+	tables := make(map[string]*SQLTable)
+	var names []string
+	for rows.Next() {
+		var tableName, columnName, dataType string
+		if err := rows.Scan(&tableName, &columnName, &dataType); err != nil {
+			return fmt.Errorf("failed to scan schema: %w", err)
+		}
+		if !t.tableAllowed(tableName) {
+			continue
+		}
+
+		table, exists := tables[tableName]
+		if !exists {
+			table = &SQLTable{Name: tableName}
+			tables[tableName] = table
+			names = append(names, tableName)
+		}
+		table.Columns = append(table.Columns, SQLColumn{Name: columnName, Type: dataType})
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to load schema: %w", err)
+	}
+
+	sort.Strings(names)
+	schema := make([]SQLTable, 0, len(names))
+	for _, name := range names {
+		schema = append(schema, *tables[name])
+	}
+	t.schema = schema
+	return nil
+}
+
+// Schema returns the tables and columns exposed to the model
+func (t *SQLTool) Schema() []SQLTable {
+	return t.schema
+}
+
+func (t *SQLTool) GetName() string {
+	return "sql_query"
+}
+
+// Idempotent reports that queries are safe to repeat in read-only mode
+func (t *SQLTool) Idempotent() bool {
+	return t.readOnly
+}
+
+func (t *SQLTool) GetDescription() string {
+	var description strings.Builder
+	if t.readOnly {
+		description.WriteString("Run a read-only SQL SELECT query")
+	} else {
+		description.WriteString("Run a SQL query")
+	}
+	description.WriteString(fmt.Sprintf(" (one statement, use $1, $2... placeholders for values, at most %d rows are returned).", t.maxRows))
+
+	if len(t.schema) > 0 {
+		description.WriteString(" Available tables:")
+		for _, table := range t.schema {
+			columns := make([]string, len(table.Columns))
+			for i, column := range table.Columns {
+				columns[i] = fmt.Sprintf("%s %s", column.Name, column.Type)
+			}
+			description.WriteString(fmt.Sprintf("\n- %s(%s)", table.Name, strings.Join(columns, ", ")))
+		}
+	}
+	return description.String()
+}
+
+func (t *SQLTool) GetDefinition() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.Function{
+			Name:        t.GetName(),
+			Description: t.GetDescription(),
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "SQL query with $1, $2... placeholders",
+					},
+					"params": map[string]interface{}{
+						"type":        "array",
+						"description": "Values bound to the query placeholders",
+					},
+				},
+				"required": []string{"query"},
+			},
+		},
+	}
+}
+
+func (t *SQLTool) Execute(ctx context.Context, args string) (string, error) {
+	var params struct {
+		Query  string        `json:"query"`
+		Params []interface{} `json:"params"`
+	}
+
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	result, err := t.Query(ctx, params.Query, params.Params...)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+	return string(output), nil
+}
+
+// Query runs a checked query and returns at most MaxRows rows
+func (t *SQLTool) Query(ctx context.Context, query string, args ...interface{}) (*SQLResult, error) {
+	if err := t.CheckQuery(query); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	tx, err := t.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: t.readOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	result := &SQLResult{
+		Columns: make([]SQLColumn, len(columnTypes)),
+		Rows:    make([]map[string]interface{}, 0),
+	}
+	for i, columnType := range columnTypes {
+		result.Columns[i] = SQLColumn{Name: columnType.Name(), Type: strings.ToLower(columnType.DatabaseTypeName())}
+	}
+
+	for rows.Next() {
+		if len(result.Rows) >= t.maxRows {
+			result.Truncated = true
+			break
+		}
+
+		values := make([]interface{}, len(columnTypes))
+		pointers := make([]interface{}, len(columnTypes))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		row := make(map[string]interface{}, len(columnTypes))
+		for i, column := range result.Columns {
+			if bytes, ok := values[i].([]byte); ok {
+				row[column.Name] = string(bytes)
+			} else {
+				row[column.Name] = values[i]
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	result.RowCount = len(result.Rows)
+	return result, nil
+}
+
+// CheckQuery rejects multiple statements, writes in read-only mode, functions running SQL and
+// tables that are not allowed
+func (t *SQLTool) CheckQuery(query string) error {
+	tokens, err := tokenizeSQL(query)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("query is required")
+	}
+
+	// Only a single trailing semicolon is accepted
+	for i, token := range tokens {
+		if token == ";" && i != len(tokens)-1 {
+			return fmt.Errorf("multiple statements are not allowed")
+		}
+	}
+
+	if t.readOnly {
+		// TABLE x is shorthand for SELECT * FROM x
+		if tokens[0] != "select" && tokens[0] != "table" {
+			return fmt.Errorf("only SELECT queries are allowed in read-only mode")
+		}
+		for _, token := range tokens {
+			if sqlWriteKeywords[token] {
+				return fmt.Errorf("keyword %s is not allowed in read-only mode", strings.ToUpper(token))
+			}
+		}
+	}
+
+	if function, found := queryFunction(tokens); found {
+		return fmt.Errorf("function %s is not allowed", function)
+	}
+
+	if len(t.allowedTables) > 0 {
+		for _, table := range referencedTables(tokens) {
+			if !t.tableAllowed(table) {
+				return fmt.Errorf("table %s is not allowed", table)
+			}
+		}
+	}
+	return nil
+}
+
+// tableAllowed reports whether a table may be queried. Schema-qualified names must be allowed
+// as such, except in the default schema where the unqualified name is enough.
+func (t *SQLTool) tableAllowed(table string) bool {
+	if len(t.allowedTables) == 0 {
+		return true
+	}
+	table = strings.ToLower(table)
+	if t.allowedTables[table] {
+		return true
+	}
+	if name, found := strings.CutPrefix(table, defaultSQLSchema+"."); found {
+		return t.allowedTables[name]
+	}
+	return false
+}
+
+func (t *SQLTool) Validate(args string) error {
+	var params struct {
+		Query string `json:"query"`
+	}
+
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	if params.Query == "" {
+		return fmt.Errorf("query is required")
+	}
+
+	return nil
+}
+
+func (t *SQLTool) GetConfig() map[string]interface{} {
+	tables := make([]string, 0, len(t.allowedTables))
+	for table := range t.allowedTables {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	return map[string]interface{}{
+		"read_only":      t.readOnly,
+		"allowed_tables": tables,
+		"max_rows":       t.maxRows,
+		"timeout":        t.timeout,
+	}
+}
+
+func (t *SQLTool) SetConfig(config map[string]interface{}) error {
+	if readOnly, ok := config["read_only"].(bool); ok {
+		t.readOnly = readOnly
+	}
+	if tables, ok := config["allowed_tables"].([]string); ok {
+		t.allowedTables = make(map[string]bool)
+		for _, table := range tables {
+			t.allowedTables[strings.ToLower(table)] = true
+		}
+	}
+	if maxRows, ok := config["max_rows"].(int); ok && maxRows > 0 {
+		t.maxRows = maxRows
+	}
+	if timeout, ok := config["timeout"].(time.Duration); ok {
+		t.timeout = timeout
+	}
+	return nil
+}
+
+// Close closes the underlying database connection
+func (t *SQLTool) Close() error {
+	return t.db.Close()
+}
+
+// sqlWriteKeywords are rejected anywhere in a read-only query
+var sqlWriteKeywords = map[string]bool{
+	"insert": true, "update": true, "delete": true, "merge": true, "upsert": true,
+	"create": true, "alter": true, "drop": true, "truncate": true, "rename": true,
+	"grant": true, "revoke": true, "copy": true, "call": true, "execute": true,
+	"into": true, "lock": true, "vacuum": true, "set": true, "do": true,
+}
+
+// sqlClauseKeywords end a FROM or JOIN table list
+var sqlClauseKeywords = map[string]bool{
+	"where": true, "group": true, "order": true, "limit": true, "offset": true,
+	"having": true, "union": true, "except": true, "intersect": true, "window": true,
+	"join": true, "inner": true, "left": true, "right": true, "full": true,
+	"cross": true, "outer": true, "natural": true, "on": true, "using": true,
+	"fetch": true, "for": true, "lateral": true,
+}
+
+// tokenizeSQL lowercases a query into identifiers and punctuation, dropping string
+// literals and comments so they cannot hide statements or keywords
+func tokenizeSQL(query string) ([]string, error) {
+	var tokens []string
+	runes := []rune(query)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/') {
+				i++
+			}
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i += 2
+		case r == '\'':
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("unterminated string literal")
+				}
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				i++
+			}
+			tokens = append(tokens, "'")
+		case r == '"':
+			start := i + 1
+			for i++; i < len(runes) && runes[i] != '"'; i++ {
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated quoted identifier")
+			}
+			tokens = appendIdentifier(tokens, strings.ToLower(string(runes[start:i])))
+			i++
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || r == '$':
+			start := i
+			for i < len(runes) && (runes[i] == '_' || runes[i] == '$' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = appendIdentifier(tokens, strings.ToLower(string(runes[start:i])))
+		default:
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// appendIdentifier joins schema-qualified names such as public.users into one token
+func appendIdentifier(tokens []string, identifier string) []string {
+	if n := len(tokens); n >= 2 && tokens[n-1] == "." && isSQLIdentifier(tokens[n-2]) {
+		return append(tokens[:n-2], tokens[n-2]+"."+identifier)
+	}
+	return append(tokens, identifier)
+}
+
+func isSQLIdentifier(token string) bool {
+	r := []rune(token)
+	return len(r) > 0 && (r[0] == '_' || unicode.IsLetter(r[0]))
+}
+
+// sqlFromFunctions take FROM in their arguments for something other than a table, as in
+// EXTRACT(year FROM created_at)
+var sqlFromFunctions = map[string]bool{
+	"extract": true, "substring": true, "trim": true, "overlay": true,
+}
+
+// sqlTableListEnd ends a comma-separated FROM list
+var sqlTableListEnd = map[string]bool{
+	"where": true, "group": true, "order": true, "limit": true, "offset": true,
+	"having": true, "union": true, "except": true, "intersect": true, "window": true,
+	"fetch": true, "for": true, "select": true, "set": true, "values": true, "returning": true,
+}
+
+// sqlQueryFunctions run the SQL or read the tables given as arguments, out of reach of the
+// table checks
+var sqlQueryFunctions = map[string]bool{
+	"query_to_xml": true, "query_to_xmlschema": true, "query_to_xml_and_xmlschema": true,
+	"cursor_to_xml": true, "cursor_to_xmlschema": true,
+	"table_to_xml": true, "table_to_xmlschema": true, "table_to_xml_and_xmlschema": true,
+	"schema_to_xml": true, "schema_to_xmlschema": true, "schema_to_xml_and_xmlschema": true,
+	"database_to_xml": true, "database_to_xmlschema": true, "database_to_xml_and_xmlschema": true,
+	"pg_read_file": true, "pg_read_binary_file": true, "pg_ls_dir": true, "pg_stat_file": true,
+	"lo_import": true, "lo_export": true, "load_file": true,
+}
+
+// queryFunction returns the first call to a function running SQL, and false when there is none
+func queryFunction(tokens []string) (string, bool) {
+	for i := 0; i+1 < len(tokens); i++ {
+		if tokens[i+1] != "(" || !isSQLIdentifier(tokens[i]) {
+			continue
+		}
+		name := tokens[i][strings.LastIndex(tokens[i], ".")+1:]
+		if sqlQueryFunctions[name] || strings.HasPrefix(name, "dblink") {
+			return tokens[i], true
+		}
+	}
+	return "", false
+}
+
+// referencedTables returns the tables named in FROM, JOIN, UPDATE, INTO and USING clauses and
+// after TABLE, which reads a whole table as in "TABLE users", at every nesting level. FROM in
+// the arguments of EXTRACT and similar functions and in IS DISTINCT FROM is ignored.
+func referencedTables(tokens []string) []string {
+	// sqlLevel is an open parenthesis
+	type sqlLevel struct {
+		function  bool // Arguments of a function in sqlFromFunctions
+		tableList bool // Commas separate tables
+	}
+
+	var tables []string
+	levels := []sqlLevel{{}}
+	expectTable := false
+
+	for i, token := range tokens {
+		level := &levels[len(levels)-1]
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+
+		switch {
+		case token == "(":
+			// A parenthesized join such as FROM (a JOIN b) starts with a table
+			joined := expectTable && next != "select" && next != "table" && next != "values" && next != "with"
+			levels = append(levels, sqlLevel{function: i > 0 && sqlFromFunctions[tokens[i-1]], tableList: joined})
+			expectTable = joined
+		case token == ")":
+			if len(levels) > 1 {
+				levels = levels[:len(levels)-1]
+			}
+			expectTable = false
+		case token == ",":
+			expectTable = level.tableList
+		case token == "from":
+			if level.function || (i > 0 && tokens[i-1] == "distinct") {
+				continue
+			}
+			level.tableList = true
+			expectTable = true
+		case token == "join":
+			level.tableList = true
+			expectTable = true
+		case token == "update" && i > 0 && (tokens[i-1] == "for" || tokens[i-1] == "key"):
+			// Row locks such as FOR UPDATE SKIP LOCKED
+			expectTable = false
+		case token == "update" || token == "into" || token == "table":
+			expectTable = true
+		case token == "using":
+			// JOIN ... USING (id) names columns, DELETE ... USING and MERGE ... USING tables
+			expectTable = next != "("
+			level.tableList = expectTable
+		case sqlTableListEnd[token]:
+			level.tableList = false
+			expectTable = false
+		case expectTable && (token == "only" || token == "lateral"):
+		case expectTable && isSQLIdentifier(token) && !sqlClauseKeywords[token]:
+			tables = append(tables, token)
+			expectTable = false
+		default:
+			expectTable = false
+		}
+	}
+	return tables
+}
@@ -0,0 +1,231 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package tools
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeSQLDriver serves a fixed schema and a users table with five rows
+type fakeSQLDriver struct {
+	readOnlyTx bool
+	lastArgs   []driver.Value
+}
+
+var testSQLDriver = &fakeSQLDriver{}
+
+func init() {
+	sql.Register("tools_fake_sql", testSQLDriver)
+}
+
+func (d *fakeSQLDriver) Open(name string) (driver.Conn, error) {
+	return &fakeSQLConn{driver: d}, nil
+}
+
+type fakeSQLConn struct {
+	driver *fakeSQLDriver
+}
+
+func (c *fakeSQLConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeSQLStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeSQLConn) Close() error { return nil }
+
+func (c *fakeSQLConn) Begin() (driver.Tx, error) { return c, nil }
+
+func (c *fakeSQLConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.driver.readOnlyTx = opts.ReadOnly
+	return c, nil
+}
+
+func (c *fakeSQLConn) Commit() error { return nil }
+
+func (c *fakeSQLConn) Rollback() error { return nil }
+
+type fakeSQLStmt struct {
+	conn  *fakeSQLConn
+	query string
+}
+
+func (s *fakeSQLStmt) Close() error  { return nil }
+func (s *fakeSQLStmt) NumInput() int { return -1 }
+
+func (s *fakeSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, fmt.Errorf("exec is not supported")
+}
+
+func (s *fakeSQLStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.conn.driver.lastArgs = args
+	if strings.Contains(s.query, "information_schema.columns") {
+		return &fakeSQLRows{
+			columns: []string{"table_name", "column_name", "data_type"},
+			types:   []string{"TEXT", "TEXT", "TEXT"},
+			values: [][]driver.Value{
+				{"orders", "id", "integer"},
+				{"users", "id", "integer"},
+				{"users", "name", "text"},
+			},
+		}, nil
+	}
+
+	rows := &fakeSQLRows{columns: []string{"id", "name"}, types: []string{"INT4", "TEXT"}}
+	for i := 1; i <= 5; i++ {
+		rows.values = append(rows.values, []driver.Value{int64(i), []byte(fmt.Sprintf("user-%d", i))})
+	}
+	return rows, nil
+}
+
+type fakeSQLRows struct {
+	columns []string
+	types   []string
+	values  [][]driver.Value
+}
+
+func (r *fakeSQLRows) Columns() []string { return r.columns }
+func (r *fakeSQLRows) Close() error      { return nil }
+
+func (r *fakeSQLRows) ColumnTypeDatabaseTypeName(index int) string { return r.types[index] }
+
+func (r *fakeSQLRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func newTestSQLTool(t *testing.T, config SQLConfig) *SQLTool {
+	config.Driver = "tools_fake_sql"
+	config.DSN = "test"
+	tool, err := NewSQLTool(config)
+	if err != nil {
+		t.Fatalf("NewSQLTool() failed: %v", err)
+	}
+	t.Cleanup(func() { tool.Close() })
+	return tool
+}
+
+func TestSQLTool_Execute(t *testing.T) {
+	tool := newTestSQLTool(t, SQLConfig{ReadOnly: true, AllowedTables: []string{"users"}, MaxRows: 3})
+
+	// Only allowed tables are exposed to the model
+	if len(tool.Schema()) != 1 || tool.Schema()[0].Name != "users" {
+		t.Errorf("Expected only the users table in the schema, got %+v", tool.Schema())
+	}
+	if !strings.Contains(tool.GetDescription(), "users(id integer, name text)") {
+		t.Errorf("Description should include the schema: %s", tool.GetDescription())
+	}
+
+	output, err := tool.Execute(context.Background(), `{"query": "SELECT id, name FROM users WHERE id > $1", "params": [0]}`)
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	var result SQLResult
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if result.RowCount != 3 || !result.Truncated {
+		t.Errorf("Expected 3 rows and truncation, got %d rows (truncated=%v)", result.RowCount, result.Truncated)
+	}
+	if len(result.Columns) != 2 || result.Columns[0].Name != "id" || result.Columns[0].Type != "int4" {
+		t.Errorf("Unexpected column metadata: %+v", result.Columns)
+	}
+	if result.Rows[1]["name"] != "user-2" {
+		t.Errorf("Unexpected row: %+v", result.Rows[1])
+	}
+	if !testSQLDriver.readOnlyTx {
+		t.Error("Read-only queries should run in a read-only transaction")
+	}
+	if len(testSQLDriver.lastArgs) != 1 {
+		t.Errorf("Expected the query parameter to be bound, got %v", testSQLDriver.lastArgs)
+	}
+}
+
+func TestSQLTool_CheckQuery(t *testing.T) {
+	tool := newTestSQLTool(t, SQLConfig{ReadOnly: true, AllowedTables: []string{"users", "orders"}})
+
+	allowed := []string{
+		"SELECT * FROM users",
+		"select u.id, o.id from public.users u join orders as o on o.user_id = u.id;",
+		"SELECT id FROM users, orders WHERE users.id = orders.id",
+		"SELECT EXTRACT(year FROM created_at) FROM users",
+		"SELECT id FROM users WHERE id IN (SELECT user_id FROM orders)",
+		"SELECT 'DROP TABLE users; --' FROM users",
+		"TABLE users",
+		"SELECT id FROM users UNION TABLE orders",
+		"SELECT * FROM (users CROSS JOIN orders)",
+		"SELECT * FROM users u JOIN orders o USING (id) WHERE u.id IN (1, 2)",
+		"SELECT SUBSTRING(name FROM 1 FOR 3), TRIM(BOTH ' ' FROM name) FROM users",
+		"SELECT id FROM users WHERE name IS DISTINCT FROM 'x'",
+		"SELECT * FROM (SELECT id FROM users) u, orders",
+	}
+	for _, query := range allowed {
+		if err := tool.CheckQuery(query); err != nil {
+			t.Errorf("Expected %q to be allowed, got %v", query, err)
+		}
+	}
+
+	rejected := []string{
+		"",
+		"DELETE FROM users",
+		"SELECT * FROM users; DROP TABLE users",
+		"SELECT * FROM users /* comment */ ; DELETE FROM users",
+		"SELECT * INTO backup FROM users",
+		"SELECT * FROM secrets",
+		"SELECT * FROM users JOIN secrets ON true",
+		"SELECT * FROM users WHERE id IN (SELECT id FROM secrets)",
+		"SELECT 'unterminated FROM users",
+		"TABLE secrets",
+		"TABLE public.secrets",
+		"SELECT id FROM users UNION TABLE secrets",
+		"SELECT id FROM users WHERE id IN (TABLE secrets)",
+		"SELECT * FROM (secrets CROSS JOIN users)",
+		"SELECT * FROM users JOIN (secrets s JOIN users u ON true) ON true",
+		"SELECT * FROM (SELECT id FROM users) u, secrets",
+		"SELECT * FROM users JOIN orders ON true, secrets",
+		"SELECT query_to_xml('select * from secrets', true, false, '')",
+		"SELECT * FROM users WHERE id IN (SELECT id FROM pg_catalog.query_to_xml('table secrets', true, false, ''))",
+		"SELECT * FROM dblink('dbname=other', 'select * from secrets') AS t(id int)",
+		"SELECT * FROM other_schema.users",
+	}
+	for _, query := range rejected {
+		if err := tool.CheckQuery(query); err == nil {
+			t.Errorf("Expected %q to be rejected", query)
+		}
+	}
+
+	// Writes are allowed outside read-only mode, still one statement at a time
+	writable := newTestSQLTool(t, SQLConfig{AllowedTables: []string{"users"}})
+	if err := writable.CheckQuery("UPDATE users SET name = $1 WHERE id = $2"); err != nil {
+		t.Errorf("Expected update to be allowed, got %v", err)
+	}
+	if err := writable.CheckQuery("INSERT INTO secrets (value) VALUES ($1)"); err == nil {
+		t.Error("Expected writes to other tables to be rejected")
+	}
+	if err := writable.CheckQuery("TABLE secrets"); err == nil {
+		t.Error("Expected reads of other tables with TABLE to be rejected")
+	}
+	if err := writable.CheckQuery("DELETE FROM users USING secrets WHERE users.id = secrets.id"); err == nil {
+		t.Error("Expected deletes using other tables to be rejected")
+	}
+	if err := writable.CheckQuery("SELECT * FROM users FOR UPDATE SKIP LOCKED"); err != nil {
+		t.Errorf("Expected row locks to be allowed, got %v", err)
+	}
+	if err := writable.CheckQuery("UPDATE users SET name = 'x'; DROP TABLE users"); err == nil {
+		t.Error("Expected multiple statements to be rejected")
+	}
+}
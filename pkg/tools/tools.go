@@ -51,16 +51,32 @@ type Tool interface {
 
 // ToolRegistry manages a collection of tools
 type ToolRegistry struct {
-	tools  map[string]Tool
-	logger *logrus.Logger
-	mu     sync.RWMutex
+	tools            map[string]Tool
+	logger           *logrus.Logger
+	maxResultBytes   int
+	toolResultLimits map[string]int
+	resultSummarizer ResultSummarizer
+	resultSanitizer  ToolResultSanitizer
+	toolGuidance     map[string]string
+	toolExamples     map[string][]ToolExample
+	toolRetryable    map[string]bool
+	auditor          ToolAuditor
+	// auditRedactedFields are the argument fields redacted from each tool's audit events
+	auditRedactedFields map[string][]string
+	toolLimits          map[string]*ToolLimit
+	quotaCounter        QuotaCounter
+	metrics             *ToolMetrics
+	mu                  sync.RWMutex
 }
 
 // NewToolRegistry creates a new tool registry
 func NewToolRegistry() *ToolRegistry {
 	registry := &ToolRegistry{
-		tools:  make(map[string]Tool),
-		logger: logrus.New(),
+		tools:            make(map[string]Tool),
+		logger:           logrus.New(),
+		toolResultLimits: make(map[string]int),
+		toolGuidance:     make(map[string]string),
+		metrics:          NewToolMetrics(),
 	}
 
 	// Register default tools
@@ -84,6 +100,13 @@ func (tr *ToolRegistry) RegisterTool(tool Tool) error {
 	return nil
 }
 
+// SetLogger sets the logger used by the tool registry
+func (tr *ToolRegistry) SetLogger(logger *logrus.Logger) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.logger = logger
+}
+
 // UnregisterTool unregisters a tool
 func (tr *ToolRegistry) UnregisterTool(name string) error {
 	tr.mu.Lock()
@@ -126,7 +149,7 @@ func (tr *ToolRegistry) GetAllDefinitions() []llm.ToolDefinition {
 
 	definitions := make([]llm.ToolDefinition, 0, len(tr.tools))
 	for _, tool := range tr.tools {
-		definitions = append(definitions, tool.GetDefinition())
+		definitions = append(definitions, tr.definition(tool))
 	}
 	return definitions
 }
@@ -139,7 +162,7 @@ func (tr *ToolRegistry) GetDefinitions(toolNames []string) []llm.ToolDefinition
 	definitions := make([]llm.ToolDefinition, 0, len(toolNames))
 	for _, name := range toolNames {
 		if tool, exists := tr.tools[name]; exists {
-			definitions = append(definitions, tool.GetDefinition())
+			definitions = append(definitions, tr.definition(tool))
 		}
 	}
 	return definitions
@@ -186,6 +209,11 @@ func (t *WebSearchTool) GetName() string {
 	return "web_search"
 }
 
+// Idempotent reports that searches are safe to repeat
+func (t *WebSearchTool) Idempotent() bool {
+	return true
+}
+
 func (t *WebSearchTool) GetDescription() string {
 	return "Search the web for information"
 }
@@ -289,6 +317,11 @@ func (t *FileReadTool) GetName() string {
 	return "file_read"
 }
 
+// Idempotent reports that reads are safe to repeat
+func (t *FileReadTool) Idempotent() bool {
+	return true
+}
+
 func (t *FileReadTool) GetDescription() string {
 	return "Read the contents of a file"
 }
@@ -404,6 +437,11 @@ func (t *FileWriteTool) GetName() string {
 	return "file_write"
 }
 
+// Idempotent reports that writes may append, so they are not safe to repeat
+func (t *FileWriteTool) Idempotent() bool {
+	return false
+}
+
 func (t *FileWriteTool) GetDescription() string {
 	return "Write content to a file"
 }
@@ -548,6 +586,11 @@ func (t *FileListTool) GetName() string {
 	return "file_list"
 }
 
+// Idempotent reports that listings are safe to repeat
+func (t *FileListTool) Idempotent() bool {
+	return true
+}
+
 func (t *FileListTool) GetDescription() string {
 	return "List files and directories in a given path"
 }
@@ -684,6 +727,11 @@ func (t *ShellTool) GetName() string {
 	return "shell"
 }
 
+// Idempotent reports that commands may have side effects, so they are not safe to repeat
+func (t *ShellTool) Idempotent() bool {
+	return false
+}
+
 func (t *ShellTool) GetDescription() string {
 	return "Execute shell commands (limited for security)"
 }
@@ -804,6 +852,11 @@ func (t *HTTPTool) GetName() string {
 	return "http_request"
 }
 
+// Idempotent reports that requests may be POSTs, so they are not safe to repeat
+func (t *HTTPTool) Idempotent() bool {
+	return false
+}
+
 func (t *HTTPTool) GetDescription() string {
 	return "Make HTTP requests"
 }
@@ -931,10 +984,23 @@ func (t *CalculatorTool) GetName() string {
 	return "calculator"
 }
 
+// Idempotent reports that calculations are safe to repeat
+func (t *CalculatorTool) Idempotent() bool {
+	return true
+}
+
 func (t *CalculatorTool) GetDescription() string {
 	return "Perform basic mathematical calculations"
 }
 
+// GetUsageExamples shows the model the single-operation expressions the calculator supports
+func (t *CalculatorTool) GetUsageExamples() []ToolExample {
+	return []ToolExample{
+		{Description: "Add two numbers", Input: `{"expression": "12.5+7"}`, Output: "Result: 19.5"},
+		{Description: "Divide two numbers", Input: `{"expression": "144/12"}`, Output: "Result: 12"},
+	}
+}
+
 func (t *CalculatorTool) GetDefinition() llm.ToolDefinition {
 	return llm.ToolDefinition{
 		Type: "function",
@@ -1091,10 +1157,23 @@ func (t *TimeTool) GetName() string {
 	return "time"
 }
 
+// Idempotent reports that time queries are safe to repeat
+func (t *TimeTool) Idempotent() bool {
+	return true
+}
+
 func (t *TimeTool) GetDescription() string {
 	return "Get current time and date information"
 }
 
+// GetUsageExamples shows the model how to ask for a timezone and format
+func (t *TimeTool) GetUsageExamples() []ToolExample {
+	return []ToolExample{
+		{Description: "Current time in Paris", Input: `{"timezone": "Europe/Paris"}`},
+		{Description: "Current Unix timestamp", Input: `{"format": "Unix"}`},
+	}
+}
+
 func (t *TimeTool) GetDefinition() llm.ToolDefinition {
 	return llm.ToolDefinition{
 		Type: "function",
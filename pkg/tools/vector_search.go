@@ -0,0 +1,227 @@
+// Copyright (c) 2024 GoLangGraph Team
+//
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+//
+// Package: GoLangGraph - A powerful Go framework for building AI agent workflows
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/llm"
+	"github.com/piotrlaczkowski/GoLangGraph/pkg/rag"
+)
+
+const (
+	defaultVectorSearchTopK    = 5
+	defaultVectorSearchMaxTopK = 20
+)
+
+// VectorSearchResult is a chunk returned by a vector store
+type VectorSearchResult = rag.RetrievedChunk
+
+// VectorStore searches stored chunks by embedding similarity
+type VectorStore = rag.VectorStore
+
+// VectorSearchTool lets agents retrieve relevant chunks from a knowledge base on demand
+type VectorSearchTool struct {
+	retriever rag.Retriever
+	topK      int
+	maxTopK   int
+	threshold float64
+	filter    map[string]interface{}
+}
+
+// NewVectorSearchTool creates a search tool over a vector store, embedding queries with the
+// provider
+func NewVectorSearchTool(store VectorStore, embedder llm.Provider) (*VectorSearchTool, error) {
+	retriever, err := rag.NewVectorRetriever(store, embedder, "")
+	if err != nil {
+		return nil, err
+	}
+	return NewRetrievalTool(retriever)
+}
+
+// NewRetrievalTool creates a search tool over any retriever, such as a vector store,
+// Elasticsearch or a hosted retrieval API
+func NewRetrievalTool(retriever rag.Retriever) (*VectorSearchTool, error) {
+	if retriever == nil {
+		return nil, fmt.Errorf("retriever is required")
+	}
+
+	return &VectorSearchTool{
+		retriever: retriever,
+		topK:      defaultVectorSearchTopK,
+		maxTopK:   defaultVectorSearchMaxTopK,
+	}, nil
+}
+
+func (t *VectorSearchTool) GetName() string {
+	return "vector_search"
+}
+
+// Idempotent reports that searches are safe to repeat
+func (t *VectorSearchTool) Idempotent() bool {
+	return true
+}
+
+func (t *VectorSearchTool) GetDescription() string {
+	return "Search the knowledge base for the passages most relevant to a query, with their relevance scores and sources"
+}
+
+func (t *VectorSearchTool) GetDefinition() llm.ToolDefinition {
+	return llm.ToolDefinition{
+		Type: "function",
+		Function: llm.Function{
+			Name:        t.GetName(),
+			Description: t.GetDescription(),
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "What to search for",
+					},
+					"top_k": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Number of passages to return (default: %d, at most %d)", t.topK, t.maxTopK),
+						"default":     t.topK,
+					},
+					"filter": map[string]interface{}{
+						"type":        "object",
+						"description": "Metadata values the passages must have, e.g. {\"source\": \"handbook\"}",
+					},
+				},
+				"required": []string{"query"},
+			},
+		},
+	}
+}
+
+func (t *VectorSearchTool) Execute(ctx context.Context, args string) (string, error) {
+	var params struct {
+		Query  string                 `json:"query"`
+		TopK   int                    `json:"top_k"`
+		Filter map[string]interface{} `json:"filter"`
+	}
+
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	results, err := t.Search(ctx, params.Query, params.TopK, params.Filter)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := json.Marshal(map[string]interface{}{
+		"query":   params.Query,
+		"results": results,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode results: %w", err)
+	}
+	return string(output), nil
+}
+
+// Search retrieves the chunks most relevant to the query scoring at least the similarity
+// threshold. The configured filter is applied on top of the given one and wins on conflicts.
+func (t *VectorSearchTool) Search(ctx context.Context, query string, topK int, filter map[string]interface{}) ([]VectorSearchResult, error) {
+	if query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	if topK <= 0 {
+		topK = t.topK
+	}
+	if topK > t.maxTopK {
+		topK = t.maxTopK
+	}
+
+	combined := make(map[string]interface{}, len(filter)+len(t.filter))
+	for key, value := range filter {
+		combined[key] = value
+	}
+	for key, value := range t.filter {
+		combined[key] = value
+	}
+
+	results, err := t.retriever.Retrieve(ctx, query, rag.RetrieveOptions{
+		TopK:     topK,
+		Filter:   combined,
+		MinScore: t.threshold,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Retrievers may not apply the minimum score themselves
+	return rag.FilterByScore(results, t.threshold), nil
+}
+
+func (t *VectorSearchTool) Validate(args string) error {
+	var params struct {
+		Query string `json:"query"`
+		TopK  int    `json:"top_k"`
+	}
+
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	if params.Query == "" {
+		return fmt.Errorf("query is required")
+	}
+	if params.TopK < 0 {
+		return fmt.Errorf("top_k must not be negative")
+	}
+
+	return nil
+}
+
+func (t *VectorSearchTool) GetConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"model":                t.model(),
+		"top_k":                t.topK,
+		"max_top_k":            t.maxTopK,
+		"similarity_threshold": t.threshold,
+		"filter":               t.filter,
+	}
+}
+
+func (t *VectorSearchTool) SetConfig(config map[string]interface{}) error {
+	if model, ok := config["model"].(string); ok {
+		if vector, isVector := t.retriever.(*rag.VectorRetriever); isVector {
+			vector.Model = model
+		}
+	}
+	if topK, ok := config["top_k"].(int); ok && topK > 0 {
+		t.topK = topK
+	}
+	if maxTopK, ok := config["max_top_k"].(int); ok && maxTopK > 0 {
+		t.maxTopK = maxTopK
+	}
+	if threshold, ok := config["similarity_threshold"].(float64); ok {
+		t.threshold = threshold
+	}
+	if filter, ok := config["filter"].(map[string]interface{}); ok {
+		t.filter = filter
+	}
+	return nil
+}
+
+// model returns the embedding model of a vector store retriever
+func (t *VectorSearchTool) model() string {
+	if vector, ok := t.retriever.(*rag.VectorRetriever); ok {
+		return vector.Model
+	}
+	return ""
+}
+
+// MatchesMetadataFilter reports whether metadata has every key and value of a filter, for
+// vector stores filtering in memory. Values are compared by their printed form, so a filter
+// decoded from JSON matches integer metadata.
+func MatchesMetadataFilter(metadata, filter map[string]interface{}) bool {
+	return rag.MatchesMetadataFilter(metadata, filter)
+}